@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+	"github.com/utkamioka/stress-go/pkg/watchdog"
+)
+
+// rampDownPeriod and rampDownSteps are how the load is lowered to nothing when
+// the watchdog trips, before the stressors are stopped and cleaned up.
+const (
+	rampDownPeriod = 3 * time.Second
+	rampDownSteps  = 6
+)
+
+// stringList is a flag value that may be given multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseAbortConditions parses --abort-if expressions such as "loadavg>64",
+// "mem-available<500MB", "disk-free</:2GB", "temp>95C" and "psi-cpu>20%".
+func parseAbortConditions(exprs []string) ([]watchdog.Condition, error) {
+	var conditions []watchdog.Condition
+	for _, expr := range exprs {
+		for _, part := range splitList(expr) {
+			c, err := parseAbortCondition(part)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+	}
+	return conditions, nil
+}
+
+// parseAbortCondition parses a single "<metric><op><value>" expression.
+func parseAbortCondition(expr string) (watchdog.Condition, error) {
+	index := strings.IndexAny(expr, "<>")
+	if index <= 0 {
+		return watchdog.Condition{}, fmt.Errorf("invalid abort condition %q: expected <metric><op><value>", expr)
+	}
+
+	c := watchdog.Condition{
+		Expr:   expr,
+		Metric: strings.TrimSpace(expr[:index]),
+		Above:  expr[index] == '>',
+	}
+	value := strings.TrimSpace(expr[index+1:])
+
+	switch c.Metric {
+	case watchdog.MetricLoadAverage:
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid load average in %q: %v", expr, err)
+		}
+		c.Threshold = threshold
+	case watchdog.MetricTemperature:
+		threshold, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToUpper(value), "C"), 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid temperature in %q: %v", expr, err)
+		}
+		c.Threshold = threshold
+	case watchdog.MetricPSICPU, watchdog.MetricPSIMemory, watchdog.MetricPSIIO:
+		threshold, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid pressure in %q: %v", expr, err)
+		}
+		c.Threshold = threshold
+	case watchdog.MetricMemoryAvailable:
+		size, err := parseAbortSize(expr, value)
+		if err != nil {
+			return c, err
+		}
+		c.Threshold = float64(size)
+	case watchdog.MetricDiskFree:
+		// The value is "<path>:<size>"; the path itself may contain colons (e.g. C:\)
+		colon := strings.LastIndex(value, ":")
+		if colon <= 0 {
+			return c, fmt.Errorf("invalid abort condition %q: expected disk-free<PATH:SIZE", expr)
+		}
+		size, err := parseAbortSize(expr, value[colon+1:])
+		if err != nil {
+			return c, err
+		}
+		c.Path = value[:colon]
+		c.Threshold = float64(size)
+	default:
+		return c, fmt.Errorf("unknown metric %q in abort condition %q (supported: loadavg, mem-available, disk-free, temp, psi-cpu, psi-memory, psi-io)", c.Metric, expr)
+	}
+	return c, nil
+}
+
+// parseAbortSize parses an absolute size; percentages are not meaningful for thresholds.
+func parseAbortSize(expr, value string) (int64, error) {
+	size, err := bytesize.ParseAbsolute(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size in %q: %v", expr, err)
+	}
+	return size, nil
+}
+
+// rampDown steps the level of every controllable stressor down to 0 over
+// rampDownPeriod, so that a tripped watchdog takes the load off the host
+// gradually rather than all at once, and then returns for the run to stop.
+func rampDown(registry *stressor.Registry, reason string) {
+	term.Printf("\n[Watchdog] %s: ramping down the load over %v\n", reason, rampDownPeriod)
+	var targets []stressor.Controllable
+	for _, s := range registry.Stressors() {
+		if c, ok := s.(stressor.Controllable); ok {
+			targets = append(targets, c)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	for step := rampDownSteps - 1; step >= 0; step-- {
+		time.Sleep(rampDownPeriod / rampDownSteps)
+		for _, c := range targets {
+			c.SetLevel(float64(step) / rampDownSteps)
+		}
+	}
+}
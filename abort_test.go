@@ -0,0 +1,65 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/utkamioka/stress-go/pkg/watchdog"
+)
+
+func TestParseAbortCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want watchdog.Condition
+		ok   bool
+	}{
+		{"loadavg>64", watchdog.Condition{Metric: "loadavg", Above: true, Threshold: 64}, true},
+		{"mem-available<500MB", watchdog.Condition{Metric: "mem-available", Threshold: 500e6}, true},
+		{"mem-available < 1GiB", watchdog.Condition{Metric: "mem-available", Threshold: 1 << 30}, true},
+		{"disk-free</:2GB", watchdog.Condition{Metric: "disk-free", Path: "/", Threshold: 2e9}, true},
+		{`disk-free<C:\:10GiB`, watchdog.Condition{Metric: "disk-free", Path: `C:\`, Threshold: 10 << 30}, true},
+		{"temp>95C", watchdog.Condition{Metric: "temp", Above: true, Threshold: 95}, true},
+		{"temp>90c", watchdog.Condition{Metric: "temp", Above: true, Threshold: 90}, true},
+		{"psi-cpu>50%", watchdog.Condition{Metric: "psi-cpu", Above: true, Threshold: 50}, true},
+		{"psi-io>20", watchdog.Condition{Metric: "psi-io", Above: true, Threshold: 20}, true},
+		{"loadavg", watchdog.Condition{}, false},
+		{">64", watchdog.Condition{}, false},
+		{"loadavg>high", watchdog.Condition{}, false},
+		{"mem-available<50%", watchdog.Condition{}, false},
+		{"disk-free<2GB", watchdog.Condition{}, false},
+		{"disk-free</:lots", watchdog.Condition{}, false},
+		{"swap>1GB", watchdog.Condition{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseAbortCondition(tt.expr)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseAbortCondition(%q) = %v, want ok=%v", tt.expr, err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+			tt.want.Expr = tt.expr
+			if got != tt.want {
+				t.Errorf("parseAbortCondition(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAbortConditions(t *testing.T) {
+	conditions, err := parseAbortConditions([]string{"loadavg>64,temp>95C", "mem-available<500MB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metrics []string
+	for _, c := range conditions {
+		metrics = append(metrics, c.Metric)
+	}
+	if want := []string{"loadavg", "temp", "mem-available"}; !slices.Equal(metrics, want) {
+		t.Errorf("parseAbortConditions metrics = %v, want %v", metrics, want)
+	}
+	if _, err := parseAbortConditions([]string{"loadavg>64,bogus"}); err == nil {
+		t.Error("parseAbortConditions with an invalid condition succeeded")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/probe"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// levelInterval is how often the load average and available memory are sampled
+// for the baseline comparison.
+const levelInterval = 2 * time.Second
+
+// systemLevels are the average system metrics over a period. A metric that could
+// not be read is NaN.
+type systemLevels struct {
+	// CPU is the utilization of all CPUs, 0-1.
+	CPU             float64
+	LoadAverage     float64
+	MemoryAvailable float64
+	// Victim is the CPU used by the victim process, in cores.
+	Victim float64
+}
+
+// baseline holds the system levels measured idle before the load and under load.
+type baseline struct {
+	duration time.Duration
+	// victim is the process whose CPU share is compared, or 0.
+	victim       int
+	idle, loaded systemLevels
+	// probes are the probe latencies measured idle, keyed by probe.
+	probes map[string]metrics.Histogram
+}
+
+// probe returns the idle latencies of the probe of kind. It returns false for a
+// nil baseline or a probe that was not run.
+func (b *baseline) probe(kind string) (metrics.Histogram, bool) {
+	if b == nil {
+		return metrics.Histogram{}, false
+	}
+	h, ok := b.probes[kind]
+	return h, ok
+}
+
+// measureLevels averages the system metrics, and the CPU share of the victim
+// process unless it is 0, until ctx is done.
+func measureLevels(ctx context.Context, victim int) systemLevels {
+	levels := systemLevels{CPU: math.NaN(), LoadAverage: math.NaN(), MemoryAvailable: math.NaN(), Victim: math.NaN()}
+	start := time.Now()
+	startCPU, cpuErr := sysinfo.ReadCPUTimes()
+	startVictim, victimErr := time.Duration(0), fmt.Errorf("no victim process")
+	if victim != 0 {
+		startVictim, victimErr = sysinfo.ReadProcessCPUTime(victim)
+	}
+	// The victim may exit during the period; its share is taken up to the last reading
+	lastVictim, lastVictimAt := startVictim, start
+
+	var load, memory float64
+	var samples int
+	sample := func() {
+		if snapshot, err := sysinfo.Read(); err == nil {
+			load += snapshot.LoadAverage
+			memory += float64(snapshot.MemoryAvailable)
+			samples++
+		}
+		if victimErr == nil {
+			if t, err := sysinfo.ReadProcessCPUTime(victim); err == nil {
+				lastVictim, lastVictimAt = t, time.Now()
+			}
+		}
+	}
+
+	ticker := time.NewTicker(levelInterval)
+	defer ticker.Stop()
+	sample()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case <-ticker.C:
+			sample()
+		}
+	}
+	sample()
+
+	if cpuErr == nil {
+		if endCPU, err := sysinfo.ReadCPUTimes(); err == nil {
+			levels.CPU = sysinfo.Utilization(startCPU, endCPU)
+		}
+	}
+	if samples > 0 {
+		levels.LoadAverage = load / float64(samples)
+		levels.MemoryAvailable = memory / float64(samples)
+	}
+	if elapsed := lastVictimAt.Sub(start); victimErr == nil && elapsed > 0 {
+		levels.Victim = (lastVictim - startVictim).Seconds() / elapsed.Seconds()
+	}
+	return levels
+}
+
+// measureBaseline measures the idle system levels, and the probe latencies when
+// probes are on, for d before the load starts. It returns false when a stop
+// signal arrives while measuring.
+func measureBaseline(d time.Duration, victim int, probes probe.Options, sigChan <-chan os.Signal) (*baseline, bool) {
+	term.Printf("Measuring the idle baseline for %v...\n", d)
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	// The idle probe latencies are kept apart from those measured under load
+	probes.Recorder = metrics.NewRecorder()
+	probesDone := startProbes(ctx, probes)
+	result := make(chan systemLevels, 1)
+	go func() {
+		result <- measureLevels(ctx, victim)
+	}()
+	select {
+	case idle := <-result:
+		<-probesDone
+		return &baseline{duration: d, victim: victim, idle: idle, probes: probeLatencies(probes.Recorder.Latencies())}, true
+	case <-sigChan:
+		return nil, false
+	}
+}
+
+// deltas returns the metrics measured both idle and under load, in the units of
+// the report. It returns nil for a nil baseline.
+func (b *baseline) deltas() []cluster.BaselineDelta {
+	if b == nil {
+		return nil
+	}
+
+	var deltas []cluster.BaselineDelta
+	add := func(metric, unit string, idle, loaded float64) {
+		if math.IsNaN(idle) || math.IsNaN(loaded) {
+			return
+		}
+		deltas = append(deltas, cluster.BaselineDelta{Metric: metric, Unit: unit, Baseline: idle, Loaded: loaded, Delta: loaded - idle})
+	}
+	add("CPU utilization", "%", b.idle.CPU*100, b.loaded.CPU*100)
+	add("Load average (1m)", "", b.idle.LoadAverage, b.loaded.LoadAverage)
+	add("Available memory", metrics.UnitBytes, b.idle.MemoryAvailable, b.loaded.MemoryAvailable)
+	if b.victim != 0 {
+		add(fmt.Sprintf("CPU of PID %d", b.victim), metrics.UnitCores, b.idle.Victim, b.loaded.Victim)
+	}
+	return deltas
+}
+
+// printBaselineReport prints how much each system metric changed under load
+// compared with the idle baseline.
+func printBaselineReport(b *baseline) {
+	deltas := b.deltas()
+	if len(deltas) == 0 {
+		return
+	}
+
+	term.Printf("Change from the idle baseline (%v):\n", b.duration)
+	for _, d := range deltas {
+		label := i18n.T(d.Metric)
+		var change string
+		switch d.Unit {
+		case "%":
+			change = i18n.Sprintf("%+.1f points", d.Delta)
+		case metrics.UnitBytes:
+			sign := "+"
+			if d.Delta < 0 {
+				sign = "-"
+			}
+			change = sign + metrics.FormatValue(d.Unit, math.Abs(d.Delta))
+		case metrics.UnitCores:
+			label = i18n.Sprintf("CPU of PID %d", b.victim)
+			change = fmt.Sprintf("%+.2f", d.Delta)
+			if d.Baseline > 0 {
+				change += fmt.Sprintf(", %+.0f%%", d.Delta/d.Baseline*100)
+			}
+		default:
+			change = fmt.Sprintf("%+.2f", d.Delta)
+		}
+		term.Printf("  %s: %s -> %s (%s)\n", label, formatLevel(d.Unit, d.Baseline), formatLevel(d.Unit, d.Loaded), change)
+	}
+	term.Println()
+}
+
+// formatLevel formats a metric of the baseline report.
+func formatLevel(unit string, value float64) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.1f%%", value)
+	case "":
+		return fmt.Sprintf("%.2f", value)
+	default:
+		return metrics.FormatValue(unit, value)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Scores of the reference machine, a small two-vCPU cloud instance with a
+// general-purpose network SSD, which gets a composite score of 1000. Changing
+// them makes earlier composite scores incomparable.
+const (
+	benchRefCPUOps      = 500e6
+	benchRefMemoryBytes = 10e9
+	benchRefDiskBytes   = 125e6
+	benchRefIOPS        = 1000
+)
+
+// benchReport is the result of the bench subcommand as written by --json.
+type benchReport struct {
+	Version     string            `json:"version"`
+	Time        time.Time         `json:"time"`
+	Duration    string            `json:"duration"`
+	Environment map[string]string `json:"environment,omitempty"`
+	CPUCores    int               `json:"cpu_cores"`
+	CPUOps      float64           `json:"cpu_ops_per_second"`
+	MemoryBytes float64           `json:"memory_bytes_per_second"`
+	DiskBytes   float64           `json:"disk_write_bytes_per_second"`
+	DiskIOPS    float64           `json:"disk_iops"`
+	Composite   float64           `json:"composite"`
+}
+
+// runBench implements the bench subcommand, which runs each stressor flat out for
+// a fixed window and prints scores that are comparable between hosts.
+func runBench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	duration := flags.Duration("duration", 10*time.Second, "Duration of each test")
+	cores := flags.Int("cpu", 0, "Number of CPU cores to use (0 = use all cores)")
+	path := flags.String("path", "", "Directory to write the disk test file in (default: as for --storage)")
+	jsonPath := flags.String("json", "", "Write the scores to this file as JSON")
+	flags.Parse(args)
+
+	if *duration <= 0 {
+		term.Eprintf("Error: --duration must be positive\n")
+		os.Exit(exitConfigError)
+	}
+	if *cores < 0 {
+		term.Eprintf("Error: Invalid core count: %d\n", *cores)
+		os.Exit(exitConfigError)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping benchmark...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	recorder := metrics.NewRecorder()
+	recorder.OnMessage(printMessage)
+	report := benchReport{
+		Version:     stress.Version,
+		Time:        time.Now(),
+		Duration:    duration.String(),
+		Environment: sysinfo.ReadEnvironment().Labels(),
+	}
+
+	term.Printf("Running CPU benchmark for %v...\n", *duration)
+	cpuResult := cpu.Benchmark(ctx, *cores, *duration)
+	report.CPUCores, report.CPUOps = cpuResult.Cores, cpuResult.OpsPerSecond
+	term.Printf("  CPU: %.1f Mops/s (%d cores, %.1f Mops/s per core)\n",
+		cpuResult.OpsPerSecond/1e6, cpuResult.Cores, cpuResult.OpsPerSecond/1e6/float64(cpuResult.Cores))
+	if ctx.Err() != nil {
+		os.Exit(exitFailure)
+	}
+
+	term.Printf("Running memory benchmark for %v...\n", *duration)
+	memoryResult, err := memory.Benchmark(ctx, *duration)
+	if err != nil {
+		term.Eprintf("Error: Memory benchmark failed: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	report.MemoryBytes = memoryResult.BytesPerSecond
+	term.Printf("  Memory: %.2f GB/s (copying %d MiB)\n", memoryResult.BytesPerSecond/1e9, memoryResult.Size/(1024*1024))
+
+	term.Printf("Running disk benchmark for %v...\n", 2**duration)
+	storageResult, err := storage.Benchmark(ctx, *path, *duration, recorder)
+	if err != nil {
+		term.Eprintf("Error: Disk benchmark failed: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	report.DiskBytes, report.DiskIOPS = storageResult.WriteBytesPerSecond, storageResult.IOPS
+	term.Printf("  Disk: %.1f MB/s sequential write, %.0f IOPS (4 KiB random writes with fsync)\n",
+		storageResult.WriteBytesPerSecond/1e6, storageResult.IOPS)
+
+	report.Composite = compositeScore(report)
+	term.Println()
+	term.Printf("Composite score: %.0f (reference machine = 1000)\n", report.Composite)
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*jsonPath, append(data, '\n'), 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write benchmark results: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		term.Printf("Benchmark results written to %s\n", *jsonPath)
+	}
+}
+
+// compositeScore is the geometric mean of the four scores relative to the
+// reference machine, times 1000, so that no single resource dominates.
+func compositeScore(r benchReport) float64 {
+	ratios := []float64{
+		r.CPUOps / benchRefCPUOps,
+		r.MemoryBytes / benchRefMemoryBytes,
+		r.DiskBytes / benchRefDiskBytes,
+		r.DiskIOPS / benchRefIOPS,
+	}
+	logSum := 0.0
+	for _, ratio := range ratios {
+		if ratio <= 0 {
+			return 0
+		}
+		logSum += math.Log(ratio)
+	}
+	return 1000 * math.Exp(logSum/float64(len(ratios)))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/console"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// The load a burn-in run applies unless the command line says otherwise: every
+// core, most of the free memory and a share of the free disk space, for a day.
+const (
+	burninTimeout = 24 * time.Hour
+	burninMemory  = "70%"
+	burninStorage = "10%"
+)
+
+// Verdicts of a burn-in certificate.
+const (
+	verdictPass       = "PASS"
+	verdictFail       = "FAIL"
+	verdictIncomplete = "INCOMPLETE"
+)
+
+// applyBurninDefaults fills in the load of a burn-in run where the command line
+// leaves it open and turns on verification for every stressor.
+func applyBurninDefaults(config *Config, timeoutStr *string) {
+	config.Burnin = true
+	if *timeoutStr == "" {
+		*timeoutStr = burninTimeout.String()
+	}
+	if config.CPU < 0 {
+		config.CPU = 0
+	}
+	if config.Memory == "" {
+		config.Memory = burninMemory
+	}
+	if config.Storage == "" {
+		config.Storage = burninStorage
+	}
+	config.CPUVerify, config.MemoryVerify, config.StorageVerify = true, true, true
+}
+
+// verifiedStressors returns the names of the stressors that verify their results.
+func verifiedStressors(config Config) []string {
+	var names []string
+	if config.CPUVerify && config.CPU >= 0 {
+		names = append(names, "CPU")
+	}
+	if config.MemoryVerify && config.Memory != "" {
+		names = append(names, "Memory")
+	}
+	if config.StorageVerify && config.Storage != "" {
+		names = append(names, "Storage")
+	}
+	for _, j := range config.Jobs {
+		if j.verified(config) {
+			names = append(names, j.name)
+		}
+	}
+	return names
+}
+
+// verificationErrors returns the total number of failed checks.
+func verificationErrors(verifications []metrics.Verification) int64 {
+	var n int64
+	for _, v := range verifications {
+		n += v.Failures
+	}
+	return n
+}
+
+// printVerificationReport prints the checks made by each verifying stressor.
+func printVerificationReport(verifications []metrics.Verification) {
+	if len(verifications) == 0 {
+		return
+	}
+
+	term.Printf("Verification:\n")
+	for _, v := range verifications {
+		status := verdictPass
+		if v.Failures > 0 {
+			status = verdictFail
+		}
+		term.Printf("  [%s] %s: %d checks, %d errors\n", v.Stressor, status, v.Checks, v.Failures)
+		for _, e := range v.Errors {
+			term.Printf("    ! %s\n", e)
+		}
+	}
+	term.Println()
+}
+
+// certificate is the outcome of a burn-in run, printed at its end for the
+// acceptance records of the machine.
+type certificate struct {
+	start, end time.Time
+	// elapsed is the time under load, which for a resumed run leaves out the
+	// time between its segments.
+	elapsed       time.Duration
+	planned       time.Duration
+	settings      []string
+	deviations    []metrics.Deviation
+	verifications []metrics.Verification
+	// code and message are the exit status and final message of the run.
+	code        int
+	message     string
+	interrupted bool
+}
+
+// verdict returns PASS when the run lasted as planned, every check passed and
+// every stressor reached its load, INCOMPLETE when it was interrupted without a
+// failure and FAIL otherwise.
+func (c certificate) verdict() string {
+	switch {
+	case c.code != 0:
+		return verdictFail
+	case c.interrupted:
+		return verdictIncomplete
+	default:
+		return verdictPass
+	}
+}
+
+// format renders the certificate as plain text.
+func (c certificate) format() string {
+	var b strings.Builder
+	rule := strings.Repeat("=", 72)
+	fmt.Fprintf(&b, "%s\n%s\n%s\n", rule, i18n.T("stress-go burn-in certificate"), rule)
+
+	host, _ := os.Hostname()
+	system := i18n.Sprintf("%s/%s, %d CPUs", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	if snapshot, err := sysinfo.Read(); err == nil {
+		system += i18n.Sprintf(", %s memory", bytesize.Format(snapshot.MemoryTotal))
+	}
+	info := [][]string{
+		{i18n.T("Host:"), host},
+		{i18n.T("System:"), system},
+	}
+	if topology, err := sysinfo.ReadTopology(); err == nil {
+		info = append(info, []string{i18n.T("CPU:"), topology.String()})
+	}
+	info = append(info,
+		[]string{i18n.T("Tool:"), "stress-go v" + stress.Version},
+		[]string{i18n.T("Started:"), c.start.Format(time.RFC3339)},
+		[]string{i18n.T("Finished:"), c.end.Format(time.RFC3339)},
+		[]string{i18n.T("Duration:"), i18n.Sprintf("%v of %v planned", c.elapsed.Truncate(time.Second), c.planned)},
+	)
+	for i, line := range c.settings {
+		label := ""
+		if i == 0 {
+			label = i18n.T("Load:")
+		}
+		info = append(info, []string{label, line})
+	}
+	writeTable(&b, info)
+	b.WriteString("\n")
+
+	rows := [][]string{{i18n.T("Stressor"), i18n.T("Achieved load"), i18n.T("Checks"), i18n.T("Errors"), i18n.T("Result")}}
+	checks := make(map[string]metrics.Verification)
+	for _, v := range c.verifications {
+		checks[v.Stressor] = v
+	}
+	// Stressors that were stopped before their first sample only have checks
+	deviations := append([]metrics.Deviation(nil), c.deviations...)
+	for _, v := range c.verifications {
+		if !slices.ContainsFunc(deviations, func(d metrics.Deviation) bool { return d.Stressor == v.Stressor }) {
+			deviations = append(deviations, metrics.Deviation{Stressor: v.Stressor})
+		}
+	}
+	slices.SortFunc(deviations, func(a, b metrics.Deviation) int { return strings.Compare(a.Stressor, b.Stressor) })
+	for _, d := range deviations {
+		load, count, errors := "-", "-", "-"
+		if d.Samples > 0 {
+			load = metrics.FormatValue(d.Unit, d.MeanAchieved)
+		}
+		result := verdictPass
+		if v, ok := checks[d.Stressor]; ok {
+			count, errors = fmt.Sprint(v.Checks), fmt.Sprint(v.Failures)
+			if v.Failures > 0 {
+				result = verdictFail
+			}
+		}
+		if d.Degraded {
+			result = verdictFail
+		}
+		rows = append(rows, []string{d.Stressor, load, count, errors, result})
+	}
+	writeTable(&b, rows)
+
+	for _, v := range c.verifications {
+		for _, e := range v.Errors {
+			fmt.Fprintf(&b, "  ! [%s] %s\n", v.Stressor, e)
+		}
+	}
+	for _, d := range c.deviations {
+		for _, issue := range d.Issues {
+			fmt.Fprintf(&b, "  ! [%s] %s\n", d.Stressor, issue)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s %s\n%s\n%s\n", i18n.T("Result:"), c.verdict(), c.message, rule)
+	return b.String()
+}
+
+// writeTable writes rows with their columns aligned, for text that may contain
+// wide characters.
+func writeTable(b *strings.Builder, rows [][]string) {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], console.Width(cell))
+		}
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-console.Width(cell)+2))
+			}
+		}
+		b.WriteString("\n")
+	}
+}
+
+// issueCertificate prints the certificate and writes it to path unless path is empty.
+func issueCertificate(path string, c certificate) {
+	text := c.format()
+	term.Printf("\n%s", text)
+	if path == "" {
+		return
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		term.Eprintf("Error: Failed to write certificate: %v\n", err)
+	} else {
+		term.Printf("Certificate written to %s\n", path)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cpu"
+)
+
+// calibrationCheckRatio is the target of the duty cycle run to show the effect
+// of the calibration.
+const calibrationCheckRatio = 0.5
+
+// defaultCalibrationPath returns where the calibrate subcommand stores its
+// results and where runs look for them.
+func defaultCalibrationPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stress-go", "calibration.json"), nil
+}
+
+// loadCalibration returns the duty-cycle calibration to use for the run. An
+// empty path looks in the default location and quietly falls back to no
+// calibration if there is none; a calibration measured on another host is
+// ignored with a warning.
+func loadCalibration(path string) (cpu.Calibration, error) {
+	if path == "" {
+		defaultPath, err := defaultCalibrationPath()
+		if err != nil {
+			return cpu.Calibration{}, nil
+		}
+		if _, err := os.Stat(defaultPath); errors.Is(err, os.ErrNotExist) {
+			return cpu.Calibration{}, nil
+		}
+		path = defaultPath
+	}
+	c, err := cpu.LoadCalibration(path)
+	if err != nil {
+		return cpu.Calibration{}, err
+	}
+	if host, _ := os.Hostname(); c.Host != host {
+		term.Eprintf("Warning: Ignoring the duty-cycle calibration in %s, which was measured on %s (run \"stress-go calibrate\" on this host)\n", path, c.Host)
+		return cpu.Calibration{}, nil
+	}
+	return c, nil
+}
+
+// runCalibrate implements the calibrate subcommand, which measures the timing
+// characteristics of the host and stores the correction factors that partial CPU
+// loads use from then on.
+func runCalibrate(args []string) {
+	flags := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	output := flags.String("output", "", "File to store the calibration in (default: stress-go/calibration.json in the user config directory)")
+	check := flags.Duration("check", 3*time.Second, "Compare a 50% load with and without the calibration for this long (0 = skip)")
+	flags.Parse(args)
+
+	if *check < 0 {
+		term.Eprintf("Error: --check must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	path := *output
+	if path == "" {
+		var err error
+		if path, err = defaultCalibrationPath(); err != nil {
+			term.Eprintf("Error: Cannot locate the user config directory, use --output: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping calibration...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	term.Println("Measuring timer and sleep behavior on this host...")
+	c := cpu.Calibrate(ctx)
+	if ctx.Err() != nil {
+		os.Exit(exitFailure)
+	}
+	term.Printf("  Timer resolution: %v\n", c.TimerResolution)
+	term.Printf("  Busy loop check interval: %v\n", c.SpinBatch)
+	term.Printf("  Sleep overshoot: %v\n", c.SleepOvershoot)
+
+	if *check > 0 {
+		term.Printf("Checking a %.0f%% load on one core for %v each without and with the calibration...\n",
+			calibrationCheckRatio*100, *check)
+		before, err := cpu.MeasureDutyCycle(ctx, calibrationCheckRatio, *check, cpu.Calibration{})
+		var after float64
+		if err == nil {
+			after, err = cpu.MeasureDutyCycle(ctx, calibrationCheckRatio, *check, c)
+		}
+		if ctx.Err() != nil {
+			os.Exit(exitFailure)
+		}
+		if err != nil {
+			term.Eprintf("Warning: Cannot measure the achieved load: %v\n", err)
+		} else {
+			term.Printf("  Achieved: %.1f%% uncalibrated, %.1f%% calibrated\n", before*100, after*100)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		term.Eprintf("Error: Failed to create %s: %v\n", filepath.Dir(path), err)
+		os.Exit(exitFailure)
+	}
+	if err := c.Save(path); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Calibration written to %s\n", path)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// parseChaosFaults returns the faults --chaos injects: those listed in spec, or every
+// fault the configured stressors can take when spec is empty.
+func parseChaosFaults(spec string, config Config) ([]stressor.Fault, error) {
+	available := map[stressor.Fault]bool{
+		stressor.FaultKill:    config.CPU >= 0 || hasJob(config, "cpu"),
+		stressor.FaultDrop:    config.Memory != "" || hasJob(config, "memory"),
+		stressor.FaultCorrupt: config.Storage != "" && config.StorageVerify,
+	}
+	for _, j := range config.Jobs {
+		if j.kind == "storage" && j.verified(config) {
+			available[stressor.FaultCorrupt] = true
+		}
+	}
+	needs := map[stressor.Fault]string{
+		stressor.FaultKill:    "--cpu",
+		stressor.FaultDrop:    "--memory",
+		stressor.FaultCorrupt: "--storage with --storage-verify",
+	}
+
+	var faults []stressor.Fault
+	if spec == "" {
+		for _, f := range stressor.Faults {
+			if available[f] {
+				faults = append(faults, f)
+			}
+		}
+		if len(faults) == 0 {
+			return nil, fmt.Errorf("--chaos needs --cpu, --memory or --storage with --storage-verify")
+		}
+		return faults, nil
+	}
+	for _, name := range splitList(spec) {
+		f := stressor.Fault(strings.ToLower(name))
+		switch {
+		case !slices.Contains(stressor.Faults, f):
+			return nil, fmt.Errorf("unknown fault %q (kill, drop or corrupt)", name)
+		case !available[f]:
+			return nil, fmt.Errorf("fault %s needs %s", f, needs[f])
+		case !slices.Contains(faults, f):
+			faults = append(faults, f)
+		}
+	}
+	return faults, nil
+}
+
+// runChaos injects one of faults into a random stressor at random intervals,
+// averaging interval, until ctx is done. The stressors and faults are chosen
+// from seed, so that a run can be repeated.
+func runChaos(ctx context.Context, interval time.Duration, seed uint64, faults []stressor.Fault, registry *stressor.Registry, recorder *metrics.Recorder) {
+	type target struct {
+		stressor stressor.Injectable
+		fault    stressor.Fault
+	}
+	rng := rand.New(rand.NewPCG(seed, seed))
+	for {
+		// Exponential gaps make faults arrive independently of each other
+		wait := time.Duration(rng.ExpFloat64() * float64(interval))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		var targets []target
+		for _, s := range registry.Stressors() {
+			injectable, ok := s.(stressor.Injectable)
+			if !ok {
+				continue
+			}
+			for _, f := range injectable.Faults() {
+				if slices.Contains(faults, f) {
+					targets = append(targets, target{injectable, f})
+				}
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		t := targets[rng.IntN(len(targets))]
+		name := t.stressor.Name()
+		detail, err := t.stressor.Inject(t.fault, rng)
+		if err != nil {
+			recorder.Logf("Chaos", "Cannot inject %s into %s: %v", t.fault, name, err)
+			continue
+		}
+		recorder.RecordFault(name, string(t.fault))
+		recorder.Logf("Chaos", "Injected %s into %s: %s", t.fault, name, detail)
+	}
+}
+
+// printChaosReport prints how many injected faults each stressor recovered from.
+func printChaosReport(seed uint64, faults []metrics.Fault) {
+	if len(faults) == 0 {
+		return
+	}
+
+	term.Printf("Injected faults (seed %d):\n", seed)
+	for _, f := range faults {
+		term.Printf("  [%s] %s: %d injected, %d recovered\n", f.Stressor, f.Kind, f.Injected, f.Recovered)
+	}
+	term.Println()
+}
@@ -0,0 +1,631 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// maxPollErrors is the number of consecutive failed status requests after which
+// the coordinator gives up on an agent.
+const maxPollErrors = 5
+
+// runAgent implements the agent subcommand, which waits for jobs from a
+// coordinator and runs each as a child stress-go process.
+func runAgent(args []string) {
+	flags := flag.NewFlagSet("agent", flag.ExitOnError)
+	listen := flags.String("listen", fmt.Sprintf(":%d", cluster.DefaultPort), "Address to listen on")
+	token := flags.String("token", "", "Bearer token required from the coordinator (required unless listening on loopback)")
+	flags.Parse(args)
+
+	agent, server, listener := listenAgent(*listen, *token)
+	serveAgent(agent, server, listener)
+}
+
+// runWorker implements the worker subcommand: an agent that joins a
+// coordinator started with --workers instead of being listed in its --hosts.
+func runWorker(args []string) {
+	flags := flag.NewFlagSet("worker", flag.ExitOnError)
+	join := flags.String("join", "", "Coordinator to join (host[:port]) [required]")
+	listen := flags.String("listen", fmt.Sprintf(":%d", cluster.DefaultPort), "Address to listen on")
+	advertise := flags.String("advertise", "", "Address the coordinator connects to (default: this host as the coordinator sees it, on the --listen port)")
+	token := flags.String("token", "", "Bearer token shared with the coordinator (required unless listening on loopback)")
+	flags.Parse(args)
+
+	if *join == "" {
+		term.Eprintf("Error: --join is required\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+	coordinator := *join
+	if _, _, err := net.SplitHostPort(coordinator); err != nil && !strings.Contains(coordinator, "://") {
+		coordinator = net.JoinHostPort(coordinator, strconv.Itoa(cluster.DefaultJoinPort))
+	}
+
+	agent, server, listener := listenAgent(*listen, *token)
+	address := *advertise
+	if address == "" {
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		address = net.JoinHostPort("", port)
+	}
+	hostname, _ := os.Hostname()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go keepJoined(ctx, cluster.NewClient(coordinator, *token), agent, cluster.JoinRequest{Address: address, Hostname: hostname})
+	serveAgent(agent, server, listener)
+}
+
+// joinInterval is how often an idle worker registers with the coordinator again.
+const joinInterval = 5 * time.Second
+
+// keepJoined registers the worker with the coordinator, and again every
+// joinInterval while no job is running, so that a coordinator started later or
+// the next run of the same coordinator finds it. Each change of the outcome is
+// reported once.
+func keepJoined(ctx context.Context, client *cluster.Client, agent *cluster.Agent, req cluster.JoinRequest) {
+	ticker := time.NewTicker(joinInterval)
+	defer ticker.Stop()
+	var last string
+	for {
+		if !agent.Busy() {
+			resp, err := client.Join(ctx, req)
+			switch {
+			case err != nil && ctx.Err() == nil && err.Error() != last:
+				term.Printf("[Worker] Cannot join the coordinator: %v\n", err)
+				last = err.Error()
+			case err == nil && last != resp.Address:
+				term.Printf("[Worker] Joined the coordinator %s as %s (%d workers so far)\n", client.Host(), resp.Address, resp.Workers)
+				last = resp.Address
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listenAgent checks the options of an agent and starts listening for the
+// coordinator on listen.
+func listenAgent(listen, token string) (*cluster.Agent, *http.Server, net.Listener) {
+	if token == "" && !isLoopback(listen) {
+		term.Eprintf("Error: --token is required unless the agent listens on a loopback address (e.g., --listen 127.0.0.1:%d)\n", cluster.DefaultPort)
+		os.Exit(exitConfigError)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		term.Eprintf("Error: Cannot locate the stress-go executable: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		term.Eprintf("Error: Cannot listen on %s: %v\n", listen, err)
+		os.Exit(exitConfigError)
+	}
+	agent := cluster.NewAgent(executable, token, func(format string, args ...any) {
+		term.Printf("[Agent] %s\n", i18n.Sprintf(format, args...))
+	})
+	return agent, &http.Server{Handler: agent.Handler()}, listener
+}
+
+// serveAgent serves the agent until SIGINT or SIGTERM, which stop its job.
+func serveAgent(agent *cluster.Agent, server *http.Server, listener net.Listener) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		term.Println("\nStopping agent...")
+		agent.Stop()
+		server.Close()
+	}()
+
+	term.Printf("[Agent] Listening on %s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+}
+
+// isLoopback reports whether addr only accepts connections from this host. An
+// address without a host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// agentJob tracks the job started on one agent.
+type agentJob struct {
+	client *cluster.Client
+	// mu guards status and err against the control endpoints; the polling loop
+	// is their only writer.
+	mu     sync.Mutex
+	status cluster.JobStatus
+	err    error // set when the job could not be started or the agent was lost
+
+	offset     time.Duration // agent clock minus coordinator clock
+	pollErrors int           // consecutive failed status requests
+}
+
+// runCoordinate implements the coordinate subcommand, which runs the same load
+// test on every agent and waits until all of them have finished.
+func runCoordinate(args []string) {
+	flags := flag.NewFlagSet("coordinate", flag.ExitOnError)
+	hosts := flags.String("hosts", "", "Comma-separated agent hosts (host[:port]) [required]")
+	token := flags.String("token", "", "Bearer token sent to the agents")
+	poll := flags.Duration("poll", 2*time.Second, "Interval between status requests")
+	startDelay := flags.Duration("start-delay", 5*time.Second, "Start the load on all agents together this long after submitting (0 = start immediately)")
+	sshHosts := flags.String("ssh-hosts", "", "Run over ssh on the hosts listed in this file instead of agents")
+	sshCopy := flags.Bool("ssh-copy", false, "Copy this executable to the ssh hosts before running")
+	sshPath := flags.String("ssh-path", "", "Path of stress-go on the ssh hosts (default stress-go, or a new temporary directory with --ssh-copy)")
+	reportJSON := flags.String("report-json", "", "Write the aggregated cluster report to this file (JSON)")
+	listen := flags.String("listen", "", fmt.Sprintf("Serve the cluster status and control endpoints on this address (agents only); with --workers also where workers join (default :%d)", cluster.DefaultJoinPort))
+	workers := flags.Int("workers", 0, "Wait for this many workers (stress-go worker --join) to join instead of using --hosts")
+	joinTimeout := flags.Duration("join-timeout", 10*time.Minute, "Give up when the --workers have not all joined in this time (0 = wait indefinitely)")
+	var sshExtra stringList
+	flags.Var(&sshExtra, "ssh-option", "Additional option passed to ssh and scp (repeatable, e.g. -i key or -oPort=2222)")
+	flags.Parse(args)
+
+	jobArgs := flags.Args()
+	sources := 0
+	for _, given := range []bool{len(splitList(*hosts)) > 0, *sshHosts != "", *workers != 0} {
+		if given {
+			sources++
+		}
+	}
+	if sources != 1 || len(jobArgs) == 0 {
+		term.Eprintf("Error: one of --hosts, --ssh-hosts or --workers and the load test options after -- are required\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+	if *workers < 0 {
+		term.Eprintf("Error: --workers must be positive\n")
+		os.Exit(exitConfigError)
+	}
+	if *workers > 0 {
+		*listen = cmp.Or(*listen, fmt.Sprintf(":%d", cluster.DefaultJoinPort))
+		if *token == "" && !isLoopback(*listen) {
+			term.Eprintf("Error: --token is required with --workers unless --listen is a loopback address\n")
+			os.Exit(exitConfigError)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	if *sshHosts != "" {
+		targets, err := loadSSHHosts(*sshHosts)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if *listen != "" {
+			term.Eprintf("Error: --listen is not supported with --ssh-hosts\n")
+			os.Exit(exitConfigError)
+		}
+		opts := sshOptions{copy: *sshCopy, remotePath: *sshPath, extra: sshExtra}
+		if opts.remotePath == "" && !opts.copy {
+			opts.remotePath = "stress-go"
+		}
+		results := runSSHFanout(targets, jobArgs, opts, *startDelay, sigChan)
+		os.Exit(finishCoordinate(results, *reportJSON))
+	}
+
+	scenario, err := jobScenario(jobArgs)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	mux := http.NewServeMux()
+	if *listen != "" {
+		startHTTPServer(*listen, mux)
+	}
+	agentHosts := splitList(*hosts)
+	if *workers > 0 {
+		agentHosts = waitForWorkers(mux, *token, *workers, *joinTimeout, sigChan)
+	}
+
+	var jobs []*agentJob
+	for _, host := range agentHosts {
+		jobs = append(jobs, &agentJob{client: cluster.NewClient(host, *token)})
+	}
+
+	// Measure each agent's clock so the start barrier falls at the same instant everywhere
+	if *startDelay > 0 {
+		forEachJob(jobs, func(j *agentJob) {
+			j.offset, j.err = j.client.ClockOffset(context.Background())
+		})
+	}
+
+	term.Printf("Starting on %d agents: %s\n", len(jobs), strings.Join(jobArgs, " "))
+	var startAt time.Time
+	if *startDelay > 0 {
+		startAt = time.Now().Add(*startDelay)
+		term.Printf("Start barrier: %s\n", startAt.Format("15:04:05.000"))
+	}
+	forEachJob(jobs, func(j *agentJob) {
+		if j.err != nil {
+			return
+		}
+		spec := cluster.JobSpec{Args: jobArgs, Scenario: scenario}
+		if !startAt.IsZero() {
+			spec.StartAt = startAt.Add(j.offset)
+		}
+		j.status, j.err = j.client.Start(context.Background(), spec)
+	})
+
+	// Keep the cluster consistent: if any agent could not start, stop the rest
+	started := true
+	for _, j := range jobs {
+		if j.err != nil {
+			term.Eprintf("[%s] Error: %v\n", j.client.Host(), j.err)
+			started = false
+		}
+	}
+	if !started {
+		term.Println("Not all agents started, stopping the others...")
+		stopJobs(jobs)
+		os.Exit(exitStartupFailure)
+	}
+	for _, j := range jobs {
+		if startAt.IsZero() {
+			term.Printf("[%s] Started job %d\n", j.client.Host(), j.status.ID)
+		} else {
+			term.Printf("[%s] Scheduled job %d (clock offset %v)\n", j.client.Host(), j.status.ID, j.offset.Truncate(time.Microsecond))
+		}
+	}
+
+	registerClusterControl(mux, jobs)
+
+	waitForJobs(jobs, *poll, sigChan)
+	var results []hostResult
+	for _, j := range jobs {
+		results = append(results, hostResult{host: j.client.Host(), status: j.status, err: j.err})
+	}
+	os.Exit(finishCoordinate(results, *reportJSON))
+}
+
+// waitForWorkers accepts workers joining on mux until n have joined and returns
+// the addresses of their agents. It exits if they have not all joined within
+// timeout or the coordinator is interrupted meanwhile.
+func waitForWorkers(mux *http.ServeMux, token string, n int, timeout time.Duration, sigChan <-chan os.Signal) []string {
+	lobby := cluster.NewLobby(token, n, func(format string, args ...any) {
+		term.Printf("%s\n", i18n.Sprintf(format, args...))
+	})
+	mux.Handle("POST /v1/join", lobby.Handler())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		var stop context.CancelFunc
+		ctx, stop = context.WithTimeout(ctx, timeout)
+		defer stop()
+	}
+	go func() {
+		select {
+		case <-sigChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	term.Printf("Waiting for %d workers to join...\n", n)
+	workers, err := lobby.Wait(ctx)
+	cancel()
+	if err != nil {
+		term.Eprintf("Error: only %d of %d workers joined: %v\n", len(workers), n, err)
+		os.Exit(exitStartupFailure)
+	}
+	return workers
+}
+
+// jobScenario returns the contents of the --scenario file of a job, which are
+// sent to the agents so that they need no copy of the file. It returns "" when
+// the job has no scenario or the file is not found here, in which case the
+// agents read the path themselves.
+func jobScenario(args []string) (string, error) {
+	for i, arg := range args {
+		name, path, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "scenario" {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil
+			}
+			path = args[i+1]
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot read the scenario: %v", err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// forEachJob calls fn for every job concurrently and waits for all calls.
+func forEachJob(jobs []*agentJob, fn func(j *agentJob)) {
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(j)
+		}()
+	}
+	wg.Wait()
+}
+
+// stopJobs asks every agent with a running job to stop it.
+func stopJobs(jobs []*agentJob) {
+	forEachJob(jobs, func(j *agentJob) {
+		if j.err != nil || j.status.ID == 0 || j.status.Finished() {
+			return
+		}
+		if _, err := j.client.Stop(context.Background(), j.status.ID); err != nil {
+			term.Eprintf("[%s] Warning: %v\n", j.client.Host(), err)
+		}
+	})
+}
+
+// waitForJobs polls the agents until every job has finished or its agent is lost.
+// A stop signal is forwarded to all agents.
+func waitForJobs(jobs []*agentJob, interval time.Duration, sigChan <-chan os.Signal) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pending := 0
+		for _, j := range jobs {
+			if j.err == nil && !j.status.Finished() {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return
+		}
+
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping all agents...")
+			stopJobs(jobs)
+		case <-ticker.C:
+		}
+
+		forEachJob(jobs, func(j *agentJob) {
+			if j.err != nil || j.status.Finished() {
+				return
+			}
+			status, err := j.client.Status(context.Background(), j.status.ID)
+			if err != nil {
+				j.pollErrors++
+				if j.pollErrors >= maxPollErrors {
+					j.mu.Lock()
+					j.err = fmt.Errorf("lost contact: %v", err)
+					j.mu.Unlock()
+					term.Eprintf("[%s] Error: %v\n", j.client.Host(), j.err)
+				}
+				return
+			}
+			j.pollErrors = 0
+			j.mu.Lock()
+			j.status = status
+			j.mu.Unlock()
+			if status.Finished() {
+				term.Printf("[%s] Finished with exit status %d\n", j.client.Host(), status.ExitCode)
+			}
+		})
+	}
+}
+
+// registerClusterControl adds the cluster-wide status and control endpoints to mux:
+// GET /v1/cluster returns the live state of every agent's job, and POST
+// /v1/cluster/pause, /v1/cluster/resume and /v1/cluster/level apply the command to
+// every running job. Both return one cluster.HostStatus per agent.
+func registerClusterControl(mux *http.ServeMux, jobs []*agentJob) {
+	mux.HandleFunc("GET /v1/cluster", func(w http.ResponseWriter, r *http.Request) {
+		writeControlJSON(w, http.StatusOK, controlJobs(jobs, (*cluster.Client).Live))
+	})
+	mux.HandleFunc("POST /v1/cluster/pause", func(w http.ResponseWriter, r *http.Request) {
+		term.Printf("\n[Control] Pausing the load on all agents\n")
+		writeControlJSON(w, http.StatusOK, controlJobs(jobs, (*cluster.Client).Pause))
+	})
+	mux.HandleFunc("POST /v1/cluster/resume", func(w http.ResponseWriter, r *http.Request) {
+		term.Printf("\n[Control] Resuming the load on all agents\n")
+		writeControlJSON(w, http.StatusOK, controlJobs(jobs, (*cluster.Client).Resume))
+	})
+	mux.HandleFunc("POST /v1/cluster/level", func(w http.ResponseWriter, r *http.Request) {
+		var req cluster.LevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+		if req.Level < 0 || req.Level > maxLevel {
+			writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("level must be between 0 and %d", maxLevel)})
+			return
+		}
+		term.Printf("\n[Control] Setting the load level to %g on all agents\n", req.Level)
+		writeControlJSON(w, http.StatusOK, controlJobs(jobs, func(c *cluster.Client, ctx context.Context, id int) (cluster.LiveStatus, error) {
+			return c.SetLevel(ctx, id, req.Level)
+		}))
+	})
+}
+
+// controlJobs calls fn for the running job of every agent concurrently and
+// collects each agent's job status with the live status fn returned.
+func controlJobs(jobs []*agentJob, fn func(c *cluster.Client, ctx context.Context, id int) (cluster.LiveStatus, error)) []cluster.HostStatus {
+	statuses := make([]cluster.HostStatus, len(jobs))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.mu.Lock()
+			status, jobErr := j.status, j.err
+			j.mu.Unlock()
+
+			hs := cluster.HostStatus{Host: j.client.Host()}
+			switch {
+			case jobErr != nil:
+				hs.Error = jobErr.Error()
+			case status.ID == 0:
+				hs.Error = "job not started"
+			default:
+				hs.Job = &status
+				if status.Finished() {
+					break
+				}
+				live, err := fn(j.client, context.Background(), status.ID)
+				if err != nil {
+					hs.Error = err.Error()
+				} else {
+					hs.Live = &live
+				}
+			}
+			statuses[i] = hs
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+// hostResult is the outcome of a load test on one host.
+type hostResult struct {
+	host   string
+	status cluster.JobStatus
+	err    error
+}
+
+// finishCoordinate prints the outcome on each host and the aggregated cluster
+// report, writes the report to reportPath when set, and returns the exit status
+// of the coordinator: 0 only when every run succeeded.
+func finishCoordinate(results []hostResult, reportPath string) int {
+	term.Printf("\nHost results:\n")
+	code := 0
+	var outcomes []cluster.HostOutcome
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			term.Printf("  [%s] FAILED: %v\n", r.host, r.err)
+			code = exitFailure
+		case r.status.ExitCode != 0:
+			term.Printf("  [%s] FAILED: exit status %d%s\n", r.host, r.status.ExitCode, lastOutput(r.status))
+			code = exitFailure
+		default:
+			term.Printf("  [%s] OK%s\n", r.host, lastOutput(r.status))
+		}
+
+		outcome := cluster.HostOutcome{Host: r.host, ExitCode: r.status.ExitCode, Summary: r.status.Summary}
+		if r.err != nil {
+			outcome.Error = r.err.Error()
+		}
+		if outcome.Summary != nil {
+			outcome.Summary.Host = r.host
+		}
+		outcomes = append(outcomes, outcome)
+	}
+
+	report := cluster.Aggregate(outcomes)
+	printClusterReport(report)
+	if reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(reportPath, data, 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write cluster report: %v\n", err)
+		} else {
+			term.Printf("Cluster report written to %s\n", reportPath)
+		}
+	}
+	return code
+}
+
+// printClusterReport prints the cluster-wide totals, the stragglers and the
+// problems reported by each host.
+func printClusterReport(report cluster.Report) {
+	term.Printf("\nCluster report: %d hosts, %d succeeded, %d failed\n", report.Hosts, report.Succeeded, report.Failed)
+	for _, t := range report.Totals {
+		ratio := 0.0
+		if t.Target > 0 {
+			ratio = t.Achieved / t.Target * 100
+		}
+		term.Printf("  [%s] total target %s, achieved %s (%.1f%%) on %d hosts",
+			t.Stressor, metrics.FormatValue(t.Unit, t.Target), metrics.FormatValue(t.Unit, t.Achieved), ratio, t.Hosts)
+		if t.Degraded > 0 {
+			term.Printf(", %d degraded", t.Degraded)
+		}
+		term.Println()
+	}
+
+	if len(report.Stragglers) > 0 {
+		term.Printf("Stragglers:\n")
+		for _, s := range report.Stragglers {
+			term.Printf("  [%s] %s: %.1f%% of target (cluster median %.1f%%)\n", s.Host, s.Stressor, s.Ratio*100, s.Median*100)
+		}
+	}
+
+	var problems []string
+	for _, o := range report.Outcomes {
+		switch {
+		case o.Summary == nil && o.Error == "":
+			problems = append(problems, fmt.Sprintf("  [%s] no summary returned", o.Host))
+		case o.Summary != nil:
+			for _, s := range o.Summary.Stressors {
+				for _, issue := range s.Issues {
+					problems = append(problems, fmt.Sprintf("  [%s] %s: %s", o.Host, s.Name, issue))
+				}
+			}
+			for _, f := range o.Summary.Failures {
+				problems = append(problems, fmt.Sprintf("  [%s] %s", o.Host, f))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		term.Printf("Problems:\n")
+		for _, p := range problems {
+			term.Println(p)
+		}
+	}
+	term.Println()
+}
+
+// lastOutput returns the job's final output line, formatted for the summary.
+func lastOutput(status cluster.JobStatus) string {
+	for i := len(status.Output) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(status.Output[i]); line != "" {
+			return " (" + line + ")"
+		}
+	}
+	return ""
+}
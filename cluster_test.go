@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:7420", true},
+		{"[::1]:7420", true},
+		{"localhost:7420", true},
+		{":7420", false},
+		{"0.0.0.0:7420", false},
+		{"192.168.1.10:7420", false},
+		{"node1:7420", false},
+		{"7420", false},
+	}
+	for _, tt := range tests {
+		if got := isLoopback(tt.addr); got != tt.want {
+			t.Errorf("isLoopback(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
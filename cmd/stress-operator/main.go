@@ -0,0 +1,50 @@
+// stress-operator は StressTest カスタムリソースに従ってクラスター上で stress-go を実行する
+// Kubernetes オペレーターです。deploy/ のマニフェストでクラスターにデプロイします。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/kube"
+	"github.com/utkamioka/stress-go/pkg/operator"
+)
+
+func main() {
+	image := flag.String("image", "", "Default stress-go image for stress tests without spec.image")
+	interval := flag.Duration("interval", 10*time.Second, "Interval between reconciliations")
+	server := flag.String("server", "", "API server URL, e.g. a kubectl proxy (default: in-cluster configuration)")
+	token := flag.String("token", "", "Bearer token for --server")
+	flag.Parse()
+
+	var client *kube.Client
+	if *server != "" {
+		client = kube.New(*server, *token)
+	} else {
+		var err error
+		client, err = kube.InCluster()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	op := operator.New(operator.Options{
+		Client:   client,
+		Image:    *image,
+		Interval: *interval,
+		Logf: func(format string, args ...any) {
+			fmt.Printf(time.Now().Format("2006-01-02 15:04:05")+" "+format+"\n", args...)
+		},
+	})
+	fmt.Printf("Watching %s resources every %v\n", operator.Kind, *interval)
+	op.Run(ctx)
+}
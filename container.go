@@ -0,0 +1,32 @@
+package main
+
+import "github.com/utkamioka/stress-go/pkg/sysinfo"
+
+// warnContainerLimits prints a warning for each load target that the cgroup limits
+// of the process (typically those of its container) will not let it reach.
+func warnContainerLimits(opts stressorOptions) {
+	inContainer := sysinfo.InContainer()
+	cg, err := sysinfo.ReadCgroup()
+	if err != nil {
+		// Percentages are then taken from the host, which a container may not be allowed to use
+		if inContainer && opts.memory.Percent > 0 {
+			term.Eprintf("Warning: No cgroup memory limit could be read (%v); --memory %g%% is a share of the host's free memory\n",
+				err, opts.memory.Percent)
+		}
+		return
+	}
+
+	if cores := opts.cpu.Cores; cores > 0 && cg.CPUQuota > 0 && float64(cores) > cg.CPUQuota {
+		term.Eprintf("Warning: --cpu %d exceeds the cgroup CPU quota of %.2f cores; the load will be throttled\n",
+			cores, cg.CPUQuota)
+	}
+	if headroom := cg.MemoryHeadroom(); headroom >= 0 && opts.memory.Size > headroom {
+		term.Eprintf("Warning: --memory %d MiB exceeds the %d MiB left under the cgroup memory limit; the process may be OOM-killed\n",
+			opts.memory.Size/(1024*1024), headroom/(1024*1024))
+	}
+	// Ephemeral storage limits are enforced by eviction and cannot be read from inside
+	if inContainer && opts.storage.Percent > 0 {
+		term.Eprintf("Warning: --storage %g%% is a share of the free space of the filesystem behind the container; "+
+			"it may exceed an ephemeral storage limit and get the container evicted\n", opts.storage.Percent)
+	}
+}
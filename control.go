@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// maxLevel bounds the load level accepted by the control API.
+const maxLevel = 10
+
+// runControl serves the live status of the run and pauses, resumes or scales the
+// load of its stressors on request, next to the health endpoints.
+type runControl struct {
+	registry *stressor.Registry
+	dl       *deadline.Deadline
+	health   *runHealth
+	bus      *events.Bus
+
+	mu     sync.Mutex
+	paused bool
+	level  float64
+}
+
+func newRunControl(registry *stressor.Registry, dl *deadline.Deadline, health *runHealth, bus *events.Bus) *runControl {
+	return &runControl{registry: registry, dl: dl, health: health, bus: bus, level: 1}
+}
+
+// register adds the control endpoints to mux. The /v1/stressors/{name} variants
+// control one stressor, e.g. one of several --job loads, and leave the others be;
+// PATCH on it replaces the target of the stressor itself.
+func (c *runControl) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/status", c.handleStatus)
+	mux.HandleFunc("POST /v1/pause", c.handlePause)
+	mux.HandleFunc("POST /v1/resume", c.handleResume)
+	mux.HandleFunc("POST /v1/level", c.handleLevel)
+	mux.HandleFunc("POST /v1/stressors/{name}/pause", c.handlePause)
+	mux.HandleFunc("POST /v1/stressors/{name}/resume", c.handleResume)
+	mux.HandleFunc("POST /v1/stressors/{name}/level", c.handleLevel)
+	mux.HandleFunc("PATCH /v1/stressors/{name}", c.handleTarget)
+}
+
+// status returns the current state of the run and its stressors.
+func (c *runControl) status() cluster.LiveStatus {
+	state, states := c.health.snapshot()
+	c.mu.Lock()
+	status := cluster.LiveStatus{State: state, Remaining: c.dl.Remaining().Seconds(), Paused: c.paused, Level: c.level}
+	c.mu.Unlock()
+	for _, s := range c.registry.Stressors() {
+		stats := s.Stats()
+		_, controllable := s.(stressor.Controllable)
+		status.Stressors = append(status.Stressors, cluster.LiveStressor{
+			Name:         s.Name(),
+			Unit:         stats.Unit,
+			State:        states[s.Name()],
+			Target:       stats.Target,
+			Achieved:     stats.Achieved,
+			Paused:       stats.Paused,
+			Controllable: controllable,
+		})
+	}
+	return status
+}
+
+func (c *runControl) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeControlJSON(w, http.StatusOK, c.status())
+}
+
+func (c *runControl) handlePause(w http.ResponseWriter, r *http.Request) {
+	targets, ok := c.targets(w, r)
+	if !ok {
+		return
+	}
+	if name := r.PathValue("name"); name != "" {
+		c.apply(targets, func(s stressor.Controllable) { s.Pause() }, nil, i18n.Sprintf("Load of %s paused", name))
+	} else {
+		c.apply(targets, func(s stressor.Controllable) { s.Pause() }, func() { c.paused = true }, i18n.T("Load paused"))
+	}
+	writeControlJSON(w, http.StatusOK, c.status())
+}
+
+func (c *runControl) handleResume(w http.ResponseWriter, r *http.Request) {
+	targets, ok := c.targets(w, r)
+	if !ok {
+		return
+	}
+	if name := r.PathValue("name"); name != "" {
+		c.apply(targets, func(s stressor.Controllable) { s.Resume() }, nil, i18n.Sprintf("Load of %s resumed", name))
+	} else {
+		c.apply(targets, func(s stressor.Controllable) { s.Resume() }, func() { c.paused = false }, i18n.T("Load resumed"))
+	}
+	writeControlJSON(w, http.StatusOK, c.status())
+}
+
+func (c *runControl) handleLevel(w http.ResponseWriter, r *http.Request) {
+	var req cluster.LevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	if req.Level < 0 || req.Level > maxLevel {
+		writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("level must be between 0 and %d", maxLevel)})
+		return
+	}
+	targets, ok := c.targets(w, r)
+	if !ok {
+		return
+	}
+	if name := r.PathValue("name"); name != "" {
+		c.apply(targets, func(s stressor.Controllable) { s.SetLevel(req.Level) }, nil,
+			i18n.Sprintf("Load level of %s set to %g", name, req.Level))
+	} else {
+		c.apply(targets, func(s stressor.Controllable) { s.SetLevel(req.Level) }, func() { c.level = req.Level },
+			i18n.Sprintf("Load level set to %g", req.Level))
+	}
+	writeControlJSON(w, http.StatusOK, c.status())
+}
+
+func (c *runControl) handleTarget(w http.ResponseWriter, r *http.Request) {
+	var req cluster.TargetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+	targets, ok := c.targets(w, r)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	s, ok := targets[0].(stressor.Retargetable)
+	if !ok {
+		writeControlJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("the target of %s cannot be changed", name)})
+		return
+	}
+	target, err := parseTarget(s.Stats().Unit, req.Target)
+	if err == nil {
+		err = s.SetTarget(target)
+	}
+	if err != nil {
+		writeControlJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid target %q: %v", req.Target, err)})
+		return
+	}
+	c.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Target of %s set to %s", name, req.Target)})
+	writeControlJSON(w, http.StatusOK, c.status())
+}
+
+// parseTarget parses the target of a PATCH request in the unit of the stressor:
+// a number of cores, or a size such as "4GB".
+func parseTarget(unit, value string) (float64, error) {
+	switch unit {
+	case metrics.UnitBytes:
+		size, err := bytesize.ParseAbsolute(value)
+		return float64(size), err
+	case metrics.UnitCores:
+		return strconv.ParseFloat(value, 64)
+	default:
+		return 0, fmt.Errorf("unsupported unit %s", unit)
+	}
+}
+
+// targets returns the controllable stressors a request applies to: the one named
+// in its path, or all of them. It answers the request itself when the named
+// stressor does not exist or cannot be controlled.
+func (c *runControl) targets(w http.ResponseWriter, r *http.Request) ([]stressor.Controllable, bool) {
+	name := r.PathValue("name")
+	var targets []stressor.Controllable
+	for _, s := range c.registry.Stressors() {
+		if name != "" && !strings.EqualFold(s.Name(), name) {
+			continue
+		}
+		controllable, ok := s.(stressor.Controllable)
+		if !ok && name != "" {
+			writeControlJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("stressor %s cannot be controlled", s.Name())})
+			return nil, false
+		}
+		if ok {
+			targets = append(targets, controllable)
+		}
+	}
+	if name != "" && len(targets) == 0 {
+		writeControlJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("no stressor named %s", name)})
+		return nil, false
+	}
+	return targets, true
+}
+
+// apply runs action on targets, records the new state of the whole run with
+// update (nil when only some stressors change) and announces the change on the bus.
+func (c *runControl) apply(targets []stressor.Controllable, action func(stressor.Controllable), update func(), message string) {
+	c.mu.Lock()
+	for _, s := range targets {
+		action(s)
+	}
+	if update != nil {
+		update()
+	}
+	c.mu.Unlock()
+	c.bus.Publish(events.Event{Type: events.RunControlled, Message: message})
+}
+
+// stepLevel changes the load level of the whole run by delta, within 0 and
+// maxLevel, as the level endpoint does. The level is rounded so that repeated
+// steps such as 0.1 do not accumulate floating point noise.
+func (c *runControl) stepLevel(delta float64) {
+	var targets []stressor.Controllable
+	for _, s := range c.registry.Stressors() {
+		if controllable, ok := s.(stressor.Controllable); ok {
+			targets = append(targets, controllable)
+		}
+	}
+	c.mu.Lock()
+	level := math.Round(min(max(c.level+delta, 0), maxLevel)*1e6) / 1e6
+	for _, s := range targets {
+		s.SetLevel(level)
+	}
+	c.level = level
+	c.mu.Unlock()
+	c.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Load level set to %g", level)})
+}
+
+// writeControlJSON writes v as the JSON response with the status code.
+func writeControlJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// ctlPollInterval is how often "ctl start --wait" and "ctl stop --wait" check
+// whether the load test has finished.
+const ctlPollInterval = time.Second
+
+// defaultSocketPath is the unix socket of the daemon unless --socket is given:
+// in the user's runtime directory, or in a per-user directory, private to its
+// owner, in the temporary one.
+func defaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "stress-go.sock")
+	}
+	return filepath.Join(privateSocketDir(), "stress-go.sock")
+}
+
+// privateSocketDir is the per-user directory of the default socket when there
+// is no runtime directory.
+func privateSocketDir() string {
+	if uid := os.Getuid(); uid >= 0 {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("stress-go-%d", uid))
+	}
+	return filepath.Join(os.TempDir(), "stress-go")
+}
+
+// runDaemon implements the daemon subcommand, an agent that stays up on a unix
+// socket so that operators on the host start, watch and stop load tests with
+// "stress-go ctl" instead of nohup and PIDs. The socket's file permissions are
+// its access control. With --schedule the daemon also starts load tests on cron
+// expressions, as a standing synthetic load generator.
+func runDaemon(args []string) {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	socket := flags.String("socket", defaultSocketPath(), "Unix socket to listen on")
+	schedulePath := flags.String("schedule", "", "File of cron expressions and the load tests to start on them")
+	flags.Parse(args)
+
+	var loads []scheduledLoad
+	if *schedulePath != "" {
+		var err error
+		if loads, err = loadSchedule(*schedulePath); err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		term.Eprintf("Error: Cannot locate the stress-go executable: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	listener, err := listenSocket(*socket)
+	if err != nil {
+		term.Eprintf("Error: Cannot listen on %s: %v\n", *socket, err)
+		os.Exit(exitFailure)
+	}
+
+	logf := func(format string, args ...any) {
+		term.Printf("[Daemon] %s\n", i18n.Sprintf(format, args...))
+	}
+	agent := cluster.NewLocalAgent(executable, logf)
+	server := &http.Server{Handler: agent.Handler()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		term.Println("\nStopping daemon...")
+		cancel()
+		agent.Stop()
+		server.Close()
+	}()
+	if len(loads) > 0 {
+		go runSchedule(ctx, agent, loads, logf)
+	}
+
+	term.Printf("[Daemon] Listening on %s\n", *socket)
+	err = server.Serve(listener)
+	os.Remove(*socket)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+}
+
+// listenSocket listens on the unix socket at path, accessible to its owner only.
+// A socket file left behind by a daemon that did not exit cleanly is replaced,
+// one that a running daemon answers on is not. The socket is created in a new
+// directory private to the owner and only then moved to path, so that no one
+// else can connect before its permissions are set. The caller removes path
+// after closing the listener.
+func listenSocket(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if dir == privateSocketDir() {
+		if err := ensurePrivateDir(dir); err != nil {
+			return nil, err
+		}
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("a daemon is already running")
+		}
+		if info.Mode().IsRegular() || info.IsDir() {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+
+	staging, err := os.MkdirTemp(dir, ".stress-go-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+	staged := filepath.Join(staging, "stress-go.sock")
+	listener, err := net.Listen("unix", staged)
+	if err != nil {
+		return nil, err
+	}
+	// The listener would remove the staged name on close, which is gone by then
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(staged, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(staged, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// ensurePrivateDir creates dir accessible to its owner only, or checks that an
+// existing one is, so that other users cannot replace the socket in it.
+func ensurePrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is not a directory private to its owner", dir)
+	}
+	return nil
+}
+
+// runCtl implements the ctl subcommand, the client of the daemon:
+//
+//	ctl start [--wait] <options>  start a load test with the options of a run
+//	ctl status [--lines N]       show the current or last load test
+//	ctl stop [--wait]            stop the load test, cleaning up as on Ctrl+C
+//	ctl adjust --level N | --pause | --resume | --target NAME=VALUE
+//
+// With --wait, ctl exits with the exit status of the load test.
+func runCtl(args []string) {
+	flags := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socket := flags.String("socket", defaultSocketPath(), "Unix socket the daemon listens on")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		term.Eprintf("Error: ctl needs a command: start, status, stop or adjust\n")
+		os.Exit(exitConfigError)
+	}
+
+	client := cluster.NewSocketClient(*socket)
+	command, args := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "start":
+		ctlStart(client, args)
+	case "status":
+		ctlStatus(client, args)
+	case "stop":
+		ctlStop(client, args)
+	case "adjust":
+		ctlAdjust(client, args)
+	default:
+		term.Eprintf("Error: Unknown ctl command %q (start, status, stop or adjust)\n", command)
+		os.Exit(exitConfigError)
+	}
+}
+
+// ctlStart starts a load test. Its own --wait option comes first, so that the
+// options of the load test need no "--" in front of them.
+func ctlStart(client *cluster.Client, args []string) {
+	wait := len(args) > 0 && args[0] == "--wait"
+	if wait {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		term.Eprintf("Error: ctl start needs the load test options, e.g. ctl start -- --timeout 1h --cpu 2\n")
+		os.Exit(exitConfigError)
+	}
+
+	status, err := client.Start(context.Background(), cluster.JobSpec{Args: args})
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Started job %d: %s\n", status.ID, strings.Join(args, " "))
+	if wait {
+		os.Exit(ctlWait(client, status.ID))
+	}
+}
+
+func ctlStatus(client *cluster.Client, args []string) {
+	flags := flag.NewFlagSet("ctl status", flag.ExitOnError)
+	lines := flags.Int("lines", 10, "Number of output lines to show of a finished load test")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	status, err := client.Status(ctx, cluster.CurrentJob)
+	if errors.Is(err, cluster.ErrNoJob) {
+		term.Println("No load test has been started.")
+		return
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	term.Printf("Job %d: %s\n", status.ID, strings.Join(status.Args, " "))
+	if status.Finished() {
+		term.Printf("Finished with exit status %d after %v\n",
+			status.ExitCode, status.FinishedAt.Sub(status.StartedAt).Truncate(time.Second))
+		output := status.Output
+		if len(output) > *lines {
+			output = output[len(output)-*lines:]
+		}
+		for _, line := range output {
+			term.Println("  " + line)
+		}
+		return
+	}
+
+	term.Printf("Running since %s\n", status.StartedAt.Format(time.DateTime))
+	live, err := client.Live(ctx, status.ID)
+	if err != nil {
+		term.Eprintf("Warning: %v\n", err)
+		return
+	}
+	term.Printf("State: %s, remaining %v, level %g", live.State, time.Duration(live.Remaining*float64(time.Second)).Truncate(time.Second), live.Level)
+	if live.Paused {
+		term.Printf(", paused")
+	}
+	term.Println()
+	for _, s := range live.Stressors {
+		term.Printf("  [%s] %s: target %s, achieved %s\n",
+			s.Name, s.State, metrics.FormatValue(s.Unit, s.Target), metrics.FormatValue(s.Unit, s.Achieved))
+	}
+}
+
+func ctlStop(client *cluster.Client, args []string) {
+	flags := flag.NewFlagSet("ctl stop", flag.ExitOnError)
+	wait := flags.Bool("wait", false, "Wait until the load test has cleaned up and exit with its status")
+	flags.Parse(args)
+
+	status, err := client.Stop(context.Background(), cluster.CurrentJob)
+	if errors.Is(err, cluster.ErrNoJob) {
+		term.Println("No load test has been started.")
+		return
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	if status.Finished() {
+		term.Printf("Job %d has already finished.\n", status.ID)
+		return
+	}
+	term.Printf("Stopping job %d\n", status.ID)
+	if *wait {
+		os.Exit(ctlWait(client, status.ID))
+	}
+}
+
+func ctlAdjust(client *cluster.Client, args []string) {
+	flags := flag.NewFlagSet("ctl adjust", flag.ExitOnError)
+	level := flags.Float64("level", -1, "Set the factor applied to the configured load (1 = as configured)")
+	pause := flags.Bool("pause", false, "Pause the load")
+	resume := flags.Bool("resume", false, "Resume the paused load")
+	target := flags.String("target", "", "Replace the target of one stressor, e.g. memory=4GB or cpu=2.5")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	var live cluster.LiveStatus
+	var err error
+	switch {
+	case *pause && !*resume && *level < 0 && *target == "":
+		live, err = client.Pause(ctx, cluster.CurrentJob)
+	case *resume && !*pause && *level < 0 && *target == "":
+		live, err = client.Resume(ctx, cluster.CurrentJob)
+	case *level >= 0 && !*pause && !*resume && *target == "":
+		live, err = client.SetLevel(ctx, cluster.CurrentJob, *level)
+	case *target != "" && !*pause && !*resume && *level < 0:
+		name, value, ok := strings.Cut(*target, "=")
+		if !ok || name == "" || value == "" {
+			term.Eprintf("Error: --target must be NAME=VALUE, e.g. memory=4GB\n")
+			os.Exit(exitConfigError)
+		}
+		live, err = client.SetTarget(ctx, cluster.CurrentJob, name, value)
+	default:
+		term.Eprintf("Error: ctl adjust needs one of --level, --pause, --resume or --target\n")
+		os.Exit(exitConfigError)
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Level %g", live.Level)
+	if live.Paused {
+		term.Printf(", paused")
+	}
+	term.Println()
+}
+
+// ctlWait polls the job until it has finished and returns its exit status.
+func ctlWait(client *cluster.Client, id int) int {
+	for {
+		status, err := client.Status(context.Background(), id)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			return exitFailure
+		}
+		if status.Finished() {
+			term.Printf("Job %d finished with exit status %d\n", id, status.ExitCode)
+			return status.ExitCode
+		}
+		time.Sleep(ctlPollInterval)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/utkamioka/stress-go/pkg/doctor"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// runDoctor implements the doctor subcommand, which checks the host before a
+// test and reports which stressors and options are expected to work.
+func runDoctor(args []string) {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	path := flags.String("path", ".", "Directory the storage stressor will write to")
+	flags.Parse(args)
+
+	checks := doctor.Run(*path)
+
+	term.Println("Host checks:")
+	failed := false
+	for _, c := range checks {
+		term.Printf("  [%-4s] %s: %s\n", c.Status, i18n.T(c.Name), c.Detail)
+		failed = failed || c.Status == doctor.Fail
+	}
+
+	// Summarize by stressor/option so the limitations are easy to act on
+	var limited []string
+	seen := make(map[string]bool)
+	for _, c := range checks {
+		if c.Status == doctor.OK {
+			continue
+		}
+		for _, affected := range c.Affects {
+			if !seen[affected] {
+				seen[affected] = true
+				limited = append(limited, affected)
+			}
+		}
+	}
+	term.Println()
+	if len(limited) == 0 {
+		term.Println("All stressors and options are expected to work on this host.")
+	} else {
+		term.Printf("May be limited or unavailable: %s\n", strings.Join(limited, ", "))
+	}
+
+	if failed {
+		os.Exit(exitVerificationFailure)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/storage"
+)
+
+// Values of --drop-caches.
+const (
+	dropCachesBefore        = "before"
+	dropCachesBetweenPhases = "between-phases"
+)
+
+// cacheDropper drops the page cache for --drop-caches, so that storage reads
+// measure the device rather than data cached by earlier writes. Without the
+// privileges to drop it, it warns once and the run goes on with the cache.
+type cacheDropper struct {
+	mu     sync.Mutex
+	failed bool
+}
+
+// drop drops the page cache and reports whether it did.
+func (d *cacheDropper) drop() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.failed {
+		return false
+	}
+	if err := storage.DropPageCache(); err != nil {
+		d.failed = true
+		term.Eprintf("Warning: Cannot drop the page cache: %v; storage reads may be served from the cache\n", err)
+		return false
+	}
+	return true
+}
+
+// observe is the event bus subscriber for --drop-caches between-phases. It drops
+// the page cache at the start of every --pattern step after the first, which
+// starts right after the drop before the load.
+func (d *cacheDropper) observe(e events.Event) {
+	if e.Type != events.PhaseStarted || e.Fields["step"] == 1 {
+		return
+	}
+	if d.drop() {
+		term.Printf("Dropped the page cache before %s\n", e.Message)
+	}
+}
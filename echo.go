@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/utkamioka/stress-go/pkg/network"
+)
+
+// defaultEchoAddr is where "stress-go echo" listens unless --listen is given.
+const defaultEchoAddr = ":9000"
+
+// runEcho handles "stress-go echo": it serves the echo server that a --network
+// load on another host can use as its --network-target, until interrupted.
+func runEcho(args []string) {
+	flags := flag.NewFlagSet("echo", flag.ExitOnError)
+	listen := flags.String("listen", defaultEchoAddr, "Address to listen on (TCP and UDP)")
+	flags.Parse(args)
+
+	server, err := network.Listen(*listen)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitStartupFailure)
+	}
+	term.Printf("[Echo] Listening on %s (TCP and UDP)\n", server.Addr())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	<-sigChan
+	term.Println("\nStopping echo server...")
+	server.Close()
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/grafana"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// printEvent prints run state changes to the console.
+func printEvent(e events.Event) {
+	switch e.Type {
+	case events.RunStarted:
+		term.Printf("Starting stress test...\n")
+		term.Printf("Duration: %v\n", e.Fields["duration"])
+		settings, _ := e.Fields["settings"].([]string)
+		for _, setting := range settings {
+			term.Println(setting)
+		}
+		term.Println()
+	case events.RunStopping:
+		term.Printf("%s. Stopping stress test...\n", e.Message)
+	case events.WatchdogTripped:
+		term.Printf("[Watchdog] Abort condition met: %s. Stopping stress test...\n", e.Message)
+	case events.StressorFailed:
+		term.Eprintf("[%s] Error: %s\n", e.Stressor, e.Message)
+	case events.DeadlineChanged:
+		term.Printf("[Deadline] %s\n", e.Message)
+	case events.RunControlled:
+		term.Printf("[Control] %s\n", e.Message)
+	case events.PhaseStarted:
+		term.Printf("[Pattern] %s\n", e.Message)
+	case events.RunFinished:
+		term.Println(e.Message)
+	}
+}
+
+// eventQueueSize is the number of events an asynchronous subscriber holds while
+// its endpoint is slow; further events are dropped.
+const eventQueueSize = 64
+
+// drainTimeout is how long the end of the run waits for the events an
+// asynchronous subscriber still holds.
+const drainTimeout = 15 * time.Second
+
+// deliverAsync returns a subscriber that hands the events to fn on a goroutine
+// of its own, in order, so that a slow or unreachable endpoint never holds up
+// the bus and with it the watchdog and the shutdown. Events that find the queue
+// full are dropped with a warning. The end of the run waits up to drainTimeout
+// for the queued events, so that the final one is not lost on exit.
+//
+// what names the deliveries in the warnings, e.g. "notifications".
+func deliverAsync(what string, fn func(events.Event)) func(events.Event) {
+	queue := make(chan events.Event, eventQueueSize)
+	finished := make(chan struct{})
+	go func() {
+		for e := range queue {
+			fn(e)
+			if e.Type == events.RunFinished {
+				close(finished)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func(e events.Event) {
+		if e.Type != events.RunFinished {
+			select {
+			case queue <- e:
+			default:
+				term.Eprintf("Warning: %s are falling behind, dropping the %s event\n", i18n.T(what), e.Type)
+			}
+			return
+		}
+		once.Do(func() {
+			timer := time.NewTimer(drainTimeout)
+			defer timer.Stop()
+			select {
+			case queue <- e:
+			case <-timer.C:
+			}
+			select {
+			case <-finished:
+			case <-timer.C:
+				term.Eprintf("Warning: Gave up waiting for %s to be sent\n", i18n.T(what))
+			}
+		})
+	}
+}
+
+// annotateEvents returns a subscriber that marks the run and each stressor as
+// Grafana annotations. Failures are reported but never interrupt the stress test.
+// It blocks on Grafana and is subscribed through deliverAsync.
+func annotateEvents(client *grafana.Client) func(events.Event) {
+	var mu sync.Mutex
+	ids := make(map[string]int64)
+	// The node, pod and instance tags tell apart the annotations of many pods and
+	// cloud instances on one dashboard
+	var envTags []string
+
+	start := func(key, text string, tags ...string) {
+		id, err := client.Start(text, append(tags, envTags...)...)
+		if err != nil {
+			term.Eprintf("Warning: Failed to create Grafana annotation: %v\n", err)
+			return
+		}
+		mu.Lock()
+		ids[key] = id
+		mu.Unlock()
+	}
+	end := func(key string) {
+		mu.Lock()
+		id, ok := ids[key]
+		delete(ids, key)
+		mu.Unlock()
+		if !ok {
+			return
+		}
+		if err := client.End(id); err != nil {
+			term.Eprintf("Warning: Failed to update Grafana annotation: %v\n", err)
+		}
+	}
+
+	return func(e events.Event) {
+		switch e.Type {
+		case events.RunStarted:
+			environment, _ := e.Fields["environment"].(map[string]string)
+			for _, key := range []string{"node", "pod", "instance_type", "lifecycle"} {
+				if value := environment[key]; value != "" {
+					envTags = append(envTags, key+":"+value)
+				}
+			}
+			settings, _ := e.Fields["settings"].([]string)
+			start("", "stress-go run: "+strings.Join(settings, ", "), "stress-go", "run")
+		case events.RunFinished:
+			end("")
+		case events.StressorStarted:
+			start(e.Stressor, "stress-go "+e.Stressor+" load", "stress-go", "phase", strings.ToLower(e.Stressor))
+		case events.StressorStopped:
+			end(e.Stressor)
+		case events.PhaseStarted:
+			start("phase", "stress-go "+e.Message, "stress-go", "step")
+		case events.PhaseFinished:
+			end("phase")
+		}
+	}
+}
+
+// publishAdjustments returns a sample listener that publishes an event whenever a
+// stressor's target differs from its previous sample.
+func publishAdjustments(bus *events.Bus) func(metrics.Sample) {
+	var mu sync.Mutex
+	targets := make(map[string]float64)
+
+	return func(s metrics.Sample) {
+		mu.Lock()
+		previous, seen := targets[s.Stressor]
+		targets[s.Stressor] = s.Target
+		mu.Unlock()
+
+		if seen && previous != s.Target {
+			bus.Publish(events.Event{
+				Type:     events.StressorAdjusted,
+				Stressor: s.Stressor,
+				Message:  i18n.Sprintf("Target changed from %s to %s", metrics.FormatValue(s.Unit, previous), metrics.FormatValue(s.Unit, s.Target)),
+				Fields:   map[string]any{"unit": s.Unit, "target": s.Target},
+			})
+		}
+	}
+}
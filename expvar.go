@@ -0,0 +1,59 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"runtime"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// expvarName is the variable under which the run counters are published.
+const expvarName = "stress_go"
+
+// registerExpvar publishes the internal counters of the run as the stress_go
+// variable and serves every published variable under /debug/vars, for debug
+// tooling that does not speak Prometheus. It must be called once per process.
+func registerExpvar(mux *http.ServeMux, registry *stressor.Registry, recorder *metrics.Recorder, supervisor *stressorSupervisor) {
+	expvar.Publish(expvarName, expvar.Func(func() any {
+		return runCounters(registry, recorder, supervisor)
+	}))
+	mux.Handle("GET /debug/vars", expvar.Handler())
+}
+
+// runCounters returns the running totals of each stressor with its current
+// target and achieved load, the errors seen so far and the number of goroutines.
+func runCounters(registry *stressor.Registry, recorder *metrics.Recorder, supervisor *stressorSupervisor) map[string]any {
+	counts := recorder.Counts()
+	stressors := make(map[string]map[string]any)
+	for _, s := range registry.Stressors() {
+		stats := s.Stats()
+		entry := map[string]any{
+			"unit":     stats.Unit,
+			"target":   stats.Target,
+			"achieved": stats.Achieved,
+		}
+		for name, value := range counts[s.Name()] {
+			entry[name] = value
+		}
+		stressors[s.Name()] = entry
+	}
+
+	var mismatches int64
+	for _, v := range recorder.Verifications() {
+		mismatches += v.Failures
+	}
+	failures := make(map[string]string)
+	for _, f := range supervisor.Failures() {
+		failures[f.name] = f.err.Error()
+	}
+	return map[string]any{
+		"stressors": stressors,
+		"errors": map[string]any{
+			"stressor_failures":     failures,
+			"verification_failures": mismatches,
+		},
+		"goroutines": runtime.NumGoroutine(),
+	}
+}
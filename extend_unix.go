@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyExtend relays SIGUSR2, which changes the remaining run time, to c.
+func notifyExtend(c chan<- os.Signal) bool {
+	signal.Notify(c, syscall.SIGUSR2)
+	return true
+}
@@ -0,0 +1,8 @@
+package main
+
+import "os"
+
+// notifyExtend reports that run time changes by signal are unavailable; Windows has no SIGUSR2.
+func notifyExtend(c chan<- os.Signal) bool {
+	return false
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// hostFacts are the values about this host that option templates can refer to,
+// so that one set of options scales to hosts of different sizes.
+type hostFacts struct {
+	Hostname string
+	// Cores is the number of logical CPUs, UsableCores what cgroup quotas and
+	// cpusets leave of them.
+	Cores       int
+	UsableCores float64
+	// MemTotal and MemAvailable are in bytes.
+	MemTotal     int64
+	MemAvailable int64
+	OS           string
+	Arch         string
+}
+
+// readHostFacts gathers the facts of this host. Memory sizes that cannot be read
+// are left at 0.
+func readHostFacts() hostFacts {
+	hostname, _ := os.Hostname()
+	facts := hostFacts{
+		Hostname:    hostname,
+		Cores:       runtime.NumCPU(),
+		UsableCores: sysinfo.EffectiveCPUs(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+	}
+	if snapshot, err := sysinfo.Read(); err == nil {
+		facts.MemTotal = snapshot.MemoryTotal
+	}
+	if available, err := sysinfo.MemoryAvailable(); err == nil {
+		facts.MemAvailable = available
+	}
+	return facts
+}
+
+// number is the result of template arithmetic. It prints without an exponent so
+// that byte counts can be used as sizes.
+type number float64
+
+func (n number) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+// templateFuncs are the functions available in option templates.
+var templateFuncs = template.FuncMap{
+	"env": func(name string, fallback ...string) string {
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return strings.Join(fallback, "")
+	},
+	"add": arithmetic(func(a, b float64) float64 { return a + b }),
+	"sub": arithmetic(func(a, b float64) float64 { return a - b }),
+	"mul": arithmetic(func(a, b float64) float64 { return a * b }),
+	"div": func(a, b any) (number, error) {
+		x, err := toNumber(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toNumber(b)
+		if err != nil {
+			return 0, err
+		}
+		if y == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return number(x / y), nil
+	},
+	"int": func(v any) (int64, error) {
+		x, err := toNumber(v)
+		return int64(x), err
+	},
+	"size": func(v any) (string, error) {
+		x, err := toNumber(v)
+		return fmt.Sprintf("%dMiB", int64(math.Floor(x/(1024*1024)))), err
+	},
+}
+
+// arithmetic adapts op to the loosely typed arguments of templates.
+func arithmetic(op func(a, b float64) float64) func(a, b any) (number, error) {
+	return func(a, b any) (number, error) {
+		x, err := toNumber(a)
+		if err != nil {
+			return 0, err
+		}
+		y, err := toNumber(b)
+		if err != nil {
+			return 0, err
+		}
+		return number(op(x, y)), nil
+	}
+}
+
+// toNumber converts a template value, such as a fact, a constant or the output of
+// env, to a float.
+func toNumber(v any) (float64, error) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case number:
+		return float64(v), nil
+	case string:
+		x, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", v)
+		}
+		return x, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+// expandOptions substitutes the host facts and environment variables into the
+// options that hold Go templates, e.g. --cpu '{{div .Cores 2}}'. Options without
+// templates are returned unchanged.
+func expandOptions(args []string) ([]string, error) {
+	if !strings.Contains(strings.Join(args, " "), "{{") {
+		return args, nil
+	}
+	facts := readHostFacts()
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		if !strings.Contains(arg, "{{") {
+			expanded[i] = arg
+			continue
+		}
+		tmpl, err := template.New("option").Funcs(templateFuncs).Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %v", arg, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, facts); err != nil {
+			return nil, fmt.Errorf("cannot expand %q: %v", arg, err)
+		}
+		expanded[i] = buf.String()
+	}
+	return expanded, nil
+}
@@ -1,3 +1,3 @@
-module stress-go
+module github.com/utkamioka/stress-go
 
 go 1.24.6
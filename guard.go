@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// Safety guard parameters. A stressor holding a resource over its limit is
+// halved at most every guardSettle, the time the memory and storage loads take
+// to release what they hold, and grows back in small steps once the resource
+// is guardHysteresis below the limit, so that the load settles just under it.
+const (
+	guardDecrease   = 0.5
+	guardIncrease   = 0.05
+	guardMinimum    = 0.02
+	guardHysteresis = 0.05
+	guardSettle     = 2 * time.Second
+)
+
+// parseUsageLimit parses the share of a resource given to --max-mem or
+// --max-disk-fill, such as "90%", into a fraction; "" is no limit.
+func parseUsageLimit(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return 0, fmt.Errorf("%q is not a percentage between 0%% and 100%%", value)
+	}
+	return percent / 100, nil
+}
+
+// safetyGuard keeps the memory in use on the whole system under maxMem and the
+// filesystems the storage loads write to under maxDiskFill by backing off the
+// stressors holding them, whichever processes take the rest. The limits are
+// shares of the resource, or 0 for none; memory and storage name the stressors
+// that back off.
+type safetyGuard struct {
+	monitor     *monitor.Monitor
+	registry    *stressor.Registry
+	recorder    *metrics.Recorder
+	maxMem      float64
+	maxDiskFill float64
+	memory      []string
+	storage     []string
+
+	mu      sync.Mutex
+	factors map[string]float64
+}
+
+// newSafetyGuard guards the memory and storage loads of config, named jobs
+// included, with the limits of config.
+func newSafetyGuard(config Config, resources *monitor.Monitor, registry *stressor.Registry, recorder *metrics.Recorder) *safetyGuard {
+	g := &safetyGuard{monitor: resources, registry: registry, recorder: recorder,
+		maxMem: config.MaxMemUsed, maxDiskFill: config.MaxDiskFill,
+		memory: []string{"Memory"}, storage: []string{"Storage"}, factors: make(map[string]float64)}
+	for _, j := range config.Jobs {
+		switch j.kind {
+		case "memory":
+			g.memory = append(g.memory, j.name)
+		case "storage":
+			g.storage = append(g.storage, j.name)
+		}
+	}
+	return g
+}
+
+// loadDirs returns the directories the stressors of config write their files
+// in, for the monitor to sample the fill of their filesystems.
+func loadDirs(config Config, opts stressorOptions) []string {
+	var dirs []string
+	if config.Storage != "" {
+		dirs = append(dirs, storageDir(config))
+	}
+	if config.PageFault != "" {
+		dirs = append(dirs, cmp.Or(opts.pageFault.Dir, os.TempDir()))
+	}
+	if config.Sparse != "" {
+		dirs = append(dirs, cmp.Or(opts.sparse.Dir, os.TempDir()))
+	}
+	if config.FD != "" || config.Inodes != 0 {
+		dirs = append(dirs, cmp.Or(opts.files.Dir, os.TempDir()))
+	}
+	for _, j := range config.Jobs {
+		if j.kind == "storage" {
+			dirs = append(dirs, cmp.Or(j.dir, storageDir(config)))
+		}
+	}
+	return dirs
+}
+
+// guardState is how far the guard has backed off one stressor.
+type guardState struct {
+	factor  float64
+	changed time.Time
+}
+
+// run checks the limits on every sample of the monitor until ctx is done.
+// Stressors a scenario starts later are guarded from their first sample on.
+func (g *safetyGuard) run(ctx context.Context) {
+	states := make(map[string]*guardState)
+	for snapshot := range g.monitor.Subscribe(ctx) {
+		for _, s := range g.registry.Stressors() {
+			c, ok := s.(stressor.Controllable)
+			if !ok {
+				continue
+			}
+			name := s.Name()
+			state := states[name]
+			if state == nil {
+				state = &guardState{factor: 1}
+				states[name] = state
+			}
+			switch {
+			case g.maxMem > 0 && slices.Contains(g.memory, name):
+				if used, ok := snapshot.MemoryUsed(); ok {
+					g.check(c, name, state, used, g.maxMem, snapshot.Time, func(backingOff bool) {
+						if backingOff {
+							g.recorder.Logf("Guard", "Memory use %.1f%% exceeds --max-mem %.0f%%: backing off %s", used*100, g.maxMem*100, name)
+							g.recorder.Flag(name, i18n.Sprintf("backed off to keep memory use at or below %.0f%%", g.maxMem*100))
+						} else {
+							g.recorder.Logf("Guard", "Memory use %.1f%% back under --max-mem %.0f%%: restoring the full load of %s", used*100, g.maxMem*100, name)
+						}
+					})
+				}
+			case g.maxDiskFill > 0 && slices.Contains(g.storage, name):
+				if s.Stats().Dir == "" {
+					continue
+				}
+				// The monitor watches the directory the stress files' directory is in
+				dir := filepath.Dir(s.Stats().Dir)
+				disk, err := g.monitor.DiskSpace(dir)
+				if err != nil || disk.Total <= 0 {
+					continue
+				}
+				used := float64(disk.Used()) / float64(disk.Total)
+				g.check(c, name, state, used, g.maxDiskFill, snapshot.Time, func(backingOff bool) {
+					if backingOff {
+						g.recorder.Logf("Guard", "Filesystem of %s %.1f%% full, over --max-disk-fill %.0f%%: backing off %s", dir, used*100, g.maxDiskFill*100, name)
+						g.recorder.Flag(name, i18n.Sprintf("backed off to keep the filesystem at or below %.0f%% full", g.maxDiskFill*100))
+					} else {
+						g.recorder.Logf("Guard", "Filesystem of %s %.1f%% full, back under --max-disk-fill %.0f%%: restoring the full load of %s", dir, used*100, g.maxDiskFill*100, name)
+					}
+				})
+			}
+		}
+	}
+}
+
+// check backs c off while used exceeds limit and lets it grow back once used is
+// well under it, calling report when the backing off starts and when it ends.
+func (g *safetyGuard) check(c stressor.Controllable, name string, state *guardState, used, limit float64, now time.Time, report func(backingOff bool)) {
+	previous := state.factor
+	switch {
+	case used > limit && now.Sub(state.changed) >= guardSettle:
+		if state.factor *= guardDecrease; state.factor < guardMinimum {
+			state.factor = 0
+		}
+	case used < limit-guardHysteresis && state.factor < 1:
+		state.factor = min(state.factor+guardIncrease, 1)
+	}
+	if state.factor == previous {
+		return
+	}
+	state.changed = now
+	c.SetGuard(state.factor)
+	g.mu.Lock()
+	g.factors[name] = state.factor
+	g.mu.Unlock()
+	if previous == 1 {
+		report(true)
+	} else if state.factor == 1 {
+		report(false)
+	}
+}
+
+// factor returns the share of its load the guard currently lets the stressor
+// named name apply; 1 is its full load. A nil guard never backs off.
+func (g *safetyGuard) factor(name string) float64 {
+	if g == nil {
+		return 1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if factor, ok := g.factors[name]; ok {
+		return factor
+	}
+	return 1
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+func TestParseUsageLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"", 0, true},
+		{"90%", 0.9, true},
+		{"95", 0.95, true},
+		{"0%", 0, false},
+		{"100%", 0, false},
+		{"lots", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseUsageLimit(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseUsageLimit(%q) = %v, %v, want %v ok=%v", tt.value, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSafetyGuardCheck(t *testing.T) {
+	g := &safetyGuard{factors: make(map[string]float64)}
+	c := stressor.NewMemory(memory.Options{Size: 1 << 20}).(stressor.Controllable)
+	state := &guardState{factor: 1}
+	var reports []bool
+	report := func(backingOff bool) { reports = append(reports, backingOff) }
+	start := time.Now()
+
+	steps := []struct {
+		after time.Duration
+		used  float64
+		want  float64
+	}{
+		{0, 0.95, 0.5},                          // over the limit: halved
+		{time.Second, 0.95, 0.5},                // still settling
+		{guardSettle, 0.95, 0.25},               // halved again
+		{guardSettle + time.Second, 0.88, 0.25}, // under the limit, within the hysteresis
+		{guardSettle + 2*time.Second, 0.5, 0.3},
+	}
+	for _, s := range steps {
+		g.check(c, "Memory", state, s.used, 0.9, start.Add(s.after), report)
+		if diff := state.factor - s.want; diff > 1e-9 || diff < -1e-9 {
+			t.Fatalf("after %v at %.0f%%: factor = %v, want %v", s.after, s.used*100, state.factor, s.want)
+		}
+	}
+	for now := start.Add(time.Minute); state.factor < 1; now = now.Add(time.Second) {
+		g.check(c, "Memory", state, 0.5, 0.9, now, report)
+	}
+	if len(reports) != 2 || !reports[0] || reports[1] {
+		t.Errorf("reports = %v, want backing off and then restored", reports)
+	}
+	if got := g.factor("Memory"); got != 1 {
+		t.Errorf("factor(Memory) = %v, want 1", got)
+	}
+	if got := (*safetyGuard)(nil).factor("Memory"); got != 1 {
+		t.Errorf("nil guard factor = %v, want 1", got)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+)
+
+// States of the run and of each stressor reported by the health endpoints.
+const (
+	healthStarting = "starting"
+	healthRunning  = "running"
+	healthStopping = "stopping"
+	healthFinished = "finished"
+	healthStopped  = "stopped"
+	healthFailed   = "failed"
+)
+
+// runHealth tracks the run and stressor states from the event bus for the
+// /healthz and /readyz endpoints.
+type runHealth struct {
+	mu        sync.Mutex
+	state     string
+	stressors map[string]string
+}
+
+// healthReport is the JSON body returned by the health endpoints.
+type healthReport struct {
+	Status    string            `json:"status"`
+	State     string            `json:"state"`
+	Stressors map[string]string `json:"stressors"`
+}
+
+func newRunHealth() *runHealth {
+	return &runHealth{state: healthStarting, stressors: make(map[string]string)}
+}
+
+// expect registers the stressors the run is configured with; the run is ready
+// only once all of them are running.
+func (h *runHealth) expect(names []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, name := range names {
+		if _, ok := h.stressors[name]; !ok {
+			h.stressors[name] = healthStarting
+		}
+	}
+}
+
+// observe updates the states from a run event.
+func (h *runHealth) observe(e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch e.Type {
+	case events.StressorStarted:
+		h.stressors[e.Stressor] = healthRunning
+		if h.state == healthStarting {
+			h.state = healthRunning
+		}
+	case events.StressorFailed:
+		h.stressors[e.Stressor] = healthFailed
+	case events.StressorStopped:
+		if h.stressors[e.Stressor] != healthFailed {
+			h.stressors[e.Stressor] = healthStopped
+		}
+	case events.RunStopping, events.WatchdogTripped:
+		h.state = healthStopping
+	case events.RunFinished:
+		h.state = healthFinished
+	}
+}
+
+// live reports whether the process should be left running: it is not once a
+// stressor has failed, so that an orchestrator restarts the wedged run.
+func (h *runHealth) live() bool {
+	for _, state := range h.stressors {
+		if state == healthFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// ready reports whether the run is applying load as configured: every stressor
+// has started, none has failed, and the run is not stopping.
+func (h *runHealth) ready() bool {
+	if h.state != healthRunning {
+		return false
+	}
+	for _, state := range h.stressors {
+		if state == healthStarting || state == healthFailed {
+			return false
+		}
+	}
+	return true
+}
+
+// register adds /healthz (liveness) and /readyz (readiness) to mux.
+func (h *runHealth) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) { h.respond(w, h.live) })
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) { h.respond(w, h.ready) })
+}
+
+// snapshot returns the run state and a copy of the stressor states.
+func (h *runHealth) snapshot() (string, map[string]string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stressors := make(map[string]string, len(h.stressors))
+	for name, state := range h.stressors {
+		stressors[name] = state
+	}
+	return h.state, stressors
+}
+
+// respond writes the current states with 200 when check passes and 503 otherwise.
+func (h *runHealth) respond(w http.ResponseWriter, check func() bool) {
+	h.mu.Lock()
+	ok := check()
+	h.mu.Unlock()
+	report := healthReport{Status: "ok"}
+	report.State, report.Stressors = h.snapshot()
+
+	code := http.StatusOK
+	if !ok {
+		report.Status = "unavailable"
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
+
+// startHTTPServer listens on addr and serves handler in the background for the
+// rest of the process. It exits with a configuration error if addr cannot be used.
+func startHTTPServer(addr string, handler http.Handler) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		term.Eprintf("Error: Cannot listen on %s: %v\n", addr, err)
+		os.Exit(exitConfigError)
+	}
+	term.Printf("Serving HTTP endpoints on http://%s\n", listener.Addr())
+	go http.Serve(listener, handler)
+}
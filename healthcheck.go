@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// runHealthcheck implements the healthcheck subcommand, which queries the health
+// endpoint of a run on this host and exits 0 when it is healthy. Container images
+// without curl use it as their HEALTHCHECK command.
+func runHealthcheck(args []string) {
+	flags := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	addr := flags.String("addr", "127.0.0.1:8080", "Address the run serves its endpoints on (its --listen)")
+	ready := flags.Bool("ready", false, "Check /readyz (load applied as configured) instead of /healthz")
+	timeout := flags.Duration("timeout", 3*time.Second, "Time to wait for the response")
+	flags.Parse(args)
+
+	path := "/healthz"
+	if *ready {
+		path = "/readyz"
+	}
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get("http://" + localAddr(*addr) + path)
+	if err != nil {
+		term.Eprintf("Error: Health check failed: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	term.Println(strings.TrimSpace(string(body)))
+	if resp.StatusCode != http.StatusOK {
+		os.Exit(exitFailure)
+	}
+}
+
+// localAddr turns a listen address into one to connect to on this host: an empty
+// or wildcard host becomes the loopback address.
+func localAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
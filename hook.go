@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+)
+
+// defaultHookTimeout is how long a hook command may run unless --hook-timeout
+// is given.
+const defaultHookTimeout = 10 * time.Minute
+
+// hookWaitDelay is how long a hook that has exited or been killed may keep its
+// output open, e.g. through a background child, before it is abandoned.
+const hookWaitDelay = 5 * time.Second
+
+// runHooks runs the user's shell commands around the run: --pre-cmd before the
+// load starts, --phase-cmd at the start of every --pattern step and --post-cmd
+// once the run has finished. Each command gets the settings and status of the run
+// in STRESS_GO_* environment variables.
+type runHooks struct {
+	pre, phase, post string
+	// timeout bounds every command, so that a hanging one neither holds back
+	// the load nor keeps the run from exiting
+	timeout time.Duration
+	// env describes the run to every command
+	env []string
+	// running tracks the phase commands, which run in the background so that
+	// they never hold back the load
+	running sync.WaitGroup
+}
+
+func newRunHooks(config Config, settings []string) *runHooks {
+	env := []string{
+		"STRESS_GO_DURATION=" + config.Timeout.String(),
+		"STRESS_GO_SETTINGS=" + strings.Join(settings, "; "),
+	}
+	if config.SummaryJSON != "" {
+		env = append(env, "STRESS_GO_SUMMARY_JSON="+config.SummaryJSON)
+	}
+	return &runHooks{pre: config.PreCmd, phase: config.PhaseCmd, post: config.PostCmd, timeout: config.HookTimeout, env: env}
+}
+
+// runPre runs --pre-cmd and returns its error, which keeps the load from starting.
+func (h *runHooks) runPre() error {
+	if h.pre == "" {
+		return nil
+	}
+	return h.run("pre", h.pre, nil)
+}
+
+// observe is the event bus subscriber that runs the phase and post commands. It
+// is subscribed after the summary writer so that --post-cmd can read the summary.
+func (h *runHooks) observe(e events.Event) {
+	switch e.Type {
+	case events.PhaseStarted:
+		if h.phase == "" {
+			return
+		}
+		env := []string{
+			"STRESS_GO_PHASE=" + e.Message,
+			fmt.Sprintf("STRESS_GO_STEP=%v", e.Fields["step"]),
+			fmt.Sprintf("STRESS_GO_LEVEL=%v", e.Fields["level"]),
+		}
+		h.running.Add(1)
+		go func() {
+			defer h.running.Done()
+			if err := h.run("phase", h.phase, env); err != nil {
+				term.Eprintf("Warning: --phase-cmd failed: %v\n", err)
+			}
+		}()
+	case events.RunFinished:
+		// The phase commands end within their timeout
+		h.running.Wait()
+		if h.post == "" {
+			return
+		}
+		env := []string{
+			fmt.Sprintf("STRESS_GO_EXIT_CODE=%v", e.Fields["exit_code"]),
+			"STRESS_GO_MESSAGE=" + e.Message,
+		}
+		if err := h.run("post", h.post, env); err != nil {
+			term.Eprintf("Warning: --post-cmd failed: %v\n", err)
+		}
+	}
+}
+
+// run runs command with the run's environment, the hook name and extra variables,
+// passing its output through to the console. The command is killed once the
+// hook timeout has passed.
+func (h *runHooks) run(hook, command string, extra []string) error {
+	term.Printf("[Hook] Running %s command: %s\n", hook, command)
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	cmd := shellCommand(ctx, command)
+	cmd.Env = append(append(os.Environ(), h.env...), "STRESS_GO_HOOK="+hook)
+	cmd.Env = append(cmd.Env, extra...)
+	cmd.Stdout = term
+	cmd.Stderr = term.ErrorWriter()
+	cmd.WaitDelay = hookWaitDelay
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v", h.timeout)
+	}
+	return err
+}
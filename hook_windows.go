@@ -0,0 +1,16 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shellCommand returns a command that runs command with cmd.exe and is killed
+// when ctx is done. The command line is passed as is, since cmd.exe does not
+// follow the usual argument quoting rules.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "cmd.exe")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `cmd.exe /C ` + command}
+	return cmd
+}
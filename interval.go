@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// workRest is the global on/off schedule of --interval: every stressor runs for
+// work, then pauses for rest, repeatedly until the end of the run.
+type workRest struct {
+	work time.Duration
+	rest time.Duration
+	// retainMemory keeps the memory stressors' allocation through the rest
+	// periods instead of releasing it.
+	retainMemory bool
+}
+
+// parseInterval parses an --interval value such as "work=50s,rest=10s,memory=retain".
+func parseInterval(spec string) (*workRest, error) {
+	w := &workRest{}
+	for _, param := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid interval parameter %q (expected key=value)", param)
+		}
+		switch key {
+		case "work", "rest":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid interval %s time %q", key, value)
+			}
+			if key == "work" {
+				w.work = d
+			} else {
+				w.rest = d
+			}
+		case "memory":
+			if value != "retain" && value != "release" {
+				return nil, fmt.Errorf("invalid interval memory %q (retain or release)", value)
+			}
+			w.retainMemory = value == "retain"
+		default:
+			return nil, fmt.Errorf("unknown interval parameter %q", key)
+		}
+	}
+	if w.work == 0 || w.rest == 0 {
+		return nil, fmt.Errorf("interval needs both work and rest times (e.g., work=50s,rest=10s)")
+	}
+	return w, nil
+}
+
+// String returns the schedule as "work 50s, rest 10s", noting retained memory.
+func (w *workRest) String() string {
+	s := i18n.Sprintf("work %v, rest %v", w.work, w.rest)
+	if w.retainMemory {
+		s += i18n.T(" (memory retained while resting)")
+	}
+	return s
+}
+
+// startInterval pauses every controllable stressor for each rest period of w
+// and resumes it for each work period, in the background until ctx is done.
+// Stressors named in memory keep their allocation while resting if w retains it.
+// A resumed run passes the time it had been going as offset to continue the
+// cycle where it left off.
+func startInterval(ctx context.Context, w *workRest, offset time.Duration, registry *stressor.Registry, memory []string, bus *events.Bus) {
+	var targets []stressor.Controllable
+	for _, s := range registry.Stressors() {
+		c, ok := s.(stressor.Controllable)
+		if !ok || (w.retainMemory && slices.Contains(memory, s.Name())) {
+			continue
+		}
+		targets = append(targets, c)
+	}
+	pause := func(rest time.Duration) {
+		for _, t := range targets {
+			t.Pause()
+		}
+		bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Rest period: load paused for %v", rest)})
+	}
+
+	position := offset % (w.work + w.rest)
+	if position >= w.work {
+		pause((w.work + w.rest - position).Round(time.Second))
+	}
+	go func() {
+		for {
+			if position < w.work {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(w.work - position):
+				}
+				pause(w.rest)
+				position = w.work
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(w.work + w.rest - position):
+			}
+			for _, t := range targets {
+				t.Resume()
+			}
+			bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Work period: load resumed for %v", w.work)})
+			position = 0
+		}
+	}()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/plugin"
+	"github.com/utkamioka/stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// job is a named instance of a built-in stressor given with --job. Several jobs of
+// one kind can run side by side, e.g. storage loads on two mounts, and each shows
+// up under its own name in the progress, reports, metrics and control API.
+type job struct {
+	name string
+	kind string // "cpu", "memory" or "storage"
+
+	// cores is the CPU job's core count (0 = all cores), size the memory or
+	// storage job's target
+	cores int
+	size  bytesize.Spec
+	// dir is where the storage job writes, max the hard cap of a memory or
+	// storage job in bytes (0 = none)
+	dir    string
+	max    int64
+	verify bool
+	// method overrides --cpu-method for a CPU job, if set
+	method cpu.Method
+	// content overrides --memory-content for a memory job, if set
+	content memory.Content
+	// mode overrides --memory-mode for a memory job, if set
+	mode memory.Mode
+	// sync overrides --storage-sync for a storage job, if set
+	sync storage.SyncMode
+	// io overrides --io-mode for a storage job, if set
+	io storage.IOMode
+}
+
+// jobKinds are the built-in stressors a job can run.
+var jobKinds = []string{"cpu", "memory", "storage"}
+
+// jobOptions are the options each kind of job accepts.
+var jobOptions = map[string][]string{
+	"cpu":     {"cores", "verify", "method"},
+	"memory":  {"size", "max", "verify", "content", "mode"},
+	"storage": {"size", "dir", "max", "verify", "sync", "io"},
+}
+
+// parseJobs parses the --job values, such as "logs=storage:size=10GB,dir=/mnt/logs".
+// Job names must differ from each other and from the built-in and plugin stressors,
+// since they address the jobs in --stressor-timeout and the control API.
+func parseJobs(specs []string, plugins []plugin.Options) ([]job, error) {
+	taken := append(slices.Clone(jobKinds), "gpu", "pagefault", "sparse", "network", "procs", "files", "scheduler")
+	for _, p := range plugins {
+		taken = append(taken, strings.ToLower(p.Name))
+	}
+
+	var jobs []job
+	for _, spec := range specs {
+		j, err := parseJob(spec)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(taken, strings.ToLower(j.name)) {
+			return nil, fmt.Errorf("invalid job %q: the name %s is already used", spec, j.name)
+		}
+		taken = append(taken, strings.ToLower(j.name))
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// parseJob parses one --job value: name=kind, optionally followed by a colon and
+// comma-separated options.
+func parseJob(spec string) (job, error) {
+	name, rest, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " /") {
+		return job{}, fmt.Errorf("invalid job %q: expected name=kind[:options]", spec)
+	}
+	kind, options, _ := strings.Cut(rest, ":")
+	j := job{name: name, kind: strings.ToLower(strings.TrimSpace(kind))}
+	if !slices.Contains(jobKinds, j.kind) {
+		return job{}, fmt.Errorf("invalid job %q: unknown kind %q (cpu, memory or storage)", spec, kind)
+	}
+
+	for _, option := range splitList(options) {
+		key, value, _ := strings.Cut(option, "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if !slices.Contains(jobOptions[j.kind], key) {
+			return job{}, fmt.Errorf("invalid job %q: unknown option %q for %s (%s)",
+				spec, key, j.kind, strings.Join(jobOptions[j.kind], ", "))
+		}
+		var err error
+		switch key {
+		case "cores":
+			j.cores, err = strconv.Atoi(value)
+			if err == nil && j.cores < 0 {
+				err = fmt.Errorf("must not be negative")
+			}
+		case "size":
+			j.size, err = bytesize.Parse(value)
+		case "dir":
+			j.dir = value
+		case "max":
+			j.max, err = bytesize.ParseAbsolute(value)
+		case "verify":
+			j.verify = value == "" || value == "true"
+		case "method":
+			j.method = cpu.Method(value)
+			err = j.method.Validate()
+		case "content":
+			j.content = memory.Content(value)
+			err = j.content.Validate()
+		case "mode":
+			j.mode = memory.Mode(value)
+			err = j.mode.Validate()
+		case "sync":
+			j.sync = storage.SyncMode(value)
+			err = j.sync.Validate()
+		case "io":
+			j.io = storage.IOMode(value)
+			err = j.io.Validate()
+		}
+		if err != nil {
+			return job{}, fmt.Errorf("invalid job %q: invalid %s: %v", spec, key, err)
+		}
+	}
+	if j.kind != "cpu" && j.size.Bytes == 0 && j.size.Percent == 0 {
+		return job{}, fmt.Errorf("invalid job %q: %s jobs need a size", spec, j.kind)
+	}
+	return j, nil
+}
+
+// hasJob reports whether the run has a job of kind.
+func hasJob(config Config, kind string) bool {
+	return slices.ContainsFunc(config.Jobs, func(j job) bool { return j.kind == kind })
+}
+
+// verified reports whether the job checks its results, either by its own verify
+// option or by the --*-verify option of its kind.
+func (j job) verified(config Config) bool {
+	switch j.kind {
+	case "cpu":
+		return j.verify || config.CPUVerify
+	case "memory":
+		return j.verify || config.MemoryVerify
+	default:
+		return j.verify || config.StorageVerify
+	}
+}
+
+// minLimit returns the tighter of two byte limits, where 0 means unlimited.
+func minLimit(current, limit int64) int64 {
+	if current == 0 || (limit > 0 && limit < current) {
+		return limit
+	}
+	return current
+}
+
+// describe returns the settings line of the job.
+func (j job) describe() string {
+	switch {
+	case j.kind == "cpu" && j.cores == 0:
+		return i18n.Sprintf("Job %s: CPU load on all cores", j.name)
+	case j.kind == "cpu":
+		return i18n.Sprintf("Job %s: CPU load on %d cores", j.name, j.cores)
+	case j.kind == "memory":
+		return i18n.Sprintf("Job %s: memory load of %s", j.name, describeSize(j.size, i18n.T("free memory")))
+	case j.dir != "":
+		return i18n.Sprintf("Job %s: storage load of %s in %s", j.name, describeSize(j.size, i18n.T("free disk space")), j.dir)
+	default:
+		return i18n.Sprintf("Job %s: storage load of %s", j.name, describeSize(j.size, i18n.T("free disk space")))
+	}
+}
+
+// stressor creates the job's stressor from the options of the run, which carry
+// the recorder, seed, calibration and limits shared by all stressors.
+func (j job) stressor(config Config, opts stressorOptions) stressor.Stressor {
+	switch j.kind {
+	case "cpu":
+		o := opts.cpu
+		o.Name, o.Cores, o.SoakTemperature = j.name, j.cores, 0
+		o.Verify = j.verified(config)
+		if j.method != "" {
+			o.Method = j.method
+		}
+		return stressor.NewCPU(o)
+	case "memory":
+		o := opts.memory
+		o.Name, o.Size, o.Percent = j.name, j.size.Bytes, j.size.Percent
+		o.Verify = j.verified(config)
+		if j.content != "" {
+			o.Content = j.content
+		}
+		if j.mode != "" {
+			o.Mode = j.mode
+		}
+		// The job's max caps the job alone; the --max-memory budget still applies
+		o.MaxBytes = minLimit(o.MaxBytes, j.max)
+		return stressor.NewMemory(o)
+	default:
+		o := opts.storage
+		o.Name, o.Size, o.Percent = j.name, j.size.Bytes, j.size.Percent
+		if j.dir != "" {
+			o.Dir = j.dir
+		}
+		o.Verify = j.verified(config)
+		if j.sync != "" {
+			o.Sync = j.sync
+		}
+		if j.io != "" {
+			o.IOMode = j.io
+		}
+		o.MaxBytes = minLimit(o.MaxBytes, j.max)
+		return stressor.NewStorage(o)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/utkamioka/stress-go/pkg/plugin"
+)
+
+func TestParseJob(t *testing.T) {
+	tests := []struct {
+		spec string
+		want job
+		ok   bool
+	}{
+		{"spin=cpu", job{name: "spin", kind: "cpu"}, true},
+		{"spin=CPU:cores=2,verify,method=bignum", job{name: "spin", kind: "cpu", cores: 2, verify: true, method: "bignum"}, true},
+		{"llc=cpu:method=cache", job{name: "llc", kind: "cpu", method: "cache"}, true},
+		{"cache=memory:size=512MiB,max=1GiB,content=mixed", job{name: "cache", kind: "memory", max: 1 << 30, content: "mixed"}, true},
+		{"logs=storage:size=10GB,dir=/mnt/logs,sync=dsync,verify=false", job{name: "logs", kind: "storage", dir: "/mnt/logs", sync: "dsync"}, true},
+		{"half=memory:size=50%", job{name: "half", kind: "memory"}, true},
+		{"cpu", job{}, false},
+		{"=cpu", job{}, false},
+		{"a b=cpu", job{}, false},
+		{"a/b=cpu", job{}, false},
+		{"x=gpu", job{}, false},
+		{"x=cpu:cores=-1", job{}, false},
+		{"x=cpu:size=1GB", job{}, false},
+		{"x=cpu:method=quantum", job{}, false},
+		{"x=memory", job{}, false},
+		{"x=memory:size=1GB,max=50%", job{}, false},
+		{"x=memory:size=1GB,dir=/tmp", job{}, false},
+		{"x=storage:size=1GB,sync=never", job{}, false},
+		{"db=storage:size=1GB,io=randwrite", job{name: "db", kind: "storage", io: "randwrite"}, true},
+		{"x=storage:size=1GB,io=random", job{}, false},
+		{"hot=memory:size=1GB,mode=bandwidth", job{name: "hot", kind: "memory", mode: "bandwidth"}, true},
+		{"x=memory:size=1GB,mode=thrash", job{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseJob(tt.spec)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseJob(%q) = %v, want ok=%v", tt.spec, err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+			// The size itself is left to the bytesize tests
+			got.size.Input, got.size.Bytes, got.size.Percent = "", 0, 0
+			if got != tt.want {
+				t.Errorf("parseJob(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJobs(t *testing.T) {
+	plugins := []plugin.Options{{Name: "Fio"}}
+	tests := []struct {
+		name  string
+		specs []string
+		ok    bool
+	}{
+		{"distinct", []string{"a=cpu", "b=memory:size=1GB"}, true},
+		{"duplicate", []string{"a=cpu", "A=cpu"}, false},
+		{"built-in name", []string{"storage=storage:size=1GB"}, false},
+		{"later stressor name", []string{"sparse=cpu"}, false},
+		{"network stressor name", []string{"network=storage"}, false},
+		{"plugin name", []string{"fio=cpu"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseJobs(tt.specs, plugins)
+			if (err == nil) != tt.ok {
+				t.Errorf("parseJobs(%q) = %v, want ok=%v", tt.specs, err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// k8sManifest holds the values rendered into the generated manifests.
+type k8sManifest struct {
+	Name         string
+	Namespace    string
+	Image        string
+	Args         []string
+	NodeSelector map[string]string
+	Tolerations  []k8sToleration
+	Requests     map[string]string
+	Limits       map[string]string
+	Parallelism  int
+}
+
+// k8sToleration is one pod toleration; an empty key with operator Exists tolerates every taint.
+type k8sToleration struct {
+	Key      string
+	Operator string
+	Value    string
+	Effect   string
+}
+
+// The DaemonSet runs stress-go as an init container followed by a pause container,
+// so that each node is loaded once instead of the pod restarting the load forever.
+var k8sTemplates = map[string]string{
+	"job": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{q .Name}}
+  namespace: {{q .Namespace}}
+  labels:
+    app.kubernetes.io/name: stress-go
+spec:
+  parallelism: {{.Parallelism}}
+  completions: {{.Parallelism}}
+  backoffLimit: 0
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: stress-go
+    spec:
+      restartPolicy: Never
+{{- template "pod" .}}
+      containers:
+        - name: stress-go
+{{- template "container" .}}
+`,
+	"daemonset": `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: {{q .Name}}
+  namespace: {{q .Namespace}}
+  labels:
+    app.kubernetes.io/name: stress-go
+spec:
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: stress-go
+      stress-go/instance: {{q .Name}}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: stress-go
+        stress-go/instance: {{q .Name}}
+    spec:
+{{- template "pod" .}}
+      initContainers:
+        - name: stress-go
+{{- template "container" .}}
+      containers:
+        - name: pause
+          image: registry.k8s.io/pause:3.9
+`,
+}
+
+const k8sCommonTemplates = `
+{{- define "pod"}}
+{{- if .NodeSelector}}
+      nodeSelector:
+{{- range $key, $value := .NodeSelector}}
+        {{q $key}}: {{q $value}}
+{{- end}}
+{{- end}}
+{{- if .Tolerations}}
+      tolerations:
+{{- range .Tolerations}}
+        - operator: {{.Operator}}
+{{- if .Key}}
+          key: {{q .Key}}
+{{- end}}
+{{- if .Value}}
+          value: {{q .Value}}
+{{- end}}
+{{- if .Effect}}
+          effect: {{.Effect}}
+{{- end}}
+{{- end}}
+{{- end}}
+      volumes:
+        - name: tmp
+          emptyDir: {}
+{{- end}}
+{{- define "container"}}
+          image: {{q .Image}}
+          command: ["stress-go"]
+          env:
+            - name: NODE_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: spec.nodeName
+            - name: POD_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.name
+            - name: POD_NAMESPACE
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.namespace
+          args:
+{{- range .Args}}
+            - {{q .}}
+{{- end}}
+{{- if or .Requests .Limits}}
+          resources:
+{{- if .Requests}}
+            requests:
+{{- range $key, $value := .Requests}}
+              {{$key}}: {{q $value}}
+{{- end}}
+{{- end}}
+{{- if .Limits}}
+            limits:
+{{- range $key, $value := .Limits}}
+              {{$key}}: {{q $value}}
+{{- end}}
+{{- end}}
+{{- end}}
+          volumeMounts:
+            - name: tmp
+              mountPath: /tmp
+{{- end}}
+`
+
+// runK8s implements the k8s subcommand.
+func runK8s(args []string) {
+	if len(args) == 0 || args[0] != "gen" {
+		term.Eprintf("Error: unknown k8s command (expected: stress-go k8s gen)\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+	runK8sGen(args[1:])
+}
+
+// runK8sGen renders a Job or DaemonSet that runs stress-go with the options given
+// after -- and writes it to standard output.
+func runK8sGen(args []string) {
+	flags := flag.NewFlagSet("k8s gen", flag.ExitOnError)
+	mode := flags.String("mode", "job", "Workload to generate: job or daemonset")
+	name := flags.String("name", "stress-go", "Name of the workload")
+	namespace := flags.String("namespace", "default", "Namespace of the workload")
+	image := flags.String("image", "stress-go:latest", "stress-go container image")
+	nodeSelector := flags.String("node-selector", "", "Comma-separated node labels (key=value,...)")
+	tolerateAll := flags.Bool("tolerate-all", false, "Tolerate every taint, e.g. to load control-plane nodes")
+	cpuRequest := flags.String("cpu-request", "", "CPU request of the stress-go container (e.g., 2)")
+	cpuLimit := flags.String("cpu-limit", "", "CPU limit of the stress-go container")
+	memoryRequest := flags.String("memory-request", "", "Memory request of the stress-go container (e.g., 4Gi)")
+	memoryLimit := flags.String("memory-limit", "", "Memory limit of the stress-go container")
+	parallelism := flags.Int("parallelism", 1, "Number of pods run by the job")
+	var tolerations stringList
+	flags.Var(&tolerations, "toleration", "Tolerate a taint given as key[=value][:effect] (repeatable)")
+	flags.Parse(args)
+
+	source, ok := k8sTemplates[*mode]
+	if !ok {
+		term.Eprintf("Error: Invalid mode %q (expected job or daemonset)\n", *mode)
+		os.Exit(exitConfigError)
+	}
+	jobArgs := flags.Args()
+	if err := validateJobArgs(jobArgs); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if *parallelism < 1 {
+		term.Eprintf("Error: Invalid parallelism: %d\n", *parallelism)
+		os.Exit(exitConfigError)
+	}
+
+	m := k8sManifest{
+		Name:         *name,
+		Namespace:    *namespace,
+		Image:        *image,
+		Args:         jobArgs,
+		NodeSelector: make(map[string]string),
+		Requests:     make(map[string]string),
+		Limits:       make(map[string]string),
+		Parallelism:  *parallelism,
+	}
+	for _, term := range splitList(*nodeSelector) {
+		key, value, _ := strings.Cut(term, "=")
+		m.NodeSelector[key] = value
+	}
+	if *tolerateAll {
+		m.Tolerations = append(m.Tolerations, k8sToleration{Operator: "Exists"})
+	}
+	for _, spec := range tolerations {
+		t, err := parseToleration(spec)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		m.Tolerations = append(m.Tolerations, t)
+	}
+	for key, value := range map[string]string{"cpu": *cpuRequest, "memory": *memoryRequest} {
+		if value != "" {
+			m.Requests[key] = value
+		}
+	}
+	for key, value := range map[string]string{"cpu": *cpuLimit, "memory": *memoryLimit} {
+		if value != "" {
+			m.Limits[key] = value
+		}
+	}
+
+	tmpl := template.Must(template.New("manifest").Funcs(template.FuncMap{"q": strconv.Quote}).Parse(source))
+	template.Must(tmpl.Parse(k8sCommonTemplates))
+	if err := tmpl.Execute(os.Stdout, m); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+}
+
+// validateJobArgs checks that the stress-go options to embed describe a load test
+// that will end on its own.
+func validateJobArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("the stress-go options after -- are required")
+	}
+	if first := args[0]; !strings.HasPrefix(first, "-") && first != "replay" {
+		return fmt.Errorf("unsupported stress-go command in manifest: %s", first)
+	}
+	// A replayed profile ends with the profile
+	if args[0] == "replay" {
+		return nil
+	}
+	for _, arg := range args {
+		if arg == "--timeout" || arg == "-timeout" || strings.HasPrefix(arg, "--timeout=") || strings.HasPrefix(arg, "-timeout=") {
+			return nil
+		}
+	}
+	return fmt.Errorf("--timeout is required in the stress-go options")
+}
+
+// parseToleration parses a toleration given as key[=value][:effect].
+func parseToleration(spec string) (k8sToleration, error) {
+	t := k8sToleration{Operator: "Exists"}
+	rest, effect, hasEffect := strings.Cut(spec, ":")
+	if hasEffect {
+		switch effect {
+		case "NoSchedule", "PreferNoSchedule", "NoExecute":
+			t.Effect = effect
+		default:
+			return t, fmt.Errorf("invalid toleration effect %q (expected NoSchedule, PreferNoSchedule or NoExecute)", effect)
+		}
+	}
+	key, value, hasValue := strings.Cut(rest, "=")
+	if key == "" {
+		return t, fmt.Errorf("invalid toleration %q: expected key[=value][:effect]", spec)
+	}
+	t.Key = key
+	if hasValue {
+		t.Operator, t.Value = "Equal", value
+	}
+	return t, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// progressPrefix starts the periodic progress line of a run.
+const progressPrefix = "Progress:"
+
+// applyLang removes a --lang option from args, which may appear before or after
+// the subcommand but not after "--", and switches the message language to it.
+// Without --lang the language detected from the locale is kept.
+func applyLang(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		var value string
+		switch {
+		case arg == "--lang" || arg == "-lang":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("--lang requires a value (en or ja)")
+			}
+			i++
+			value = args[i]
+		case strings.HasPrefix(arg, "--lang=") || strings.HasPrefix(arg, "-lang="):
+			value = arg[strings.Index(arg, "=")+1:]
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		lang, err := i18n.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		i18n.SetLang(lang)
+	}
+	return rest, nil
+}
+
+// isProgressLine reports whether line is the progress line of a run in any language,
+// so that it can be left out of the output relayed from other stress-go processes.
+func isProgressLine(line string) bool {
+	for _, prefix := range i18n.Translations(progressPrefix) {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLabel reports whether line is labeled with label (such as "Error:") in any
+// language, either at its start or after a "[name] " prefix.
+func hasLabel(line, label string) bool {
+	for _, variant := range i18n.Translations(label) {
+		if strings.HasPrefix(line, variant) || strings.Contains(line, "] "+variant) {
+			return true
+		}
+	}
+	return false
+}
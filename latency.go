@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// latencyPoint is one point of the loaded-latency curve as written by --json.
+type latencyPoint struct {
+	Level          float64 `json:"level"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	Nanoseconds    float64 `json:"latency_ns"`
+}
+
+// latencyReport is the result of the latency subcommand as written by --json.
+type latencyReport struct {
+	Version     string            `json:"version"`
+	Time        time.Time         `json:"time"`
+	Duration    string            `json:"duration"`
+	Size        int64             `json:"size"`
+	LineSize    int               `json:"line_size"`
+	Caches      string            `json:"caches,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+	// Points start with the idle latency, followed by each load level.
+	Points []latencyPoint `json:"points"`
+}
+
+// runLatency implements the latency subcommand, which measures the memory access
+// latency with a pointer chase, first idle and then while the bandwidth load runs
+// at each level, to show how latency rises with bandwidth (a loaded-latency curve).
+func runLatency(args []string) {
+	flags := flag.NewFlagSet("latency", flag.ExitOnError)
+	duration := flags.Duration("duration", 5*time.Second, "Duration of the measurement at each level")
+	sizeSpec := flags.String("size", "", "Size of the buffer the latency is measured on (default: 256MiB or 4x the last level cache, whichever is larger)")
+	lineSize := flags.Int("line-size", 0, "Cache line size in bytes; the chase loads once per line (default: detected, or 64)")
+	levelSpec := flags.String("levels", "25%,50%,75%,100%", "Comma-separated bandwidth load levels to measure under, after the idle measurement")
+	jsonPath := flags.String("json", "", "Write the latency curve to this file as JSON")
+	flags.Parse(args)
+
+	if *duration <= 0 {
+		term.Eprintf("Error: --duration must be positive\n")
+		os.Exit(exitConfigError)
+	}
+	caches, cacheErr := sysinfo.ReadCaches()
+	size := memory.DefaultLatencySize()
+	if *sizeSpec != "" {
+		var err error
+		if size, err = bytesize.ParseAbsolute(*sizeSpec); err != nil {
+			term.Eprintf("Error: Invalid --size: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if *lineSize == 0 {
+		*lineSize = caches.LineSize()
+	}
+	levels := []float64{0}
+	for _, value := range splitList(*levelSpec) {
+		level, err := parseLevel(value)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		levels = append(levels, level)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping measurement...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	report := latencyReport{
+		Version:     stress.Version,
+		Time:        time.Now(),
+		Duration:    duration.String(),
+		Size:        size,
+		LineSize:    *lineSize,
+		Environment: sysinfo.ReadEnvironment().Labels(),
+	}
+	if cacheErr != nil {
+		term.Printf("CPU caches: unknown (%v)\n", cacheErr)
+	} else {
+		report.Caches = caches.String()
+		term.Printf("CPU caches: %s\n", report.Caches)
+		if last, ok := caches.LastLevel(); ok && size < 2*last.Size {
+			term.Printf("Warning: the %d MiB buffer is not much larger than the %d MiB last level cache; the latency includes cache hits\n",
+				size/(1024*1024), last.Size/(1024*1024))
+		}
+	}
+	term.Printf("Measuring memory latency on %d MiB for %v per level...\n", size/(1024*1024), *duration)
+	term.Println("  Load      Bandwidth      Latency")
+	_, err := memory.MeasureLatency(ctx, memory.LatencyOptions{
+		Size:     size,
+		LineSize: *lineSize,
+		Duration: *duration,
+		Levels:   levels,
+		Progress: func(p memory.LatencyPoint) {
+			report.Points = append(report.Points, latencyPoint(p))
+			if p.Level == 0 {
+				term.Printf("  idle    %7.2f GB/s %9.1f ns\n", p.BytesPerSecond/1e9, p.Nanoseconds)
+			} else {
+				term.Printf("  %4.0f%%   %7.2f GB/s %9.1f ns\n", p.Level*100, p.BytesPerSecond/1e9, p.Nanoseconds)
+			}
+		},
+	})
+	if err != nil && ctx.Err() == nil {
+		term.Eprintf("Error: Memory latency measurement failed: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	if len(report.Points) > 1 {
+		idle, loaded := report.Points[0], report.Points[len(report.Points)-1]
+		term.Println()
+		term.Printf("Latency at %.2f GB/s is %.1fx the idle latency\n", loaded.BytesPerSecond/1e9, loaded.Nanoseconds/idle.Nanoseconds)
+	}
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*jsonPath, append(data, '\n'), 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write latency results: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		term.Printf("Latency results written to %s\n", *jsonPath)
+	}
+	if ctx.Err() != nil {
+		os.Exit(exitFailure)
+	}
+}
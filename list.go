@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"slices"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/workload"
+)
+
+// listTitles are the headings of each kind in the list subcommand.
+var listTitles = map[workload.Kind]string{
+	workload.Methods:  "CPU methods:",
+	workload.Patterns: "Load patterns:",
+	workload.Engines:  "Engines:",
+}
+
+// listedWorkload is one workload as written by list --json.
+type listedWorkload struct {
+	Kind        workload.Kind `json:"kind"`
+	Name        string        `json:"name"`
+	Domain      string        `json:"domain"`
+	Usage       string        `json:"usage"`
+	Description string        `json:"description"`
+	Available   bool          `json:"available"`
+	Requirement string        `json:"requirement,omitempty"`
+}
+
+// runList implements the list subcommand, which shows the CPU methods, load
+// patterns and engines of this build, and whether each can be used on this OS.
+func runList(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "Write the list as JSON to standard output")
+	flags.Parse(args)
+
+	kinds := workload.Kinds
+	if flags.NArg() > 0 {
+		kinds = nil
+		for _, arg := range flags.Args() {
+			kind := workload.Kind(arg)
+			if !slices.Contains(workload.Kinds, kind) {
+				term.Eprintf("Error: Unknown list %q (methods, patterns or engines)\n", arg)
+				os.Exit(exitConfigError)
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	if *jsonOutput {
+		listed := []listedWorkload{}
+		for _, kind := range kinds {
+			for _, w := range workload.List(kind) {
+				listed = append(listed, listedWorkload(w))
+			}
+		}
+		data, _ := json.MarshalIndent(listed, "", "  ")
+		os.Stdout.Write(append(data, '\n'))
+		return
+	}
+
+	for i, kind := range kinds {
+		if i > 0 {
+			term.Println()
+		}
+		term.Println(listTitles[kind])
+		for _, w := range workload.List(kind) {
+			status := i18n.T("available")
+			if !w.Available {
+				status = i18n.Sprintf("unavailable, needs %s", i18n.T(w.Requirement))
+			}
+			term.Printf("  %-12s %-10s %s (%s)\n", w.Domain, w.Name, i18n.T(w.Description), status)
+			term.Printf("  %-12s %-10s %s\n", "", "", w.Usage)
+		}
+	}
+}
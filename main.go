@@ -1,230 +1,2626 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
+	"math/rand/v2"
+	"net/http"
 	"os"
 	"os/signal"
-	"regexp"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"stress-go/pkg/cpu"
-	"stress-go/pkg/memory"
-	"stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/console"
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/fsmeta"
+	"github.com/utkamioka/stress-go/pkg/gpu"
+	"github.com/utkamioka/stress-go/pkg/grafana"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/inhibit"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/network"
+	"github.com/utkamioka/stress-go/pkg/overhead"
+	"github.com/utkamioka/stress-go/pkg/pagefault"
+	"github.com/utkamioka/stress-go/pkg/pattern"
+	"github.com/utkamioka/stress-go/pkg/plugin"
+	"github.com/utkamioka/stress-go/pkg/probe"
+	"github.com/utkamioka/stress-go/pkg/procs"
+	"github.com/utkamioka/stress-go/pkg/profile"
+	"github.com/utkamioka/stress-go/pkg/report"
+	"github.com/utkamioka/stress-go/pkg/sched"
+	"github.com/utkamioka/stress-go/pkg/sparse"
+	"github.com/utkamioka/stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+	"github.com/utkamioka/stress-go/pkg/supervise"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+	"github.com/utkamioka/stress-go/pkg/systemd"
+	"github.com/utkamioka/stress-go/pkg/tempdirs"
+	"github.com/utkamioka/stress-go/pkg/watchdog"
 )
 
+// Exit statuses other than 0 (success).
+const (
+	// exitFailure is used for runtime errors not covered by a more specific status.
+	exitFailure = 1
+	// exitConfigError is used for invalid options; it matches the status the flag package uses.
+	exitConfigError = 2
+	// exitWatchdogAbort is used when an --abort-if condition or degraded SMART health stopped the run.
+	exitWatchdogAbort = 3
+	// exitCleanupTimeout is used when stressors did not stop within --stop-timeout.
+	exitCleanupTimeout = 4
+	// exitStartupFailure is used when a stressor failed before producing any load, or
+	// when --pre-cmd failed.
+	exitStartupFailure = 5
+	// exitVerificationFailure is used when doctor or selftest checks fail, or when
+	// a stressor finds that data or results it verifies were corrupted.
+	exitVerificationFailure = 6
+	// exitPartial is used when the run finished but a stressor did not reach its target.
+	exitPartial = 7
+	// exitSLOViolation is used when an SLO was violated in more windows than --slo-budget allows.
+	exitSLOViolation = 8
+	// exitThresholdFailure is used when a stressor fell short of a --fail-under threshold.
+	exitThresholdFailure = 9
+)
+
+// cloudMetadataTimeout bounds the --cloud-metadata lookup, which off the cloud
+// only ends when it times out.
+const cloudMetadataTimeout = 2 * time.Second
+
+// term owns the terminal during a run: log lines scroll above the progress line,
+// which is repainted below them.
+var term = console.New(os.Stdout, os.Stderr)
+
 type Config struct {
 	Timeout time.Duration
 	CPU     int
+	// CPULoad is the utilization each of the CPU cores is held at (0.0-1.0),
+	// or 0 to keep them fully busy.
+	CPULoad float64
 	Memory  string
 	Storage string
+	// GPU is the target GPU utilization in percent, or 0 for no GPU load.
+	GPU       float64
+	GPUMemory string
+	GPUDevice int
+	// PageFault is the size of the file mapped by the page fault load, either a
+	// size or a multiple of the memory such as "1.5x", or empty for no such load.
+	PageFault     string
+	PageFaultRate float64
+	PageFaultDir  string
+	// Sparse is the total apparent size of the sparse files whose holes are
+	// punched and filled again, or empty for no such load.
+	Sparse     string
+	SparseRate float64
+	SparseDir  string
+	// Network is the kind of network load (tcp, udp or churn), or empty for no
+	// such load. NetworkRate is a size per second for tcp and udp, and a number
+	// of connections per second for churn.
+	Network            string
+	NetworkTarget      string
+	NetworkSize        string
+	NetworkRate        string
+	NetworkConnections int
+	// Procs is the most child processes alive at once, or 0 for no process
+	// load. ProcsRate is spawns per second, and ProcsLifetime how long each
+	// child sleeps before it exits.
+	Procs         int
+	ProcsRate     float64
+	ProcsLifetime time.Duration
+	// FD is the number of file descriptors held open, either a count or a
+	// share of the open file limit such as "80%", and Inodes the number of small
+	// files churned in a directory tree; both empty or 0 for no such load.
+	FD         string
+	Inodes     int
+	FilesDepth int
+	FilesRate  float64
+	FilesDir   string
+	// Switch is the number of goroutine pairs ping-ponging messages and Timers
+	// the number of goroutines re-arming timers, both 0 for no scheduler load.
+	// SwitchRate is messages per second over all the pairs.
+	Switch        int
+	SwitchRate    float64
+	Timers        int
+	TimerInterval time.Duration
+	ReportHTML    string
+	SummaryJSON   string
+	// Output is the format (text, json or csv) in which the samples and the
+	// summary are streamed to ReportFile, or to stdout if it is empty.
+	Output     string
+	ReportFile string
+	Listen     string
+	// MetricsAddr serves /metrics in Prometheus text format, on the mux of
+	// Listen when both are the same address.
+	MetricsAddr string
+	// Pprof serves the runtime profiles of stress-go itself on the Listen address.
+	Pprof bool
+	// PprofDir receives periodic CPU and heap profiles of stress-go itself, every PprofInterval.
+	PprofDir      string
+	PprofInterval time.Duration
+	Profile       string
+
+	// Pattern steps the CPU and memory load through levels, or is nil for a constant load.
+	Pattern *pattern.Pattern
+	// Interval alternates work and rest periods for every stressor, or is nil for a continuous load.
+	Interval *workRest
+	// RampUp and RampDown are how long the load takes to grow from nothing at
+	// the start of the run and to fall back to nothing before its end.
+	RampUp   time.Duration
+	RampDown time.Duration
+
+	// MemorySpec, StorageSpec and GPUMemorySpec are Memory, Storage and GPUMemory as parsed.
+	MemorySpec    bytesize.Spec
+	StorageSpec   bytesize.Spec
+	GPUMemorySpec bytesize.Spec
+	DryRun        bool
+
+	// CPUMethod is the computation of the CPU load, one of cpu.CPUMethods; the
+	// operands of bignum have CPUBignumBits bits (0 = the default).
+	CPUMethod     string
+	CPUBignumBits int
+	// CPUVerify, MemoryVerify and StorageVerify make the stressors check their results.
+	CPUVerify     bool
+	MemoryVerify  bool
+	StorageVerify bool
+	// MemoryContent is the data written in the memory load's allocation:
+	// compressible, incompressible or mixed (empty touches every page).
+	MemoryContent string
+	// MemoryMode is what the memory load keeps doing with its allocation:
+	// churn, bandwidth, lock or swap (empty holds it). MemoryRate, parsed into
+	// MemoryRateBytes, is the bytes rewritten per second by churn and swap, and
+	// MemoryWorkers the workers of churn, bandwidth and swap (0 = the default).
+	MemoryMode      string
+	MemoryRate      string
+	MemoryRateBytes int64
+	MemoryWorkers   int
+	// IOMode keeps the storage load reading or writing its files with IODepth
+	// workers, calling fsync after every FsyncEvery writes.
+	IOMode     string
+	IODepth    int
+	FsyncEvery int
+	// StoragePath is the directory the storage load writes to, and whose free
+	// space a percentage is taken of (default: the temporary directory).
+	StoragePath string
+	// StorageFiles is the number of files the storage load is spread over, and
+	// StorageBS the bytes read or written by one system call, parsed into StorageBlockSize.
+	StorageFiles     int
+	StorageBS        string
+	StorageBlockSize int
+	// StorageSync is how the storage load's writes reach the disk: fsync, dsync or sync.
+	StorageSync string
+	// StorageNetworkMix adds the stat, lock and small sync write operations of a
+	// network filesystem to the storage load even on a local disk.
+	StorageNetworkMix bool
+	// Burnin is set for "stress-go burnin", which ends with a certificate written
+	// to Certificate, if given.
+	Burnin      bool
+	Certificate string
+	// Calibration is the duty-cycle calibration file, or empty for the default location.
+	Calibration string
+
+	TextfileDir      string
+	TextfileInterval time.Duration
+
+	// State is the file the run's state is checkpointed to every StateInterval
+	// for "stress-go resume", or empty for no checkpoints.
+	State         string
+	StateInterval time.Duration
+
+	AbortIf []watchdog.Condition
+
+	// Smart monitors the SMART health of SmartDevice, or of the disk under the
+	// storage load, every SmartInterval; the run aborts when it degrades or the
+	// temperature exceeds SmartMaxTemp (0 for no limit).
+	Smart         bool
+	SmartDevice   string
+	SmartInterval time.Duration
+	SmartMaxTemp  float64
+
+	NoThermalFailsafe bool
+	MaxLoadAverage    float64
+	// SoakTemperature is the CPU temperature a thermal soak holds, or 0.
+	SoakTemperature float64
+
+	// Baseline is how long the system is measured idle before the load, or 0.
+	Baseline time.Duration
+	// Victim is the process whose CPU share is compared with the baseline, or 0.
+	Victim int
+
+	// ProbeInterval is how often the canary probes run, or 0 for none.
+	ProbeInterval time.Duration
+	// Probes are the probes to run; empty runs every probe.
+	Probes []string
+
+	// SLOs are the objectives evaluated every SLOWindow while the load runs.
+	SLOs      []slo
+	SLOWindow time.Duration
+	// SLOBudget is how many violated windows fail the run; unset never fails it.
+	SLOBudget sloBudget
+	// FailUnder are the least results the stressors must reach for the run to pass.
+	FailUnder []threshold
+
+	// Seed drives every random choice of the run, so that runs with the same seed
+	// and options apply the same load.
+	Seed uint64
+
+	// Chaos is the mean interval between injected faults, or 0 for none.
+	Chaos       time.Duration
+	ChaosSeed   uint64
+	ChaosFaults []stressor.Fault
+
+	MaxMemory     string
+	MaxDisk       string
+	MaxCPUPercent float64
+	// MaxMemUsed and MaxDiskFill are the shares of the system memory and of the
+	// filesystems under the storage loads in use, by any process, that the
+	// safety guard keeps the memory and storage loads under, or 0 for none.
+	MaxMemUsed  float64
+	MaxDiskFill float64
+	// NoDashboard keeps the single progress line on a terminal instead of the
+	// live dashboard of the system resources and stressors.
+	NoDashboard bool
+
+	StopTimeout time.Duration
+	// GracePeriod is how long the orchestrator waits after SIGTERM before SIGKILL;
+	// cleanup after a stop signal is cut short to finish within it.
+	GracePeriod time.Duration
+	// CloudMetadata tags the results with the instance type, zone and lifecycle
+	// read from the cloud's instance metadata service.
+	CloudMetadata bool
+	// DropCaches is when the page cache is dropped: "before" the load,
+	// "between-phases" also after the storage writes and at every pattern step,
+	// or "" never.
+	DropCaches string
+	ExtendBy   time.Duration
+	// LevelStep is how much SIGUSR1 raises and SIGUSR2 lowers the load level,
+	// or 0 to leave SIGUSR2 to --extend-by.
+	LevelStep  float64
+	StartAt    time.Time
+	AllowSleep bool
+	FailFast   bool
+	// OverheadCPU is the CPU that stress-go's own bookkeeping is confined to,
+	// away from the load, or -1.
+	OverheadCPU int
+
+	Plugins []plugin.Options
+	// Jobs are the named instances of built-in stressors given with --job.
+	Jobs []job
+
+	// StressorTimeouts maps lower-case stressor names to their own run time.
+	StressorTimeouts map[string]time.Duration
+	// StressorStarts maps the lower-case names of the --scenario stages to when
+	// they start into the run.
+	StressorStarts map[string]time.Duration
+
+	GrafanaURL   string
+	GrafanaToken string
+	GrafanaTags  string
+
+	NotifyURL string
+
+	PreCmd      string
+	PhaseCmd    string
+	PostCmd     string
+	HookTimeout time.Duration
 }
 
 func main() {
+	// A child of --procs only sleeps and exits
+	procs.RunChild()
+
 	var config Config
 	var timeoutStr string
+	var abortExprs stringList
+	var pluginSpecs stringList
+	var jobSpecs stringList
+	var scenarioPath string
+	var stressorTimeouts stringList
+	var startAt string
+	var cpuSpec string
+	var cpuLoad float64
+	var patternSpec string
+	var intervalSpec string
+	var chaosFaults string
+	var probesSpec string
+	var sloExprs stringList
+	var sloBudgetSpec string
+	var failUnder string
+	var maxMem string
+	var maxDiskFill string
+
+	args, err := applyLang(os.Args[1:])
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if len(args) > 0 && args[0] == "record" {
+		runRecord(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "doctor" {
+		runDoctor(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "calibrate" {
+		runCalibrate(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "bench" {
+		runBench(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "latency" {
+		runLatency(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "search" {
+		runSearch(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "tenants" {
+		runTenants(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "healthcheck" {
+		runHealthcheck(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "selftest" {
+		runSelftest(args[1:])
+		return
+	}
+	if len(args) > 0 && (args[0] == "agent" || args[0] == "serve") {
+		runAgent(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "worker" {
+		runWorker(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "daemon" {
+		runDaemon(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "ctl" {
+		runCtl(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "coordinate" {
+		runCoordinate(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "k8s" {
+		runK8s(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "service" {
+		runService(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "echo" {
+		runEcho(args[1:])
+		return
+	}
+	if len(args) > 0 && args[0] == "list" {
+		runList(args[1:])
+		return
+	}
+	if len(args) > 0 && (args[0] == "version" || args[0] == "--version") {
+		term.Printf("stress-go v%s\n", stress.Version)
+		return
+	}
+	// A resumed run continues with the options it was started with
+	var resumed *runState
+	var offset time.Duration
+	if len(args) > 0 && args[0] == "resume" {
+		resumed, args = runResume(args[1:])
+		offset = resumed.elapsed()
+	}
+	stateArgs := slices.Clone(args)
+	if resumed != nil {
+		stateArgs = resumed.Args
+	}
+	replayMode := len(args) > 0 && args[0] == "replay"
+	burninMode := len(args) > 0 && args[0] == "burnin"
+	if replayMode || burninMode {
+		args = args[1:]
+	}
+	// Templates in the options scale one command line to hosts of different sizes
+	if args, err = expandOptions(args); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
 
 	flag.StringVar(&timeoutStr, "timeout", "", "Duration to apply load (e.g., 30s, 5m, 1h)")
-	flag.IntVar(&config.CPU, "cpu", -1, "Number of CPU cores to use (0 = use all cores)")
+	flag.StringVar(&cpuSpec, "cpu", "", "Number of CPU cores to use (0 = use all cores), optionally with a load per core (e.g., 4x60%)")
+	flag.Float64Var(&cpuLoad, "cpu-load", 0, "Hold each CPU core at this utilization percentage instead of 100% (implies --cpu 0 if not given)")
+	flag.StringVar(&config.CPUMethod, "cpu-method", string(cpu.MethodInteger), "Computation of the CPU load: integer, bignum, float, crypto, prime, branch, cache or all (rotating)")
+	flag.IntVar(&config.CPUBignumBits, "cpu-bignum-bits", cpu.DefaultBignumBits, "Operand size in bits of --cpu-method bignum")
 	flag.StringVar(&config.Memory, "memory", "", "Memory load (e.g., 1GB, 512MB, 95%)")
 	flag.StringVar(&config.Storage, "storage", "", "Storage load (e.g., 500MB, 80%)")
-	flag.Parse()
+	flag.Float64Var(&config.GPU, "gpu", 0, "GPU compute load as a utilization percentage (needs a build with -tags gpu)")
+	flag.StringVar(&config.GPUMemory, "gpu-memory", "", "VRAM to allocate with --gpu (e.g., 4GB, 80% of free VRAM)")
+	flag.IntVar(&config.GPUDevice, "gpu-device", 0, "Index of the GPU loaded by --gpu")
+	flag.StringVar(&config.PageFault, "pagefault", "", "Map a file of this size and touch random pages to cause major page faults (e.g., 64GB, 1.5x of memory)")
+	flag.Float64Var(&config.PageFaultRate, "pagefault-rate", 0, "Target major page faults per second for --pagefault (0 = as many as possible)")
+	flag.StringVar(&config.PageFaultDir, "pagefault-dir", "", "Directory for the file mapped by --pagefault (default: the temporary directory)")
+	flag.StringVar(&config.Sparse, "sparse", "", "Punch holes in and refill sparse files of this total apparent size (e.g., 10GB)")
+	flag.Float64Var(&config.SparseRate, "sparse-rate", 0, "Target hole punch and fill operations per second for --sparse (0 = as many as possible)")
+	flag.StringVar(&config.SparseDir, "sparse-dir", "", "Directory for the files of --sparse (default: the temporary directory)")
+	flag.StringVar(&config.Network, "network", "", "Network load: tcp (bandwidth), udp (datagrams) or churn (connections opened and closed)")
+	flag.StringVar(&config.NetworkTarget, "network-target", "", "Destination host:port of --network (default: a built-in echo server on loopback)")
+	flag.StringVar(&config.NetworkSize, "network-size", "", "Size of each write or datagram of --network (default: 64KB for tcp, 1400B for udp, none for churn)")
+	flag.StringVar(&config.NetworkRate, "network-rate", "", "Target rate of --network: a size per second for tcp and udp (e.g., 100MB), connections per second for churn (default: as fast as possible)")
+	flag.IntVar(&config.NetworkConnections, "network-conns", 0, "Parallel connections of --network (default 4)")
+	flag.IntVar(&config.Procs, "procs", 0, "Keep spawning and reaping child processes, at most this many alive at once")
+	flag.Float64Var(&config.ProcsRate, "procs-rate", 0, "Target child processes spawned per second for --procs (0 = as many as possible)")
+	flag.DurationVar(&config.ProcsLifetime, "procs-lifetime", 0, "How long each child of --procs sleeps before it exits (default: exits at once)")
+	flag.StringVar(&config.FD, "fd", "", "Hold this many file descriptors open, or a share of the open file limit (e.g., 10000, 80%)")
+	flag.IntVar(&config.Inodes, "inodes", 0, "Keep creating and removing small files in a deep directory tree of this many files")
+	flag.IntVar(&config.FilesDepth, "files-depth", 0, "Depth of the directory tree of --inodes (default 4, deeper when the files need it)")
+	flag.Float64Var(&config.FilesRate, "files-rate", 0, "Target file creations and removals per second for --inodes, or descriptors reopened per second for --fd alone (0 = as many as possible)")
+	flag.StringVar(&config.FilesDir, "files-dir", "", "Directory for the files of --fd and --inodes (default: the temporary directory)")
+	flag.IntVar(&config.Switch, "switch", 0, "Ping-pong messages between this many goroutine pairs over unbuffered channels")
+	flag.Float64Var(&config.SwitchRate, "switch-rate", 0, "Target messages per second over all the pairs of --switch (0 = as many as possible)")
+	flag.IntVar(&config.Timers, "timers", 0, "Keep this many high-resolution timers firing")
+	flag.DurationVar(&config.TimerInterval, "timer-interval", 0, "How often each timer of --timers fires (default 1ms)")
+	flag.StringVar(&config.ReportHTML, "report-html", "", "Write a self-contained HTML report to the given file")
+	flag.StringVar(&config.SummaryJSON, "summary-json", "", "Write a machine-readable summary of the run to the given file")
+	flag.StringVar(&config.Output, "output", outputText, "Format of the samples and summary: text, json (JSON lines) or csv")
+	flag.StringVar(&config.ReportFile, "report-file", "", "Write the samples and summary in the --output format to the given file (default: stdout for json and csv)")
+	flag.StringVar(&config.Listen, "listen", "", "Serve the health, status and control endpoints and the expvar counters on this address (e.g., :8080)")
+	flag.StringVar(&config.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics of the run under /metrics on this address (e.g., :9090)")
+	flag.BoolVar(&config.Pprof, "pprof", false, "Also serve the runtime profiles of stress-go itself under /debug/pprof/ (needs --listen)")
+	flag.StringVar(&config.PprofDir, "pprof-dir", "", "Write CPU and heap profiles of stress-go itself to this directory periodically")
+	flag.DurationVar(&config.PprofInterval, "pprof-interval", time.Minute, "Interval between the profiles written to --pprof-dir")
+	flag.StringVar(&config.GrafanaURL, "grafana-url", "", "Grafana base URL to post run/phase annotations to")
+	flag.StringVar(&config.GrafanaToken, "grafana-token", "", "Grafana API token used for annotations")
+	flag.StringVar(&config.GrafanaTags, "grafana-tags", "", "Comma-separated tags added to every Grafana annotation")
+	flag.StringVar(&config.NotifyURL, "notify-url", "", "POST JSON notifications of the run start, phases, watchdog aborts and completion to this URL")
+	flag.StringVar(&config.PreCmd, "pre-cmd", "", "Shell command to run before the load starts; the run is not started if it fails")
+	flag.StringVar(&config.PhaseCmd, "phase-cmd", "", "Shell command to run in the background at the start of every --pattern step")
+	flag.StringVar(&config.PostCmd, "post-cmd", "", "Shell command to run after the run has finished")
+	flag.DurationVar(&config.HookTimeout, "hook-timeout", defaultHookTimeout, "Kill a --pre-cmd, --phase-cmd or --post-cmd command that runs longer than this")
+	flag.StringVar(&config.TextfileDir, "textfile-dir", "", "Directory to write node_exporter textfile metrics to")
+	flag.DurationVar(&config.TextfileInterval, "textfile-interval", 15*time.Second, "Interval between textfile metric updates")
+	flag.StringVar(&config.State, "state", "", "Checkpoint the state of the run to this file for \"stress-go resume\"")
+	flag.DurationVar(&config.StateInterval, "state-interval", time.Minute, "Interval between --state checkpoints")
+	flag.Var(&abortExprs, "abort-if", "Abort when a condition holds (e.g., loadavg>64, mem-available<500MB, disk-free</:2GB, temp>95C, psi-cpu>50%)")
+	flag.BoolVar(&config.Smart, "smart", false, "Monitor the SMART health of the disk under test and abort when it degrades (needs smartctl)")
+	flag.StringVar(&config.SmartDevice, "smart-device", "", "Device monitored by --smart (default: the disk of the storage load's directory)")
+	flag.DurationVar(&config.SmartInterval, "smart-interval", defaultSmartInterval, "Interval between the SMART readings of --smart")
+	flag.Float64Var(&config.SmartMaxTemp, "smart-max-temp", 0, "Abort when the disk temperature read by --smart exceeds this value in °C")
+	flag.BoolVar(&config.NoThermalFailsafe, "no-thermal-failsafe", false, "Disable the built-in CPU thermal failsafe (for deliberate thermal testing)")
+	flag.Float64Var(&config.SoakTemperature, "soak-temp", 0, "Modulate the CPU load to hold the CPU at this temperature in °C (thermal soak)")
+	flag.Float64Var(&config.MaxLoadAverage, "max-loadavg", 0, "Reduce CPU load to keep the 1-minute load average at or below this value")
+	flag.StringVar(&config.MaxMemory, "max-memory", "", "Hard cap on memory held by all stressors together, GPU memory included (e.g., 4GB)")
+	flag.StringVar(&config.MaxDisk, "max-disk", "", "Hard cap on disk space held by all stressors together: storage, page fault, sparse and --inodes files (e.g., 10GB)")
+	flag.Float64Var(&config.MaxCPUPercent, "max-cpu-percent", 0, "Hard cap on the CPU usage of all CPU stressors together as a percentage of the usable cores (cgroup quota aware)")
+	flag.StringVar(&maxMem, "max-mem", "", "Back off the memory loads while memory in use on the whole system exceeds this share (e.g., 90%)")
+	flag.StringVar(&maxDiskFill, "max-disk-fill", "", "Back off the storage loads while the filesystem they write to is fuller than this share (e.g., 95%)")
+	flag.BoolVar(&config.NoDashboard, "no-dashboard", false, "Show the single progress line instead of the live dashboard on a terminal")
+	flag.StringVar(&startAt, "start-at", "", "Wait until this RFC 3339 time before applying load")
+	flag.DurationVar(&config.ExtendBy, "extend-by", 30*time.Minute, "Change the remaining run time by this much on SIGUSR2 (negative shortens)")
+	flag.Float64Var(&config.LevelStep, "level-step", 0, "Raise the load level by this much on SIGUSR1 and lower it on SIGUSR2 (e.g., 0.1; SIGUSR2 then no longer changes the run time)")
+	flag.DurationVar(&config.StopTimeout, "stop-timeout", 60*time.Second, "Maximum time allowed for cleanup after stopping (0 = unlimited)")
+	flag.DurationVar(&config.GracePeriod, "grace-period", 0, "Time the orchestrator allows between SIGTERM and SIGKILL (e.g., 10s for docker stop); cleanup after a stop signal finishes within it")
+	flag.StringVar(&config.DropCaches, "drop-caches", "", "Drop the page cache so that storage reads hit the device: before (the load) or between-phases (also after the storage writes and at every --pattern step)")
+	flag.BoolVar(&config.CloudMetadata, "cloud-metadata", false, "Tag results with the AWS/GCP/Azure instance type, zone and lifecycle (spot or on-demand)")
+	flag.BoolVar(&config.FailFast, "fail-fast", false, "Stop all stressors as soon as one of them fails")
+	flag.BoolVar(&config.AllowSleep, "allow-sleep", false, "Do not prevent system sleep/hibernate during the run")
+	flag.IntVar(&config.OverheadCPU, "overhead-cpu", -1, "Confine stress-go's own bookkeeping to this CPU and keep the load off it (Linux)")
+	flag.Var(&stressorTimeouts, "stressor-timeout", "Stop one stressor after its own duration, given as name=duration (repeatable)")
+	flag.Var(&jobSpecs, "job", "Run a named built-in stressor given as name=kind:options, e.g. logs=storage:size=10GB,dir=/mnt/logs (repeatable)")
+	flag.Var(&pluginSpecs, "plugin", "Run an external stressor plugin given as name=command (repeatable)")
+	flag.StringVar(&scenarioPath, "scenario", "", "Run the stages of built-in loads in this JSON file, each from its own start for its own duration")
+	flag.DurationVar(&config.RampUp, "ramp-up", 0, "Grow every load from nothing to its target over this long at the start")
+	flag.DurationVar(&config.RampDown, "ramp-down", 0, "Take every load back to nothing over this long before the end")
+	flag.StringVar(&patternSpec, "pattern", "", "Step the CPU and memory load through levels (e.g., steps:levels=20,40,60,80;hold=2m)")
+	flag.StringVar(&intervalSpec, "interval", "", "Alternate work and rest periods for every stressor (e.g., work=50s,rest=10s[,memory=retain])")
+	flag.StringVar(&config.Profile, "profile", "", "Load profile file to reproduce (replay mode)")
+	flag.BoolVar(&config.CPUVerify, "cpu-verify", false, "Check floating point results on every core while loading the CPU")
+	flag.BoolVar(&config.MemoryVerify, "memory-verify", false, "Fill memory with test patterns and check them while holding it")
+	flag.StringVar(&config.MemoryContent, "memory-content", "", "Data written to the memory load for zram/zswap: compressible, incompressible or mixed")
+	flag.StringVar(&config.MemoryMode, "memory-mode", "", "Keep working the memory load: churn, bandwidth, lock or swap (default: hold it)")
+	flag.StringVar(&config.MemoryRate, "memory-rate", "", "Bytes rewritten per second by --memory-mode churn and swap, e.g. 500MB (default: as fast as possible)")
+	flag.IntVar(&config.MemoryWorkers, "memory-workers", 0, "Number of workers of --memory-mode churn, bandwidth and swap (default: 1, every CPU for bandwidth)")
+	flag.StringVar(&config.IOMode, "io-mode", "", "Keep reading or writing the storage files: seqwrite, seqread, randread, randwrite or mixed (default: a read and an append every 2s)")
+	flag.IntVar(&config.IODepth, "io-depth", 1, "Number of workers doing the --io-mode reads and writes in parallel (queue depth)")
+	flag.IntVar(&config.FsyncEvery, "fsync-every", 0, "Call fsync after every N writes of each --io-mode worker (default: never)")
+	flag.StringVar(&config.StoragePath, "storage-path", "", "Directory the storage load writes to; percentages are of its free space (default: the temporary directory)")
+	flag.IntVar(&config.StorageFiles, "storage-files", 0, "Number of files the storage load is spread over (default: files of up to 64MB)")
+	flag.StringVar(&config.StorageBS, "storage-bs", "", "Bytes read or written by one system call of the storage load, e.g. 4KB or 1MB (default 64KB)")
+	flag.BoolVar(&config.StorageVerify, "storage-verify", false, "Write checksummed blocks and check them on every read")
+	flag.StringVar(&config.StorageSync, "storage-sync", string(storage.SyncFsync), "How storage writes reach the disk: fsync (buffered, fsync per file), dsync (O_DSYNC) or sync (O_SYNC)")
+	flag.BoolVar(&config.StorageNetworkMix, "storage-network-mix", false, "Add stat, lock and small sync write operations to the storage load (automatic on NFS, SMB and FUSE)")
+	flag.StringVar(&config.Calibration, "calibration", "", "Duty-cycle calibration file to correct partial CPU loads with (default: the one stored by calibrate)")
+	flag.StringVar(&config.Certificate, "certificate", "", "Write the burn-in certificate to the given file (burnin mode)")
+	flag.DurationVar(&config.Baseline, "baseline", 0, "Measure the system idle for this long before the load and report the change (e.g., 30s)")
+	flag.IntVar(&config.Victim, "victim", 0, "PID of a process whose CPU share is compared with the baseline")
+	flag.DurationVar(&config.ProbeInterval, "probe-interval", 0, "Run canary latency probes at this interval alongside the load (e.g., 100ms)")
+	flag.StringVar(&probesSpec, "probes", "", "Comma-separated probes to run: wakeup, alloc, pread, rtt (default: all)")
+	flag.Var(&sloExprs, "slo", "Objective checked every --slo-window (e.g., wakeup-p99<5ms, cpu-achieved>90%); repeatable")
+	flag.DurationVar(&config.SLOWindow, "slo-window", defaultSLOWindow, "Window over which each --slo is evaluated")
+	flag.StringVar(&sloBudgetSpec, "slo-budget", "", "Fail the run when an SLO is violated in more windows than this (e.g., 3 or 1%)")
+	flag.StringVar(&failUnder, "fail-under", "", "Fail the run when a stressor falls short: <stressor>=<percent>% of its target or <stressor>=<rate>/s (e.g., cpu=90%,storage=200MB/s)")
+	flag.DurationVar(&config.Chaos, "chaos", 0, "Inject faults into the stressors at random, on average at this interval (e.g., 30s)")
+	flag.Uint64Var(&config.Seed, "seed", 0, "Seed for the written data, test patterns, probe offsets and chaos faults (0 = random)")
+	flag.Uint64Var(&config.ChaosSeed, "chaos-seed", 0, "Seed for choosing the injected faults (0 = --seed)")
+	flag.StringVar(&chaosFaults, "chaos-faults", "", "Comma-separated faults to inject: kill, drop, corrupt (default: all that apply)")
+	flag.BoolVar(&config.DryRun, "dry-run", false, "Validate the options, show how they resolve on this host and exit without applying load")
+	flag.CommandLine.Parse(args)
+
+	if config.LevelStep < 0 || config.LevelStep > maxLevel {
+		term.Eprintf("Error: --level-step must be in range 0-%d\n", maxLevel)
+		os.Exit(exitConfigError)
+	}
+	// With --level-step, SIGUSR2 lowers the load instead of changing the run time
+	if config.LevelStep > 0 && flagGiven("extend-by") {
+		term.Eprintf("Error: --level-step takes over SIGUSR2 and cannot be combined with --extend-by\n")
+		os.Exit(exitConfigError)
+	}
+
+	config.CPU, config.CPULoad, err = parseCPUSpec(cpuSpec, cpuLoad)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	var replayProfile *profile.Profile
+	if replayMode {
+		if config.Profile == "" {
+			term.Eprintf("Error: --profile option is required in replay mode\n")
+			printUsage()
+			os.Exit(exitConfigError)
+		}
+		p, err := profile.Load(config.Profile)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		replayProfile = p
+
+		// Replay the whole profile unless a timeout is given
+		if timeoutStr == "" {
+			timeoutStr = p.Duration().String()
+		}
+	}
+
+	if burninMode {
+		applyBurninDefaults(&config, &timeoutStr)
+	} else if config.Certificate != "" {
+		term.Eprintf("Error: --certificate can only be used in burnin mode\n")
+		os.Exit(exitConfigError)
+	}
+
+	var stages []stage
+	if scenarioPath != "" {
+		stages, err = loadScenario(scenarioPath)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		for _, st := range stages {
+			jobSpecs = append(jobSpecs, st.jobSpec())
+		}
+		// The run lasts until the last stage ends unless --timeout says otherwise
+		if timeoutStr == "" {
+			timeoutStr = scenarioLength(stages).String()
+		}
+	}
 
 	if timeoutStr == "" {
-		fmt.Fprintf(os.Stderr, "Error: --timeout option is required\n")
+		term.Eprintf("Error: --timeout option is required\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err != nil {
+		term.Eprintf("Error: Invalid time format: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	config.Timeout = timeout
+
+	if config.RampUp < 0 || config.RampDown < 0 || config.RampUp+config.RampDown > config.Timeout {
+		term.Eprintf("Error: --ramp-up and --ramp-down must not be negative and must fit in --timeout\n")
+		os.Exit(exitConfigError)
+	}
+
+	if config.MaxMemUsed, err = parseUsageLimit(maxMem); err != nil {
+		term.Eprintf("Error: Invalid --max-mem: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.MaxDiskFill, err = parseUsageLimit(maxDiskFill); err != nil {
+		term.Eprintf("Error: Invalid --max-disk-fill: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	if startAt != "" {
+		config.StartAt, err = time.Parse(time.RFC3339Nano, startAt)
+		if err != nil {
+			term.Eprintf("Error: Invalid --start-at time: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	config.AbortIf, err = parseAbortConditions(abortExprs)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.SmartDevice != "" {
+		config.Smart = true
+	}
+	if config.HookTimeout <= 0 {
+		term.Eprintf("Error: --hook-timeout must be positive\n")
+		os.Exit(exitConfigError)
+	}
+	if config.TextfileDir != "" {
+		if err := checkTextfileDir(config.TextfileDir); err != nil {
+			term.Eprintf("Error: Invalid --textfile-dir: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.State != "" {
+		if config.StateInterval <= 0 {
+			term.Eprintf("Error: --state-interval must be positive\n")
+			os.Exit(exitConfigError)
+		}
+		// Starting over would overwrite the checkpoint of a run that can still be resumed
+		if _, err := loadState(config.State); err == nil && resumed == nil {
+			term.Eprintf("Error: %s holds an unfinished run; continue it with \"stress-go resume --state %s\" or remove the file\n", config.State, config.State)
+			os.Exit(exitConfigError)
+		}
+	}
+	if !validOutput(config.Output) {
+		term.Eprintf("Error: --output must be text, json or csv: %s\n", config.Output)
+		os.Exit(exitConfigError)
+	}
+	if config.Output != outputText && config.ReportFile == "" {
+		// Keep stdout to the machine-readable lines
+		term = console.New(os.Stderr, os.Stderr)
+	}
+	if config.SmartInterval <= 0 || config.SmartMaxTemp < 0 {
+		term.Eprintf("Error: --smart-interval must be positive and --smart-max-temp must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+
+	for _, spec := range pluginSpecs {
+		pluginOpts, err := plugin.ParseSpec(spec)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		config.Plugins = append(config.Plugins, pluginOpts)
+	}
+
+	config.Jobs, err = parseJobs(jobSpecs, config.Plugins)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	config.StressorTimeouts, err = parseStressorTimeouts(stressorTimeouts, config.Plugins, config.Jobs)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	config.StressorStarts = make(map[string]time.Duration)
+	for _, st := range stages {
+		name := strings.ToLower(st.name)
+		config.StressorStarts[name] = st.start
+		// An explicit --stressor-timeout shortens or lengthens the stage
+		if _, ok := config.StressorTimeouts[name]; !ok {
+			config.StressorTimeouts[name] = st.duration
+		}
+	}
+
+	// Check if at least one load type is specified
+	if !replayMode && config.CPU < 0 && config.Memory == "" && config.Storage == "" && config.GPU == 0 && config.PageFault == "" && config.Sparse == "" && config.Network == "" && config.Procs == 0 && config.FD == "" && config.Inodes == 0 && config.Switch == 0 && config.Timers == 0 && len(config.Plugins) == 0 && len(config.Jobs) == 0 {
+		term.Eprintf("Error: At least one load type must be specified\n")
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitConfigError)
+	}
+
+	if patternSpec != "" {
+		if replayMode || (config.CPU < 0 && config.Memory == "") {
+			term.Eprintf("Error: --pattern requires --cpu or --memory and cannot be used in replay mode\n")
+			os.Exit(exitConfigError)
+		}
+		config.Pattern, err = pattern.Parse(patternSpec)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		if last := config.Pattern.Duration() - config.Pattern.Hold; last >= offset+config.Timeout {
+			term.Eprintf("Warning: The run ends at %v, before the last step of the pattern starts at %v\n", offset+config.Timeout, last)
+		}
+	}
+
+	if intervalSpec != "" {
+		config.Interval, err = parseInterval(intervalSpec)
+		if err != nil {
+			term.Eprintf("Error: Invalid --interval: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	if config.Baseline < 0 || (config.Victim != 0 && config.Baseline == 0) {
+		term.Eprintf("Error: --baseline must not be negative, and --victim needs --baseline\n")
+		os.Exit(exitConfigError)
+	}
+	if config.Victim != 0 {
+		if _, err := sysinfo.ReadProcessCPUTime(config.Victim); err != nil {
+			term.Eprintf("Error: Cannot watch the victim process: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	if config.Pprof && config.Listen == "" {
+		term.Eprintf("Error: --pprof needs --listen\n")
+		os.Exit(exitConfigError)
+	}
+	if config.PprofDir != "" {
+		if config.PprofInterval <= 0 {
+			term.Eprintf("Error: --pprof-interval must be positive\n")
+			os.Exit(exitConfigError)
+		}
+		if err := os.MkdirAll(config.PprofDir, 0755); err != nil {
+			term.Eprintf("Error: Cannot create the profile directory: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	if config.ProbeInterval < 0 {
+		term.Eprintf("Error: --probe-interval must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	if config.Probes, err = parseProbes(probesSpec); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if len(config.Probes) > 0 && config.ProbeInterval == 0 {
+		config.ProbeInterval = defaultProbeInterval
+	}
+
+	if config.SLOs, err = parseSLOs(sloExprs); err == nil {
+		config.SLOBudget, err = parseSLOBudget(sloBudgetSpec)
+	}
+	if err == nil && config.SLOWindow <= 0 {
+		err = fmt.Errorf("--slo-window must be positive")
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	for _, s := range config.SLOs {
+		switch {
+		case s.Stressor != "" && !stressorConfigured(config, s.Stressor):
+			term.Eprintf("Error: SLO %s needs the %s load\n", s.Expr, s.Stressor)
+			os.Exit(exitConfigError)
+		case s.Probe != "" && config.ProbeInterval == 0:
+			// An SLO on a probe runs the probe without further options
+			config.ProbeInterval = defaultProbeInterval
+			config.Probes = []string{s.Probe}
+		case s.Probe != "" && len(config.Probes) > 0 && !slices.Contains(config.Probes, s.Probe):
+			config.Probes = append(config.Probes, s.Probe)
+		}
+	}
+	if config.FailUnder, err = parseThresholds(failUnder); err != nil {
+		term.Eprintf("Error: Invalid --fail-under: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+
+	if config.Chaos < 0 {
+		term.Eprintf("Error: --chaos must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	if config.Chaos > 0 {
+		config.ChaosFaults, err = parseChaosFaults(chaosFaults, config)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	// A random seed is shown with the settings so that the run can be repeated
+	if config.Seed == 0 {
+		config.Seed = rand.Uint64()
+	}
+	if config.ChaosSeed == 0 {
+		config.ChaosSeed = config.Seed
+	}
+
+	if config.SoakTemperature != 0 {
+		if config.SoakTemperature < 0 || config.CPU < 0 || config.CPULoad > 0 || replayMode || config.Pattern != nil {
+			term.Eprintf("Error: --soak-temp needs a positive temperature and --cpu, and cannot be combined with a CPU load percentage, --pattern or replay mode\n")
+			os.Exit(exitConfigError)
+		}
+		if _, err := sysinfo.ReadCPUTemperature(); err != nil {
+			term.Eprintf("Error: --soak-temp needs a CPU temperature sensor: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	opts := stressorOptions{
+		cpu: cpu.Options{
+			Cores:             config.CPU,
+			Load:              config.CPULoad,
+			SoakTemperature:   config.SoakTemperature,
+			NoThermalFailsafe: config.NoThermalFailsafe,
+			MaxLoadAverage:    config.MaxLoadAverage,
+			Method:            cpu.Method(config.CPUMethod),
+			BignumBits:        config.CPUBignumBits,
+			Verify:            config.CPUVerify,
+		},
+		memory:  memory.Options{Verify: config.MemoryVerify, Content: memory.Content(config.MemoryContent), Mode: memory.Mode(config.MemoryMode), Workers: config.MemoryWorkers, Seed: config.Seed},
+		storage: storage.Options{Verify: config.StorageVerify, Sync: storage.SyncMode(config.StorageSync), NetworkMix: config.StorageNetworkMix, Seed: config.Seed},
+	}
+	if err := opts.cpu.Method.Validate(); err != nil {
+		term.Eprintf("Error: Invalid --cpu-method: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.CPUBignumBits < cpu.MinBignumBits || config.CPUBignumBits > cpu.MaxBignumBits {
+		term.Eprintf("Error: --cpu-bignum-bits must be in range %d-%d\n", cpu.MinBignumBits, cpu.MaxBignumBits)
+		os.Exit(exitConfigError)
+	}
+	if err := opts.memory.Content.Validate(); err != nil {
+		term.Eprintf("Error: Invalid --memory-content: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.MemoryVerify && config.MemoryContent != "" {
+		term.Eprintf("Error: --memory-verify writes its own test patterns and cannot be combined with --memory-content\n")
+		os.Exit(exitConfigError)
+	}
+	if err := opts.memory.Mode.Validate(); err != nil {
+		term.Eprintf("Error: Invalid --memory-mode: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.MemoryVerify && opts.memory.Mode != "" && opts.memory.Mode != memory.ModeLock {
+		term.Eprintf("Error: --memory-verify cannot be combined with --memory-mode %s, which overwrites the test patterns\n", config.MemoryMode)
+		os.Exit(exitConfigError)
+	}
+	if config.MemoryRate != "" {
+		config.MemoryRateBytes, err = bytesize.ParseAbsolute(config.MemoryRate)
+		if err != nil || config.MemoryRateBytes <= 0 {
+			term.Eprintf("Error: Invalid --memory-rate: %s\n", config.MemoryRate)
+			os.Exit(exitConfigError)
+		}
+		opts.memory.Rate = config.MemoryRateBytes
+	}
+	if config.MemoryWorkers < 0 {
+		term.Eprintf("Error: --memory-workers must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	if err := opts.storage.Sync.Validate(); err != nil {
+		term.Eprintf("Error: Invalid --storage-sync: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	opts.storage.IOMode = storage.IOMode(config.IOMode)
+	if err := opts.storage.IOMode.Validate(); err != nil {
+		term.Eprintf("Error: Invalid --io-mode: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if config.IODepth < 1 || config.FsyncEvery < 0 {
+		term.Eprintf("Error: --io-depth must be at least 1 and --fsync-every must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	opts.storage.IODepth, opts.storage.FsyncEvery = config.IODepth, config.FsyncEvery
+	if config.StoragePath != "" {
+		if err := checkStoragePath(config.StoragePath); err != nil {
+			term.Eprintf("Error: Invalid --storage-path: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		opts.storage.Dir = config.StoragePath
+	}
+	if config.StorageFiles < 0 {
+		term.Eprintf("Error: --storage-files must not be negative\n")
+		os.Exit(exitConfigError)
+	}
+	opts.storage.Files = config.StorageFiles
+	if config.StorageBS != "" {
+		bs, err := bytesize.ParseAbsolute(config.StorageBS)
+		if err != nil || bs <= 0 || bs > maxStorageBlockSize {
+			term.Eprintf("Error: Invalid --storage-bs: %s (1 byte to %s)\n", config.StorageBS, bytesize.Format(maxStorageBlockSize))
+			os.Exit(exitConfigError)
+		}
+		config.StorageBlockSize = int(bs)
+		opts.storage.BlockSize = config.StorageBlockSize
+	}
+	if config.StorageVerify && config.StorageBlockSize%storage.VerifyBlockSize != 0 {
+		term.Eprintf("Error: --storage-bs must be a multiple of %d bytes with --storage-verify\n", storage.VerifyBlockSize)
+		os.Exit(exitConfigError)
+	}
+	if config.CPU >= 0 || hasJob(config, "cpu") {
+		opts.cpu.Calibration, err = loadCalibration(config.Calibration)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.Memory != "" {
+		config.MemorySpec, err = bytesize.Parse(config.Memory)
+		if err != nil {
+			term.Eprintf("Error: Invalid --memory: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		opts.memory.Size, opts.memory.Percent = config.MemorySpec.Bytes, config.MemorySpec.Percent
+	}
+	if config.Storage != "" {
+		config.StorageSpec, err = bytesize.Parse(config.Storage)
+		if err != nil {
+			term.Eprintf("Error: Invalid --storage: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		opts.storage.Size, opts.storage.Percent = config.StorageSpec.Bytes, config.StorageSpec.Percent
+	}
+	if config.GPU != 0 || config.GPUMemory != "" {
+		if !gpu.Supported {
+			term.Eprintf("Error: --gpu needs a build with GPU support: go build -tags gpu (needs cgo and the CUDA driver)\n")
+			os.Exit(exitConfigError)
+		}
+		opts.gpu = gpu.Options{Device: config.GPUDevice, Utilization: config.GPU}
+		if config.GPUMemory != "" {
+			config.GPUMemorySpec, err = bytesize.Parse(config.GPUMemory)
+			if err != nil {
+				term.Eprintf("Error: Invalid --gpu-memory: %v\n", err)
+				os.Exit(exitConfigError)
+			}
+			opts.gpu.MemorySize, opts.gpu.MemoryPercent = config.GPUMemorySpec.Bytes, config.GPUMemorySpec.Percent
+		}
+		if err := opts.gpu.Validate(); err != nil {
+			term.Eprintf("Error: Invalid --gpu: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.PageFault != "" {
+		opts.pageFault = pagefault.Options{Rate: config.PageFaultRate, Dir: config.PageFaultDir, Seed: config.Seed}
+		if ratio, ok := strings.CutSuffix(config.PageFault, "x"); ok {
+			opts.pageFault.MemoryRatio, err = strconv.ParseFloat(ratio, 64)
+		} else {
+			opts.pageFault.Size, err = bytesize.ParseAbsolute(config.PageFault)
+		}
+		if err == nil {
+			err = opts.pageFault.Validate()
+		}
+		if err != nil {
+			term.Eprintf("Error: Invalid --pagefault: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.Sparse != "" {
+		opts.sparse = sparse.Options{Rate: config.SparseRate, Dir: config.SparseDir, Seed: config.Seed}
+		opts.sparse.Size, err = bytesize.ParseAbsolute(config.Sparse)
+		if err == nil {
+			err = opts.sparse.Validate()
+		}
+		if err != nil {
+			term.Eprintf("Error: Invalid --sparse: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.Network != "" {
+		opts.network, err = networkOptions(config)
+		if err != nil {
+			term.Eprintf("Error: Invalid --network: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.Procs != 0 {
+		opts.procs = procs.Options{Max: config.Procs, Rate: config.ProcsRate, Lifetime: config.ProcsLifetime}
+		if err := opts.procs.Validate(); err != nil {
+			term.Eprintf("Error: Invalid --procs: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.FD != "" || config.Inodes != 0 {
+		opts.files = fsmeta.Options{Inodes: config.Inodes, Depth: config.FilesDepth, Rate: config.FilesRate, Dir: config.FilesDir}
+		opts.files.FDs, err = parseFDs(config.FD)
+		if err == nil {
+			err = opts.files.Validate()
+		}
+		if err != nil {
+			term.Eprintf("Error: Invalid --fd or --inodes: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+	if config.Switch != 0 || config.Timers != 0 {
+		opts.scheduler = sched.Options{Pairs: config.Switch, Rate: config.SwitchRate, Timers: config.Timers, TimerInterval: config.TimerInterval}
+		if err := opts.scheduler.Validate(); err != nil {
+			term.Eprintf("Error: Invalid --switch or --timers: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	// Hard caps apply regardless of how each stressor computes its target
+	if config.MaxCPUPercent < 0 || config.MaxCPUPercent > 100 {
+		term.Eprintf("Error: --max-cpu-percent must be in range 0-100\n")
+		os.Exit(exitConfigError)
+	}
+	// The caps are budgets shared by all stressors, named jobs included, that
+	// each reserves from before it allocates, so that together they never
+	// exceed them: CPU in millicores, memory with the VRAM of --gpu-memory, and
+	// disk space with the files of --pagefault and --sparse
+	if config.MaxCPUPercent > 0 {
+		opts.cpu.Budget = budget.New(int64(config.MaxCPUPercent / 100 * sysinfo.EffectiveCPUs() * 1000))
+	}
+	if config.MaxMemory != "" {
+		limit, err := bytesize.ParseAbsolute(config.MaxMemory)
+		if err != nil {
+			term.Eprintf("Error: Invalid --max-memory: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		opts.memory.Budget = budget.New(limit)
+		opts.gpu.Budget = opts.memory.Budget
+	}
+	if config.MaxDisk != "" {
+		limit, err := bytesize.ParseAbsolute(config.MaxDisk)
+		if err != nil {
+			term.Eprintf("Error: Invalid --max-disk: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		opts.storage.Budget = budget.New(limit)
+		opts.pageFault.Budget = opts.storage.Budget
+		opts.sparse.Budget = opts.storage.Budget
+		opts.files.Budget = opts.storage.Budget
+	}
+	switch config.DropCaches {
+	case "", dropCachesBefore:
+	case dropCachesBetweenPhases:
+		opts.storage.DropCaches = true
+	default:
+		term.Eprintf("Error: Invalid --drop-caches %q (before or between-phases)\n", config.DropCaches)
+		os.Exit(exitConfigError)
+	}
+
+	if err := checkCapacity(config); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	warnContainerLimits(opts)
+	if config.DryRun {
+		printDryRun(config, replayProfile)
+		return
+	}
+	// Before any load thread exists, so that every one of them stays off the CPU
+	if config.OverheadCPU >= 0 {
+		if err := overhead.Reserve(config.OverheadCPU); err != nil {
+			term.Eprintf("Error: Invalid --overhead-cpu: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+	}
+
+	// Every change of run state is published on the bus; the console output and
+	// Grafana annotations are subscribers
+	bus := &events.Bus{}
+	bus.Subscribe(printEvent)
+	if config.GrafanaURL != "" {
+		client := grafana.NewClient(config.GrafanaURL, config.GrafanaToken, splitList(config.GrafanaTags))
+		bus.Subscribe(deliverAsync("Grafana annotations", annotateEvents(client)))
+	}
+	var hook *webhook
+	if config.NotifyURL != "" {
+		hook = newWebhook(config.NotifyURL)
+		bus.Subscribe(deliverAsync("notifications", hook.observe))
+	}
+	var output *outputWriter
+	if config.Output != outputText || config.ReportFile != "" {
+		output, err = newOutputWriter(config.Output, config.ReportFile)
+		if err != nil {
+			term.Eprintf("Error: Invalid --report-file: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		bus.Subscribe(output.observe)
+	}
+	health := newRunHealth()
+	bus.Subscribe(health.observe)
+	// The control endpoints are added to the same mux once the stressors exist
+	var mux *http.ServeMux
+	if config.Listen != "" {
+		mux = http.NewServeMux()
+		health.register(mux)
+		if config.Pprof {
+			registerPprof(mux)
+		}
+		startHTTPServer(config.Listen, mux)
+	}
+
+	// Node, pod and container details make results from many pods attributable
+	environment := sysinfo.ReadEnvironment()
+	if config.CloudMetadata {
+		if instance, err := sysinfo.ReadCloudInstance(cloudMetadataTimeout); err != nil {
+			term.Eprintf("Warning: Cloud instance metadata not available: %v\n", err)
+		} else {
+			environment.Cloud = instance
+		}
+	}
+	settings := describeLoad(config, replayProfile)
+	if description := environment.Describe(); description != "" {
+		settings = append(settings, i18n.T("Environment: ")+description)
+	}
+	bus.Publish(events.Event{
+		Type: events.RunStarted,
+		Fields: map[string]any{
+			"duration":    config.Timeout.String(),
+			"settings":    settings,
+			"environment": environment.Labels(),
+		},
+	})
+
+	// Keep laptops and desktops from suspending in the middle of a soak test
+	if !config.AllowSleep {
+		if release, err := inhibit.Acquire("stress-go load test in progress"); err != nil {
+			term.Eprintf("Warning: Could not prevent system sleep: %v\n", err)
+		} else {
+			defer release()
+		}
+	}
+
+	// シグナルハンドリング
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	notifyServiceStop(sigChan)
+
+	// Hold at the start barrier so that every host in a distributed run starts together
+	if !config.StartAt.IsZero() && !waitForStart(config.StartAt, sigChan) {
+		finishRun(bus, 0, i18n.T("Stress test cancelled before start."))
+		return
+	}
+	var base *baseline
+	if config.Baseline > 0 {
+		var ok bool
+		if base, ok = measureBaseline(config.Baseline, config.Victim, probeOptions(config, nil), sigChan); !ok {
+			finishRun(bus, 0, i18n.T("Stress test cancelled before start."))
+			return
+		}
+	}
+
+	hooks := newRunHooks(config, settings)
+	if err := hooks.runPre(); err != nil {
+		term.Eprintf("Error: --pre-cmd failed: %v\n", err)
+		finishRun(bus, exitStartupFailure, i18n.T("Stress test not started."))
+		return
+	}
+	var dropper cacheDropper
+	if config.DropCaches != "" && dropper.drop() {
+		term.Println("Dropped the page cache before the load")
+	}
+
+	ctx, dl, cancel := deadline.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	if config.LevelStep == 0 {
+		go handleExtendSignals(ctx, dl, config.ExtendBy, bus)
+	}
+
+	var wg sync.WaitGroup
+	recorder := metrics.NewRecorder()
+	recorder.SetLabels(environment.Labels())
+	recorder.OnMessage(printMessage)
+	recorder.OnSample(publishAdjustments(bus))
+	startTime := time.Now()
+	// A resumed run counts from the start of its first segment
+	runStart := startTime
+	if resumed != nil {
+		seedCounts(recorder, resumed.Counts)
+		runStart = resumed.Started
+	}
+	supervisor := &stressorSupervisor{cancel: cancel, recorder: recorder, bus: bus, failFast: config.FailFast}
+	meter := startPowerMeter(config)
+	smartHealth := startSmart(config)
+	slos := newSLOMonitor(config.SLOs)
+	self := newOverheadMeter(config.OverheadCPU)
+	summarize := summarizer(startTime, config.Seed, recorder, supervisor, meter, self, base, slos, config.SLOBudget, config.FailUnder)
+	if config.SummaryJSON != "" {
+		bus.Subscribe(writeSummary(config.SummaryJSON, summarize))
+	}
+	if output != nil {
+		output.summarize = summarize
+		recorder.OnSample(output.sample)
+		recorder.OnMessage(output.message)
+	}
+	if hook != nil {
+		hook.setSummary(summarize)
+	}
+	bus.Subscribe(hooks.observe)
+	if config.DropCaches == dropCachesBetweenPhases {
+		bus.Subscribe(dropper.observe)
+	}
+	opts.cpu.Recorder = recorder
+	opts.memory.Recorder = recorder
+	opts.storage.Recorder = recorder
+	opts.gpu.Recorder = recorder
+	opts.pageFault.Recorder = recorder
+	opts.sparse.Recorder = recorder
+	opts.network.Recorder = recorder
+	opts.procs.Recorder = recorder
+	opts.files.Recorder = recorder
+	opts.scheduler.Recorder = recorder
+	// One monitor samples the system for the dashboard, the safety guard and the
+	// percentage targets of the memory and storage loads
+	resources := monitor.New(monitor.DefaultInterval)
+	opts.memory.Monitor = resources
+	opts.storage.Monitor = resources
+	for _, dir := range loadDirs(config, opts) {
+		resources.Watch(dir)
+	}
+	go overhead.Run(func() { resources.Run(ctx) })
+
+	// Replay a recorded profile instead of fixed loads
+	var registry stressor.Registry
+	if replayProfile != nil {
+		registerReplay(&registry, replayProfile, offset, opts)
+	}
+	if config.CPU >= 0 {
+		registry.Register(stressor.NewCPU(opts.cpu))
+	}
+	if config.Memory != "" {
+		registry.Register(stressor.NewMemory(opts.memory))
+	}
+	if config.Storage != "" {
+		registry.Register(stressor.NewStorage(opts.storage))
+	}
+	if config.GPU != 0 {
+		registry.Register(stressor.NewGPU(opts.gpu))
+	}
+	if config.PageFault != "" {
+		registry.Register(stressor.NewPageFault(opts.pageFault))
+	}
+	if config.Sparse != "" {
+		registry.Register(stressor.NewSparse(opts.sparse))
+	}
+	if config.Network != "" {
+		registry.Register(stressor.NewNetwork(opts.network))
+	}
+	if config.Procs != 0 {
+		registry.Register(stressor.NewProcs(opts.procs))
+	}
+	if config.FD != "" || config.Inodes != 0 {
+		registry.Register(stressor.NewFiles(opts.files))
+	}
+	if config.Switch != 0 || config.Timers != 0 {
+		registry.Register(stressor.NewScheduler(opts.scheduler))
+	}
+	for _, j := range config.Jobs {
+		registry.Register(j.stressor(config, opts))
+	}
+	for _, pluginOpts := range config.Plugins {
+		pluginOpts.Recorder = recorder
+		pluginOpts.Seed = config.Seed
+		registry.Register(plugin.New(pluginOpts))
+	}
+	var names []string
+	for _, s := range registry.Stressors() {
+		names = append(names, s.Name())
+	}
+	health.expect(names)
+	control := newRunControl(&registry, dl, health, bus)
+	if mux != nil {
+		control.register(mux)
+		registerExpvar(mux, &registry, recorder, supervisor)
+	}
+	if config.LevelStep > 0 {
+		go handleLevelSignals(ctx, control, config.LevelStep)
+	}
+	switch {
+	case config.MetricsAddr == "":
+	case config.MetricsAddr == config.Listen:
+		registerMetrics(ctx, mux, dl, recorder)
+	default:
+		metricsMux := http.NewServeMux()
+		registerMetrics(ctx, metricsMux, dl, recorder)
+		startHTTPServer(config.MetricsAddr, metricsMux)
+	}
+	var phase progressPhase
+	bus.Subscribe(phase.observe)
+	if config.Pattern != nil {
+		startPattern(ctx, config.Pattern, offset, &registry, recorder, bus)
+	}
+	if config.Interval != nil {
+		memory := []string{"Memory"}
+		for _, j := range config.Jobs {
+			if j.kind == "memory" {
+				memory = append(memory, j.name)
+			}
+		}
+		startInterval(ctx, config.Interval, offset, &registry, memory, bus)
+	}
+	if config.RampUp > 0 || config.RampDown > 0 {
+		startRamp(ctx, dl, config.RampUp, config.RampDown, &registry)
+	}
+	var guard *safetyGuard
+	if config.MaxMemUsed > 0 || config.MaxDiskFill > 0 {
+		guard = newSafetyGuard(config, resources, &registry, recorder)
+		go overhead.Run(func() { guard.run(ctx) })
+	}
+	if config.Chaos > 0 {
+		go runChaos(ctx, config.Chaos, config.ChaosSeed, config.ChaosFaults, &registry, recorder)
+	}
+	// The scenario runs its stages itself so that SIGHUP can reschedule them
+	var scenario *scenarioRun
+	if scenarioPath != "" {
+		scenario = &scenarioRun{path: scenarioPath, start: startTime, ctx: ctx, wg: &wg, supervisor: supervisor,
+			registry: &registry, health: health, bus: bus, build: func(st stage) (stressor.Stressor, error) {
+				return stageStressor(st, config, opts, &registry)
+			}}
+		scenario.launch(stages, config.StressorTimeouts)
+		go handleReloadSignals(ctx, scenario)
+	}
+	startStressors(ctx, &wg, supervisor, &registry, config.StressorTimeouts, scenario.owns)
+
+	// Show progress
+	ends := stressorEnds(&registry, config.StressorTimeouts, config.StressorStarts, startTime)
+	var dash *dashboard
+	if !config.NoDashboard && term.Interactive() {
+		dash = &dashboard{monitor: resources, registry: &registry, guard: guard}
+	}
+	go overhead.Run(func() { showProgress(ctx, dl, &phase, ends, dash) })
+
+	// Collect system metrics for the report
+	go overhead.Run(func() { sampleSystem(ctx, recorder) })
+	go overhead.Run(func() { self.measure(ctx, recorder) })
+	if meter != nil {
+		go overhead.Run(func() { measurePower(ctx, meter, recorder) })
+	}
+	probesDone := startProbes(ctx, probeOptions(config, recorder))
+	profilesDone := dumpProfiles(ctx, config.PprofDir, config.PprofInterval)
+	slos.start(ctx, config.SLOWindow, recorder)
+	loaded := make(chan systemLevels, 1)
+	if base != nil {
+		go func() {
+			loaded <- measureLevels(ctx, config.Victim)
+		}()
+	}
+
+	// Export metrics for node_exporter's textfile collector
+	textfileDone := make(chan struct{})
+	if config.TextfileDir != "" {
+		go func() {
+			defer close(textfileDone)
+			overhead.Run(func() { exportTextfile(ctx, config.TextfileDir, config.TextfileInterval, dl, recorder) })
+		}()
+	} else {
+		close(textfileDone)
+	}
+
+	// Checkpoint the run for "stress-go resume"
+	var state *checkpoint
+	if config.State != "" {
+		state = &checkpoint{path: config.State, args: stateArgs, started: runStart, offset: offset, start: startTime,
+			dl: dl, recorder: recorder, registry: &registry}
+		go state.run(ctx, config.StateInterval)
+	}
+
+	// Watch abort conditions
+	tripChan := make(chan *watchdog.Trip, 1)
+	if len(config.AbortIf) > 0 {
+		go func() {
+			if trip := watchdog.Watch(ctx, config.AbortIf, recorder); trip != nil {
+				tripChan <- trip
+			}
+		}()
+	}
+
+	// Watch the SMART health of the disk under test
+	smartChan := make(chan string, 1)
+	if smartHealth != nil {
+		go func() {
+			if reason := smartHealth.watch(ctx, recorder); reason != "" {
+				smartChan <- reason
+			}
+		}()
 	}
 
-	timeout, err := time.ParseDuration(timeoutStr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Invalid time format: %v\n", err)
-		os.Exit(1)
+	// Tell systemd the stressors are running and keep its watchdog fed until
+	// exit, as long as the stressors record samples and then the cleanup goes on
+	notifySystemd("READY=1\nSTATUS=Applying load: " + strings.Join(settings, ", "))
+	keepalive := &systemd.Watchdog{}
+	keepalive.Progress()
+	recorder.OnSample(func(metrics.Sample) { keepalive.Progress() })
+	keepaliveCtx, stopKeepalive := context.WithCancel(context.Background())
+	defer stopKeepalive()
+	go keepalive.Run(keepaliveCtx, term.Eprintf)
+
+	var trip *watchdog.Trip
+	var smartTrip string
+	interrupted := false
+	stopTimeout := config.StopTimeout
+	select {
+	case <-sigChan:
+		bus.Publish(events.Event{Type: events.RunStopping, Message: i18n.T("Interrupt signal received")})
+		interrupted = true
+		stopTimeout = graceTimeout(config.StopTimeout, config.GracePeriod)
+		cancel()
+	case trip = <-tripChan:
+		rampDown(&registry, trip.String())
+		cancel()
+		bus.Publish(events.Event{Type: events.WatchdogTripped, Message: trip.String()})
+	case smartTrip = <-smartChan:
+		rampDown(&registry, smartTrip)
+		cancel()
+		bus.Publish(events.Event{Type: events.WatchdogTripped, Message: smartTrip})
+	case <-ctx.Done():
 	}
-	config.Timeout = timeout
+	stopped := time.Now()
 
-	// Check if at least one load type is specified
-	if config.CPU < 0 && config.Memory == "" && config.Storage == "" {
-		fmt.Fprintf(os.Stderr, "Error: At least one load type must be specified\n")
-		printUsage()
-		os.Exit(1)
+	notifySystemd("STOPPING=1\nSTATUS=Cleaning up")
+	scenario.close()
+	if !waitForCleanup(&wg, stopTimeout, sigChan, keepalive) {
+		finishRun(bus, exitCleanupTimeout, i18n.T("Stress test stopped before cleanup finished."))
+	}
+	verifyCleanup()
+	// An interrupted run can be resumed for the time it had left
+	if state != nil {
+		if err := state.save(stopped, !interrupted); err != nil {
+			term.Eprintf("Warning: Failed to write the run state: %v\n", err)
+		} else if interrupted {
+			term.Printf("Run state saved; continue with \"stress-go resume --state %s\"\n", config.State)
+		}
+	}
+	<-textfileDone
+	<-probesDone
+	<-profilesDone
+	deviations := recorder.Deviations()
+	printDeviationReport(deviations)
+	printWorkerReport(recorder.WorkerUsages())
+	failures := supervisor.Failures()
+	printFailures(failures)
+	verifications := recorder.Verifications()
+	if !config.Burnin {
+		printVerificationReport(verifications)
 	}
+	printChaosReport(config.ChaosSeed, recorder.Faults())
+	printProbeReport(recorder.Latencies(), base)
+	sloResults := slos.Results()
+	printSLOReport(sloResults, config.SLOWindow)
+	thresholds := evaluateThresholds(config.FailUnder, deviations, recorder.Values())
+	printThresholdReport(thresholds)
+	if base != nil {
+		base.loaded = <-loaded
+		printBaselineReport(base)
+	}
+	meter.Sample()
+	printPowerReport(meter.Total())
+	printOverheadReport(self.total())
+	smartHealth.printReport()
 
-	fmt.Printf("Starting stress test...\n")
-	fmt.Printf("Duration: %v\n", config.Timeout)
-	if config.CPU >= 0 {
-		if config.CPU == 0 {
-			fmt.Printf("CPU load: all cores\n")
+	if config.ReportHTML != "" {
+		info := report.RunInfo{
+			Start:    startTime,
+			End:      time.Now(),
+			Timeout:  dl.Total().Truncate(time.Second),
+			Settings: settings,
+		}
+		if err := report.WriteHTML(config.ReportHTML, info, recorder); err != nil {
+			term.Eprintf("Error: Failed to write HTML report: %v\n", err)
 		} else {
-			fmt.Printf("CPU load: %d cores\n", config.CPU)
+			term.Printf("HTML report written to %s\n", config.ReportHTML)
 		}
 	}
-	if config.Memory != "" {
-		fmt.Printf("Memory load: %s\n", config.Memory)
+
+	var code int
+	var message string
+	switch code = outcomeExitCode(deviations); {
+	case trip != nil:
+		code, message = exitWatchdogAbort, i18n.Sprintf("Stress test aborted by watchdog: %s", trip)
+	case smartTrip != "":
+		code, message = exitWatchdogAbort, i18n.Sprintf("Stress test aborted by watchdog: %s", smartTrip)
+	case supervisor.crashed.Load():
+		code, message = exitFailure, i18n.T("Stress test stopped because a stressor crashed.")
+	case code == exitStartupFailure:
+		message = i18n.T("Stress test failed: a stressor could not start.")
+	case verificationErrors(verifications) > 0:
+		code, message = exitVerificationFailure, i18n.Sprintf("Stress test failed: verification found %d error(s).", verificationErrors(verifications))
+	case len(exceededSLOs(sloResults, config.SLOBudget)) > 0:
+		code, message = exitSLOViolation, i18n.Sprintf("Stress test failed: %s violated in more windows than the budget of %s.",
+			strings.Join(exceededSLOs(sloResults, config.SLOBudget), ", "), config.SLOBudget)
+	case len(failedThresholds(thresholds)) > 0:
+		code, message = exitThresholdFailure, i18n.Sprintf("Stress test failed: %s not met.", strings.Join(failedThresholds(thresholds), ", "))
+	case len(failures) > 0:
+		code, message = exitFailure, i18n.Sprintf("Stress test failed: %d stressor(s) returned an error.", len(failures))
+	case code != 0:
+		message = i18n.T("Stress test completed with failures.")
+	default:
+		message = i18n.T("Stress test completed.")
 	}
-	if config.Storage != "" {
-		fmt.Printf("Storage load: %s\n", config.Storage)
+	if config.Burnin {
+		issueCertificate(config.Certificate, certificate{
+			start:         runStart,
+			end:           time.Now(),
+			elapsed:       offset + time.Since(startTime),
+			planned:       (offset + dl.Total()).Truncate(time.Second),
+			settings:      settings,
+			deviations:    deviations,
+			verifications: verifications,
+			code:          code,
+			message:       message,
+			interrupted:   interrupted,
+		})
 	}
-	fmt.Println()
+	finishRun(bus, code, message)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
-	defer cancel()
+// waitForStart sleeps until the scheduled start time and reports how precisely
+// it was met. It returns false when a stop signal arrives while waiting.
+func waitForStart(at time.Time, sigChan <-chan os.Signal) bool {
+	if wait := time.Until(at); wait > 0 {
+		term.Printf("Waiting until %s to start (in %v)...\n", at.Local().Format("15:04:05.000"), wait.Truncate(time.Millisecond))
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-sigChan:
+			return false
+		}
+	}
+	term.Printf("Start barrier released %v after the scheduled time\n\n", time.Since(at).Truncate(time.Microsecond))
+	return true
+}
 
-	// シグナルハンドリング
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// finishRun publishes the end of the run and exits with code unless it is 0.
+func finishRun(bus *events.Bus, code int, message string) {
+	bus.Publish(events.Event{Type: events.RunFinished, Message: message, Fields: map[string]any{"exit_code": code}})
+	if code != 0 {
+		os.Exit(code)
+	}
+}
 
-	var wg sync.WaitGroup
+// outcomeExitCode classifies a finished run from the per-stressor results: a stressor
+// that reported problems without ever producing load failed to start, and any other
+// degraded stressor makes the run a partial completion.
+func outcomeExitCode(deviations []metrics.Deviation) int {
+	code := 0
+	for _, d := range deviations {
+		switch {
+		case len(d.Issues) > 0 && d.MaxAchieved == 0:
+			return exitStartupFailure
+		case d.Degraded:
+			code = exitPartial
+		}
+	}
+	return code
+}
 
-	// Start CPU load
-	if config.CPU >= 0 {
+// stressorOptions holds the options for each stressor as configured on the command line.
+type stressorOptions struct {
+	cpu       cpu.Options
+	memory    memory.Options
+	storage   storage.Options
+	gpu       gpu.Options
+	pageFault pagefault.Options
+	sparse    sparse.Options
+	network   network.Options
+	procs     procs.Options
+	files     fsmeta.Options
+	scheduler sched.Options
+}
+
+// startStressors runs every registered stressor in its own goroutine under the
+// supervisor, except those owned reports the --scenario run starts itself.
+// Stressors with an entry in timeouts stop on their own once it elapses, while
+// the others keep running.
+func startStressors(ctx context.Context, wg *sync.WaitGroup, supervisor *stressorSupervisor, registry *stressor.Registry, timeouts map[string]time.Duration, owned func(string) bool) {
+	for _, s := range registry.Stressors() {
+		if owned(s.Name()) {
+			continue
+		}
+		if timeout, ok := timeouts[strings.ToLower(s.Name())]; ok {
+			s = stressor.WithTimeout(s, timeout)
+		}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			cpu.GenerateLoad(ctx, config.CPU)
+			supervisor.run(s.Name(), func() error { return stressor.Run(ctx, s) })
 		}()
 	}
+}
 
-	// Start memory load
-	if config.Memory != "" {
-		memorySize, err := parseSize(config.Memory)
+// networkOptions builds the options of the --network load, whose rate is a
+// size per second for tcp and udp and a number of connections for churn.
+func networkOptions(config Config) (network.Options, error) {
+	opts := network.Options{Mode: network.Mode(config.Network), Target: config.NetworkTarget, Connections: config.NetworkConnections}
+	if err := opts.Mode.Validate(); err != nil {
+		return opts, err
+	}
+	if config.NetworkSize != "" {
+		size, err := bytesize.ParseAbsolute(config.NetworkSize)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to parse memory size: %v\n", err)
-			os.Exit(1)
+			return opts, err
+		}
+		opts.Size = int(size)
+	}
+	if config.NetworkRate != "" {
+		var err error
+		if opts.Mode == network.ModeChurn {
+			opts.Rate, err = strconv.ParseFloat(config.NetworkRate, 64)
+		} else {
+			var rate int64
+			rate, err = bytesize.ParseAbsolute(config.NetworkRate)
+			opts.Rate = float64(rate)
+		}
+		if err != nil || opts.Rate <= 0 {
+			return opts, fmt.Errorf("invalid rate %q", config.NetworkRate)
 		}
-		
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			memory.GenerateLoad(ctx, memorySize)
-		}()
 	}
+	return opts, opts.Validate()
+}
 
-	// Start storage load
-	if config.Storage != "" {
-		storageSize, err := parseSize(config.Storage)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to parse storage size: %v\n", err)
-			os.Exit(1)
+// parseFDs parses a --fd value: a number of descriptors, or a percentage of the
+// open file limit of the process. An empty value holds none.
+func parseFDs(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		share, err := strconv.ParseFloat(percent, 64)
+		if err != nil || share <= 0 || share > 100 {
+			return 0, fmt.Errorf("invalid share of the open file limit %q: expected a percentage of 0-100", value)
 		}
-		
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			storage.GenerateLoad(ctx, storageSize)
-		}()
+		limit := fsmeta.OpenFileLimit()
+		if limit == 0 {
+			return 0, fmt.Errorf("the open file limit is unknown on this platform; give a number of descriptors")
+		}
+		return max(int(float64(limit)*share/100), 1), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of descriptors %q", value)
 	}
+	return n, nil
+}
 
-	// Show progress
-	go showProgress(ctx, config.Timeout)
+// parseCPUSpec parses a --cpu value such as "4" or "4x60%" together with the
+// --cpu-load percentage into the core count (-1 for no CPU load) and the load
+// per core (0 for fully busy cores). A load without a core count uses all cores.
+func parseCPUSpec(spec string, loadPercent float64) (int, float64, error) {
+	coresSpec, percentSpec, hasLoad := strings.Cut(spec, "x")
+	cores := -1
+	if coresSpec != "" {
+		n, err := strconv.Atoi(coresSpec)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid --cpu %q (expected a core count such as 4, or 4x60%%)", spec)
+		}
+		cores = n
+	}
+	if hasLoad {
+		if loadPercent != 0 {
+			return 0, 0, fmt.Errorf("--cpu %q already gives the load; --cpu-load cannot be used with it", spec)
+		}
+		p, err := strconv.ParseFloat(strings.TrimSuffix(percentSpec, "%"), 64)
+		if err != nil || coresSpec == "" {
+			return 0, 0, fmt.Errorf("invalid --cpu %q (expected a core count such as 4, or 4x60%%)", spec)
+		}
+		loadPercent = p
+	}
+	if loadPercent == 0 && !hasLoad {
+		return cores, 0, nil
+	}
+	if loadPercent <= 0 || loadPercent > 100 {
+		return 0, 0, fmt.Errorf("CPU load must be in range 0-100%%: %g", loadPercent)
+	}
+	return max(cores, 0), loadPercent / 100, nil
+}
 
-	select {
-	case <-sigChan:
-		fmt.Println("\nInterrupt signal received. Stopping stress test...")
-		cancel()
-	case <-ctx.Done():
+// parseStressorTimeouts parses --stressor-timeout values such as "storage=10m".
+// Names are matched case-insensitively against the built-in stressors and plugins.
+func parseStressorTimeouts(specs []string, plugins []plugin.Options, jobs []job) (map[string]time.Duration, error) {
+	known := []string{"cpu", "memory", "storage", "gpu", "pagefault", "sparse", "network", "procs", "files", "scheduler"}
+	for _, p := range plugins {
+		known = append(known, strings.ToLower(p.Name))
+	}
+	for _, j := range jobs {
+		known = append(known, strings.ToLower(j.name))
+	}
+
+	timeouts := make(map[string]time.Duration)
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid stressor timeout %q: expected name=duration", spec)
+		}
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("invalid stressor timeout %q: unknown stressor %q", spec, name)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid stressor timeout %q: expected a positive duration", spec)
+		}
+		timeouts[name] = timeout
 	}
+	return timeouts, nil
+}
+
+// stressorSupervisor collects the errors returned by the stressors and stops the
+// whole run when a stressor panics (or fails, with --fail-fast), so that every
+// other stressor still releases its memory and temporary files before exit.
+type stressorSupervisor struct {
+	cancel   context.CancelFunc
+	recorder *metrics.Recorder
+	bus      *events.Bus
+	failFast bool
+	crashed  atomic.Bool
 
-	wg.Wait()
-	fmt.Println("Stress test completed.")
+	mu       sync.Mutex
+	failures []stressorFailure
 }
 
-func parseSize(sizeStr string) (int64, error) {
-	sizeStr = strings.TrimSpace(sizeStr)
-	
-	// Percentage specification
-	if strings.HasSuffix(sizeStr, "%") {
-		percentStr := strings.TrimSuffix(sizeStr, "%")
-		percent, err := strconv.ParseFloat(percentStr, 64)
-		if err != nil {
-			return 0, fmt.Errorf("invalid percentage: %s", percentStr)
+// stressorFailure is a fatal error returned by one stressor.
+type stressorFailure struct {
+	name string
+	err  error
+}
+
+// run executes a stressor and records the error it returns. A panic is turned into
+// a recorded failure that stops the run; the stressor's own deferred cleanup has
+// already run by the time the panic is recovered.
+func (s *stressorSupervisor) run(name string, fn func() error) {
+	s.bus.Publish(events.Event{Type: events.StressorStarted, Stressor: name})
+	defer s.bus.Publish(events.Event{Type: events.StressorStopped, Stressor: name})
+
+	var stressorErr error
+	err := supervise.Run(func() { stressorErr = fn() })
+	if err == nil {
+		if stressorErr != nil {
+			s.fail(name, stressorErr)
+			if s.failFast {
+				s.bus.Publish(events.Event{Type: events.RunStopping, Stressor: name, Message: i18n.Sprintf("%s failed (--fail-fast)", name)})
+				s.cancel()
+			}
+		}
+		return
+	}
+	s.crashed.Store(true)
+	s.fail(name, fmt.Errorf("stressor crashed: %v", err))
+	if panicErr, ok := err.(*supervise.PanicError); ok {
+		term.Eprintf("%s", panicErr.Stack)
+	}
+	s.bus.Publish(events.Event{Type: events.RunStopping, Stressor: name, Message: i18n.Sprintf("%s crashed", name)})
+	s.cancel()
+}
+
+// fail records a fatal stressor error for the summary and the report.
+func (s *stressorSupervisor) fail(name string, err error) {
+	s.bus.Publish(events.Event{Type: events.StressorFailed, Stressor: name, Message: err.Error()})
+	s.recorder.Flag(name, err.Error())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, stressorFailure{name: name, err: err})
+}
+
+// Failures returns the fatal errors in the order the stressors returned them.
+func (s *stressorSupervisor) Failures() []stressorFailure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stressorFailure(nil), s.failures...)
+}
+
+// cleanupBeat is how often waitForCleanup reports to the systemd watchdog that
+// the main loop is still waiting on the cleanup.
+const cleanupBeat = time.Second
+
+// waitForCleanup waits for all stressors to finish their cleanup. It gives up when
+// the timeout elapses or another stop signal arrives, and reports whether cleanup completed.
+// Until then it keeps the systemd watchdog fed, as the stressors no longer record samples.
+func waitForCleanup(wg *sync.WaitGroup, timeout time.Duration, sigChan <-chan os.Signal, keepalive *systemd.Watchdog) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	beat := time.NewTicker(cleanupBeat)
+	defer beat.Stop()
+
+	for {
+		select {
+		case <-done:
+			return true
+		case <-beat.C:
+			keepalive.Progress()
+			continue
+		case <-expired:
+			term.Eprintf("Error: Cleanup did not finish within %v\n", timeout)
+		case <-sigChan:
+			term.Eprintf("Error: Second stop signal received, exiting without finishing cleanup\n")
 		}
-		if percent < 0 || percent > 100 {
-			return 0, fmt.Errorf("percentage must be in range 0-100: %f", percent)
+		return false
+	}
+}
+
+// verifyCleanup checks, once every stressor has stopped, that their memory was
+// released and their temporary directories removed. It removes directories left
+// behind and warns about anything it cannot clean up.
+func verifyCleanup() {
+	var problems []string
+	if held := memory.HeldBytes(); held > 0 {
+		problems = append(problems, i18n.Sprintf("%s of memory still held", bytesize.Format(held)))
+	}
+	for _, dir := range tempdirs.Remaining() {
+		if err := os.RemoveAll(dir); err != nil {
+			problems = append(problems, i18n.Sprintf("%s could not be removed: %v", dir, err))
+			continue
 		}
-		// Return negative value to distinguish percentage
-		return int64(-percent), nil
+		term.Eprintf("Warning: Removed %s left behind by a stressor\n", dir)
+	}
+	if len(problems) > 0 {
+		term.Eprintf("Warning: Cleanup incomplete: %s\n", strings.Join(problems, "; "))
+		return
+	}
+	term.Println("Cleanup verified: memory released and temporary files removed")
+}
+
+// graceMargin is the share of --grace-period kept back after the cleanup for
+// writing the summary, reports and notifications before SIGKILL, at most
+// maxGraceMargin.
+const (
+	graceMargin    = 0.2
+	maxGraceMargin = 5 * time.Second
+)
+
+// graceTimeout returns how long cleanup may take after a stop signal: the stop
+// timeout, shortened so that the run still finishes within the grace period.
+func graceTimeout(stopTimeout, gracePeriod time.Duration) time.Duration {
+	if gracePeriod <= 0 {
+		return stopTimeout
 	}
+	margin := min(time.Duration(float64(gracePeriod)*graceMargin), maxGraceMargin)
+	budget := max(gracePeriod-margin, time.Millisecond)
+	if stopTimeout > 0 && stopTimeout < budget {
+		return stopTimeout
+	}
+	return budget
+}
 
-	// Absolute value specification
-	re := regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([KMGT]?B?)?$`)
-	matches := re.FindStringSubmatch(strings.ToUpper(sizeStr))
-	if matches == nil {
-		return 0, fmt.Errorf("invalid size format: %s", sizeStr)
+// notifySystemd sends a state notification when running as a systemd Type=notify service.
+func notifySystemd(state string) {
+	if _, err := systemd.Notify(state); err != nil {
+		term.Eprintf("Warning: %v\n", err)
 	}
+}
 
-	value, err := strconv.ParseFloat(matches[1], 64)
-	if err != nil {
-		return 0, err
-	}
-
-	unit := matches[2]
-	multiplier := int64(1)
-
-	switch unit {
-	case "", "B":
-		multiplier = 1
-	case "KB", "K":
-		multiplier = 1024
-	case "MB", "M":
-		multiplier = 1024 * 1024
-	case "GB", "G":
-		multiplier = 1024 * 1024 * 1024
-	case "TB", "T":
-		multiplier = 1024 * 1024 * 1024 * 1024
+// describeContent explains a --memory-content kind in the settings.
+func describeContent(content memory.Content) string {
+	switch content {
+	case memory.ContentCompressible:
+		return i18n.T("compressible, a short sequence repeated over every page")
+	case memory.ContentIncompressible:
+		return i18n.T("incompressible, random data")
 	default:
-		return 0, fmt.Errorf("unsupported unit: %s", unit)
+		return i18n.T("mixed, half random and half repeated data in every page")
 	}
+}
 
-	return int64(value * float64(multiplier)), nil
+// describeMemoryMode explains --memory-mode in the settings, with the rate and
+// workers it runs at.
+func describeMemoryMode(config Config) string {
+	rate := i18n.T("full speed")
+	if config.MemoryRateBytes > 0 {
+		rate = i18n.Sprintf("%s/s", bytesize.Format(config.MemoryRateBytes))
+	}
+	workers := config.MemoryWorkers
+	switch memory.Mode(config.MemoryMode) {
+	case memory.ModeChurn:
+		return i18n.Sprintf("churn, rewriting the allocation at %s on %d workers", rate, max(workers, 1))
+	case memory.ModeBandwidth:
+		return i18n.Sprintf("bandwidth, streaming reads and writes on %d workers", cmp.Or(workers, runtime.GOMAXPROCS(0)))
+	case memory.ModeLock:
+		return i18n.T("lock, pinned in physical memory with mlock")
+	default:
+		return i18n.Sprintf("swap, allowed beyond physical memory and rewritten at %s on %d workers", rate, max(workers, 1))
+	}
 }
 
-func showProgress(ctx context.Context, totalDuration time.Duration) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
+// describeSync explains a --storage-sync mode in the settings.
+func describeSync(mode storage.SyncMode) string {
+	switch mode {
+	case storage.SyncDsync:
+		return i18n.T("O_DSYNC, every write waits for its data to reach the disk")
+	case storage.SyncFull:
+		return i18n.T("O_SYNC, every write waits for its data and metadata to reach the disk")
+	default:
+		return i18n.T("buffered, with an fsync after each file")
+	}
+}
 
-	startTime := time.Now()
+// describeLoad returns a human-readable line for each configured load type.
+func describeLoad(config Config, replayProfile *profile.Profile) []string {
+	// forTimeout notes a stressor's own timeout, if any
+	forTimeout := func(name string) string {
+		timeout, ok := config.StressorTimeouts[strings.ToLower(name)]
+		if !ok {
+			return ""
+		}
+		if start, ok := config.StressorStarts[strings.ToLower(name)]; ok {
+			return i18n.Sprintf(" (from %v for %v)", start, timeout)
+		}
+		return i18n.Sprintf(" (for %v)", timeout)
+	}
+
+	var lines []string
+	if replayProfile != nil {
+		lines = append(lines, i18n.Sprintf("Replay profile: %s (host %s, %v, %d samples)",
+			config.Profile, replayProfile.Host, replayProfile.Duration().Truncate(time.Second), len(replayProfile.Points)))
+	}
+	if config.CPU >= 0 {
+		switch {
+		case config.CPU == 0 && config.CPULoad > 0:
+			lines = append(lines, i18n.Sprintf("CPU load: all cores at %g%%%s", config.CPULoad*100, forTimeout("CPU")))
+		case config.CPU == 0:
+			lines = append(lines, i18n.T("CPU load: all cores")+forTimeout("CPU"))
+		case config.CPULoad > 0:
+			lines = append(lines, i18n.Sprintf("CPU load: %d cores at %g%%%s", config.CPU, config.CPULoad*100, forTimeout("CPU")))
+		default:
+			lines = append(lines, i18n.Sprintf("CPU load: %d cores%s", config.CPU, forTimeout("CPU")))
+		}
+	}
+	if config.CPU >= 0 || hasJob(config, "cpu") {
+		switch method := cpu.Method(config.CPUMethod); method {
+		case cpu.MethodBignum:
+			lines = append(lines, i18n.Sprintf("CPU method: %d-bit modular exponentiation", config.CPUBignumBits))
+		case cpu.MethodAll:
+			lines = append(lines, i18n.T("CPU method: all, rotating on every worker"))
+		case cpu.MethodInteger:
+		default:
+			lines = append(lines, i18n.Sprintf("CPU method: %s", method))
+		}
+	}
+	if config.Memory != "" {
+		lines = append(lines, i18n.Sprintf("Memory load: %s%s", describeSize(config.MemorySpec, i18n.T("free memory")), forTimeout("Memory")))
+	}
+	if (config.Memory != "" || hasJob(config, "memory")) && config.MemoryContent != "" {
+		lines = append(lines, i18n.Sprintf("Memory content: %s", describeContent(memory.Content(config.MemoryContent))))
+	}
+	if (config.Memory != "" || hasJob(config, "memory")) && config.MemoryMode != "" {
+		lines = append(lines, i18n.Sprintf("Memory mode: %s", describeMemoryMode(config)))
+	}
+	if config.Storage != "" {
+		lines = append(lines, i18n.Sprintf("Storage load: %s%s", describeSize(config.StorageSpec, i18n.T("free disk space")), forTimeout("Storage")))
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && config.IOMode != "" {
+		lines = append(lines, i18n.Sprintf("Storage I/O: %s on %d workers", config.IOMode, config.IODepth))
+		if config.FsyncEvery > 0 {
+			lines = append(lines, i18n.Sprintf("Storage fsync: after every %d writes", config.FsyncEvery))
+		}
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && config.StoragePath != "" {
+		lines = append(lines, i18n.Sprintf("Storage directory: %s", config.StoragePath))
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && config.StorageFiles > 0 {
+		lines = append(lines, i18n.Sprintf("Storage files: %d", config.StorageFiles))
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && config.StorageBlockSize > 0 {
+		lines = append(lines, i18n.Sprintf("Storage block size: %s", bytesize.Spec{Input: config.StorageBS, Bytes: int64(config.StorageBlockSize)}))
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && storage.SyncMode(config.StorageSync) != storage.SyncFsync {
+		lines = append(lines, i18n.Sprintf("Storage writes: %s", describeSync(storage.SyncMode(config.StorageSync))))
+	}
+	if (config.Storage != "" || hasJob(config, "storage")) && config.StorageNetworkMix {
+		lines = append(lines, i18n.T("Storage operations: stat storms, byte-range locks and small fsync'ed writes added"))
+	}
+	if config.GPU != 0 {
+		lines = append(lines, i18n.Sprintf("GPU load: %.0f%% utilization of GPU %d%s", config.GPU, config.GPUDevice, forTimeout("GPU")))
+		if config.GPUMemory != "" {
+			lines = append(lines, i18n.Sprintf("GPU memory: %s", describeSize(config.GPUMemorySpec, i18n.T("free VRAM"))))
+		}
+	}
+	if config.PageFault != "" {
+		rate := i18n.T("as many as possible")
+		if config.PageFaultRate > 0 {
+			rate = i18n.Sprintf("%.0f/s", config.PageFaultRate)
+		}
+		lines = append(lines, i18n.Sprintf("Page fault load: %s file, %s faults%s", config.PageFault, rate, forTimeout("PageFault")))
+	}
+	if config.Sparse != "" {
+		rate := i18n.T("as many as possible")
+		if config.SparseRate > 0 {
+			rate = i18n.Sprintf("%.0f/s", config.SparseRate)
+		}
+		lines = append(lines, i18n.Sprintf("Sparse file load: %s of sparse files, %s hole punches and fills%s", config.Sparse, rate, forTimeout("Sparse")))
+	}
+	if config.Network != "" {
+		target := cmp.Or(config.NetworkTarget, i18n.T("the built-in echo server"))
+		rate := i18n.T("as fast as possible")
+		if config.NetworkRate != "" {
+			rate = i18n.Sprintf("%s/s", config.NetworkRate)
+		}
+		lines = append(lines, i18n.Sprintf("Network load: %s to %s, %s%s", config.Network, target, rate, forTimeout("Network")))
+	}
+	if config.Procs != 0 {
+		rate := i18n.T("as many as possible")
+		if config.ProcsRate > 0 {
+			rate = i18n.Sprintf("%.0f/s", config.ProcsRate)
+		}
+		lifetime := i18n.T("exiting at once")
+		if config.ProcsLifetime > 0 {
+			lifetime = i18n.Sprintf("sleeping %v", config.ProcsLifetime)
+		}
+		lines = append(lines, i18n.Sprintf("Process load: up to %d children, %s spawns, %s%s", config.Procs, rate, lifetime, forTimeout("Procs")))
+	}
+	if config.FD != "" || config.Inodes != 0 {
+		rate := i18n.T("as many as possible")
+		if config.FilesRate > 0 {
+			rate = i18n.Sprintf("%.0f/s", config.FilesRate)
+		}
+		switch {
+		case config.FD != "" && config.Inodes != 0:
+			lines = append(lines, i18n.Sprintf("File load: %s descriptors held, %d small files, %s creations and removals%s", config.FD, config.Inodes, rate, forTimeout("Files")))
+		case config.Inodes != 0:
+			lines = append(lines, i18n.Sprintf("File load: %d small files, %s creations and removals%s", config.Inodes, rate, forTimeout("Files")))
+		default:
+			lines = append(lines, i18n.Sprintf("File load: %s descriptors held, %s reopened%s", config.FD, rate, forTimeout("Files")))
+		}
+	}
+	if config.Switch != 0 || config.Timers != 0 {
+		rate := i18n.T("as many as possible")
+		if config.SwitchRate > 0 {
+			rate = i18n.Sprintf("%.0f/s", config.SwitchRate)
+		}
+		interval := cmp.Or(config.TimerInterval, time.Millisecond)
+		switch {
+		case config.Switch != 0 && config.Timers != 0:
+			lines = append(lines, i18n.Sprintf("Scheduler load: %d goroutine pairs, %s messages, %d timers every %v%s", config.Switch, rate, config.Timers, interval, forTimeout("Scheduler")))
+		case config.Switch != 0:
+			lines = append(lines, i18n.Sprintf("Scheduler load: %d goroutine pairs, %s messages%s", config.Switch, rate, forTimeout("Scheduler")))
+		default:
+			lines = append(lines, i18n.Sprintf("Scheduler load: %d timers every %v%s", config.Timers, interval, forTimeout("Scheduler")))
+		}
+	}
+	for _, j := range config.Jobs {
+		lines = append(lines, j.describe()+forTimeout(j.name))
+	}
+	for _, p := range config.Plugins {
+		lines = append(lines, i18n.Sprintf("Plugin load: %s (%s)%s", p.Name, strings.Join(p.Command, " "), forTimeout(p.Name)))
+	}
+	if config.SoakTemperature > 0 {
+		lines = append(lines, i18n.Sprintf("Thermal soak: holding the CPU at %.1f°C", config.SoakTemperature))
+	}
+	if config.ProbeInterval > 0 {
+		probes := config.Probes
+		if len(probes) == 0 {
+			probes = probe.Kinds
+		}
+		lines = append(lines, i18n.Sprintf("Probes: %s every %v", strings.Join(probes, ", "), config.ProbeInterval))
+	}
+	if len(config.SLOs) > 0 {
+		var exprs []string
+		for _, s := range config.SLOs {
+			exprs = append(exprs, s.Expr)
+		}
+		line := i18n.Sprintf("SLOs: %s every %v", strings.Join(exprs, ", "), config.SLOWindow)
+		if config.SLOBudget.set {
+			line += i18n.Sprintf(", failing beyond %s violated windows", config.SLOBudget)
+		}
+		lines = append(lines, line)
+	}
+	if len(config.FailUnder) > 0 {
+		lines = append(lines, describeThresholds(config.FailUnder))
+	}
+	if config.Victim != 0 {
+		lines = append(lines, i18n.Sprintf("Baseline: %v idle before the load, watching PID %d", config.Baseline, config.Victim))
+	} else if config.Baseline > 0 {
+		lines = append(lines, i18n.Sprintf("Baseline: %v idle before the load", config.Baseline))
+	}
+	if names := verifiedStressors(config); len(names) > 0 {
+		lines = append(lines, i18n.Sprintf("Verification: %s", strings.Join(names, ", ")))
+	}
+	if config.Pattern != nil {
+		lines = append(lines, i18n.T("Load pattern: ")+config.Pattern.String())
+	}
+	if config.Interval != nil {
+		lines = append(lines, i18n.T("Work/rest interval: ")+config.Interval.String())
+	}
+	if config.RampUp > 0 || config.RampDown > 0 {
+		lines = append(lines, i18n.Sprintf("Ramp: up over %v, down over %v", config.RampUp, config.RampDown))
+	}
+	if config.Chaos > 0 {
+		faults := make([]string, len(config.ChaosFaults))
+		for i, f := range config.ChaosFaults {
+			faults[i] = string(f)
+		}
+		lines = append(lines, i18n.Sprintf("Chaos: %s about every %v (seed %d)", strings.Join(faults, ", "), config.Chaos, config.ChaosSeed))
+	}
+	if config.OverheadCPU >= 0 {
+		lines = append(lines, i18n.Sprintf("Bookkeeping: confined to CPU %d, away from the load", config.OverheadCPU))
+	}
+	lines = append(lines, i18n.Sprintf("Random seed: %d", config.Seed))
+	return lines
+}
+
+// splitList splits a comma-separated option value, dropping empty elements.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// sampleSystem periodically records system-wide metrics while the test runs.
+func sampleSystem(ctx context.Context, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			elapsed := time.Since(startTime)
-			remaining := totalDuration - elapsed
-			
-			if remaining <= 0 {
-				return
+			snapshot, err := sysinfo.Read()
+			if err != nil {
+				continue
+			}
+			recorder.RecordValue("Load average (1m)", "", snapshot.LoadAverage)
+			recorder.RecordValue("Available memory", metrics.UnitBytes, float64(snapshot.MemoryAvailable))
+		}
+	}
+}
+
+// handleLevelSignals raises the load level of the run by step each time
+// SIGUSR1 arrives and lowers it on SIGUSR2, until ctx is done.
+func handleLevelSignals(ctx context.Context, control *runControl, step float64) {
+	up, down := make(chan os.Signal, 1), make(chan os.Signal, 1)
+	if !notifyLevel(up, down) {
+		return
+	}
+	defer signal.Stop(up)
+	defer signal.Stop(down)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-up:
+			control.stepLevel(step)
+		case <-down:
+			control.stepLevel(-step)
+		}
+	}
+}
+
+// handleReloadSignals reloads the --scenario file each time SIGHUP arrives,
+// until ctx is done.
+func handleReloadSignals(ctx context.Context, scenario *scenarioRun) {
+	reloadChan := make(chan os.Signal, 1)
+	if !notifyReload(reloadChan) {
+		return
+	}
+	defer signal.Stop(reloadChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reloadChan:
+			scenario.reload()
+		}
+	}
+}
+
+// flagGiven reports whether the flag name was set on the command line rather
+// than left at its default.
+func flagGiven(name string) bool {
+	given := false
+	flag.CommandLine.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+	return given
+}
+
+// handleExtendSignals moves the deadline by step each time SIGUSR2 arrives,
+// until ctx is done. A negative step shortens the run.
+func handleExtendSignals(ctx context.Context, dl *deadline.Deadline, step time.Duration, bus *events.Bus) {
+	extendChan := make(chan os.Signal, 1)
+	if !notifyExtend(extendChan) {
+		return
+	}
+	defer signal.Stop(extendChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-extendChan:
+			end := dl.Extend(step)
+			bus.Publish(events.Event{
+				Type:    events.DeadlineChanged,
+				Message: i18n.Sprintf("Run time changed by %v, now ending at %s (remaining %v)", step, end.Format(time.TimeOnly), dl.Remaining().Truncate(time.Second)),
+				Fields:  map[string]any{"end": end},
+			})
+		}
+	}
+}
+
+// printMessage prints a progress message from a stressor.
+func printMessage(m metrics.Message) {
+	term.Printf("[%s] %s\n", m.Stressor, m.Text)
+}
+
+// printFailures prints the fatal errors returned by the stressors.
+func printFailures(failures []stressorFailure) {
+	if len(failures) == 0 {
+		return
+	}
+
+	term.Printf("Stressor errors:\n")
+	for _, f := range failures {
+		term.Printf("  [%s] %v\n", f.name, f.err)
+	}
+	term.Println()
+}
+
+// printWorkerReport prints how evenly the OS scheduled each stressor's workers:
+// the spread of the CPU time they achieved and every worker that lagged.
+func printWorkerReport(usages []metrics.WorkerUsage) {
+	if len(usages) == 0 {
+		return
+	}
+
+	term.Printf("Per-worker CPU time:\n")
+	for len(usages) > 0 {
+		// usages are sorted by stressor; take the run of one stressor's workers
+		n := 1
+		for n < len(usages) && usages[n].Stressor == usages[0].Stressor {
+			n++
+		}
+		workers := usages[:n]
+		usages = usages[n:]
+
+		lowest, highest, sum := workers[0], workers[0], 0.0
+		for _, w := range workers {
+			if w.MeanAchieved < lowest.MeanAchieved {
+				lowest = w
+			}
+			if w.MeanAchieved > highest.MeanAchieved {
+				highest = w
+			}
+			sum += w.MeanAchieved
+		}
+		term.Printf("  [%s] %d workers: mean %.1f%%, lowest %.1f%% (worker %d), highest %.1f%% (worker %d)\n",
+			lowest.Stressor, len(workers), sum/float64(len(workers))*100,
+			lowest.MeanAchieved*100, lowest.Worker, highest.MeanAchieved*100, highest.Worker)
+		for _, w := range workers {
+			if w.Lagging() {
+				term.Printf("    ! worker %d: %.1f%% of a core against a target of %.1f%% (min %.1f%%)\n",
+					w.Worker, w.MeanAchieved*100, w.MeanTarget*100, w.MinAchieved*100)
 			}
+		}
+	}
+	term.Println()
+}
+
+// printDeviationReport prints target-vs-achieved statistics for each stressor.
+func printDeviationReport(deviations []metrics.Deviation) {
+	if len(deviations) == 0 {
+		return
+	}
 
-			progress := float64(elapsed) / float64(totalDuration) * 100
-			fmt.Printf("\rProgress: %.1f%% (Remaining: %v)", progress, remaining.Truncate(time.Second))
+	term.Printf("\nTarget vs achieved:\n")
+	for _, d := range deviations {
+		status := "OK"
+		if d.Degraded {
+			status = "DEGRADED"
+		}
+		if d.Samples > 0 {
+			term.Printf("  [%s] %s: target %s, achieved %s (min %s, max %s), deviation mean %+.1f%% / max %+.1f%%\n",
+				d.Stressor, status,
+				metrics.FormatValue(d.Unit, d.MeanTarget),
+				metrics.FormatValue(d.Unit, d.MeanAchieved),
+				metrics.FormatValue(d.Unit, d.MinAchieved),
+				metrics.FormatValue(d.Unit, d.MaxAchieved),
+				d.MeanDeviation, d.MaxDeviation)
+		} else {
+			term.Printf("  [%s] %s: no samples recorded\n", d.Stressor, status)
+		}
+		for _, issue := range d.Issues {
+			term.Printf("    ! %s\n", issue)
 		}
 	}
+	term.Println()
 }
 
 func printUsage() {
 	fmt.Fprintf(os.Stderr, `
 Usage: stress-go --timeout <duration> [options]
+       stress-go --scenario <file> [options]
+       stress-go record --output <file> [--interval <duration>] [--timeout <duration>] [--path <dir>]
+       stress-go replay --profile <file> [--timeout <duration>] [options]
+       stress-go burnin [--timeout <duration>] [--certificate <file>] [options]
+       stress-go resume --state <file>
+       stress-go doctor [--path <dir>]
+       stress-go calibrate [--output <file>] [--check <duration>]
+       stress-go bench [--duration <duration>] [--cpu <cores>] [--path <dir>] [--json <file>]
+       stress-go latency [--duration <duration>] [--size <size>] [--line-size <bytes>] [--levels <pct,...>] [--json <file>]
+       stress-go search (--cpu <cores> | --memory <size>) --until <cond> [--method bisect|step]
+                        [--step <pct>] [--precision <pct>] [--settle <duration>] [--hold <duration>] [--json <file>]
+       stress-go tenants --timeout <duration> --tenant <name:limits:options> [--tenant ...]
+                         [--interval <duration>] [--json <file>]   (Linux, cgroup v2, root)
+       stress-go selftest   (CPU, memory, storage, page fault, sparse, network and process stressors;
+                        not GPU)
+       stress-go healthcheck [--addr <addr>] [--ready] [--timeout <duration>]
+       stress-go daemon [--socket <path>] [--schedule <file>]
+       stress-go ctl [--socket <path>] start [--wait] <options> | status [--lines <n>] | stop [--wait]
+       stress-go ctl [--socket <path>] adjust (--level <n> | --pause | --resume | --target <name=value>)
+       stress-go agent [--listen <addr>] [--token <token>]   (also: serve)
+       stress-go worker --join <coordinator> [--listen <addr>] [--advertise <addr>] [--token <token>]
+       stress-go coordinate --hosts <host,...> [--token <token>] [--report-json <file>] [--listen <addr>] -- <options>
+       stress-go coordinate --workers <n> [--join-timeout <duration>] [--token <token>] [--listen <addr>] -- <options>
+       stress-go coordinate --ssh-hosts <file> [--ssh-copy] [--ssh-path <path>] [--report-json <file>] -- <options>
+       stress-go k8s gen [--mode job|daemonset] [--image <image>] [--node-selector <k=v,...>] -- <options>
+       stress-go service install [--name <name>] [--auto] -- <options>   (Windows)
+       stress-go service uninstall [--name <name>]                        (Windows)
+       stress-go echo [--listen <addr>]   (target for --network on another host)
+       stress-go list [--json] [methods|patterns|engines]
+       stress-go version
 
 Options:
   --timeout <duration>  Duration to apply load (e.g., 30s, 5m, 1h) [required]
-  --cpu <cores>         Number of CPU cores to use (0 = use all cores)
+  --cpu <cores>         Number of CPU cores to use (0 = use all cores); append x<percent> to hold
+                        each core at that utilization instead of 100%% (e.g., 4x60%%)
+  --cpu-load <percent>  Utilization of each CPU core (e.g., 60); without --cpu, all cores are used
+  --cpu-method <m>      Computation of the CPU load: integer (default; with SIMD on ARM64),
+                        bignum (modular exponentiation, as in RSA key operations), float
+                        (matrix multiply), crypto (AES and SHA-256), prime (sieve), branch
+                        (unpredictable branches), cache (random walk over twice the LLC) or
+                        all (every method in turn on each worker)
+  --cpu-bignum-bits <n> Operand size of --cpu-method bignum (default 2048, 64-16384)
   --memory <size>       Memory load (e.g., 1GB, 512MB, 95%%)
   --storage <size>      Storage load (e.g., 500MB, 80%%)
+  --gpu <percent>       GPU compute load as a utilization percentage (needs a build with -tags gpu)
+  --gpu-memory <size>   VRAM to allocate with --gpu (e.g., 4GB, 80%% of free VRAM)
+  --gpu-device <n>      Index of the GPU loaded by --gpu (default 0)
+  --pagefault <size>    Map a file of this size (e.g., 64GB) or multiple of memory (e.g., 1.5x)
+                        and touch random pages to cause major page faults
+  --pagefault-rate <n>  Target major page faults per second (default 0 = as many as possible)
+  --pagefault-dir <dir> Directory for the --pagefault file (default: the temporary directory)
+  --sparse <size>       Punch holes in and refill sparse files of this total size (e.g., 10GB)
+                        to churn the file system's extents (Linux)
+  --sparse-rate <n>     Target hole punches and fills per second (default 0 = as many as possible)
+  --sparse-dir <dir>    Directory for the --sparse files (default: the temporary directory)
+  --network <mode>      Network load: tcp (stream bandwidth), udp (datagrams) or churn
+                        (TCP connections opened and closed, for conntrack and ephemeral ports)
+  --network-target <host:port>
+                        Destination of --network (default: a built-in echo server on loopback;
+                        run "stress-go echo" on the other host)
+  --network-size <size> Size of each write or UDP datagram (default 64KB for tcp, 1400B for udp;
+                        for churn, data echoed on each connection, default none)
+  --network-rate <n>    Target bytes per second for tcp and udp (e.g., 100MB), connections per
+                        second for churn (default: as fast as possible)
+  --network-conns <n>   Parallel connections or churn workers (default 4)
+  --procs <n>           Keep spawning and reaping child processes, at most n alive at once, to
+                        load the scheduler, PID table and process accounting
+  --procs-rate <n>      Target child processes spawned per second (default 0 = as many as possible)
+  --procs-lifetime <duration>
+                        How long each child sleeps before it exits (default: exits at once)
+  --fd <n>              Hold n file descriptors open, or a share of the open file limit (e.g., 80%%)
+  --inodes <n>          Keep creating and removing n small files in a deep directory tree, to load
+                        the dentry cache and file system metadata
+  --files-depth <n>     Depth of the --inodes directory tree (default 4, deeper when needed)
+  --files-rate <n>      Target creations and removals per second, or descriptors reopened per
+                        second with --fd alone (default 0 = as many as possible)
+  --files-dir <dir>     Directory for the --fd and --inodes files (default: the temporary directory)
+  --switch <n>          Ping-pong messages between n goroutine pairs over unbuffered channels, to
+                        load the scheduler and thread wakeups
+  --switch-rate <n>     Target messages per second over all pairs (default 0 = as many as possible)
+  --timers <n>          Keep n high-resolution timers firing, to load the runtime and kernel timers
+  --timer-interval <duration>
+                        How often each timer fires (default 1ms)
+  --report-html <file>  Write a self-contained HTML report with charts
+  --summary-json <file> Write a machine-readable summary of the run (JSON)
+  --output <format>     Stream the samples and the summary as text, json (JSON lines) or csv
+                        (default text); json and csv go to stdout unless --report-file is given,
+                        with the console output moved to stderr
+  --report-file <file>  Write the --output stream to this file
+  --listen <addr>       Serve /healthz, /readyz, the /v1 status and control API and /debug/vars on this address
+  --metrics-addr <addr> Serve Prometheus metrics of the run under /metrics on this address
+  --pprof               Also serve the runtime profiles of stress-go itself under /debug/pprof/
+  --pprof-dir <dir>     Write CPU and heap profiles of stress-go itself to this directory
+  --pprof-interval <duration>
+                        Interval between the profiles written to --pprof-dir (default 1m)
+  --grafana-url <url>   Post run/phase annotations to this Grafana instance
+  --grafana-token <tok> Grafana API token for annotations
+  --grafana-tags <tags> Comma-separated tags added to every annotation
+  --notify-url <url>    POST JSON on the run start, phases, watchdog aborts and completion
+  --pre-cmd <cmd>       Shell command to run before the load starts (the run is not started if it fails)
+  --phase-cmd <cmd>     Shell command to run in the background at the start of every --pattern step
+  --post-cmd <cmd>      Shell command to run after the run has finished
+  --hook-timeout <duration>
+                        Kill a hook command that runs longer than this (default 10m)
+  --textfile-dir <dir>  Write metrics for node_exporter's textfile collector
+  --textfile-interval <duration>
+                        Interval between textfile metric updates (default 15s)
+  --state <file>        Checkpoint the elapsed time, pattern position, counters and files of the
+                        run to this file, from which "stress-go resume" continues it after a
+                        crash or restart
+  --state-interval <duration>
+                        Interval between --state checkpoints (default 1m)
+  --abort-if <cond>     Ramp the load down over 3s, stop and exit with status 3 when a condition holds; repeatable
+                        (loadavg>N, mem-available<SIZE, disk-free<PATH:SIZE, temp>N C,
+                        psi-cpu>N%%, psi-memory>N%%, psi-io>N%%)
+  --smart               Monitor the SMART health of the disk under test with smartctl, log
+                        changes and stop with status 3 when it degrades (new bad sectors,
+                        media errors, failed assessment)
+  --smart-device <dev>  Device for --smart (default: the disk of the storage directory)
+  --smart-interval <duration>
+                        Interval between SMART readings (default 1m)
+  --smart-max-temp <C>  Also stop when the disk temperature exceeds this value
+  --no-thermal-failsafe Do not back off CPU load near critical temperatures
+  --soak-temp <C>       Thermal soak: modulate the CPU load to hold the CPU die at this
+                        temperature (needs a CPU sensor such as coretemp or k10temp)
+  --baseline <duration> Measure the system idle for this long before the load and report
+                        what changed under load
+  --victim <pid>        Also compare the CPU share of this process (needs --baseline)
+  --probe-interval <duration>
+                        Run canary latency probes at this interval alongside the load
+  --probes <list>       Probes to run: wakeup, alloc, pread, rtt (default: all; implies
+                        --probe-interval 100ms)
+  --slo <objective>     Objective checked every window: <probe>-p99<LATENCY, <probe>-max<LATENCY
+                        or cpu|memory|storage|gpu-achieved>N%% (e.g., rtt-p99<2ms); repeatable
+  --slo-window <duration>
+                        Window over which each --slo is evaluated (default 10s)
+  --slo-budget <n|N%%>   Exit with status 8 when an SLO is violated in more windows than this
+  --fail-under <list>   Exit with status 9 when a stressor falls short of a threshold:
+                        <stressor>=N%% of its mean target or <stressor>=<rate>/s of mean
+                        throughput, comma-separated (e.g., cpu=90%%,storage=200MB/s)
+  --seed <n>            Seed for every random choice (written data, test patterns, probe
+                        offsets, chaos), to repeat a run exactly (default: random)
+  --chaos <duration>    Inject faults into the stressors at random, on average at this
+                        interval: kill CPU workers, drop memory, corrupt storage blocks
+  --chaos-seed <n>      Seed for the injected faults, to repeat a run (default: --seed)
+  --chaos-faults <list> Faults to inject: kill, drop, corrupt (default: all that apply)
+  --max-loadavg <n>     Modulate CPU load to keep the 1-minute load average <= n
+  --max-memory <size>   Hard cap on memory held by all stressors together (GPU memory included)
+  --max-disk <size>     Hard cap on disk space held by all stressors together (storage,
+                        page fault, sparse and --inodes files)
+  --max-cpu-percent <n> Hard cap on the CPU usage of all CPU stressors together as a
+                        percentage of the usable cores
+                        (the cgroup CPU quota and cpuset are taken into account)
+  --max-mem <n%%>       Back off the memory loads while memory in use on the whole
+                        system, by any process, exceeds this share (e.g., 90%%)
+  --max-disk-fill <n%%> Back off the storage loads while the filesystem they write to is
+                        fuller than this share (e.g., 95%%)
+  --start-at <time>     Wait until this RFC 3339 time before applying load (start barrier)
+  --extend-by <duration>
+                        Change the run time by this much on each SIGUSR2; negative
+                        values shorten the run (default 30m, not on Windows)
+  --level-step <n>      Raise the load level by this much on each SIGUSR1 and lower it on
+                        SIGUSR2 (e.g., 0.1); SIGUSR2 then no longer changes the run time
+  --stop-timeout <duration>
+                        Maximum cleanup time after stopping (default 60s, 0 = unlimited)
+  --grace-period <duration>
+                        Time allowed between SIGTERM and SIGKILL by the orchestrator; cleanup
+                        after a stop signal is shortened to finish within it
+  --cloud-metadata      Tag the results with the AWS/GCP/Azure instance type, zone and
+                        lifecycle (spot or on-demand) from the instance metadata service
+  --fail-fast           Stop all stressors as soon as one of them returns an error
+  --allow-sleep         Do not prevent system sleep/hibernate during the run
+  --no-dashboard        Show only the progress line instead of the live dashboard of the
+                        system resources and stressors on a terminal
+  --overhead-cpu <n>    Run stress-go's own progress, metrics and monitoring on CPU n and keep
+                        the load off it (Linux); the overhead is reported either way
+  --stressor-timeout <name=duration>
+                        Stop one stressor (cpu, memory, storage, gpu, pagefault, sparse, network, procs,
+                        files, scheduler, a job or a plugin) after its own duration while the others keep running; repeatable
+  --job <name=kind:options>
+                        Run a named cpu, memory or storage load, e.g.
+                        logs=storage:size=10GB,dir=/mnt/logs,sync=dsync or hot=cpu:cores=2,verify;
+                        repeatable, so that one kind of load can run several times
+  --plugin <name=cmd>   Run an external stressor plugin speaking JSON lines on stdio; repeatable
+  --scenario <file>     Run the stages of a JSON scenario file, each a --job load with its own
+                        start and duration; --timeout defaults to the end of the last stage
+                        (SIGHUP reloads the file for the stages that have not started yet)
+  --ramp-up <duration>  Grow every load (CPU duty cycle, memory, disk footprint, ...) from nothing
+                        to its target over this long at the start of the run
+  --ramp-down <duration>
+                        Take every load back to nothing over this long before the end of the run
+  --pattern <spec>      Step the CPU and memory load through levels of the configured load,
+                        e.g. steps:levels=20,40,60,80;hold=2m
+  --interval <spec>     Alternate work and rest periods for every stressor, releasing memory while
+                        resting unless memory=retain, e.g. work=50s,rest=10s,memory=retain
+  --profile <file>      Load profile to reproduce (replay mode)
+  --cpu-verify          Check floating point results on every core
+  --memory-verify       Fill memory with test patterns and check them while holding it
+  --memory-content <c>  Data written to the memory load, for systems with zram or zswap:
+                        compressible (compresses to a few percent), incompressible (random)
+                        or mixed (about 2:1); default: one byte per page
+  --memory-mode <mode>  Keep working the memory load: churn (rewrite it at --memory-rate),
+                        bandwidth (streaming reads and writes on every CPU), lock (mlock it)
+                        or swap (allow sizes beyond physical memory and rewrite all of it);
+                        churn, bandwidth and swap report the achieved GB/s
+  --memory-rate <size>  Bytes rewritten per second by churn and swap (default: unlimited)
+  --memory-workers <n>  Workers of churn, bandwidth and swap (default: 1, every CPU for bandwidth)
+  --drop-caches <when>  Drop the page cache so that storage reads hit the device: before
+                        (the load) or between-phases (also after the storage writes and at
+                        every --pattern step); needs root, otherwise the run goes on with a warning
+  --io-mode <mode>      Keep reading or writing the storage files: seqwrite, seqread, randread,
+                        randwrite or mixed, reporting IOPS and MiB/s (default: a read and an
+                        append every 2s)
+  --io-depth <n>        Number of workers doing the --io-mode I/O in parallel (default 1)
+  --fsync-every <n>     Call fsync after every n writes of each --io-mode worker
+  --storage-path <dir>  Directory the storage load writes to; a percentage is of its free space
+                        (default: the temporary directory)
+  --storage-files <n>   Number of files the storage load is spread over (default: files of up to 64MB)
+  --storage-bs <size>   Bytes read or written by one system call of the storage load (default 64KB)
+  --storage-verify      Write checksummed, sequence-numbered blocks and check them on every
+                        read (also with --io-mode) and in a final scan of every file before cleanup
+  --storage-sync <mode> How storage writes reach the disk: fsync (buffered writes and an fsync
+                        per file, default), dsync (O_DSYNC, as databases write) or sync (O_SYNC)
+  --storage-network-mix Add stat storms, byte-range locks and small fsync'ed writes to the storage
+                        load; automatic when the directory is on NFS, SMB, CephFS or FUSE
+  --calibration <file>  Correct partial CPU loads with this duty-cycle calibration
+                        (default: the one stored by "stress-go calibrate", if any)
+  --certificate <file>  Write the burn-in certificate to this file (burnin mode; it is
+                        always printed). burnin defaults to --timeout 24h --cpu 0
+                        --memory 70%% --storage 10%% with all verification enabled
+  --dry-run             Validate the options, show the sizes they resolve to on this host and exit
+  --lang <en|ja>        Language of the messages (default: from LC_ALL, LC_MESSAGES or LANG);
+                        accepted by every subcommand
   --help                Show this help
 
+Sizes:
+  KB, MB, GB, TB are powers of 1000; KiB, MiB, GiB, TiB and bare K, M, G, T are
+  powers of 1024. Long forms such as "megabytes" and "gibibytes" are accepted.
+
+Templates:
+  Option values may be Go templates expanded with facts about this host: .Hostname,
+  .Cores, .UsableCores, .MemTotal, .MemAvailable (bytes), .OS, .Arch; functions env,
+  add, sub, mul, div, int and size (bytes as MiB), e.g. --cpu '{{div .Cores 2 | int}}'
+
+Exit status:
+  0 success, 1 runtime error, stressor error or crash, 2 invalid options, 3 aborted by --abort-if or --smart,
+  4 cleanup timeout, 5 stressor or --pre-cmd failed to start, 6 doctor/selftest failure or verification error,
+  7 partial completion (a stressor was DEGRADED), 8 SLO violations beyond --slo-budget,
+  9 a --fail-under threshold not met
+
 Examples:
   stress-go --timeout 60s --cpu 2
   stress-go --timeout 30s --cpu 0          # Use all CPU cores
   stress-go --timeout 5m --memory 1GB
   stress-go --timeout 2m --storage 80%%
   stress-go --timeout 30s --cpu 1 --memory 512MB --storage 500MB
+  stress-go --timeout 10m --gpu 80 --gpu-memory 90%%
+  stress-go --timeout 30m --pagefault 1.5x --pagefault-dir /mnt/data
+  stress-go --timeout 1h --sparse 20GB --sparse-dir /var/lib/images
+  stress-go --timeout 1h --fd 90%% --inodes 1000000 --files-rate 5000
+  stress-go --timeout 10m --switch 2000 --timers 1000 --timer-interval 500us
+  stress-go --timeout 24h --storage 80%% --smart --smart-max-temp 60
+  stress-go --timeout 1h --job logs=storage:size=10GB,dir=/mnt/logs --job db=storage:size=5GB,dir=/mnt/db
+  stress-go --timeout 2h --cpu 0 --soak-temp 85
+  stress-go --timeout 1h --cpu 0 --memory 50%% --interval work=50s,rest=10s,memory=retain
+  stress-go --timeout 1h --cpu 2 --memory 1GB --chaos 30s --chaos-seed 42
+  stress-go --timeout 1h --cpu '{{sub .Cores 1}}' --memory '{{size (mul .MemTotal 0.5)}}'
+  stress-go --timeout 10m --cpu 0 --baseline 30s --victim 1234
+  stress-go --timeout 10m --cpu 0 --memory 80%% --probe-interval 100ms --baseline 30s
+  stress-go --timeout 1h --cpu 0 --slo "wakeup-p99<5ms" --slo "cpu-achieved>95%%" --slo-budget 1%%
+  stress-go record --output prod.json --interval 5s --timeout 1h
+  stress-go replay --profile prod.json
+  stress-go burnin --timeout 24h --certificate burnin.txt
+  stress-go doctor --path /var/tmp
+  stress-go calibrate                      # Once per host, for accurate partial CPU loads
+  stress-go bench --duration 30s --json bench.json
+  stress-go latency --levels 10%%,20%%,40%%,60%%,80%%,100%% --json latency.json
+  stress-go search --cpu 0 --until "psi-cpu>20%%" --until "temp>90C"
 
 `)
-}
\ No newline at end of file
+}
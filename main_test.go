@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+func TestParseCPUSpec(t *testing.T) {
+	tests := []struct {
+		name  string
+		spec  string
+		load  float64
+		cores int
+		ratio float64
+		ok    bool
+	}{
+		{name: "no CPU load", spec: "", cores: -1, ok: true},
+		{name: "cores", spec: "4", cores: 4, ok: true},
+		{name: "all cores", spec: "0", cores: 0, ok: true},
+		{name: "cores with load", spec: "4x60%", cores: 4, ratio: 0.6, ok: true},
+		{name: "load without percent sign", spec: "2x25", cores: 2, ratio: 0.25, ok: true},
+		{name: "all cores with load", spec: "0x50%", cores: 0, ratio: 0.5, ok: true},
+		{name: "cpu-load alone", load: 60, cores: 0, ratio: 0.6, ok: true},
+		{name: "cpu-load with cores", spec: "3", load: 100, cores: 3, ratio: 1, ok: true},
+		{name: "both loads", spec: "4x60%", load: 60, ok: false},
+		{name: "load without cores", spec: "x60%", ok: false},
+		{name: "zero load", spec: "4x0%", ok: false},
+		{name: "load over 100", spec: "4x150%", ok: false},
+		{name: "negative cpu-load", spec: "4", load: -10, ok: false},
+		{name: "negative cores", spec: "-2", ok: false},
+		{name: "not a number", spec: "four", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cores, ratio, err := parseCPUSpec(tt.spec, tt.load)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseCPUSpec(%q, %g) error = %v, want ok %v", tt.spec, tt.load, err, tt.ok)
+			}
+			if tt.ok && (cores != tt.cores || ratio != tt.ratio) {
+				t.Errorf("parseCPUSpec(%q, %g) = %d, %g, want %d, %g", tt.spec, tt.load, cores, ratio, tt.cores, tt.ratio)
+			}
+		})
+	}
+}
+
+func TestVerificationErrors(t *testing.T) {
+	verifications := []metrics.Verification{
+		{Stressor: "CPU", Checks: 100},
+		{Stressor: "Storage", Checks: 256, Failures: 2},
+		{Stressor: "Memory", Checks: 64, Failures: 1},
+	}
+	// Any failure makes the run exit with exitVerificationFailure
+	if got := verificationErrors(verifications); got != 3 {
+		t.Errorf("verificationErrors() = %d, want 3", got)
+	}
+	if got := verificationErrors(verifications[:1]); got != 0 {
+		t.Errorf("verificationErrors() without failures = %d, want 0", got)
+	}
+}
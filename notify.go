@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// notifyTimeout bounds each webhook call so an unreachable endpoint never holds up
+// the stress test for long.
+const notifyTimeout = 10 * time.Second
+
+// notification is the JSON body posted to --notify-url. Text makes it usable as
+// is by chat webhooks such as Slack's, the other fields are for pipelines.
+type notification struct {
+	Text        string            `json:"text"`
+	Host        string            `json:"host"`
+	Event       events.Event      `json:"event"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Summary     *cluster.Summary  `json:"summary,omitempty"`
+}
+
+// webhook posts the start, phase changes, watchdog aborts and completion of the
+// run to a URL. Failures are reported but never interrupt the stress test. Its
+// observe blocks on the endpoint and is subscribed through deliverAsync.
+type webhook struct {
+	url    string
+	host   string
+	client *http.Client
+
+	mu          sync.Mutex
+	environment map[string]string
+	// summarize builds the summary attached to the completion; nil until the
+	// run has been set up
+	summarize func(events.Event) cluster.Summary
+}
+
+func newWebhook(url string) *webhook {
+	host, _ := os.Hostname()
+	return &webhook{url: url, host: host, client: &http.Client{Timeout: notifyTimeout}}
+}
+
+// setSummary attaches the summary built by summarize to the completion notice.
+func (w *webhook) setSummary(summarize func(events.Event) cluster.Summary) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.summarize = summarize
+}
+
+// observe sends the notification of e, waiting for the endpoint.
+func (w *webhook) observe(e events.Event) {
+	w.mu.Lock()
+	if e.Type == events.RunStarted {
+		w.environment, _ = e.Fields["environment"].(map[string]string)
+	}
+	n := notification{Host: w.host, Event: e, Environment: w.environment}
+	summarize := w.summarize
+	w.mu.Unlock()
+
+	switch e.Type {
+	case events.RunStarted:
+		settings, _ := e.Fields["settings"].([]string)
+		n.Text = i18n.Sprintf("stress-go started on %s: %s", w.host, strings.Join(settings, "; "))
+	case events.PhaseStarted:
+		n.Text = i18n.Sprintf("stress-go on %s entered phase %s", w.host, e.Message)
+	case events.PhaseFinished:
+		n.Text = i18n.Sprintf("stress-go on %s finished step %v", w.host, e.Fields["step"])
+	case events.WatchdogTripped:
+		n.Text = i18n.Sprintf("stress-go on %s is aborting: %s", w.host, e.Message)
+	case events.RunFinished:
+		n.Text = i18n.Sprintf("stress-go finished on %s: %s", w.host, e.Message)
+		if summarize != nil {
+			summary := summarize(e)
+			n.Summary = &summary
+		}
+	default:
+		return
+	}
+	if err := w.post(n); err != nil {
+		term.Eprintf("Warning: Failed to send notification: %v\n", err)
+	}
+}
+
+// post sends n as JSON and checks that the endpoint accepted it.
+func (w *webhook) post(n notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %v", err)
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
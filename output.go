@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Formats of --output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputCSV  = "csv"
+)
+
+// csvHeader is the first row of --output csv. Sample rows carry the values of
+// one sample, summary rows the means of a stressor over the run.
+var csvHeader = []string{"type", "time", "stressor", "unit", "target", "achieved", "ops_per_second"}
+
+// outputRecord is one line of --output json: a sample, a message, a change of
+// the run state or the summary written when the run finishes.
+type outputRecord struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	Stressor string    `json:"stressor,omitempty"`
+	// Unit, Target, Achieved and OpsPerSecond are set on samples
+	Unit         string   `json:"unit,omitempty"`
+	Target       *float64 `json:"target,omitempty"`
+	Achieved     *float64 `json:"achieved,omitempty"`
+	OpsPerSecond *float64 `json:"ops_per_second,omitempty"`
+	// Message is the text of a message or of an event
+	Message string `json:"message,omitempty"`
+	// Fields are the details of an event
+	Fields  map[string]any   `json:"fields,omitempty"`
+	Summary *cluster.Summary `json:"summary,omitempty"`
+}
+
+// outputWriter streams the samples of every stressor while the run goes on, and
+// its summary when it finishes, to --report-file or to stdout in the format of
+// --output, so that a test harness can follow and assert on the achieved load.
+type outputWriter struct {
+	format string
+	// summarize builds the summary written when the run finishes
+	summarize func(events.Event) cluster.Summary
+
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	csv    *csv.Writer
+	err    error
+}
+
+// validOutput reports whether format is one of the formats of --output.
+func validOutput(format string) bool {
+	return format == outputText || format == outputJSON || format == outputCSV
+}
+
+// newOutputWriter creates path, or writes to stdout if path is empty.
+func newOutputWriter(format, path string) (*outputWriter, error) {
+	o := &outputWriter{format: format, w: os.Stdout}
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		o.w, o.closer = f, f
+	}
+	if format == outputCSV {
+		o.csv = csv.NewWriter(o.w)
+		o.csv.Write(csvHeader)
+		o.csv.Flush()
+	}
+	return o, nil
+}
+
+// sample writes one sample of a stressor.
+func (o *outputWriter) sample(s metrics.Sample) {
+	switch o.format {
+	case outputJSON:
+		o.writeJSON(outputRecord{Type: "sample", Time: s.Time, Stressor: s.Stressor, Unit: s.Unit,
+			Target: &s.Target, Achieved: &s.Achieved, OpsPerSecond: &s.OpsPerSecond})
+	case outputCSV:
+		o.writeCSV("sample", s.Time, s.Stressor, s.Unit, s.Target, s.Achieved, s.OpsPerSecond)
+	default:
+		o.writeText("%s [%s] %s of %s\n", s.Time.Format(time.RFC3339), s.Stressor,
+			metrics.FormatValue(s.Unit, s.Achieved), metrics.FormatValue(s.Unit, s.Target))
+	}
+}
+
+// message writes a progress message of a stressor. CSV has no row for them.
+func (o *outputWriter) message(m metrics.Message) {
+	switch o.format {
+	case outputJSON:
+		o.writeJSON(outputRecord{Type: "message", Time: m.Time, Stressor: m.Stressor, Message: m.Text})
+	case outputText:
+		o.writeText("%s [%s] %s\n", m.Time.Format(time.RFC3339), m.Stressor, m.Text)
+	}
+}
+
+// observe writes the changes of the run state, and the summary once the run
+// finishes, after which the report file is closed.
+func (o *outputWriter) observe(e events.Event) {
+	if e.Type != events.RunFinished {
+		if o.format == outputJSON {
+			o.writeJSON(outputRecord{Type: string(e.Type), Time: e.Time, Stressor: e.Stressor, Message: e.Message, Fields: e.Fields})
+		}
+		return
+	}
+
+	summary := o.summarize(e)
+	switch o.format {
+	case outputJSON:
+		o.writeJSON(outputRecord{Type: "summary", Time: e.Time, Summary: &summary})
+	case outputCSV:
+		for _, s := range summary.Stressors {
+			o.writeCSV("summary", summary.End, s.Name, s.Unit, s.Target, s.Achieved, 0)
+		}
+	default:
+		o.writeText("%s Finished with exit code %d: %s\n", summary.End.Format(time.RFC3339), summary.ExitCode, summary.Message)
+		for _, s := range summary.Stressors {
+			o.writeText("%s [%s] mean %s of %s (%+.1f%%)\n", summary.End.Format(time.RFC3339), s.Name,
+				metrics.FormatValue(s.Unit, s.Achieved), metrics.FormatValue(s.Unit, s.Target), s.Deviation)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closer != nil {
+		if err := o.closer.Close(); err != nil && o.err == nil {
+			o.err = err
+		}
+		o.closer = nil
+	}
+	if o.err != nil {
+		term.Eprintf("Error: Failed to write the report: %v\n", o.err)
+	}
+	// Samples recorded while the stressors wind down have nowhere to go
+	o.w = io.Discard
+	if o.csv != nil {
+		o.csv = csv.NewWriter(io.Discard)
+	}
+}
+
+func (o *outputWriter) writeJSON(r outputRecord) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	o.write(append(data, '\n'))
+}
+
+func (o *outputWriter) writeCSV(kind string, t time.Time, stressor, unit string, target, achieved, ops float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.csv.Write([]string{kind, t.Format(time.RFC3339Nano), stressor, unit,
+		formatCSVFloat(target), formatCSVFloat(achieved), formatCSVFloat(ops)})
+	o.csv.Flush()
+	if err := o.csv.Error(); err != nil && o.err == nil {
+		o.err = err
+	}
+}
+
+func (o *outputWriter) writeText(format string, args ...any) {
+	o.write(fmt.Appendf(nil, format, args...))
+}
+
+// write writes one complete line, keeping the first error for the end of the
+// run rather than warning on every sample.
+func (o *outputWriter) write(line []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, err := o.w.Write(line); err != nil && o.err == nil {
+		o.err = err
+	}
+}
+
+// formatCSVFloat formats a value with no more digits than it needs.
+func formatCSVFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
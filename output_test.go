@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+func TestOutputWriter(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: outputJSON,
+			want: []string{
+				`{"type":"run_started","time":"2026-01-02T03:04:05Z","fields":{"duration":"1m0s"}}`,
+				`{"type":"sample","time":"2026-01-02T03:04:05Z","stressor":"CPU","unit":"cores","target":2,"achieved":1.5,"ops_per_second":0}`,
+				`{"type":"message","time":"2026-01-02T03:04:05Z","stressor":"CPU","message":"Starting"}`,
+				`{"type":"summary","time":"2026-01-02T03:04:05Z","summary":{"start":"0001-01-01T00:00:00Z","end":"2026-01-02T03:04:05Z","exit_code":0,"stressors":[{"name":"CPU","unit":"cores","target":2,"achieved":1.5,"min_achieved":0,"deviation":-25,"degraded":true}]}}`,
+			},
+		},
+		{
+			format: outputCSV,
+			want: []string{
+				"type,time,stressor,unit,target,achieved,ops_per_second",
+				"sample,2026-01-02T03:04:05Z,CPU,cores,2,1.5,0",
+				"summary,2026-01-02T03:04:05Z,CPU,cores,2,1.5,0",
+			},
+		},
+		{
+			format: outputText,
+			want: []string{
+				"2026-01-02T03:04:05Z [CPU] 1.50 cores of 2.00 cores",
+				"2026-01-02T03:04:05Z [CPU] Starting",
+				"2026-01-02T03:04:05Z Finished with exit code 0: ",
+				"2026-01-02T03:04:05Z [CPU] mean 1.50 cores of 2.00 cores (-25.0%)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report")
+			output, err := newOutputWriter(tt.format, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			output.summarize = func(e events.Event) cluster.Summary {
+				return cluster.Summary{End: e.Time, Stressors: []cluster.StressorSummary{
+					{Name: "CPU", Unit: metrics.UnitCores, Target: 2, Achieved: 1.5, Deviation: -25, Degraded: true},
+				}}
+			}
+			output.observe(events.Event{Time: at, Type: events.RunStarted, Fields: map[string]any{"duration": "1m0s"}})
+			output.sample(metrics.Sample{Time: at, Stressor: "CPU", Unit: metrics.UnitCores, Target: 2, Achieved: 1.5})
+			output.message(metrics.Message{Time: at, Stressor: "CPU", Text: "Starting"})
+			output.observe(events.Event{Time: at, Type: events.RunFinished})
+			// Samples after the end of the run are dropped
+			output.sample(metrics.Sample{Time: at, Stressor: "CPU", Unit: metrics.UnitCores})
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("report =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
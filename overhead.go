@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/overhead"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// overheadInterval is how often the overhead is recorded for the report.
+const overheadInterval = 2 * time.Second
+
+// overheadMeter measures the CPU time and memory used by stress-go's own
+// bookkeeping and the Go runtime over the run, apart from the load.
+type overheadMeter struct {
+	start     overhead.Usage
+	startWall time.Time
+	// startProcess is the CPU time of the whole process at the start, for the
+	// share of the overhead, unless processErr is set.
+	startProcess time.Duration
+	processErr   error
+	reserved     int // the CPU of --overhead-cpu, or -1
+	peakMemory   atomic.Int64
+}
+
+// newOverheadMeter starts measuring from now.
+func newOverheadMeter(reserved int) *overheadMeter {
+	m := &overheadMeter{start: overhead.Sample(), startWall: time.Now(), reserved: reserved}
+	m.startProcess, m.processErr = sysinfo.ReadProcessCPUTime(os.Getpid())
+	return m
+}
+
+// bookkeepingMemory returns the memory the Go runtime uses beyond the buffers
+// of the memory load.
+func bookkeepingMemory(u overhead.Usage) int64 {
+	return max(int64(u.Memory)-memory.HeldBytes(), 0)
+}
+
+// measure records the overhead of every interval until ctx is done.
+func (m *overheadMeter) measure(ctx context.Context, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(overheadInterval)
+	defer ticker.Stop()
+
+	last, lastWall := m.start, m.startWall
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			u := overhead.Sample()
+			recorder.RecordValue("Self overhead CPU", metrics.UnitCores, float64(u.CPU()-last.CPU())/float64(now.Sub(lastWall)))
+			used := bookkeepingMemory(u)
+			recorder.RecordValue("Self overhead memory", metrics.UnitBytes, float64(used))
+			if used > m.peakMemory.Load() {
+				m.peakMemory.Store(used)
+			}
+			last, lastWall = u, now
+		}
+	}
+}
+
+// total returns the overhead since the meter started. The memory is the peak
+// of the samples taken while the load ran, as the runtime keeps the memory of
+// the released load for a while after it ends.
+func (m *overheadMeter) total() (cluster.OverheadSummary, error) {
+	u := overhead.Sample()
+	wall := time.Since(m.startWall)
+	summary := cluster.OverheadSummary{
+		BookkeepingSeconds: (u.Bookkeeping - m.start.Bookkeeping).Seconds(),
+		RuntimeSeconds:     (u.Runtime - m.start.Runtime).Seconds(),
+		PeakMemoryBytes:    m.peakMemory.Load(),
+	}
+	cpu := u.CPU() - m.start.CPU()
+	summary.Cores = float64(cpu) / float64(wall)
+	if end, err := sysinfo.ReadProcessCPUTime(os.Getpid()); m.processErr == nil && err == nil && end > m.startProcess {
+		summary.Share = float64(cpu) / float64(end-m.startProcess)
+	}
+	if m.reserved >= 0 {
+		summary.ReservedCPU = &m.reserved
+	}
+	return summary, u.BookkeepingErr
+}
+
+// printOverheadReport prints the CPU time and memory used by stress-go itself.
+func printOverheadReport(s cluster.OverheadSummary, bookkeepingErr error) {
+	term.Printf("Self overhead:\n")
+	if bookkeepingErr != nil {
+		term.Printf("  CPU: %.3f cores (Go runtime %.2fs; bookkeeping not measured: %v)\n", s.Cores, s.RuntimeSeconds, bookkeepingErr)
+	} else {
+		term.Printf("  CPU: %.3f cores (bookkeeping %.2fs, Go runtime %.2fs)\n", s.Cores, s.BookkeepingSeconds, s.RuntimeSeconds)
+	}
+	if s.Share > 0 {
+		term.Printf("  Share of the process CPU time: %.2f%%\n", s.Share*100)
+	}
+	if s.PeakMemoryBytes > 0 {
+		term.Printf("  Memory: %s at peak, beyond the memory load\n", metrics.FormatValue(metrics.UnitBytes, float64(s.PeakMemoryBytes)))
+	}
+	if s.ReservedCPU != nil {
+		term.Printf("  Bookkeeping confined to CPU %d\n", *s.ReservedCPU)
+	}
+	term.Println()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/pattern"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// patternStressors are the stressors whose level a load pattern steps.
+var patternStressors = map[string]bool{"CPU": true, "Memory": true}
+
+// startPattern applies the first level of p before the stressors start and steps
+// through the remaining levels in the background until ctx is done. Each step is
+// recorded as a phase so that the reports show when every transition happened.
+// A resumed run passes the time it had been going as offset to continue at the
+// step it had reached.
+func startPattern(ctx context.Context, p *pattern.Pattern, offset time.Duration, registry *stressor.Registry, recorder *metrics.Recorder, bus *events.Bus) {
+	var targets []stressor.Controllable
+	for _, s := range registry.Stressors() {
+		if c, ok := s.(stressor.Controllable); ok && patternStressors[s.Name()] {
+			targets = append(targets, c)
+		}
+	}
+
+	start := time.Now().Add(-offset)
+	apply := func(step int) {
+		level := p.Levels[step]
+		for _, t := range targets {
+			t.SetLevel(level)
+		}
+		name := fmt.Sprintf("Step %d/%d: %g%%", step+1, len(p.Levels), level*100)
+		recorder.StartPhase(name)
+		fields := map[string]any{"step": step + 1, "level": level}
+		// The last level has no end of its own; it lasts until the end of the run
+		if step < len(p.Levels)-1 {
+			fields["end"] = start.Add(p.Hold * time.Duration(step+1))
+		}
+		bus.Publish(events.Event{Type: events.PhaseStarted, Message: name, Fields: fields})
+	}
+	first := p.Step(offset)
+	apply(first)
+
+	go func() {
+		step := first
+		defer func() {
+			recorder.EndPhase()
+			bus.Publish(events.Event{Type: events.PhaseFinished, Fields: map[string]any{"step": step + 1}})
+		}()
+		for step < len(p.Levels)-1 {
+			timer := time.NewTimer(time.Until(start.Add(p.Hold * time.Duration(step+1))))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			bus.Publish(events.Event{Type: events.PhaseFinished, Fields: map[string]any{"step": step + 1}})
+			step++
+			apply(step)
+		}
+		// The last level holds until the end of the run
+		<-ctx.Done()
+	}()
+}
@@ -0,0 +1,95 @@
+// Package budget は実行全体で共有する資源 (メモリ・ディスク容量・CPU) の上限を管理します。
+//
+// --max-memory、--max-disk、--max-cpu-percent の上限は負荷生成モジュールごとではなく実行全体に適用されます。
+// 負荷生成モジュールは資源を使用する前に Budget から予約し、解放したときに返却するため、
+// 目標値の計算を誤ったモジュールや複数のジョブがあっても、合計が上限を超えることはありません。
+// 量の単位は資源ごとに決めます (メモリとディスク容量はバイト、CPU はミリコア)。
+package budget
+
+import "sync/atomic"
+
+// Budget は複数の負荷生成モジュールで共有する資源の上限と使用量です。New で作成します。
+// nil の Budget は上限なしとして動作します。
+type Budget struct {
+	limit int64 // 0 means unlimited
+	usage atomic.Int64
+	peak  atomic.Int64
+}
+
+// New は上限 limit の Budget を作成します。
+//
+// 引数:
+//
+//	limit - 資源の上限 (0 の場合は上限なし)
+func New(limit int64) *Budget {
+	return &Budget{limit: limit}
+}
+
+// Reserve は残りの資源から最大 n を予約し、予約できた量を返します。上限に達している場合は 0 を返します。
+// 予約した資源は使い終わったら Release で返却してください。
+//
+// 引数:
+//
+//	n - 予約する量
+func (b *Budget) Reserve(n int64) int64 {
+	if b == nil {
+		return n
+	}
+	for {
+		used := b.usage.Load()
+		granted := n
+		if b.limit > 0 {
+			granted = min(n, max(b.limit-used, 0))
+		}
+		if b.usage.CompareAndSwap(used, used+granted) {
+			b.updatePeak(used + granted)
+			return granted
+		}
+	}
+}
+
+// updatePeak raises the recorded peak usage to at least used.
+func (b *Budget) updatePeak(used int64) {
+	for {
+		peak := b.peak.Load()
+		if used <= peak || b.peak.CompareAndSwap(peak, used) {
+			return
+		}
+	}
+}
+
+// Release は Reserve で予約した資源を返却します。
+//
+// 引数:
+//
+//	n - 返却する量
+func (b *Budget) Release(n int64) {
+	if b == nil {
+		return
+	}
+	b.usage.Add(-n)
+}
+
+// Limit は上限を返します。上限がない場合は 0 です。
+func (b *Budget) Limit() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.limit
+}
+
+// Used は現在予約されている量を返します。
+func (b *Budget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.usage.Load()
+}
+
+// Peak は実行中に予約された量の最大値を返します。
+func (b *Budget) Peak() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.peak.Load()
+}
@@ -0,0 +1,49 @@
+package budget
+
+import "testing"
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		reserves []int64
+		granted  []int64
+	}{
+		{"unlimited", 0, []int64{100, 200}, []int64{100, 200}},
+		{"within", 300, []int64{100, 200}, []int64{100, 200}},
+		{"capped", 250, []int64{100, 200, 50}, []int64{100, 150, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.limit)
+			for i, n := range tt.reserves {
+				if got := b.Reserve(n); got != tt.granted[i] {
+					t.Errorf("Reserve(%d) #%d = %d, want %d", n, i, got, tt.granted[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	b := New(100)
+	b.Reserve(80)
+	b.Release(50)
+	if got := b.Reserve(100); got != 70 {
+		t.Errorf("Reserve after Release = %d, want 70", got)
+	}
+	if b.Used() != 100 || b.Peak() != 100 {
+		t.Errorf("Used, Peak = %d, %d, want 100, 100", b.Used(), b.Peak())
+	}
+}
+
+func TestNil(t *testing.T) {
+	var b *Budget
+	if got := b.Reserve(42); got != 42 {
+		t.Errorf("nil Reserve(42) = %d, want 42", got)
+	}
+	b.Release(42)
+	if b.Limit() != 0 {
+		t.Errorf("nil Limit = %d, want 0", b.Limit())
+	}
+}
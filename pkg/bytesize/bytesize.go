@@ -0,0 +1,136 @@
+// Package bytesize はサイズ指定 (例: "1.5GiB", "512MB", "80%") の解析と表示を提供します。
+package bytesize
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// specPattern splits a size such as "1.5 GiB" or "80%" into its number and unit.
+var specPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?|\.[0-9]+)\s*([A-Za-z]*|%)$`)
+
+// units maps lower-case unit names to their size in bytes. SI units (KB, MB, ...)
+// are powers of 1000 and IEC units (KiB, MiB, ...) powers of 1024, as in cloud
+// provider dashboards and df -H / df -h. A bare unit letter (K, M, ...) is binary.
+var units = map[string]int64{
+	"": 1, "b": 1, "byte": 1, "bytes": 1,
+
+	"kb": 1e3, "kilobyte": 1e3, "kilobytes": 1e3,
+	"mb": 1e6, "megabyte": 1e6, "megabytes": 1e6,
+	"gb": 1e9, "gigabyte": 1e9, "gigabytes": 1e9,
+	"tb": 1e12, "terabyte": 1e12, "terabytes": 1e12,
+
+	"k": 1 << 10, "kib": 1 << 10, "kibibyte": 1 << 10, "kibibytes": 1 << 10,
+	"m": 1 << 20, "mib": 1 << 20, "mebibyte": 1 << 20, "mebibytes": 1 << 20,
+	"g": 1 << 30, "gib": 1 << 30, "gibibyte": 1 << 30, "gibibytes": 1 << 30,
+	"t": 1 << 40, "tib": 1 << 40, "tebibyte": 1 << 40, "tebibytes": 1 << 40,
+}
+
+// Spec は解析したサイズ指定です。Bytes と Percent のどちらか一方だけが 0 より大きい値を持ちます。
+type Spec struct {
+	// Input は解析した元の文字列です。
+	Input string
+	// Bytes は絶対値で指定したサイズ (バイト) です。
+	Bytes int64
+	// Percent はパーセンテージで指定した割合 (0 より大きく 100 以下) です。
+	Percent float64
+}
+
+// Parse は "1.5GiB"・"512MB"・"80%" 形式のサイズ指定を解析します。
+// 0 以下のサイズ、0% や 100% を超える割合、int64 に収まらないサイズはエラーになります。
+//
+// 引数:
+//
+//	s - サイズ指定
+func Parse(s string) (Spec, error) {
+	input := strings.TrimSpace(s)
+	spec := Spec{Input: input}
+	if input == "" {
+		return spec, fmt.Errorf("size is empty (expected e.g. 512MiB, 1.5GB or 80%%)")
+	}
+	if strings.HasPrefix(input, "-") {
+		return spec, fmt.Errorf("invalid size %q: sizes must be positive", input)
+	}
+	matches := specPattern.FindStringSubmatch(input)
+	if matches == nil {
+		return spec, fmt.Errorf("invalid size %q: expected a number followed by an optional unit, e.g. 512MiB, 1.5GB or 80%%", input)
+	}
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return spec, fmt.Errorf("invalid size %q: %v", input, err)
+	}
+
+	if matches[2] == "%" {
+		if value <= 0 || value > 100 {
+			return spec, fmt.Errorf("invalid size %q: percentages must be greater than 0%% and at most 100%%", input)
+		}
+		spec.Percent = value
+		return spec, nil
+	}
+
+	multiplier, ok := units[strings.ToLower(matches[2])]
+	if !ok {
+		return spec, fmt.Errorf("invalid size %q: unknown unit %q (use B, KB/MB/GB/TB, KiB/MiB/GiB/TiB or K/M/G/T)", input, matches[2])
+	}
+	bytes := value * float64(multiplier)
+	if bytes >= math.MaxInt64 {
+		return spec, fmt.Errorf("invalid size %q: too large", input)
+	}
+	spec.Bytes = int64(bytes)
+	if spec.Bytes <= 0 {
+		return spec, fmt.Errorf("invalid size %q: sizes must be at least 1 byte", input)
+	}
+	return spec, nil
+}
+
+// ParseAbsolute はパーセンテージを許さないサイズ指定を解析し、バイト数を返します。
+//
+// 引数:
+//
+//	s - サイズ指定
+func ParseAbsolute(s string) (int64, error) {
+	spec, err := Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	if spec.IsPercent() {
+		return 0, fmt.Errorf("invalid size %q: percentages are not supported here, give an absolute size such as 4GiB", spec.Input)
+	}
+	return spec.Bytes, nil
+}
+
+// IsPercent はパーセンテージで指定されたかどうかを返します。
+func (s Spec) IsPercent() bool {
+	return s.Percent > 0
+}
+
+// String は解析結果を "1GB = 1000000000 bytes (953.67 MiB)" または "80%" の形式で返します。
+func (s Spec) String() string {
+	if s.IsPercent() {
+		return strconv.FormatFloat(s.Percent, 'f', -1, 64) + "%"
+	}
+	return fmt.Sprintf("%s = %d bytes (%s)", s.Input, s.Bytes, Format(s.Bytes))
+}
+
+// Format はバイト数を IEC 単位で読みやすく表します (例: "953.67 MiB")。
+//
+// 引数:
+//
+//	bytes - バイト数
+func Format(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	for _, prefix := range []string{"KiB", "MiB", "GiB", "TiB"} {
+		value /= unit
+		if value < unit || prefix == "TiB" {
+			return fmt.Sprintf("%.2f %s", value, prefix)
+		}
+	}
+	return ""
+}
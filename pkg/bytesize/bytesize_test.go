@@ -0,0 +1,91 @@
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		bytes   int64
+		percent float64
+		ok      bool
+	}{
+		{"512", 512, 0, true},
+		{"1B", 1, 0, true},
+		{"1KB", 1000, 0, true},
+		{"1kb", 1000, 0, true},
+		{"1.5GB", 1_500_000_000, 0, true},
+		{"2 megabytes", 2_000_000, 0, true},
+		{"1KiB", 1024, 0, true},
+		{"1K", 1024, 0, true},
+		{"1.5GiB", 3 << 29, 0, true},
+		{"2 mebibytes", 2 << 20, 0, true},
+		{"1TiB", 1 << 40, 0, true},
+		{".5MiB", 512 << 10, 0, true},
+		{" 80% ", 0, 80, true},
+		{"100%", 0, 100, true},
+		{"0.5%", 0, 0.5, true},
+		{"", 0, 0, false},
+		{"-1GB", 0, 0, false},
+		{"0", 0, 0, false},
+		{"0.1B", 0, 0, false},
+		{"0%", 0, 0, false},
+		{"101%", 0, 0, false},
+		{"1PB", 0, 0, false},
+		{"1.GB", 0, 0, false},
+		{"GB", 0, 0, false},
+		{"9999999TiB", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			spec, err := Parse(tt.input)
+			if (err == nil) != tt.ok {
+				t.Fatalf("Parse(%q) = %v, want ok=%v", tt.input, err, tt.ok)
+			}
+			if err == nil && (spec.Bytes != tt.bytes || spec.Percent != tt.percent) {
+				t.Errorf("Parse(%q) = %d bytes, %g%%, want %d bytes, %g%%", tt.input, spec.Bytes, spec.Percent, tt.bytes, tt.percent)
+			}
+		})
+	}
+}
+
+func TestParseAbsolute(t *testing.T) {
+	tests := []struct {
+		input string
+		bytes int64
+		ok    bool
+	}{
+		{"4GiB", 4 << 30, true},
+		{"500MB", 500_000_000, true},
+		{"50%", 0, false},
+		{"lots", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			bytes, err := ParseAbsolute(tt.input)
+			if (err == nil) != tt.ok {
+				t.Fatalf("ParseAbsolute(%q) = %v, want ok=%v", tt.input, err, tt.ok)
+			}
+			if bytes != tt.bytes {
+				t.Errorf("ParseAbsolute(%q) = %d, want %d", tt.input, bytes, tt.bytes)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{512, "512 B"},
+		{1024, "1.00 KiB"},
+		{1_000_000_000, "953.67 MiB"},
+		{3 << 39, "1.50 TiB"},
+		{2 << 50, "2048.00 TiB"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.bytes); got != tt.want {
+			t.Errorf("Format(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,549 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBusy は別のジョブの実行中にジョブを開始しようとした場合のエラーです。
+var ErrBusy = errors.New("still running")
+
+// outputLines is the number of trailing output lines kept for each job.
+const outputLines = 200
+
+// controlClient forwards requests to the jobs' control API.
+var controlClient = &http.Client{Timeout: requestTimeout}
+
+// Agent はジョブを受け付けて実行するエージェントです。NewAgent で作成し、
+// Handler を HTTP サーバーに登録して使用します。同時に実行できるジョブは1つです。
+type Agent struct {
+	executable string
+	token      string
+	logf       func(format string, args ...any)
+	// local is set for an agent that only its owner reaches, which accepts
+	// every option of a load test.
+	local bool
+
+	mu     sync.Mutex
+	nextID int
+	job    *job // the current or most recent job
+}
+
+// job is one run of the stress-go executable.
+type job struct {
+	cmd *exec.Cmd
+	// controlAddr is the local address on which the job serves its status and control API.
+	controlAddr string
+
+	mu     sync.Mutex
+	status JobStatus
+	done   chan struct{}
+}
+
+// NewAgent は executable をジョブとして実行する Agent を作成します。
+//
+// 引数:
+//
+//	executable - ジョブとして実行する stress-go の実行ファイル
+//	token      - リクエストに要求する Bearer トークン (空の場合は認証なし)
+//	logf       - ジョブの開始・終了を記録する関数 (nil の場合は記録しない)
+func NewAgent(executable, token string, logf func(format string, args ...any)) *Agent {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	return &Agent{executable: executable, token: token, logf: logf}
+}
+
+// NewLocalAgent は所有者だけが操作できる経路 (unix ソケットなど) で使用する Agent を作成します。
+// NewAgent と異なり、プラグイン・フック・ファイルへの出力を含むすべてのオプションを受け付けます。
+//
+// 引数:
+//
+//	executable - ジョブとして実行する stress-go の実行ファイル
+//	logf       - ジョブの開始・終了を記録する関数 (nil の場合は記録しない)
+func NewLocalAgent(executable string, logf func(format string, args ...any)) *Agent {
+	a := NewAgent(executable, "", logf)
+	a.local = true
+	return a
+}
+
+// Handler はエージェントの HTTP API を返します。
+//
+//	GET    /v1/clock      エージェントの現在時刻 (Clock) を返す
+//	POST   /v1/jobs       JobSpec を受け取ってジョブを開始し、JobStatus を返す
+//	GET    /v1/jobs/{id}  ジョブの JobStatus を返す (id に "current" で最新のジョブ)
+//	DELETE /v1/jobs/{id}  ジョブを停止する (Ctrl+C と同じ後始末が行われる)
+//	GET    /v1/jobs/{id}/live    実行中のジョブの LiveStatus を返す
+//	POST   /v1/jobs/{id}/pause   実行中のジョブの負荷を一時停止し、LiveStatus を返す
+//	POST   /v1/jobs/{id}/resume  一時停止した負荷を再開し、LiveStatus を返す
+//	POST   /v1/jobs/{id}/level   LevelRequest を受け取って負荷レベルを変更し、LiveStatus を返す
+//	PATCH  /v1/jobs/{id}/stressors/{name}  TargetRequest を受け取って1つの負荷の目標値を変更し、LiveStatus を返す
+func (a *Agent) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/clock", a.handleClock)
+	mux.HandleFunc("POST /v1/jobs", a.handleStart)
+	mux.HandleFunc("GET /v1/jobs/{id}", a.handleStatus)
+	mux.HandleFunc("DELETE /v1/jobs/{id}", a.handleStop)
+	mux.HandleFunc("GET /v1/jobs/{id}/live", a.handleControl("GET", "/v1/status"))
+	mux.HandleFunc("POST /v1/jobs/{id}/pause", a.handleControl("POST", "/v1/pause"))
+	mux.HandleFunc("POST /v1/jobs/{id}/resume", a.handleControl("POST", "/v1/resume"))
+	mux.HandleFunc("POST /v1/jobs/{id}/level", a.handleControl("POST", "/v1/level"))
+	mux.HandleFunc("PATCH /v1/jobs/{id}/stressors/{name}", a.handleControl("PATCH", "/v1/stressors/{name}"))
+	return a.authenticate(mux)
+}
+
+// authenticate rejects requests without the agent's token, if one is set.
+func (a *Agent) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" && r.Header.Get("Authorization") != "Bearer "+a.token {
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Agent) handleClock(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Clock{Time: time.Now()})
+}
+
+func (a *Agent) handleStart(w http.ResponseWriter, r *http.Request) {
+	var spec JobSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid job: %v", err))
+		return
+	}
+	if err := a.validateArgs(spec.Args); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := spec.Args
+	if !spec.StartAt.IsZero() {
+		args = append(slices.Clip(args), "--start-at", spec.StartAt.Format(time.RFC3339Nano))
+	}
+
+	status, err := a.start(args, spec.Scenario)
+	if errors.Is(err, ErrBusy) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, status)
+}
+
+// Start は args を指定してジョブを開始し、その JobStatus を返します。HTTP API を介さずにジョブを開始する場合に使用します。
+// 別のジョブが実行中の場合は ErrBusy を返します。
+//
+// 引数:
+//
+//	args - ジョブの実行ファイルに渡す負荷試験のオプション
+func (a *Agent) Start(args []string) (JobStatus, error) {
+	return a.start(args, "")
+}
+
+// start starts a job with args, running it on a copy of the scenario sent by
+// the coordinator unless scenario is empty.
+func (a *Agent) start(args []string, scenario string) (JobStatus, error) {
+	if err := a.validateArgs(args); err != nil {
+		return JobStatus{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.job != nil && !a.job.snapshot().Finished() {
+		return JobStatus{}, fmt.Errorf("job %d is %w", a.job.snapshot().ID, ErrBusy)
+	}
+
+	a.nextID++
+	j, err := a.startJob(a.nextID, args, scenario)
+	if err != nil {
+		return JobStatus{}, err
+	}
+	a.job = j
+	return j.snapshot(), nil
+}
+
+func (a *Agent) handleStatus(w http.ResponseWriter, r *http.Request) {
+	j, ok := a.lookup(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (a *Agent) handleStop(w http.ResponseWriter, r *http.Request) {
+	j, ok := a.lookup(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such job")
+		return
+	}
+	if !j.snapshot().Finished() {
+		a.logf("Stopping job %d", j.snapshot().ID)
+		j.stop()
+	}
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+// handleControl returns a handler that forwards the request to the job's own
+// status and control API at path, with {name} replaced by the stressor in the
+// request path, and relays its response.
+func (a *Agent) handleControl(method, path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		j, ok := a.lookup(r.PathValue("id"))
+		if !ok {
+			writeError(w, http.StatusNotFound, "no such job")
+			return
+		}
+		if status := j.snapshot(); status.Finished() {
+			writeError(w, http.StatusConflict, fmt.Sprintf("job %d has finished", status.ID))
+			return
+		}
+
+		path := strings.ReplaceAll(path, "{name}", url.PathEscape(r.PathValue("name")))
+		req, err := http.NewRequestWithContext(r.Context(), method, "http://"+j.controlAddr+path, r.Body)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := controlClient.Do(req)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("job %d is not reachable: %v", j.snapshot().ID, err))
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}
+}
+
+// lookup returns the job with the given ID, or the most recent job for "current".
+func (a *Agent) lookup(id string) (*job, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.job == nil {
+		return nil, false
+	}
+	if id == "current" {
+		return a.job, true
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n != a.job.snapshot().ID {
+		return nil, false
+	}
+	return a.job, true
+}
+
+// Busy はジョブを実行中かどうかを返します。
+func (a *Agent) Busy() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.job != nil && !a.job.snapshot().Finished()
+}
+
+// Stop は実行中のジョブを停止し、終了するまで待ちます。エージェントの終了時に使用します。
+func (a *Agent) Stop() {
+	a.mu.Lock()
+	j := a.job
+	a.mu.Unlock()
+	if j != nil && !j.snapshot().Finished() {
+		j.stop()
+		<-j.done
+	}
+}
+
+// jobOptions are the options a job started over the network may have: those
+// of the load itself. Options that run commands (--plugin and the hooks), write
+// files at a given path or send data to other hosts are left out, as anyone who
+// reaches the agent could otherwise run code or overwrite files on the host.
+// This includes the directories of the loads, which therefore write their
+// files in the temporary directory of the agent.
+var jobOptions = map[string]bool{
+	"lang": true, "timeout": true, "start-at": true, "dry-run": true,
+	"cpu": true, "cpu-load": true, "cpu-method": true, "cpu-bignum-bits": true, "cpu-verify": true,
+	"memory": true, "memory-verify": true, "memory-content": true,
+	"memory-mode": true, "memory-rate": true, "memory-workers": true,
+	"storage": true, "storage-verify": true, "storage-sync": true, "storage-network-mix": true,
+	"storage-files": true, "storage-bs": true, "io-mode": true, "io-depth": true, "fsync-every": true,
+	"gpu": true, "gpu-memory": true, "gpu-device": true,
+	"pagefault": true, "pagefault-rate": true,
+	"sparse": true, "sparse-rate": true,
+	"network": true, "network-size": true, "network-rate": true, "network-conns": true,
+	"procs": true, "procs-rate": true, "procs-lifetime": true,
+	"fd": true, "inodes": true, "files-depth": true, "files-rate": true,
+	"switch": true, "switch-rate": true, "timers": true, "timer-interval": true,
+	"job": true, "scenario": true, "pattern": true, "interval": true, "profile": true, "calibration": true,
+	"abort-if": true, "smart": true, "smart-device": true, "smart-interval": true, "smart-max-temp": true,
+	"no-thermal-failsafe": true, "soak-temp": true, "max-loadavg": true,
+	"max-memory": true, "max-disk": true, "max-cpu-percent": true, "max-mem": true, "max-disk-fill": true,
+	"extend-by": true, "ramp-up": true, "ramp-down": true, "stop-timeout": true, "grace-period": true, "stressor-timeout": true,
+	"drop-caches": true, "cloud-metadata": true, "fail-fast": true, "allow-sleep": true, "overhead-cpu": true, "no-dashboard": true,
+	"baseline": true, "victim": true, "probe-interval": true, "probes": true,
+	"slo": true, "slo-window": true, "slo-budget": true, "fail-under": true,
+	"chaos": true, "chaos-faults": true, "seed": true, "chaos-seed": true,
+}
+
+// validateArgs accepts load test arguments only, so that a job cannot start
+// another agent or coordinator. Unless the agent is local, every option must
+// also be one of jobOptions.
+func (a *Agent) validateArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("job arguments must not be empty")
+	}
+	if first := args[0]; !strings.HasPrefix(first, "-") && first != "replay" {
+		return fmt.Errorf("unsupported job command: %s", first)
+	}
+	if a.local {
+		return nil
+	}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		// Negative values such as "--extend-by -10m" are not options
+		if name != "" && name[0] >= '0' && name[0] <= '9' {
+			continue
+		}
+		if !jobOptions[name] {
+			return fmt.Errorf("option not allowed in a job: %s", arg)
+		}
+	}
+	for _, spec := range optionValues(args, "job") {
+		if jobDir(spec) {
+			return fmt.Errorf("job directory not allowed in a job: %s", spec)
+		}
+	}
+	return nil
+}
+
+// optionValues returns the values given to the option name in args, either as
+// "--name=value" or as "--name value".
+func optionValues(args []string, name string) []string {
+	var values []string
+	for i, arg := range args {
+		option, value, ok := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || option != name {
+			continue
+		}
+		if ok {
+			values = append(values, value)
+		} else if i+1 < len(args) {
+			values = append(values, args[i+1])
+		}
+	}
+	return values
+}
+
+// jobDir reports whether the --job value spec, such as
+// "logs=storage:size=10GB,dir=/mnt/logs", sets the directory of its files.
+func jobDir(spec string) bool {
+	_, options, _ := strings.Cut(spec, ":")
+	for _, option := range strings.Split(options, ",") {
+		if key, _, _ := strings.Cut(option, "="); strings.TrimSpace(key) == "dir" {
+			return true
+		}
+	}
+	return false
+}
+
+// startJob starts the executable with args and collects its output in the background.
+// The job writes its summary to a temporary file that is read once it has finished.
+// A scenario sent along is written to a temporary file too, which replaces the
+// --scenario path of args.
+func (a *Agent) startJob(id int, args []string, scenario string) (*job, error) {
+	summaryFile, err := os.CreateTemp("", "stress-go-summary-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summary file: %v", err)
+	}
+	summaryFile.Close()
+	summaryPath := summaryFile.Name()
+	temporary := []string{summaryPath}
+	removeTemporary := func() {
+		for _, path := range temporary {
+			os.Remove(path)
+		}
+	}
+
+	if scenario != "" {
+		scenarioPath, err := writeTemp("stress-go-scenario-*.json", scenario)
+		if err != nil {
+			removeTemporary()
+			return nil, fmt.Errorf("failed to write scenario file: %v", err)
+		}
+		temporary = append(temporary, scenarioPath)
+		args = withScenario(args, scenarioPath)
+	}
+
+	controlAddr, err := freeLocalAddr()
+	if err != nil {
+		removeTemporary()
+		return nil, fmt.Errorf("failed to reserve a control port: %v", err)
+	}
+
+	cmd := exec.Command(a.executable, append(slices.Clip(args), "--summary-json", summaryPath, "--listen", controlAddr)...)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	j := &job{
+		cmd:         cmd,
+		controlAddr: controlAddr,
+		done:        make(chan struct{}),
+		status: JobStatus{
+			ID:        id,
+			Args:      args,
+			State:     StateRunning,
+			StartedAt: time.Now(),
+		},
+	}
+	if err := cmd.Start(); err != nil {
+		removeTemporary()
+		return nil, fmt.Errorf("failed to start job: %v", err)
+	}
+	a.logf("Started job %d: %s", id, strings.Join(args, " "))
+
+	go j.collectOutput(reader)
+	go func() {
+		err := cmd.Wait()
+		writer.Close()
+		summary, _ := ReadSummary(summaryPath)
+		removeTemporary()
+
+		j.mu.Lock()
+		j.status.State = StateFinished
+		j.status.FinishedAt = time.Now()
+		j.status.ExitCode = cmd.ProcessState.ExitCode()
+		if err != nil && j.status.ExitCode < 0 {
+			j.status.Error = err.Error()
+		}
+		j.status.Summary = summary
+		code := j.status.ExitCode
+		j.mu.Unlock()
+
+		close(j.done)
+		a.logf("Job %d finished with exit status %d", id, code)
+	}()
+	return j, nil
+}
+
+// freeLocalAddr returns a loopback address with a port that is free at the moment.
+func freeLocalAddr() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
+
+// collectOutput keeps the last outputLines lines of the job's output. Progress
+// updates that rewrite the line with a carriage return keep only the latest text.
+func (j *job) collectOutput(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		j.mu.Lock()
+		j.status.Output = append(j.status.Output, line)
+		if excess := len(j.status.Output) - outputLines; excess > 0 {
+			j.status.Output = j.status.Output[excess:]
+		}
+		j.mu.Unlock()
+	}
+	io.Copy(io.Discard, r)
+}
+
+// stop asks the job to stop as if interrupted, so that it still cleans up.
+// Platforms without interrupt signals kill the process instead.
+func (j *job) stop() {
+	if err := j.cmd.Process.Signal(os.Interrupt); err != nil {
+		j.cmd.Process.Kill()
+	}
+}
+
+// snapshot returns a copy of the job status.
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	status.Output = append([]string(nil), j.status.Output...)
+	return status
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorResponse is the body of an error response.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, errorResponse{Error: message})
+}
+
+// writeTemp writes content to a new temporary file named after pattern and
+// returns its path.
+func writeTemp(pattern, content string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// withScenario returns args with the value of --scenario replaced by path, or
+// with --scenario path added if args have none.
+func withScenario(args []string, path string) []string {
+	args = slices.Clone(args)
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == "scenario" && i+1 < len(args) {
+			args[i+1] = path
+			return args
+		}
+		if strings.HasPrefix(name, "scenario=") {
+			args[i] = "--scenario=" + path
+			return args
+		}
+	}
+	return append(args, "--scenario", path)
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import "testing"
+
+func TestValidateArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		local bool
+		args  []string
+		ok    bool
+	}{
+		{"load options", false, []string{"--timeout", "30m", "--cpu", "0", "--memory=80%"}, true},
+		{"single dash", false, []string{"-timeout", "1m", "-cpu-verify"}, true},
+		{"negative value", false, []string{"--timeout", "1h", "--extend-by", "-10m"}, true},
+		{"replay", false, []string{"replay", "--profile", "load.json"}, true},
+		{"empty", false, nil, false},
+		{"subcommand", false, []string{"agent", "--listen", ":7420"}, false},
+		{"plugin", false, []string{"--timeout", "1m", "--plugin", "x=sh -c id"}, false},
+		{"hook", false, []string{"--timeout", "1m", "--pre-cmd=touch /tmp/x"}, false},
+		{"hook timeout", false, []string{"--timeout", "1m", "--hook-timeout", "1h"}, false},
+		{"hook after a boolean", false, []string{"--timeout", "1m", "--cpu-verify", "--post-cmd", "id"}, false},
+		{"file output", false, []string{"--timeout", "1m", "--report-html", "/etc/passwd"}, false},
+		{"summary", false, []string{"--timeout", "1m", "--summary-json=/root/x"}, false},
+		{"textfile", false, []string{"--timeout", "1m", "--textfile-dir", "/etc"}, false},
+		{"storage path", false, []string{"--timeout", "1m", "--storage", "1GB", "--storage-path", "/etc"}, false},
+		{"pagefault dir", false, []string{"--timeout", "1m", "--pagefault", "1GB", "--pagefault-dir=/etc"}, false},
+		{"sparse dir", false, []string{"--timeout", "1m", "--sparse", "1GB", "--sparse-dir", "/etc"}, false},
+		{"files dir", false, []string{"--timeout", "1m", "--inodes", "100000", "--files-dir=/etc"}, false},
+		{"job", false, []string{"--timeout", "1m", "--job", "logs=storage:size=1GB,sync=dsync"}, true},
+		{"job dir", false, []string{"--timeout", "1m", "--job", "logs=storage:size=1GB,dir=/etc"}, false},
+		{"job dir inline", false, []string{"--timeout", "1m", "--job=logs=storage:dir=/etc,size=1GB"}, false},
+		{"outbound", false, []string{"--timeout", "1m", "--notify-url", "http://example.com"}, false},
+		{"local storage path", true, []string{"--timeout", "1m", "--storage", "1GB", "--storage-path", "/mnt/data"}, true},
+		{"local plugin", true, []string{"--timeout", "1m", "--plugin", "x=./load"}, true},
+		{"local subcommand", true, []string{"agent"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAgent("stress-go", "", nil)
+			if tt.local {
+				a = NewLocalAgent("stress-go", nil)
+			}
+			err := a.validateArgs(tt.args)
+			if (err == nil) != tt.ok {
+				t.Errorf("validateArgs(%q) = %v, want ok=%v", tt.args, err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoJob は、指定したジョブ (またはまだ1つも開始していない場合の最新のジョブ) が
+// エージェントに存在しないことを表すエラーです。
+var ErrNoJob = errors.New("no such job")
+
+// CurrentJob はジョブの ID の代わりに指定すると、エージェントの最新のジョブを対象とします。
+const CurrentJob = 0
+
+// requestTimeout bounds each call so that one unreachable agent does not stall the others.
+const requestTimeout = 10 * time.Second
+
+// Client はエージェントの HTTP API を呼び出します。
+type Client struct {
+	host       string
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient はエージェントのホストに接続する Client を作成します。
+//
+// 引数:
+//
+//	host  - エージェントのホスト ("host" または "host:port"、ポート省略時は DefaultPort)
+//	token - エージェントに送る Bearer トークン (空の場合は送らない)
+func NewClient(host, token string) *Client {
+	baseURL := host
+	if !strings.Contains(host, "://") {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(DefaultPort))
+		}
+		baseURL = "http://" + host
+	}
+	return &Client{
+		host:       host,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewSocketClient は unix ソケットで待ち受けるエージェント (stress-go daemon) に接続する Client を作成します。
+//
+// 引数:
+//
+//	path - エージェントが待ち受ける unix ソケットのパス
+func NewSocketClient(path string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+	return &Client{
+		host:       path,
+		baseURL:    "http://stress-go",
+		httpClient: &http.Client{Timeout: requestTimeout, Transport: transport},
+	}
+}
+
+// Host はエージェントのホストを返します。
+func (c *Client) Host() string {
+	return c.host
+}
+
+// ClockOffset はエージェントの時計がこのホストの時計からどれだけ進んでいるかを測定します。
+// 往復時間の半分を片道の遅延とみなして補正します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+func (c *Client) ClockOffset(ctx context.Context) (time.Duration, error) {
+	var clock Clock
+	sent := time.Now()
+	if err := c.call(ctx, http.MethodGet, "/v1/clock", nil, &clock); err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	return clock.Time.Sub(midpoint), nil
+}
+
+// Join はコーディネーター (coordinate --workers) にワーカーとして参加します。このクライアントは
+// エージェントではなくコーディネーターのアドレスで作成します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	req - 参加の申し込み
+func (c *Client) Join(ctx context.Context, req JoinRequest) (JoinResponse, error) {
+	var resp JoinResponse
+	err := c.call(ctx, http.MethodPost, "/v1/join", req, &resp)
+	return resp, err
+}
+
+// Start はジョブを開始します。
+//
+// 引数:
+//
+//	ctx  - リクエストのコンテキスト
+//	spec - ジョブの内容
+func (c *Client) Start(ctx context.Context, spec JobSpec) (JobStatus, error) {
+	return c.do(ctx, http.MethodPost, "/v1/jobs", spec)
+}
+
+// Status はジョブの状態を取得します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	id  - ジョブの ID
+func (c *Client) Status(ctx context.Context, id int) (JobStatus, error) {
+	return c.do(ctx, http.MethodGet, jobPath(id), nil)
+}
+
+// Stop はジョブを停止します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	id  - ジョブの ID
+func (c *Client) Stop(ctx context.Context, id int) (JobStatus, error) {
+	return c.do(ctx, http.MethodDelete, jobPath(id), nil)
+}
+
+// Live は実行中のジョブの現在の状態を取得します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	id  - ジョブの ID
+func (c *Client) Live(ctx context.Context, id int) (LiveStatus, error) {
+	return c.control(ctx, http.MethodGet, id, "live", nil)
+}
+
+// Pause は実行中のジョブの負荷を一時停止します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	id  - ジョブの ID
+func (c *Client) Pause(ctx context.Context, id int) (LiveStatus, error) {
+	return c.control(ctx, http.MethodPost, id, "pause", nil)
+}
+
+// Resume は一時停止したジョブの負荷を再開します。
+//
+// 引数:
+//
+//	ctx - リクエストのコンテキスト
+//	id  - ジョブの ID
+func (c *Client) Resume(ctx context.Context, id int) (LiveStatus, error) {
+	return c.control(ctx, http.MethodPost, id, "resume", nil)
+}
+
+// SetLevel は実行中のジョブの負荷レベルを変更します。
+//
+// 引数:
+//
+//	ctx   - リクエストのコンテキスト
+//	id    - ジョブの ID
+//	level - 設定された目標値に掛ける倍率 (1.0 で設定どおり)
+func (c *Client) SetLevel(ctx context.Context, id int, level float64) (LiveStatus, error) {
+	return c.control(ctx, http.MethodPost, id, "level", LevelRequest{Level: level})
+}
+
+// SetTarget は実行中のジョブの1つの負荷の目標値を変更します。
+//
+// 引数:
+//
+//	ctx    - リクエストのコンテキスト
+//	id     - ジョブの ID
+//	name   - 負荷の名前 ("memory"、ジョブの名前など)
+//	target - 新しい目標値 (CPU はコア数、Memory と Storage はサイズ)
+func (c *Client) SetTarget(ctx context.Context, id int, name, target string) (LiveStatus, error) {
+	return c.control(ctx, http.MethodPatch, id, "stressors/"+url.PathEscape(name), TargetRequest{Target: target})
+}
+
+// control sends a request to the job's control endpoint and decodes its live status.
+func (c *Client) control(ctx context.Context, method string, id int, action string, body any) (LiveStatus, error) {
+	var status LiveStatus
+	err := c.call(ctx, method, jobPath(id)+"/"+action, body, &status)
+	return status, err
+}
+
+// jobPath returns the API path of the job with id, or of the latest job for CurrentJob.
+func jobPath(id int) string {
+	if id == CurrentJob {
+		return "/v1/jobs/current"
+	}
+	return fmt.Sprintf("/v1/jobs/%d", id)
+}
+
+// do sends a request and decodes the job status from the response.
+func (c *Client) do(ctx context.Context, method, path string, body any) (JobStatus, error) {
+	var status JobStatus
+	err := c.call(ctx, method, path, body, &status)
+	return status, err
+}
+
+// call sends a request with body encoded as JSON and decodes the response into result.
+func (c *Client) call(ctx context.Context, method, path string, body, result any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("agent %s: %v", c.host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var e errorResponse
+		json.NewDecoder(resp.Body).Decode(&e)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("agent %s: %w", c.host, ErrNoJob)
+		}
+		return fmt.Errorf("agent %s: %s: %s", c.host, resp.Status, e.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("agent %s: invalid response: %v", c.host, err)
+	}
+	return nil
+}
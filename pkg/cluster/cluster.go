@@ -0,0 +1,113 @@
+// Package cluster は複数ホストで負荷テストを実行するためのエージェントとコーディネーターの
+// 通信プロトコル (HTTP/JSON) を実装します。
+//
+// エージェントはジョブ (stress-go のコマンドライン引数) を受け付け、自身の実行ファイルを
+// 子プロセスとして起動して実行します。コーディネーターは Client を使用して複数のエージェントに
+// 同じジョブを送り、状態を収集します。
+package cluster
+
+import "time"
+
+// DefaultPort はエージェントが待ち受けるデフォルトのポートです。
+const DefaultPort = 7420
+
+// ジョブの状態です。
+const (
+	StateRunning  = "running"
+	StateFinished = "finished"
+)
+
+// JobSpec はエージェントに送るジョブの内容です。
+type JobSpec struct {
+	// Args は stress-go に渡すコマンドライン引数です (例: ["--timeout", "60s", "--cpu", "2"])。
+	Args []string `json:"args"`
+	// StartAt はエージェントの時計で負荷を開始する時刻です。全ホストの開始をそろえる
+	// 開始バリアとして使用します。ゼロ値の場合はすぐに開始します。
+	StartAt time.Time `json:"start_at,omitempty"`
+	// Scenario は --scenario のファイルの内容です。空でない場合、エージェントはこれを一時ファイルに
+	// 書き出し、Args の --scenario のパスをそのファイルに置き換えて実行します。
+	Scenario string `json:"scenario,omitempty"`
+}
+
+// Clock はエージェントの現在時刻です。コーディネーターとの時計のずれの測定に使用します。
+type Clock struct {
+	Time time.Time `json:"time"`
+}
+
+// JobStatus はエージェントで実行中または実行済みのジョブの状態です。
+type JobStatus struct {
+	// ID はエージェント内でジョブを識別する番号です。
+	ID int `json:"id"`
+	// Args はジョブのコマンドライン引数です。
+	Args []string `json:"args"`
+	// State は StateRunning または StateFinished です。
+	State string `json:"state"`
+	// ExitCode は終了したジョブの終了コードです。
+	ExitCode int `json:"exit_code"`
+	// Error はジョブを起動・待機できなかった場合のエラーです。
+	Error string `json:"error,omitempty"`
+	// StartedAt はジョブの開始時刻です。
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt はジョブの終了時刻です。実行中はゼロ値です。
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	// Output はジョブの出力の末尾の行です。
+	Output []string `json:"output,omitempty"`
+	// Summary は終了したジョブの結果の要約です。ジョブが要約を書き出さずに終了した場合は nil です。
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+// Finished はジョブが終了しているかどうかを返します。
+func (s JobStatus) Finished() bool {
+	return s.State == StateFinished
+}
+
+// LiveStatus は実行中の stress-go の現在の状態です。stress-go を --listen 付きで実行すると
+// GET /v1/status で返され、エージェントとコーディネーターが実行中の負荷を監視するために使用します。
+type LiveStatus struct {
+	// State は実行の状態 ("starting", "running", "stopping", "finished") です。
+	State string `json:"state"`
+	// Remaining は終了までの残り時間 (秒) です。
+	Remaining float64 `json:"remaining"`
+	// Paused は制御 API で負荷を一時停止しているかどうかです。
+	Paused bool `json:"paused"`
+	// Level は制御 API で設定した負荷レベル (1.0 で設定どおり) です。
+	Level float64 `json:"level"`
+	// Stressors は負荷生成モジュールごとの状態です。
+	Stressors []LiveStressor `json:"stressors"`
+}
+
+// LiveStressor は実行中の1つの負荷生成モジュールの状態です。
+type LiveStressor struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit"`
+	State string `json:"state"`
+	// Target と Achieved は現在の目標値と直近の実測値です。
+	Target   float64 `json:"target"`
+	Achieved float64 `json:"achieved"`
+	Paused   bool    `json:"paused"`
+	// Controllable は一時停止・負荷レベルの変更に対応しているかどうかです。
+	Controllable bool `json:"controllable"`
+}
+
+// LevelRequest は負荷レベルを変更するリクエストの内容です。
+type LevelRequest struct {
+	// Level は設定された目標値に掛ける倍率です (1.0 で設定どおり、0.5 で半分)。
+	Level float64 `json:"level"`
+}
+
+// TargetRequest は1つの負荷の目標値を変更するリクエストの内容です。
+type TargetRequest struct {
+	// Target は新しい目標値です。CPU はコア数 ("2.5")、Memory と Storage はサイズ ("4GB") です。
+	Target string `json:"target"`
+}
+
+// HostStatus はコーディネーターから見た1ホストの現在の状態です。
+type HostStatus struct {
+	Host string `json:"host"`
+	// Job はエージェントで実行中または実行済みのジョブの状態です。ジョブを開始できなかった場合は nil です。
+	Job *JobStatus `json:"job,omitempty"`
+	// Live は実行中のジョブの現在の状態です。ジョブが終了している場合は nil です。
+	Live *LiveStatus `json:"live,omitempty"`
+	// Error はホストの状態を取得できなかった場合のエラーです。
+	Error string `json:"error,omitempty"`
+}
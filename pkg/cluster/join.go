@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultJoinPort はコーディネーターが worker --join の参加を受け付けるデフォルトのポートです。
+const DefaultJoinPort = 7421
+
+// JoinRequest はワーカーがコーディネーターに参加を申し込む内容です。
+type JoinRequest struct {
+	// Address はワーカーのエージェントに接続するアドレス ("host:port") です。host を省略した場合は、
+	// コーディネーターから見た接続元のアドレスを使用します。
+	Address string `json:"address"`
+	// Hostname はワーカーのホスト名です。
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// JoinResponse は参加を受け付けたコーディネーターの応答です。
+type JoinResponse struct {
+	// Address はコーディネーターがワーカーのエージェントに接続するアドレスです。
+	Address string `json:"address"`
+	// Workers はこれまでに参加したワーカーの数です。
+	Workers int `json:"workers"`
+}
+
+// Lobby はコーディネーターでワーカーの参加を受け付け、必要な数がそろうのを待ちます。
+// NewLobby で作成し、Handler を HTTP サーバーに登録して使用します。
+type Lobby struct {
+	token string
+	size  int
+	logf  func(format string, args ...any)
+
+	mu      sync.Mutex
+	workers []string
+	// full is closed once size workers have joined.
+	full chan struct{}
+}
+
+// NewLobby はワーカーの参加を受け付ける Lobby を作成します。
+//
+// 引数:
+//
+//	token - ワーカーに要求する Bearer トークン (空の場合は認証なし)
+//	size  - 受け付けるワーカーの数
+//	logf  - 参加したワーカーを記録する関数 (nil の場合は記録しない)
+func NewLobby(token string, size int, logf func(format string, args ...any)) *Lobby {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	return &Lobby{token: token, size: size, logf: logf, full: make(chan struct{})}
+}
+
+// Handler は JoinRequest を受け取ってワーカーを登録し、JoinResponse を返すハンドラーを返します。
+// POST /v1/join に登録して使用します。必要な数がそろった後の新しい参加には 409 Conflict を返します。
+func (l *Lobby) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.token != "" && r.Header.Get("Authorization") != "Bearer "+l.token {
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		var req JoinRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid join request: %v", err))
+			return
+		}
+		address, err := workerAddress(req.Address, r.RemoteAddr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		n, err := l.add(address, req.Hostname)
+		if err != nil {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, JoinResponse{Address: address, Workers: n})
+	})
+}
+
+// add registers the worker at address unless it has joined already, and
+// returns the number of workers.
+func (l *Lobby) add(address, hostname string) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if slices.Contains(l.workers, address) {
+		return len(l.workers), nil
+	}
+	if len(l.workers) >= l.size {
+		return 0, fmt.Errorf("the coordinator already has all %d workers", l.size)
+	}
+	l.workers = append(l.workers, address)
+	if hostname != "" && hostname != address {
+		l.logf("Worker %d/%d joined: %s (%s)", len(l.workers), l.size, address, hostname)
+	} else {
+		l.logf("Worker %d/%d joined: %s", len(l.workers), l.size, address)
+	}
+	if len(l.workers) == l.size {
+		close(l.full)
+	}
+	return len(l.workers), nil
+}
+
+// Wait は必要な数のワーカーが参加するまで待ち、そのエージェントのアドレスを参加順に返します。
+// ctx が終了した場合は、それまでに参加したワーカーのアドレスと ctx のエラーを返します。
+//
+// 引数:
+//
+//	ctx - 待機のコンテキスト
+func (l *Lobby) Wait(ctx context.Context) ([]string, error) {
+	var err error
+	select {
+	case <-l.full:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.workers) == l.size {
+		err = nil
+	}
+	return slices.Clone(l.workers), err
+}
+
+// workerAddress resolves the agent address a worker advertised, taking the host
+// from the connection when the worker left it out or gave a wildcard address.
+func workerAddress(advertised, remoteAddr string) (string, error) {
+	host, port, err := net.SplitHostPort(advertised)
+	if err != nil {
+		return "", fmt.Errorf("invalid worker address %q: %v", advertised, err)
+	}
+	if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+		return "", fmt.Errorf("invalid port in worker address %q", advertised)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if host, _, err = net.SplitHostPort(remoteAddr); err != nil {
+			return "", fmt.Errorf("cannot determine the worker host: %v", err)
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLobby(t *testing.T) {
+	lobby := NewLobby("secret", 2, nil)
+	server := httptest.NewServer(lobby.Handler())
+	defer server.Close()
+
+	if _, err := NewClient(server.URL, "wrong").Join(context.Background(), JoinRequest{Address: ":7420"}); err == nil {
+		t.Error("Join with the wrong token succeeded")
+	}
+
+	client := NewClient(server.URL, "secret")
+	joins := []struct {
+		address string
+		want    string
+		ok      bool
+	}{
+		{":7420", "127.0.0.1:7420", true},
+		{"0.0.0.0:7420", "127.0.0.1:7420", true}, // the same worker again
+		{"node2:7500", "node2:7500", true},
+		{"node3:7420", "", false}, // the lobby is full
+		{"node4", "", false},
+	}
+	for _, j := range joins {
+		resp, err := client.Join(context.Background(), JoinRequest{Address: j.address})
+		if (err == nil) != j.ok || resp.Address != j.want {
+			t.Errorf("Join(%q) = %q, %v, want %q ok=%v", j.address, resp.Address, err, j.want, j.ok)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	workers, err := lobby.Wait(ctx)
+	if err != nil || !slices.Equal(workers, []string{"127.0.0.1:7420", "node2:7500"}) {
+		t.Errorf("Wait() = %q, %v", workers, err)
+	}
+}
+
+func TestLobbyWaitTimeout(t *testing.T) {
+	lobby := NewLobby("", 2, nil)
+	lobby.add("node1:7420", "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	workers, err := lobby.Wait(ctx)
+	if err == nil || len(workers) != 1 {
+		t.Errorf("Wait() = %q, %v, want one worker and an error", workers, err)
+	}
+}
+
+func TestWithScenario(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"--timeout", "1h", "--scenario", "a.json"}, []string{"--timeout", "1h", "--scenario", "/tmp/s.json"}},
+		{[]string{"-scenario=a.json", "--cpu", "1"}, []string{"--scenario=/tmp/s.json", "--cpu", "1"}},
+		{[]string{"--timeout", "1h"}, []string{"--timeout", "1h", "--scenario", "/tmp/s.json"}},
+	}
+	for _, tt := range tests {
+		if got := withScenario(tt.args, "/tmp/s.json"); !slices.Equal(got, tt.want) {
+			t.Errorf("withScenario(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// stragglerThreshold is the fraction of the cluster's median achieved/target ratio
+// below which a host is reported as a straggler for a stressor.
+const stragglerThreshold = 0.90
+
+// Summary は1ホストでの負荷テストの結果の要約です。stress-go を --summary-json 付きで
+// 実行すると書き出され、コーディネーターがホストごとの結果を集計するために使用します。
+type Summary struct {
+	// Host は結果を集めたホストです。コーディネーターが設定します。
+	Host string `json:"host,omitempty"`
+	// Start と End は負荷テストの開始・終了時刻です。
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// ExitCode は stress-go の終了コードです。
+	ExitCode int `json:"exit_code"`
+	// Message は終了時のメッセージです。
+	Message string `json:"message,omitempty"`
+	// Seed は負荷の乱数のシード (--seed) です。同じシードと設定で実行すると同じ負荷を再現できます。
+	Seed uint64 `json:"seed,omitempty"`
+	// Environment は実行環境 (ホスト名、ノード名、Pod名、コンテナランタイムなど) です。
+	Environment map[string]string `json:"environment,omitempty"`
+	// Stressors は負荷生成モジュールごとの目標値と実測値です。
+	Stressors []StressorSummary `json:"stressors"`
+	// Failures は負荷生成モジュールが返したエラーです。
+	Failures []string `json:"failures,omitempty"`
+	// Phases は --pattern の各ステップの開始・終了時刻です。
+	Phases []Phase `json:"phases,omitempty"`
+	// Power は RAPL で計測した電力ドメインごとの消費電力です。計測できなかった場合は空です。
+	Power []PowerSummary `json:"power,omitempty"`
+	// Baseline は --baseline で計測した負荷をかける前と負荷中のシステム指標です。計測しなかった場合は空です。
+	Baseline []BaselineDelta `json:"baseline,omitempty"`
+	// Probes はカナリアプローブごとのレイテンシの分布です。プローブを実行しなかった場合は空です。
+	Probes []ProbeSummary `json:"probes,omitempty"`
+	// SLOs は --slo で指定した目標ごとの違反の記録です。
+	SLOs []SLOSummary `json:"slos,omitempty"`
+	// Thresholds は --fail-under で指定した下限ごとの判定結果です。
+	Thresholds []ThresholdSummary `json:"thresholds,omitempty"`
+	// Overhead は stress-go 自身の計測・表示処理が使用した資源です。
+	Overhead *OverheadSummary `json:"overhead,omitempty"`
+}
+
+// OverheadSummary は stress-go 自身の計測・表示処理 (進行状況、指標の記録、監視) と Go ランタイムが使用した資源です。
+type OverheadSummary struct {
+	// BookkeepingSeconds は計測・表示処理の CPU 時間 (秒) です。計測できなかった場合は 0 です。
+	BookkeepingSeconds float64 `json:"bookkeeping_seconds"`
+	// RuntimeSeconds は Go ランタイムの GC とメモリの返却の CPU 時間 (秒、ランタイムの推定値) です。
+	RuntimeSeconds float64 `json:"runtime_seconds"`
+	// Cores は両者を合わせた平均の使用コア数です。
+	Cores float64 `json:"cores"`
+	// Share はプロセス全体の CPU 時間に占める割合 (0〜1) です。プロセスの CPU 時間を取得できなかった場合は 0 です。
+	Share float64 `json:"share,omitempty"`
+	// PeakMemoryBytes は Go ランタイムが確保したメモリのうちメモリ負荷のバッファ以外の最大値 (バイト) です。
+	PeakMemoryBytes int64 `json:"peak_memory_bytes"`
+	// ReservedCPU は計測・表示処理に予約した CPU (--overhead-cpu) です。予約しなかった場合は省略します。
+	ReservedCPU *int `json:"reserved_cpu,omitempty"`
+}
+
+// ThresholdSummary は --fail-under の1つの下限の判定結果です。
+type ThresholdSummary struct {
+	Threshold string `json:"threshold"`
+	// Value は目標に対する達成率 (割合) か、平均スループット (バイト/秒) です。
+	Value float64 `json:"value"`
+	// Failed は下限に届かなかったか、結果が記録されなかったかどうかです。
+	Failed bool `json:"failed"`
+}
+
+// SLOSummary は1つの目標 (SLO) の評価結果です。
+type SLOSummary struct {
+	SLO string `json:"slo"`
+	// Windows は評価した区間の数です。
+	Windows    int            `json:"windows"`
+	Violations []SLOViolation `json:"violations,omitempty"`
+	// Exceeded は違反が --slo-budget を超えたかどうかです。
+	Exceeded bool `json:"exceeded"`
+}
+
+// SLOViolation は目標を満たさなかった区間の終了時刻と、その区間の観測値です。
+// 観測値はレイテンシでは秒、達成率では目標に対する割合です。
+type SLOViolation struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// ProbeSummary は1つのカナリアプローブのレイテンシの分布です (秒)。
+type ProbeSummary struct {
+	Probe string  `json:"probe"`
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+	// IdleP99 は --baseline で計測した負荷をかける前の 99 パーセンタイルです。計測しなかった場合は 0 です。
+	IdleP99 float64 `json:"idle_p99,omitempty"`
+}
+
+// BaselineDelta は1つのシステム指標の、負荷をかける前 (ベースライン) と負荷中の平均値です。
+type BaselineDelta struct {
+	Metric   string  `json:"metric"`
+	Unit     string  `json:"unit,omitempty"`
+	Baseline float64 `json:"baseline"`
+	Loaded   float64 `json:"loaded"`
+	Delta    float64 `json:"delta"`
+}
+
+// PowerSummary は1つの電力ドメイン (CPU パッケージまたは DRAM) の平均電力と消費エネルギーです。
+type PowerSummary struct {
+	Domain string  `json:"domain"`
+	DRAM   bool    `json:"dram"`
+	Watts  float64 `json:"watts"`
+	Joules float64 `json:"joules"`
+}
+
+// Phase は負荷パターンの1つのステップの記録です。
+type Phase struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// StressorSummary は1つの負荷生成モジュールの目標値と実測値の要約です。
+type StressorSummary struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	// Target と Achieved は実行中の目標値・実測値の平均です。
+	Target   float64 `json:"target"`
+	Achieved float64 `json:"achieved"`
+	// MinAchieved は実測値の最小値です。
+	MinAchieved float64 `json:"min_achieved"`
+	// Deviation は目標値に対する実測値の平均の乖離（%）です。
+	Deviation float64  `json:"deviation"`
+	Degraded  bool     `json:"degraded"`
+	Issues    []string `json:"issues,omitempty"`
+	// Checks と CheckFailures は検証 (--cpu-verify など) を行った単位の数と、そのうち不一致だった数です。
+	Checks        int64 `json:"checks,omitempty"`
+	CheckFailures int64 `json:"check_failures,omitempty"`
+	// Workers はワーカーごとに OS が割り当てた CPU 時間です。計測できる負荷 (Linux の CPU 負荷) のみです。
+	Workers []WorkerSummary `json:"workers,omitempty"`
+}
+
+// WorkerSummary は負荷生成モジュールのワーカー1つの実行結果です。
+type WorkerSummary struct {
+	Worker int `json:"worker"`
+	// Target と Achieved はワーカーの目標使用率と実際の使用率 (1コアに対する比率) の平均です。
+	Target      float64 `json:"target"`
+	Achieved    float64 `json:"achieved"`
+	MinAchieved float64 `json:"min_achieved"`
+}
+
+// ReadSummary は --summary-json で書き出された Summary をファイルから読み込みます。
+func ReadSummary(path string) (*Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var summary Summary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("invalid summary %s: %v", path, err)
+	}
+	return &summary, nil
+}
+
+// HostOutcome はコーディネーターから見た1ホストの実行結果です。
+type HostOutcome struct {
+	Host string `json:"host"`
+	// ExitCode はホストでの stress-go の終了コードです。
+	ExitCode int `json:"exit_code"`
+	// Error はジョブを開始できなかった、またはホストとの通信が途絶えた場合のエラーです。
+	Error string `json:"error,omitempty"`
+	// Summary はホストでの結果の要約です。結果を回収できなかった場合は nil です。
+	Summary *Summary `json:"summary,omitempty"`
+}
+
+// Failed はホストでの実行が失敗したかどうかを返します。
+func (o HostOutcome) Failed() bool {
+	return o.Error != "" || o.ExitCode != 0
+}
+
+// Report はクラスター全体の負荷テストの結果です。
+type Report struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Hosts・Succeeded・Failed はホスト数と、そのうち成功・失敗したホスト数です。
+	Hosts     int `json:"hosts"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Totals は負荷生成モジュールごとの全ホストの合計です。
+	Totals []Total `json:"totals"`
+	// Stragglers はクラスターの中央値より目標の達成率が低かったホストです。
+	Stragglers []Straggler `json:"stragglers,omitempty"`
+	// Outcomes はホストごとの結果です。
+	Outcomes []HostOutcome `json:"outcomes"`
+}
+
+// Total は1つの負荷生成モジュールの全ホストの合計です。
+type Total struct {
+	Stressor string  `json:"stressor"`
+	Unit     string  `json:"unit"`
+	Hosts    int     `json:"hosts"`
+	Target   float64 `json:"target"`
+	Achieved float64 `json:"achieved"`
+	// Degraded は目標値に達しなかったホストの数です。
+	Degraded int `json:"degraded"`
+}
+
+// Straggler は1つの負荷生成モジュールで他のホストより目標の達成率が低かったホストです。
+type Straggler struct {
+	Host     string `json:"host"`
+	Stressor string `json:"stressor"`
+	// Ratio はホストの達成率 (実測値/目標値)、Median はクラスターの達成率の中央値です。
+	Ratio  float64 `json:"ratio"`
+	Median float64 `json:"median"`
+}
+
+// Aggregate はホストごとの結果をクラスター全体の Report にまとめます。
+func Aggregate(outcomes []HostOutcome) Report {
+	report := Report{Hosts: len(outcomes), Outcomes: outcomes}
+
+	totals := make(map[string]*Total)
+	var names []string
+	ratios := make(map[string]map[string]float64) // stressor -> host -> achieved/target
+	for _, o := range outcomes {
+		if o.Failed() {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+		if o.Summary == nil {
+			continue
+		}
+		if report.Start.IsZero() || o.Summary.Start.Before(report.Start) {
+			report.Start = o.Summary.Start
+		}
+		if o.Summary.End.After(report.End) {
+			report.End = o.Summary.End
+		}
+		for _, s := range o.Summary.Stressors {
+			t, ok := totals[s.Name]
+			if !ok {
+				t = &Total{Stressor: s.Name, Unit: s.Unit}
+				totals[s.Name] = t
+				names = append(names, s.Name)
+				ratios[s.Name] = make(map[string]float64)
+			}
+			t.Hosts++
+			t.Target += s.Target
+			t.Achieved += s.Achieved
+			if s.Degraded {
+				t.Degraded++
+			}
+			if s.Target > 0 {
+				ratios[s.Name][o.Host] = s.Achieved / s.Target
+			}
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		report.Totals = append(report.Totals, *totals[name])
+		report.Stragglers = append(report.Stragglers, stragglers(name, ratios[name])...)
+	}
+	return report
+}
+
+// stragglers returns the hosts whose ratio falls clearly below the median of all hosts.
+func stragglers(stressor string, ratios map[string]float64) []Straggler {
+	if len(ratios) < 2 {
+		return nil
+	}
+	values := make([]float64, 0, len(ratios))
+	for _, r := range ratios {
+		values = append(values, r)
+	}
+	sort.Float64s(values)
+	median := values[len(values)/2]
+	if len(values)%2 == 0 {
+		median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+	}
+
+	var result []Straggler
+	for host, r := range ratios {
+		if r < median*stragglerThreshold {
+			result = append(result, Straggler{Host: host, Stressor: stressor, Ratio: r, Median: median})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Ratio < result[j].Ratio })
+	return result
+}
@@ -0,0 +1,221 @@
+// Package console は端末への出力を調停します。
+// ログ行はステータス行 (進捗表示) の上にスクロールし、ステータス行は常に最下行に再描画されます。
+// ステータスは複数行 (ダッシュボード) にすることもできます。
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// plainStatusInterval is how often the status line is written as a regular line
+// when the output is not a terminal, where it cannot be repainted in place.
+const plainStatusInterval = 10 * time.Second
+
+// Console はログ行とステータス行の出力先です。複数のゴルーチンから同時に使用できます。
+type Console struct {
+	mu          sync.Mutex
+	w           io.Writer
+	errw        io.Writer
+	interactive bool
+	status      string
+	shown       int // width of the status line currently on the terminal
+	rows        int // number of lines of a multi-line status currently on the terminal
+	lastPlain   time.Time
+}
+
+// New は w に出力する Console を返します。w が端末の場合はステータス行をその場で再描画し、
+// それ以外 (ファイルやパイプ) の場合はステータス行を一定間隔で通常の行として出力します。
+//
+// 引数:
+//
+//	w    - ログ行とステータス行の出力先
+//	errw - エラー出力の出力先
+func New(w, errw io.Writer) *Console {
+	return &Console{w: w, errw: errw, interactive: isTerminal(w)}
+}
+
+// isTerminal reports whether w is a character device such as a terminal or console.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write は p をログ出力として書き込みます。ステータス行を表示中の場合は消去してから書き込み、
+// p が改行で終わる場合はその下にステータス行を再描画します。
+func (c *Console) Write(p []byte) (int, error) {
+	return c.write(c.w, p)
+}
+
+// write writes p to dst with the status line moved out of the way.
+func (c *Console) write(dst io.Writer, p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.erase()
+	n, err := dst.Write(p)
+	if bytes.HasSuffix(p, []byte("\n")) {
+		c.paint()
+	}
+	return n, err
+}
+
+// ErrorWriter はエラー出力に書き込む io.Writer を返します。子プロセスのエラー出力の転送などに使用します。
+// Write と同じく、ステータス行と混ざりません。
+func (c *Console) ErrorWriter() io.Writer {
+	return errorWriter{c}
+}
+
+// errorWriter writes to the error output of a Console.
+type errorWriter struct{ c *Console }
+
+func (w errorWriter) Write(p []byte) (int, error) {
+	return w.c.write(w.c.errw, p)
+}
+
+// Printf は format を i18n で現在の言語に翻訳し、書式化してログ出力として書き込みます。
+func (c *Console) Printf(format string, args ...any) {
+	fmt.Fprint(c, i18n.Sprintf(format, args...))
+}
+
+// Println は引数を空白で区切り、改行を付けてログ出力として書き込みます。
+// 文字列の引数は i18n で現在の言語に翻訳します。
+func (c *Console) Println(args ...any) {
+	for i, arg := range args {
+		if s, ok := arg.(string); ok {
+			args[i] = i18n.T(s)
+		}
+	}
+	fmt.Fprintln(c, args...)
+}
+
+// Eprintf は format を i18n で現在の言語に翻訳し、書式化してエラー出力に書き込みます。
+// エラー出力が同じ端末に表示される場合もステータス行と混ざりません。
+func (c *Console) Eprintf(format string, args ...any) {
+	c.write(c.errw, []byte(i18n.Sprintf(format, args...)))
+}
+
+// Interactive は出力先が端末で、ステータス行をその場で再描画するかどうかを返します。
+func (c *Console) Interactive() bool {
+	return c.interactive
+}
+
+// SetStatus はステータス行を line に置き換えます。line が改行を含む場合は複数行のステータスとして、
+// 各行を端末の幅に収まるように切り詰めて表示します。端末の幅を取得できないプラットフォームでは、
+// 各行を " | " でつないだ1行として表示します。
+func (c *Console) SetStatus(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = line
+	if c.interactive {
+		c.erase()
+		c.paint()
+		return
+	}
+	if time.Since(c.lastPlain) >= plainStatusInterval {
+		c.lastPlain = time.Now()
+		fmt.Fprintln(c.w, line)
+	}
+}
+
+// ClearStatus はステータス行を消去し、以降は表示しません。
+func (c *Console) ClearStatus() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.erase()
+	c.status = ""
+}
+
+// erase removes the status from the terminal, leaving the cursor at the start of its first line.
+func (c *Console) erase() {
+	if c.rows > 0 {
+		// Only terminals that report their width, and so take ANSI sequences,
+		// show a multi-line status: clear each line, moving up from the last
+		fmt.Fprint(c.w, "\r\x1b[K"+strings.Repeat("\x1b[A\x1b[K", c.rows-1))
+		c.rows = 0
+		return
+	}
+	if c.shown == 0 {
+		return
+	}
+	// Overwriting with spaces works on every terminal, unlike ANSI erase sequences
+	fmt.Fprintf(c.w, "\r%s\r", strings.Repeat(" ", c.shown))
+	c.shown = 0
+}
+
+// paint draws the status at the cursor, which must be at the start of a line.
+func (c *Console) paint() {
+	if !c.interactive || c.status == "" {
+		return
+	}
+	if !strings.Contains(c.status, "\n") {
+		fmt.Fprint(c.w, c.status)
+		c.shown = Width(c.status)
+		return
+	}
+	lines := strings.Split(c.status, "\n")
+	width := terminalWidth(c.w)
+	if width <= 0 {
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		line := strings.Join(lines, " | ")
+		fmt.Fprint(c.w, line)
+		c.shown = Width(line)
+		return
+	}
+	// A line that wraps would take more rows than erase moves up over
+	for i, line := range lines {
+		lines[i] = truncate(line, width-1)
+	}
+	fmt.Fprint(c.w, strings.Join(lines, "\n"))
+	c.rows = len(lines)
+}
+
+// truncate cuts s to at most width columns.
+func truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	used := 0
+	for i, r := range s {
+		if used += Width(string(r)); used > width {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// Width は s が端末上で占める桁数を返します。日本語のメッセージなどの東アジアの全角文字は 2 桁と数えます。
+//
+// 引数:
+//
+//	s - 表示する文字列
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+			r >= 0x2E80 && r <= 0xA4CF, // CJK radicals through Yi
+			r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+			r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+			r >= 0xFE30 && r <= 0xFE4F, // CJK compatibility forms
+			r >= 0xFF00 && r <= 0xFF60, // fullwidth forms
+			r >= 0xFFE0 && r <= 0xFFE6:
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || netbsd || dragonfly)
+
+package console
+
+import "io"
+
+// terminalWidth returns 0: the width of the terminal is not available on this
+// platform, which shows a multi-line status on a single line.
+func terminalWidth(w io.Writer) int {
+	return 0
+}
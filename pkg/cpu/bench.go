@@ -0,0 +1,63 @@
+package cpu
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// benchBatch is the number of iterations a benchmark worker runs between checks
+// of the deadline; about a millisecond of work.
+const benchBatch = 1 << 20
+
+// benchSink keeps the benchmark results alive so the loop is not optimized away.
+var benchSink atomic.Uint64
+
+// BenchResult は Benchmark の結果です。
+type BenchResult struct {
+	// Cores は使用したコア数です。
+	Cores int
+	// OpsPerSecond は全コア合計の1秒あたりの演算回数です。負荷生成に使う整数演算ループの1回を1演算と数えます。
+	OpsPerSecond float64
+}
+
+// Benchmark は d の間、cores 個のコアで整数演算ループを休みなく実行し、1秒あたりの演算回数を返します。
+// 同じループを使うため、異なるホストの結果を比較できます。
+//
+// 引数:
+//
+//	ctx   - 計測を中断するためのコンテキスト
+//	cores - 使用するコア数。0 の場合は使用可能なすべてのコア
+//	d     - 計測時間
+func Benchmark(ctx context.Context, cores int, d time.Duration) BenchResult {
+	if cores <= 0 {
+		cores = max(int(sysinfo.EffectiveCPUs()), 1)
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(cores))
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	var total atomic.Uint64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range cores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result, iterations uint64
+			for ctx.Err() == nil {
+				result = burn(result, benchBatch)
+				iterations += benchBatch
+			}
+			total.Add(iterations)
+			benchSink.Add(result)
+		}()
+	}
+	wg.Wait()
+	return BenchResult{Cores: cores, OpsPerSecond: float64(total.Load()) / time.Since(start).Seconds()}
+}
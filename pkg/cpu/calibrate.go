@@ -0,0 +1,185 @@
+package cpu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Calibration measurement sizes: enough samples for a stable median in well under
+// two seconds.
+const (
+	timerSamples = 1000
+	spinSamples  = 200
+	sleepSamples = 5
+)
+
+// sleepRequests are the idle times whose overshoot is measured, covering the range
+// the duty cycle sleeps for.
+var sleepRequests = []time.Duration{
+	1 * time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+}
+
+// Calibration は部分負荷のデューティ比制御の補正値です。ホストのカーネルや仮想化の種類によって
+// タイマーの精度やスリープの超過時間が大きく異なるため、Calibrate で計測した値で周期ごとのビジー時間と
+// 休止時間を補正します。ゼロ値の場合は補正しません。
+type Calibration struct {
+	// Host は計測したホスト名です。
+	Host string `json:"host"`
+	// Measured は計測した日時です。
+	Measured time.Time `json:"measured"`
+	// TimerResolution は時刻の読み取りで観測できる最小の時間差です。
+	TimerResolution time.Duration `json:"timer_resolution"`
+	// SpinBatch は負荷ループ1回分の所要時間です。ビジー時間はこの単位で判定するため、平均でその半分だけ超過します。
+	SpinBatch time.Duration `json:"spin_batch"`
+	// SleepOvershoot は休止が要求した時間を超過する時間の中央値です。
+	SleepOvershoot time.Duration `json:"sleep_overshoot"`
+}
+
+// Calibrate はこのホストのタイマーの精度、負荷ループのコスト、スリープの超過時間を計測して補正値を返します。
+// 計測は1コアで2秒ほどかかります。ctx が終了した場合はそこまでの計測値を返します。
+//
+// 引数:
+//
+//	ctx - 計測を中断するためのコンテキスト
+func Calibrate(ctx context.Context) Calibration {
+	host, _ := os.Hostname()
+	topology, _ := sysinfo.ReadTopology()
+	_, vector := vectorWorkload(topology)
+	w := worker{cpu: -1, vector: vector}
+
+	c := Calibration{Host: host, Measured: time.Now()}
+	c.TimerResolution = measureTimerResolution()
+
+	spins := make([]time.Duration, 0, spinSamples)
+	var result uint64
+	for range spinSamples {
+		start := time.Now()
+		result = w.work(result, spinBatch)
+		spins = append(spins, time.Since(start))
+	}
+	benchSink.Add(result)
+	c.SpinBatch = median(spins)
+
+	var overshoots []time.Duration
+	for _, request := range sleepRequests {
+		for range sleepSamples {
+			start := time.Now()
+			select {
+			case <-ctx.Done():
+				c.SleepOvershoot = median(overshoots)
+				return c
+			case <-time.After(request):
+			}
+			overshoots = append(overshoots, max(time.Since(start)-request, 0))
+		}
+	}
+	c.SleepOvershoot = median(overshoots)
+	return c
+}
+
+// measureTimerResolution returns the smallest step between two different readings
+// of the clock.
+func measureTimerResolution() time.Duration {
+	resolution := time.Duration(0)
+	for range timerSamples {
+		start := time.Now()
+		now := time.Now()
+		for now.Equal(start) {
+			now = time.Now()
+		}
+		if step := now.Sub(start); resolution == 0 || step < resolution {
+			resolution = step
+		}
+	}
+	return resolution
+}
+
+// median returns the middle value of samples, or 0 if there are none.
+func median(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// spinOverrun is how far a busy period overruns its target on average: the loop
+// notices the deadline only between batches and only to the clock's resolution.
+func (c Calibration) spinOverrun() time.Duration {
+	return (c.SpinBatch + c.TimerResolution) / 2
+}
+
+// IsZero は補正値が計測されていない (ゼロ値) かどうかを返します。
+func (c Calibration) IsZero() bool {
+	return c.Measured.IsZero()
+}
+
+// LoadCalibration は Save で書き出した補正値を読み込みます。
+func LoadCalibration(path string) (Calibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Calibration{}, fmt.Errorf("failed to read calibration: %v", err)
+	}
+	var c Calibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Calibration{}, fmt.Errorf("invalid calibration %s: %v", path, err)
+	}
+	if c.IsZero() || c.SpinBatch < 0 || c.SleepOvershoot < 0 || c.TimerResolution < 0 {
+		return Calibration{}, fmt.Errorf("invalid calibration %s: missing or negative values", path)
+	}
+	return c, nil
+}
+
+// Save は補正値を JSON ファイルとして書き出します。
+func (c Calibration) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode calibration: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write calibration: %v", err)
+	}
+	return nil
+}
+
+// MeasureDutyCycle は1コアに目標使用率 ratio の部分負荷を d の間かけ、実際に消費したCPU時間の割合を返します。
+// 補正値 c の効果を確かめるために使用します。
+//
+// 引数:
+//
+//	ctx   - 計測を中断するためのコンテキスト
+//	ratio - 目標使用率（0.0〜1.0）
+//	d     - 計測時間
+//	c     - 使用する補正値。ゼロ値の場合は補正しない
+func MeasureDutyCycle(ctx context.Context, ratio float64, d time.Duration, c Calibration) (float64, error) {
+	topology, _ := sysinfo.ReadTopology()
+	_, vector := vectorWorkload(topology)
+	w := worker{cpu: -1, vector: vector, timing: c}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	startCPU, err := processCPUTime()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	generateDutyCycleLoad(ctx, w, func() float64 { return ratio }, nil)
+	endCPU, err := processCPUTime()
+	if err != nil {
+		return 0, err
+	}
+	return float64(endCPU-startCPU) / float64(time.Since(start)), nil
+}
@@ -0,0 +1,396 @@
+package cpu
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/overhead"
+	"github.com/utkamioka/stress-go/pkg/supervise"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// workerRestartDelay is how long a worker stopped by KillWorker stays down.
+const workerRestartDelay = 2 * time.Second
+
+// Controller は実行中のCPU負荷を操作します。Start が返します。
+type Controller struct {
+	opts      Options
+	coreCount int
+	cancel    context.CancelFunc
+	done      chan struct{}
+	result    Result
+	panicked  *supervise.PanicError
+	// soak holds the CPU temperature for Options.SoakTemperature, or is nil.
+	soak *soak
+	// kills stops each worker's current run, for KillWorker.
+	kills []atomic.Pointer[context.CancelFunc]
+	// threads holds the OS thread ID of each worker once it runs, for the
+	// per-worker CPU accounting, or 0.
+	threads []atomic.Int64
+
+	override atomic.Uint64 // math.Float64bits of the ratio set by SetTarget, or NaN
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	achieved atomic.Uint64 // math.Float64bits of the last measured cores
+	// correction is the math.Float64bits of the factor applied to the busy time of
+	// a partial load, so that the CPU time the workers get meets the target even
+	// when other processes compete for their cores.
+	correction atomic.Uint64
+}
+
+// Stats は実行中のCPU負荷の状態です。
+type Stats struct {
+	// Cores は負荷をかけているコア数です。
+	Cores int
+	// Target は現在の目標使用コア数です。
+	Target float64
+	// Achieved は直近の測定で実際に使用されていたコア数です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// Start は opts に従ってCPU負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続きます。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "CPU"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+
+	// If the core count is 0, use all cores the process may run on. A cgroup CPU quota
+	// (as set for containers) counts only whole cores, so that no worker is throttled.
+	coreCount := opts.Cores
+	usable := sysinfo.EffectiveCPUs()
+	// The affinity is narrower than at startup when a CPU is reserved for bookkeeping
+	allowed, affinityErr := sysinfo.AllowedCPUs()
+	if affinityErr == nil && len(allowed) < int(usable) {
+		usable = float64(len(allowed))
+	}
+	if coreCount == 0 {
+		coreCount = max(int(usable), 1)
+	}
+	c := &Controller{
+		opts:      opts,
+		coreCount: coreCount,
+		done:      make(chan struct{}),
+		result:    Result{Cores: coreCount},
+		kills:     make([]atomic.Pointer[context.CancelFunc], coreCount),
+		threads:   make([]atomic.Int64, coreCount),
+	}
+	if opts.SoakTemperature > 0 {
+		if _, err := sysinfo.ReadCPUTemperature(); err != nil {
+			return nil, fmt.Errorf("thermal soak needs a CPU temperature sensor: %v", err)
+		}
+		c.soak = newSoak(opts.SoakTemperature, sysinfo.ReadCPUTemperature)
+	}
+	c.override.Store(math.Float64bits(math.NaN()))
+	c.scale.Store(math.Float64bits(1))
+	c.correction.Store(math.Float64bits(1))
+	recorder := opts.Recorder
+	if opts.Cores == 0 && affinityErr == nil && len(allowed) < runtime.NumCPU() && coreCount == len(allowed) {
+		recorder.Logf("CPU", "Using the %d of %d cores left by the CPU affinity", coreCount, runtime.NumCPU())
+	} else if opts.Cores == 0 && coreCount < runtime.NumCPU() {
+		recorder.Logf("CPU", "Using %d of %d cores within the cgroup CPU limit of %.2f cores", coreCount, runtime.NumCPU(), usable)
+	}
+	topology, _ := sysinfo.ReadTopology()
+	if topology.Heterogeneous() {
+		recorder.Logf("CPU", "CPU topology: %s", topology)
+	}
+	vectorName, vector := vectorWorkload(topology)
+	switch opts.Method {
+	case MethodBignum:
+		recorder.Logf("CPU", "CPU method: %d-bit modular exponentiation", cmp.Or(opts.BignumBits, DefaultBignumBits))
+	case MethodFloat, MethodCrypto, MethodPrime, MethodBranch:
+		recorder.Logf("CPU", "CPU method: %s", opts.Method)
+	case MethodCache:
+		recorder.Logf("CPU", "CPU method: cache, walking a %d MiB table", chaseTableSize()/(1024*1024))
+	case MethodAll:
+		recorder.Logf("CPU", "CPU method: all, rotating every %v", rotateInterval)
+	default:
+		if vector != nil {
+			recorder.Logf("CPU", "Vector workload: %s", vectorName)
+		}
+	}
+	kernels := newKernels(opts)
+	verify := newVerifier(opts.Verify, recorder)
+	if verify != nil {
+		recorder.Logf("CPU", "Verifying floating point results on every core")
+	}
+	if !opts.Calibration.IsZero() {
+		recorder.Logf("CPU", "Duty cycle calibrated on %s: sleep overshoot %v, spin batch %v",
+			opts.Calibration.Measured.Format(time.DateOnly), opts.Calibration.SleepOvershoot, opts.Calibration.SpinBatch)
+	}
+	pins := placement(topology, coreCount)
+	if pins != nil {
+		recorder.Logf("CPU", "Pinning workers to the fastest cores first: CPUs %v", pins)
+	}
+
+	startCPU, cpuErr := processCPUTime()
+	startWall := time.Now()
+
+	// A panic in any worker stops the others and is re-raised by Wait, after the
+	// GOMAXPROCS reset
+	ctx, c.cancel = context.WithCancel(ctx)
+	group, ctx := supervise.WithContext(ctx)
+	guard := startGuard(ctx, group, coreCount, opts)
+	targetCores := func() float64 { return float64(coreCount) * c.ratio() }
+	report := func(achieved float64) { c.achieved.Store(math.Float64bits(achieved)) }
+
+	if c.soak != nil {
+		group.Go(func() { c.soak.run(ctx, recorder) })
+	}
+
+	limit := func() float64 { return min(c.ratio(), guard.limit()) }
+	// A partial load is corrected by the CPU time its worker threads actually
+	// get; the thermal soak corrects its load by the temperature instead
+	feedback := (opts.Target != nil || opts.Load > 0) && sysinfo.ThreadAccounting
+	busy := limit
+	if feedback {
+		busy = func() float64 { return min(c.ratio()*math.Float64frombits(c.correction.Load()), guard.limit()) }
+	}
+	oldMaxProcs := -1
+	if opts.Target == nil && c.soak == nil && (opts.Load == 0 || opts.Load == 1) {
+		recorder.Logf("CPU", "Starting load generation on %d cores", coreCount)
+
+		// Set GOMAXPROCS to limit OS thread count
+		oldMaxProcs = runtime.GOMAXPROCS(coreCount)
+
+		// Start goroutine for each CPU core
+		for i := 0; i < coreCount; i++ {
+			w := worker{id: i, cpu: -1, kernel: kernels(uint64(i)), vector: vector, verify: verify, timing: opts.Calibration}
+			if pins != nil {
+				w.cpu = pins[i]
+			}
+			group.Go(func() {
+				c.runWorker(ctx, w, func(ctx context.Context, w worker) { generateCoreLoad(ctx, w, limit, recorder) })
+			})
+		}
+	} else {
+		if opts.Load > 0 {
+			recorder.Logf("CPU", "Starting load generation at %g%% on %d cores", opts.Load*100, coreCount)
+		} else {
+			recorder.Logf("CPU", "Starting variable load generation on %d cores", coreCount)
+		}
+
+		for i := 0; i < coreCount; i++ {
+			w := worker{id: i, cpu: -1, kernel: kernels(uint64(i)), vector: vector, verify: verify, timing: opts.Calibration}
+			if pins != nil {
+				w.cpu = pins[i]
+			}
+			group.Go(func() {
+				c.runWorker(ctx, w, func(ctx context.Context, w worker) { generateDutyCycleLoad(ctx, w, busy, recorder) })
+			})
+		}
+	}
+
+	// Measure achieved utilization alongside the workers
+	group.Go(func() { overhead.Run(func() { measureUsage(ctx, targetCores, guard, recorder, report) }) })
+	if sysinfo.ThreadAccounting {
+		group.Go(func() { overhead.Run(func() { c.measureWorkers(ctx, busy, limit, feedback) }) })
+	}
+
+	go func() {
+		defer close(c.done)
+		c.panicked = group.Join()
+		if oldMaxProcs > 0 {
+			runtime.GOMAXPROCS(oldMaxProcs)
+		}
+		if endCPU, err := processCPUTime(); cpuErr == nil && err == nil {
+			c.result.MeanCores = float64(endCPU-startCPU) / float64(time.Since(startWall))
+		}
+		recorder.Logf("CPU", "Load generation completed")
+	}()
+	return c, nil
+}
+
+// runWorker runs w until ctx is done, restarting it after workerRestartDelay
+// whenever KillWorker stops it.
+func (c *Controller) runWorker(ctx context.Context, w worker, run func(context.Context, worker)) {
+	recorder := c.opts.Recorder
+	if sysinfo.ThreadAccounting {
+		// Keep the worker on one thread so that the thread's CPU time is the worker's
+		runtime.LockOSThread()
+		c.threads[w.id].Store(int64(sysinfo.CurrentThread()))
+	}
+	for {
+		workerCtx, cancel := context.WithCancel(ctx)
+		c.kills[w.id].Store(&cancel)
+		run(workerCtx, w)
+		c.kills[w.id].Store(nil)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(workerRestartDelay):
+		}
+		recorder.Logf("CPU", "Restarting worker %d", w.id)
+		recorder.RecordRecovery("CPU", "kill")
+	}
+}
+
+// measureWorkers periodically records the CPU time the OS actually gave each
+// worker's thread against the busy ratio it was asked for, so that uneven
+// scheduling, such as cgroup throttling that hits some workers, is visible.
+// With feedback, the workers' mean CPU time is also compared with the target
+// ratio to correct the busy ratio asked of a partial load.
+func (c *Controller) measureWorkers(ctx context.Context, busy, limit func() float64, feedback bool) {
+	recorder := c.opts.Recorder
+	lastCPU := make([]time.Duration, c.coreCount)
+	lastThread := make([]int64, c.coreCount)
+	lastWall := time.Now()
+	targets := newTargetAverage(lastWall)
+	wanted := newTargetAverage(lastWall)
+
+	ticker := time.NewTicker(dutyCyclePeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			targets.add(busy(), now)
+			wanted.add(limit(), now)
+			if now.Sub(lastWall) < sampleInterval {
+				continue
+			}
+			target := targets.take()
+			var used float64
+			var measured int
+			for i := range c.threads {
+				tid := c.threads[i].Load()
+				if tid == 0 {
+					continue
+				}
+				cpuTime, err := sysinfo.ThreadCPUTime(int(tid))
+				if err != nil {
+					recorder.Logf("CPU", "Per-worker CPU accounting inactive: %v", err)
+					return
+				}
+				// A worker's first interval starts when its thread is first seen
+				if tid == lastThread[i] {
+					usage := float64(cpuTime-lastCPU[i]) / float64(now.Sub(lastWall))
+					recorder.RecordWorkerUsage("CPU", i, target, usage)
+					used += usage
+					measured++
+				}
+				lastCPU[i], lastThread[i] = cpuTime, tid
+			}
+			if want := wanted.take(); feedback && measured > 0 {
+				c.correct(want, used/float64(measured))
+			}
+			lastWall = now
+		}
+	}
+}
+
+// correct moves the busy time correction of a partial load part of the way
+// towards the factor that makes the workers' CPU time achieved meet want.
+func (c *Controller) correct(want, achieved float64) {
+	// A paused or full load has nothing to correct
+	if want <= 0 || want >= 1 {
+		return
+	}
+	correction := math.Float64frombits(c.correction.Load())
+	correction *= 1 + feedbackGain*(want/achieved-1)
+	// A worker cannot be busier than the whole period, so do not wind up beyond it
+	correction = min(max(correction, 1/maxCorrection), maxCorrection, 1/want)
+	c.correction.Store(math.Float64bits(correction))
+}
+
+// KillWorker は障害注入のために rng で選んだワーカー1つを停止し、その番号を返します。
+// 停止したワーカーは少し後に自動的に再起動します。すべてのワーカーが再起動を待っている場合はエラーを返します。
+func (c *Controller) KillWorker(rng *rand.Rand) (int, error) {
+	// Start from a random worker and kill the first one still running
+	first := rng.IntN(c.coreCount)
+	for i := range c.coreCount {
+		id := (first + i) % c.coreCount
+		if cancel := c.kills[id].Swap(nil); cancel != nil {
+			(*cancel)()
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no worker is running")
+}
+
+// SetTarget は各コアの目標使用率（0.0〜1.0）を変更します。
+// 以降は Options.Target の代わりにこの値を使用します。
+func (c *Controller) SetTarget(ratio float64) {
+	c.override.Store(math.Float64bits(clampRatio(ratio)))
+}
+
+// SetScale は Options で指定した目標使用率に掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で使用率を指定している場合は影響しません。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで負荷を止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	if c.panicked != nil {
+		panic(c.panicked)
+	}
+	return c.result, nil
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Cores:    c.coreCount,
+		Target:   float64(c.coreCount) * c.ratio(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Paused:   c.paused.Load(),
+	}
+}
+
+// ratio returns the busy ratio each core should currently run at, before the guard applies.
+func (c *Controller) ratio() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	if override := math.Float64frombits(c.override.Load()); !math.IsNaN(override) {
+		return override
+	}
+	scale := math.Float64frombits(c.scale.Load())
+	if c.opts.Target != nil {
+		return clampRatio(c.opts.Target() * scale)
+	}
+	if c.opts.Load > 0 {
+		return clampRatio(c.opts.Load * scale)
+	}
+	if c.soak != nil {
+		return clampRatio(c.soak.load() * scale)
+	}
+	return clampRatio(scale)
+}
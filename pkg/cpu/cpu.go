@@ -4,72 +4,367 @@ import (
 	"context"
 	"fmt"
 	"runtime"
-	"sync"
+	"slices"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
 )
 
-// GenerateLoad は指定されたCPUコア数で負荷を生成します。
+// sampleInterval is how often achieved CPU usage is measured.
+const sampleInterval = 5 * time.Second
+
+// dutyCyclePeriod is the length of one busy/idle cycle for partial load.
+const dutyCyclePeriod = 100 * time.Millisecond
+
+// feedbackGain is the share of the measured shortfall or excess of a partial
+// load that is corrected at each measurement; less than all of it, so that
+// noise in the measurement does not make the load oscillate.
+const feedbackGain = 0.5
+
+// maxCorrection bounds how far the feedback may raise or lower the busy time of
+// a partial load, as a factor of the target.
+const maxCorrection = 4.0
+
+// spinBatch is the number of iterations run between checks of the busy time in
+// a duty cycle.
+const spinBatch = 10000
+
+// Options はCPU負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "CPU" を使用します。
+	// 同じ種類の負荷を複数のジョブとして実行する場合に、ジョブを区別するために指定します。
+	Name string
+	// Cores は使用するCPUコア数です。0 の場合は全CPUコアを使用します。
+	// cgroup の CPU クォータや cpuset で制限されている場合は、その範囲に収まるコア数です。
+	Cores int
+	// Target は各コアの目標使用率（0.0〜1.0）を返す関数です。周期ごとに呼び出されます。
+	// nil の場合は常に100%の負荷をかけます。
+	Target func() float64
+	// Load は各コアの一定の目標使用率（0.0〜1.0）です。0 の場合は Target に従います。
+	// Target と同様に、各コアで稼働と休止を繰り返すデューティ比で負荷を調整します。
+	Load float64
+	// SoakTemperature は CPU を保持する温度（℃）です。指定すると CPU ダイの温度センサーを監視し、
+	// この温度を保つように各コアの使用率を調整します (サーマルソーク)。Target とは併用できません。
+	// 0 の場合は温度による調整を行いません。
+	SoakTemperature float64
+	// NoThermalFailsafe は組み込みのサーマルフェイルセーフを無効化します。
+	// 意図的な熱試験を行う場合にのみ指定してください。
+	NoThermalFailsafe bool
+	// MaxLoadAverage は1分間ロードアベレージの上限です。超えるとCPU負荷を下げ、
+	// 下回ると元に戻します。0 の場合は制限しません。
+	MaxLoadAverage float64
+	// MaxPercent は使用可能なCPU時間（cgroup のクォータと cpuset を考慮）に対する使用率の上限（%）です。
+	// 0 の場合は制限しません。
+	MaxPercent float64
+	// Budget は実行全体の負荷生成モジュールで共有する CPU の上限 (ミリコア) です (nil可)。
+	// 開始時に負荷をかけるコア数を予約し、予約できなかった分だけ負荷を下げます。
+	Budget *budget.Budget
+	// Method は演算方式です。空の場合は MethodInteger です。
+	Method Method
+	// BignumBits は MethodBignum のオペランドのビット数です。0 の場合は DefaultBignumBits です。
+	// 大きいほど1回の演算が長くなり、部分負荷の制御が粗くなります。
+	BignumBits int
+	// Verify は各コアで定期的に浮動小数点演算の結果を検証します。結果は Recorder に記録します。
+	Verify bool
+	// Calibration は部分負荷のデューティ比制御の補正値です。ゼロ値の場合は補正しません。
+	Calibration Calibration
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Result はCPU負荷の実行結果です。
+type Result struct {
+	// Cores は負荷をかけたコア数です。
+	Cores int
+	// MeanCores は実行中にプロセスが消費したCPU時間から求めた平均使用コア数です。
+	MeanCores float64
+}
+
+// GenerateLoad は opts に従ってCPU負荷を生成し、ctx が終了するまで実行します。
+// Target を指定した場合、各コアは一定周期ごとにビジー時間と休止時間を切り替え、その比率で使用率を制御します。
 //
 // 引数:
 //
-//	ctx       - 負荷生成の制御に使用するコンテキスト
-//	coreCount - 使用するCPUコア数。0の場合は全CPUコアを使用
-func GenerateLoad(ctx context.Context, coreCount int) {
-	// If coreCount is 0, use all available CPU cores
-	if coreCount == 0 {
-		coreCount = runtime.NumCPU()
-	}
-	
-	fmt.Printf("[CPU] Starting load generation on %d cores\n", coreCount)
-	
-	// Set GOMAXPROCS to limit OS thread count
-	oldMaxProcs := runtime.GOMAXPROCS(coreCount)
-	defer runtime.GOMAXPROCS(oldMaxProcs)
-
-	var wg sync.WaitGroup
-	
-	// Start goroutine for each CPU core
-	for i := 0; i < coreCount; i++ {
-		wg.Add(1)
-		go func(coreID int) {
-			defer wg.Done()
-			generateCoreLoad(ctx, coreID)
-		}(i)
-	}
-	
-	wg.Wait()
-	fmt.Printf("[CPU] Load generation completed\n")
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func GenerateLoad(ctx context.Context, opts Options) (Result, error) {
+	c, err := Start(ctx, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	return c.Wait()
+}
+
+// Validate はオプションの値を検証します。
+func (opts Options) Validate() error {
+	if opts.Cores < 0 {
+		return fmt.Errorf("invalid core count: %d", opts.Cores)
+	}
+	if opts.SoakTemperature < 0 {
+		return fmt.Errorf("invalid soak temperature: %v", opts.SoakTemperature)
+	}
+	if opts.SoakTemperature > 0 && opts.Target != nil {
+		return fmt.Errorf("soak temperature and target cannot be combined")
+	}
+	if opts.Load < 0 || opts.Load > 1 {
+		return fmt.Errorf("CPU load must be in range 0.0-1.0: %v", opts.Load)
+	}
+	if opts.Load > 0 && (opts.Target != nil || opts.SoakTemperature > 0) {
+		return fmt.Errorf("CPU load cannot be combined with a target or soak temperature")
+	}
+	if opts.MaxPercent < 0 || opts.MaxPercent > 100 {
+		return fmt.Errorf("CPU limit must be in range 0-100: %v", opts.MaxPercent)
+	}
+	if opts.BignumBits != 0 && (opts.BignumBits < MinBignumBits || opts.BignumBits > MaxBignumBits) {
+		return fmt.Errorf("bignum operand size must be in range %d-%d bits: %d", MinBignumBits, MaxBignumBits, opts.BignumBits)
+	}
+	return opts.Method.Validate()
+}
+
+// worker describes one load generating goroutine.
+type worker struct {
+	id int
+	// cpu is the CPU the worker is pinned to, or -1 to leave placement to the scheduler.
+	cpu int
+	// kernel is the loop of the selected method, or nil for the integer loop.
+	kernel func(result, iterations uint64) uint64
+	// vector is the SIMD loop run alongside the integer loop, or nil if the
+	// architecture has none.
+	vector func(iterations uint64)
+	// verify checks the core's floating point results between batches, or is nil.
+	verify *verifier
+	// timing corrects the busy and idle times of partial load for the host.
+	timing Calibration
+}
+
+// pin locks the worker's goroutine to its thread and restricts the thread to
+// its CPU, if it has one. The thread is discarded when the goroutine exits, so
+// the affinity does not leak to other goroutines.
+func (w worker) pin(recorder *metrics.Recorder) {
+	if w.cpu < 0 {
+		return
+	}
+	runtime.LockOSThread()
+	if err := sysinfo.PinThread(w.cpu); err != nil {
+		recorder.Logf("CPU", "Cannot pin worker %d to CPU %d: %v", w.id, w.cpu, err)
+	}
+}
+
+// work runs iterations of the integer loop and, where the architecture has one,
+// a quarter as many iterations of the SIMD loop, so that the vector and FP units
+// are loaded along with the integer pipelines. Another method runs its own loop
+// for about as long instead.
+func (w worker) work(result, iterations uint64) uint64 {
+	if w.kernel != nil {
+		return w.kernel(result, iterations)
+	}
+	result = burn(result, iterations)
+	if w.vector != nil {
+		w.vector(iterations / 4)
+	}
+	return result
+}
+
+// placement returns the CPU to pin each of workers to, or nil to leave placement
+// to the scheduler. Workers are only pinned on heterogeneous CPUs (big.LITTLE or
+// hybrid cores) when there are fewer of them than usable CPUs: the scheduler
+// would otherwise move them between fast and slow cores, under-stressing the
+// fast ones. The fastest allowed CPUs are used first.
+func placement(t sysinfo.Topology, workers int) []int {
+	if !t.Heterogeneous() {
+		return nil
+	}
+	allowed, err := sysinfo.AllowedCPUs()
+	if err != nil || workers >= len(allowed) {
+		return nil
+	}
+	var cpus []int
+	for _, cpu := range t.CPUs() {
+		if slices.Contains(allowed, cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	if len(cpus) < workers {
+		return nil
+	}
+	return cpus[:workers]
+}
+
+// generateDutyCycleLoad alternates busy spinning and sleeping so that the busy share
+// of each period matches the target ratio. The worker's calibration shortens both
+// by the time they are expected to overrun on this host.
+func generateDutyCycleLoad(ctx context.Context, w worker, target func() float64, recorder *metrics.Recorder) {
+	w.pin(recorder)
+	var result uint64
+	var failed bool
+	for {
+		busy := time.Duration(float64(dutyCyclePeriod) * clampRatio(target()))
+		start := time.Now()
+		var batches int64
+		for time.Since(start) < busy-w.timing.spinOverrun() {
+			result = w.work(result, spinBatch)
+			batches++
+		}
+		if busy > 0 {
+			recorder.AddCount("CPU", "iterations", batches*spinBatch)
+			w.verify.check(w, &failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			// Use result to prevent optimization
+			if result == 0 {
+				recorder.Logf("CPU", "Final result: %d", result)
+			}
+			return
+		case <-time.After(dutyCyclePeriod - busy - w.timing.SleepOvershoot):
+		}
+	}
+}
+
+// clampRatio limits a utilization ratio to the range 0.0-1.0.
+func clampRatio(ratio float64) float64 {
+	return min(max(ratio, 0), 1)
+}
+
+// measureUsage periodically records the number of cores actually kept busy by the process.
+// targetCores returns the number of cores that should be busy at the time of sampling.
+// Shortfalls caused by the guard are reported by the guard itself.
+// Each measurement is also passed to report.
+func measureUsage(ctx context.Context, targetCores func() float64, guard *loadGuard, recorder *metrics.Recorder, report func(float64)) {
+	lastCPU, err := processCPUTime()
+	if err != nil {
+		recorder.Logf("CPU", "Error: %v", err)
+		return
+	}
+	lastWall := time.Now()
+	targets := newTargetAverage(lastWall)
+
+	ticker := time.NewTicker(dutyCyclePeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case nowWall := <-ticker.C:
+			// Average the target over the interval so that variable loads compare fairly
+			targets.add(targetCores(), nowWall)
+			if nowWall.Sub(lastWall) < sampleInterval {
+				continue
+			}
+			nowCPU, err := processCPUTime()
+			if err != nil {
+				recorder.Logf("CPU", "Error: %v", err)
+				continue
+			}
+
+			achieved := float64(nowCPU-lastCPU) / float64(nowWall.Sub(lastWall))
+			target := targets.take()
+			recorder.Record("CPU", metrics.UnitCores, target, achieved)
+			report(achieved)
+			if achieved < target*0.9 && guard.limit() == 1 {
+				recorder.Flag("CPU", "CPU time below target (CPU quota or throttling)")
+			}
+
+			lastCPU, lastWall = nowCPU, nowWall
+		}
+	}
+}
+
+// targetAverage averages a target over time, sampled more often than the CPU
+// time is measured, so that a target that changes within a sample interval,
+// such as a paused load, is compared with the CPU time of the same interval.
+type targetAverage struct {
+	sum      float64
+	duration time.Duration
+	last     time.Time
+}
+
+func newTargetAverage(start time.Time) *targetAverage {
+	return &targetAverage{last: start}
+}
+
+// add counts value as the target from the previous sample until now.
+func (a *targetAverage) add(value float64, now time.Time) {
+	d := now.Sub(a.last)
+	a.sum += value * float64(d)
+	a.duration += d
+	a.last = now
+}
+
+// take returns the average since the previous take and starts a new one.
+func (a *targetAverage) take() float64 {
+	if a.duration <= 0 {
+		return 0
+	}
+	average := a.sum / float64(a.duration)
+	a.sum, a.duration = 0, 0
+	return average
 }
 
 // generateCoreLoad generates load on a single CPU core.
-func generateCoreLoad(ctx context.Context, coreID int) {
-	fmt.Printf("[CPU] Starting load generation on core %d\n", coreID)
-	
+// limit returns the allowed busy ratio, which is lowered when the environment requires
+// backing off or the load is throttled or paused through the controller.
+func generateCoreLoad(ctx context.Context, w worker, limit func() float64, recorder *metrics.Recorder) {
+	recorder.Logf("CPU", "Starting load generation on core %d", w.id)
+	w.pin(recorder)
+
 	// Execute maximum CPU-intensive calculations
 	var result uint64
+	var failed bool
 	checkInterval := uint64(50000000) // Check context every 50M iterations
-	
+
 	for {
-		// Pure integer operations for maximum CPU utilization
-		for i := uint64(0); i < checkInterval; i++ {
-			// Mix of operations to maximize CPU usage
-			result = result*1103515245 + 12345 // Linear congruential generator
-			result ^= result >> 21
-			result ^= result << 35
-			result ^= result >> 4
-			result += i * 31
+		if ratio := limit(); ratio <= 0 {
+			// Paused - stay idle until the load is resumed
+			select {
+			case <-ctx.Done():
+			case <-time.After(dutyCyclePeriod):
+			}
+		} else {
+			start := time.Now()
+			result = w.work(result, checkInterval)
+			recorder.AddCount("CPU", "iterations", int64(checkInterval))
+			w.verify.check(w, &failed)
+
+			// Idle proportionally to the busy time when the load is limited
+			if ratio := limit(); ratio > 0 && ratio < 1 {
+				idle := time.Duration(float64(time.Since(start))*(1-ratio)/ratio) - w.timing.SleepOvershoot
+				select {
+				case <-ctx.Done():
+				case <-time.After(idle):
+				}
+			}
 		}
-		
+
 		// Check context only after many iterations
 		select {
 		case <-ctx.Done():
-			fmt.Printf("[CPU] Stopping load generation on core %d\n", coreID)
+			recorder.Logf("CPU", "Stopping load generation on core %d", w.id)
 			// Use result to prevent optimization
 			if result == 0 {
-				fmt.Printf("[CPU] Final result: %d\n", result)
+				recorder.Logf("CPU", "Final result: %d", result)
 			}
 			return
 		default:
 			// Continue without any pause
 		}
 	}
-}
\ No newline at end of file
+}
+
+// burn executes the given number of iterations of pure integer operations
+// for maximum CPU utilization and returns the accumulated result.
+func burn(result uint64, iterations uint64) uint64 {
+	for i := uint64(0); i < iterations; i++ {
+		// Mix of operations to maximize CPU usage
+		result = result*1103515245 + 12345 // Linear congruential generator
+		result ^= result >> 21
+		result ^= result << 35
+		result ^= result >> 4
+		result += i * 31
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+package cpu
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/supervise"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Thermal failsafe parameters. Load is reduced linearly once the hottest sensor is
+// within thermalBackoffStart degrees of its critical trip point, down to
+// thermalMinimumRatio at thermalBackoffFull degrees.
+const (
+	thermalBackoffStart  = 15.0
+	thermalBackoffFull   = 5.0
+	thermalMinimumRatio  = 0.1
+	thermalCheckInterval = 1 * time.Second
+)
+
+// Load average ceiling parameters. The 1-minute load average reacts slowly, so the
+// busy ratio is adjusted in small multiplicative steps.
+const (
+	loadAverageCheckInterval = 2 * time.Second
+	loadAverageDecrease      = 0.8
+	loadAverageIncrease      = 1.1
+	loadAverageMinimumRatio  = 0.05
+	loadAverageHysteresis    = 0.9
+)
+
+// loadGuard publishes the maximum busy ratio allowed by the environment. Each source
+// (hard cap, thermal failsafe, load average ceiling) maintains its own ceiling
+// and the lowest one applies.
+type loadGuard struct {
+	hardCap     float64
+	thermal     atomic.Uint64 // math.Float64bits of the ratio
+	loadAverage atomic.Uint64 // math.Float64bits of the ratio
+}
+
+// startGuard starts the monitors enabled in opts in group until ctx is done.
+// It returns nil when no monitor is active.
+// coreCount is the number of busy workers the caller runs.
+func startGuard(ctx context.Context, group *supervise.Group, coreCount int, opts Options) *loadGuard {
+	recorder := opts.Recorder
+	g := &loadGuard{hardCap: 1}
+	g.thermal.Store(math.Float64bits(1))
+	g.loadAverage.Store(math.Float64bits(1))
+	active := false
+
+	if limit := opts.MaxPercent; limit > 0 {
+		allowedCores := limit / 100 * sysinfo.EffectiveCPUs()
+		if ratio := allowedCores / float64(coreCount); ratio < 1 {
+			g.hardCap = ratio
+			recorder.Logf("CPU", "Load capped at %.0f%% of usable CPU (%.2f cores)", limit, allowedCores)
+			recorder.Flag("CPU", i18n.Sprintf("load capped by hard CPU limit (%.0f%%)", limit))
+			active = true
+		}
+	}
+	if opts.Budget != nil {
+		wanted := int64(coreCount) * 1000
+		granted := opts.Budget.Reserve(wanted)
+		group.Go(func() {
+			<-ctx.Done()
+			opts.Budget.Release(granted)
+		})
+		if granted < wanted {
+			limit := float64(opts.Budget.Limit()) / 1000
+			g.hardCap = min(g.hardCap, float64(granted)/float64(wanted))
+			recorder.Logf("CPU", "Load capped at %.2f cores, the rest of the %.2f cores shared by all CPU loads", float64(granted)/1000, limit)
+			recorder.Flag("CPU", i18n.Sprintf("load capped by hard CPU limit (%.2f cores)", limit))
+			active = true
+		}
+	}
+
+	if !opts.NoThermalFailsafe {
+		if _, err := sysinfo.ReadThermalHeadroom(); err != nil {
+			recorder.Logf("CPU", "Thermal failsafe inactive: %v", err)
+		} else {
+			group.Go(func() { g.watchThermal(ctx, recorder) })
+			active = true
+		}
+	}
+
+	if limit := opts.MaxLoadAverage; limit > 0 {
+		if _, err := sysinfo.Read(); err != nil {
+			recorder.Logf("CPU", "Load average ceiling inactive: %v", err)
+		} else {
+			group.Go(func() { g.watchLoadAverage(ctx, limit, recorder) })
+			active = true
+		}
+	}
+
+	if !active {
+		return nil
+	}
+	return g
+}
+
+// watchThermal updates the thermal ceiling from the thermal headroom.
+func (g *loadGuard) watchThermal(ctx context.Context, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(thermalCheckInterval)
+	defer ticker.Stop()
+
+	backingOff := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			headroom, err := sysinfo.ReadThermalHeadroom()
+			if err != nil {
+				continue
+			}
+
+			ratio := 1.0
+			if headroom < thermalBackoffStart {
+				ratio = (headroom - thermalBackoffFull) / (thermalBackoffStart - thermalBackoffFull)
+				ratio = max(ratio, thermalMinimumRatio)
+			}
+			g.thermal.Store(math.Float64bits(ratio))
+
+			if ratio < 1 && !backingOff {
+				recorder.Logf("CPU", "Thermal failsafe: %.1f°C below critical, reducing load to %.0f%%", headroom, ratio*100)
+				recorder.Flag("CPU", "load reduced by thermal failsafe near critical temperature")
+				backingOff = true
+			} else if ratio == 1 && backingOff {
+				recorder.Logf("CPU", "Thermal failsafe: temperature recovered, restoring full load")
+				backingOff = false
+			}
+		}
+	}
+}
+
+// watchLoadAverage lowers the load average ceiling while the 1-minute load average
+// exceeds limit and raises it again once the load average has dropped below it.
+func (g *loadGuard) watchLoadAverage(ctx context.Context, limit float64, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(loadAverageCheckInterval)
+	defer ticker.Stop()
+
+	ratio := 1.0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshot, err := sysinfo.Read()
+			if err != nil {
+				continue
+			}
+
+			previous := ratio
+			if snapshot.LoadAverage > limit {
+				ratio = max(ratio*loadAverageDecrease, loadAverageMinimumRatio)
+			} else if snapshot.LoadAverage < limit*loadAverageHysteresis {
+				ratio = min(ratio*loadAverageIncrease, 1)
+			}
+			g.loadAverage.Store(math.Float64bits(ratio))
+
+			if ratio < 1 && previous == 1 {
+				recorder.Logf("CPU", "Load average %.2f exceeds %.2f, reducing load", snapshot.LoadAverage, limit)
+				recorder.Flag("CPU", i18n.Sprintf("load reduced to keep load average at or below %.2f", limit))
+			} else if ratio == 1 && previous < 1 {
+				recorder.Logf("CPU", "Load average %.2f within limit, restoring full load", snapshot.LoadAverage)
+			}
+		}
+	}
+}
+
+// limit returns the maximum busy ratio currently allowed. A nil guard allows full load.
+func (g *loadGuard) limit() float64 {
+	if g == nil {
+		return 1
+	}
+	return min(g.hardCap, math.Float64frombits(g.thermal.Load()), math.Float64frombits(g.loadAverage.Load()))
+}
@@ -0,0 +1,232 @@
+package cpu
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// The costs below are the number of integer loop iterations that one unit of
+// each kernel takes about as long as, measured on x86-64, so that batches of
+// every method last alike and the duty cycle stays accurate.
+const (
+	matrixCost = 12000
+	cryptoCost = 2700
+	sieveCost  = 46000
+	branchCost = 5500
+	chaseCost  = 10000
+)
+
+// matrixSize is the order of the matrices of MethodFloat; three of them fill
+// 6 KB, well within L1, so that the FPU rather than memory sets the pace.
+const matrixSize = 16
+
+// matrix multiplies two matrices of float64 and feeds the normalized product
+// back as the next left operand, which keeps the values away from overflow and
+// denormals.
+type matrix struct {
+	a, b, c [matrixSize * matrixSize]float64
+}
+
+func newMatrix(seed uint64) *matrix {
+	rng := rand.New(rand.NewPCG(seed, matrixSize))
+	m := &matrix{}
+	for i := range m.a {
+		m.a[i] = rng.Float64() + 0.5
+		m.b[i] = rng.Float64() + 0.5
+	}
+	return m
+}
+
+func (m *matrix) run(result, iterations uint64) uint64 {
+	for range max(iterations/matrixCost, 1) {
+		largest := 0.0
+		for i := range matrixSize {
+			for j := range matrixSize {
+				var sum float64
+				for k := range matrixSize {
+					sum += m.a[i*matrixSize+k] * m.b[k*matrixSize+j]
+				}
+				m.c[i*matrixSize+j] = sum
+				largest = max(largest, math.Abs(sum))
+			}
+		}
+		for i := range m.c {
+			m.a[i] = m.c[i]/largest + 0.5
+		}
+		result ^= math.Float64bits(m.c[0])
+	}
+	return result
+}
+
+// cryptoBlock is how much MethodCrypto encrypts and hashes at a time.
+const cryptoBlock = 1024
+
+// hasher encrypts a block with AES-128 in CTR mode and hashes the ciphertext
+// with SHA-256, using the AES and SHA instructions where the CPU has them.
+type hasher struct {
+	stream cipher.Stream
+	buffer []byte
+}
+
+func newHasher(seed uint64) *hasher {
+	var key [16]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		// A 16-byte key is always valid
+		panic(err)
+	}
+	return &hasher{stream: cipher.NewCTR(block, make([]byte, aes.BlockSize)), buffer: make([]byte, cryptoBlock)}
+}
+
+func (h *hasher) run(result, iterations uint64) uint64 {
+	for range max(iterations/cryptoCost, 1) {
+		h.stream.XORKeyStream(h.buffer, h.buffer)
+		sum := sha256.Sum256(h.buffer)
+		result ^= binary.LittleEndian.Uint64(sum[:])
+	}
+	return result
+}
+
+// sieveLimit is the range of MethodPrime's sieve; its 1 KB bitmap stays in L1.
+const sieveLimit = 8192
+
+// sieve counts the primes below sieveLimit with the sieve of Eratosthenes.
+type sieve struct {
+	composite [sieveLimit / 64]uint64
+}
+
+func (s *sieve) run(result, iterations uint64) uint64 {
+	for range max(iterations/sieveCost, 1) {
+		clear(s.composite[:])
+		count := uint64(0)
+		for n := uint64(2); n < sieveLimit; n++ {
+			if s.composite[n/64]&(1<<(n%64)) != 0 {
+				continue
+			}
+			count++
+			for m := n * n; m < sieveLimit; m += n {
+				s.composite[m/64] |= 1 << (m % 64)
+			}
+		}
+		result = result*31 + count
+	}
+	return result
+}
+
+// branchUnit is the number of random branches of one unit of MethodBranch.
+const branchUnit = 256
+
+// branches takes branches that depend on random bits, which the branch
+// predictor cannot learn, so that the pipeline is flushed about every other one.
+func branches(result, iterations uint64) uint64 {
+	x := result | 1
+	for range max(iterations/branchCost, 1) {
+		for range branchUnit {
+			// xorshift64
+			x ^= x << 13
+			x ^= x >> 7
+			x ^= x << 17
+			switch x & 7 {
+			case 0:
+				result += x
+			case 1:
+				result ^= x >> 3
+			case 2:
+				result -= x << 1
+			case 3:
+				result = result*3 + 1
+			default:
+				if x&8 != 0 {
+					result++
+				} else {
+					result--
+				}
+			}
+		}
+	}
+	return result
+}
+
+// defaultChaseSize is the size of MethodCache's table when the size of the
+// last level cache is unknown.
+const defaultChaseSize = 64 * 1024 * 1024
+
+// maxChaseSize bounds MethodCache's table on CPUs with a very large last level
+// cache, where twice its size would take a noticeable share of the memory and
+// a long time to shuffle; a table this large misses most of any cache anyway.
+const maxChaseSize = 256 * 1024 * 1024
+
+// chaseSteps is the number of dependent loads of one unit of MethodCache.
+const chaseSteps = 16
+
+// chaseTableSize returns the size of MethodCache's table in bytes, twice the
+// size of the last level cache up to maxChaseSize, so that following it misses
+// every cache level on almost every step.
+func chaseTableSize() int64 {
+	if caches, err := sysinfo.ReadCaches(); err == nil {
+		if llc, ok := caches.LastLevel(); ok && llc.Size > 0 {
+			return min(2*llc.Size, maxChaseSize)
+		}
+	}
+	return defaultChaseSize
+}
+
+// newChaseTable returns a random cyclic permutation of chaseTableSize bytes.
+// The workers only read it and share it.
+func newChaseTable() []uint32 {
+	table := make([]uint32, chaseTableSize()/4)
+	for i := range table {
+		table[i] = uint32(i)
+	}
+	// Sattolo's algorithm gives a single cycle through every entry
+	for i := len(table) - 1; i > 0; i-- {
+		j := rand.IntN(i)
+		table[i], table[j] = table[j], table[i]
+	}
+	return table
+}
+
+// chase walks the table from a position of its own.
+type chase struct {
+	table    []uint32
+	position uint32
+}
+
+func (c *chase) run(result, iterations uint64) uint64 {
+	p := c.position
+	for range max(iterations/chaseCost, 1) {
+		for range chaseSteps {
+			p = c.table[p]
+		}
+	}
+	c.position = p
+	return result ^ uint64(p)
+}
+
+// rotateInterval is how long each method runs in MethodAll before the worker
+// moves on to the next.
+const rotateInterval = 2 * time.Second
+
+// rotation runs the kernels of every method in turn. Workers start at
+// different methods so that all of them run at once on enough cores.
+type rotation struct {
+	kernels []func(result, iterations uint64) uint64
+	current int
+	since   time.Time
+}
+
+func (r *rotation) run(result, iterations uint64) uint64 {
+	if time.Since(r.since) >= rotateInterval {
+		r.current = (r.current + 1) % len(r.kernels)
+		r.since = time.Now()
+	}
+	return r.kernels[r.current](result, iterations)
+}
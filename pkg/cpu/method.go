@@ -0,0 +1,157 @@
+package cpu
+
+import (
+	"cmp"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Method は CPU 負荷の演算方式です。
+type Method string
+
+const (
+	// MethodInteger は整数の乗算・加算・シフト・排他的論理和のループです。ARM64 では SIMD のループも並行して実行します。
+	MethodInteger Method = "integer"
+	// MethodBignum は多倍長整数のべき剰余 (RSA の秘密鍵演算と同じ形) を繰り返します。
+	// 鍵生成や署名、ブロックチェーンの検証に近い負荷で、整数ループとは消費電力や IPC の傾向が異なります。
+	MethodBignum Method = "bignum"
+	// MethodFloat は倍精度浮動小数点数の行列積を繰り返し、FPU と SIMD ユニットに負荷をかけます。
+	MethodFloat Method = "float"
+	// MethodCrypto は AES-128-CTR による暗号化と SHA-256 のハッシュを繰り返します。CPU の暗号命令を使用します。
+	MethodCrypto Method = "crypto"
+	// MethodPrime はエラトステネスのふるいで素数を数え続けます。L1 キャッシュに収まるメモリアクセスと整数演算の負荷です。
+	MethodPrime Method = "prime"
+	// MethodBranch は乱数に依存する予測できない分岐を繰り返し、分岐予測の失敗とパイプラインのフラッシュを起こします。
+	MethodBranch Method = "branch"
+	// MethodCache は最終レベルキャッシュの2倍の大きさの配列をランダムにたどり、キャッシュミスとメモリの待ち時間を発生させます。
+	MethodCache Method = "cache"
+	// MethodAll はワーカーごとに上記のすべての演算方式を一定時間ずつ順に切り替えて実行します。
+	MethodAll Method = "all"
+)
+
+// CPUMethods は選択できるすべての演算方式です。
+var CPUMethods = []Method{MethodInteger, MethodBignum, MethodFloat, MethodCrypto, MethodPrime, MethodBranch, MethodCache, MethodAll}
+
+// DefaultBignumBits は Options.BignumBits が 0 の場合のオペランドのビット数です。
+const DefaultBignumBits = 2048
+
+// MinBignumBits と MaxBignumBits は Options.BignumBits に指定できる範囲です。
+const (
+	MinBignumBits = 64
+	MaxBignumBits = 16384
+)
+
+// Validate は演算方式が有効かどうかを検証します。空の場合は MethodInteger とみなします。
+func (m Method) Validate() error {
+	if m != "" && !slices.Contains(CPUMethods, m) {
+		return fmt.Errorf("unknown CPU method %q (integer, bignum, float, crypto, prime, branch, cache or all)", m)
+	}
+	return nil
+}
+
+// newKernels returns a function that makes the loop each worker runs for
+// opts.Method, or nil for the integer loop, which runs with the vector loop in
+// worker.work. State shared by the workers of a load, such as the table of
+// MethodCache, is made once here and freed with the load.
+func newKernels(opts Options) func(seed uint64) func(result, iterations uint64) uint64 {
+	var table []uint32
+	if opts.Method == MethodCache || opts.Method == MethodAll {
+		table = newChaseTable()
+	}
+	kernel := func(method Method, seed uint64) func(result, iterations uint64) uint64 {
+		switch method {
+		case MethodBignum:
+			return newBignum(cmp.Or(opts.BignumBits, DefaultBignumBits), seed).run
+		case MethodFloat:
+			return newMatrix(seed).run
+		case MethodCrypto:
+			return newHasher(seed).run
+		case MethodPrime:
+			return (&sieve{}).run
+		case MethodBranch:
+			return branches
+		case MethodCache:
+			return (&chase{table: table, position: uint32(seed % uint64(len(table)))}).run
+		}
+		return nil
+	}
+	return func(seed uint64) func(result, iterations uint64) uint64 {
+		if opts.Method != MethodAll {
+			return kernel(opts.Method, seed)
+		}
+		r := &rotation{since: time.Now()}
+		for _, method := range CPUMethods {
+			if method == MethodAll {
+				continue
+			}
+			k := kernel(method, seed)
+			if k == nil {
+				// The integer loop runs without the vector loop here
+				k = burn
+			}
+			r.kernels = append(r.kernels, k)
+		}
+		r.current = int(seed % uint64(len(r.kernels)))
+		return r.run
+	}
+}
+
+// bignum repeats a modular exponentiation with an odd modulus, exponent and
+// base of the same size, which math/big computes with Montgomery multiplication
+// as RSA and Diffie-Hellman implementations do.
+type bignum struct {
+	base, exponent, modulus, out *big.Int
+	// cost is the number of integer loop iterations that one exponentiation
+	// takes about as long as, so that batches of both last alike
+	cost uint64
+}
+
+// newBignum draws the operands of size bits from a generator seeded with seed.
+func newBignum(size int, seed uint64) *bignum {
+	rng := rand.New(rand.NewPCG(seed, uint64(size)))
+	random := func() *big.Int {
+		words := make([]big.Word, (size+bits.UintSize-1)/bits.UintSize)
+		for i := range words {
+			words[i] = big.Word(rng.Uint64())
+		}
+		// Exactly size bits, with the top bit set
+		n := new(big.Int).SetBits(words)
+		n.Rsh(n, uint(len(words)*bits.UintSize-size))
+		return n.SetBit(n, size-1, 1)
+	}
+	modulus := random()
+	modulus.SetBit(modulus, 0, 1)
+	base := random()
+	base.Mod(base, modulus)
+	// Multiplying w-word numbers takes about w² steps and an exponentiation
+	// w·64 of them; the w² term covers the overhead that dominates small sizes
+	w := uint64((size + 63) / 64)
+	return &bignum{
+		base:     base,
+		exponent: random(),
+		modulus:  modulus,
+		out:      new(big.Int),
+		cost:     w * w * (30*w + 400),
+	}
+}
+
+// run performs about iterations integer loop iterations' worth of
+// exponentiations, at least one, feeding each result into the next base.
+func (b *bignum) run(result, iterations uint64) uint64 {
+	for range max(iterations/b.cost, 1) {
+		b.out.Exp(b.base, b.exponent, b.modulus)
+		b.base, b.out = b.out, b.base
+		if b.base.BitLen() < 2 {
+			// 0 and 1 are fixed points that would end the work
+			b.base.SetInt64(3)
+		}
+		if words := b.base.Bits(); len(words) > 0 {
+			result ^= uint64(words[0])
+		}
+	}
+	return result
+}
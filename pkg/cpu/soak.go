@@ -0,0 +1,103 @@
+package cpu
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Thermal soak parameters. The busy ratio follows a PI controller on the CPU
+// temperature, in velocity form so that the ratio never winds up beyond 0-1:
+// each interval it moves by soakGainP per degree the error changed and by
+// soakGainI per degree of remaining error.
+const (
+	soakInterval = 1 * time.Second
+	soakGainP    = 0.05
+	soakGainI    = 0.01
+	// soakBand is how close to the target, in °C, counts as holding it.
+	soakBand = 2.0
+)
+
+// soak modulates the busy ratio of every core to hold the CPU at a target temperature.
+type soak struct {
+	target float64
+	// read returns the current CPU temperature.
+	read  func() (float64, error)
+	ratio atomic.Uint64 // math.Float64bits of the busy ratio
+}
+
+// newSoak returns a soak controller for target °C, starting at full load so that
+// the CPU heats up as fast as it can.
+func newSoak(target float64, read func() (float64, error)) *soak {
+	s := &soak{target: target, read: read}
+	s.ratio.Store(math.Float64bits(1))
+	return s
+}
+
+// load returns the busy ratio the cores should run at. A nil soak runs at full load.
+func (s *soak) load() float64 {
+	if s == nil {
+		return 1
+	}
+	return math.Float64frombits(s.ratio.Load())
+}
+
+// step updates the busy ratio from a temperature reading and the previous error.
+func (s *soak) step(temp, previousError float64) float64 {
+	e := s.target - temp
+	ratio := s.load() + soakGainP*(e-previousError) + soakGainI*e
+	s.ratio.Store(math.Float64bits(clampRatio(ratio)))
+	return e
+}
+
+// run holds the target temperature until ctx is done and logs how well it was held.
+func (s *soak) run(ctx context.Context, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(soakInterval)
+	defer ticker.Stop()
+
+	recorder.Logf("CPU", "Thermal soak: modulating load to hold %.1f°C", s.target)
+	start := time.Now()
+	var reached time.Time
+	var samples, inBand int
+	var peak float64
+	previousError := math.NaN()
+	for {
+		select {
+		case <-ctx.Done():
+			switch {
+			case reached.IsZero():
+				recorder.Logf("CPU", "Thermal soak: %.1f°C was not reached, the peak was %.1f°C", s.target, peak)
+			case samples > 0:
+				recorder.Logf("CPU", "Thermal soak: held within %.0f°C of %.1f°C for %.0f%% of the time after reaching it (peak %.1f°C)",
+					soakBand, s.target, float64(inBand)/float64(samples)*100, peak)
+			}
+			return
+		case <-ticker.C:
+			temp, err := s.read()
+			if err != nil {
+				continue
+			}
+			recorder.RecordValue("CPU temperature", "°C", temp)
+			peak = max(peak, temp)
+			if math.IsNaN(previousError) {
+				previousError = s.target - temp
+			}
+			previousError = s.step(temp, previousError)
+
+			near := math.Abs(temp-s.target) <= soakBand
+			if reached.IsZero() && (near || temp > s.target) {
+				reached = time.Now()
+				recorder.Logf("CPU", "Thermal soak: reached %.1f°C after %v", temp, reached.Sub(start).Truncate(time.Second))
+			}
+			if !reached.IsZero() {
+				samples++
+				if near {
+					inBand++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cpu
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the total user+system CPU time consumed by the process on Unix-like systems.
+func processCPUTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, fmt.Errorf("failed to get resource usage: %v", err)
+	}
+
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
@@ -0,0 +1,26 @@
+package cpu
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// processCPUTime returns the total user+kernel CPU time consumed by the process on Windows.
+func processCPUTime() (time.Duration, error) {
+	handle, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get process handle: %v", err)
+	}
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, fmt.Errorf("failed to get process times: %v", err)
+	}
+
+	// Filetime values are expressed in 100-nanosecond intervals
+	ticks := func(ft syscall.Filetime) int64 {
+		return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime)
+	}
+	return time.Duration((ticks(kernel) + ticks(user)) * 100), nil
+}
@@ -0,0 +1,24 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// vectorAvailable reports whether this architecture has a SIMD loop.
+const vectorAvailable = true
+
+// Implemented in vector_arm64.s.
+func neonBurn(iterations uint64)
+func sveBurn(iterations uint64)
+func sveVectorBits() uint64
+
+// vectorWorkload returns the SIMD loop for this CPU and its name: SVE at the
+// implemented vector length where supported, NEON (Advanced SIMD) otherwise.
+func vectorWorkload(t sysinfo.Topology) (string, func(iterations uint64)) {
+	if t.HasFeature("sve") {
+		return fmt.Sprintf("SVE (%d-bit)", sveVectorBits()), sveBurn
+	}
+	return "NEON", neonBurn
+}
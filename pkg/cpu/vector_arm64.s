@@ -0,0 +1,78 @@
+#include "textflag.h"
+
+// func neonBurn(iterations uint64)
+//
+// Mixes integer shifts, additions and XORs with double-precision multiply-adds
+// on 128-bit NEON registers, keeping both the SIMD integer and FP pipes busy.
+TEXT ·neonBurn(SB), NOSPLIT, $0-8
+	MOVD	iterations+0(FP), R0
+	CBZ	R0, done
+	MOVD	$1103515245, R1
+	VDUP	R1, V1.D2
+	VDUP	R1, V2.D2
+	MOVD	$12345, R2
+	VDUP	R2, V3.D2
+	MOVD	$0x3ff0000010c6f7a1, R3 // 1.0000001
+	VDUP	R3, V4.D2
+	VDUP	R3, V7.D2
+	VDUP	R3, V9.D2
+	VEOR	V8.B16, V8.B16, V8.B16
+	VEOR	V10.B16, V10.B16, V10.B16
+
+neonLoop:
+	VADD	V1.D2, V2.D2, V2.D2
+	VEOR	V2.B16, V3.B16, V3.B16
+	VUSHR	$21, V3.D2, V5.D2
+	VEOR	V5.B16, V2.B16, V2.B16
+	VSHL	$35, V2.D2, V6.D2
+	VEOR	V6.B16, V3.B16, V3.B16
+	VFMLA	V4.D2, V7.D2, V8.D2
+	VFMLA	V4.D2, V9.D2, V10.D2
+	SUBS	$1, R0, R0
+	BNE	neonLoop
+
+done:
+	RET
+
+// func sveBurn(iterations uint64)
+//
+// The same mix as neonBurn on full-width SVE registers. The Go assembler has no
+// SVE mnemonics, so the instructions are encoded by hand; the comments give the
+// assembly they encode.
+TEXT ·sveBurn(SB), NOSPLIT, $0-8
+	MOVD	iterations+0(FP), R0
+	CBZ	R0, sveDone
+	MOVD	$1103515245, R1
+	MOVD	$12345, R2
+	MOVD	$0x3ff0000010c6f7a1, R3 // 1.0000001
+	WORD	$0x05e03821 // mov z1.d, x1
+	WORD	$0x05e03822 // mov z2.d, x1
+	WORD	$0x05e03843 // mov z3.d, x2
+	WORD	$0x05e03864 // mov z4.d, x3
+	WORD	$0x05e03867 // mov z7.d, x3
+	WORD	$0x05e03869 // mov z9.d, x3
+	WORD	$0x25f8c008 // mov z8.d, #0
+	WORD	$0x25f8c00a // mov z10.d, #0
+	WORD	$0x25d8e3e0 // ptrue p0.d
+
+sveLoop:
+	WORD	$0x04e10042 // add z2.d, z2.d, z1.d
+	WORD	$0x04a23063 // eor z3.d, z3.d, z2.d
+	WORD	$0x04eb9465 // lsr z5.d, z3.d, #21
+	WORD	$0x04a53042 // eor z2.d, z2.d, z5.d
+	WORD	$0x04e39c46 // lsl z6.d, z2.d, #35
+	WORD	$0x04a63063 // eor z3.d, z3.d, z6.d
+	WORD	$0x65e70088 // fmla z8.d, p0/m, z4.d, z7.d
+	WORD	$0x65e9008a // fmla z10.d, p0/m, z4.d, z9.d
+	SUBS	$1, R0, R0
+	BNE	sveLoop
+
+sveDone:
+	RET
+
+// func sveVectorBits() uint64
+TEXT ·sveVectorBits(SB), NOSPLIT, $0-8
+	WORD	$0x04bf5020 // rdvl x0, #1
+	LSL	$3, R0, R0
+	MOVD	R0, ret+0(FP)
+	RET
@@ -0,0 +1,14 @@
+//go:build !arm64
+
+package cpu
+
+import "github.com/utkamioka/stress-go/pkg/sysinfo"
+
+// vectorAvailable reports whether this architecture has a SIMD loop.
+const vectorAvailable = false
+
+// vectorWorkload returns no SIMD loop; on these architectures the integer loop
+// is the whole workload.
+func vectorWorkload(t sysinfo.Topology) (string, func(iterations uint64)) {
+	return "", nil
+}
@@ -0,0 +1,68 @@
+package cpu
+
+import (
+	"math"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// fpCheckSeed is the input of the floating point check. It is a variable so that the
+// compiler cannot evaluate the check at build time.
+var fpCheckSeed = 1.5
+
+// fpCheckRounds is the number of rounds of the floating point check.
+const fpCheckRounds = 2000
+
+// fpCheck runs a fixed sequence of floating point operations (multiply, divide,
+// square root, fused multiply-add, exponent) and returns the result. IEEE 754 makes
+// the result exact and reproducible, so a core that returns anything else than the
+// reference computed at start has a faulty FPU. It is kept out of line so that
+// every call runs the same machine code.
+//
+//go:noinline
+func fpCheck(x float64) float64 {
+	y := 1.0 / 3
+	for i := 0; i < fpCheckRounds; i++ {
+		x = math.Sqrt(x*x+y) - x/7
+		y = math.FMA(y, 1.000001, x) / 3
+		x = math.Mod(x*1e3, 997) + math.Exp(-y)
+	}
+	return x + y
+}
+
+// verifier compares the floating point check of a worker against the reference.
+type verifier struct {
+	reference uint64
+	recorder  *metrics.Recorder
+}
+
+// newVerifier computes the reference result, or returns nil when verification is off.
+func newVerifier(enabled bool, recorder *metrics.Recorder) *verifier {
+	if !enabled {
+		return nil
+	}
+	return &verifier{reference: math.Float64bits(fpCheck(fpCheckSeed)), recorder: recorder}
+}
+
+// check runs the floating point check on the worker's core and records the outcome.
+// Only a worker's first mismatch is logged; later ones are counted.
+func (v *verifier) check(w worker, failed *bool) {
+	if v == nil {
+		return
+	}
+	result := math.Float64bits(fpCheck(fpCheckSeed))
+	if result == v.reference {
+		v.recorder.RecordVerification("CPU", 1)
+		return
+	}
+	detail := i18n.Sprintf("worker %d: FP result %#016x, expected %#016x", w.id, result, v.reference)
+	if w.cpu >= 0 {
+		detail = i18n.Sprintf("worker %d on CPU %d: FP result %#016x, expected %#016x", w.id, w.cpu, result, v.reference)
+	}
+	if !*failed {
+		*failed = true
+		v.recorder.Logf("CPU", "Verification error: %s", detail)
+	}
+	v.recorder.RecordVerification("CPU", 1, detail)
+}
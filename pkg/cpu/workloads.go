@@ -0,0 +1,79 @@
+package cpu
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "integer",
+		Usage:       "--cpu <cores> [--cpu-method integer]",
+		Description: "Integer multiply, add, shift and xor loop on every worker",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "vector",
+		Usage:       "--cpu <cores>",
+		Description: "SIMD (SVE or NEON) and floating point multiply-add loop, run alongside the integer loop",
+		Available:   vectorAvailable,
+		Requirement: "ARM64",
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "bignum",
+		Usage:       "--cpu <cores> --cpu-method bignum [--cpu-bignum-bits 2048]",
+		Description: "Modular exponentiation of big integers, as in RSA key operations and key generation",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "float",
+		Usage:       "--cpu <cores> --cpu-method float",
+		Description: "Double precision matrix multiply, loading the FPU and SIMD units",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "crypto",
+		Usage:       "--cpu <cores> --cpu-method crypto",
+		Description: "AES-128-CTR encryption and SHA-256 hashing, using the CPU's crypto instructions",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "prime",
+		Usage:       "--cpu <cores> --cpu-method prime",
+		Description: "Sieve of Eratosthenes counting primes, within the L1 cache",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "branch",
+		Usage:       "--cpu <cores> --cpu-method branch",
+		Description: "Unpredictable branches on random bits, flushing the pipeline on mispredictions",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "cache",
+		Usage:       "--cpu <cores> --cpu-method cache",
+		Description: "Random pointer chase over twice the last level cache, stalling on cache misses",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Methods,
+		Domain:      "cpu",
+		Name:        "all",
+		Usage:       "--cpu <cores> --cpu-method all",
+		Description: "Every method in turn on each worker, switching every 2 seconds",
+		Available:   true,
+	})
+}
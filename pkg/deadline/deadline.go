@@ -0,0 +1,88 @@
+// Package deadline は実行中に延長・短縮できる終了時刻を提供します。
+package deadline
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Deadline は実行中に変更できる終了時刻です。WithTimeout で作成します。
+type Deadline struct {
+	start  time.Time
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	end   time.Time
+	timer *time.Timer
+	done  bool
+}
+
+// WithTimeout は timeout 後に終了するコンテキストと、その終了時刻を操作する Deadline を返します。
+// context.WithTimeout と異なり、終了時刻は Deadline の Extend で後から変更できます。
+//
+// 引数:
+//
+//	parent  - 親コンテキスト
+//	timeout - 終了までの時間
+func WithTimeout(parent context.Context, timeout time.Duration) (context.Context, *Deadline, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	now := time.Now()
+	d := &Deadline{start: now, end: now.Add(timeout), cancel: cancel}
+	d.timer = time.AfterFunc(timeout, d.expire)
+	return ctx, d, func() {
+		d.mu.Lock()
+		d.done = true
+		d.timer.Stop()
+		d.mu.Unlock()
+		cancel()
+	}
+}
+
+// expire cancels the context once the deadline has passed. A timer that fired
+// while Extend moved the deadline later is ignored; the reset timer fires again.
+func (d *Deadline) expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done || time.Now().Before(d.end) {
+		return
+	}
+	d.done = true
+	d.cancel()
+}
+
+// Extend は終了時刻を delta だけ延長します。負の値を指定すると短縮し、
+// 短縮後の終了時刻が過ぎている場合は直ちに終了します。すでに終了している場合は何もしません。
+// 変更後の終了時刻を返します。
+//
+// 引数:
+//
+//	delta - 延長する時間 (負の値で短縮)
+func (d *Deadline) Extend(delta time.Duration) time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done {
+		return d.end
+	}
+	d.end = d.end.Add(delta)
+	d.timer.Stop()
+	d.timer.Reset(max(time.Until(d.end), 0))
+	return d.end
+}
+
+// End は現在の終了時刻を返します。
+func (d *Deadline) End() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.end
+}
+
+// Total は開始から現在の終了時刻までの時間を返します。
+func (d *Deadline) Total() time.Duration {
+	return d.End().Sub(d.start)
+}
+
+// Remaining は終了までの残り時間を返します。終了後は 0 を返します。
+func (d *Deadline) Remaining() time.Duration {
+	return max(time.Until(d.End()), 0)
+}
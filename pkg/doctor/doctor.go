@@ -0,0 +1,127 @@
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Status はチェック結果の重要度です。
+type Status int
+
+const (
+	OK Status = iota
+	Warn
+	Fail
+)
+
+// String returns the label printed in front of a check result.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warn:
+		return "WARN"
+	default:
+		return "FAIL"
+	}
+}
+
+// Check は1項目分の事前チェック結果です。
+// Affects には結果が影響する負荷生成モジュールやオプション（例: "--memory"）を列挙します。
+type Check struct {
+	Name    string
+	Status  Status
+	Detail  string
+	Affects []string
+}
+
+// Run はこのホストで負荷テストを実行できるかを確認し、結果を返します。
+//
+// 引数:
+//
+//	diskPath - ストレージ負荷で使用するディレクトリ
+func Run(diskPath string) []Check {
+	checks := []Check{checkMemory(), checkDisk(diskPath), checkThermal(), checkTopology(), checkCaches()}
+	return append(checks, platformChecks()...)
+}
+
+// checkMemory reports how much memory the memory stressor can take.
+func checkMemory() Check {
+	c := Check{Name: "Available memory", Affects: []string{"--memory"}}
+	snapshot, err := sysinfo.Read()
+	if err != nil {
+		c.Status, c.Detail = Fail, fmt.Sprintf("cannot read memory information: %v", err)
+		return c
+	}
+	available, err := sysinfo.MemoryAvailable()
+	if err != nil {
+		c.Status, c.Detail = Fail, fmt.Sprintf("cannot read memory information: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("%d MiB available of %d MiB",
+		available/(1024*1024), snapshot.MemoryTotal/(1024*1024))
+	if available < snapshot.MemoryAvailable {
+		c.Detail += " (capped by the cgroup memory limit)"
+	}
+	if available < 256*1024*1024 {
+		c.Status = Warn
+	}
+	return c
+}
+
+// checkDisk reports how much space the storage stressor can fill under diskPath.
+func checkDisk(diskPath string) Check {
+	c := Check{Name: "Free disk space (" + diskPath + ")", Affects: []string{"--storage"}}
+	space, err := sysinfo.ReadDiskSpace(diskPath)
+	if err != nil {
+		c.Status, c.Detail = Fail, fmt.Sprintf("cannot read filesystem information: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("%d MiB available of %d MiB", space.Available/(1024*1024), space.Total/(1024*1024))
+	if space.Available < 1024*1024*1024 {
+		c.Status = Warn
+	}
+	return c
+}
+
+// checkThermal reports whether the thermal failsafe and temp abort conditions have sensors to work with.
+func checkThermal() Check {
+	c := Check{Name: "Temperature sensors", Affects: []string{"thermal failsafe", "--abort-if temp"}}
+	headroom, err := sysinfo.ReadThermalHeadroom()
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("unavailable, CPU load will not back off on overheating: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("%.1f°C below the critical trip point", headroom)
+	return c
+}
+
+// checkTopology reports the CPU cores the CPU stressor will load, and whether
+// they differ in performance (big.LITTLE or hybrid cores).
+func checkTopology() Check {
+	c := Check{Name: "CPU topology", Affects: []string{"--cpu"}}
+	topology, err := sysinfo.ReadTopology()
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("cannot read CPU information, assuming identical cores: %v", err)
+		return c
+	}
+	c.Detail = topology.String()
+	if topology.Heterogeneous() {
+		c.Detail += "; cores differ in performance, workers are pinned to the fastest cores when --cpu is below the CPU count"
+	}
+	return c
+}
+
+// checkCaches reports the CPU caches, which size the buffer of the latency
+// subcommand and its chase stride.
+func checkCaches() Check {
+	c := Check{Name: "CPU caches", Affects: []string{"latency"}}
+	caches, err := sysinfo.ReadCaches()
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("%v; assuming %d-byte cache lines", err, sysinfo.DefaultCacheLineSize)
+		return c
+	}
+	c.Detail = caches.String()
+	return c
+}
@@ -0,0 +1,54 @@
+//go:build darwin || freebsd || openbsd
+
+package doctor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+)
+
+// platformChecks returns the macOS, FreeBSD and OpenBSD specific checks.
+func platformChecks() []Check {
+	return []Check{
+		checkPrivileges(),
+		checkNofile(),
+	}
+}
+
+func checkPrivileges() Check {
+	c := Check{Name: "Privileges", Affects: []string{"root-only tuning"}}
+	if os.Geteuid() == 0 {
+		c.Detail = "running as root"
+		return c
+	}
+	c.Status = Warn
+	c.Detail = fmt.Sprintf("running as uid %d, features that need root will be unavailable", os.Geteuid())
+	return c
+}
+
+func checkNofile() Check {
+	c := Check{Name: "Open file limit (RLIMIT_NOFILE)", Affects: []string{"--storage"}}
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("cannot read: %v", err)
+		return c
+	}
+	// The limits are signed on FreeBSD and unsigned on macOS and OpenBSD
+	soft, hard := uint64(limit.Cur), uint64(limit.Max)
+	c.Detail = fmt.Sprintf("soft %s, hard %s", formatRlimit(soft), formatRlimit(hard))
+	if soft < 1024 {
+		c.Status = Warn
+	}
+	return c
+}
+
+// formatRlimit renders a resource limit value; RLIM_INFINITY is the largest
+// signed value on all three systems.
+func formatRlimit(v uint64) string {
+	if v == math.MaxInt64 {
+		return "unlimited"
+	}
+	return fmt.Sprint(v)
+}
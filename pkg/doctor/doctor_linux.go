@@ -0,0 +1,234 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Constants missing from the syscall package; both are the same on all mainstream architectures.
+const (
+	rlimitMemlock   = 8
+	sysIoUringSetup = 425
+	rlimInfinity    = ^uint64(0)
+)
+
+// platformChecks returns the Linux specific checks.
+func platformChecks() []Check {
+	cg, err := sysinfo.ReadCgroup()
+	return []Check{
+		checkPrivileges(),
+		checkNofile(),
+		checkMemlock(),
+		checkCgroupMemory(cg, err),
+		checkCgroupCPU(cg, err),
+		checkCgroupIO(cg, err),
+		checkHugePages(),
+		checkSleepInhibit(),
+		checkIoUring(),
+		checkICMP(),
+		checkRAPL(),
+	}
+}
+
+func checkPrivileges() Check {
+	c := Check{Name: "Privileges", Affects: []string{"root-only tuning"}}
+	if os.Geteuid() == 0 {
+		c.Detail = "running as root"
+		return c
+	}
+	c.Status = Warn
+	c.Detail = fmt.Sprintf("running as uid %d, features that need root will be unavailable", os.Geteuid())
+	return c
+}
+
+func checkNofile() Check {
+	c := Check{Name: "Open file limit (RLIMIT_NOFILE)", Affects: []string{"--storage"}}
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("cannot read: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("soft %s, hard %s", formatRlimit(limit.Cur), formatRlimit(limit.Max))
+	if limit.Cur < 1024 {
+		c.Status = Warn
+	}
+	return c
+}
+
+func checkMemlock() Check {
+	c := Check{Name: "Locked memory limit (RLIMIT_MEMLOCK)", Affects: []string{"locked memory"}}
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(rlimitMemlock, &limit); err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("cannot read: %v", err)
+		return c
+	}
+	c.Detail = fmt.Sprintf("soft %s, hard %s", formatRlimit(limit.Cur), formatRlimit(limit.Max))
+	if limit.Cur != rlimInfinity && limit.Cur < 64*1024*1024 {
+		c.Status = Warn
+	}
+	return c
+}
+
+// formatRlimit renders a resource limit value, which is either a count or a byte size.
+func formatRlimit(v uint64) string {
+	if v == rlimInfinity {
+		return "unlimited"
+	}
+	return strconv.FormatUint(v, 10)
+}
+
+func checkCgroupMemory(cg sysinfo.Cgroup, err error) Check {
+	c := Check{Name: "cgroup memory limit", Affects: []string{"--memory"}}
+	switch {
+	case err != nil:
+		c.Detail = fmt.Sprintf("not detected (%v)", err)
+	case cg.MemoryLimit == 0:
+		c.Detail = fmt.Sprintf("unlimited (cgroup v%d)", cg.Version)
+	default:
+		c.Status = Warn
+		c.Detail = fmt.Sprintf("%d MiB (%d MiB in use, cgroup v%d), memory load beyond this will be reclaimed or OOM-killed",
+			cg.MemoryLimit/(1024*1024), cg.MemoryUsage/(1024*1024), cg.Version)
+	}
+	return c
+}
+
+func checkCgroupCPU(cg sysinfo.Cgroup, err error) Check {
+	c := Check{Name: "cgroup CPU quota", Affects: []string{"--cpu"}}
+	switch {
+	case err != nil:
+		c.Detail = fmt.Sprintf("not detected (%v)", err)
+	case cg.CPUQuota == 0:
+		c.Detail = fmt.Sprintf("unlimited (cgroup v%d)", cg.Version)
+	case cg.CPUQuota >= float64(runtime.NumCPU()):
+		c.Detail = fmt.Sprintf("%.2f cores, not below the %d CPUs of this host", cg.CPUQuota, runtime.NumCPU())
+	default:
+		c.Status = Warn
+		c.Detail = fmt.Sprintf("%.2f cores of %d, CPU load beyond this will be throttled", cg.CPUQuota, runtime.NumCPU())
+	}
+	return c
+}
+
+func checkCgroupIO(cg sysinfo.Cgroup, err error) Check {
+	c := Check{Name: "cgroup I/O limit", Affects: []string{"--storage"}}
+	switch {
+	case err != nil:
+		c.Detail = fmt.Sprintf("not detected (%v)", err)
+	case len(cg.IO) == 0:
+		c.Detail = fmt.Sprintf("unlimited (cgroup v%d)", cg.Version)
+	default:
+		var limits []string
+		for _, l := range cg.IO {
+			limits = append(limits, fmt.Sprintf("%s %s", l.Device, formatIOLimit(l)))
+		}
+		c.Status = Warn
+		c.Detail = fmt.Sprintf("%s, storage load on these devices will be throttled", strings.Join(limits, "; "))
+	}
+	return c
+}
+
+// formatIOLimit renders the limited directions of an I/O limit, e.g. "write 10 MiB/s, read 500 IOPS".
+func formatIOLimit(l sysinfo.IOLimit) string {
+	var parts []string
+	if l.ReadBPS > 0 {
+		parts = append(parts, fmt.Sprintf("read %.1f MiB/s", float64(l.ReadBPS)/(1024*1024)))
+	}
+	if l.WriteBPS > 0 {
+		parts = append(parts, fmt.Sprintf("write %.1f MiB/s", float64(l.WriteBPS)/(1024*1024)))
+	}
+	if l.ReadIOPS > 0 {
+		parts = append(parts, fmt.Sprintf("read %d IOPS", l.ReadIOPS))
+	}
+	if l.WriteIOPS > 0 {
+		parts = append(parts, fmt.Sprintf("write %d IOPS", l.WriteIOPS))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func checkHugePages() Check {
+	c := Check{Name: "Huge page pool", Affects: []string{"huge page memory"}}
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("cannot read: %v", err)
+		return c
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key, rest, ok := strings.Cut(scanner.Text(), ":"); ok && strings.HasPrefix(key, "Huge") {
+			values[key] = strings.TrimSpace(rest)
+		}
+	}
+	if values["HugePages_Total"] == "" || values["HugePages_Total"] == "0" {
+		c.Status, c.Detail = Warn, "no huge pages reserved (vm.nr_hugepages is 0)"
+		return c
+	}
+	c.Detail = fmt.Sprintf("%s free of %s pages (%s each)",
+		values["HugePages_Free"], values["HugePages_Total"], values["Hugepagesize"])
+	return c
+}
+
+func checkSleepInhibit() Check {
+	c := Check{Name: "Sleep inhibition (systemd-inhibit)", Affects: []string{"sleep prevention"}}
+	path, err := exec.LookPath("systemd-inhibit")
+	if err != nil {
+		c.Status, c.Detail = Warn, "systemd-inhibit not found, the host may suspend during long runs"
+		return c
+	}
+	c.Detail = path
+	return c
+}
+
+func checkIoUring() Check {
+	c := Check{Name: "io_uring", Affects: []string{"io_uring I/O"}}
+	// A NULL params pointer makes a supported kernel fail with EFAULT without creating a ring
+	_, _, errno := syscall.Syscall(sysIoUringSetup, 1, 0, 0)
+	switch errno {
+	case syscall.EFAULT:
+		c.Detail = "available"
+	case syscall.ENOSYS:
+		c.Status, c.Detail = Warn, "not supported by this kernel"
+	case syscall.EPERM:
+		c.Status, c.Detail = Warn, "disabled (kernel.io_uring_disabled or seccomp)"
+	default:
+		c.Status, c.Detail = Warn, fmt.Sprintf("unavailable: %v", errno)
+	}
+	return c
+}
+
+// checkRAPL reports whether the power used during the run can be measured.
+func checkRAPL() Check {
+	c := Check{Name: "RAPL energy counters", Affects: []string{"power reporting"}}
+	domains, err := sysinfo.PowerDomains()
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("unavailable, power will not be reported: %v", err)
+		return c
+	}
+	names := make([]string, len(domains))
+	for i, d := range domains {
+		names[i] = d.Name
+	}
+	c.Detail = "available for " + strings.Join(names, ", ")
+	return c
+}
+
+func checkICMP() Check {
+	c := Check{Name: "Unprivileged ICMP sockets", Affects: []string{"ICMP probes"}}
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_ICMP)
+	if err != nil {
+		c.Status, c.Detail = Warn, fmt.Sprintf("unavailable (net.ipv4.ping_group_range): %v", err)
+		return c
+	}
+	syscall.Close(fd)
+	c.Detail = "available"
+	return c
+}
@@ -0,0 +1,19 @@
+package doctor
+
+import "os/exec"
+
+// platformChecks returns the Windows specific checks.
+func platformChecks() []Check {
+	return []Check{checkPrivileges()}
+}
+
+func checkPrivileges() Check {
+	c := Check{Name: "Privileges", Affects: []string{"administrator-only tuning"}}
+	// "net session" only succeeds from an elevated prompt
+	if err := exec.Command("net", "session").Run(); err != nil {
+		c.Status, c.Detail = Warn, "not running as administrator, features that need elevation will be unavailable"
+		return c
+	}
+	c.Detail = "running as administrator"
+	return c
+}
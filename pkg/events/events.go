@@ -0,0 +1,121 @@
+// Package events は負荷テストの実行状態の変化を型付きのイベントとして配信するイベントバスを提供します。
+//
+// 実行状態はすべてイベントとして発行されます。コンソール出力、API サーバー、Webhook、
+// ライブラリの利用者はいずれも Bus を購読して同じイベントを受け取ります。
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Type はイベントの種類です。
+type Type string
+
+// イベントの種類です。
+const (
+	// RunStarted は負荷テストの開始です。Fields に "duration" と "settings" を含みます。
+	RunStarted Type = "run_started"
+	// RunStopping は期限前の停止の開始です (シグナル受信など)。Message に理由を含みます。
+	RunStopping Type = "run_stopping"
+	// RunFinished は負荷テストの終了です。Fields に "exit_code" を含みます。
+	RunFinished Type = "run_finished"
+	// PhaseStarted は負荷パターンなどで区切られたフェーズの開始です。
+	PhaseStarted Type = "phase_started"
+	// PhaseFinished はフェーズの終了です。
+	PhaseFinished Type = "phase_finished"
+	// StressorStarted は負荷生成モジュールの開始です。
+	StressorStarted Type = "stressor_started"
+	// StressorAdjusted は負荷生成モジュールの目標値の変更です。Fields に "unit" と "target" を含みます。
+	StressorAdjusted Type = "stressor_adjusted"
+	// StressorFailed は負荷生成モジュールのエラーまたはパニックです。Message にエラーを含みます。
+	StressorFailed Type = "stressor_failed"
+	// StressorStopped は負荷生成モジュールの終了です。
+	StressorStopped Type = "stressor_stopped"
+	// WatchdogTripped は --abort-if の条件の成立です。Message に条件を含みます。
+	WatchdogTripped Type = "watchdog_tripped"
+	// DeadlineChanged は終了時刻の変更です。Fields に "end" を含みます。
+	DeadlineChanged Type = "deadline_changed"
+	// RunControlled は制御 API による一時停止・再開・負荷レベルの変更です。Message に内容を含みます。
+	RunControlled Type = "run_controlled"
+)
+
+// Event は実行状態の変化を表すイベントです。
+type Event struct {
+	// Time はイベントの発生時刻です。
+	Time time.Time `json:"time"`
+	// Type はイベントの種類です。
+	Type Type `json:"type"`
+	// Stressor は負荷生成モジュールに関するイベントの場合の対象の名前です。
+	Stressor string `json:"stressor,omitempty"`
+	// Message は人間が読める説明です。
+	Message string `json:"message,omitempty"`
+	// Fields は種類ごとの追加情報です。
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// Bus はイベントを購読者に配信します。ゼロ値は購読者のいない Bus として使用できます。
+// nil の Bus に対する呼び出しは何もしません。
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[int]func(Event)
+	nextID      int
+}
+
+// Subscribe はイベントが発行されるたびに呼び出されるコールバックを登録し、
+// 登録を解除する関数を返します。コールバックは発行順に、発行したゴルーチンから呼び出されるため、
+// 速やかに処理を返してください。
+//
+// 引数:
+//
+//	fn - イベントを受け取るコールバック
+func (b *Bus) Subscribe(fn func(Event)) func() {
+	if b == nil {
+		return func() {}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]func(Event))
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+// Publish はイベントをすべての購読者に配信します。Time が未設定の場合は現在時刻を設定します。
+//
+// 引数:
+//
+//	e - 発行するイベント
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	// Deliver in subscription order, outside the lock so subscribers may publish
+	b.mu.Lock()
+	ids := make([]int, 0, len(b.subscribers))
+	for id := range b.subscribers {
+		ids = append(ids, id)
+	}
+	subscribers := make([]func(Event), 0, len(ids))
+	slices.Sort(ids)
+	for _, id := range ids {
+		subscribers = append(subscribers, b.subscribers[id])
+	}
+	b.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
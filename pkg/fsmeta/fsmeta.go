@@ -0,0 +1,594 @@
+// Package fsmeta は大量のファイルディスクリプタを開いたまま保持し、深いディレクトリツリーに
+// 多数の小さなファイルを作成して作成と削除を繰り返すことで、オープンファイル数の上限 (ulimit)・
+// dentry キャッシュ・ファイルシステムのメタデータに負荷をかけます。大きなファイルを読み書きする
+// ストレージ負荷では通らない経路を試験します。
+package fsmeta
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/tempdirs"
+)
+
+// sampleInterval is how often the achieved operation rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// retryInterval is how long a worker waits after an operation failed.
+const retryInterval = 100 * time.Millisecond
+
+// workers is the number of goroutines that create the files and churn them.
+const workers = 4
+
+// fanout is the number of subdirectories of each directory of the tree, and
+// filesPerDir the number of files in each directory at the bottom.
+const (
+	fanout      = 16
+	filesPerDir = 256
+)
+
+// defaultDepth is the depth of the directory tree unless Options.Depth is set,
+// and maxDepth the deepest allowed.
+const (
+	defaultDepth = 4
+	maxDepth     = 128
+)
+
+// fileContent is written to each small file, so that every file takes a data
+// block as well as an inode.
+var fileContent = []byte("stress-go small file\n")
+
+// fileBlock is the disk space a small file is assumed to take.
+const fileBlock = 4096
+
+// fdFiles is the number of files the held descriptors are opened on.
+const fdFiles = 64
+
+// fdReserve is the number of descriptors kept free below the open file limit
+// for the rest of the process: the other stressors, the HTTP endpoints and the
+// files being churned.
+const fdReserve = 256
+
+// Options はファイルディスクリプタとメタデータの負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Files" を使用します。
+	Name string
+	// FDs は開いたまま保持するファイルディスクリプタの数です。オープンファイル数の上限から
+	// 256 を引いた数を超える場合は、その数に抑えます。
+	FDs int
+	// Inodes はディレクトリツリーに作成する小さなファイルの数です。0 の場合はファイルを作成せず、
+	// 保持しているファイルディスクリプタを閉じては開き直します。
+	Inodes int
+	// Depth はディレクトリツリーの深さです。0 の場合は 4 です。ファイル数に必要な深さより浅い場合は深くします。
+	Depth int
+	// Rate は目標の操作数 (ファイルの作成と削除、または開き直しの合計、1秒あたり) です。
+	// 0 の場合は制限せずに可能な限り操作します。
+	Rate float64
+	// Dir はファイルを作成するディレクトリです。空の場合はOSの一時ディレクトリを使用します。
+	Dir string
+	// Budget は実行全体の負荷生成モジュールで共有するディスク容量の上限です (nil可)。
+	// ファイルを作成する前にファイル数 × 4KB を予約し、上限に収まらない場合はファイルを減らします。
+	Budget *budget.Budget
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if o.FDs < 0 || o.Inodes < 0 || o.Rate < 0 {
+		return fmt.Errorf("descriptors, inodes and rate must not be negative")
+	}
+	if o.FDs == 0 && o.Inodes == 0 {
+		return fmt.Errorf("descriptors or inodes must be positive")
+	}
+	if o.Depth < 0 || o.Depth > maxDepth {
+		return fmt.Errorf("directory depth must be in range 0-%d", maxDepth)
+	}
+	return nil
+}
+
+// Result はファイルディスクリプタとメタデータの負荷の実行結果です。
+type Result struct {
+	// Created と Removed はファイルを作成・削除した回数です (最初のツリーの作成を含みます)。
+	Created, Removed int64
+	// Reopened はファイルディスクリプタを閉じて開き直した回数です。
+	Reopened int64
+	// PeakFDs は同時に保持したファイルディスクリプタの最大数です。
+	PeakFDs int
+}
+
+// Stats は実行中のファイルディスクリプタとメタデータの負荷の状態です。
+type Stats struct {
+	// Target は現在の目標操作数（1秒あたり）です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定での操作数（1秒あたり）です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// Dir はファイルを作成する一時ディレクトリです。作成前は空です。
+	Dir string
+	// FDs は保持しているファイルディスクリプタの数です。
+	FDs int64
+	// Files は現在ツリーにあるファイルの数です。
+	Files int64
+}
+
+// Controller は実行中のファイルディスクリプタとメタデータの負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+	err    error
+
+	created  atomic.Int64
+	removed  atomic.Int64
+	reopened atomic.Int64
+	churned  atomic.Int64 // operations after the tree was built, which the rate counts
+	building atomic.Int32 // workers still creating their part of the tree
+	fds      atomic.Int64
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	dir      atomic.Pointer[string] // the temporary directory once run creates it
+	achieved atomic.Uint64          // math.Float64bits of the last measured operation rate
+
+	// mu guards lastError, the last error logged, so that a worker retrying
+	// against the same failure does not repeat it every retryInterval.
+	mu        sync.Mutex
+	lastError string
+}
+
+// Start は opts に従ってファイルディスクリプタとメタデータの負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続き、終了時にファイルディスクリプタを閉じてファイルをすべて削除します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Files"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+
+	c := &Controller{opts: opts, done: make(chan struct{})}
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c, nil
+}
+
+// run opens the descriptors, builds the tree and churns it until ctx is done,
+// then closes the descriptors and removes every file.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("Files", "Load generation completed")
+
+	dir, err := os.MkdirTemp(c.opts.Dir, "stress-go-files-")
+	if err != nil {
+		c.err = fmt.Errorf("failed to create temporary directory: %v", err)
+		return
+	}
+	c.dir.Store(&dir)
+	tempdirs.Track(dir)
+	defer func() {
+		if c.opts.Inodes > 0 {
+			recorder.Logf("Files", "Removing %d files in %s", c.created.Load()-c.removed.Load(), dir)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			recorder.Logf("Files", "Failed to remove %s: %v", dir, err)
+		}
+	}()
+
+	// The small files take a block each of the disk space shared with the
+	// other stressors
+	inodes := c.opts.Inodes
+	reserved := c.opts.Budget.Reserve(int64(inodes) * fileBlock)
+	defer c.opts.Budget.Release(reserved)
+	if capped := int(reserved / fileBlock); capped < inodes {
+		limit := c.opts.Budget.Limit()
+		recorder.Flag("Files", i18n.Sprintf("files capped by hard disk limit (%d MiB)", limit/(1024*1024)))
+		recorder.Logf("Files", "Small files capped from %d to %d by the disk limit", inodes, capped)
+		inodes = capped
+		if inodes == 0 && c.opts.FDs == 0 {
+			c.err = fmt.Errorf("no room for the small files within the hard disk limit of %d MiB", limit/(1024*1024))
+			return
+		}
+	}
+
+	held, err := c.openDescriptors(dir)
+	defer func() {
+		for _, f := range held {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	tree := newTree(dir, inodes, c.opts.Depth)
+	switch {
+	case inodes > 0 && len(held) > 0:
+		recorder.Logf("Files", "Holding %d file descriptors and churning %d files in a tree %d directories deep in %s",
+			len(held), inodes, tree.depth, dir)
+	case inodes > 0:
+		recorder.Logf("Files", "Churning %d files in a tree %d directories deep in %s", inodes, tree.depth, dir)
+	default:
+		recorder.Logf("Files", "Holding %d file descriptors and reopening them in %s", len(held), dir)
+	}
+	if c.opts.Rate > 0 {
+		recorder.Logf("Files", "Target rate: %.0f operations/s", c.opts.Rate)
+	}
+
+	var wg sync.WaitGroup
+	if inodes > 0 {
+		c.building.Store(workers)
+	}
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if inodes > 0 {
+				c.churnFiles(ctx, tree, w)
+			} else {
+				c.reopen(ctx, held, w)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	lastOps, lastTime := int64(0), time.Now()
+	building := inodes > 0
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			ops := c.churned.Load()
+			// The window in which the tree was still being built is left out
+			if building {
+				building = c.building.Load() > 0
+				lastOps, lastTime = ops, now
+				continue
+			}
+			rate := float64(ops-lastOps) / now.Sub(lastTime).Seconds()
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.Record("Files", metrics.UnitOpsPerSecond, c.targetRate(), rate)
+			lastOps, lastTime = ops, now
+		}
+	}
+	wg.Wait()
+
+	c.result.Created = c.created.Load()
+	c.result.Removed = c.removed.Load()
+	c.result.Reopened = c.reopened.Load()
+	recorder.AddCount("Files", "created", c.result.Created)
+	recorder.AddCount("Files", "removed", c.result.Removed)
+	recorder.AddCount("Files", "reopened", c.result.Reopened)
+	if inodes > 0 {
+		recorder.Logf("Files", "Created %d small files and removed %d", c.result.Created, c.result.Removed)
+	} else {
+		recorder.Logf("Files", "Reopened %d file descriptors", c.result.Reopened)
+	}
+	if c.result.PeakFDs > 0 {
+		recorder.Logf("Files", "Held up to %d file descriptors", c.result.PeakFDs)
+	}
+}
+
+// openDescriptors opens Options.FDs descriptors, spread over fdFiles files in
+// dir, up to fdReserve below the open file limit. Hitting a limit that was not
+// foreseen is flagged rather than failing the load, which keeps what it got.
+func (c *Controller) openDescriptors(dir string) ([]*os.File, error) {
+	recorder, want := c.opts.Recorder, c.opts.FDs
+	if want == 0 {
+		return nil, nil
+	}
+	if limit := OpenFileLimit(); limit > 0 && want > limit-fdReserve {
+		capped := max(limit-fdReserve, 0)
+		recorder.Flag("Files", i18n.Sprintf("descriptors capped by the open file limit (%d)", limit))
+		recorder.Logf("Files", "File descriptors capped from %d to %d, %d below the open file limit", want, capped, fdReserve)
+		want = capped
+	}
+
+	fdDir := filepath.Join(dir, "fd")
+	if err := os.Mkdir(fdDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for descriptors: %v", err)
+	}
+	paths := make([]string, min(want, fdFiles))
+	for i := range paths {
+		paths[i] = filepath.Join(fdDir, fmt.Sprintf("fd-%d", i))
+		if err := os.WriteFile(paths[i], fileContent, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to create a file for descriptors: %v", err)
+		}
+	}
+
+	held := make([]*os.File, 0, want)
+	for i := range want {
+		f, err := os.Open(paths[i%len(paths)])
+		if err != nil {
+			flagLimit(recorder, err)
+			recorder.Logf("Files", "Holding %d of %d file descriptors: %v", len(held), want, err)
+			break
+		}
+		held = append(held, f)
+	}
+	c.fds.Store(int64(len(held)))
+	c.result.PeakFDs = len(held)
+	if len(held) == 0 && c.opts.Inodes == 0 {
+		return held, fmt.Errorf("no file descriptor could be opened")
+	}
+	return held, nil
+}
+
+// reopen closes and opens again the descriptors of held that belong to worker
+// until ctx is done.
+func (c *Controller) reopen(ctx context.Context, held []*os.File, worker int) {
+	var mine []int
+	for i := worker; i < len(held); i += workers {
+		mine = append(mine, i)
+	}
+	if len(mine) == 0 {
+		return
+	}
+	rng := rand.New(rand.NewPCG(rand.Uint64(), uint64(worker)))
+	pace := c.pacer()
+	for ctx.Err() == nil {
+		if !pace(ctx) {
+			continue
+		}
+		k := rng.IntN(len(mine))
+		f := held[mine[k]]
+		f.Close()
+		reopened, err := os.Open(f.Name())
+		if err != nil {
+			// The slot is given up; the descriptors left are still held
+			c.fail(err)
+			c.fds.Add(-1)
+			held[mine[k]] = nil
+			mine = append(mine[:k], mine[k+1:]...)
+			if len(mine) == 0 {
+				return
+			}
+			continue
+		}
+		held[mine[k]] = reopened
+		c.reopened.Add(1)
+		c.churned.Add(1)
+	}
+}
+
+// churnFiles creates the files of the leaves of the tree that belong to
+// worker, then creates and removes random files of them until ctx is done.
+func (c *Controller) churnFiles(ctx context.Context, t *tree, worker int) {
+	var leaves []int
+	for leaf := worker; leaf < t.leaves(); leaf += workers {
+		leaves = append(leaves, leaf)
+	}
+	if !c.build(ctx, t, leaves) || len(leaves) == 0 {
+		return
+	}
+
+	rng := rand.New(rand.NewPCG(rand.Uint64(), uint64(worker)))
+	pace := c.pacer()
+	for ctx.Err() == nil {
+		if !pace(ctx) {
+			continue
+		}
+		leaf := leaves[rng.IntN(len(leaves))]
+		i := leaf*filesPerDir + rng.IntN(min(filesPerDir, t.files-leaf*filesPerDir))
+		var err error
+		if t.exists[i] {
+			if err = os.Remove(t.path(i)); err == nil {
+				t.exists[i] = false
+				c.removed.Add(1)
+			} else {
+				c.fail(err)
+			}
+		} else {
+			err = c.createFile(t, i)
+		}
+		if err != nil {
+			timing.Sleep(ctx, retryInterval)
+			continue
+		}
+		c.churned.Add(1)
+	}
+}
+
+// build creates the directories and files of leaves as fast as it can, and
+// reports whether the churn should follow. The rate is measured only once
+// every worker has built its part.
+func (c *Controller) build(ctx context.Context, t *tree, leaves []int) bool {
+	defer c.building.Add(-1)
+	for _, leaf := range leaves {
+		if err := os.MkdirAll(t.leafDir(leaf), 0o700); err != nil {
+			c.fail(err)
+			return false
+		}
+		for i := leaf * filesPerDir; i < min((leaf+1)*filesPerDir, t.files); i++ {
+			if ctx.Err() != nil {
+				return false
+			}
+			if c.createFile(t, i) != nil {
+				timing.Sleep(ctx, retryInterval)
+			}
+		}
+	}
+	return true
+}
+
+// createFile creates the small file i of the tree.
+func (c *Controller) createFile(t *tree, i int) error {
+	if err := os.WriteFile(t.path(i), fileContent, 0o600); err != nil {
+		c.fail(err)
+		return err
+	}
+	t.exists[i] = true
+	c.created.Add(1)
+	return nil
+}
+
+// fail counts and flags a failed operation, logging it unless it repeats the
+// last one logged.
+func (c *Controller) fail(err error) {
+	recorder := c.opts.Recorder
+	recorder.AddCount("Files", "errors", 1)
+	flagLimit(recorder, err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if message := err.Error(); message != c.lastError {
+		c.lastError = message
+		recorder.Logf("Files", "Error: %v", err)
+	}
+}
+
+// flagLimit flags err when it is one of the limits this load runs into.
+func flagLimit(recorder *metrics.Recorder, err error) {
+	switch {
+	case errors.Is(err, syscall.EMFILE):
+		recorder.Flag("Files", "open file limit reached (EMFILE)")
+	case errors.Is(err, syscall.ENFILE):
+		recorder.Flag("Files", "system file table full (ENFILE)")
+	case errors.Is(err, syscall.ENOSPC):
+		recorder.Flag("Files", "no space or inodes left on device (ENOSPC)")
+	}
+}
+
+// pacer returns a function that waits for the next operation of a worker at
+// its share of the target rate. It reports false, after a short sleep, while
+// the load is paused or scaled to nothing.
+func (c *Controller) pacer() func(ctx context.Context) bool {
+	next := time.Now()
+	return func(ctx context.Context) bool {
+		if c.paused.Load() || c.scale.Load() == 0 {
+			timing.Sleep(ctx, timing.IdleInterval)
+			next = time.Now()
+			return false
+		}
+		if rate := c.targetRate(); rate > 0 {
+			next = next.Add(time.Duration(float64(time.Second) * workers / rate))
+			if wait := time.Until(next); wait > 0 {
+				return timing.Sleep(ctx, wait)
+			}
+		}
+		return true
+	}
+}
+
+// tree lays out the small files: files/filesPerDir leaf directories at the
+// bottom of a tree depth levels deep with fanout subdirectories per level.
+type tree struct {
+	root   string
+	files  int
+	depth  int
+	exists []bool // indexed by file; each leaf is only touched by its worker
+}
+
+// newTree lays out files under root at least depth levels deep.
+func newTree(root string, files, depth int) *tree {
+	depth = cmp.Or(depth, defaultDepth)
+	for leaves := (files + filesPerDir - 1) / filesPerDir; int(math.Pow(fanout, float64(depth))) < leaves; {
+		depth++
+	}
+	return &tree{root: root, files: files, depth: depth, exists: make([]bool, files)}
+}
+
+// leaves returns the number of leaf directories holding files.
+func (t *tree) leaves() int {
+	return (t.files + filesPerDir - 1) / filesPerDir
+}
+
+// leafDir returns the directory of a leaf, one hexadecimal digit of its number
+// per level.
+func (t *tree) leafDir(leaf int) string {
+	parts := make([]string, t.depth+1)
+	parts[0] = t.root
+	for level := t.depth; level > 0; level-- {
+		parts[level] = strconv.FormatInt(int64(leaf%fanout), fanout)
+		leaf /= fanout
+	}
+	return filepath.Join(parts...)
+}
+
+// path returns the path of file i.
+func (t *tree) path(i int) string {
+	return filepath.Join(t.leafDir(i/filesPerDir), "f"+strconv.Itoa(i%filesPerDir))
+}
+
+// targetRate returns the current operation rate target, or 0 for as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return c.opts.Rate * math.Float64frombits(c.scale.Load())
+}
+
+// SetScale は Options で指定した目標操作数に掛ける係数を変更します (1.0 で指定どおり)。
+// 目標を指定していない場合は、0 で停止し、それ以外では可能な限り操作します。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで操作を止めます。保持しているファイルディスクリプタとファイルはそのままです。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了してファイルを削除するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, c.err
+}
+
+// workDir returns the temporary directory, or "" before run creates it.
+func (c *Controller) workDir() string {
+	if dir := c.dir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:   c.targetRate(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Paused:   c.paused.Load(),
+		Dir:      c.workDir(),
+		FDs:      c.fds.Load(),
+		Files:    c.created.Load() - c.removed.Load(),
+	}
+}
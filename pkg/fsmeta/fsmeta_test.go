@@ -0,0 +1,54 @@
+package fsmeta
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	tests := []struct {
+		files, depth int
+		wantDepth    int
+	}{
+		{files: 1000, wantDepth: defaultDepth},
+		{files: 1000, depth: 10, wantDepth: 10},
+		// 20M files need 78125 leaves, more than the 65536 of four levels
+		{files: 20_000_000, wantDepth: 5},
+	}
+	for _, tt := range tests {
+		tr := newTree("/root", tt.files, tt.depth)
+		if tr.depth != tt.wantDepth {
+			t.Errorf("newTree(%d, %d).depth = %d, want %d", tt.files, tt.depth, tr.depth, tt.wantDepth)
+		}
+	}
+
+	tr := newTree("/root", 1000, 0)
+	if got, want := tr.path(0), filepath.Join("/root", "0", "0", "0", "0", "f0"); got != want {
+		t.Errorf("path(0) = %q, want %q", got, want)
+	}
+	// File 999 is the 231st file of leaf 3
+	if got, want := tr.path(999), filepath.Join("/root", "0", "0", "0", "3", "f231"); got != want {
+		t.Errorf("path(999) = %q, want %q", got, want)
+	}
+	if got := tr.leaves(); got != 4 {
+		t.Errorf("leaves() = %d, want 4", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		opts Options
+		ok   bool
+	}{
+		{Options{FDs: 100}, true},
+		{Options{Inodes: 1000, Rate: 50}, true},
+		{Options{}, false},
+		{Options{FDs: -1}, false},
+		{Options{Inodes: 10, Depth: maxDepth + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v.Validate() = %v, want ok=%v", tt.opts, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fsmeta
+
+// OpenFileLimit はこのプロセスが開けるファイルディスクリプタの数の上限を返します。
+// このプラットフォームでは上限を取得できないため 0 を返します。
+func OpenFileLimit() int {
+	return 0
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package fsmeta
+
+import "syscall"
+
+// OpenFileLimit はこのプロセスが開けるファイルディスクリプタの数の上限 (RLIMIT_NOFILE の soft limit) を返します。
+// 取得できない場合は 0 を返します。
+func OpenFileLimit() int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil || limit.Cur > 1<<30 {
+		return 0
+	}
+	return int(limit.Cur)
+}
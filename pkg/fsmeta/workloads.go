@@ -0,0 +1,22 @@
+package fsmeta
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "files",
+		Name:        "fd",
+		Usage:       "--fd N",
+		Description: "Holding many open file descriptors, up to the open file limit",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "files",
+		Name:        "inodes",
+		Usage:       "--inodes N",
+		Description: "Creating and removing small files in a deep directory tree to load the dentry cache and file system metadata",
+		Available:   true,
+	})
+}
@@ -0,0 +1,267 @@
+//go:build gpu && cgo
+
+package gpu
+
+/*
+#cgo LDFLAGS: -lcuda
+#include <stdlib.h>
+#include <cuda.h>
+
+static const char *errorName(CUresult r) {
+	const char *name = NULL;
+	if (cuGetErrorName(r, &name) != CUDA_SUCCESS || name == NULL) {
+		return "unknown CUDA error";
+	}
+	return name;
+}
+
+// launchBurn runs the burn kernel with its parameters kept in C memory, so that
+// no Go pointers are handed to the driver.
+static CUresult launchBurn(CUfunction fn, CUdeviceptr out, unsigned int iterations,
+		unsigned int blocks, unsigned int threads) {
+	void **params = malloc(2 * sizeof(void *));
+	CUdeviceptr *outArg = malloc(sizeof(CUdeviceptr));
+	unsigned int *iterArg = malloc(sizeof(unsigned int));
+	*outArg = out;
+	*iterArg = iterations;
+	params[0] = outArg;
+	params[1] = iterArg;
+	CUresult r = cuLaunchKernel(fn, blocks, 1, 1, threads, 1, 1, 0, NULL, params, NULL);
+	if (r == CUDA_SUCCESS) {
+		r = cuCtxSynchronize();
+	}
+	free(iterArg);
+	free(outArg);
+	free(params);
+	return r;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+	"unsafe"
+)
+
+// Supported はこのビルドが GPU のバックエンドを含むかどうかです。
+const Supported = true
+
+// burnPTX is the compute kernel: every thread runs a chain of fused multiply-adds
+// and stores the result, so that the compiler cannot drop the loop.
+const burnPTX = `
+.version 6.0
+.target sm_50
+.address_size 64
+
+.visible .entry burn(.param .u64 out, .param .u32 iterations)
+{
+	.reg .pred %p;
+	.reg .b32 %r<6>;
+	.reg .f32 %f<4>;
+	.reg .b64 %rd<4>;
+
+	ld.param.u64 %rd1, [out];
+	ld.param.u32 %r1, [iterations];
+	mov.u32 %r2, %ctaid.x;
+	mov.u32 %r3, %ntid.x;
+	mov.u32 %r4, %tid.x;
+	mad.lo.s32 %r5, %r2, %r3, %r4;
+	cvt.rn.f32.u32 %f1, %r5;
+	mov.f32 %f2, 0f3F800001;
+	mov.f32 %f3, 0f3F7FFFFE;
+LOOP:
+	fma.rn.f32 %f1, %f1, %f2, %f3;
+	fma.rn.f32 %f1, %f1, %f3, %f2;
+	sub.u32 %r1, %r1, 1;
+	setp.ne.u32 %p, %r1, 0;
+	@%p bra LOOP;
+	cvta.to.global.u64 %rd2, %rd1;
+	mul.wide.u32 %rd3, %r5, 4;
+	add.s64 %rd2, %rd2, %rd3;
+	st.global.f32 [%rd2], %f1;
+	ret;
+}
+`
+
+const (
+	// launchTarget is the duration one kernel launch aims for; shorter
+	// launches keep the duty cycle accurate, longer ones the overhead low.
+	launchTarget = 10 * time.Millisecond
+	// threadsPerBlock and blocksPerSM size the grid to fill every multiprocessor.
+	threadsPerBlock = 256
+	blocksPerSM     = 8
+	// allocChunk is the size of each VRAM allocation, so that a shortfall
+	// still leaves most of the target allocated.
+	allocChunk = 256 * 1024 * 1024
+)
+
+// cudaDevice drives one GPU through the CUDA driver API on its primary context.
+type cudaDevice struct {
+	dev        C.CUdevice
+	ctx        C.CUcontext
+	module     C.CUmodule
+	fn         C.CUfunction
+	out        C.CUdeviceptr
+	blocks     C.uint
+	iterations C.uint
+	deviceName string
+	buffers    []C.CUdeviceptr
+}
+
+// cudaError converts a CUDA result to an error, or nil on success.
+func cudaError(call string, r C.CUresult) error {
+	if r == C.CUDA_SUCCESS {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", call, C.GoString(C.errorName(r)))
+}
+
+func openDevice(index int) (device, error) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := cudaError("cuInit", C.cuInit(0)); err != nil {
+		return nil, err
+	}
+	d := &cudaDevice{iterations: 1024}
+	if err := cudaError("cuDeviceGet", C.cuDeviceGet(&d.dev, C.int(index))); err != nil {
+		return nil, err
+	}
+	if err := cudaError("cuDevicePrimaryCtxRetain", C.cuDevicePrimaryCtxRetain(&d.ctx, d.dev)); err != nil {
+		return nil, err
+	}
+	if err := d.setup(); err != nil {
+		C.cuDevicePrimaryCtxRelease(d.dev)
+		return nil, err
+	}
+	return d, nil
+}
+
+// setup loads the kernel and sizes its grid. It runs on a locked OS thread.
+func (d *cudaDevice) setup() error {
+	if err := cudaError("cuCtxSetCurrent", C.cuCtxSetCurrent(d.ctx)); err != nil {
+		return err
+	}
+
+	var name [256]C.char
+	if err := cudaError("cuDeviceGetName", C.cuDeviceGetName(&name[0], C.int(len(name)), d.dev)); err != nil {
+		return err
+	}
+	d.deviceName = C.GoString(&name[0])
+
+	var sms C.int
+	if err := cudaError("cuDeviceGetAttribute", C.cuDeviceGetAttribute(&sms, C.CU_DEVICE_ATTRIBUTE_MULTIPROCESSOR_COUNT, d.dev)); err != nil {
+		return err
+	}
+	d.blocks = C.uint(max(int(sms), 1) * blocksPerSM)
+
+	ptx := C.CString(burnPTX)
+	defer C.free(unsafe.Pointer(ptx))
+	if err := cudaError("cuModuleLoadData", C.cuModuleLoadData(&d.module, unsafe.Pointer(ptx))); err != nil {
+		return err
+	}
+	entry := C.CString("burn")
+	defer C.free(unsafe.Pointer(entry))
+	if err := cudaError("cuModuleGetFunction", C.cuModuleGetFunction(&d.fn, d.module, entry)); err != nil {
+		C.cuModuleUnload(d.module)
+		return err
+	}
+	if err := cudaError("cuMemAlloc", C.cuMemAlloc(&d.out, C.size_t(d.blocks*threadsPerBlock*4))); err != nil {
+		C.cuModuleUnload(d.module)
+		return err
+	}
+	return nil
+}
+
+// current makes the device's context current on the calling goroutine's OS
+// thread, which stays locked until the returned function is called.
+func (d *cudaDevice) current() (func(), error) {
+	runtime.LockOSThread()
+	if err := cudaError("cuCtxSetCurrent", C.cuCtxSetCurrent(d.ctx)); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return runtime.UnlockOSThread, nil
+}
+
+func (d *cudaDevice) name() string {
+	return d.deviceName
+}
+
+func (d *cudaDevice) memoryInfo() (free, total int64, err error) {
+	unlock, err := d.current()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+
+	var f, t C.size_t
+	if err := cudaError("cuMemGetInfo", C.cuMemGetInfo(&f, &t)); err != nil {
+		return 0, 0, err
+	}
+	return int64(f), int64(t), nil
+}
+
+func (d *cudaDevice) allocate(size int64) (int64, error) {
+	unlock, err := d.current()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	var allocated int64
+	for allocated < size {
+		chunk := min(size-allocated, allocChunk)
+		var ptr C.CUdeviceptr
+		if err := cudaError("cuMemAlloc", C.cuMemAlloc(&ptr, C.size_t(chunk))); err != nil {
+			return allocated, err
+		}
+		d.buffers = append(d.buffers, ptr)
+		// Touch the memory so that the driver backs it
+		if err := cudaError("cuMemsetD8", C.cuMemsetD8(ptr, 0xA5, C.size_t(chunk))); err != nil {
+			return allocated, err
+		}
+		allocated += chunk
+	}
+	return allocated, nil
+}
+
+func (d *cudaDevice) compute(duration time.Duration) error {
+	unlock, err := d.current()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	deadline := time.Now().Add(duration)
+	for remaining := duration; remaining > 0; remaining = time.Until(deadline) {
+		start := time.Now()
+		if err := cudaError("cuLaunchKernel", C.launchBurn(d.fn, d.out, d.iterations, d.blocks, threadsPerBlock)); err != nil {
+			return err
+		}
+		// Adapt the kernel length so that one launch takes about launchTarget
+		if elapsed := time.Since(start); elapsed > 0 {
+			scaled := float64(d.iterations) * float64(launchTarget) / float64(elapsed)
+			d.iterations = C.uint(min(max(scaled, 64), 1<<24))
+		}
+	}
+	return nil
+}
+
+func (d *cudaDevice) close() error {
+	unlock, err := d.current()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, ptr := range d.buffers {
+		C.cuMemFree(ptr)
+	}
+	d.buffers = nil
+	C.cuMemFree(d.out)
+	C.cuModuleUnload(d.module)
+	return cudaError("cuDevicePrimaryCtxRelease", C.cuDevicePrimaryCtxRelease(d.dev))
+}
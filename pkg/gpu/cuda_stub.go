@@ -0,0 +1,11 @@
+//go:build !gpu || !cgo
+
+package gpu
+
+// Supported はこのビルドが GPU のバックエンドを含むかどうかです。
+const Supported = false
+
+// openDevice fails in builds without a GPU backend.
+func openDevice(index int) (device, error) {
+	return nil, ErrNotSupported
+}
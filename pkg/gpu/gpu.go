@@ -0,0 +1,270 @@
+// Package gpu は GPU の演算負荷と VRAM の確保による負荷を生成します。
+//
+// GPU を操作するバックエンド (CUDA ドライバー API) は、gpu ビルドタグと cgo を有効にして
+// ビルドした場合にのみ含まれます (go build -tags gpu)。それ以外のビルドでは Supported が false で、
+// Start は ErrNotSupported を返します。
+package gpu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// sampleInterval is how often the achieved utilization is recorded.
+const sampleInterval = 5 * time.Second
+
+// dutyCyclePeriod is the length of one busy/idle cycle for partial load.
+const dutyCyclePeriod = 100 * time.Millisecond
+
+// ErrNotSupported は GPU のバックエンドを含まないビルドで GPU 負荷を開始しようとした場合のエラーです。
+var ErrNotSupported = errors.New("this build has no GPU support; rebuild with -tags gpu (needs cgo and the CUDA driver)")
+
+// device is one GPU opened by the backend. Its methods are called from one
+// goroutine at a time.
+type device interface {
+	// name returns the product name of the GPU.
+	name() string
+	// memoryInfo returns the free and total VRAM in bytes.
+	memoryInfo() (free, total int64, err error)
+	// allocate allocates and touches up to size bytes of VRAM and returns how
+	// much it got, with the error that stopped it short.
+	allocate(size int64) (int64, error)
+	// compute keeps the GPU busy with arithmetic kernels for about d.
+	compute(d time.Duration) error
+	// close releases the VRAM and the device.
+	close() error
+}
+
+// Options は GPU 負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "GPU" を使用します。
+	Name string
+	// Device は負荷をかける GPU の番号 (0 から) です。
+	Device int
+	// Utilization は GPU の演算時間に対する目標使用率 (%、0 より大きく 100 以下) です。
+	Utilization float64
+	// MemorySize は確保する VRAM のサイズ (バイト) です。0 の場合は MemoryPercent に従います。
+	MemorySize int64
+	// MemoryPercent は開始時の空き VRAM に対する確保するサイズの割合 (%) です。
+	// MemorySize と MemoryPercent がともに 0 の場合、VRAM は確保しません。
+	MemoryPercent float64
+	// Budget は実行全体の負荷生成モジュールで共有するメモリの上限です (nil可)。確保する VRAM もこの上限に含めます。
+	Budget *budget.Budget
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if !Supported {
+		return ErrNotSupported
+	}
+	if o.Device < 0 {
+		return fmt.Errorf("GPU device must not be negative")
+	}
+	if o.Utilization <= 0 || o.Utilization > 100 {
+		return fmt.Errorf("GPU utilization must be in range 0-100 (exclusive of 0)")
+	}
+	if o.MemorySize < 0 || o.MemoryPercent < 0 || o.MemoryPercent > 100 {
+		return fmt.Errorf("GPU memory must be a positive size or a percentage in range 0-100")
+	}
+	if o.MemorySize > 0 && o.MemoryPercent > 0 {
+		return fmt.Errorf("specify only one of the GPU memory size and percentage")
+	}
+	return nil
+}
+
+// Result は GPU 負荷の実行結果です。
+type Result struct {
+	// Device は GPU の製品名です。
+	Device string
+	// MemoryBytes は確保した VRAM のサイズ (バイト) です。
+	MemoryBytes int64
+	// MeanUtilization は実行中の平均使用率 (%) です。
+	MeanUtilization float64
+}
+
+// Stats は実行中の GPU 負荷の状態です。
+type Stats struct {
+	// Target は現在の目標使用率 (%) です。
+	Target float64
+	// Achieved は直近の測定での使用率 (%) です。
+	Achieved float64
+	// MemoryBytes は確保している VRAM のサイズ (バイト) です。
+	MemoryBytes int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// Controller は実行中の GPU 負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	dev    device
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+	err    error
+
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	achieved atomic.Uint64 // math.Float64bits of the last measured utilization
+}
+
+// Start は opts に従って GPU 負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// VRAM は開始時に確保し、終了まで保持します。負荷は ctx が終了するか Stop が呼ばれるまで続きます。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "GPU"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	recorder := opts.Recorder
+
+	dev, err := openDevice(opts.Device)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open GPU %d: %v", opts.Device, err)
+	}
+	c := &Controller{opts: opts, dev: dev, done: make(chan struct{}), result: Result{Device: dev.name()}}
+	c.scale.Store(math.Float64bits(1))
+	recorder.Logf("GPU", "Device %d: %s", opts.Device, dev.name())
+
+	if opts.MemorySize > 0 || opts.MemoryPercent > 0 {
+		free, total, err := dev.memoryInfo()
+		if err != nil {
+			dev.close()
+			return nil, fmt.Errorf("cannot read GPU memory: %v", err)
+		}
+		size := opts.MemorySize
+		if opts.MemoryPercent > 0 {
+			size = int64(float64(free) * opts.MemoryPercent / 100)
+		}
+		// The VRAM counts against the memory shared with the other stressors
+		if granted := opts.Budget.Reserve(size); granted < size {
+			limit := opts.Budget.Limit()
+			recorder.Logf("GPU", "VRAM allocation capped from %d MiB to %d MiB by the memory limit", size/(1024*1024), granted/(1024*1024))
+			recorder.Flag("GPU", i18n.Sprintf("VRAM allocation capped by hard memory limit (%d MiB)", limit/(1024*1024)))
+			size = granted
+		}
+		allocated, err := dev.allocate(size)
+		opts.Budget.Release(size - allocated)
+		c.result.MemoryBytes = allocated
+		recorder.AddCount("GPU", "vram_bytes", allocated)
+		recorder.Logf("GPU", "Allocated %d MiB of VRAM (%d MiB free of %d MiB)",
+			allocated/(1024*1024), free/(1024*1024), total/(1024*1024))
+		if err != nil {
+			recorder.Logf("GPU", "VRAM allocation stopped at %d of %d MiB: %v", allocated/(1024*1024), size/(1024*1024), err)
+			recorder.Flag("GPU", "VRAM allocation stopped short of the target")
+		}
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	recorder.Logf("GPU", "Starting compute load at %.0f%% utilization", opts.Utilization)
+	go c.run(ctx)
+	return c, nil
+}
+
+// run alternates compute kernels and idle time in duty cycles until ctx is done,
+// recording the share of time the GPU was busy.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer func() {
+		if err := c.dev.close(); err != nil {
+			recorder.Logf("GPU", "Error releasing the GPU: %v", err)
+		}
+		c.opts.Budget.Release(c.result.MemoryBytes)
+		recorder.Logf("GPU", "Load generation completed")
+	}()
+
+	start := time.Now()
+	sampleStart, sampleBusy := start, time.Duration(0)
+	var totalBusy time.Duration
+	for ctx.Err() == nil {
+		cycleStart := time.Now()
+		if busy := time.Duration(float64(dutyCyclePeriod) * c.ratio()); busy > 0 {
+			if err := c.dev.compute(busy); err != nil {
+				c.err = fmt.Errorf("GPU compute failed: %v", err)
+				return
+			}
+			elapsed := time.Since(cycleStart)
+			sampleBusy += elapsed
+			totalBusy += elapsed
+		}
+		recorder.AddCount("GPU", "cycles", 1)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(dutyCyclePeriod - time.Since(cycleStart)):
+		}
+
+		if now := time.Now(); now.Sub(sampleStart) >= sampleInterval {
+			achieved := float64(sampleBusy) / float64(now.Sub(sampleStart)) * 100
+			c.achieved.Store(math.Float64bits(achieved))
+			recorder.Record("GPU", metrics.UnitPercent, c.ratio()*100, achieved)
+			sampleStart, sampleBusy = now, 0
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 0 {
+		c.result.MeanUtilization = float64(totalBusy) / float64(elapsed) * 100
+	}
+}
+
+// SetScale は Options で指定した目標使用率に掛ける係数を変更します (1.0 で指定どおり)。
+// 確保した VRAM は変わりません。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで演算負荷を止めます。確保した VRAM は保持します。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了して GPU を解放するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, c.err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:      c.ratio() * 100,
+		Achieved:    math.Float64frombits(c.achieved.Load()),
+		MemoryBytes: c.result.MemoryBytes,
+		Paused:      c.paused.Load(),
+	}
+}
+
+// ratio returns the share of each duty cycle the GPU should be busy.
+func (c *Controller) ratio() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return min(c.opts.Utilization/100*math.Float64frombits(c.scale.Load()), 1)
+}
@@ -0,0 +1,15 @@
+package gpu
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "gpu",
+		Name:        "cuda",
+		Usage:       "--gpu <percent>",
+		Description: "Compute kernel and VRAM allocation through the CUDA driver API (NVIDIA)",
+		Available:   Supported,
+		Requirement: "a build with -tags gpu (cgo and the CUDA driver)",
+	})
+}
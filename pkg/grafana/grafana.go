@@ -0,0 +1,98 @@
+package grafana
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds each call so an unreachable Grafana never delays the stress test.
+const requestTimeout = 10 * time.Second
+
+// Client は Grafana annotations API へ注釈を送信します。
+type Client struct {
+	baseURL    string
+	token      string
+	tags       []string
+	httpClient *http.Client
+}
+
+// annotation is the request body of the Grafana annotations API.
+type annotation struct {
+	Time    int64    `json:"time,omitempty"`
+	TimeEnd int64    `json:"timeEnd,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Text    string   `json:"text,omitempty"`
+}
+
+// NewClient は Grafana の URL、API トークン、全注釈に付与するタグから Client を作成します。
+func NewClient(baseURL, token string, tags []string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		tags:       tags,
+		httpClient: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Start は開始時刻のみを持つ注釈を作成し、その ID を返します。
+// 終了時には End を呼び出して注釈を期間（region）に変換します。
+func (c *Client) Start(text string, tags ...string) (int64, error) {
+	body := annotation{
+		Time: time.Now().UnixMilli(),
+		Tags: append(append([]string(nil), c.tags...), tags...),
+		Text: text,
+	}
+
+	var response struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do(http.MethodPost, "/api/annotations", body, &response); err != nil {
+		return 0, err
+	}
+	return response.ID, nil
+}
+
+// End は Start で作成した注釈に終了時刻を設定します。
+func (c *Client) End(id int64) error {
+	body := annotation{TimeEnd: time.Now().UnixMilli()}
+	return c.do(http.MethodPatch, fmt.Sprintf("/api/annotations/%d", id), body, nil)
+}
+
+// do sends a JSON request and decodes the JSON response into out when non-nil.
+func (c *Client) do(method, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode annotation: %v", err)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("annotation request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("annotation request failed: %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode annotation response: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,824 @@
+package i18n
+
+// japanese is the Japanese catalog. Keys must match the English format strings
+// exactly, including leading spaces and trailing newlines, and each translation
+// must keep the verbs of its key (reordered with explicit indexes if needed).
+var japanese = map[string]string{
+	// Labels matched by other stress-go processes
+	"Progress:": "進捗:",
+	"Error:":    "エラー:",
+	"Warning:":  "警告:",
+
+	// Run
+	"Starting stress test...\n":                                              "負荷テストを開始します...\n",
+	"Duration: %v\n":                                                         "実行時間: %v\n",
+	"%s. Stopping stress test...\n":                                          "%s。負荷テストを停止します...\n",
+	"\n[Watchdog] %s: ramping down the load over %v\n":                       "\n[Watchdog] %s: %v かけて負荷を下げます\n",
+	"[Watchdog] Abort condition met: %s. Stopping stress test...\n":          "[Watchdog] 中止条件を満たしました: %s。負荷テストを停止します...\n",
+	"[%s] Error: %s\n":                                                       "[%s] エラー: %s\n",
+	"Interrupt signal received":                                              "割り込みシグナルを受信しました",
+	"%s failed (--fail-fast)":                                                "%s が失敗しました (--fail-fast)",
+	"%s crashed":                                                             "%s がクラッシュしました",
+	"Stress test cancelled before start.":                                    "負荷テストは開始前にキャンセルされました。",
+	"Stress test not started.":                                               "負荷テストは開始されませんでした。",
+	"Stress test stopped before cleanup finished.":                           "負荷テストは後片付けの完了前に停止しました。",
+	"Stress test aborted by watchdog: %s":                                    "負荷テストはウォッチドッグにより中止されました: %s",
+	"Stress test stopped because a stressor crashed.":                        "負荷生成モジュールがクラッシュしたため負荷テストを停止しました。",
+	"Stress test failed: a stressor could not start.":                        "負荷テストに失敗しました: 負荷生成モジュールを開始できませんでした。",
+	"Stress test failed: %d stressor(s) returned an error.":                  "負荷テストに失敗しました: %d 個の負荷生成モジュールがエラーを返しました。",
+	"Stress test completed with failures.":                                   "負荷テストは完了しましたが、目標を達成できない項目がありました。",
+	"Stress test completed.":                                                 "負荷テストが完了しました。",
+	"Stress test failed: verification found %d error(s).":                    "負荷テストに失敗しました: 検証で %d 件のエラーが見つかりました。",
+	"Stress test failed: %s violated in more windows than the budget of %s.": "負荷テストに失敗しました: %s の違反が許容数 %s を超えました。",
+	"Waiting until %s to start (in %v)...\n":                                 "%s まで開始を待機しています (あと %v)...\n",
+	"Measuring the idle baseline for %v...\n":                                "負荷をかける前のベースラインを %v 計測しています...\n",
+	"Start barrier released %v after the scheduled time\n\n":                 "予定時刻から %v 後に開始しました\n\n",
+	"Run time changed by %v, now ending at %s (remaining %v)":                "実行時間を %v 変更しました。%s に終了します (残り %v)",
+	"Target changed from %s to %s":                                           "目標値を %s から %s に変更しました",
+	"Load paused":                                                            "負荷を一時停止しました",
+	"Load resumed":                                                           "負荷を再開しました",
+	"Load level set to %g":                                                   "負荷レベルを %g に設定しました",
+	"Load of %s paused":                                                      "%s の負荷を一時停止しました",
+	"Load of %s resumed":                                                     "%s の負荷を再開しました",
+	"Load level of %s set to %g":                                             "%s の負荷レベルを %g に設定しました",
+	"HTML report written to %s\n":                                            "HTML レポートを %s に書き込みました\n",
+	"Serving HTTP endpoints on http://%s\n":                                  "HTTP エンドポイントを http://%s で公開しています\n",
+	"Replaying profile: peak CPU %.0f%%, peak memory +%d MiB, peak disk +%d MiB\n": "プロファイルを再生します: CPU 最大 %.0f%%、メモリ最大 +%d MiB、ディスク最大 +%d MiB\n",
+
+	// Progress line
+	" %.1f%% (Remaining: %v, ends at %s)": " %.1f%% (残り: %v、%s に終了)",
+	" | %s":                               " | %s",
+	" | %s (%.0f%% done, %v left)":        " | %s (%.0f%% 完了、残り %v)",
+	" [%s: %v left]":                      " [%s: 残り %v]",
+	" [%s: done]":                         " [%s: 完了]",
+
+	// Load settings
+	" (for %v)": " (%v 間)",
+	"Replay profile: %s (host %s, %v, %d samples)":        "再生するプロファイル: %s (ホスト %s、%v、%d サンプル)",
+	"CPU load: all cores":                                 "CPU 負荷: 全コア",
+	"CPU load: %d cores%s":                                "CPU 負荷: %d コア%s",
+	"CPU load: all cores at %g%%%s":                       "CPU 負荷: 全コア、使用率 %g%%%s",
+	"CPU load: %d cores at %g%%%s":                        "CPU 負荷: %d コア、使用率 %g%%%s",
+	"Memory load: %s%s":                                   "メモリ負荷: %s%s",
+	"Storage load: %s%s":                                  "ストレージ負荷: %s%s",
+	"GPU load: %.0f%% utilization of GPU %d%s":            "GPU 負荷: GPU %[2]d の使用率 %.0[1]f%%%[3]s",
+	"GPU memory: %s":                                      "GPU メモリ: %s",
+	"Plugin load: %s (%s)%s":                              "プラグイン負荷: %s (%s)%s",
+	"Job %s: CPU load on all cores":                       "ジョブ %s: CPU 負荷 全コア",
+	"Job %s: CPU load on %d cores":                        "ジョブ %s: CPU 負荷 %d コア",
+	"Job %s: memory load of %s":                           "ジョブ %s: メモリ負荷 %s",
+	"Job %s: storage load of %s in %s":                    "ジョブ %s: ストレージ負荷 %s (%s)",
+	"Job %s: storage load of %s":                          "ジョブ %s: ストレージ負荷 %s",
+	"Environment: ":                                       "実行環境: ",
+	"Load pattern: ":                                      "負荷パターン: ",
+	"Work/rest interval: ":                                "稼働と休止の周期: ",
+	"work %v, rest %v":                                    "稼働 %v、休止 %v",
+	" (memory retained while resting)":                    " (休止中もメモリを保持)",
+	"Verification: %s":                                    "検証: %s",
+	"Bookkeeping: confined to CPU %d, away from the load": "計測・表示処理: CPU %d で負荷と分けて実行",
+	"Thermal soak: holding the CPU at %.1f°C":             "サーマルソーク: CPU を %.1f°C に保持",
+	"Chaos: %s about every %v (seed %d)":                  "カオス: 約 %[2]v ごとに %[1]s (シード %[3]d)",
+	"Random seed: %d":                                     "乱数のシード: %d",
+	"Probes: %s every %v":                                 "プローブ: %[2]v ごとに %[1]s",
+	"SLOs: %s every %v":                                   "SLO: %[2]v ごとに %[1]s",
+	", failing beyond %s violated windows":                "、違反が %s 区間を超えたら失敗",
+	"Baseline: %v idle before the load, watching PID %d":  "ベースライン: 負荷をかける前に %v 計測 (PID %d を監視)",
+	"Baseline: %v idle before the load":                   "ベースライン: 負荷をかける前に %v 計測",
+	"%s of %s":                                            "%[2]sの%[1]s",
+	"free memory":                                         "空きメモリ",
+	"free VRAM":                                           "空き VRAM",
+	"free disk space":                                     "ディスクの空き容量",
+
+	// Results
+	"Stressor errors:\n":      "負荷生成モジュールのエラー:\n",
+	"\nTarget vs achieved:\n": "\n目標値と実績値:\n",
+	"  [%s] %s: target %s, achieved %s (min %s, max %s), deviation mean %+.1f%% / max %+.1f%%\n": "  [%s] %s: 目標 %s、実績 %s (最小 %s、最大 %s)、乖離 平均 %+.1f%% / 最大 %+.1f%%\n",
+	"  [%s] %s: no samples recorded\n": "  [%s] %s: サンプルが記録されていません\n",
+	"Per-worker CPU time:\n":           "ワーカーごとの CPU 時間:\n",
+	"  [%s] %d workers: mean %.1f%%, lowest %.1f%% (worker %d), highest %.1f%% (worker %d)\n": "  [%s] ワーカー %d 個: 平均 %.1f%%、最低 %.1f%% (ワーカー %d)、最高 %.1f%% (ワーカー %d)\n",
+	"    ! worker %d: %.1f%% of a core against a target of %.1f%% (min %.1f%%)\n":             "    ! ワーカー %d: 目標 %.1[3]f%% に対して 1 コアの %.1[2]f%% (最小 %.1[4]f%%)\n",
+	"Verification:\n":                   "検証:\n",
+	"  [%s] %s: %d checks, %d errors\n": "  [%s] %s: 検証 %d 回、エラー %d 件\n",
+	"Power: not measured (%v)\n":        "消費電力: 計測しません (%v)\n",
+	"Power (RAPL):\n":                   "消費電力 (RAPL):\n",
+	"  %s: %.1f W average, %.0f J\n":    "  %s: 平均 %.1f W、%.0f J\n",
+	"  Total: package %.1f W, DRAM %.1f W average, %.0f J (%.3f kWh)\n": "  合計: 平均 パッケージ %.1f W、DRAM %.1f W、%.0f J (%.3f kWh)\n",
+	"Self overhead:\n": "stress-go 自身の使用量:\n",
+	"  CPU: %.3f cores (Go runtime %.2fs; bookkeeping not measured: %v)\n": "  CPU: %.3f コア (Go ランタイム %.2f 秒、計測・表示処理は計測できません: %v)\n",
+	"  CPU: %.3f cores (bookkeeping %.2fs, Go runtime %.2fs)\n":            "  CPU: %.3f コア (計測・表示処理 %.2f 秒、Go ランタイム %.2f 秒)\n",
+	"  Share of the process CPU time: %.2f%%\n":                            "  プロセス全体の CPU 時間に占める割合: %.2f%%\n",
+	"  Memory: %s at peak, beyond the memory load\n":                       "  メモリ: 最大 %s (メモリ負荷を除く)\n",
+	"  Bookkeeping confined to CPU %d\n":                                   "  計測・表示処理は CPU %d で実行しました\n",
+	"Injected faults (seed %d):\n":                                         "注入した障害 (シード %d):\n",
+	"  [%s] %s: %d injected, %d recovered\n":                               "  [%s] %s: 注入 %d 回、回復 %d 回\n",
+	"Probe latency:\n":                                                     "プローブのレイテンシ:\n",
+	"  %s: p50 %v, p99 %v, max %v (%d samples)":                            "  %s: p50 %v、p99 %v、最大 %v (%d サンプル)",
+	", idle p99 %v":                         "、アイドル時 p99 %v",
+	"SLOs (%v windows):\n":                  "SLO (%v ごとの区間):\n",
+	"  %s: %d of %d windows violated":       "  %s: %[3]d 区間中 %[2]d 区間で違反",
+	" (first at %s, last at %s, worst %s)":  " (最初 %s、最後 %s、最悪値 %s)",
+	"Change from the idle baseline (%v):\n": "ベースライン (%v) からの変化:\n",
+	"CPU utilization":                       "CPU 使用率",
+	"Load average (1m)":                     "ロードアベレージ (1分)",
+	"CPU of PID %d":                         "PID %d の CPU",
+	"%+.1f points":                          "%+.1f ポイント",
+
+	// Burn-in certificate
+	"stress-go burn-in certificate": "stress-go バーンイン試験証明書",
+	"Host:":                         "ホスト:",
+	"System:":                       "システム:",
+	"CPU:":                          "CPU:",
+	"Tool:":                         "ツール:",
+	"Started:":                      "開始:",
+	"Finished:":                     "終了:",
+	"Duration:":                     "実行時間:",
+	"Load:":                         "負荷:",
+	"%s/%s, %d CPUs":                "%s/%s、%d CPU",
+	", %s memory":                   "、メモリ %s",
+	"%v of %v planned":              "%v (予定 %v)",
+	"Stressor":                      "負荷生成モジュール",
+	"Achieved load":                 "実績負荷",
+	"Checks":                        "検証回数",
+	"Errors":                        "エラー",
+	"Result":                        "結果",
+	"Result:":                       "判定:",
+	"Certificate written to %s\n":   "証明書を %s に書き込みました\n",
+
+	// Errors and warnings
+	"Error: %v\n":                           "エラー: %v\n",
+	"Error: %v":                             "エラー: %v",
+	"Warning: %v\n":                         "警告: %v\n",
+	"Error: --timeout option is required\n": "エラー: --timeout オプションは必須です\n",
+	"Error: --profile option is required in replay mode\n":   "エラー: replay モードでは --profile オプションが必須です\n",
+	"Error: --certificate can only be used in burnin mode\n": "エラー: --certificate は burnin モードでのみ使用できます\n",
+	"Error: --soak-temp needs a positive temperature and --cpu, and cannot be combined with a CPU load percentage, --pattern or replay mode\n": "エラー: --soak-temp には正の温度と --cpu の指定が必要で、CPU 負荷の使用率、--pattern や replay モードとは併用できません\n",
+	"Error: --soak-temp needs a CPU temperature sensor: %v\n":                                                                                  "エラー: --soak-temp には CPU の温度センサーが必要です: %v\n",
+	"Error: --chaos must not be negative\n":                                                                                                    "エラー: --chaos に負の値は指定できません\n",
+	"Error: --probe-interval must not be negative\n":                                                                                           "エラー: --probe-interval に負の値は指定できません\n",
+	"Error: --pprof needs --listen\n":                                                                                                          "エラー: --pprof には --listen の指定が必要です\n",
+	"Error: --pprof-interval must be positive\n":                                                                                               "エラー: --pprof-interval には正の値を指定してください\n",
+	"Error: Cannot create the profile directory: %v\n":                                                                                         "エラー: プロファイルのディレクトリを作成できません: %v\n",
+	"Warning: Cannot write CPU profile: %v\n":                                                                                                  "警告: CPU プロファイルを書き込めません: %v\n",
+	"Warning: Cannot write heap profile: %v\n":                                                                                                 "警告: ヒーププロファイルを書き込めません: %v\n",
+	"Error: SLO %s needs the %s load\n":                                                                                                        "エラー: SLO %s には %s の負荷が必要です\n",
+	"Error: --baseline must not be negative, and --victim needs --baseline\n":                                                                  "エラー: --baseline に負の値は指定できず、--victim には --baseline の指定が必要です\n",
+	"Error: Cannot watch the victim process: %v\n":                                                                                             "エラー: 監視対象のプロセスを監視できません: %v\n",
+	"Error: Failed to write certificate: %v\n":                                                                                                 "エラー: 証明書を書き込めませんでした: %v\n",
+	"Error: Invalid time format: %v\n":                                                                                                         "エラー: 時間の形式が正しくありません: %v\n",
+	"Error: Invalid --textfile-dir: %v\n":                                                                                                      "エラー: --textfile-dir が正しくありません: %v\n",
+	"Error: Invalid --start-at time: %v\n":                                                                                                     "エラー: --start-at の時刻が正しくありません: %v\n",
+	"Error: At least one load type must be specified\n":                                                                                        "エラー: 負荷の種類を 1 つ以上指定してください\n",
+	"Error: --pattern requires --cpu or --memory and cannot be used in replay mode\n":                                                          "エラー: --pattern には --cpu または --memory が必要で、replay モードでは使用できません\n",
+	"Warning: The run ends at %v, before the last step of the pattern starts at %v\n":                                                          "警告: 実行は %v で終了し、%v に始まるパターンの最後のステップに到達しません\n",
+	"Error: Invalid --memory: %v\n":                                                                                                            "エラー: --memory が正しくありません: %v\n",
+	"Error: Invalid --gpu-memory: %v\n":                                                                                                        "エラー: --gpu-memory が正しくありません: %v\n",
+	"Error: Invalid --gpu: %v\n":                                                                                                               "エラー: --gpu が正しくありません: %v\n",
+	"Error: --gpu needs a build with GPU support: go build -tags gpu (needs cgo and the CUDA driver)\n":                                        "エラー: --gpu には GPU 対応のビルドが必要です: go build -tags gpu (cgo と CUDA ドライバーが必要)\n",
+	"Error: Invalid --storage: %v\n":                                                                                                           "エラー: --storage が正しくありません: %v\n",
+	"Error: --max-cpu-percent must be in range 0-100\n":                                                                                        "エラー: --max-cpu-percent は 0 から 100 の範囲で指定してください\n",
+	"Error: Invalid --max-memory: %v\n":                                                                                                        "エラー: --max-memory が正しくありません: %v\n",
+	"Error: Invalid --max-disk: %v\n":                                                                                                          "エラー: --max-disk が正しくありません: %v\n",
+	"Error: Cleanup did not finish within %v\n":                                                                                                "エラー: 後片付けが %v 以内に完了しませんでした\n",
+	"Error: Second stop signal received, exiting without finishing cleanup\n":                                                                  "エラー: 2 回目の停止シグナルを受信したため、後片付けを完了せずに終了します\n",
+	"Error: Failed to write HTML report: %v\n":                                                                                                 "エラー: HTML レポートを書き込めませんでした: %v\n",
+	"Error: Failed to write summary: %v\n":                                                                                                     "エラー: サマリーを書き込めませんでした: %v\n",
+	"Error: Cannot listen on %s: %v\n":                                                                                                         "エラー: %s で待ち受けできません: %v\n",
+	"Error: Cannot locate the stress-go executable: %v\n":                                                                                      "エラー: stress-go の実行ファイルが見つかりません: %v\n",
+	"Warning: Could not prevent system sleep: %v\n":                                                                                            "警告: システムのスリープを抑止できませんでした: %v\n",
+	"Warning: Failed to create Grafana annotation: %v\n":                                                                                       "警告: Grafana のアノテーションを作成できませんでした: %v\n",
+	"Warning: Failed to update Grafana annotation: %v\n":                                                                                       "警告: Grafana のアノテーションを更新できませんでした: %v\n",
+	"Warning: Failed to send notification: %v\n":                                                                                               "警告: 通知を送信できませんでした: %v\n",
+	"Warning: %s are falling behind, dropping the %s event\n":                                                                                  "警告: %s が遅れているため、%s のイベントを破棄します\n",
+	"Warning: Gave up waiting for %s to be sent\n":                                                                                             "警告: %s の送信の完了を待たずに終了します\n",
+	"notifications":       "通知",
+	"Grafana annotations": "Grafana の注釈",
+	"Warning: Cloud instance metadata not available: %v\n":                                                            "警告: クラウドのインスタンスメタデータを取得できません: %v\n",
+	"Error: Health check failed: %v\n":                                                                                "エラー: ヘルスチェックに失敗しました: %v\n",
+	"Warning: Failed to write textfile metrics: %v\n":                                                                 "警告: textfile メトリクスを書き込めませんでした: %v\n",
+	"Warning: cannot evaluate %s: %v":                                                                                 "警告: %s を評価できません: %v",
+	"Warning: --cpu %d exceeds the cgroup CPU quota of %.2f cores; the load will be throttled\n":                      "警告: --cpu %d は cgroup の CPU クォータ %.2f コアを超えています。負荷は制限されます\n",
+	"Warning: --memory %d MiB exceeds the %d MiB left under the cgroup memory limit; the process may be OOM-killed\n": "警告: --memory %d MiB は cgroup のメモリ上限までの残り %d MiB を超えています。プロセスが OOM Killer に停止される可能性があります\n",
+	"Warning: --storage %g%% is a share of the free space of the filesystem behind the container; " +
+		"it may exceed an ephemeral storage limit and get the container evicted\n": "警告: --storage %g%% はコンテナの背後にあるファイルシステムの空き容量に対する割合です。" +
+		"エフェメラルストレージの上限を超え、コンテナが退避される可能性があります\n",
+	"Warning: No cgroup memory limit could be read (%v); --memory %g%% is a share of the host's free memory\n": "警告: cgroup のメモリ上限を読み取れませんでした (%v)。--memory %g%% はホストの空きメモリに対する割合になります\n",
+
+	// Dry run
+	"Dry run: the options are valid, no load will be applied.": "ドライラン: オプションは有効です。負荷はかけません。",
+	"Resolved on this host:":                                   "このホストでの解決結果:",
+	"  Memory: cannot read available memory: %v\n":             "  メモリ: 利用可能なメモリを読み取れません: %v\n",
+	"  Memory: %v\n": "  メモリ: %v\n",
+	"  Memory: %d bytes (%s) now, %s of %s free with a safety margin\n": "  メモリ: 現在 %d バイト (%s)、空き %[4]s の %[3]s (安全マージン込み)\n",
+	"  Memory: %d bytes (%s), %s free\n":                                "  メモリ: %d バイト (%s)、空き %s\n",
+	"  Storage: cannot read free space of %s: %v\n":                     "  ストレージ: %s の空き容量を読み取れません: %v\n",
+	"  Storage: %v\n": "  ストレージ: %v\n",
+	"  Storage: %d bytes (%s) in %s, %s of %s free with a safety margin\n": "  ストレージ: %[3]s に %[1]d バイト (%[2]s)、空き %[5]s の %[4]s (安全マージン込み)\n",
+	"  Storage: %d bytes (%s) in %s, %s free\n":                            "  ストレージ: %[3]s に %[1]d バイト (%[2]s)、空き %[4]s\n",
+	"  CPU: %.2f usable cores\n":                                           "  CPU: 使用可能 %.2f コア\n",
+
+	// CPU
+	"Starting load generation on %d cores":                           "%d コアで負荷生成を開始します",
+	"Starting variable load generation on %d cores":                  "%d コアで可変負荷の生成を開始します",
+	"Starting load generation at %g%% on %d cores":                   "使用率 %g%% で %d コアの負荷の生成を開始します",
+	"Using the %d of %d cores left by the CPU affinity":              "CPU アフィニティで使用できる %[2]d コア中 %[1]d コアを使用します",
+	"Using %d of %d cores within the cgroup CPU limit of %.2f cores": "cgroup の CPU 上限 %.2[3]f コアに収まるよう %[2]d コア中 %[1]d コアを使用します",
+	"CPU topology: %s":    "CPU の構成: %s",
+	"Vector workload: %s": "ベクトル演算の負荷: %s",
+	"Pinning workers to the fastest cores first: CPUs %v":                           "性能の高いコアから順にワーカーを固定します: CPU %v",
+	"Restarting worker %d":                                                          "ワーカー %d を再起動します",
+	"worker %d":                                                                     "ワーカー %d",
+	"Cannot pin worker %d to CPU %d: %v":                                            "ワーカー %d を CPU %d に固定できません: %v",
+	"Verifying floating point results on every core":                                "すべてのコアで浮動小数点演算の結果を検証します",
+	"worker %d: FP result %#016x, expected %#016x":                                  "ワーカー %d: 浮動小数点演算の結果 %#016x、期待値 %#016x",
+	"worker %d on CPU %d: FP result %#016x, expected %#016x":                        "CPU %[2]d のワーカー %[1]d: 浮動小数点演算の結果 %#016[3]x、期待値 %#016[4]x",
+	"Verification error: %s":                                                        "検証エラー: %s",
+	"Load generation completed":                                                     "負荷生成が完了しました",
+	"Starting load generation on core %d":                                           "コア %d で負荷生成を開始します",
+	"Stopping load generation on core %d":                                           "コア %d の負荷生成を停止します",
+	"Final result: %d":                                                              "最終結果: %d",
+	"allocated memory not resident (swapped out or reclaimed)":                      "確保したメモリが物理メモリ上にありません (スワップアウトまたは回収)",
+	"CPU time below target (CPU quota or throttling)":                               "CPU 時間が目標を下回っています (CPU クォータまたはスロットリング)",
+	"Load capped at %.0f%% of usable CPU (%.2f cores)":                              "負荷を使用可能な CPU の %.0f%% (%.2f コア) に制限します",
+	"load capped by hard CPU limit (%.0f%%)":                                        "CPU のハードリミット (%.0f%%) により負荷を制限",
+	"Load capped at %.2f cores, the rest of the %.2f cores shared by all CPU loads": "負荷を %.2f コアに制限します (すべての CPU 負荷で共有する %.2f コアの残り)",
+	"load capped by hard CPU limit (%.2f cores)":                                    "CPU のハードリミット (%.2f コア) により負荷を制限",
+	"Thermal failsafe inactive: %v":                                                 "温度フェイルセーフは無効です: %v",
+	"Load average ceiling inactive: %v":                                             "ロードアベレージの上限は無効です: %v",
+	"Thermal failsafe: %.1f°C below critical, reducing load to %.0f%%":              "温度フェイルセーフ: 臨界温度まで %.1f°C のため負荷を %.0f%% に下げます",
+	"load reduced by thermal failsafe near critical temperature":                    "臨界温度に近づいたため温度フェイルセーフにより負荷を低減",
+	"Thermal failsafe: temperature recovered, restoring full load":                  "温度フェイルセーフ: 温度が下がったため負荷を元に戻します",
+	"Thermal soak: modulating load to hold %.1f°C":                                  "サーマルソーク: %.1f°C を保つように負荷を調整します",
+	"Thermal soak: reached %.1f°C after %v":                                         "サーマルソーク: %[2]v 後に %.1[1]f°C に達しました",
+	"Thermal soak: %.1f°C was not reached, the peak was %.1f°C":                     "サーマルソーク: %.1f°C に達しませんでした (最高 %.1f°C)",
+	"Thermal soak: held within %.0f°C of %.1f°C for %.0f%% of the time after reaching it (peak %.1f°C)": "サーマルソーク: 到達後の %.0[3]f%% の時間を %.1[2]f°C ±%.0[1]f°C 以内に保持しました (最高 %.1[4]f°C)",
+	"Load average %.2f exceeds %.2f, reducing load":                                                     "ロードアベレージ %.2f が %.2f を超えたため負荷を下げます",
+	"load reduced to keep load average at or below %.2f":                                                "ロードアベレージを %.2f 以下に保つため負荷を低減",
+	"Load average %.2f within limit, restoring full load":                                               "ロードアベレージ %.2f が上限内に戻ったため負荷を元に戻します",
+	"Per-worker CPU accounting inactive: %v":                                                            "ワーカーごとの CPU 時間の計測は無効です: %v",
+
+	// Memory
+	"Starting dynamic load generation with %.1f%% of free memory":               "空きメモリの %.1f%% で動的な負荷生成を開始します",
+	"Starting variable load generation":                                         "可変負荷の生成を開始します",
+	"Starting load generation with %d MiB":                                      "%d MiB で負荷生成を開始します",
+	"Error recalculating size: %v":                                              "サイズの再計算でエラーが発生しました: %v",
+	"Increased allocation by %d MiB (total: %d MiB)":                            "確保量を %d MiB 増やしました (合計: %d MiB)",
+	"released chunk %d (%d MiB)":                                                "チャンク %d (%d MiB) を解放",
+	"Decreased allocation by %d MiB (total: %d MiB)":                            "確保量を %d MiB 減らしました (合計: %d MiB)",
+	"Stopping memory load generation":                                           "メモリ負荷の生成を停止します",
+	"Allocation of %d MiB capped to %d MiB by the memory limit":                 "確保量 %d MiB をメモリの上限により %d MiB に制限しました",
+	"allocation capped by hard memory limit (%d MiB)":                           "メモリのハードリミット (%d MiB) により確保量を制限",
+	"Allocated: %d MiB, System usage: %d MiB, Heap size: %d MiB":                "確保量: %d MiB、システム使用量: %d MiB、ヒープサイズ: %d MiB",
+	"Verifying allocated memory with test patterns":                             "確保したメモリをテストパターンで検証します",
+	"chunk %d: %d bad words, first at offset %d (expected %#016x, read %#016x)": "チャンク %d: 不一致 %d ワード、最初はオフセット %d (期待値 %#016x、読み込み値 %#016x)",
+
+	// Storage
+	"Starting dynamic load generation with %.1f%% of free disk space": "ディスクの空き容量の %.1f%% で動的な負荷生成を開始します",
+	"Storage load generation completed":                               "ストレージ負荷の生成が完了しました",
+	"Temporary directory: %s":                                         "一時ディレクトリ: %s",
+	"Cleaned up temporary files":                                      "一時ファイルを削除しました",
+	"Container detected: %s is on overlayfs, using the volume at %s":  "コンテナを検出しました: %s は overlayfs 上にあるため %s のボリュームを使用します",
+	"Container detected: %s is on overlayfs and no writable volume was found; set TMPDIR to a mounted volume to test a real disk": "コンテナを検出しました: %s は overlayfs 上にあり、書き込み可能なボリュームが見つかりません。実ディスクを試験するには TMPDIR にマウントしたボリュームを指定してください",
+	"files written to the container's overlayfs layer":                                                      "コンテナの overlayfs レイヤーに書き込み",
+	"Error writing additional file: %v":                                                                     "追加ファイルの書き込みでエラーが発生しました: %v",
+	"Error: Invalid --storage-sync: %v\n":                                                                   "エラー: --storage-sync が無効です: %v\n",
+	"Error: Invalid --cpu-method: %v\n":                                                                     "エラー: --cpu-method が無効です: %v\n",
+	"Error: --cpu-bignum-bits must be in range %d-%d\n":                                                     "エラー: --cpu-bignum-bits には %d〜%d を指定してください\n",
+	"CPU method: %d-bit modular exponentiation":                                                             "CPU の演算方式: %d ビットのべき剰余",
+	"Error: Invalid --overhead-cpu: %v\n":                                                                   "エラー: --overhead-cpu が無効です: %v\n",
+	"Error: Invalid --memory-content: %v\n":                                                                 "エラー: --memory-content が無効です: %v\n",
+	"Error: --memory-verify writes its own test patterns and cannot be combined with --memory-content\n":    "エラー: --memory-verify は独自のテストパターンを書き込むため、--memory-content と同時には指定できません\n",
+	"Memory content: %s":                                                                                    "メモリのデータ: %s",
+	"compressible, a short sequence repeated over every page":                                               "圧縮しやすいデータ、各ページで短いデータを繰り返し",
+	"incompressible, random data":                                                                           "圧縮できないデータ、乱数",
+	"mixed, half random and half repeated data in every page":                                               "混在、各ページの半分が乱数で残りは繰り返し",
+	"Filling allocated memory with %s data":                                                                 "確保したメモリを %s のデータで埋めます",
+	"Storage operations: stat storms, byte-range locks and small fsync'ed writes added":                     "ストレージの操作: 属性の取得の連続・バイト範囲ロック・fsync 付きの小さな書き込みを追加",
+	"%s is on %s: adding stat, lock and small synchronous write operations":                                 "%s は %s 上にあるため、属性の取得・ロック・小さな同期書き込みの操作を追加します",
+	"Adding stat, lock and small synchronous write operations":                                              "属性の取得・ロック・小さな同期書き込みの操作を追加します",
+	"Warning: free space reported by %s may be shared or stale, so the percentage target may be inaccurate": "警告: %s が報告する空き容量は共有されているか古い可能性があるため、パーセンテージの目標は不正確なことがあります",
+	"percentage of free space on %s":                                                                        "%s の空き容量に対するパーセンテージ",
+	"%d metadata, lock or small write operations failed":                                                    "メタデータ・ロック・小さな書き込みの操作が %d 回失敗しました",
+	"Storage writes: %s":                                                                                    "ストレージの書き込み: %s",
+	"O_DSYNC, every write waits for its data to reach the disk":                                             "O_DSYNC、書き込みごとにデータがディスクに届くまで待機",
+	"O_SYNC, every write waits for its data and metadata to reach the disk":                                 "O_SYNC、書き込みごとにデータとメタデータがディスクに届くまで待機",
+	"buffered, with an fsync after each file":                                                               "バッファ経由、ファイルごとに fsync",
+	"Increased disk usage by %d MiB (total: %d MiB)":                                                        "ディスク使用量を %d MiB 増やしました (合計: %d MiB)",
+	"Decreased disk usage by %d MiB (total: %d MiB)":                                                        "ディスク使用量を %d MiB 減らしました (合計: %d MiB)",
+	"Read error: %v":   "読み込みエラー: %v",
+	"Append error: %v": "追記エラー: %v",
+	"I/O operation %d completed (%d files active)":                              "I/O 操作 %d が完了しました (使用中のファイル %d 個)",
+	"no space left on device (ENOSPC)":                                          "デバイスに空き容量がありません (ENOSPC)",
+	"writes capped by hard disk limit (%d MiB)":                                 "ディスクのハードリミット (%d MiB) により書き込みを制限",
+	"Writing checksummed blocks and verifying them on every read":               "チェックサム付きのブロックを書き込み、読み込みのたびに検証します",
+	"%d more bad blocks in %s":                                                  "%[2]s にはほかに %[1]d 個の不正なブロックがあります",
+	"checksum mismatch (stored %08x, computed %08x)":                            "チェックサムの不一致 (記録値 %08x、計算値 %08x)",
+	"not a verification block (magic %08x)":                                     "検証用のブロックではありません (マジック %08x)",
+	"holds block %d of file %d":                                                 "ファイル %[2]d のブロック %[1]d が書かれています",
+	"truncated block":                                                           "途中で切れたブロック",
+	"%s block %d (offset %d): %s":                                               "%s のブロック %d (オフセット %d): %s",
+	"%s block %d":                                                               "%s のブロック %d",
+	"Detected the injected corruption of %s block %d":                           "注入した %s のブロック %d の破損を検出しました",
+	"Cannot repair %s block %d: %v":                                             "%s のブロック %d を修復できません: %v",
+	"Final integrity scan of %d files...":                                       "終了時の整合性の検証: %d 個のファイルを検証しています...",
+	"Final integrity scan passed: %d blocks in %d files":                        "終了時の整合性の検証に合格しました: %[2]d 個のファイルの %[1]d ブロック",
+	"Final integrity scan FAILED: %d bad of %d blocks in %d files":              "終了時の整合性の検証に不合格: %[3]d 個のファイルの %[2]d ブロックのうち %[1]d ブロックが不正",
+	"... and %d more bad blocks":                                                "... ほかに %d 個の不正なブロック",
+	"Bad block: %s":                                                             "不正なブロック: %s",
+	"final integrity scan found bad blocks":                                     "終了時の整合性の検証で不正なブロックを検出",
+	"%s: read error after block %d: %v":                                         "%s: ブロック %d の後で読み込みエラー: %v",
+	"Cannot drop the page cache after writing, reads may be served from it: %v": "書き込み後にページキャッシュを破棄できません。読み込みはキャッシュから返される可能性があります: %v",
+	"Dropped the page cache after writing %d MiB":                               "%d MiB の書き込み後にページキャッシュを破棄しました",
+
+	// list
+	"CPU methods:":          "CPU の演算方式:",
+	"Load patterns:":        "負荷パターン:",
+	"Engines:":              "エンジン:",
+	"available":             "使用可能",
+	"unavailable, needs %s": "使用不可、%s が必要",
+	"Error: Unknown list %q (methods, patterns or engines)\n":                                 "エラー: 不明な一覧 %q (methods、patterns または engines)\n",
+	"Integer multiply, add, shift and xor loop on every worker":                               "各ワーカーで整数の乗算・加算・シフト・排他的論理和を繰り返す",
+	"SIMD (SVE or NEON) and floating point multiply-add loop, run alongside the integer loop": "整数演算と並行して SIMD (SVE または NEON) と浮動小数点の積和演算を繰り返す",
+	"ARM64": "ARM64",
+	"Modular exponentiation of big integers, as in RSA key operations and key generation":                         "RSA の鍵演算や鍵生成と同様の多倍長整数のべき剰余",
+	"Step the CPU and memory load through levels, holding each for a fixed time":                                  "CPU とメモリの負荷を段階的に変え、各段階を一定時間維持する",
+	"Synchronous writes, appends and reads of temporary files through the page cache, with fsync after each file": "ページキャッシュを介して一時ファイルを同期的に書き込み・追記・読み込みし、ファイルごとに fsync する",
+	"Compute kernel and VRAM allocation through the CUDA driver API (NVIDIA)":                                     "CUDA ドライバー API (NVIDIA) による演算カーネルの実行と VRAM の確保",
+	"a build with -tags gpu (cgo and the CUDA driver)":                                                            "-tags gpu を指定したビルド (cgo と CUDA ドライバー)",
+	"Random reads of a memory-mapped file larger than memory, served by major page faults":                        "メモリより大きいメモリマップドファイルのランダムな読み込み (メジャーページフォールトで読み込む)",
+	"a Unix-like OS": "Unix 系の OS",
+	"Punching holes in sparse files with fallocate and filling them again, churning the file system's extents": "fallocate でスパースファイルに穴を開けては埋め直し、ファイルシステムのエクステントを繰り返し変更する",
+	"Linux": "Linux",
+
+	// Page fault
+	"The %d MiB file fits in the %d MiB of memory; once it is cached, few major faults occur": "%d MiB のファイルは %d MiB のメモリに収まるため、キャッシュされた後はメジャーページフォールトがほとんど発生しません",
+	"Writing a %d MiB file to map in %s":                                                      "%[2]s にメモリマップする %[1]d MiB のファイルを書き込んでいます",
+	"File capped from %d MiB to %d MiB by the disk limit":                                     "ディスクの上限によりファイルを %d MiB から %d MiB に縮小しました",
+	"file capped by hard disk limit (%d MiB)":                                                 "ディスクのハードリミット (%d MiB) によりファイルを縮小",
+	"File written in %v": "ファイルを %v で書き込みました",
+	"Touching random pages with %d workers at %.0f faults/s": "%d 個のワーカーでランダムなページに触れます (毎秒 %.0f 回のページフォールト)",
+	"Touching random pages with %d workers":                  "%d 個のワーカーでランダムなページに触れます",
+	"Cannot read the page fault count: %v":                   "ページフォールトの回数を読み取れません: %v",
+	"Failed to remove %s: %v":                                "%s を削除できませんでした: %v",
+	"Error: Invalid --pagefault: %v\n":                       "エラー: --pagefault が正しくありません: %v\n",
+	"Page fault load: %s file, %s faults%s":                  "ページフォールト負荷: ファイル %s、ページフォールト %s%s",
+	"as many as possible":                                    "上限なし",
+	"%.0f/s":                                                 "毎秒 %.0f 回",
+
+	// SMART
+	"SMART: not monitored (%v; set --smart-device)\n":                  "SMART: 監視しません (%v。--smart-device を指定してください)\n",
+	"SMART: not monitored (%v)\n":                                      "SMART: 監視しません (%v)\n",
+	"SMART: Warning: %s already fails its overall health assessment\n": "SMART: 警告: %s はすでに総合評価が不合格です\n",
+	"SMART: monitoring %s (%s) every %v: %s\n":                         "SMART: %s (%s) を %v ごとに監視します: %s\n",
+	"Warning: %v":                     "警告: %v",
+	"%s: %s":                          "%s: %s",
+	"temperature %.0f°C above %.0f°C": "温度 %.0f°C が %.0f°C を超えました",
+	"SMART health of %s degraded: %s": "%s の SMART の健康状態が悪化しました: %s",
+	"passed":                          "合格",
+	"FAILED":                          "不合格",
+	"health %s, temperature %.0f°C, reallocated %d, pending %d, uncorrectable %d, media errors %d": "総合評価 %s、温度 %.0f°C、代替処理済み %d、代替待ち %d、訂正不能 %d、メディアエラー %d",
+	"SMART (%s):\n":                   "SMART (%s):\n",
+	"  Start: %s\n":                   "  開始時: %s\n",
+	"  End:   %s\n":                   "  終了時: %s\n",
+	"  Highest temperature: %.0f°C\n": "  最高温度: %.0f°C\n",
+	"Error: --smart-interval must be positive and --smart-max-temp must not be negative\n": "エラー: --smart-interval は正の値、--smart-max-temp は 0 以上である必要があります\n",
+
+	// Sparse files
+	"Punching and filling holes in %d sparse files of %d MiB in %s at %.0f operations/s": "%[3]s の %[2]d MiB のスパースファイル %[1]d 個に穴を開けては埋め直します (毎秒 %.0[4]f 回)",
+	"Punching and filling holes in %d sparse files of %d MiB in %s":                      "%[3]s の %[2]d MiB のスパースファイル %[1]d 個に穴を開けては埋め直します",
+	"Sparse files capped from %d MiB to %d MiB by the disk limit":                        "ディスクの上限によりスパースファイルを %d MiB から %d MiB に縮小しました",
+	"files capped by hard disk limit (%d MiB)":                                           "ディスクのハードリミット (%d MiB) によりファイルを縮小",
+	"Punched %d holes and filled %d; %d MiB of %d MiB allocated at the end":              "%d 回穴を開け、%d 回埋めました。終了時の占有容量は %d MiB / %d MiB です",
+	"Punch error: %v":               "穴を開けられませんでした: %v",
+	"Write error: %v":               "書き込みエラー: %v",
+	"Sync error: %v":                "同期エラー: %v",
+	"Error: Invalid --sparse: %v\n": "エラー: --sparse が正しくありません: %v\n",
+	"Sparse file load: %s of sparse files, %s hole punches and fills%s": "スパースファイルの負荷: スパースファイル %s、穴開けと埋め直し %s%s",
+
+	// Page cache
+	"Warning: Cannot drop the page cache: %v; storage reads may be served from the cache\n": "警告: ページキャッシュを破棄できません: %v。ストレージの読み込みはキャッシュから返される可能性があります\n",
+	"Dropped the page cache before the load":                                                "負荷の開始前にページキャッシュを破棄しました",
+	"Dropped the page cache before %s\n":                                                    "%s の前にページキャッシュを破棄しました\n",
+	"Error: Invalid --drop-caches %q (before or between-phases)\n":                          "エラー: --drop-caches の値 %q が正しくありません (before または between-phases)\n",
+
+	// Interval
+	"Error: Invalid --interval: %v\n":  "エラー: --interval が正しくありません: %v\n",
+	"Rest period: load paused for %v":  "休止: %v 負荷を一時停止します",
+	"Work period: load resumed for %v": "稼働: %v 負荷を再開します",
+
+	// Hooks
+	"[Hook] Running %s command: %s\n":          "[Hook] %s コマンドを実行しています: %s\n",
+	"Error: --pre-cmd failed: %v\n":            "エラー: --pre-cmd が失敗しました: %v\n",
+	"Warning: --phase-cmd failed: %v\n":        "警告: --phase-cmd が失敗しました: %v\n",
+	"Error: --hook-timeout must be positive\n": "エラー: --hook-timeout は正の値を指定してください\n",
+	"Warning: --post-cmd failed: %v\n":         "警告: --post-cmd が失敗しました: %v\n",
+
+	// Notifications
+	"stress-go started on %s: %s":      "stress-go が %s で開始しました: %s",
+	"stress-go on %s entered phase %s": "%s の stress-go が段階 %s に入りました",
+	"stress-go on %s finished step %v": "%s の stress-go が段階 %v を終了しました",
+	"stress-go on %s is aborting: %s":  "%s の stress-go を中止しています: %s",
+	"stress-go finished on %s: %s":     "stress-go が %s で終了しました: %s",
+
+	// Probes
+	"Cannot start the %s probe: %v": "%s プローブを開始できません: %v",
+	"The %s probe stopped: %v":      "%s プローブが停止しました: %v",
+
+	// SLOs
+	"Violated %s (observed %s)": "%s に違反しました (観測値 %s)",
+
+	// Chaos
+	"Injected %s into %s: %s":      "%[2]s に %[1]s を注入しました: %[3]s",
+	"Cannot inject %s into %s: %v": "%[2]s に %[1]s を注入できません: %[3]v",
+
+	// Plugins
+	"Started plugin (pid %d)":                 "プラグインを開始しました (pid %d)",
+	"Plugin finished":                         "プラグインが終了しました",
+	"Ignoring malformed plugin output: %s":    "プラグインの不正な出力を無視します: %s",
+	"Ignoring unknown plugin message type %q": "プラグインの不明なメッセージ種別 %q を無視します",
+
+	// record
+	"Error: --output option is required\n":                        "エラー: --output オプションは必須です\n",
+	"Error: --interval must be positive\n":                        "エラー: --interval には正の値を指定してください\n",
+	"\nInterrupt signal received. Saving profile...":              "\n割り込みシグナルを受信しました。プロファイルを保存しています...",
+	"Recording load profile every %v to %s (Ctrl+C to stop)...\n": "%v ごとに負荷プロファイルを %s に記録しています (Ctrl+C で停止)...\n",
+	"Error: Recording stopped: %v\n":                              "エラー: 記録が停止しました: %v\n",
+	"Error: No samples were recorded\n":                           "エラー: サンプルが記録されませんでした\n",
+	"Recorded %d samples (%v) to %s\n":                            "%d サンプル (%v) を %s に記録しました\n",
+
+	// doctor
+	"Host checks:": "ホストの確認:",
+	"All stressors and options are expected to work on this host.": "このホストではすべての負荷生成モジュールとオプションが動作する見込みです。",
+	"May be limited or unavailable: %s\n":                          "制限されるか利用できない可能性があります: %s\n",
+	"Available memory":                                             "利用可能なメモリ",
+	"CPU topology":                                                 "CPU の構成",
+	"CPU caches":                                                   "CPU キャッシュ",
+	"Temperature sensors":                                          "温度センサー",
+	"Privileges":                                                   "権限",
+	"Open file limit (RLIMIT_NOFILE)":                              "オープンファイル数の上限 (RLIMIT_NOFILE)",
+	"Locked memory limit (RLIMIT_MEMLOCK)":                         "ロックメモリの上限 (RLIMIT_MEMLOCK)",
+	"cgroup memory limit":                                          "cgroup のメモリ上限",
+	"cgroup CPU quota":                                             "cgroup の CPU クォータ",
+	"cgroup I/O limit":                                             "cgroup の I/O 上限",
+	"Huge page pool":                                               "Huge page プール",
+	"Sleep inhibition (systemd-inhibit)":                           "スリープの抑止 (systemd-inhibit)",
+	"Unprivileged ICMP sockets":                                    "非特権 ICMP ソケット",
+	"RAPL energy counters":                                         "RAPL エネルギーカウンタ",
+
+	// bench
+	"Error: --duration must be positive\n":                                             "エラー: --duration には正の値を指定してください\n",
+	"Error: Invalid core count: %d\n":                                                  "エラー: コア数 %d は正しくありません\n",
+	"\nInterrupt signal received. Stopping benchmark...":                               "\n割り込みシグナルを受信しました。ベンチマークを停止しています...",
+	"Running CPU benchmark for %v...\n":                                                "CPU のベンチマークを %v 実行しています...\n",
+	"  CPU: %.1f Mops/s (%d cores, %.1f Mops/s per core)\n":                            "  CPU: %.1f Mops/s (%d コア、1 コアあたり %.1f Mops/s)\n",
+	"Running memory benchmark for %v...\n":                                             "メモリのベンチマークを %v 実行しています...\n",
+	"Error: Memory benchmark failed: %v\n":                                             "エラー: メモリのベンチマークに失敗しました: %v\n",
+	"  Memory: %.2f GB/s (copying %d MiB)\n":                                           "  メモリ: %.2f GB/s (%d MiB をコピー)\n",
+	"Running disk benchmark for %v...\n":                                               "ディスクのベンチマークを %v 実行しています...\n",
+	"Error: Disk benchmark failed: %v\n":                                               "エラー: ディスクのベンチマークに失敗しました: %v\n",
+	"  Disk: %.1f MB/s sequential write, %.0f IOPS (4 KiB random writes with fsync)\n": "  ディスク: 順次書き込み %.1f MB/s、%.0f IOPS (fsync 付きの 4 KiB ランダム書き込み)\n",
+	"Composite score: %.0f (reference machine = 1000)\n":                               "総合スコア: %.0f (基準マシン = 1000)\n",
+	"Error: Failed to write benchmark results: %v\n":                                   "エラー: ベンチマークの結果を書き込めませんでした: %v\n",
+	"Benchmark results written to %s\n":                                                "ベンチマークの結果を %s に書き込みました\n",
+
+	// latency
+	"Error: Invalid --size: %v\n":                          "エラー: --size が正しくありません: %v\n",
+	"\nInterrupt signal received. Stopping measurement...": "\n割り込みシグナルを受信しました。計測を停止しています...",
+	"CPU caches: %s\n":                                     "CPU キャッシュ: %s\n",
+	"CPU caches: unknown (%v)\n":                           "CPU キャッシュ: 不明 (%v)\n",
+	"Warning: the %d MiB buffer is not much larger than the %d MiB last level cache; the latency includes cache hits\n": "警告: %d MiB のバッファは %d MiB の最終レベルキャッシュより十分大きくないため、レイテンシにキャッシュヒットが含まれます\n",
+	"Measuring memory latency on %d MiB for %v per level...\n":                                                          "%d MiB のバッファでメモリのレイテンシを負荷レベルごとに %v 計測しています...\n",
+	"  Load      Bandwidth      Latency":                                                                                "  負荷      帯域           レイテンシ",
+	"  idle    %7.2f GB/s %9.1f ns\n":                                                                                   "  アイドル %7.2f GB/s %9.1f ns\n",
+	"Error: Memory latency measurement failed: %v\n":                                                                    "エラー: メモリのレイテンシの計測に失敗しました: %v\n",
+	"Latency at %.2f GB/s is %.1fx the idle latency\n":                                                                  "%.2f GB/s でのレイテンシはアイドル時の %.1f 倍です\n",
+	"Error: Failed to write latency results: %v\n":                                                                      "エラー: レイテンシの計測結果を書き込めませんでした: %v\n",
+	"Latency results written to %s\n":                                                                                   "レイテンシの計測結果を %s に書き込みました\n",
+
+	// search
+	"Error: Specify either --cpu or --memory to search\n":                         "エラー: 探索する負荷として --cpu か --memory のどちらかを指定してください\n",
+	"Error: Unknown search method %q (bisect or step)\n":                          "エラー: 不明な探索方法 %q です (bisect または step)\n",
+	"Error: --settle must not be negative and --hold must be positive\n":          "エラー: --settle に負の値は指定できず、--hold には正の値を指定してください\n",
+	"\nInterrupt signal received. Stopping search...":                             "\n割り込みシグナルを受信しました。探索を停止しています...",
+	"Searching the maximum sustainable %s load until %s (%s, %v per level)...\n":  "%[2]s が成立するまで %[1]s の最大持続可能負荷を探索しています (%[3]s、各レベル %[4]v)...\n",
+	"  Level %.0f%% (%s): passed, achieved %s\n":                                  "  レベル %.0f%% (%s): 合格、実績 %s\n",
+	"  Level %.0f%% (%s): failed on %s\n":                                         "  レベル %.0f%% (%s): %s で不合格\n",
+	"Search interrupted.\n":                                                       "探索を中断しました。\n",
+	"Maximum sustainable level: %.0f%% (%s)\n":                                    "最大持続可能レベル: %.0f%% (%s)\n",
+	"Maximum sustainable level: none, the criteria failed at every level tried\n": "最大持続可能レベル: なし (試したすべてのレベルで条件が成立しました)\n",
+	"Breaking point: %.0f%% (%s), failed on %s\n":                                 "限界点: %.0f%% (%s)、%s で不合格\n",
+	"Breaking point: none, the criteria held at full load\n":                      "限界点: なし (全負荷でも条件は成立しませんでした)\n",
+	"Error: %s load failed: %v\n":                                                 "エラー: %s の負荷生成に失敗しました: %v\n",
+	"Error: Failed to write search results: %v\n":                                 "エラー: 探索結果を書き込めませんでした: %v\n",
+	"Search results written to %s\n":                                              "探索結果を %s に書き込みました\n",
+
+	// tenants
+	"Error: Cannot create the tenant cgroups: %v\n":       "エラー: テナントの cgroup を作成できません: %v\n",
+	"Running %d tenants for %v in their own cgroups...\n": "%d 個のテナントをそれぞれの cgroup で %v 実行しています...\n",
+	"  %s: limits %s, options %s\n":                       "  %s: 制限 %s、オプション %s\n",
+	"\nInterrupt signal received. Stopping tenants...":    "\n割り込みシグナルを受信しました。テナントを停止しています...",
+	"Tenants:\n":                        "テナント:\n",
+	"  %s (limits %s, exit code %d):\n": "  %s (制限 %s、終了コード %d):\n",
+	"    CPU: %.2f cores mean, %.2f cores max; throttled in %.1f%% of periods (%.1fs)\n": "    CPU: 平均 %.2f コア、最大 %.2f コア、制御周期の %.1f%% で抑制 (%.1f 秒)\n",
+	"    Memory: %s peak; memory.high %d times, memory.max %d times, %d OOM kills\n":     "    メモリ: 最大 %s、memory.high 超過 %d 回、memory.max 到達 %d 回、OOM kill %d 回\n",
+	"    [%s] target %s, achieved %s\n":                                                  "    [%s] 目標 %s、実績 %s\n",
+	"    ! Not contained: %s\n":                                                          "    ! 制限を超えました: %s\n",
+	"CPU %.2f cores above cpu.max of %.2f cores":                                         "CPU %.2f コア (cpu.max の %.2f コアを超過)",
+	"memory %s above memory.max of %s":                                                   "メモリ %s (memory.max の %s を超過)",
+	"Error: Failed to write tenant results: %v\n":                                        "エラー: テナントの結果を書き込めませんでした: %v\n",
+	"Tenant results written to %s\n":                                                     "テナントの結果を %s に書き込みました\n",
+	"Isolation failed: %s used more than their limits allow.\n":                          "分離に失敗しました: %s が制限を超えて資源を使用しました。\n",
+	"All tenants stayed within their limits.":                                            "すべてのテナントが制限内に収まりました。",
+
+	// calibrate
+	"Warning: Ignoring the duty-cycle calibration in %s, which was measured on %s (run \"stress-go calibrate\" on this host)\n": "警告: %s のデューティ比の補正値は %s で計測されたものなので使用しません (このホストで \"stress-go calibrate\" を実行してください)\n",
+	"Error: --check must not be negative\n":                                                "エラー: --check に負の値は指定できません\n",
+	"Error: Cannot locate the user config directory, use --output: %v\n":                   "エラー: ユーザー設定ディレクトリが見つかりません。--output を指定してください: %v\n",
+	"\nInterrupt signal received. Stopping calibration...":                                 "\n割り込みシグナルを受信しました。補正値の計測を停止しています...",
+	"Measuring timer and sleep behavior on this host...":                                   "このホストのタイマーとスリープの特性を計測しています...",
+	"  Timer resolution: %v\n":                                                             "  タイマーの分解能: %v\n",
+	"  Busy loop check interval: %v\n":                                                     "  負荷ループの判定間隔: %v\n",
+	"  Sleep overshoot: %v\n":                                                              "  スリープの超過時間: %v\n",
+	"Checking a %.0f%% load on one core for %v each without and with the calibration...\n": "1 コアで %.0f%% の負荷を補正なし・補正ありでそれぞれ %v 実行して確認しています...\n",
+	"Warning: Cannot measure the achieved load: %v\n":                                      "警告: 実際の負荷を計測できません: %v\n",
+	"  Achieved: %.1f%% uncalibrated, %.1f%% calibrated\n":                                 "  実測: 補正なし %.1f%%、補正あり %.1f%%\n",
+	"Error: Failed to create %s: %v\n":                                                     "エラー: %s を作成できませんでした: %v\n",
+	"Calibration written to %s\n":                                                          "補正値を %s に書き込みました\n",
+	"Duty cycle calibrated on %s: sleep overshoot %v, spin batch %v":                       "%s に計測したデューティ比の補正値を使用します: スリープの超過時間 %v、負荷ループの判定間隔 %v",
+
+	// selftest
+	"Self-test failed: %v\n": "セルフテストに失敗しました: %v\n",
+	"Self-test passed.":      "セルフテストに成功しました。",
+
+	// agent and coordinate
+	"[Agent] Listening on %s\n": "[Agent] %s で待ち受けています\n",
+	"Error: --token is required unless the agent listens on a loopback address (e.g., --listen 127.0.0.1:%d)\n": "エラー: ループバックアドレス以外で待ち受ける場合は --token が必要です (例: --listen 127.0.0.1:%d)\n",
+	"\nStopping agent...":                 "\nエージェントを停止しています...",
+	"Started job %d: %s":                  "ジョブ %d を開始しました: %s",
+	"Stopping job %d":                     "ジョブ %d を停止しています",
+	"Job %d finished with exit status %d": "ジョブ %d が終了しました (終了ステータス %d)",
+
+	// daemon and ctl
+	"[Daemon] Listening on %s\n":                                                             "[Daemon] %s で待ち受けています\n",
+	"\nStopping daemon...":                                                                   "\nデーモンを停止しています...",
+	"Scheduled %q for %v (line %d, next at %s): %s":                                          "%[3]d 行目: %[1]q に %[2]v 実行します (次回 %[4]s): %[5]s",
+	"Skipped the load test scheduled by line %d: %v":                                         "%d 行目で予定された負荷テストをスキップしました: %v",
+	"Error: ctl needs a command: start, status, stop or adjust\n":                            "エラー: ctl にはコマンド (start, status, stop, adjust) が必要です\n",
+	"Error: Unknown ctl command %q (start, status, stop or adjust)\n":                        "エラー: 不明な ctl コマンド %q です (start, status, stop, adjust)\n",
+	"Error: ctl start needs the load test options, e.g. ctl start -- --timeout 1h --cpu 2\n": "エラー: ctl start には負荷テストのオプションが必要です (例: ctl start -- --timeout 1h --cpu 2)\n",
+	"Error: ctl adjust needs one of --level, --pause, --resume or --target\n":                "エラー: ctl adjust には --level, --pause, --resume, --target のいずれか1つが必要です\n",
+	"Error: --target must be NAME=VALUE, e.g. memory=4GB\n":                                  "エラー: --target は 名前=値 の形式で指定してください (例: memory=4GB)\n",
+	"Started job %d: %s\n":                                                                   "ジョブ %d を開始しました: %s\n",
+	"Stopping job %d\n":                                                                      "ジョブ %d を停止しています\n",
+	"Job %d finished with exit status %d\n":                                                  "ジョブ %d が終了しました (終了ステータス %d)\n",
+	"Job %d has already finished.\n":                                                         "ジョブ %d はすでに終了しています。\n",
+	"No load test has been started.":                                                         "負荷テストはまだ開始されていません。",
+	"Job %d: %s\n":                                                                           "ジョブ %d: %s\n",
+	"Finished with exit status %d after %v\n":                                                "%[2]v 後に終了しました (終了ステータス %[1]d)\n",
+	"Running since %s\n":                                                                     "%s から実行中\n",
+	"State: %s, remaining %v, level %g":                                                      "状態: %s、残り %v、負荷レベル %g",
+	"Level %g":                                                                               "負荷レベル %g",
+	", paused":                                                                               "、一時停止中",
+	"  [%s] %s: target %s, achieved %s\n":                                                    "  [%s] %s: 目標 %s、実測 %s\n",
+	"Error: --listen is not supported with --ssh-hosts\n":                                    "エラー: --listen は --ssh-hosts と併用できません\n",
+	"Starting on %d agents: %s\n":                                                            "%d 台のエージェントで開始します: %s\n",
+	"Start barrier: %s\n":                                                                    "開始時刻: %s\n",
+	"Start barrier: %s (host clocks must be synchronized)\n":                                 "開始時刻: %s (ホストの時刻が同期している必要があります)\n",
+	"[%s] Error: %v\n":                                                                       "[%s] エラー: %v\n",
+	"[%s] Warning: %v\n":                                                                     "[%s] 警告: %v\n",
+	"Not all agents started, stopping the others...":                                         "一部のエージェントが開始できなかったため、他のエージェントを停止しています...",
+	"[%s] Started job %d\n":                                                                  "[%s] ジョブ %d を開始しました\n",
+	"[%s] Scheduled job %d (clock offset %v)\n":                                              "[%s] ジョブ %d を予約しました (時刻のずれ %v)\n",
+	"\nInterrupt signal received. Stopping all agents...":                                    "\n割り込みシグナルを受信しました。すべてのエージェントを停止しています...",
+	"[%s] Finished with exit status %d\n":                                                    "[%s] 終了しました (終了ステータス %d)\n",
+	"\n[Control] Pausing the load on all agents\n":                                           "\n[Control] すべてのエージェントの負荷を一時停止します\n",
+	"\n[Control] Resuming the load on all agents\n":                                          "\n[Control] すべてのエージェントの負荷を再開します\n",
+	"\n[Control] Setting the load level to %g on all agents\n":                               "\n[Control] すべてのエージェントの負荷レベルを %g に設定します\n",
+	"\nHost results:\n":                                                                      "\nホストごとの結果:\n",
+	"  [%s] FAILED: %v\n":                                                                    "  [%s] 失敗: %v\n",
+	"  [%s] FAILED: exit status %d%s\n":                                                      "  [%s] 失敗: 終了ステータス %d%s\n",
+	"Error: Failed to write cluster report: %v\n":                                            "エラー: クラスターレポートを書き込めませんでした: %v\n",
+	"Cluster report written to %s\n":                                                         "クラスターレポートを %s に書き込みました\n",
+	"\nCluster report: %d hosts, %d succeeded, %d failed\n":                                  "\nクラスターレポート: %d ホスト、成功 %d、失敗 %d\n",
+	"  [%s] total target %s, achieved %s (%.1f%%) on %d hosts":                               "  [%s] 合計 目標 %s、実績 %s (%.1f%%)、%d ホスト",
+	", %d degraded":                                                                          "、低下 %d",
+	"Stragglers:\n":                                                                          "目標に届かなかったホスト:\n",
+	"  [%s] %s: %.1f%% of target (cluster median %.1f%%)\n":                                  "  [%s] %s: 目標の %.1f%% (クラスターの中央値 %.1f%%)\n",
+	"Problems:\n":                                                                            "問題:\n",
+	"\nInterrupt signal received. Stopping all hosts...":                                     "\n割り込みシグナルを受信しました。すべてのホストを停止しています...",
+	"\nInterrupt signal received again. Closing ssh connections...":                          "\n再度割り込みシグナルを受信しました。ssh 接続を閉じています...",
+	"Starting on %d hosts over ssh\n":                                                        "ssh で %d 台のホストで開始します\n",
+	"Copying %s to %s on %d hosts\n":                                                         "%[1]s を %[3]d 台のホストの %[2]s にコピーしています\n",
+	"Copying %s to a temporary directory on %d hosts\n":                                      "%[1]s を %[2]d 台のホストの一時ディレクトリにコピーしています\n",
+	"[%s] Warning: Failed to remove %s: %v %s\n":                                             "[%s] 警告: %s を削除できませんでした: %v %s\n",
+	"[%s] Error: Copy failed: %v %s\n":                                                       "[%s] エラー: コピーに失敗しました: %v %s\n",
+
+	// k8s and service
+	"Error: unknown k8s command (expected: stress-go k8s gen)\n":                              "エラー: 不明な k8s コマンドです (stress-go k8s gen を指定してください)\n",
+	"Error: Invalid mode %q (expected job or daemonset)\n":                                    "エラー: モード %q は正しくありません (job または daemonset を指定してください)\n",
+	"Error: Invalid parallelism: %d\n":                                                        "エラー: 並列数 %d は正しくありません\n",
+	"Error: the service command is only available on Windows (use a systemd unit on Linux)\n": "エラー: service コマンドは Windows でのみ使用できます (Linux では systemd のユニットを使用してください)\n",
+	"Error: unknown service command (expected install, uninstall or run)\n":                   "エラー: 不明な service コマンドです (install、uninstall または run を指定してください)\n",
+	"Error: unknown service command %q (expected install, uninstall or run)\n":                "エラー: 不明な service コマンド %q です (install、uninstall または run を指定してください)\n",
+	"Installed service %s: %s\n":                                                              "サービス %s をインストールしました: %s\n",
+	"Start it with: sc.exe start %s\n":                                                        "開始するには次を実行してください: sc.exe start %s\n",
+	"Removed service %s\n":                                                                    "サービス %s を削除しました\n",
+
+	// stress-go resume / --state
+	"Error: --state option is required\n":        "エラー: --state オプションは必須です\n",
+	"Error: --state-interval must be positive\n": "エラー: --state-interval には正の値を指定してください\n",
+	"Error: %s holds an unfinished run; continue it with \"stress-go resume --state %s\" or remove the file\n": "エラー: %s には終了していない実行の状態があります。\"stress-go resume --state %s\" で続きを実行するか、ファイルを削除してください\n",
+	"Warning: Not removing %s, which is not a stress-go directory\n":                                           "警告: %s は stress-go のディレクトリではないため削除しません\n",
+	"Warning: Failed to remove %s: %v\n":                                                                       "警告: %s を削除できませんでした: %v\n",
+	"Removed the files left in %s\n":                                                                           "%s に残っていたファイルを削除しました\n",
+	"Resuming the run started at %s: %v elapsed, %v remaining\n\n":                                             "%s に開始した実行を再開します: 経過 %v、残り %v\n\n",
+	"Warning: Failed to write the run state: %v\n":                                                             "警告: 実行の状態を書き込めませんでした: %v\n",
+	"Run state saved; continue with \"stress-go resume --state %s\"\n":                                         "実行の状態を保存しました。\"stress-go resume --state %s\" で続きを実行できます\n",
+
+	// Network load and stress-go echo
+	"Error: Invalid --network: %v\n":                                  "エラー: --network が無効です: %v\n",
+	"the built-in echo server":                                        "組み込みのエコーサーバー",
+	"as fast as possible":                                             "可能な限り",
+	"%s/s":                                                            "毎秒 %s",
+	"Network load: %s to %s, %s%s":                                    "ネットワーク負荷: %[2]s へ %[1]s、%[3]s%[4]s",
+	"Echo server listening on %s (TCP and UDP)":                       "エコーサーバーが %s で待ち受けています (TCP と UDP)",
+	"Opening and closing TCP connections to %s on %d workers":         "%s への TCP コネクションの確立と切断を %d ワーカーで繰り返します",
+	"Sending %d-byte %s writes to %s over %d connections":             "%[1]d バイトの %[2]s の送信を %[3]s へ %[4]d コネクションで行います",
+	"Sent %d MiB, received %d MiB over %d connections with %d errors": "%d MiB を送信、%d MiB を受信しました (コネクション %d、エラー %d)",
+	"Connect error: %v":                                               "接続エラー: %v",
+	"Send error: %v":                                                  "送信エラー: %v",
+	"Echo error: %v":                                                  "エコーのエラー: %v",
+	"[Echo] Listening on %s (TCP and UDP)\n":                          "[Echo] %s で待ち受けています (TCP と UDP)\n",
+	"\nStopping echo server...":                                       "\nエコーサーバーを停止しています...",
+
+	// --output and --report-file
+	"Error: --output must be text, json or csv: %s\n": "エラー: --output は text、json、csv のいずれかで指定してください: %s\n",
+	"Error: Invalid --report-file: %v\n":              "エラー: --report-file が正しくありません: %v\n",
+	"Error: Failed to write the report: %v\n":         "エラー: レポートの書き込みに失敗しました: %v\n",
+
+	// --scenario
+	" [%s: starts in %v]": " [%s: 開始まで %v]",
+	" (from %v for %v)":   " (%v 後から %v 間)",
+
+	// --ramp-up and --ramp-down
+	"Error: --ramp-up and --ramp-down must not be negative and must fit in --timeout\n": "エラー: --ramp-up と --ramp-down は負でない値で、合計が --timeout 以下である必要があります\n",
+	"Ramp: up over %v, down over %v": "ランプ: %v かけて上昇、%v かけて下降",
+
+	// --storage-path, --storage-files and --storage-bs
+	"Error: Invalid --storage-path: %v\n":                                        "エラー: --storage-path が無効です: %v\n",
+	"Error: --storage-files must not be negative\n":                              "エラー: --storage-files は負でない値である必要があります\n",
+	"Error: Invalid --storage-bs: %s (1 byte to %s)\n":                           "エラー: --storage-bs が無効です: %s (1 バイトから %s)\n",
+	"Error: --storage-bs must be a multiple of %d bytes with --storage-verify\n": "エラー: --storage-verify の場合、--storage-bs は %d バイトの倍数である必要があります\n",
+	"Storage directory: %s":                                                      "ストレージのディレクトリ: %s",
+	"Storage files: %d":                                                          "ストレージのファイル数: %d",
+	"Storage block size: %s":                                                     "ストレージのブロックサイズ: %s",
+
+	// --io-mode, --io-depth and --fsync-every
+	"Error: Invalid --io-mode: %v\n":                                                                 "エラー: --io-mode が無効です: %v\n",
+	"Error: --io-depth must be at least 1 and --fsync-every must not be negative\n":                  "エラー: --io-depth は 1 以上、--fsync-every は負でない値である必要があります\n",
+	"Storage I/O: %s on %d workers":                                                                  "ストレージの I/O: %s (ワーカー %d 個)",
+	"Storage fsync: after every %d writes":                                                           "ストレージの fsync: 書き込み %d 回ごと",
+	"Running %s I/O of %d bytes on %d workers":                                                       "%[2]d バイト単位の %[1]s の I/O をワーカー %[3]d 個で実行します",
+	"Calling fsync after every %d writes":                                                            "書き込み %d 回ごとに fsync します",
+	"I/O error: %v":                                                                                  "I/O エラー: %v",
+	"%.0f IOPS, %.1f MiB/s read, %.1f MiB/s written (%d files active)":                               "%.0f IOPS、読み込み %.1f MiB/s、書き込み %.1f MiB/s (対象ファイル %d 個)",
+	"Achieved %.0f IOPS and %.1f MiB/s on average (%d MiB read, %d MiB written)":                     "平均 %.0f IOPS、%.1f MiB/s でした (読み込み %d MiB、書き込み %d MiB)",
+	"Sequential overwrites of the stress files on --io-depth workers, reporting IOPS and throughput": "--io-depth 個のワーカーによるストレス用ファイルの順次上書き。IOPS とスループットを記録",
+	"Sequential reads of the stress files on --io-depth workers, reporting IOPS and throughput":      "--io-depth 個のワーカーによるストレス用ファイルの順次読み込み。IOPS とスループットを記録",
+	"Reads at random offsets of the stress files with pread, reporting IOPS and throughput":          "pread によるストレス用ファイルのランダムな位置の読み込み。IOPS とスループットを記録",
+	"Overwrites at random offsets of the stress files with pwrite, reporting IOPS and throughput":    "pwrite によるストレス用ファイルのランダムな位置の上書き。IOPS とスループットを記録",
+	"Random reads and overwrites in equal parts, reporting IOPS and throughput":                      "ランダムな位置の読み込みと上書きを半分ずつ。IOPS とスループットを記録",
+
+	// Network workloads
+	"Streaming data over TCP connections to load the bandwidth of the NIC and network stack":         "TCP 接続でデータを送り続け、NIC とネットワークスタックの帯域に負荷をかける",
+	"Sending UDP datagrams to load the packet processing of the NIC and network stack":               "UDP データグラムを送り続け、NIC とネットワークスタックのパケット処理に負荷をかける",
+	"Opening and closing TCP connections to churn the connection tracking table and ephemeral ports": "TCP 接続の確立と切断を繰り返し、コネクション追跡テーブルとエフェメラルポートに負荷をかける",
+	// --procs
+	"Error: Invalid --procs: %v\n": "エラー: --procs が無効です: %v\n",
+	"exiting at once":              "すぐに終了",
+	"sleeping %v":                  "%v 待機",
+	"Process load: up to %d children, %s spawns, %s%s":           "プロセス負荷: 子プロセス最大 %d 個、生成 %s、%s%s",
+	"Spawning up to %d child processes that live for %v":         "%[2]v 存続する子プロセスを最大 %[1]d 個まで生成します",
+	"Spawning up to %d short-lived child processes":              "短命な子プロセスを最大 %d 個まで生成します",
+	"Spawned %d processes, at most %d at once, with %d failures": "%d 個のプロセスを生成しました (同時に最大 %d 個、失敗 %d 回)",
+	"Process limit reached at %d children: %v":                   "子プロセス %d 個でプロセス数の上限に達しました: %v",
+	"Spawn error: %v": "プロセス生成エラー: %v",
+	"Spawning and reaping short-lived child processes to load the scheduler and PID table": "短命な子プロセスの生成と回収を繰り返し、スケジューラーと PID テーブルに負荷をかける",
+	"Keeping up to N sleeping child processes alive to fill the process table":             "待機する子プロセスを最大 N 個存続させ、プロセステーブルを埋める",
+
+	// Runtime targets of the control API
+	"Target of %s set to %s":                                                                                "%s の目標値を %s に変更しました",
+	"Cannot lock memory, holding it unlocked: %v":                                                           "メモリをロックできないため、ロックせずに保持します: %v",
+	"memory not locked":                                                                                     "メモリをロックできません",
+	"Rewriting memory in %s mode at %d MiB/s on %d workers":                                                 "%s モードでメモリを毎秒 %d MiB で書き換えます (ワーカー %d 個)",
+	"Running %s mode on %d workers":                                                                         "%s モードを %d 個のワーカーで実行します",
+	"Locking allocated memory in physical memory":                                                           "確保したメモリを物理メモリにロックします",
+	"Allowing allocation beyond physical memory to force swapping":                                          "スワップを発生させるため、物理メモリを超える確保を許可します",
+	"Resident: %d MiB of %d MiB, the rest swapped out":                                                      "物理メモリ上: %d MiB / %d MiB (残りはスワップアウト)",
+	"Memory bandwidth: %.2f GB/s (%.2f GB/s read, %.2f GB/s written)":                                       "メモリ帯域: %.2f GB/s (読み込み %.2f GB/s、書き込み %.2f GB/s)",
+	"Achieved %.2f GB/s on average (%d MiB read, %d MiB written)":                                           "平均 %.2f GB/s を達成しました (読み込み %d MiB、書き込み %d MiB)",
+	"Error: Invalid --memory-mode: %v\n":                                                                    "エラー: --memory-mode が無効です: %v\n",
+	"Error: --memory-verify cannot be combined with --memory-mode %s, which overwrites the test patterns\n": "エラー: --memory-mode %s はテストパターンを上書きするため、--memory-verify と同時には指定できません\n",
+	"Error: Invalid --memory-rate: %s\n":                                                                    "エラー: --memory-rate が無効です: %s\n",
+	"Error: --memory-workers must not be negative\n":                                                        "エラー: --memory-workers に負の値は指定できません\n",
+	"Memory mode: %s": "メモリの操作: %s",
+	"churn, rewriting the allocation at %s on %d workers":                                                          "churn (確保したメモリを %s で書き換え、ワーカー %d 個)",
+	"bandwidth, streaming reads and writes on %d workers":                                                          "bandwidth (%d 個のワーカーで連続した読み書き)",
+	"lock, pinned in physical memory with mlock":                                                                   "lock (mlock で物理メモリに固定)",
+	"swap, allowed beyond physical memory and rewritten at %s on %d workers":                                       "swap (物理メモリを超えて確保し、%s で書き換え、ワーカー %d 個)",
+	"Allocating memory in 64MB chunks and holding it, touching one byte per page":                                  "64MB 単位でメモリを確保して保持し、各ページの1バイトに書き込みます",
+	"Rewriting the allocated memory at --memory-rate to keep dirtying pages, reporting GB/s":                       "確保したメモリを --memory-rate の速さで書き換え続けてダーティページを作ります。GB/s を報告します",
+	"Streaming reads and writes of the allocated memory on every CPU to saturate memory bandwidth, reporting GB/s": "すべての CPU で確保したメモリの連続した読み書きを行い、メモリ帯域を使い切ります。GB/s を報告します",
+	"Locking the allocated memory with mlock so that it is never swapped out or reclaimed":                         "確保したメモリを mlock でロックし、スワップアウトや回収をさせません",
+	"Allocating beyond physical memory and rewriting all of it to force swapping, reporting GB/s":                  "物理メモリを超えて確保し、全体を書き換え続けてスワップを発生させます。GB/s を報告します",
+	"Linux or macOS": "Linux または macOS",
+	"full speed":     "最大速度",
+	"Error: --level-step must be in range 0-%d\n":                                      "エラー: --level-step は 0〜%d の範囲で指定してください\n",
+	"Error: --level-step takes over SIGUSR2 and cannot be combined with --extend-by\n": "エラー: --level-step は SIGUSR2 を使用するため、--extend-by と同時には指定できません\n",
+	"Scenario not reloaded: %v":                                                        "シナリオを読み直せませんでした: %v",
+	"Stage %s has already started and is left as it is":                                "ステージ %s は開始済みのため変更しません",
+	"Stage %s would have started already and is skipped":                               "ステージ %s は開始時刻を過ぎているため追加しません",
+	"Stage %s skipped: %v":                                                             "ステージ %s を追加しません: %v",
+	"Scenario reloaded from %s: %d stage(s) scheduled, %d called off":                  "%s からシナリオを読み直しました: %d 個のステージを予定、%d 個を取り消し",
+	"%s of memory still held":                                                          "メモリ %s が解放されていません",
+	"%s could not be removed: %v":                                                      "%s を削除できません: %v",
+	"Warning: Removed %s left behind by a stressor\n":                                  "警告: 負荷生成モジュールが残した %s を削除しました\n",
+	"Warning: Cleanup incomplete: %s\n":                                                "警告: クリーンアップが完了していません: %s\n",
+	"Cleanup verified: memory released and temporary files removed":                    "クリーンアップを確認しました: メモリは解放され、一時ファイルは削除されました",
+	"CPU method: %s":                                                                                               "CPU の演算方式: %s",
+	"CPU method: all, rotating every %v":                                                                           "CPU の演算方式: all (%v ごとに切り替え)",
+	"CPU method: all, rotating on every worker":                                                                    "CPU の演算方式: all (各ワーカーで順に切り替え)",
+	"CPU method: cache, walking a %d MiB table":                                                                    "CPU の演算方式: cache (%d MiB の表をたどります)",
+	"Double precision matrix multiply, loading the FPU and SIMD units":                                             "倍精度浮動小数点数の行列積による FPU と SIMD ユニットの負荷",
+	"AES-128-CTR encryption and SHA-256 hashing, using the CPU's crypto instructions":                              "CPU の暗号命令を使用する AES-128-CTR の暗号化と SHA-256 のハッシュ",
+	"Sieve of Eratosthenes counting primes, within the L1 cache":                                                   "L1 キャッシュに収まるエラトステネスのふるいによる素数の計数",
+	"Unpredictable branches on random bits, flushing the pipeline on mispredictions":                               "乱数による予測できない分岐と、予測の失敗によるパイプラインのフラッシュ",
+	"Random pointer chase over twice the last level cache, stalling on cache misses":                               "最終レベルキャッシュの2倍の配列をランダムにたどり、キャッシュミスで待たせる負荷",
+	"Every method in turn on each worker, switching every 2 seconds":                                               "各ワーカーですべての演算方式を2秒ごとに順に切り替えて実行",
+	"holds write %d instead of write %d (lost write)":                                                              "書き込み %[2]d ではなく書き込み %[1]d のデータが残っています (書き込みの消失)",
+	"Thresholds (--fail-under):\n":                                                                                 "下限 (--fail-under):\n",
+	"  [%s] %s: no result recorded\n":                                                                              "  [%s] %s: 結果が記録されていません\n",
+	"  [%s] %s: %s on average, at least %s required\n":                                                             "  [%s] %s: 平均 %s (下限 %s)\n",
+	"  [%s] %s: achieved %.1f%% of the target, at least %s required\n":                                             "  [%s] %s: 目標の %.1f%% を達成 (下限 %s)\n",
+	"Stress test failed: %s not met.":                                                                              "負荷テストに失敗しました: %s を満たしませんでした。",
+	"Fail under: %s":                                                                                               "失敗とする下限: %s",
+	"Error: Invalid --fail-under: %v\n":                                                                            "エラー: --fail-under が無効です: %v\n",
+	"Error: one of --hosts, --ssh-hosts or --workers and the load test options after -- are required\n":            "エラー: --hosts・--ssh-hosts・--workers のいずれか1つと、-- の後に負荷テストのオプションが必要です\n",
+	"Error: --workers must be positive\n":                                                                          "エラー: --workers には正の数を指定してください\n",
+	"Error: --token is required with --workers unless --listen is a loopback address\n":                            "エラー: --workers では、--listen がループバックアドレスの場合を除き --token が必要です\n",
+	"Error: --join is required\n":                                                                                  "エラー: --join が必要です\n",
+	"[Worker] Cannot join the coordinator: %v\n":                                                                   "[Worker] コーディネーターに参加できません: %v\n",
+	"[Worker] Joined the coordinator %s as %s (%d workers so far)\n":                                               "[Worker] コーディネーター %s に %s として参加しました (現在 %d 台)\n",
+	"Waiting for %d workers to join...\n":                                                                          "%d 台のワーカーの参加を待っています...\n",
+	"Error: only %d of %d workers joined: %v\n":                                                                    "エラー: %[2]d 台中 %[1]d 台のワーカーしか参加しませんでした: %[3]v\n",
+	"Worker %d/%d joined: %s (%s)":                                                                                 "ワーカー %d/%d が参加しました: %s (%s)",
+	"Worker %d/%d joined: %s":                                                                                      "ワーカー %d/%d が参加しました: %s",
+	"Holding many open file descriptors, up to the open file limit":                                                "オープンファイル数の上限まで、多数のファイルディスクリプタを開いたまま保持する",
+	"Creating and removing small files in a deep directory tree to load the dentry cache and file system metadata": "深いディレクトリツリーで小さなファイルの作成と削除を繰り返し、dentry キャッシュとファイルシステムのメタデータに負荷をかける",
+	"Removing %d files in %s":                                                                                      "%[2]s の %[1]d 個のファイルを削除しています",
+	"Small files capped from %d to %d by the disk limit":                                                           "ディスクの上限により小さなファイルを %d 個から %d 個に減らしました",
+	"Holding %d file descriptors and churning %d files in a tree %d directories deep in %s":                        "%[4]s でファイルディスクリプタ %[1]d 個を保持し、深さ %[3]d のツリーの %[2]d 個のファイルの作成と削除を繰り返します",
+	"Churning %d files in a tree %d directories deep in %s":                                                        "%[3]s で深さ %[2]d のツリーの %[1]d 個のファイルの作成と削除を繰り返します",
+	"Holding %d file descriptors and reopening them in %s":                                                         "%[2]s でファイルディスクリプタ %[1]d 個を保持し、閉じては開き直します",
+	"Target rate: %.0f operations/s":                                                                               "目標: 毎秒 %.0f 回の操作",
+	"Created %d small files and removed %d":                                                                        "小さなファイルを %d 個作成し、%d 個削除しました",
+	"Reopened %d file descriptors":                                                                                 "ファイルディスクリプタを %d 回開き直しました",
+	"Held up to %d file descriptors":                                                                               "ファイルディスクリプタを最大 %d 個保持しました",
+	"descriptors capped by the open file limit (%d)":                                                               "オープンファイル数の上限 (%d) によりディスクリプタ数を制限",
+	"File descriptors capped from %d to %d, %d below the open file limit":                                          "ファイルディスクリプタをオープンファイル数の上限より %[3]d 少ない %[2]d 個に抑えました (指定 %[1]d 個)",
+	"Holding %d of %d file descriptors: %v":                                                                        "ファイルディスクリプタを %[2]d 個中 %[1]d 個だけ保持します: %[3]v",
+	"open file limit reached (EMFILE)":                                                                             "オープンファイル数の上限に到達 (EMFILE)",
+	"system file table full (ENFILE)":                                                                              "システムのファイルテーブルが満杯 (ENFILE)",
+	"no space or inodes left on device (ENOSPC)":                                                                   "デバイスの空き容量または inode が不足 (ENOSPC)",
+	"Error: Invalid --fd or --inodes: %v\n":                                                                        "エラー: --fd または --inodes が正しくありません: %v\n",
+	"File load: %s descriptors held, %d small files, %s creations and removals%s":                                  "ファイル負荷: ディスクリプタ %s を保持、小さなファイル %d 個、作成と削除 %s%s",
+	"File load: %d small files, %s creations and removals%s":                                                       "ファイル負荷: 小さなファイル %d 個、作成と削除 %s%s",
+	"File load: %s descriptors held, %s reopened%s":                                                                "ファイル負荷: ディスクリプタ %s を保持、開き直し %s%s",
+	"Error: Invalid --max-mem: %v\n":                                                                               "エラー: --max-mem が正しくありません: %v\n",
+	"Error: Invalid --max-disk-fill: %v\n":                                                                         "エラー: --max-disk-fill が正しくありません: %v\n",
+	"Memory use %.1f%% exceeds --max-mem %.0f%%: backing off %s":                                                   "メモリ使用率 %.1f%% が --max-mem の %.0f%% を超えたため、%s を抑制します",
+	"Memory use %.1f%% back under --max-mem %.0f%%: restoring the full load of %s":                                 "メモリ使用率 %.1f%% が --max-mem の %.0f%% を下回ったため、%s の負荷を元に戻しました",
+	"backed off to keep memory use at or below %.0f%%":                                                             "メモリ使用率を %.0f%% 以下に保つために抑制",
+	"Filesystem of %s %.1f%% full, over --max-disk-fill %.0f%%: backing off %s":                                    "%s のファイルシステムの使用率 %.1f%% が --max-disk-fill の %.0f%% を超えたため、%s を抑制します",
+	"Filesystem of %s %.1f%% full, back under --max-disk-fill %.0f%%: restoring the full load of %s":               "%s のファイルシステムの使用率 %.1f%% が --max-disk-fill の %.0f%% を下回ったため、%s の負荷を元に戻しました",
+	"backed off to keep the filesystem at or below %.0f%% full":                                                    "ファイルシステムの使用率を %.0f%% 以下に保つために抑制",
+	"CPU %s":                     "CPU %s",
+	"Memory %s %s available":     "メモリ %s 利用可能 %s",
+	"Swap %s":                    "スワップ %s",
+	"Memory pressure %.1f%%":     "メモリプレッシャー %.1f%%",
+	"Disk %s %s available in %s": "ディスク %s 空き %s (%s)",
+	"  %s: %s of %s":             "  %s: %s / 目標 %s",
+	" (paused)":                  " (一時停止中)",
+	" (backed off to %.0f%% by the safety guard)":                                    " (安全制限により %.0f%% に抑制中)",
+	"Ping-ponging messages between %d goroutine pairs and firing %d timers every %v": "%d 組のゴルーチンの間でメッセージを受け渡し、%d 個のタイマーを %v ごとに発火させています",
+	"Ping-ponging messages between %d goroutine pairs":                               "%d 組のゴルーチンの間でメッセージを受け渡しています",
+	"Firing %d timers every %v":                                                      "%d 個のタイマーを %v ごとに発火させています",
+	"Target rate: %.0f messages/s":                                                   "目標: 毎秒 %.0f 件のメッセージ",
+	"Context switches are not counted: %v":                                           "コンテキストスイッチは計測しません: %v",
+	"Passed %d messages between %d goroutine pairs":                                  "%[2]d 組のゴルーチンの間で %[1]d 件のメッセージを受け渡しました",
+	"Fired %d timers, %v late on average":                                            "タイマーを %d 回発火させました (平均の遅れ %v)",
+	"The process made %d context switches":                                           "プロセスのコンテキストスイッチは %d 回でした",
+	"Scheduler load: %d goroutine pairs, %s messages, %d timers every %v%s":          "スケジューラー負荷: ゴルーチン %d 組、メッセージ %s、タイマー %d 個 (%v ごと)%s",
+	"Scheduler load: %d goroutine pairs, %s messages%s":                              "スケジューラー負荷: ゴルーチン %d 組、メッセージ %s%s",
+	"Scheduler load: %d timers every %v%s":                                           "スケジューラー負荷: タイマー %d 個 (%v ごと)%s",
+	"Error: Invalid --switch or --timers: %v\n":                                      "エラー: --switch または --timers が正しくありません: %v\n",
+}
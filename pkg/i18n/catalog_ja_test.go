@@ -0,0 +1,73 @@
+package i18n
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// directives returns the formatting directive (flags, width, precision and verb)
+// of each argument of a format string by argument number, resolving explicit
+// indexes such as %[2]s and %.0[1]f.
+func directives(format string) map[int]string {
+	found := make(map[int]string)
+	arg := 1
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			i++
+			continue
+		}
+		var directive strings.Builder
+		for i++; i < len(format); i++ {
+			c := format[i]
+			if c == '[' {
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					break
+				}
+				if n, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+					arg = n
+				}
+				i += end
+				continue
+			}
+			directive.WriteByte(c)
+			if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+				break
+			}
+		}
+		found[arg] += directive.String()
+		arg++
+	}
+	return found
+}
+
+func TestDirectives(t *testing.T) {
+	tests := []struct {
+		format string
+		want   map[int]string
+	}{
+		{"%s of %d%%", map[int]string{1: "s", 2: "d"}},
+		{"%[2]s の %[1]s", map[int]string{1: "s", 2: "s"}},
+		{"GPU %[2]d の使用率 %.0[1]f%%%[3]s", map[int]string{1: ".0f", 2: "d", 3: "s"}},
+		{"%-10s %5.1f", map[int]string{1: "-10s", 2: "5.1f"}},
+		{"%[2]v then %v", map[int]string{2: "v", 3: "v"}},
+	}
+	for _, tt := range tests {
+		if got := directives(tt.format); !maps.Equal(got, tt.want) {
+			t.Errorf("directives(%q) = %v, want %v", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestJapaneseVerbs(t *testing.T) {
+	for key, translated := range japanese {
+		if want, got := directives(key), directives(translated); !maps.Equal(got, want) {
+			t.Errorf("translation of %q has verbs %v, want %v", key, got, want)
+		}
+	}
+}
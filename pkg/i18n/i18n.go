@@ -0,0 +1,106 @@
+// Package i18n はユーザー向けメッセージの言語 (英語・日本語) を切り替えます。
+//
+// メッセージは英語の書式文字列をキーとしてカタログから翻訳します。カタログにない
+// メッセージ (OS やライブラリが返すエラーの詳細など) は英語のまま表示します。
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Lang はメッセージの言語です。
+type Lang string
+
+const (
+	English  Lang = "en"
+	Japanese Lang = "ja"
+)
+
+// catalogs holds the translations of each language other than English, keyed by
+// the English format string.
+var catalogs = map[Lang]map[string]string{
+	Japanese: japanese,
+}
+
+var current atomic.Value // Lang
+
+func init() {
+	current.Store(Detect())
+}
+
+// Parse は "en"・"ja" などの言語指定を Lang に変換します。
+//
+// 引数:
+//
+//	s - 言語指定 (en, ja, または ja_JP.UTF-8 のようなロケール名)
+func Parse(s string) (Lang, error) {
+	lang := strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case lang == "en" || strings.HasPrefix(lang, "en_") || lang == "c" || lang == "posix":
+		return English, nil
+	case lang == "ja" || strings.HasPrefix(lang, "ja_"):
+		return Japanese, nil
+	}
+	return English, fmt.Errorf("unsupported language %q (supported: en, ja)", s)
+}
+
+// Detect は環境変数 LC_ALL・LC_MESSAGES・LANG から言語を判定します。
+// 最初に設定されている変数のロケールが日本語の場合は Japanese、それ以外は English を返します。
+func Detect() Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if lang, err := Parse(value); err == nil {
+				return lang
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// SetLang はメッセージの言語を変更します。
+func SetLang(lang Lang) {
+	current.Store(lang)
+}
+
+// Current は現在のメッセージの言語を返します。
+func Current() Lang {
+	return current.Load().(Lang)
+}
+
+// T は英語のメッセージ (書式文字列) を現在の言語に翻訳します。
+// 翻訳がない場合は message をそのまま返します。
+//
+// 引数:
+//
+//	message - 英語のメッセージ
+func T(message string) string {
+	if translated, ok := catalogs[Current()][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf は format を現在の言語に翻訳してから書式化します。
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Translations は message とそのすべての言語への翻訳を返します。
+// 他の言語で実行された stress-go の出力を解釈する場合に使用します。
+//
+// 引数:
+//
+//	message - 英語のメッセージ
+func Translations(message string) []string {
+	variants := []string{message}
+	for _, catalog := range catalogs {
+		if translated, ok := catalog[message]; ok {
+			variants = append(variants, translated)
+		}
+	}
+	return variants
+}
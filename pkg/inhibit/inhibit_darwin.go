@@ -0,0 +1,23 @@
+package inhibit
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// Acquire は負荷テスト中のスリープを抑止し、抑止を解除する関数を返します。
+// macOS では caffeinate により IOPMAssertion を取得します。
+func Acquire(reason string) (func(), error) {
+	// -i prevents idle sleep; -w releases the assertion if this process dies unexpectedly
+	cmd := exec.Command("caffeinate", "-i", "-w", strconv.Itoa(os.Getpid()))
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start caffeinate: %v", err)
+	}
+
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}, nil
+}
@@ -0,0 +1,55 @@
+package inhibit
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// startupGrace is how long systemd-inhibit is given to fail (e.g. no D-Bus) before
+// the lock is considered taken.
+const startupGrace = 200 * time.Millisecond
+
+// Acquire は負荷テスト中のスリープ・サスペンドを抑止し、抑止を解除する関数を返します。
+// Linux では systemd-inhibit を使用します。
+func Acquire(reason string) (func(), error) {
+	path, err := exec.LookPath("systemd-inhibit")
+	if err != nil {
+		return nil, fmt.Errorf("systemd-inhibit not found: %v", err)
+	}
+
+	// The inhibitor lock is held for as long as the child command runs
+	cmd := exec.Command(path,
+		"--what=sleep:idle",
+		"--who=stress-go",
+		"--why="+reason,
+		"--mode=block",
+		"sleep", "infinity")
+	// Release the lock even if this process exits without calling the release function
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start systemd-inhibit: %v", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+		return nil, fmt.Errorf("systemd-inhibit failed: %s", strings.TrimSpace(stderr.String()))
+	case <-time.After(startupGrace):
+	}
+
+	return func() {
+		cmd.Process.Kill()
+		<-exited
+	}, nil
+}
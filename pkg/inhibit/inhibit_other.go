@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package inhibit
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Acquire はこのプラットフォームでは未対応です。
+func Acquire(reason string) (func(), error) {
+	return nil, fmt.Errorf("sleep inhibition is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,52 @@
+package inhibit
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+)
+
+// Execution state flags for SetThreadExecutionState.
+const (
+	esContinuous     = 0x80000000
+	esSystemRequired = 0x00000001
+)
+
+var (
+	kernel32                = syscall.NewLazyDLL("kernel32.dll")
+	setThreadExecutionState = kernel32.NewProc("SetThreadExecutionState")
+)
+
+// Acquire は負荷テスト中のスリープを抑止し、抑止を解除する関数を返します。
+// Windows では SetThreadExecutionState を使用します。
+func Acquire(reason string) (func(), error) {
+	// The execution state belongs to the calling thread, so it is set and cleared
+	// from a goroutine locked to a single OS thread.
+	result := make(chan error)
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer close(done)
+
+		ret, _, errno := setThreadExecutionState.Call(uintptr(esContinuous | esSystemRequired))
+		if ret == 0 {
+			result <- fmt.Errorf("SetThreadExecutionState failed: %v", errno)
+			return
+		}
+		result <- nil
+
+		<-release
+		setThreadExecutionState.Call(uintptr(esContinuous))
+	}()
+
+	if err := <-result; err != nil {
+		return nil, err
+	}
+	return func() {
+		close(release)
+		<-done
+	}, nil
+}
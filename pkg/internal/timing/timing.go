@@ -0,0 +1,32 @@
+// Package timing は負荷生成モジュールが負荷の速さを抑えたり、一時停止中に待機したりするための共通の処理です。
+package timing
+
+import (
+	"context"
+	"time"
+)
+
+// IdleInterval は一時停止中や処理する対象がないワーカーが、再開すべきかどうかを確認する間隔です。
+const IdleInterval = 100 * time.Millisecond
+
+// Sleep は d だけ待機します。先に ctx が終了した場合は false を返します。
+// d が 0 以下の場合は待機せず、ctx が終了しているかどうかだけを確認します。
+//
+// 引数:
+//
+//	ctx - 待機を中断するコンテキスト
+//	d   - 待機する時間
+func Sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		// Pacing loops that fall behind call this on every operation
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,29 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSleep(t *testing.T) {
+	if !Sleep(context.Background(), time.Millisecond) {
+		t.Error("Sleep() = false, want true")
+	}
+	if !Sleep(context.Background(), -time.Second) {
+		t.Error("Sleep() of a negative duration = false, want true")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if Sleep(ctx, time.Hour) {
+		t.Error("Sleep() after cancel = true, want false")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Sleep() after cancel took %v, want it to return at once", elapsed)
+	}
+	if Sleep(ctx, 0) {
+		t.Error("Sleep(0) after cancel = true, want false")
+	}
+}
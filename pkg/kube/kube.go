@@ -0,0 +1,176 @@
+// Package kube は Kubernetes API サーバーの REST API を呼び出す最小限のクライアントです。
+//
+// stress-go は標準ライブラリのみに依存するため、client-go の代わりにオペレーターが
+// 必要とする操作 (JSON の取得・作成・更新・削除) だけを実装しています。
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir is where Kubernetes mounts the pod's service account credentials.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// requestTimeout bounds every API request.
+const requestTimeout = 30 * time.Second
+
+// Client は Kubernetes API サーバーのクライアントです。
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// APIError は API サーバーがエラーを返した場合のエラーです。
+type APIError struct {
+	// Code は HTTP ステータスコードです。
+	Code int
+	// Message は API サーバーが返した Status のメッセージです。
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("kubernetes API error %d: %s", e.Code, e.Message)
+}
+
+// IsNotFound は err がリソースが存在しないことを示すかどうかを返します。
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
+
+// IsConflict は err がリソースの競合 (作成済み、または resourceVersion の不一致) を示すかどうかを返します。
+func IsConflict(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict
+}
+
+// InCluster は Pod のサービスアカウントの認証情報を使用する Client を作成します。
+func InCluster() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster (KUBERNETES_SERVICE_HOST is not set)")
+	}
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %v", err)
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid service account CA certificate")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &Client{
+		server: "https://" + net.JoinHostPort(host, port),
+		token:  strings.TrimSpace(string(token)),
+		http:   &http.Client{Transport: transport, Timeout: requestTimeout},
+	}, nil
+}
+
+// New は server (例: "http://127.0.0.1:8001" の kubectl proxy) に接続する Client を作成します。
+//
+// 引数:
+//
+//	server - API サーバーの URL
+//	token  - Bearer トークン (空の場合は送信しない)
+func New(server, token string) *Client {
+	return &Client{
+		server: strings.TrimRight(server, "/"),
+		token:  token,
+		http:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Get は path のリソースを取得して out にデコードします。
+func (c *Client) Get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, "", nil, out)
+}
+
+// Create は path のコレクションに obj を作成し、作成されたリソースを out にデコードします (out は nil可)。
+func (c *Client) Create(ctx context.Context, path string, obj, out any) error {
+	return c.do(ctx, http.MethodPost, path, "application/json", obj, out)
+}
+
+// Update は path のリソースを obj で置き換えます。obj の resourceVersion が古い場合は競合エラーになります。
+func (c *Client) Update(ctx context.Context, path string, obj, out any) error {
+	return c.do(ctx, http.MethodPut, path, "application/json", obj, out)
+}
+
+// Patch は path のリソースに JSON マージパッチを適用します。
+func (c *Client) Patch(ctx context.Context, path string, patch, out any) error {
+	return c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", patch, out)
+}
+
+// Delete は path のリソースを削除します。リソースが存在しない場合は何もしません。
+func (c *Client) Delete(ctx context.Context, path string) error {
+	err := c.do(ctx, http.MethodDelete, path, "", nil, nil)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// do sends one request and decodes the JSON response into out unless it is nil.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(data))
+		}
+		return &APIError{Code: resp.StatusCode, Message: status.Message}
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s %s: %v", method, path, err)
+	}
+	return nil
+}
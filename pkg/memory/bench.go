@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// benchMaxSize is the largest buffer the memory benchmark copies; large enough to
+// defeat CPU caches on any current server.
+const benchMaxSize = 1024 * 1024 * 1024
+
+// BenchResult は Benchmark の結果です。
+type BenchResult struct {
+	// Size はコピーに使ったバッファの合計サイズ（バイト）です。
+	Size int64
+	// BytesPerSecond は1秒あたりに読み書きしたバイト数です。コピーしたバイト数の2倍 (読み込みと書き込み) です。
+	BytesPerSecond float64
+}
+
+// Benchmark は d の間、論理 CPU ごとのワーカーでバッファ間のコピーを繰り返し、メモリ帯域を計測します。
+// バッファは空きメモリの 1/4 (最大 1GiB) です。
+//
+// 引数:
+//
+//	ctx - 計測を中断するためのコンテキスト
+//	d   - 計測時間
+func Benchmark(ctx context.Context, d time.Duration) (BenchResult, error) {
+	available, err := calculatePercentageSize(nil, 25, 0)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	workers := runtime.GOMAXPROCS(0)
+	// Each worker copies between two halves of its own share
+	half := min(available, benchMaxSize) / int64(workers) / 2
+	if half < 1024*1024 {
+		return BenchResult{}, fmt.Errorf("insufficient free memory for the memory benchmark")
+	}
+
+	buffers := make([][]byte, workers)
+	for i := range buffers {
+		buffers[i] = make([]byte, 2*half)
+		initializeBuffer(buffers[i])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, buffer := range buffers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src, dst := buffer[:half], buffer[half:]
+			var moved int64
+			for ctx.Err() == nil {
+				copy(dst, src)
+				src, dst = dst, src
+				moved += 2 * half
+			}
+			total.Add(moved)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.KeepAlive(buffers)
+	return BenchResult{Size: 2 * half * int64(workers), BytesPerSecond: float64(total.Load()) / elapsed.Seconds()}, nil
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+// pageSize is the unit in which zram and zswap compress memory.
+const pageSize = 4096
+
+// Content はメモリ負荷が確保したメモリに書き込むデータの種類です。
+// zram・zswap を使用するシステムでは、同じサイズの確保でもデータの圧縮率によって実際に消費するメモリが大きく変わります。
+type Content string
+
+const (
+	// ContentCompressible は短いデータの繰り返しで各ページを埋めます。zram・zswap で数十分の一に圧縮されます。
+	ContentCompressible Content = "compressible"
+	// ContentIncompressible は各ページを乱数で埋めます。圧縮できないため、確保した分だけメモリを消費します。
+	ContentIncompressible Content = "incompressible"
+	// ContentMixed は各ページの半分を乱数で、残りを繰り返しで埋めます。圧縮率はおよそ 2:1 です。
+	ContentMixed Content = "mixed"
+)
+
+// Contents はすべてのデータの種類です。
+var Contents = []Content{ContentCompressible, ContentIncompressible, ContentMixed}
+
+// Validate はデータの種類が有効かどうかを検証します。空の場合は各ページの1バイトだけを書き込みます。
+func (c Content) Validate() error {
+	if c != "" && !slices.Contains(Contents, c) {
+		return fmt.Errorf("unknown memory content %q (compressible, incompressible or mixed)", c)
+	}
+	return nil
+}
+
+// fillContent writes content to buffer page by page, drawing the random parts
+// from data. Each page starts differently, so that zram does not store pages
+// filled with the same word, or identical pages, at no cost.
+func fillContent(buffer []byte, content Content, data *rand.ChaCha8) {
+	if content == "" {
+		initializeBuffer(buffer)
+		return
+	}
+	for start := 0; start < len(buffer); start += pageSize {
+		page := buffer[start:min(start+pageSize, len(buffer))]
+		random := len(page)
+		switch content {
+		case ContentCompressible:
+			random = min(64, len(page))
+		case ContentMixed:
+			random = len(page) / 2
+		}
+		data.Read(page[:random])
+		if random == 0 {
+			continue
+		}
+		// Repeat the random head over the rest of the page
+		for i := random; i < len(page); i += random {
+			copy(page[i:], page[:random])
+		}
+	}
+}
+
+// newContentSource returns the generator of the random parts of the content.
+// The key also holds the package name so that other users of the same seed get
+// different streams.
+func newContentSource(seed uint64) *rand.ChaCha8 {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	copy(key[8:], "stress-go memory")
+	return rand.NewChaCha8(key)
+}
@@ -0,0 +1,281 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/supervise"
+)
+
+// Controller は実行中のメモリ負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	group  *supervise.Group
+	cancel context.CancelFunc
+	peak   int64
+	err    error
+
+	override  atomic.Int64  // target set by SetTarget, or -1
+	scale     atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused    atomic.Bool
+	target    atomic.Int64
+	allocated atomic.Int64
+	changed   chan struct{}
+	// percent is what Options.Percent resolved to at the latest sample of the monitor
+	percent percentTarget
+	// drops carries DropChunk requests to the run loop; stopped is closed when it ends.
+	drops   chan dropRequest
+	stopped chan struct{}
+
+	// mu guards buffers, the allocated memory published to the workers of Options.Mode
+	mu      sync.Mutex
+	buffers [][]byte
+	// read and written are the totals of the workers; bandwidth is
+	// math.Float64bits of the rate of the last tick, average the rate over the run
+	read       atomic.Int64
+	written    atomic.Int64
+	bandwidth  atomic.Uint64
+	average    float64
+	lockFailed bool
+}
+
+// held is the memory allocated by all controllers together, for HeldBytes.
+var held atomic.Int64
+
+// HeldBytes は実行中のすべてのメモリ負荷が現在確保しているメモリの合計 (バイト) を返します。
+func HeldBytes() int64 {
+	return held.Load()
+}
+
+// setAllocated records the bytes the controller now holds. Released bytes are
+// returned to the shared budget, which allocate reserved them from.
+func (c *Controller) setAllocated(bytes int64) {
+	change := bytes - c.allocated.Swap(bytes)
+	held.Add(change)
+	if change < 0 {
+		c.opts.Budget.Release(-change)
+	}
+}
+
+// dropRequest asks the run loop to release one chunk. The reply describes the
+// released chunk, or is empty if nothing is allocated.
+type dropRequest struct {
+	rng   *rand.Rand
+	reply chan string
+}
+
+// Stats は実行中のメモリ負荷の状態です。
+type Stats struct {
+	// Target は現在の目標メモリサイズ（バイト）です。
+	Target int64
+	// Allocated は現在確保しているメモリの量（バイト）です。
+	Allocated int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// BytesPerSecond は Options.Mode のワーカーが直近に読み書きした1秒あたりのバイト数です。
+	BytesPerSecond float64
+}
+
+// Start は opts に従ってメモリ負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続きます。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Memory"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+
+	c := &Controller{
+		opts:    opts,
+		changed: make(chan struct{}, 1),
+		drops:   make(chan dropRequest),
+		stopped: make(chan struct{}),
+	}
+	c.override.Store(-1)
+	c.scale.Store(math.Float64bits(1))
+
+	// Fail early when the initial target cannot be determined
+	if opts.Percent > 0 {
+		c.percent.resolve(opts.Monitor, opts.Percent, 0)
+	}
+	if _, err := c.targetSize(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case opts.Percent > 0:
+		opts.Recorder.Logf("Memory", "Starting dynamic load generation with %.1f%% of free memory", opts.Percent)
+	case opts.Target != nil:
+		opts.Recorder.Logf("Memory", "Starting variable load generation")
+	default:
+		opts.Recorder.Logf("Memory", "Starting load generation with %d MiB", opts.Size/(1024*1024))
+	}
+	if opts.Verify {
+		opts.Recorder.Logf("Memory", "Verifying allocated memory with test patterns")
+	}
+	if opts.Content != "" {
+		opts.Recorder.Logf("Memory", "Filling allocated memory with %s data", opts.Content)
+	}
+	switch opts.Mode {
+	case ModeLock:
+		opts.Recorder.Logf("Memory", "Locking allocated memory in physical memory")
+	case ModeSwap:
+		opts.Recorder.Logf("Memory", "Allowing allocation beyond physical memory to force swapping")
+	}
+
+	measureBaseline()
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.group, ctx = supervise.WithContext(ctx)
+	if opts.Percent > 0 {
+		c.follow(ctx, opts.Monitor)
+	}
+	c.group.Go(func() {
+		defer close(c.stopped)
+		c.err = c.run(ctx)
+	})
+	return c, nil
+}
+
+// percentTarget holds the allocation a percentage of free memory resolved to,
+// or the error resolving it.
+type percentTarget struct {
+	mu   sync.Mutex
+	size int64
+	err  error
+}
+
+// resolve resolves percent against the free memory m reports.
+func (p *percentTarget) resolve(m *monitor.Monitor, percent float64, allocated int64) {
+	size, err := calculatePercentageSize(m, percent, allocated)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size, p.err = size, err
+}
+
+func (p *percentTarget) get() (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size, p.err
+}
+
+// follow resolves Options.Percent again on every sample of m, sampling with a
+// monitor of its own when m is nil, and brings the allocation in line with it.
+func (c *Controller) follow(ctx context.Context, m *monitor.Monitor) {
+	if m == nil {
+		m = monitor.New(monitor.DefaultInterval)
+		c.group.Go(func() { m.Run(ctx) })
+	}
+	samples := m.Subscribe(ctx)
+	c.group.Go(func() {
+		for range samples {
+			c.percent.resolve(m, c.opts.Percent, c.allocated.Load())
+			c.notify()
+		}
+	})
+}
+
+// DropChunk は障害注入のために、rng で選んだ確保済みのメモリチャンク1つを解放し、その内容を返します。
+// 解放した分は次の調整で確保し直します。
+func (c *Controller) DropChunk(rng *rand.Rand) (string, error) {
+	req := dropRequest{rng: rng, reply: make(chan string, 1)}
+	select {
+	case c.drops <- req:
+	case <-c.stopped:
+		return "", fmt.Errorf("memory load is not running")
+	}
+	if detail := <-req.reply; detail != "" {
+		return detail, nil
+	}
+	return "", fmt.Errorf("no memory allocated")
+}
+
+// SetTarget は目標メモリサイズ（バイト）を変更します。
+// 以降は Options で指定したサイズ・パーセンテージ・関数の代わりにこの値を使用します。
+func (c *Controller) SetTarget(bytes int64) {
+	c.override.Store(max(bytes, 0))
+	c.notify()
+}
+
+// SetScale は Options で指定した目標メモリサイズに掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で目標を指定している場合は影響しません。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+	c.notify()
+}
+
+// Pause は確保しているメモリをすべて解放し、Resume が呼ばれるまで負荷を止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+	c.notify()
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+	c.notify()
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	c.group.Wait()
+	return Result{PeakBytes: c.peak, BytesPerSecond: c.average}, c.err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:         c.target.Load(),
+		Allocated:      c.allocated.Load(),
+		Paused:         c.paused.Load(),
+		BytesPerSecond: math.Float64frombits(c.bandwidth.Load()),
+	}
+}
+
+// notify wakes the allocation loop so that a change takes effect immediately.
+func (c *Controller) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// targetSize returns the number of bytes that should currently be allocated.
+func (c *Controller) targetSize() (int64, error) {
+	if c.paused.Load() {
+		return 0, nil
+	}
+	if override := c.override.Load(); override >= 0 {
+		return override, nil
+	}
+	var size int64
+	var err error
+	switch {
+	case c.opts.Percent > 0:
+		size, err = c.percent.get()
+	case c.opts.Target != nil:
+		size = max(c.opts.Target(), 0)
+	default:
+		size = c.opts.Size
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(float64(size) * math.Float64frombits(c.scale.Load())), nil
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+const (
+	// chaseBatch is the number of dependent loads between clock reads.
+	chaseBatch = 1 << 14
+	// injectChunk is the size each bandwidth worker copies between delays.
+	injectChunk = 256 * 1024
+	// defaultChaseSize is the smallest default chase buffer size; it grows with
+	// the last level cache when that is detected.
+	defaultChaseSize = 256 * 1024 * 1024
+	// chaseCacheMultiple is how many times the last level cache the default
+	// chase buffer is, so that almost every load misses the caches.
+	chaseCacheMultiple = 4
+)
+
+// LatencyOptions はメモリレイテンシ計測の設定です。
+type LatencyOptions struct {
+	// Size はポインタチェイスに使うバッファのサイズ（バイト）です。0 の場合は DefaultLatencySize です。
+	// CPU のキャッシュより十分大きくする必要があります。
+	Size int64
+	// LineSize はキャッシュラインのサイズ（バイト）です。ポインタチェイスはキャッシュライン1つにつき1回だけ読み込みます。
+	// 0 の場合は検出したサイズ (検出できない場合は 64 バイト) を使用します。
+	LineSize int
+	// Duration は負荷レベルごとの計測時間です。
+	Duration time.Duration
+	// Levels は帯域負荷のレベル (0 で負荷なし、1 で最大) の一覧です。0 はアイドル時のレイテンシを計測します。
+	Levels []float64
+	// Workers は帯域負荷をかけるワーカーの数です。0 の場合は論理 CPU 数から1を引いた数 (最小 1) です。
+	Workers int
+	// Progress は負荷レベルごとの計測が終わるたびに呼ばれます（nil可）。
+	Progress func(LatencyPoint)
+}
+
+// LatencyPoint は1つの負荷レベルでのレイテンシの計測結果です。
+type LatencyPoint struct {
+	// Level は帯域負荷のレベル (0 から 1) です。
+	Level float64
+	// BytesPerSecond は帯域負荷のワーカーが1秒あたりに読み書きしたバイト数です。
+	BytesPerSecond float64
+	// Nanoseconds はメモリの読み込み1回あたりの平均レイテンシ（ナノ秒）です。
+	Nanoseconds float64
+}
+
+// MeasureLatency は依存関係のある読み込みを繋いだポインタチェイスでメモリアクセスのレイテンシを計測します。
+// opts.Levels の負荷レベルごとに、帯域負荷のワーカーがバッファ間のコピーとその間の待機を繰り返す中で計測し、
+// 負荷の大きさとレイテンシの関係 (loaded latency) を返します。
+//
+// 引数:
+//
+//	ctx  - 計測を中断するためのコンテキスト
+//	opts - 計測の設定
+func MeasureLatency(ctx context.Context, opts LatencyOptions) ([]LatencyPoint, error) {
+	size := opts.Size
+	if size == 0 {
+		size = DefaultLatencySize()
+	}
+	lineSize := opts.LineSize
+	if lineSize == 0 {
+		caches, _ := sysinfo.ReadCaches()
+		lineSize = caches.LineSize()
+	}
+	if lineSize < 8 || lineSize&(lineSize-1) != 0 {
+		return nil, fmt.Errorf("cache line size must be a power of two of at least 8 bytes")
+	}
+	if size < 1024*1024 {
+		return nil, fmt.Errorf("latency buffer must be at least 1MB")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("latency duration must be positive")
+	}
+	for _, level := range opts.Levels {
+		if level < 0 || level > 1 {
+			return nil, fmt.Errorf("load level must be in range 0-100%%")
+		}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = max(runtime.GOMAXPROCS(0)-1, 1)
+	}
+
+	chase := newChase(size/8, int64(lineSize/8))
+	var buffers [][]byte
+	if slices.ContainsFunc(opts.Levels, func(level float64) bool { return level > 0 }) {
+		available, err := calculatePercentageSize(nil, 25, 0)
+		if err != nil {
+			return nil, err
+		}
+		half := min(available, benchMaxSize) / int64(workers) / 2
+		if half < injectChunk {
+			return nil, fmt.Errorf("insufficient free memory for the bandwidth load")
+		}
+		buffers = make([][]byte, workers)
+		for i := range buffers {
+			buffers[i] = make([]byte, 2*half)
+			initializeBuffer(buffers[i])
+		}
+	}
+
+	var points []LatencyPoint
+	for _, level := range opts.Levels {
+		point := measureLoadedLatency(ctx, chase, buffers, level, opts.Duration)
+		if ctx.Err() != nil {
+			return points, ctx.Err()
+		}
+		points = append(points, point)
+		if opts.Progress != nil {
+			opts.Progress(point)
+		}
+	}
+	runtime.KeepAlive(buffers)
+	return points, nil
+}
+
+// DefaultLatencySize は MeasureLatency が既定で使用するバッファのサイズ (バイト) を返します。
+// 256MB と、検出した最終レベルキャッシュ (L3 など) の 4 倍のうち大きい方です。
+func DefaultLatencySize() int64 {
+	caches, err := sysinfo.ReadCaches()
+	if err != nil {
+		return defaultChaseSize
+	}
+	last, _ := caches.LastLevel()
+	return max(defaultChaseSize, chaseCacheMultiple*last.Size)
+}
+
+// newChase builds a chase buffer of n words in which following the stored
+// indices from entry 0 visits every cache line of lineWords words once, in
+// random order, so that hardware prefetchers cannot predict the next load.
+func newChase(n, lineWords int64) []uint64 {
+	lines := n / lineWords
+	order := make([]uint64, lines)
+	for i := range order {
+		order[i] = uint64(i)
+	}
+	// Sattolo's algorithm yields a single cycle through all lines
+	for i := len(order) - 1; i > 0; i-- {
+		j := rand.IntN(i)
+		order[i], order[j] = order[j], order[i]
+	}
+
+	chase := make([]uint64, lines*lineWords)
+	for i, line := range order {
+		next := order[(i+1)%len(order)]
+		chase[line*uint64(lineWords)] = next * uint64(lineWords)
+	}
+	return chase
+}
+
+// measureLoadedLatency runs the chase for d while the bandwidth workers copy
+// their buffers at level, and returns the mean latency per load.
+func measureLoadedLatency(ctx context.Context, chase []uint64, buffers [][]byte, level float64, d time.Duration) LatencyPoint {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	var moved atomic.Int64
+	var wg sync.WaitGroup
+	if level > 0 {
+		for _, buffer := range buffers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				moved.Add(injectBandwidth(ctx, buffer, level))
+			}()
+		}
+	}
+
+	// Chase on a locked thread, leaving the workers the other CPUs
+	runtime.LockOSThread()
+	start := time.Now()
+	var loads int64
+	p := uint64(0)
+	for ctx.Err() == nil {
+		for range chaseBatch {
+			p = chase[p]
+		}
+		loads += chaseBatch
+	}
+	elapsed := time.Since(start)
+	runtime.UnlockOSThread()
+	wg.Wait()
+	sink.Store(p)
+
+	return LatencyPoint{
+		Level:          level,
+		BytesPerSecond: float64(moved.Load()) / elapsed.Seconds(),
+		Nanoseconds:    float64(elapsed.Nanoseconds()) / float64(loads),
+	}
+}
+
+// sink keeps the result of the chase alive so that the loop is not optimized away.
+var sink atomic.Uint64
+
+// injectBandwidth copies between the halves of buffer in chunks until ctx is
+// done, spinning after each chunk for as long as needed to keep the copies to
+// level of the time. It returns the bytes read and written.
+func injectBandwidth(ctx context.Context, buffer []byte, level float64) int64 {
+	half := len(buffer) / 2
+	src, dst := buffer[:half], buffer[half:]
+	var moved int64
+	offset := 0
+	for ctx.Err() == nil {
+		start := time.Now()
+		copy(dst[offset:offset+injectChunk], src[offset:offset+injectChunk])
+		moved += 2 * injectChunk
+		if level < 1 {
+			// Busy-wait instead of sleeping: a sleep is far coarser than one copy
+			idle := time.Duration(float64(time.Since(start)) * (1 - level) / level)
+			for spinStart := time.Now(); time.Since(spinStart) < idle; {
+			}
+		}
+		if offset += injectChunk; offset+injectChunk > half {
+			offset = 0
+			src, dst = dst, src
+		}
+	}
+	return moved
+}
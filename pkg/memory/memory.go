@@ -1,198 +1,414 @@
 package memory
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"runtime"
 	"runtime/debug"
+	"slices"
 	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/monitor"
 )
 
-// GenerateLoad は指定されたメモリサイズで負荷を生成します。
+// adjustInterval is how often the allocation is brought in line with the target.
+const adjustInterval = 2 * time.Second
+
+// chunkSize is the largest single buffer. Memory is grown and released in chunks so
+// that lowering the target frees memory promptly.
+const chunkSize = 64 * 1024 * 1024
+
+// Options はメモリ負荷の設定です。Size・Percent・Target のいずれか1つを指定します。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Memory" を使用します。
+	// 同じ種類の負荷を複数のジョブとして実行する場合に、ジョブを区別するために指定します。
+	Name string
+	// Size は確保するメモリサイズ（バイト）です。
+	Size int64
+	// Percent は空きメモリに対するパーセンテージです。空きメモリの変化に合わせて確保量を調整します。
+	Percent float64
+	// Monitor は Percent の確保量の計算に使用する空きメモリの測定値の提供元です (nil可)。
+	// 測定のたびに確保量を計算し直します。nil の場合は負荷が自分で測定します。
+	Monitor *monitor.Monitor
+	// Target は目標メモリサイズ（バイト）を返す関数です。調整のたびに呼び出されます。
+	Target func() int64
+	// MaxBytes は確保するメモリの上限（バイト）です。目標サイズにかかわらず、
+	// この上限を超えて確保することはありません。0 の場合は制限しません。
+	MaxBytes int64
+	// Budget は実行全体の負荷生成モジュールで共有するメモリの上限です (nil可)。
+	// 確保する前に予約し、解放したときに返却するため、MaxBytes と同じく超えることはありません。
+	Budget *budget.Budget
+	// Verify は確保したメモリに検証用のパターンを書き込み、定期的に読み戻して検証します。
+	// 結果は Recorder に記録します。
+	Verify bool
+	// Content は確保したメモリに書き込むデータの種類です。空の場合は各ページの1バイトだけを書き込みます。
+	// Verify とは同時に指定できません (Verify のパターンは圧縮できないデータです)。
+	Content Content
+	// Mode は確保したメモリに対して続ける操作です。空の場合は確保したメモリを保持するだけです。
+	// ModeChurn・ModeBandwidth・ModeSwap は Verify とは同時に指定できません (検証用のパターンを上書きします)。
+	Mode Mode
+	// Rate は ModeChurn・ModeSwap で1秒あたりに書き換えるバイト数です。0 の場合は制限しません。
+	Rate int64
+	// Workers は ModeChurn・ModeBandwidth・ModeSwap の読み書きを行うワーカーの数です。
+	// 0 の場合は ModeBandwidth では論理 CPU の数、それ以外では 1 です。
+	Workers int
+	// Seed は検証用のパターンや Content のデータを生成する乱数のシードです。同じシードでは同じデータを書き込みます。
+	// 0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Result はメモリ負荷の実行結果です。
+type Result struct {
+	// PeakBytes は実行中に確保したメモリの最大量（バイト）です。
+	PeakBytes int64
+	// BytesPerSecond は Options.Mode のワーカーが1秒あたりに読み書きした平均のバイト数です。
+	// ワーカーを使わない操作では 0 です。
+	BytesPerSecond float64
+}
+
+// GenerateLoad は opts に従ってメモリ負荷を生成し、ctx が終了するまで保持します。
 //
 // 引数:
 //
 //	ctx  - 負荷生成の制御に使用するコンテキスト
-//	size - 確保するメモリサイズ（バイト）。負の値の場合は空きメモリのパーセンテージとして解釈
-func GenerateLoad(ctx context.Context, size int64) {
-	if size < 0 {
-		// Percentage specification - use dynamic adjustment
-		percent := float64(-size)
-		fmt.Printf("[Memory] Starting dynamic load generation with %.1f%% of free memory\n", percent)
-		generateDynamicLoad(ctx, percent)
-	} else {
-		// Absolute value specification - use static allocation
-		fmt.Printf("[Memory] Starting load generation with %d MB\n", size/(1024*1024))
-		generateStaticLoad(ctx, size)
+//	opts - 負荷の設定
+func GenerateLoad(ctx context.Context, opts Options) (Result, error) {
+	c, err := Start(ctx, opts)
+	if err != nil {
+		return Result{}, err
 	}
+	return c.Wait()
 }
 
-// generateStaticLoad generates a fixed amount of memory load
-func generateStaticLoad(ctx context.Context, size int64) {
+// Validate は目標サイズの指定方法 (Size・Percent・Target) がちょうど1つであることなど、オプションの値を検証します。
+func (opts Options) Validate() error {
+	specified := 0
+	for _, set := range []bool{opts.Size > 0, opts.Percent > 0, opts.Target != nil} {
+		if set {
+			specified++
+		}
+	}
+	if specified != 1 {
+		return fmt.Errorf("exactly one of size, percentage or target must be specified")
+	}
+	if opts.MaxBytes < 0 {
+		return fmt.Errorf("invalid memory limit: %d", opts.MaxBytes)
+	}
+	if opts.Verify && opts.Content != "" {
+		return fmt.Errorf("verification writes its own test patterns and cannot be combined with %s content", opts.Content)
+	}
+	if opts.Verify && opts.Mode.rewrites() {
+		return fmt.Errorf("verification cannot be combined with the %s mode, which overwrites the test patterns", opts.Mode)
+	}
+	if opts.Rate < 0 {
+		return fmt.Errorf("invalid memory rate: %d", opts.Rate)
+	}
+	if opts.Workers < 0 {
+		return fmt.Errorf("invalid number of memory workers: %d", opts.Workers)
+	}
+	if err := opts.Mode.Validate(); err != nil {
+		return err
+	}
+	return opts.Content.Validate()
+}
+
+// run keeps the allocated memory in line with the controller's target until ctx is done,
+// adjusting on every tick and whenever the target is changed. It returns an error
+// only when the initial target cannot be determined.
+func (c *Controller) run(ctx context.Context) error {
+	recorder := c.opts.Recorder
+
 	// Disable GC to ensure memory retention
 	oldGCPercent := debug.SetGCPercent(-1)
 	defer debug.SetGCPercent(oldGCPercent)
 
-	// Allocate memory
-	buffer := make([]byte, size)
-	
-	// Initialize memory content (to ensure actual memory usage)
-	fmt.Printf("[Memory] Initializing memory...\n")
-	for i := int64(0); i < size; i += 4096 { // Initialize in 4KB chunks
-		if i+4096 > size {
-			buffer[i] = byte(i % 256)
-		} else {
-			buffer[i] = byte(i % 256)
-		}
+	var buffers [][]byte
+	var totalAllocated int64
+	// In verify mode, seeds holds the pattern of each buffer
+	var seeds []uint64
+	nextSeed := c.opts.Seed
+	if nextSeed == 0 {
+		nextSeed = uint64(time.Now().UnixNano())
+	}
+	// content generates the data written in the buffers outside verify mode
+	content := newContentSource(nextSeed)
+	nextCheck := 0
+	// Reports the bandwidth of the workers of Options.Mode since the last tick
+	var lastRead, lastWritten int64
+	lastTime := time.Now()
+	reportBandwidth := func(now time.Time) {
+		read, written, elapsed := c.read.Load(), c.written.Load(), now.Sub(lastTime).Seconds()
+		readRate, writeRate := float64(read-lastRead)/elapsed, float64(written-lastWritten)/elapsed
+		lastRead, lastWritten, lastTime = read, written, now
+		c.bandwidth.Store(math.Float64bits(readRate + writeRate))
+		recorder.RecordValue(cmp.Or(c.opts.Name, "Memory")+" bandwidth", metrics.UnitBytesPerSecond, readRate+writeRate)
+		recorder.Logf("Memory", "Memory bandwidth: %.2f GB/s (%.2f GB/s read, %.2f GB/s written)",
+			(readRate+writeRate)/1e9, readRate/1e9, writeRate/1e9)
 	}
+	// dropped counts chunks released by DropChunk that are not yet allocated again
+	dropped := 0
 
-	fmt.Printf("[Memory] Allocated %d MB of memory\n", size/(1024*1024))
-	
-	// Periodically display memory usage
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	adjust := func(record bool) error {
+		targetSize, err := c.targetSize()
+		if err != nil {
+			recorder.Logf("Memory", "Error recalculating size: %v", err)
+			recorder.Flag("Memory", err.Error())
+			return err
+		}
+		c.target.Store(targetSize)
 
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Printf("[Memory] Stopping memory load generation\n")
-			// Release buffer reference
-			buffer = nil
-			runtime.GC()
-			return
-		case <-ticker.C:
-			showMemoryStats(size)
-			// Lightly use buffer to prevent deallocation
-			if len(buffer) > 0 {
+		if targetSize > totalAllocated {
+			// Need to allocate more
+			var additionalSize int64
+			for totalAllocated < targetSize {
+				requested := min(targetSize-totalAllocated, chunkSize)
+				buffer := allocate(requested, totalAllocated, c.opts)
+				if len(buffer) == 0 {
+					break
+				}
+				if c.opts.Verify {
+					fillPattern(buffer, nextSeed)
+					seeds = append(seeds, nextSeed)
+					nextSeed++
+				} else {
+					fillContent(buffer, c.opts.Content, content)
+				}
+				c.lockBuffer(buffer)
+				buffers = append(buffers, buffer)
+				totalAllocated += int64(len(buffer))
+				c.setAllocated(totalAllocated)
+				recorder.AddCount("Memory", "bytes_allocated", int64(len(buffer)))
+				additionalSize += int64(len(buffer))
+				if int64(len(buffer)) < requested {
+					break
+				}
+			}
+			if additionalSize > 0 {
+				c.peak = max(c.peak, totalAllocated)
+				recorder.Logf("Memory", "Increased allocation by %d MiB (total: %d MiB)",
+					additionalSize/(1024*1024), totalAllocated/(1024*1024))
+			}
+			for ; dropped > 0 && totalAllocated >= targetSize; dropped-- {
+				recorder.RecordRecovery("Memory", "drop")
+			}
+		} else if targetSize < totalAllocated {
+			// Need to release some memory
+			excessSize := totalAllocated - targetSize
+			releasedSize := int64(0)
+
+			// Release buffers from the end without dropping below the target
+			for i := len(buffers) - 1; i >= 0; i-- {
+				bufferSize := int64(len(buffers[i]))
+				if releasedSize+bufferSize > excessSize {
+					break
+				}
+				c.unlockBuffer(buffers[i])
+				buffers[i] = nil
+				buffers = buffers[:i]
+				if c.opts.Verify {
+					seeds = seeds[:i]
+				}
+				releasedSize += bufferSize
+				totalAllocated -= bufferSize
+			}
+
+			if releasedSize > 0 {
+				runtime.GC() // Force garbage collection
+				recorder.Logf("Memory", "Decreased allocation by %d MiB (total: %d MiB)",
+					releasedSize/(1024*1024), totalAllocated/(1024*1024))
+			}
+		}
+		c.setAllocated(totalAllocated)
+		c.publish(buffers)
+
+		if record {
+			showMemoryStats(recorder, totalAllocated)
+			// The memory actually held is what stays resident, not what was allocated
+			resident, measured := residentBytes(totalAllocated)
+			recorder.Record("Memory", metrics.UnitBytes, float64(targetSize), float64(resident))
+			switch {
+			case c.opts.Mode == ModeSwap && measured:
+				// Swapping is the point of the mode, so it is reported rather than flagged
+				recorder.Logf("Memory", "Resident: %d MiB of %d MiB, the rest swapped out",
+					resident/(1024*1024), totalAllocated/(1024*1024))
+			case measured && float64(resident) < float64(totalAllocated)*residentShortfall:
+				recorder.Flag("Memory", "allocated memory not resident (swapped out or reclaimed)")
+			}
+			if c.opts.Mode.rewrites() {
+				reportBandwidth(time.Now())
+			}
+		}
+
+		// Keep buffers active; in verify mode they are rewritten by verify instead,
+		// and the workers of the other modes keep them busy
+		if !c.opts.Verify && !c.opts.Mode.rewrites() {
+			for _, buffer := range buffers {
 				buffer[0] = byte(time.Now().Unix() % 256)
 			}
 		}
+		return nil
 	}
-}
 
-// generateDynamicLoad generates memory load with dynamic adjustment based on percentage
-func generateDynamicLoad(ctx context.Context, percent float64) {
-	// Disable GC to ensure memory retention
-	oldGCPercent := debug.SetGCPercent(-1)
-	defer debug.SetGCPercent(oldGCPercent)
+	// verify reads back a few buffers in turn and rewrites them with a new pattern,
+	// so that every buffer is checked and rewritten at regular intervals.
+	verify := func() {
+		for range min(len(buffers), verifyPerTick) {
+			i := nextCheck % len(buffers)
+			nextCheck = i + 1
+			if m := checkPattern(buffers[i], seeds[i]); m.count > 0 {
+				detail := i18n.Sprintf("chunk %d: %d bad words, first at offset %d (expected %#016x, read %#016x)",
+					i, m.count, m.offset, m.expected, m.actual)
+				recorder.Logf("Memory", "Verification error: %s", detail)
+				recorder.RecordVerification("Memory", 1, detail)
+			} else {
+				recorder.RecordVerification("Memory", 1)
+			}
+			seeds[i] = nextSeed
+			nextSeed++
+			fillPattern(buffers[i], seeds[i])
+		}
+	}
 
-	var buffers [][]byte
-	var totalAllocated int64
-	
-	// Check and adjust every 2 seconds
-	ticker := time.NewTicker(2 * time.Second)
+	// drop releases one chunk chosen by rng, as if it had been lost
+	drop := func(rng *rand.Rand) string {
+		if len(buffers) == 0 {
+			return ""
+		}
+		i := rng.IntN(len(buffers))
+		size := int64(len(buffers[i]))
+		c.unlockBuffer(buffers[i])
+		buffers = slices.Delete(buffers, i, i+1)
+		if c.opts.Verify {
+			seeds = slices.Delete(seeds, i, i+1)
+		}
+		totalAllocated -= size
+		c.setAllocated(totalAllocated)
+		c.publish(buffers)
+		dropped++
+		runtime.GC()
+		return i18n.Sprintf("released chunk %d (%d MiB)", i, size/(1024*1024))
+	}
+
+	ticker := time.NewTicker(adjustInterval)
 	defer ticker.Stop()
 
-	// Initial allocation
-	targetSize, err := calculatePercentageSize(percent)
-	if err != nil {
-		fmt.Printf("[Memory] Error: %v\n", err)
-		return
+	if err := adjust(false); err != nil {
+		return fmt.Errorf("initial allocation failed: %v", err)
 	}
-	
-	if targetSize > 0 {
-		buffer := make([]byte, targetSize)
-		initializeBuffer(buffer)
-		buffers = append(buffers, buffer)
-		totalAllocated = targetSize
-		fmt.Printf("[Memory] Initial allocation: %d MB\n", targetSize/(1024*1024))
-	}
-
+	started := time.Now()
+	lastTime = started
+	waitWorkers := c.startWorkers(ctx)
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Printf("[Memory] Stopping dynamic memory load generation\n")
+			recorder.Logf("Memory", "Stopping memory load generation")
+			// The workers stop before the average is taken and the buffers are released
+			waitWorkers()
+			if c.opts.Mode.rewrites() {
+				read, written := c.read.Load(), c.written.Load()
+				c.average = float64(read+written) / time.Since(started).Seconds()
+				recorder.Logf("Memory", "Achieved %.2f GB/s on average (%d MiB read, %d MiB written)",
+					c.average/1e9, read/(1024*1024), written/(1024*1024))
+			}
 			// Release all buffers
+			c.publish(nil)
 			for i := range buffers {
+				c.unlockBuffer(buffers[i])
 				buffers[i] = nil
 			}
 			buffers = nil
+			c.setAllocated(0)
 			runtime.GC()
-			return
-			
+			return nil
+		case <-c.changed:
+			adjust(false)
+		case req := <-c.drops:
+			req.reply <- drop(req.rng)
 		case <-ticker.C:
-			// Recalculate target size based on current free memory
-			newTargetSize, err := calculatePercentageSize(percent)
-			if err != nil {
-				fmt.Printf("[Memory] Error recalculating size: %v\n", err)
-				continue
-			}
-			
-			// Adjust allocation if needed
-			if newTargetSize > totalAllocated {
-				// Need to allocate more
-				additionalSize := newTargetSize - totalAllocated
-				if additionalSize > 0 {
-					buffer := make([]byte, additionalSize)
-					initializeBuffer(buffer)
-					buffers = append(buffers, buffer)
-					totalAllocated += additionalSize
-					fmt.Printf("[Memory] Increased allocation by %d MB (total: %d MB)\n", 
-						additionalSize/(1024*1024), totalAllocated/(1024*1024))
-				}
-			} else if newTargetSize < totalAllocated && len(buffers) > 1 {
-				// Need to release some memory
-				excessSize := totalAllocated - newTargetSize
-				releasedSize := int64(0)
-				
-				// Release buffers from the end
-				for i := len(buffers) - 1; i > 0 && releasedSize < excessSize; i-- {
-					bufferSize := int64(len(buffers[i]))
-					buffers[i] = nil
-					buffers = buffers[:i]
-					releasedSize += bufferSize
-					totalAllocated -= bufferSize
-				}
-				
-				if releasedSize > 0 {
-					runtime.GC() // Force garbage collection
-					fmt.Printf("[Memory] Decreased allocation by %d MB (total: %d MB)\n", 
-						releasedSize/(1024*1024), totalAllocated/(1024*1024))
-				}
-			}
-			
-			showMemoryStats(totalAllocated)
-			
-			// Keep buffers active
-			for _, buffer := range buffers {
-				if len(buffer) > 0 {
-					buffer[0] = byte(time.Now().Unix() % 256)
-				}
+			adjust(true)
+			if c.opts.Verify {
+				verify()
 			}
 		}
 	}
 }
 
+// allocate returns a buffer of the requested size, shrunk so that the stressor never
+// holds more than opts.MaxBytes together with the already allocated bytes, and
+// reserved from opts.Budget. The reservation is returned by setAllocated.
+func allocate(requested, allocated int64, opts Options) []byte {
+	if limit := opts.MaxBytes; limit > 0 && allocated+requested > limit {
+		capped := max(limit-allocated, 0)
+		if capped > 0 {
+			opts.Recorder.Logf("Memory", "Allocation of %d MiB capped to %d MiB by the memory limit",
+				requested/(1024*1024), capped/(1024*1024))
+		}
+		opts.Recorder.Flag("Memory", i18n.Sprintf("allocation capped by hard memory limit (%d MiB)", limit/(1024*1024)))
+		requested = capped
+	}
+	if granted := opts.Budget.Reserve(requested); granted < requested {
+		limit := opts.Budget.Limit()
+		if granted > 0 {
+			opts.Recorder.Logf("Memory", "Allocation of %d MiB capped to %d MiB by the memory limit",
+				requested/(1024*1024), granted/(1024*1024))
+		}
+		opts.Recorder.Flag("Memory", i18n.Sprintf("allocation capped by hard memory limit (%d MiB)", limit/(1024*1024)))
+		requested = granted
+	}
+	if requested == 0 {
+		return nil
+	}
+	return make([]byte, requested)
+}
+
 // initializeBuffer initializes buffer to ensure actual memory usage
 func initializeBuffer(buffer []byte) {
 	size := int64(len(buffer))
 	for i := int64(0); i < size; i += 4096 { // Initialize in 4KB chunks
-		if i < size {
-			buffer[i] = byte(i % 256)
-		}
+		buffer[i] = byte(i % 256)
 	}
 }
 
-// calculatePercentageSize は空きメモリのパーセンテージから実際のサイズを計算します。
-func calculatePercentageSize(percent float64) (int64, error) {
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-
-	// システムの総メモリを取得（簡易実装）
-	// 実際のシステムメモリ情報はOSによって異なるため、
-	// ここでは現在のヒープサイズを基準とした推定値を使用
-	totalSystemMemory := int64(8 * 1024 * 1024 * 1024) // 8GB as default
-	
-	// より正確には /proc/meminfo (Linux) や Windows API を使用すべき
-	usedMemory := int64(memStats.Sys)
-	freeMemory := totalSystemMemory - usedMemory
+// ResolvePercent は空きメモリに対するパーセンテージ指定が、現時点で何バイトの負荷になるかを返します。
+// 実行中の目標値は空きメモリの変化に合わせて変わります。
+//
+// 引数:
+//
+//	percent - 空きメモリに対するパーセンテージ
+func ResolvePercent(percent float64) (int64, error) {
+	return calculatePercentageSize(nil, percent, 0)
+}
 
+// calculatePercentageSize は空きメモリのパーセンテージから実際のサイズを計算します。
+// 空きメモリは m が測定したシステムの利用可能メモリを cgroup のメモリ上限までの残りで制限した値に、
+// 負荷として確保済みのメモリを加えたものです。
+//
+// 引数:
+//
+//	m         - 空きメモリの測定値の提供元 (nil の場合はシステムから直接読み取る)
+//	percent   - 空きメモリに対するパーセンテージ
+//	allocated - 負荷として確保済みのメモリ（バイト）
+func calculatePercentageSize(m *monitor.Monitor, percent float64, allocated int64) (int64, error) {
+	available, err := m.MemoryAvailable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read available memory: %v", err)
+	}
+	// Memory this load already holds would be free without it
+	freeMemory := available + allocated
 	if freeMemory <= 0 {
 		return 0, fmt.Errorf("insufficient free memory")
 	}
 
 	targetSize := int64(float64(freeMemory) * percent / 100.0)
-	
+
 	// Use 95% of calculated size for safety
 	targetSize = int64(float64(targetSize) * 0.95)
 
@@ -204,12 +420,12 @@ func calculatePercentageSize(percent float64) (int64, error) {
 }
 
 // showMemoryStats displays memory usage statistics.
-func showMemoryStats(allocatedSize int64) {
+func showMemoryStats(recorder *metrics.Recorder, allocatedSize int64) {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
-	fmt.Printf("[Memory] Allocated: %d MB, System usage: %d MB, Heap size: %d MB\n",
+	recorder.Logf("Memory", "Allocated: %d MiB, System usage: %d MiB, Heap size: %d MiB",
 		allocatedSize/(1024*1024),
 		memStats.Sys/(1024*1024),
 		memStats.HeapSys/(1024*1024))
-}
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin
+
+package memory
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// lockSupported reports whether ModeLock can lock memory on this platform.
+const lockSupported = false
+
+func lockMemory(buffer []byte) error {
+	return fmt.Errorf("locking memory is not supported on %s", runtime.GOOS)
+}
+
+func unlockMemory(buffer []byte) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package memory
+
+import "syscall"
+
+// lockSupported reports whether ModeLock can lock memory on this platform.
+const lockSupported = true
+
+// lockMemory pins buffer in physical memory. The Go heap does not move
+// objects, so the pages stay locked as long as the buffer is held.
+func lockMemory(buffer []byte) error {
+	return syscall.Mlock(buffer)
+}
+
+func unlockMemory(buffer []byte) error {
+	return syscall.Munlock(buffer)
+}
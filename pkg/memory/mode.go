@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+)
+
+// Mode はメモリ負荷が確保したメモリに対して続ける操作です。空の場合は確保したメモリを保持するだけです。
+type Mode string
+
+const (
+	// ModeChurn は確保したメモリを Rate の速さで書き換え続け、ダーティページを作り続けます。
+	ModeChurn Mode = "churn"
+	// ModeBandwidth は Workers 個のワーカーで確保したメモリの読み込みと書き込みを続け、メモリ帯域を使い切ります。
+	ModeBandwidth Mode = "bandwidth"
+	// ModeLock は確保したメモリを mlock で物理メモリに固定し、スワップアウトや回収をさせません。
+	ModeLock Mode = "lock"
+	// ModeSwap は物理メモリを超えるサイズの確保を許し、確保したメモリ全体を書き換え続けてスワップを発生させます。
+	ModeSwap Mode = "swap"
+)
+
+// Modes はすべての操作です。
+var Modes = []Mode{ModeChurn, ModeBandwidth, ModeLock, ModeSwap}
+
+// blockSize is how much a memory worker reads or writes at a time, between
+// checks of its pace and of the published buffers.
+const blockSize = 1024 * 1024
+
+// Validate は操作が有効かどうかを検証します。空の場合は確保したメモリを保持するだけです。
+func (m Mode) Validate() error {
+	if m != "" && !slices.Contains(Modes, m) {
+		return fmt.Errorf("unknown memory mode %q (churn, bandwidth, lock or swap)", m)
+	}
+	return nil
+}
+
+// rewrites reports whether the mode keeps writing the allocated memory, which
+// would overwrite the test patterns of Verify.
+func (m Mode) rewrites() bool {
+	return m == ModeChurn || m == ModeBandwidth || m == ModeSwap
+}
+
+// publish makes the allocated buffers available to the workers.
+func (c *Controller) publish(buffers [][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.buffers = slices.Clone(buffers)
+}
+
+// activeBuffers returns the buffers the workers may read and write.
+func (c *Controller) activeBuffers() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buffers
+}
+
+// lockBuffer pins buffer in physical memory in lock mode. The first failure is
+// logged and flagged, typically for RLIMIT_MEMLOCK; the buffer is then held
+// without being locked.
+func (c *Controller) lockBuffer(buffer []byte) {
+	if c.opts.Mode != ModeLock {
+		return
+	}
+	if err := lockMemory(buffer); err != nil {
+		if !c.lockFailed {
+			c.opts.Recorder.Logf("Memory", "Cannot lock memory, holding it unlocked: %v", err)
+			c.opts.Recorder.Flag("Memory", "memory not locked")
+		}
+		c.lockFailed = true
+	}
+}
+
+// unlockBuffer undoes lockBuffer before buffer is released.
+func (c *Controller) unlockBuffer(buffer []byte) {
+	if c.opts.Mode == ModeLock {
+		unlockMemory(buffer)
+	}
+}
+
+// startWorkers starts the workers of the modes that keep reading or writing
+// the memory, and returns a function that waits for them to stop after ctx is
+// done.
+func (c *Controller) startWorkers(ctx context.Context) func() {
+	mode := c.opts.Mode
+	if !mode.rewrites() {
+		return func() {}
+	}
+	workers := c.opts.Workers
+	if workers == 0 {
+		workers = 1
+		if mode == ModeBandwidth {
+			workers = runtime.GOMAXPROCS(0)
+		}
+	}
+	if c.opts.Rate > 0 && mode != ModeBandwidth {
+		c.opts.Recorder.Logf("Memory", "Rewriting memory in %s mode at %d MiB/s on %d workers", mode, c.opts.Rate/(1024*1024), workers)
+	} else {
+		c.opts.Recorder.Logf("Memory", "Running %s mode on %d workers", mode, workers)
+	}
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.worker(ctx, i, workers)
+		}()
+	}
+	return wg.Wait
+}
+
+// worker goes through the buffers block by block until ctx is done: churn and
+// swap overwrite each block with generated data, at an equal share of
+// Options.Rate, while bandwidth copies the first half of each block over the
+// second half. Each worker takes every workers-th block of each buffer, so that
+// no two workers touch the same memory.
+func (c *Controller) worker(ctx context.Context, id, workers int) {
+	mode := c.opts.Mode
+	var source []byte
+	if mode != ModeBandwidth {
+		seed := c.opts.Seed
+		if seed != 0 {
+			seed += uint64(id) + 1
+		} else {
+			seed = uint64(time.Now().UnixNano()) + uint64(id)
+		}
+		// Random data by default, so that zram and zswap cannot make the rewrites cheap
+		source = make([]byte, blockSize)
+		fillContent(source, cmp.Or(c.opts.Content, ContentIncompressible), newContentSource(seed))
+	}
+	rate := float64(c.opts.Rate) / float64(workers)
+
+	// moved is whether the worker found a block in the current pass over the buffers
+	chunk, offset, moved := 0, id*blockSize, false
+	paceStart, written := time.Now(), 0
+	for ctx.Err() == nil {
+		buffers := c.activeBuffers()
+		if len(buffers) == 0 {
+			// Paused or released: start the pace over when memory is back
+			paceStart, written = time.Now(), 0
+			timing.Sleep(ctx, timing.IdleInterval)
+			continue
+		}
+		if chunk >= len(buffers) {
+			// Too little memory is allocated for every worker to have a block
+			if !moved {
+				timing.Sleep(ctx, timing.IdleInterval)
+			}
+			chunk, offset, moved = 0, id*blockSize, false
+			continue
+		}
+		buffer := buffers[chunk]
+		if offset >= len(buffer) {
+			chunk, offset = chunk+1, id*blockSize
+			continue
+		}
+		block := buffer[offset:min(offset+blockSize, len(buffer))]
+		if mode == ModeBandwidth {
+			half := len(block) / 2
+			copy(block[half:], block[:half])
+			c.countBytes(half, half)
+		} else {
+			copy(block, source)
+			c.countBytes(0, len(block))
+			written += len(block)
+		}
+		offset += workers * blockSize
+		moved = true
+
+		if rate > 0 && mode != ModeBandwidth {
+			if ahead := time.Duration(float64(written)/rate*float64(time.Second)) - time.Since(paceStart); ahead > 0 {
+				timing.Sleep(ctx, ahead)
+			}
+		}
+	}
+}
+
+// countBytes adds the bytes a worker read and wrote to the totals.
+func (c *Controller) countBytes(read, written int) {
+	if read > 0 {
+		c.read.Add(int64(read))
+		c.opts.Recorder.AddCount("Memory", "bytes_read", int64(read))
+	}
+	if written > 0 {
+		c.written.Add(int64(written))
+		c.opts.Recorder.AddCount("Memory", "bytes_written", int64(written))
+	}
+}
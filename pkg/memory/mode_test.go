@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// runMode holds 4 MiB in the memory mode of opts until its workers have moved
+// at least bytes, and returns the controller and result after it has stopped.
+func runMode(t *testing.T, opts Options, bytes int64) (*Controller, Result) {
+	t.Helper()
+	opts.Size = 4 * 1024 * 1024
+	opts.Seed = 1
+	c, err := Start(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for c.read.Load()+c.written.Load() < bytes && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Stop()
+	result, err := c.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.read.Load() + c.written.Load(); got < bytes {
+		t.Fatalf("%s: moved %d bytes in 10s, want at least %d", opts.Mode, got, bytes)
+	}
+	return c, result
+}
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		mode          Mode
+		workers       int
+		reads, writes bool
+	}{
+		{ModeChurn, 2, false, true},
+		// More workers than the 4 blocks of the memory: the others wait
+		{ModeBandwidth, 8, true, true},
+		{ModeSwap, 2, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			recorder := metrics.NewRecorder()
+			c, result := runMode(t, Options{Mode: tt.mode, Workers: tt.workers, Recorder: recorder}, 16*1024*1024)
+			read, written := c.read.Load(), c.written.Load()
+			if (read > 0) != tt.reads || (written > 0) != tt.writes {
+				t.Errorf("read %d and wrote %d bytes, want reads=%v writes=%v", read, written, tt.reads, tt.writes)
+			}
+			// Bandwidth copies the first half of each block over the second half
+			if tt.mode == ModeBandwidth && read != written {
+				t.Errorf("read %d bytes and wrote %d, want the same", read, written)
+			}
+			counts := recorder.Counts()["Memory"]
+			if counts["bytes_read"] != read || counts["bytes_written"] != written {
+				t.Errorf("counts = %v, want %d bytes read and %d written", counts, read, written)
+			}
+			if result.BytesPerSecond <= 0 {
+				t.Errorf("BytesPerSecond = %g, want the average bandwidth", result.BytesPerSecond)
+			}
+		})
+	}
+}
+
+func TestModeChurnRate(t *testing.T) {
+	const rate = 16 * 1024 * 1024
+	start := time.Now()
+	c, _ := runMode(t, Options{Mode: ModeChurn, Rate: rate, Workers: 2}, 8*1024*1024)
+	// Each worker writes at most one block ahead of its share of the rate
+	if limit := int64(time.Since(start).Seconds()*rate) + 2*blockSize; c.written.Load() > limit {
+		t.Errorf("wrote %d bytes in %v at %d bytes/s, want at most %d", c.written.Load(), time.Since(start), rate, limit)
+	}
+}
+
+func TestModeLock(t *testing.T) {
+	c, result := runMode(t, Options{Mode: ModeLock}, 0)
+	if c.read.Load() != 0 || c.written.Load() != 0 || result.BytesPerSecond != 0 {
+		t.Errorf("lock mode moved %d and %d bytes at %g bytes/s, want nothing", c.read.Load(), c.written.Load(), result.BytesPerSecond)
+	}
+	if result.PeakBytes != 4*1024*1024 {
+		t.Errorf("PeakBytes = %d, want 4 MiB", result.PeakBytes)
+	}
+}
+
+func TestModeValidate(t *testing.T) {
+	for _, mode := range append([]Mode{""}, Modes...) {
+		if err := mode.Validate(); err != nil {
+			t.Errorf("Mode(%q).Validate() = %v, want nil", mode, err)
+		}
+	}
+	if err := Mode("numa").Validate(); err == nil {
+		t.Errorf(`Mode("numa").Validate() = nil, want an error`)
+	}
+	for _, mode := range []Mode{ModeChurn, ModeBandwidth, ModeSwap} {
+		if err := (Options{Size: 1, Mode: mode, Verify: true}).Validate(); err == nil {
+			t.Errorf("Validate() of %s with Verify = nil, want an error", mode)
+		}
+	}
+	if err := (Options{Size: 1, Mode: ModeLock, Verify: true}).Validate(); err != nil {
+		t.Errorf("Validate() of lock with Verify = %v, want nil", err)
+	}
+}
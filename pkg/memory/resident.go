@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// residentShortfall is the share of the allocated memory that may be missing
+// from physical memory before the load is flagged as swapped out.
+const residentShortfall = 0.9
+
+var (
+	baselineMu sync.Mutex
+	// baseline is the resident memory of the process while no controller held
+	// any memory, or -1 if it is not known.
+	baseline int64 = -1
+)
+
+// measureBaseline records the resident memory of the process as the baseline
+// if no controller holds memory yet. Controllers started alongside keep the
+// baseline of the first.
+func measureBaseline() {
+	baselineMu.Lock()
+	defer baselineMu.Unlock()
+	if HeldBytes() > 0 && baseline >= 0 {
+		return
+	}
+	rss, err := sysinfo.ResidentMemory()
+	if err != nil {
+		rss = -1
+	}
+	baseline = rss
+}
+
+// residentBytes returns how much of the allocated bytes are resident in
+// physical memory: the resident memory of the process above the baseline,
+// shared among the controllers in proportion to what they hold, at most
+// allocated. Where the platform does not report resident memory, it returns
+// allocated and false.
+func residentBytes(allocated int64) (int64, bool) {
+	baselineMu.Lock()
+	base := baseline
+	baselineMu.Unlock()
+	rss, err := sysinfo.ResidentMemory()
+	if base < 0 || err != nil {
+		return allocated, false
+	}
+	held := HeldBytes()
+	if held <= 0 {
+		return 0, true
+	}
+	above := max(rss-base, 0)
+	share := int64(float64(above) * float64(allocated) / float64(held))
+	return min(share, allocated), true
+}
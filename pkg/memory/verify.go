@@ -0,0 +1,52 @@
+package memory
+
+import "unsafe"
+
+// verifyPerTick is how many buffers are read back and rewritten on every tick in
+// verify mode, so that all memory is checked at regular intervals.
+const verifyPerTick = 4
+
+// words returns the whole 64-bit words of buffer.
+func words(buffer []byte) []uint64 {
+	return unsafe.Slice((*uint64)(unsafe.Pointer(unsafe.SliceData(buffer))), len(buffer)/8)
+}
+
+// patternWord returns the word at index i of the pattern with the given seed. Every
+// word differs, so that stuck bits, flipped bits and swapped addresses all show.
+func patternWord(seed uint64, i int) uint64 {
+	x := seed + uint64(i)*0x9e3779b97f4a7c15
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	return x
+}
+
+// fillPattern writes the pattern with the given seed to buffer.
+func fillPattern(buffer []byte, seed uint64) {
+	w := words(buffer)
+	for i := range w {
+		w[i] = patternWord(seed, i)
+	}
+}
+
+// mismatch describes the words of a buffer that did not hold the expected pattern.
+type mismatch struct {
+	count            int
+	offset           int
+	expected, actual uint64
+}
+
+// checkPattern compares buffer with the pattern with the given seed and returns the
+// number of wrong words and the first of them.
+func checkPattern(buffer []byte, seed uint64) mismatch {
+	var m mismatch
+	for i, actual := range words(buffer) {
+		if expected := patternWord(seed, i); actual != expected {
+			if m.count == 0 {
+				m.offset, m.expected, m.actual = i*8, expected, actual
+			}
+			m.count++
+		}
+	}
+	return m
+}
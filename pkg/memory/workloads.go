@@ -0,0 +1,35 @@
+package memory
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "memory",
+		Name:        "hold",
+		Usage:       "--memory <size>",
+		Description: "Allocating memory in 64MB chunks and holding it, touching one byte per page",
+		Available:   true,
+	})
+	for _, w := range []struct {
+		mode        Mode
+		usage       string
+		description string
+		requirement string
+	}{
+		{ModeChurn, " [--memory-rate 1GB]", "Rewriting the allocated memory at --memory-rate to keep dirtying pages, reporting GB/s", ""},
+		{ModeBandwidth, " [--memory-workers N]", "Streaming reads and writes of the allocated memory on every CPU to saturate memory bandwidth, reporting GB/s", ""},
+		{ModeLock, "", "Locking the allocated memory with mlock so that it is never swapped out or reclaimed", "Linux or macOS"},
+		{ModeSwap, " [--memory-rate 1GB]", "Allocating beyond physical memory and rewriting all of it to force swapping, reporting GB/s", ""},
+	} {
+		workload.Register(workload.Workload{
+			Kind:        workload.Engines,
+			Domain:      "memory",
+			Name:        string(w.mode),
+			Usage:       "--memory <size> --memory-mode " + string(w.mode) + w.usage,
+			Description: w.description,
+			Available:   w.mode != ModeLock || lockSupported,
+			Requirement: w.requirement,
+		})
+	}
+}
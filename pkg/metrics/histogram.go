@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"math/bits"
+	"time"
+)
+
+// histogramBuckets covers latencies from 1µs up to roughly 36 minutes in power-of-two steps.
+const histogramBuckets = 32
+
+// Histogram は操作レイテンシの分布を2のべき乗のバケットで保持します。
+type Histogram struct {
+	Count  int64
+	Sum    time.Duration
+	Min    time.Duration
+	Max    time.Duration
+	Counts [histogramBuckets]int64
+}
+
+// observe adds a single latency observation to the histogram.
+func (h *Histogram) observe(d time.Duration) {
+	if h.Count == 0 || d < h.Min {
+		h.Min = d
+	}
+	if d > h.Max {
+		h.Max = d
+	}
+	h.Count++
+	h.Sum += d
+	h.Counts[bucketIndex(d)]++
+}
+
+// bucketIndex returns the bucket whose upper bound is the smallest power-of-two
+// number of microseconds not less than d.
+func bucketIndex(d time.Duration) int {
+	us := uint64(d / time.Microsecond)
+	if us <= 1 {
+		return 0
+	}
+	index := bits.Len64(us - 1)
+	if index >= histogramBuckets {
+		index = histogramBuckets - 1
+	}
+	return index
+}
+
+// BucketUpperBound は i 番目のバケットの上限値を返します。
+func BucketUpperBound(i int) time.Duration {
+	return time.Duration(uint64(1)<<uint(i)) * time.Microsecond
+}
+
+// Mean returns the average observed latency.
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile は q (0〜1) 分位のレイテンシの推定値を返します。
+// バケット内では一様に分布しているとみなして補間し、観測された最小値から最大値の範囲に収めます。
+//
+// 引数:
+//
+//	q - 分位 (0.99 で 99 パーセンタイル)
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var cumulative int64
+	for i, count := range h.Counts {
+		if count == 0 {
+			continue
+		}
+		if float64(cumulative+count) >= rank {
+			var lower time.Duration
+			if i > 0 {
+				lower = BucketUpperBound(i - 1)
+			}
+			upper := BucketUpperBound(i)
+			d := lower + time.Duration(float64(upper-lower)*(rank-float64(cumulative))/float64(count))
+			return min(max(d, h.Min), h.Max)
+		}
+		cumulative += count
+	}
+	return h.Max
+}
+
+// Since は h から prev (同じ操作のそれ以前の時点の Histogram) を差し引いた、その間の観測の分布を返します。
+// その間の最小値・最大値は記録していないため、観測のあったバケットの範囲で近似します。
+//
+// 引数:
+//
+//	prev - 差し引くそれ以前の時点の Histogram
+func (h Histogram) Since(prev Histogram) Histogram {
+	w := Histogram{Count: h.Count - prev.Count, Sum: h.Sum - prev.Sum}
+	first, last := -1, -1
+	for i := range h.Counts {
+		w.Counts[i] = h.Counts[i] - prev.Counts[i]
+		if w.Counts[i] > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first >= 0 {
+		if first > 0 {
+			w.Min = BucketUpperBound(first - 1)
+		}
+		w.Max = min(BucketUpperBound(last), h.Max)
+	}
+	return w
+}
@@ -0,0 +1,711 @@
+package metrics
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// Units used by the stressors when recording samples.
+const (
+	UnitCores   = "cores"
+	UnitBytes   = "bytes"
+	UnitPercent = "%"
+	// UnitFaultsPerSecond is the rate of major page faults.
+	UnitFaultsPerSecond = "faults/s"
+	// UnitOpsPerSecond is the rate of file system operations.
+	UnitOpsPerSecond = "ops/s"
+	// UnitBytesPerSecond is the rate of network traffic.
+	UnitBytesPerSecond = "B/s"
+	// UnitConnsPerSecond is the rate of new network connections.
+	UnitConnsPerSecond = "conns/s"
+	// UnitProcsPerSecond is the rate of spawned processes.
+	UnitProcsPerSecond = "procs/s"
+	// UnitSwitchesPerSecond is the rate of context switches made by the OS.
+	UnitSwitchesPerSecond = "switches/s"
+	// UnitWakeupsPerSecond is the rate of goroutine wakeups by messages and timers.
+	UnitWakeupsPerSecond = "wakeups/s"
+)
+
+// degradedThreshold is the ratio of achieved/target below which a stressor is
+// considered not to have reached its intended load.
+const degradedThreshold = 0.90
+
+// Sample は負荷生成モジュールの目標値と実測値の1回分の記録です。
+type Sample struct {
+	Time     time.Time
+	Stressor string
+	Unit     string
+	Target   float64
+	Achieved float64
+	// OpsPerSecond is the rate of timed operations (see ObserveLatency) since the
+	// stressor's previous sample, or 0 for stressors without discrete operations.
+	OpsPerSecond float64
+}
+
+// Message は負荷生成モジュールが出力する進行状況のメッセージです。
+type Message struct {
+	Time     time.Time
+	Stressor string
+	Text     string
+}
+
+// Value はシステム指標など、目標値を持たない時系列データの1回分の記録です。
+type Value struct {
+	Time   time.Time
+	Series string
+	Unit   string
+	Value  float64
+}
+
+// Phase は負荷パターンのステップなど、実行中に区切られた区間の記録です。
+type Phase struct {
+	Name  string
+	Start time.Time
+	// End は区間の終了時刻です。終了していない区間ではゼロ値です。
+	End time.Time
+}
+
+// Deviation は1つの負荷生成モジュールにおける目標値と実測値の乖離の集計結果です。
+type Deviation struct {
+	Stressor     string
+	Unit         string
+	Samples      int
+	MeanTarget   float64
+	MeanAchieved float64
+	MinAchieved  float64
+	MaxAchieved  float64
+	// MeanDeviation and MaxDeviation are relative to the target, in percent.
+	MeanDeviation float64
+	MaxDeviation  float64
+	Issues        []string
+	Degraded      bool
+}
+
+// maxVerificationErrors is how many mismatches are kept per stressor for the report;
+// further mismatches are only counted.
+const maxVerificationErrors = 10
+
+// Verification は負荷生成モジュールが書き込んだデータや演算結果の検証の集計結果です。
+type Verification struct {
+	Stressor string
+	// Checks は検証した単位 (CPU の演算、メモリのチャンク、ストレージのブロック) の数です。
+	Checks int64
+	// Failures は期待値と一致しなかった単位の数です。
+	Failures int64
+	// Errors は最初の数件の不一致の内容 (位置、期待値、実際の値) です。同じ内容は一度だけ記録されます。
+	Errors []string
+}
+
+// Fault は障害注入 (--chaos) で負荷生成モジュールに与えた障害の種類ごとの集計です。
+type Fault struct {
+	Stressor string
+	// Kind は障害の種類 ("kill"、"drop"、"corrupt") です。
+	Kind string
+	// Injected は注入した回数、Recovered は負荷生成モジュールが検出・回復した回数です。
+	Injected  int64
+	Recovered int64
+}
+
+// WorkerUsage は負荷生成モジュールのワーカー1つに OS が実際に割り当てた CPU 時間の集計結果です。
+// cgroup のスロットリングなどで一部のワーカーだけが遅れている偏りを示します。
+type WorkerUsage struct {
+	Stressor string
+	Worker   int
+	Samples  int
+	// MeanTarget と MeanAchieved はワーカーの目標使用率と実際の使用率 (1コアに対する比率) の平均です。
+	MeanTarget   float64
+	MeanAchieved float64
+	// MinAchieved は計測区間ごとの実際の使用率の最小値です。
+	MinAchieved float64
+}
+
+// Lagging はワーカーの平均使用率が目標を大きく下回っているかどうかを返します。
+func (w WorkerUsage) Lagging() bool {
+	return w.MeanTarget > 0 && w.MeanAchieved < w.MeanTarget*degradedThreshold
+}
+
+// Recorder は各負荷生成モジュールから送られるサンプルを保持します。
+// nil の Recorder に対する呼び出しは何もしません。
+type Recorder struct {
+	*recorderState
+	// name replaces the stressor names recorded through this Recorder; see WithName
+	name string
+}
+
+// recorderState is what the Recorders returned by WithName share.
+type recorderState struct {
+	mu        sync.Mutex
+	samples   []Sample
+	values    []Value
+	latencies map[string]*Histogram
+	issues    map[string][]string
+	phases    []Phase
+	verified  map[string]*Verification
+	faults    map[string]*Fault
+	workers   map[string]*WorkerUsage
+
+	// ops counts timed operations per stressor; lastOps and lastSample hold the
+	// count and time at the stressor's previous sample for the rate calculation.
+	ops        map[string]int64
+	lastOps    map[string]int64
+	lastSample map[string]time.Time
+
+	// counts holds running totals per stressor, such as the bytes written
+	counts map[string]map[string]int64
+
+	sampleListeners  []func(Sample)
+	messageListeners []func(Message)
+
+	// labels are added to every exported series, e.g. the node and pod names
+	labels map[string]string
+}
+
+// NewRecorder は空の Recorder を作成します。
+func NewRecorder() *Recorder {
+	return &Recorder{recorderState: &recorderState{
+		latencies:  make(map[string]*Histogram),
+		issues:     make(map[string][]string),
+		verified:   make(map[string]*Verification),
+		faults:     make(map[string]*Fault),
+		workers:    make(map[string]*WorkerUsage),
+		ops:        make(map[string]int64),
+		lastOps:    make(map[string]int64),
+		lastSample: make(map[string]time.Time),
+		counts:     make(map[string]map[string]int64),
+	}}
+}
+
+// WithName は、負荷生成モジュールの名前を name に置き換えて r に記録する Recorder を返します。
+// 同じ種類の負荷を名前付きのジョブとして複数実行する場合に、ジョブごとに記録を分けるために使用します。
+//
+// 引数:
+//
+//	name - 記録に使用する負荷生成モジュールの名前
+func (r *Recorder) WithName(name string) *Recorder {
+	if r == nil {
+		return nil
+	}
+	return &Recorder{recorderState: r.recorderState, name: name}
+}
+
+// stressorName returns the name to record stressor under.
+func (r *Recorder) stressorName(stressor string) string {
+	if r.name != "" {
+		return r.name
+	}
+	return stressor
+}
+
+// OnSample はサンプルが記録されるたびに呼び出されるコールバックを登録します。
+// 負荷生成モジュールごとの定期的な指標（使用率、確保バイト数、操作数/秒）を
+// 独自のテストフレームワークやUIに取り込む場合に使用します。
+// コールバックは記録したゴルーチンから呼び出されるため、速やかに処理を返してください。
+func (r *Recorder) OnSample(fn func(Sample)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sampleListeners = append(r.sampleListeners, fn)
+}
+
+// OnMessage は負荷生成モジュールがメッセージを出力するたびに呼び出されるコールバックを登録します。
+// コールバックが登録されていない場合、メッセージはどこにも出力されません。
+func (r *Recorder) OnMessage(fn func(Message)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messageListeners = append(r.messageListeners, fn)
+}
+
+// SetLabels はエクスポートするすべてのメトリクスに付与する固定ラベル (ノード名、Pod名など) を設定します。
+func (r *Recorder) SetLabels(labels map[string]string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.labels = make(map[string]string, len(labels))
+	for key, value := range labels {
+		r.labels[key] = value
+	}
+}
+
+// Labels は SetLabels で設定した固定ラベルのコピーを返します。
+func (r *Recorder) Labels() map[string]string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	labels := make(map[string]string, len(r.labels))
+	for key, value := range r.labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// Logf は負荷生成モジュールの進行状況のメッセージを OnMessage で登録されたコールバックに送ります。
+// format は i18n で現在の言語に翻訳してから書式化します。
+func (r *Recorder) Logf(stressor, format string, args ...any) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	listeners := r.messageListeners
+	r.mu.Unlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+	message := Message{Time: time.Now(), Stressor: stressor, Text: i18n.Sprintf(format, args...)}
+	for _, fn := range listeners {
+		fn(message)
+	}
+}
+
+// Record は目標値と実測値のサンプルを記録します。
+func (r *Recorder) Record(stressor, unit string, target, achieved float64) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	now := time.Now()
+	sample := Sample{
+		Time:     now,
+		Stressor: stressor,
+		Unit:     unit,
+		Target:   target,
+		Achieved: achieved,
+	}
+	if last, ok := r.lastSample[stressor]; ok && now.After(last) {
+		sample.OpsPerSecond = float64(r.ops[stressor]-r.lastOps[stressor]) / now.Sub(last).Seconds()
+	}
+	r.lastSample[stressor] = now
+	r.lastOps[stressor] = r.ops[stressor]
+	r.samples = append(r.samples, sample)
+	listeners := r.sampleListeners
+	r.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(sample)
+	}
+}
+
+// RecordValue は目標値を持たない時系列データ（システム指標など）を記録します。
+func (r *Recorder) RecordValue(series, unit string, value float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values = append(r.values, Value{
+		Time:   time.Now(),
+		Series: series,
+		Unit:   unit,
+		Value:  value,
+	})
+}
+
+// ObserveLatency は操作1回分のレイテンシを記録します。
+// 操作は "Storage read" のように負荷生成モジュール名と操作名の組で集計されます。
+func (r *Recorder) ObserveLatency(stressor, operation string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := stressor + " " + operation
+	h, ok := r.latencies[key]
+	if !ok {
+		h = &Histogram{}
+		r.latencies[key] = h
+	}
+	h.observe(d)
+	r.ops[stressor]++
+}
+
+// AddCount は負荷生成モジュールの累計カウンタに delta を加算します。
+//
+// 引数:
+//
+//	stressor - 負荷生成モジュールの名前
+//	name - カウンタの名前 ("bytes_written" など)
+//	delta - 加算する値
+func (r *Recorder) AddCount(stressor, name string, delta int64) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.counts[stressor]
+	if !ok {
+		c = make(map[string]int64)
+		r.counts[stressor] = c
+	}
+	c[name] += delta
+}
+
+// Counts は負荷生成モジュールごとの累計カウンタのコピーを返します。
+// レイテンシを計測した操作の回数は "ops" として含まれます。
+func (r *Recorder) Counts() map[string]map[string]int64 {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make(map[string]map[string]int64)
+	for stressor, c := range r.counts {
+		result[stressor] = maps.Clone(c)
+	}
+	for stressor, ops := range r.ops {
+		if result[stressor] == nil {
+			result[stressor] = make(map[string]int64)
+		}
+		result[stressor]["ops"] += ops
+	}
+	return result
+}
+
+// Flag は環境要因（クォータ、スロットリング、ENOSPC など）により負荷が
+// 妨げられたことを記録します。同じ理由は一度だけ記録されます。
+// reason は i18n で現在の言語に翻訳して記録します。
+func (r *Recorder) Flag(stressor, reason string) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	reason = i18n.T(reason)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.issues[stressor] {
+		if existing == reason {
+			return
+		}
+	}
+	r.issues[stressor] = append(r.issues[stressor], reason)
+}
+
+// RecordVerification は検証の結果を記録します。
+//
+// 引数:
+//
+//	stressor - 負荷生成モジュールの名前
+//	checks - 検証した単位の数 (不一致だったものを含む)
+//	errs - 不一致だった単位ごとの内容
+func (r *Recorder) RecordVerification(stressor string, checks int64, errs ...string) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.verified[stressor]
+	if !ok {
+		v = &Verification{Stressor: stressor}
+		r.verified[stressor] = v
+	}
+	v.Checks += checks
+	v.Failures += int64(len(errs))
+	for _, err := range errs {
+		if len(v.Errors) < maxVerificationErrors && !slices.Contains(v.Errors, err) {
+			v.Errors = append(v.Errors, err)
+		}
+	}
+}
+
+// Verifications returns the verification tallies sorted by stressor name.
+func (r *Recorder) Verifications() []Verification {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]Verification, 0, len(r.verified))
+	for _, v := range r.verified {
+		c := *v
+		c.Errors = append([]string(nil), v.Errors...)
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Stressor < result[j].Stressor })
+	return result
+}
+
+// RecordFault は障害注入で stressor に kind の障害を与えたことを記録します。
+func (r *Recorder) RecordFault(stressor, kind string) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fault(stressor, kind).Injected++
+}
+
+// RecordRecovery は stressor が注入された kind の障害を検出し、回復したことを記録します。
+func (r *Recorder) RecordRecovery(stressor, kind string) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fault(stressor, kind).Recovered++
+}
+
+// fault returns the tally for stressor and kind, creating it if needed. r.mu must be held.
+func (r *Recorder) fault(stressor, kind string) *Fault {
+	key := stressor + " " + kind
+	f, ok := r.faults[key]
+	if !ok {
+		f = &Fault{Stressor: stressor, Kind: kind}
+		r.faults[key] = f
+	}
+	return f
+}
+
+// Faults returns the fault tallies sorted by stressor and kind.
+func (r *Recorder) Faults() []Fault {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]Fault, 0, len(r.faults))
+	for _, f := range r.faults {
+		result = append(result, *f)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Stressor != result[j].Stressor {
+			return result[i].Stressor < result[j].Stressor
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+// RecordWorkerUsage は stressor のワーカー1つの計測区間の目標使用率と実際の使用率を記録します。
+//
+// 引数:
+//
+//	stressor - 負荷生成モジュールの名前
+//	worker - ワーカーの番号
+//	target - 区間の目標使用率 (1コアに対する比率)
+//	achieved - 区間にワーカーのスレッドが使用した CPU 時間の比率
+func (r *Recorder) RecordWorkerUsage(stressor string, worker int, target, achieved float64) {
+	if r == nil {
+		return
+	}
+	stressor = r.stressorName(stressor)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fmt.Sprintf("%s %d", stressor, worker)
+	w, ok := r.workers[key]
+	if !ok {
+		w = &WorkerUsage{Stressor: stressor, Worker: worker, MinAchieved: achieved}
+		r.workers[key] = w
+	}
+	// Keep running means so that long runs do not grow the tally
+	w.Samples++
+	w.MeanTarget += (target - w.MeanTarget) / float64(w.Samples)
+	w.MeanAchieved += (achieved - w.MeanAchieved) / float64(w.Samples)
+	w.MinAchieved = min(w.MinAchieved, achieved)
+}
+
+// WorkerUsages returns the per-worker tallies sorted by stressor and worker.
+func (r *Recorder) WorkerUsages() []WorkerUsage {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]WorkerUsage, 0, len(r.workers))
+	for _, w := range r.workers {
+		result = append(result, *w)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Stressor != result[j].Stressor {
+			return result[i].Stressor < result[j].Stressor
+		}
+		return result[i].Worker < result[j].Worker
+	})
+	return result
+}
+
+// StartPhase は name の区間を開始します。終了していない区間はその時刻で終了します。
+func (r *Recorder) StartPhase(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.endPhase(now)
+	r.phases = append(r.phases, Phase{Name: name, Start: now})
+}
+
+// EndPhase は終了していない区間を終了します。
+func (r *Recorder) EndPhase() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endPhase(time.Now())
+}
+
+// endPhase closes the last phase at t if it is still open.
+func (r *Recorder) endPhase(t time.Time) {
+	if n := len(r.phases); n > 0 && r.phases[n-1].End.IsZero() {
+		r.phases[n-1].End = t
+	}
+}
+
+// Phases returns a copy of the recorded phases in order.
+func (r *Recorder) Phases() []Phase {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Phase(nil), r.phases...)
+}
+
+// Samples returns a copy of all recorded samples in recording order.
+func (r *Recorder) Samples() []Sample {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sample(nil), r.samples...)
+}
+
+// Values returns a copy of all recorded time series values in recording order.
+func (r *Recorder) Values() []Value {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Value(nil), r.values...)
+}
+
+// Latencies returns a copy of the latency histograms keyed by "<stressor> <operation>".
+func (r *Recorder) Latencies() map[string]Histogram {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make(map[string]Histogram, len(r.latencies))
+	for key, h := range r.latencies {
+		result[key] = *h
+	}
+	return result
+}
+
+// Deviations は記録されたサンプルから負荷生成モジュールごとの乖離統計を計算します。
+func (r *Recorder) Deviations() []Deviation {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byStressor := make(map[string]*Deviation)
+	var names []string
+	for _, s := range r.samples {
+		d, ok := byStressor[s.Stressor]
+		if !ok {
+			d = &Deviation{
+				Stressor:    s.Stressor,
+				Unit:        s.Unit,
+				MinAchieved: math.Inf(1),
+				MaxAchieved: math.Inf(-1),
+			}
+			byStressor[s.Stressor] = d
+			names = append(names, s.Stressor)
+		}
+		d.Samples++
+		d.MeanTarget += s.Target
+		d.MeanAchieved += s.Achieved
+		d.MinAchieved = math.Min(d.MinAchieved, s.Achieved)
+		d.MaxAchieved = math.Max(d.MaxAchieved, s.Achieved)
+		if s.Target > 0 {
+			dev := (s.Achieved - s.Target) / s.Target * 100
+			d.MeanDeviation += dev
+			if math.Abs(dev) > math.Abs(d.MaxDeviation) {
+				d.MaxDeviation = dev
+			}
+		}
+	}
+
+	// Stressors that only reported issues still appear in the summary
+	for name := range r.issues {
+		if _, ok := byStressor[name]; !ok {
+			byStressor[name] = &Deviation{Stressor: name}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	result := make([]Deviation, 0, len(names))
+	for _, name := range names {
+		d := byStressor[name]
+		if d.Samples > 0 {
+			n := float64(d.Samples)
+			d.MeanTarget /= n
+			d.MeanAchieved /= n
+			d.MeanDeviation /= n
+		} else {
+			d.MinAchieved, d.MaxAchieved = 0, 0
+		}
+		d.Issues = append([]string(nil), r.issues[name]...)
+		d.Degraded = len(d.Issues) > 0 ||
+			(d.MeanTarget > 0 && d.MeanAchieved < d.MeanTarget*degradedThreshold)
+		result = append(result, *d)
+	}
+	return result
+}
+
+// FormatValue は単位に応じて値を人間が読める形式に変換します。
+func FormatValue(unit string, value float64) string {
+	switch unit {
+	case UnitBytes:
+		return fmt.Sprintf("%d MiB", int64(value)/(1024*1024))
+	case UnitCores:
+		return fmt.Sprintf("%.2f cores", value)
+	case UnitPercent:
+		return fmt.Sprintf("%.1f%%", value)
+	case UnitFaultsPerSecond:
+		return fmt.Sprintf("%.0f faults/s", value)
+	case UnitOpsPerSecond:
+		return fmt.Sprintf("%.0f ops/s", value)
+	case UnitBytesPerSecond:
+		return fmt.Sprintf("%.1f MiB/s", value/(1024*1024))
+	case UnitConnsPerSecond:
+		return fmt.Sprintf("%.0f conns/s", value)
+	case UnitProcsPerSecond:
+		return fmt.Sprintf("%.0f procs/s", value)
+	case UnitSwitchesPerSecond:
+		return fmt.Sprintf("%.0f switches/s", value)
+	case UnitWakeupsPerSecond:
+		return fmt.Sprintf("%.0f wakeups/s", value)
+	default:
+		return fmt.Sprintf("%.2f %s", value, unit)
+	}
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WritePrometheus は各負荷生成モジュールの最新の状態を Prometheus テキスト形式で書き出します。
+func (r *Recorder) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	common := formatLabels(r.Labels())
+	if common != "" {
+		common += ","
+	}
+
+	latest := r.latestSamples()
+	fmt.Fprintf(bw, "# HELP stress_go_target Requested load per stressor.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_target gauge\n")
+	for _, s := range latest {
+		fmt.Fprintf(bw, "stress_go_target{%sstressor=%q,unit=%q} %g\n", common, s.Stressor, s.Unit, s.Target)
+	}
+	fmt.Fprintf(bw, "# HELP stress_go_achieved Measured load per stressor.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_achieved gauge\n")
+	for _, s := range latest {
+		fmt.Fprintf(bw, "stress_go_achieved{%sstressor=%q,unit=%q} %g\n", common, s.Stressor, s.Unit, s.Achieved)
+	}
+
+	deviations := r.Deviations()
+	fmt.Fprintf(bw, "# HELP stress_go_degraded Whether the stressor failed to reach its target (1) or not (0).\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_degraded gauge\n")
+	for _, d := range deviations {
+		degraded := 0
+		if d.Degraded {
+			degraded = 1
+		}
+		fmt.Fprintf(bw, "stress_go_degraded{%sstressor=%q} %d\n", common, d.Stressor, degraded)
+	}
+	fmt.Fprintf(bw, "# HELP stress_go_issues Number of distinct environment issues reported per stressor.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_issues gauge\n")
+	for _, d := range deviations {
+		fmt.Fprintf(bw, "stress_go_issues{%sstressor=%q} %d\n", common, d.Stressor, len(d.Issues))
+	}
+
+	latencies := r.Latencies()
+	var operations []string
+	for key := range latencies {
+		operations = append(operations, key)
+	}
+	sort.Strings(operations)
+	fmt.Fprintf(bw, "# HELP stress_go_latency_seconds Latency of stressor operations.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_latency_seconds histogram\n")
+	for _, key := range operations {
+		h := latencies[key]
+		stressor, operation, _ := strings.Cut(key, " ")
+		labels := fmt.Sprintf("%sstressor=%q,operation=%q", common, stressor, operation)
+		var cumulative int64
+		for i, count := range h.Counts {
+			cumulative += count
+			fmt.Fprintf(bw, "stress_go_latency_seconds_bucket{%s,le=\"%g\"} %d\n", labels, BucketUpperBound(i).Seconds(), cumulative)
+		}
+		fmt.Fprintf(bw, "stress_go_latency_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.Count)
+		fmt.Fprintf(bw, "stress_go_latency_seconds_sum{%s} %g\n", labels, h.Sum.Seconds())
+		fmt.Fprintf(bw, "stress_go_latency_seconds_count{%s} %d\n", labels, h.Count)
+	}
+
+	counts := r.Counts()
+	var stressors []string
+	for stressor := range counts {
+		stressors = append(stressors, stressor)
+	}
+	sort.Strings(stressors)
+	fmt.Fprintf(bw, "# HELP stress_go_count_total Running totals per stressor, such as bytes written or read.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_count_total counter\n")
+	for _, stressor := range stressors {
+		var names []string
+		for name := range counts[stressor] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(bw, "stress_go_count_total{%sstressor=%q,name=%q} %d\n", common, stressor, name, counts[stressor][name])
+		}
+	}
+
+	values := r.latestValues()
+	fmt.Fprintf(bw, "# HELP stress_go_system System metrics sampled during the run.\n")
+	fmt.Fprintf(bw, "# TYPE stress_go_system gauge\n")
+	for _, v := range values {
+		fmt.Fprintf(bw, "stress_go_system{%sseries=%q,unit=%q} %g\n", common, v.Series, v.Unit, v.Value)
+	}
+
+	return bw.Flush()
+}
+
+// PrometheusLabels は固定ラベルを Prometheus のラベル指定 (例: {node="n1",pod="p1"}) にします。
+// ラベルがない場合は空文字列を返します。
+func PrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + formatLabels(labels) + "}"
+}
+
+// formatLabels formats labels as comma-separated name="value" pairs sorted by name.
+func formatLabels(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// latestSamples returns the most recent sample of each stressor, sorted by stressor name.
+func (r *Recorder) latestSamples() []Sample {
+	samples := r.Samples()
+	latest := make(map[string]Sample)
+	for _, s := range samples {
+		latest[s.Stressor] = s
+	}
+	result := make([]Sample, 0, len(latest))
+	for _, s := range latest {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Stressor < result[j].Stressor })
+	return result
+}
+
+// latestValues returns the most recent value of each series, sorted by series name.
+func (r *Recorder) latestValues() []Value {
+	values := r.Values()
+	latest := make(map[string]Value)
+	for _, v := range values {
+		latest[v.Series] = v
+	}
+	result := make([]Value, 0, len(latest))
+	for _, v := range latest {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Series < result[j].Series })
+	return result
+}
@@ -0,0 +1,267 @@
+// Package monitor は実行中のシステム全体の資源の状況 (CPU 使用率・メモリ・スワップ・メモリプレッシャー・
+// ディスクの使用率) を一定間隔で測定します。ダッシュボードの表示、安全制限 (--max-mem・--max-disk-fill)、
+// メモリとストレージのパーセンテージ指定の負荷が同じ測定値を共有します。
+package monitor
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// DefaultInterval はデフォルトの測定間隔です。
+const DefaultInterval = time.Second
+
+// staleSamples is how many intervals a sample is used for before a caller
+// asking for a value reads it from the system instead.
+const staleSamples = 2
+
+// Snapshot は1回の測定結果です。
+type Snapshot struct {
+	// Time は測定した時刻です。一度も測定していない場合はゼロ値です。
+	Time time.Time
+	// CPU はシステム全体の CPU 使用率 (0.0〜1.0) です。最初の測定など、取得できない場合は -1 です。
+	CPU float64
+	// MemoryTotal はメモリの総量 (バイト) です。cgroup のメモリ上限の方が小さい場合はその上限です。
+	// 取得できない場合は 0 です。
+	MemoryTotal int64
+	// MemoryAvailable はプロセスが新たに確保できるメモリの量 (バイト) です。
+	MemoryAvailable int64
+	// SwapTotal と SwapFree はスワップの総量と空き (バイト) です。スワップがない場合は 0 です。
+	SwapTotal int64
+	SwapFree  int64
+	// MemoryPressure はメモリの PSI (some avg10、%) です。取得できない場合は -1 です。
+	MemoryPressure float64
+	// Disks は Watch で監視しているパスごとのファイルシステムの容量です。
+	Disks map[string]sysinfo.DiskSpace
+}
+
+// MemoryUsed は使用中のメモリの割合 (0.0〜1.0) を返します。メモリの総量が不明な場合は false を返します。
+func (s Snapshot) MemoryUsed() (float64, bool) {
+	if s.MemoryTotal <= 0 {
+		return 0, false
+	}
+	return usedShare(s.MemoryTotal, s.MemoryAvailable), true
+}
+
+// SwapUsed は使用中のスワップの割合 (0.0〜1.0) を返します。スワップがない場合は false を返します。
+func (s Snapshot) SwapUsed() (float64, bool) {
+	if s.SwapTotal <= 0 {
+		return 0, false
+	}
+	return usedShare(s.SwapTotal, s.SwapFree), true
+}
+
+// DiskUsed は path を含むファイルシステムの使用中の割合 (0.0〜1.0) を返します。
+// path を監視していない場合や容量が不明な場合は false を返します。
+//
+// 引数:
+//
+//	path - Watch で監視しているパス
+func (s Snapshot) DiskUsed(path string) (float64, bool) {
+	disk, ok := s.Disks[filepath.Clean(path)]
+	if !ok || disk.Total <= 0 {
+		return 0, false
+	}
+	return usedShare(disk.Total, disk.Available), true
+}
+
+// usedShare returns the share of total that is not free.
+func usedShare(total, free int64) float64 {
+	return min(max(float64(total-free)/float64(total), 0), 1)
+}
+
+// Monitor はシステムの資源の状況を一定間隔で測定し、最新の測定結果を提供します。
+// New で作成し、Run で測定を開始します。Watch・Latest・MemoryAvailable・DiskSpace は nil の Monitor でも
+// 呼び出すことができ、その場合は呼び出しのたびにシステムから直接読み取ります。
+type Monitor struct {
+	interval time.Duration
+
+	mu          sync.Mutex
+	paths       map[string]int // watched path -> number of watchers
+	latest      Snapshot
+	cpu         sysinfo.CPUTimes
+	subscribers []chan Snapshot
+}
+
+// New は interval ごとに測定する Monitor を作成します。
+//
+// 引数:
+//
+//	interval - 測定間隔 (0 以下の場合は DefaultInterval)
+func New(interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Monitor{interval: interval, paths: make(map[string]int)}
+}
+
+// Watch は path を含むファイルシステムの容量を以降の測定に加え、監視をやめる関数を返します。
+// 同じパスを複数回監視した場合は、すべての監視をやめるまで測定を続けます。
+//
+// 引数:
+//
+//	path - 監視するディレクトリ
+func (m *Monitor) Watch(path string) (unwatch func()) {
+	if m == nil {
+		return func() {}
+	}
+	path = filepath.Clean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paths[path]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.paths[path]--; m.paths[path] <= 0 {
+				delete(m.paths, path)
+			}
+		})
+	}
+}
+
+// Run は ctx が終了するまで測定を続けます。最初の測定はすぐに行います。
+//
+// 引数:
+//
+//	ctx - 測定を止めるコンテキスト
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.publish(m.sample())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Subscribe は以降の測定結果を受け取るチャネルを返します。受け取りが遅れた場合は最新の結果だけが残ります。
+// チャネルは ctx が終了すると閉じられます。
+//
+// 引数:
+//
+//	ctx - 受け取りをやめるコンテキスト
+func (m *Monitor) Subscribe(ctx context.Context) <-chan Snapshot {
+	ch := make(chan Snapshot, 1)
+	m.mu.Lock()
+	m.subscribers = append(m.subscribers, ch)
+	m.mu.Unlock()
+	context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.subscribers = slices.DeleteFunc(m.subscribers, func(c chan Snapshot) bool { return c == ch })
+		close(ch)
+	})
+	return ch
+}
+
+// Latest は最新の測定結果を返します。一度も測定していない場合や m が nil の場合はゼロ値を返します。
+func (m *Monitor) Latest() Snapshot {
+	if m == nil {
+		return Snapshot{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latest
+}
+
+// MemoryAvailable はプロセスが新たに確保できるメモリの量 (バイト) を返します。
+// 最新の測定結果が古い場合や m が nil の場合は、システムから直接読み取ります。
+func (m *Monitor) MemoryAvailable() (int64, error) {
+	if s, ok := m.fresh(); ok && s.MemoryTotal > 0 {
+		return s.MemoryAvailable, nil
+	}
+	return sysinfo.MemoryAvailable()
+}
+
+// DiskSpace は path を含むファイルシステムの容量を返します。
+// path を監視していない場合や最新の測定結果が古い場合は、システムから直接読み取ります。
+//
+// 引数:
+//
+//	path - 容量を調べるディレクトリ
+func (m *Monitor) DiskSpace(path string) (sysinfo.DiskSpace, error) {
+	if s, ok := m.fresh(); ok {
+		if disk, ok := s.Disks[filepath.Clean(path)]; ok {
+			return disk, nil
+		}
+	}
+	return sysinfo.ReadDiskSpace(path)
+}
+
+// fresh returns the latest sample unless it is too old to stand for the
+// current state of the system.
+func (m *Monitor) fresh() (Snapshot, bool) {
+	s := m.Latest()
+	if s.Time.IsZero() || time.Since(s.Time) > staleSamples*m.interval {
+		return s, false
+	}
+	return s, true
+}
+
+// sample reads the state of the system. Values the system does not report are
+// left at their "unknown" value.
+func (m *Monitor) sample() Snapshot {
+	s := Snapshot{Time: time.Now(), CPU: -1, MemoryPressure: -1}
+
+	if times, err := sysinfo.ReadCPUTimes(); err == nil {
+		m.mu.Lock()
+		if m.cpu.Total > 0 {
+			s.CPU = sysinfo.Utilization(m.cpu, times)
+		}
+		m.cpu = times
+		m.mu.Unlock()
+	}
+	if snapshot, err := sysinfo.Read(); err == nil {
+		s.MemoryTotal, s.MemoryAvailable = snapshot.MemoryTotal, snapshot.MemoryAvailable
+		s.SwapTotal, s.SwapFree = snapshot.SwapTotal, snapshot.SwapFree
+	}
+	// A container's memory ends at its cgroup limit, whatever the host has free
+	if cg, err := sysinfo.ReadCgroup(); err == nil && cg.MemoryLimit > 0 {
+		if s.MemoryTotal == 0 {
+			s.MemoryAvailable = cg.MemoryHeadroom()
+		}
+		s.MemoryTotal = min(cmp.Or(s.MemoryTotal, cg.MemoryLimit), cg.MemoryLimit)
+		s.MemoryAvailable = min(s.MemoryAvailable, cg.MemoryHeadroom())
+	}
+	if pressure, err := sysinfo.ReadPressure("memory"); err == nil {
+		s.MemoryPressure = pressure
+	}
+
+	m.mu.Lock()
+	paths := slices.Collect(maps.Keys(m.paths))
+	m.mu.Unlock()
+	s.Disks = make(map[string]sysinfo.DiskSpace, len(paths))
+	for _, path := range paths {
+		if disk, err := sysinfo.ReadDiskSpace(path); err == nil {
+			s.Disks[path] = disk
+		}
+	}
+	return s
+}
+
+// publish makes s the latest sample and hands it to the subscribers, replacing
+// any sample they have not taken yet.
+func (m *Monitor) publish(s Snapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latest = s
+	for _, ch := range m.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- s
+	}
+}
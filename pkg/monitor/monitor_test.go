@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+func TestSnapshotUsage(t *testing.T) {
+	s := Snapshot{
+		MemoryTotal: 1000, MemoryAvailable: 250,
+		Disks: map[string]sysinfo.DiskSpace{"/data": {Total: 200, Available: 20}},
+	}
+	if used, ok := s.MemoryUsed(); !ok || used != 0.75 {
+		t.Errorf("MemoryUsed() = %v, %v, want 0.75", used, ok)
+	}
+	if _, ok := s.SwapUsed(); ok {
+		t.Error("SwapUsed() reported swap without any")
+	}
+	if used, ok := s.DiskUsed("/data/"); !ok || used != 0.9 {
+		t.Errorf("DiskUsed(/data/) = %v, %v, want 0.9", used, ok)
+	}
+	if _, ok := s.DiskUsed("/other"); ok {
+		t.Error("DiskUsed reported a path that is not watched")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	m := New(time.Hour)
+	dir := t.TempDir()
+	unwatch1 := m.Watch(dir)
+	unwatch2 := m.Watch(dir + "/")
+	if _, ok := m.sample().Disks[dir]; !ok {
+		t.Fatalf("sample() does not include the watched %s", dir)
+	}
+	unwatch1()
+	unwatch1()
+	if _, ok := m.sample().Disks[dir]; !ok {
+		t.Fatal("unwatching once more than watched stopped watching")
+	}
+	unwatch2()
+	if _, ok := m.sample().Disks[dir]; ok {
+		t.Fatal("sample() includes a path no longer watched")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	m := New(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	samples := m.Subscribe(ctx)
+	m.publish(Snapshot{MemoryTotal: 1})
+	m.publish(Snapshot{MemoryTotal: 2})
+	if s := <-samples; s.MemoryTotal != 2 {
+		t.Errorf("received MemoryTotal %d, want only the latest sample", s.MemoryTotal)
+	}
+	if m.Latest().MemoryTotal != 2 {
+		t.Errorf("Latest().MemoryTotal = %d, want 2", m.Latest().MemoryTotal)
+	}
+	cancel()
+	if _, ok := <-samples; ok {
+		t.Error("channel still open after the context ended")
+	}
+}
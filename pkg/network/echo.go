@@ -0,0 +1,107 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// maxDatagram is the largest UDP payload the echo server receives.
+const maxDatagram = 65507
+
+// Server は TCP と UDP の同じポートで受信したデータをそのまま送り返すエコーサーバーです。
+// Listen が返します。負荷の送信先を指定しない場合に使用するほか、別のホストで起動して送信先にできます。
+type Server struct {
+	tcp  net.Listener
+	udp  net.PacketConn
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	conn map[net.Conn]bool
+}
+
+// Listen は addr (例: ":9000"、"127.0.0.1:0") の TCP と UDP でエコーサーバーを開始します。
+// ポートに 0 を指定すると、TCP で空いているポートを選び、UDP でも同じポートを使用します。
+//
+// 引数:
+//
+//	addr - 待ち受けるアドレス
+func Listen(addr string) (*Server, error) {
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	host, _, _ := net.SplitHostPort(addr)
+	port := tcp.Addr().(*net.TCPAddr).Port
+	udp, err := net.ListenPacket("udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		tcp.Close()
+		return nil, err
+	}
+
+	s := &Server{tcp: tcp, udp: udp, conn: make(map[net.Conn]bool)}
+	s.wg.Add(2)
+	go s.acceptTCP()
+	go s.echoUDP()
+	return s, nil
+}
+
+// Addr は待ち受けているアドレス (ホスト:ポート) を返します。
+func (s *Server) Addr() string {
+	return s.tcp.Addr().String()
+}
+
+// Close は待ち受けと接続をすべて閉じ、処理の終了を待ちます。
+func (s *Server) Close() error {
+	err := errors.Join(s.tcp.Close(), s.udp.Close())
+	s.mu.Lock()
+	for c := range s.conn {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+// acceptTCP echoes every accepted connection in its own goroutine until the listener closes.
+func (s *Server) acceptTCP() {
+	defer s.wg.Done()
+	for {
+		c, err := s.tcp.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s.mu.Lock()
+		s.conn[c] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			io.Copy(c, c)
+			c.Close()
+			s.mu.Lock()
+			delete(s.conn, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// echoUDP sends every datagram back to its sender until the socket closes.
+func (s *Server) echoUDP() {
+	defer s.wg.Done()
+	buffer := make([]byte, maxDatagram)
+	for {
+		n, addr, err := s.udp.ReadFrom(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s.udp.WriteTo(buffer[:n], addr)
+	}
+}
@@ -0,0 +1,458 @@
+// Package network は TCP・UDP の送信やコネクションの生成と切断を繰り返すことで、
+// NIC・ネットワークスタック・コネクション追跡 (conntrack) のテーブルに負荷をかけます。
+// 送信先を指定しない場合は、ループバックで起動する組み込みのエコーサーバーに送信します。
+package network
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// sampleInterval is how often the achieved rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// retryInterval is how long a worker waits before connecting again after an error.
+const retryInterval = time.Second
+
+// ioTimeout bounds connecting and reading the echo of a churned connection.
+const ioTimeout = 5 * time.Second
+
+// maxLag is how far a paced worker may fall behind its schedule, for example
+// while a full send buffer blocks it, before it gives up catching up.
+const maxLag = time.Second
+
+// defaultConnections is the number of connections, each with its own worker, unless Options.Connections is set.
+const defaultConnections = 4
+
+// Default sizes of one write: a large one for TCP, a datagram that is not
+// fragmented on an Ethernet MTU for UDP, and none for churned connections.
+const (
+	defaultTCPSize = 64 * 1024
+	defaultUDPSize = 1400
+)
+
+// Mode はネットワーク負荷の種類です。
+type Mode string
+
+const (
+	// ModeTCP は TCP のコネクションで送信し続け、帯域に負荷をかけます。
+	ModeTCP Mode = "tcp"
+	// ModeUDP は UDP のデータグラムを送信し続け、パケットの処理に負荷をかけます。
+	ModeUDP Mode = "udp"
+	// ModeChurn は TCP のコネクションの確立と切断を繰り返し、コネクション追跡のテーブルやエフェメラルポートに負荷をかけます。
+	ModeChurn Mode = "churn"
+)
+
+// Validate は種類が有効かどうかを検証します。
+func (m Mode) Validate() error {
+	switch m {
+	case ModeTCP, ModeUDP, ModeChurn:
+		return nil
+	default:
+		return fmt.Errorf("unknown network mode %q (tcp, udp or churn)", m)
+	}
+}
+
+// Options はネットワーク負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Network" を使用します。
+	Name string
+	// Mode は負荷の種類です。
+	Mode Mode
+	// Target は送信先 (ホスト:ポート) です。空の場合はループバックで組み込みのエコーサーバーを起動して送信先にします。
+	// ModeChurn で Size を指定する場合、送信先は受信したデータを送り返す必要があります (stress-go echo など)。
+	Target string
+	// Size は1回の送信のサイズ（バイト）です。ModeUDP ではデータグラムのサイズです。
+	// 0 の場合は ModeTCP で 64KB、ModeUDP で 1400 バイト、ModeChurn では送信せずに接続と切断だけを行います。
+	Size int
+	// Rate は目標値です。ModeTCP と ModeUDP では送信するバイト数、ModeChurn では確立するコネクション数 (1秒あたり) です。
+	// 0 の場合は制限せずに可能な限り送信します。
+	Rate float64
+	// Connections は並列に使用するコネクションの数です。コネクションごとに1つのワーカーが送信します。0 の場合は 4 です。
+	Connections int
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if err := o.Mode.Validate(); err != nil {
+		return err
+	}
+	if o.Size < 0 || o.Rate < 0 || o.Connections < 0 {
+		return fmt.Errorf("network size, rate and connections must not be negative")
+	}
+	if o.Mode == ModeUDP && o.Size > maxDatagram {
+		return fmt.Errorf("UDP datagrams must be at most %d bytes: %d", maxDatagram, o.Size)
+	}
+	if o.Target != "" {
+		if _, _, err := net.SplitHostPort(o.Target); err != nil {
+			return fmt.Errorf("invalid network target %q: %v", o.Target, err)
+		}
+	}
+	return nil
+}
+
+// Unit は Mode の目標値と実測値の単位を返します。
+func (o Options) Unit() string {
+	if o.Mode == ModeChurn {
+		return metrics.UnitConnsPerSecond
+	}
+	return metrics.UnitBytesPerSecond
+}
+
+// Result はネットワーク負荷の実行結果です。
+type Result struct {
+	// BytesSent と BytesReceived は送信・受信したバイト数です。
+	BytesSent     int64
+	BytesReceived int64
+	// Connections は確立したコネクションの数です。
+	Connections int64
+	// Errors は接続・送受信のエラーの数です。
+	Errors int64
+}
+
+// Stats は実行中のネットワーク負荷の状態です。
+type Stats struct {
+	// Target は現在の目標値 (Options.Rate の単位) です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定での実測値です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// Controller は実行中のネットワーク負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+	// buffer is the data sent, shared read-only by the workers
+	buffer []byte
+	// lastError is the last error logged, so that a worker retrying against the
+	// same failure does not repeat it every retryInterval
+	mu        sync.Mutex
+	lastError string
+
+	sent        atomic.Int64
+	received    atomic.Int64
+	connections atomic.Int64
+	errors      atomic.Int64
+	scale       atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused      atomic.Bool
+	achieved    atomic.Uint64 // math.Float64bits of the last measured rate
+}
+
+// Start は opts に従ってネットワーク負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 送信先を指定していない場合は、エコーサーバーを起動してから返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続き、終了時にコネクションとエコーサーバーを閉じます。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Network"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	if opts.Connections == 0 {
+		opts.Connections = defaultConnections
+	}
+	if opts.Size == 0 {
+		switch opts.Mode {
+		case ModeTCP:
+			opts.Size = defaultTCPSize
+		case ModeUDP:
+			opts.Size = defaultUDPSize
+		}
+	}
+
+	var server *Server
+	if opts.Target == "" {
+		var err error
+		if server, err = Listen("127.0.0.1:0"); err != nil {
+			return nil, fmt.Errorf("failed to start the echo server: %v", err)
+		}
+		opts.Target = server.Addr()
+	}
+
+	c := &Controller{opts: opts, done: make(chan struct{}), buffer: make([]byte, opts.Size)}
+	// Random data keeps compression on the path from shrinking the traffic
+	rand.NewChaCha8([32]byte{}).Read(c.buffer)
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx, server)
+	return c, nil
+}
+
+// run starts the workers and records the achieved rate until ctx is done.
+func (c *Controller) run(ctx context.Context, server *Server) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("Network", "Load generation completed")
+	if server != nil {
+		defer server.Close()
+		recorder.Logf("Network", "Echo server listening on %s (TCP and UDP)", server.Addr())
+	}
+
+	switch c.opts.Mode {
+	case ModeChurn:
+		recorder.Logf("Network", "Opening and closing TCP connections to %s on %d workers", c.opts.Target, c.opts.Connections)
+	default:
+		recorder.Logf("Network", "Sending %d-byte %s writes to %s over %d connections", c.opts.Size, c.opts.Mode, c.opts.Target, c.opts.Connections)
+	}
+	var wg sync.WaitGroup
+	for range c.opts.Connections {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.opts.Mode == ModeChurn {
+				c.churn(ctx)
+			} else {
+				c.stream(ctx, string(c.opts.Mode))
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	last, lastTime := c.progress(), time.Now()
+	var counted Result
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			progress := c.progress()
+			rate := float64(progress-last) / now.Sub(lastTime).Seconds()
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.Record("Network", c.opts.Unit(), c.targetRate(), rate)
+			last, lastTime = progress, now
+			c.count(&counted)
+		}
+	}
+	wg.Wait()
+	c.count(&counted)
+
+	c.result = Result{
+		BytesSent:     c.sent.Load(),
+		BytesReceived: c.received.Load(),
+		Connections:   c.connections.Load(),
+		Errors:        c.errors.Load(),
+	}
+	recorder.Logf("Network", "Sent %d MiB, received %d MiB over %d connections with %d errors",
+		c.result.BytesSent/(1024*1024), c.result.BytesReceived/(1024*1024), c.result.Connections, c.result.Errors)
+}
+
+// count adds the traffic since the last call to the counts of the recorder, so
+// that they stay current for the metrics endpoint while the load runs.
+func (c *Controller) count(counted *Result) {
+	recorder := c.opts.Recorder
+	sent, received, connections := c.sent.Load(), c.received.Load(), c.connections.Load()
+	recorder.AddCount("Network", "bytes_sent", sent-counted.BytesSent)
+	recorder.AddCount("Network", "bytes_received", received-counted.BytesReceived)
+	recorder.AddCount("Network", "connections", connections-counted.Connections)
+	counted.BytesSent, counted.BytesReceived, counted.Connections = sent, received, connections
+}
+
+// progress returns the measure of the mode's rate so far: connections for churn, bytes sent otherwise.
+func (c *Controller) progress() int64 {
+	if c.opts.Mode == ModeChurn {
+		return c.connections.Load()
+	}
+	return c.sent.Load()
+}
+
+// stream keeps one connection of network ("tcp" or "udp") open and writes to it
+// at the worker's share of the target rate until ctx is done, reading and
+// discarding whatever comes back. It reconnects after errors.
+func (c *Controller) stream(ctx context.Context, network string) {
+	dialer := net.Dialer{Timeout: ioTimeout}
+	for ctx.Err() == nil {
+		conn, err := dialer.DialContext(ctx, network, c.opts.Target)
+		if err != nil {
+			c.fail(ctx, "Connect error: %v", err)
+			continue
+		}
+		c.connections.Add(1)
+		// Closing the connection unblocks a write stalled by a full send buffer
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		drained := make(chan struct{})
+		go func() {
+			defer close(drained)
+			c.drain(conn)
+		}()
+
+		next := time.Now()
+		for ctx.Err() == nil {
+			if !c.pace(ctx, &next) {
+				continue
+			}
+			n, err := conn.Write(c.buffer)
+			c.sent.Add(int64(n))
+			if err != nil {
+				c.fail(ctx, "Send error: %v", err)
+				break
+			}
+		}
+		stop()
+		conn.Close()
+		<-drained
+	}
+}
+
+// drain reads and discards the data coming back on conn until it is closed.
+func (c *Controller) drain(conn net.Conn) {
+	buffer := make([]byte, max(c.opts.Size, 64*1024))
+	for {
+		n, err := conn.Read(buffer)
+		c.received.Add(int64(n))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// churn opens and closes TCP connections at the worker's share of the target
+// rate until ctx is done. With a size, each connection sends that much and
+// waits for the echo before closing.
+func (c *Controller) churn(ctx context.Context) {
+	dialer := net.Dialer{Timeout: ioTimeout}
+	next := time.Now()
+	for ctx.Err() == nil {
+		if !c.pace(ctx, &next) {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", c.opts.Target)
+		if err != nil {
+			c.fail(ctx, "Connect error: %v", err)
+			continue
+		}
+		if err := c.exchange(conn); err != nil {
+			conn.Close()
+			c.fail(ctx, "Echo error: %v", err)
+			continue
+		}
+		conn.Close()
+		c.connections.Add(1)
+	}
+}
+
+// exchange sends the buffer on conn and reads the echo back.
+func (c *Controller) exchange(conn net.Conn) error {
+	if len(c.buffer) == 0 {
+		return nil
+	}
+	conn.SetDeadline(time.Now().Add(ioTimeout))
+	n, err := conn.Write(c.buffer)
+	c.sent.Add(int64(n))
+	if err != nil {
+		return err
+	}
+	n, err = io.ReadFull(conn, make([]byte, len(c.buffer)))
+	c.received.Add(int64(n))
+	return err
+}
+
+// pace waits until the worker's next operation is due at its share of the
+// target rate, and reports false when the worker should check ctx and its
+// pause state again instead of operating.
+func (c *Controller) pace(ctx context.Context, next *time.Time) bool {
+	if c.paused.Load() || c.scale.Load() == 0 {
+		timing.Sleep(ctx, timing.IdleInterval)
+		*next = time.Now()
+		return false
+	}
+	rate := c.targetRate()
+	if rate <= 0 {
+		return true
+	}
+	// A TCP or UDP operation is one write of Size bytes
+	if c.opts.Mode != ModeChurn {
+		rate /= float64(c.opts.Size)
+	}
+	*next = next.Add(time.Duration(float64(time.Second) * float64(c.opts.Connections) / rate))
+	if lag := time.Since(*next); lag > maxLag {
+		*next = time.Now()
+	}
+	return timing.Sleep(ctx, time.Until(*next))
+}
+
+// fail counts an error and logs it unless it repeats the last one, then waits
+// retryInterval before the worker tries again. Errors caused by the end of the
+// load are ignored.
+func (c *Controller) fail(ctx context.Context, format string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	c.errors.Add(1)
+	c.opts.Recorder.AddCount("Network", "errors", 1)
+	message := fmt.Sprintf(format, err)
+	c.mu.Lock()
+	repeated := message == c.lastError
+	c.lastError = message
+	c.mu.Unlock()
+	if !repeated {
+		c.opts.Recorder.Logf("Network", format, err)
+	}
+	timing.Sleep(ctx, retryInterval)
+}
+
+// targetRate returns the current target in the unit of Options.Rate, or 0 for as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return c.opts.Rate * math.Float64frombits(c.scale.Load())
+}
+
+// SetScale は Options で指定した目標値に掛ける係数を変更します (1.0 で指定どおり)。
+// 目標を指定していない場合は、0 で停止し、それ以外では可能な限り送信します。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで送信を止めます。TCP のコネクションは開いたままにします。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了してコネクションを閉じるまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, nil
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:   c.targetRate(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Paused:   c.paused.Load(),
+	}
+}
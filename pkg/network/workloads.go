@@ -0,0 +1,30 @@
+package network
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "network",
+		Name:        string(ModeTCP),
+		Usage:       "--network tcp",
+		Description: "Streaming data over TCP connections to load the bandwidth of the NIC and network stack",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "network",
+		Name:        string(ModeUDP),
+		Usage:       "--network udp",
+		Description: "Sending UDP datagrams to load the packet processing of the NIC and network stack",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "network",
+		Name:        string(ModeChurn),
+		Usage:       "--network churn",
+		Description: "Opening and closing TCP connections to churn the connection tracking table and ephemeral ports",
+		Available:   true,
+	})
+}
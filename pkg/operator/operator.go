@@ -0,0 +1,350 @@
+// Package operator は StressTest カスタムリソースに従ってクラスター上で負荷テストを実行する
+// Kubernetes オペレーターを実装します。
+//
+// オペレーターは StressTest ごとに、nodeSelector に一致するノードへ stress-go の Pod を
+// 1つずつ作成します。Pod の状態と stress-go の結果の要約 (コンテナの終了メッセージとして
+// 書き出されます) を StressTest の status に集め、全 Pod が終了したら Pod を削除します。
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/kube"
+)
+
+// testLabel is the pod label holding the UID of the owning StressTest.
+const testLabel = Group + "/test-uid"
+
+// terminationLog is where the pod's stress-go writes its summary, which Kubernetes
+// then reports as the container's termination message.
+const terminationLog = "/dev/termination-log"
+
+// Options はオペレーターの設定です。
+type Options struct {
+	// Client は Kubernetes API サーバーのクライアントです。
+	Client *kube.Client
+	// Image は spec.image を指定していない StressTest で使用する stress-go のイメージです。
+	Image string
+	// Interval は StressTest の状態を確認する間隔です。0 の場合は 10 秒です。
+	Interval time.Duration
+	// Logf はオペレーターの動作を記録する関数です (nil可)。
+	Logf func(format string, args ...any)
+}
+
+// Operator は StressTest リソースを調整するオペレーターです。New で作成します。
+type Operator struct {
+	opts Options
+}
+
+// New は opts に従って動作する Operator を作成します。
+func New(opts Options) *Operator {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...any) {}
+	}
+	return &Operator{opts: opts}
+}
+
+// Run は ctx が終了するまで、一定間隔ですべての StressTest を調整します。
+// 個々の調整の失敗は記録して次の間隔で再試行します。
+func (o *Operator) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := o.ReconcileAll(ctx); err != nil {
+			o.opts.Logf("Error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileAll は全ネームスペースの StressTest を一度ずつ調整します。
+func (o *Operator) ReconcileAll(ctx context.Context) error {
+	var list stressTestList
+	if err := o.opts.Client.Get(ctx, "/apis/"+APIVersion+"/"+Resource, &list); err != nil {
+		return fmt.Errorf("failed to list stress tests: %v", err)
+	}
+	for i := range list.Items {
+		st := &list.Items[i]
+		if err := o.reconcile(ctx, st); err != nil {
+			o.opts.Logf("[%s/%s] Error: %v", st.Metadata.Namespace, st.Metadata.Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcile moves one stress test forward: it creates the pods of a new test,
+// collects the pod results of a running one and removes the pods of a finished one.
+func (o *Operator) reconcile(ctx context.Context, st *StressTest) error {
+	// Pods are removed with the stress test through their owner reference
+	if st.Metadata.DeletionTimestamp != nil {
+		return nil
+	}
+
+	switch st.Status.Phase {
+	case PhaseSucceeded, PhaseFailed:
+		if st.Spec.KeepPods {
+			return nil
+		}
+		return o.deletePods(ctx, st)
+	case "", PhasePending:
+		return o.start(ctx, st)
+	default:
+		return o.track(ctx, st)
+	}
+}
+
+// start creates one pod on every schedulable node matching the node selector.
+func (o *Operator) start(ctx context.Context, st *StressTest) error {
+	if _, err := time.ParseDuration(st.Spec.Duration); err != nil {
+		return o.fail(ctx, st, fmt.Sprintf("invalid duration %q", st.Spec.Duration))
+	}
+	image := st.Spec.Image
+	if image == "" {
+		image = o.opts.Image
+	}
+	if image == "" {
+		return o.fail(ctx, st, "no image: set spec.image or the operator's --image")
+	}
+
+	nodes, err := o.matchingNodes(ctx, st.Spec.NodeSelector)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return o.fail(ctx, st, "no schedulable nodes match the node selector")
+	}
+
+	now := time.Now().UTC()
+	st.Status = StressTestStatus{
+		Phase:              PhaseRunning,
+		StartTime:          &now,
+		Nodes:              len(nodes),
+		ObservedGeneration: st.Metadata.Generation,
+	}
+	for _, nodeName := range nodes {
+		p := o.newPod(st, nodeName, image)
+		err := o.opts.Client.Create(ctx, podsPath(st.Metadata.Namespace), p, nil)
+		if err != nil && !kube.IsConflict(err) {
+			return fmt.Errorf("failed to create pod on %s: %v", nodeName, err)
+		}
+		st.Status.Pods = append(st.Status.Pods, PodResult{Node: nodeName, Pod: p.Metadata.Name, Phase: "Pending"})
+	}
+	o.opts.Logf("[%s/%s] Started on %d nodes", st.Metadata.Namespace, st.Metadata.Name, len(nodes))
+	return o.updateStatus(ctx, st)
+}
+
+// track copies the state of every pod into the status and finishes the stress
+// test once all pods have terminated.
+func (o *Operator) track(ctx context.Context, st *StressTest) error {
+	pods, err := o.listPods(ctx, st)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]pod, len(pods))
+	for _, p := range pods {
+		byName[p.Metadata.Name] = p
+	}
+
+	previous := st.Status
+	previous.Pods = append([]PodResult(nil), st.Status.Pods...)
+	st.Status.Succeeded, st.Status.Failed = 0, 0
+	for i := range st.Status.Pods {
+		r := &st.Status.Pods[i]
+		if p, ok := byName[r.Pod]; ok {
+			updateResult(r, p)
+		} else if r.Phase != "Succeeded" && r.Phase != "Failed" {
+			r.Phase = "Failed"
+		}
+		switch r.Phase {
+		case "Succeeded":
+			st.Status.Succeeded++
+		case "Failed":
+			st.Status.Failed++
+		}
+	}
+
+	if st.Status.Succeeded+st.Status.Failed == len(st.Status.Pods) {
+		now := time.Now().UTC()
+		st.Status.CompletionTime = &now
+		st.Status.Phase = PhaseSucceeded
+		st.Status.Message = fmt.Sprintf("Completed on %d nodes", st.Status.Succeeded)
+		if st.Status.Failed > 0 {
+			st.Status.Phase = PhaseFailed
+			st.Status.Message = fmt.Sprintf("Failed on %d of %d nodes", st.Status.Failed, len(st.Status.Pods))
+		}
+		o.opts.Logf("[%s/%s] %s", st.Metadata.Namespace, st.Metadata.Name, st.Status.Message)
+	} else {
+		st.Status.Message = fmt.Sprintf("%d of %d pods finished", st.Status.Succeeded+st.Status.Failed, len(st.Status.Pods))
+	}
+
+	if reflect.DeepEqual(previous, st.Status) {
+		return nil
+	}
+	return o.updateStatus(ctx, st)
+}
+
+// updateResult copies the pod's phase, and once terminated the exit code and
+// summary of stress-go, into r.
+func updateResult(r *PodResult, p pod) {
+	if p.Status.Phase != "" {
+		r.Phase = p.Status.Phase
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil {
+			code := t.ExitCode
+			r.ExitCode = &code
+			var summary cluster.Summary
+			if json.Unmarshal([]byte(t.Message), &summary) == nil {
+				summary.Host = r.Node
+				r.Summary = &summary
+			}
+		}
+	}
+}
+
+// fail marks a stress test that cannot be started as failed.
+func (o *Operator) fail(ctx context.Context, st *StressTest, message string) error {
+	o.opts.Logf("[%s/%s] %s", st.Metadata.Namespace, st.Metadata.Name, message)
+	now := time.Now().UTC()
+	st.Status = StressTestStatus{
+		Phase:              PhaseFailed,
+		Message:            message,
+		CompletionTime:     &now,
+		ObservedGeneration: st.Metadata.Generation,
+	}
+	return o.updateStatus(ctx, st)
+}
+
+// matchingNodes returns the names of the schedulable nodes matching selector.
+func (o *Operator) matchingNodes(ctx context.Context, selector map[string]string) ([]string, error) {
+	path := "/api/v1/nodes"
+	if len(selector) > 0 {
+		var terms []string
+		for key, value := range selector {
+			terms = append(terms, key+"="+value)
+		}
+		path += "?" + url.Values{"labelSelector": {strings.Join(terms, ",")}}.Encode()
+	}
+
+	var nodes nodeList
+	if err := o.opts.Client.Get(ctx, path, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	var names []string
+	for _, n := range nodes.Items {
+		if !n.Spec.Unschedulable {
+			names = append(names, n.Metadata.Name)
+		}
+	}
+	return names, nil
+}
+
+// newPod returns the pod that runs the stress test on one node.
+func (o *Operator) newPod(st *StressTest, nodeName, image string) pod {
+	args := append([]string{"--timeout", st.Spec.Duration}, st.Spec.Args...)
+	args = append(args, "--summary-json", terminationLog)
+	return pod{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Metadata: ObjectMeta{
+			Name:      st.Metadata.Name + "-" + nodeName,
+			Namespace: st.Metadata.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "stress-go",
+				"app.kubernetes.io/managed-by": "stress-operator",
+				testLabel:                      st.Metadata.UID,
+			},
+			OwnerReferences: []OwnerReference{{
+				APIVersion:         APIVersion,
+				Kind:               Kind,
+				Name:               st.Metadata.Name,
+				UID:                st.Metadata.UID,
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		Spec: podSpec{
+			NodeName:      nodeName,
+			RestartPolicy: "Never",
+			Containers: []container{{
+				Name:                   "stress-go",
+				Image:                  image,
+				Command:                []string{"stress-go"},
+				Args:                   args,
+				Env:                    downwardEnv(),
+				TerminationMessagePath: terminationLog,
+			}},
+		},
+	}
+}
+
+// downwardEnv exposes the node, pod and namespace names to stress-go, which
+// stamps them onto its metrics and summary.
+func downwardEnv() []envVar {
+	var vars []envVar
+	for name, path := range map[string]string{
+		"NODE_NAME":     "spec.nodeName",
+		"POD_NAME":      "metadata.name",
+		"POD_NAMESPACE": "metadata.namespace",
+	} {
+		v := envVar{Name: name}
+		v.ValueFrom.FieldRef.FieldPath = path
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars
+}
+
+// deletePods removes the remaining pods of a finished stress test.
+func (o *Operator) deletePods(ctx context.Context, st *StressTest) error {
+	pods, err := o.listPods(ctx, st)
+	if err != nil {
+		return err
+	}
+	for _, p := range pods {
+		if err := o.opts.Client.Delete(ctx, podsPath(st.Metadata.Namespace)+"/"+p.Metadata.Name); err != nil {
+			return fmt.Errorf("failed to delete pod %s: %v", p.Metadata.Name, err)
+		}
+	}
+	return nil
+}
+
+// listPods returns the pods created for the stress test.
+func (o *Operator) listPods(ctx context.Context, st *StressTest) ([]pod, error) {
+	var pods podList
+	query := url.Values{"labelSelector": {testLabel + "=" + st.Metadata.UID}}
+	if err := o.opts.Client.Get(ctx, podsPath(st.Metadata.Namespace)+"?"+query.Encode(), &pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+	return pods.Items, nil
+}
+
+// updateStatus writes the status subresource of the stress test.
+func (o *Operator) updateStatus(ctx context.Context, st *StressTest) error {
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/%s/%s/status", APIVersion, st.Metadata.Namespace, Resource, st.Metadata.Name)
+	if err := o.opts.Client.Update(ctx, path, st, nil); err != nil {
+		return fmt.Errorf("failed to update status: %v", err)
+	}
+	return nil
+}
+
+// podsPath returns the API path of the pods in namespace.
+func podsPath(namespace string) string {
+	return "/api/v1/namespaces/" + namespace + "/pods"
+}
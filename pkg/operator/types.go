@@ -0,0 +1,170 @@
+package operator
+
+import (
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+)
+
+// StressTest リソースの API グループ・バージョン・種類です。
+const (
+	Group      = "stress-go.utkamioka.github.io"
+	Version    = "v1alpha1"
+	Kind       = "StressTest"
+	Resource   = "stresstests"
+	APIVersion = Group + "/" + Version
+)
+
+// StressTest のフェーズです。
+const (
+	PhasePending   = "Pending"
+	PhaseRunning   = "Running"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+)
+
+// ObjectMeta は Kubernetes のリソースのメタデータのうち、オペレーターが使用するフィールドです。
+type ObjectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	UID               string            `json:"uid,omitempty"`
+	ResourceVersion   string            `json:"resourceVersion,omitempty"`
+	Generation        int64             `json:"generation,omitempty"`
+	CreationTimestamp time.Time         `json:"creationTimestamp,omitzero"`
+	DeletionTimestamp *time.Time        `json:"deletionTimestamp,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
+}
+
+// OwnerReference はリソースの所有者です。所有者が削除されると Kubernetes がリソースを削除します。
+type OwnerReference struct {
+	APIVersion         string `json:"apiVersion"`
+	Kind               string `json:"kind"`
+	Name               string `json:"name"`
+	UID                string `json:"uid"`
+	Controller         bool   `json:"controller,omitempty"`
+	BlockOwnerDeletion bool   `json:"blockOwnerDeletion,omitempty"`
+}
+
+// StressTest はクラスター上の負荷テストを宣言的に記述するカスタムリソースです。
+type StressTest struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Metadata   ObjectMeta       `json:"metadata"`
+	Spec       StressTestSpec   `json:"spec"`
+	Status     StressTestStatus `json:"status,omitzero"`
+}
+
+// StressTestSpec は負荷テストの内容です。
+type StressTestSpec struct {
+	// NodeSelector に一致するノードごとに1つの Pod で負荷をかけます。空の場合は全ノードが対象です。
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Duration は負荷をかける時間です (例: "30m")。stress-go の --timeout として渡します。
+	Duration string `json:"duration"`
+	// Args は各 Pod の stress-go に渡すオプションです (例: ["--cpu", "0", "--memory", "80%"])。
+	Args []string `json:"args,omitempty"`
+	// Image は stress-go のコンテナイメージです。空の場合はオペレーターの --image を使用します。
+	Image string `json:"image,omitempty"`
+	// KeepPods を true にすると、終了後も Pod を削除せずに残します。
+	KeepPods bool `json:"keepPods,omitempty"`
+}
+
+// StressTestStatus は負荷テストの進行状況と結果です。
+type StressTestStatus struct {
+	// Phase は Pending・Running・Succeeded・Failed のいずれかです。
+	Phase string `json:"phase,omitempty"`
+	// Message はフェーズの補足説明です。
+	Message string `json:"message,omitempty"`
+	// StartTime と CompletionTime は Pod を作成した時刻と全 Pod が終了した時刻です。
+	StartTime      *time.Time `json:"startTime,omitempty"`
+	CompletionTime *time.Time `json:"completionTime,omitempty"`
+	// Nodes・Succeeded・Failed は対象ノード数と、そのうち成功・失敗した Pod の数です。
+	Nodes     int `json:"nodes"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Pods はノードごとの Pod の状態です。
+	Pods []PodResult `json:"pods,omitempty"`
+	// ObservedGeneration は status に反映した spec の世代です。
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// PodResult は1つのノードで負荷をかける Pod の状態と結果です。
+type PodResult struct {
+	Node  string `json:"node"`
+	Pod   string `json:"pod"`
+	Phase string `json:"phase"`
+	// ExitCode は終了した stress-go の終了コードです。
+	ExitCode *int `json:"exitCode,omitempty"`
+	// Summary は stress-go が終了時に書き出した結果の要約です。
+	Summary *cluster.Summary `json:"summary,omitempty"`
+}
+
+// stressTestList is the response of listing StressTest resources.
+type stressTestList struct {
+	Items []StressTest `json:"items"`
+}
+
+// The Pod and Node types below hold only the fields the operator reads or sets.
+
+type pod struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   ObjectMeta `json:"metadata"`
+	Spec       podSpec    `json:"spec"`
+	Status     podStatus  `json:"status,omitzero"`
+}
+
+type podSpec struct {
+	NodeName      string      `json:"nodeName,omitempty"`
+	RestartPolicy string      `json:"restartPolicy,omitempty"`
+	Containers    []container `json:"containers"`
+}
+
+type container struct {
+	Name                   string   `json:"name"`
+	Image                  string   `json:"image"`
+	Command                []string `json:"command,omitempty"`
+	Args                   []string `json:"args,omitempty"`
+	Env                    []envVar `json:"env,omitempty"`
+	TerminationMessagePath string   `json:"terminationMessagePath,omitempty"`
+}
+
+type envVar struct {
+	Name      string `json:"name"`
+	ValueFrom struct {
+		FieldRef struct {
+			FieldPath string `json:"fieldPath"`
+		} `json:"fieldRef"`
+	} `json:"valueFrom"`
+}
+
+type podStatus struct {
+	Phase             string            `json:"phase,omitempty"`
+	Message           string            `json:"message,omitempty"`
+	ContainerStatuses []containerStatus `json:"containerStatuses,omitempty"`
+}
+
+type containerStatus struct {
+	Name  string `json:"name"`
+	State struct {
+		Terminated *struct {
+			ExitCode int    `json:"exitCode"`
+			Message  string `json:"message"`
+		} `json:"terminated,omitempty"`
+	} `json:"state"`
+}
+
+type podList struct {
+	Items []pod `json:"items"`
+}
+
+type node struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Unschedulable bool `json:"unschedulable,omitempty"`
+	} `json:"spec"`
+}
+
+type nodeList struct {
+	Items []node `json:"items"`
+}
@@ -0,0 +1,136 @@
+// Package overhead は stress-go 自身の計測・表示処理 (進行状況、指標の記録、監視) が消費する CPU 時間とメモリを、
+// 意図した負荷とは分けて計測し、必要に応じて計測・表示処理を専用の CPU に閉じ込めます。
+package overhead
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Go runtime metrics read by Sample. The GC and scavenger time are estimates
+// kept by the runtime. The memory in use is the total mapped by the runtime less
+// the free heap, which holds the buffers of a load just released.
+const (
+	metricGC          = "/cpu/classes/gc/total:cpu-seconds"
+	metricScavenge    = "/cpu/classes/scavenge/total:cpu-seconds"
+	metricMemory      = "/memory/classes/total:bytes"
+	metricHeapFree    = "/memory/classes/heap/free:bytes"
+	metricHeapRelease = "/memory/classes/heap/released:bytes"
+)
+
+var (
+	mu sync.Mutex
+	// threads holds the CPU time of each running bookkeeping thread when it
+	// started the bookkeeping, by thread ID.
+	threads = map[int]time.Duration{}
+	// finished is the bookkeeping CPU time of the threads that have stopped.
+	finished time.Duration
+	// accountingErr is why the bookkeeping threads cannot be measured, if so.
+	accountingErr error
+	// reserved is the CPU the bookkeeping threads are confined to, or -1.
+	reserved = -1
+)
+
+// Usage は計測・表示処理と Go ランタイムが使用した資源です。
+type Usage struct {
+	// Bookkeeping は Run で実行した計測・表示処理のスレッドが使用した CPU 時間の累計です。
+	// BookkeepingErr が nil でない場合は計測できていません。
+	Bookkeeping time.Duration
+	// BookkeepingErr はスレッドごとの CPU 時間を計測できない理由です (Linux 以外など)。
+	BookkeepingErr error
+	// Runtime は Go ランタイムの GC とメモリの返却が使用した CPU 時間の累計 (ランタイムの推定値) です。
+	Runtime time.Duration
+	// Memory は Go ランタイムが使用中のメモリ (空きのヒープを除く、バイト) です。負荷として確保したバッファも含みます。
+	Memory uint64
+}
+
+// CPU は計測・表示処理と Go ランタイムの CPU 時間の合計を返します。
+func (u Usage) CPU() time.Duration {
+	return u.Bookkeeping + u.Runtime
+}
+
+// Run は fn を計測・表示処理として呼び出し元の goroutine で実行し、使用した CPU 時間を計測します。
+// 実行中は goroutine を OS のスレッドに固定し、Reserve で CPU を予約している場合はそのスレッドを予約した CPU に固定します。
+//
+// 引数:
+//
+//	fn - 計測・表示処理 (負荷の生成は含めないでください)
+func Run(fn func()) {
+	runtime.LockOSThread()
+	tid := sysinfo.CurrentThread()
+	start, err := sysinfo.ThreadCPUTime(tid)
+
+	mu.Lock()
+	if err != nil {
+		accountingErr = err
+	} else {
+		threads[tid] = start
+	}
+	pinned := reserved >= 0 && sysinfo.PinThread(reserved) == nil
+	mu.Unlock()
+
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if start, ok := threads[tid]; ok {
+			if end, err := sysinfo.ThreadCPUTime(tid); err == nil {
+				finished += end - start
+			}
+			delete(threads, tid)
+		}
+		// A pinned thread stays locked, so that it exits with the goroutine
+		// instead of running the load on the reserved CPU
+		if !pinned {
+			runtime.UnlockOSThread()
+		}
+	}()
+	fn()
+}
+
+// Reserve は cpu を計測・表示処理の専用とし、プロセスの他のスレッド (負荷を生成するスレッドを含む) を cpu 以外で実行します。
+// 以降に Run で実行する計測・表示処理は cpu で実行します。負荷を開始する前に呼び出してください。
+// Linux 以外では常にエラーを返します。
+//
+// 引数:
+//
+//	cpu - 計測・表示処理に使用する CPU の番号
+func Reserve(cpu int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := sysinfo.ExcludeCPU(cpu); err != nil {
+		return err
+	}
+	reserved = cpu
+	return nil
+}
+
+// Sample は現時点までの使用量を返します。
+func Sample() Usage {
+	samples := []metrics.Sample{{Name: metricGC}, {Name: metricScavenge}, {Name: metricMemory}, {Name: metricHeapFree}, {Name: metricHeapRelease}}
+	metrics.Read(samples)
+	var u Usage
+	for _, s := range samples {
+		switch s.Name {
+		case metricGC, metricScavenge:
+			u.Runtime += time.Duration(s.Value.Float64() * float64(time.Second))
+		case metricMemory:
+			u.Memory += s.Value.Uint64()
+		case metricHeapFree, metricHeapRelease:
+			u.Memory -= s.Value.Uint64()
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	u.Bookkeeping, u.BookkeepingErr = finished, accountingErr
+	for tid, start := range threads {
+		if now, err := sysinfo.ThreadCPUTime(tid); err == nil {
+			u.Bookkeeping += now - start
+		}
+	}
+	return u
+}
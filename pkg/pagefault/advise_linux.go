@@ -0,0 +1,9 @@
+package pagefault
+
+import "syscall"
+
+// adviseRandom turns off read-ahead for mapping, so that each fault reads one
+// page instead of prefetching its neighbours into the cache.
+func adviseRandom(mapping []byte) {
+	syscall.Madvise(mapping, syscall.MADV_RANDOM)
+}
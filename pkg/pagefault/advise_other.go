@@ -0,0 +1,7 @@
+//go:build !linux && !windows
+
+package pagefault
+
+// adviseRandom does nothing where the syscall package has no madvise; faults
+// may then read ahead neighbouring pages.
+func adviseRandom(mapping []byte) {}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package pagefault
+
+import (
+	"os"
+	"syscall"
+)
+
+// Supported はこのプラットフォームでページフォールト負荷を生成できるかどうかです。
+const Supported = true
+
+// mapFile maps size bytes of file read-only and shared, so that every first
+// touch of an uncached page is a major fault served from the file.
+func mapFile(file *os.File, size int64) ([]byte, error) {
+	mapping, err := syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	adviseRandom(mapping)
+	return mapping, nil
+}
+
+func unmapFile(mapping []byte) error {
+	return syscall.Munmap(mapping)
+}
+
+// majorFaults returns the number of major page faults of the process so far.
+func majorFaults() (int64, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return int64(usage.Majflt), nil
+}
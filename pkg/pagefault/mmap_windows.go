@@ -0,0 +1,21 @@
+package pagefault
+
+import (
+	"fmt"
+	"os"
+)
+
+// Supported はこのプラットフォームでページフォールト負荷を生成できるかどうかです。
+const Supported = false
+
+func mapFile(file *os.File, size int64) ([]byte, error) {
+	return nil, fmt.Errorf("memory-mapped files are not supported on Windows")
+}
+
+func unmapFile(mapping []byte) error {
+	return nil
+}
+
+func majorFaults() (int64, error) {
+	return 0, fmt.Errorf("page fault counts are not supported on Windows")
+}
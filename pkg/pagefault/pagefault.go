@@ -0,0 +1,405 @@
+// Package pagefault はメモリより大きいファイルをメモリマップし、ランダムなページに触れ続けることで、
+// メジャーページフォールトとそれに伴うディスクからの読み戻しによる負荷を生成します。
+// メモリ負荷とストレージ負荷のどちらでも生じない、仮想メモリとディスクの間の負荷です。
+package pagefault
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+	"github.com/utkamioka/stress-go/pkg/tempdirs"
+)
+
+// sampleInterval is how often the achieved fault rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// writeChunk is the unit in which the backing file is written.
+const writeChunk = 1024 * 1024
+
+// Options はページフォールト負荷の設定です。Size と MemoryRatio のどちらか一方を指定します。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "PageFault" を使用します。
+	Name string
+	// Size はメモリマップするファイルのサイズ（バイト）です。
+	Size int64
+	// MemoryRatio は物理メモリ (cgroup のメモリ上限がある場合はその上限) に対するファイルのサイズの倍率です。
+	// 1 より大きくすると、ファイル全体がページキャッシュに収まらずメジャーページフォールトが続きます。
+	MemoryRatio float64
+	// Dir はファイルを作成するディレクトリです。空の場合はOSの一時ディレクトリを使用します。
+	Dir string
+	// Rate は目標のページフォールト数（1秒あたり）です。0 の場合は制限せずに可能な限り発生させます。
+	Rate float64
+	// Workers はページに触れるワーカーの数です。0 の場合は論理 CPU 数です。
+	// ワーカーはディスクからの読み込みを待つため、多いほど I/O の並列度が上がります。
+	Workers int
+	// Seed はファイルの内容と触れるページを決める乱数のシードです。0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// Budget は実行全体の負荷生成モジュールで共有するディスク容量の上限です (nil可)。
+	// ファイルを作成する前にそのサイズを予約し、上限に収まらない場合はファイルを小さくします。
+	Budget *budget.Budget
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if !Supported {
+		return fmt.Errorf("the page fault load is not supported on this platform")
+	}
+	if (o.Size > 0) == (o.MemoryRatio > 0) {
+		return fmt.Errorf("specify exactly one of the page fault file size and memory ratio")
+	}
+	if o.Size < 0 || o.MemoryRatio < 0 || o.Rate < 0 || o.Workers < 0 {
+		return fmt.Errorf("page fault size, ratio, rate and workers must not be negative")
+	}
+	return nil
+}
+
+// Result はページフォールト負荷の実行結果です。
+type Result struct {
+	// FileBytes はメモリマップしたファイルのサイズ（バイト）です。
+	FileBytes int64
+	// Touches はページに触れた回数です。
+	Touches int64
+	// MajorFaults は実行中にプロセスで発生したメジャーページフォールトの数です。
+	MajorFaults int64
+}
+
+// Stats は実行中のページフォールト負荷の状態です。
+type Stats struct {
+	// Target は現在の目標ページフォールト数（1秒あたり）です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定でのメジャーページフォールト数（1秒あたり）です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// Dir はファイルを書き込む一時ディレクトリです。作成前は空です。
+	Dir string
+}
+
+// Controller は実行中のページフォールト負荷を操作します。Start が返します。
+type Controller struct {
+	opts    Options
+	workers int
+	cancel  context.CancelFunc
+	done    chan struct{}
+	result  Result
+	err     error
+
+	touches  atomic.Int64
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	dir      atomic.Pointer[string] // the temporary directory once run creates it
+	achieved atomic.Uint64          // math.Float64bits of the last measured fault rate
+}
+
+// Start は opts に従ってページフォールト負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// ファイルの作成も含めてバックグラウンドで行います。負荷は ctx が終了するか Stop が呼ばれるまで続き、
+// 終了時にファイルを削除します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "PageFault"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+	if opts.MemoryRatio > 0 {
+		memory, err := memorySize()
+		if err != nil {
+			return nil, err
+		}
+		opts.Size = int64(float64(memory) * opts.MemoryRatio)
+	}
+	opts.Size -= opts.Size % int64(os.Getpagesize())
+	if opts.Size <= 0 {
+		return nil, fmt.Errorf("page fault file must be at least one page")
+	}
+
+	c := &Controller{
+		opts:    opts,
+		workers: opts.Workers,
+		done:    make(chan struct{}),
+	}
+	if c.workers == 0 {
+		c.workers = runtime.NumCPU()
+	}
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c, nil
+}
+
+// memorySize returns the memory a file must exceed to stay out of the page
+// cache: the physical memory, or the cgroup limit that the cache is charged to.
+func memorySize() (int64, error) {
+	snapshot, err := sysinfo.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the memory size: %v", err)
+	}
+	size := snapshot.MemoryTotal
+	if cg, err := sysinfo.ReadCgroup(); err == nil && cg.MemoryLimit > 0 {
+		size = min(size, cg.MemoryLimit)
+	}
+	return size, nil
+}
+
+// run creates and maps the backing file, then touches its pages until ctx is done.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("PageFault", "Load generation completed")
+
+	dir, err := os.MkdirTemp(c.opts.Dir, "stress-go-pagefault-")
+	if err != nil {
+		c.err = fmt.Errorf("failed to create temporary directory: %v", err)
+		return
+	}
+	c.dir.Store(&dir)
+	tempdirs.Track(dir)
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			recorder.Logf("PageFault", "Failed to remove %s: %v", dir, err)
+		}
+	}()
+	path := filepath.Join(dir, "pagefault.dat")
+
+	if memory, err := memorySize(); err == nil && c.opts.Size <= memory {
+		recorder.Logf("PageFault", "The %d MiB file fits in the %d MiB of memory; once it is cached, few major faults occur",
+			c.opts.Size/(1024*1024), memory/(1024*1024))
+	}
+	if space, err := sysinfo.ReadDiskSpace(dir); err == nil && space.Available < c.opts.Size {
+		c.err = fmt.Errorf("insufficient disk space for the %d MiB page fault file: %d MiB free in %s",
+			c.opts.Size/(1024*1024), space.Available/(1024*1024), dir)
+		return
+	}
+	// The file counts against the disk space shared with the other stressors
+	if granted := c.opts.Budget.Reserve(c.opts.Size); granted < c.opts.Size {
+		limit := c.opts.Budget.Limit()
+		recorder.Flag("PageFault", i18n.Sprintf("file capped by hard disk limit (%d MiB)", limit/(1024*1024)))
+		size := granted - granted%int64(os.Getpagesize())
+		c.opts.Budget.Release(granted - size)
+		if size == 0 {
+			c.err = fmt.Errorf("no room for the page fault file within the hard disk limit of %d MiB", limit/(1024*1024))
+			return
+		}
+		recorder.Logf("PageFault", "File capped from %d MiB to %d MiB by the disk limit", c.opts.Size/(1024*1024), size/(1024*1024))
+		c.opts.Size = size
+	}
+	defer c.opts.Budget.Release(c.opts.Size)
+
+	recorder.Logf("PageFault", "Writing a %d MiB file to map in %s", c.opts.Size/(1024*1024), dir)
+	start := time.Now()
+	if err := c.writeFile(ctx, path); err != nil {
+		if ctx.Err() == nil {
+			c.err = err
+		}
+		return
+	}
+	recorder.Logf("PageFault", "File written in %v", time.Since(start).Truncate(time.Second))
+	c.result.FileBytes = c.opts.Size
+
+	file, err := os.Open(path)
+	if err != nil {
+		c.err = fmt.Errorf("failed to open the page fault file: %v", err)
+		return
+	}
+	defer file.Close()
+	mapping, err := mapFile(file, c.opts.Size)
+	if err != nil {
+		c.err = fmt.Errorf("failed to map the page fault file: %v", err)
+		return
+	}
+	defer unmapFile(mapping)
+
+	if c.opts.Rate > 0 {
+		recorder.Logf("PageFault", "Touching random pages with %d workers at %.0f faults/s", c.workers, c.opts.Rate)
+	} else {
+		recorder.Logf("PageFault", "Touching random pages with %d workers", c.workers)
+	}
+	faultsBefore, _ := majorFaults()
+	var wg sync.WaitGroup
+	for i := range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.touch(ctx, mapping, i)
+		}()
+	}
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	lastFaults, lastTime := faultsBefore, time.Now()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			faults, err := majorFaults()
+			if err != nil {
+				recorder.Logf("PageFault", "Cannot read the page fault count: %v", err)
+				continue
+			}
+			rate := float64(faults-lastFaults) / now.Sub(lastTime).Seconds()
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.AddCount("PageFault", "major_faults", faults-lastFaults)
+			recorder.Record("PageFault", metrics.UnitFaultsPerSecond, c.targetRate(), rate)
+			lastFaults, lastTime = faults, now
+		}
+	}
+	wg.Wait()
+
+	c.result.Touches = c.touches.Load()
+	recorder.AddCount("PageFault", "touches", c.result.Touches)
+	if faults, err := majorFaults(); err == nil {
+		c.result.MajorFaults = faults - faultsBefore
+	}
+}
+
+// writeFile fills the file at path with random data, so that neither sparse
+// files nor compression let pages be mapped without reading the disk.
+func (c *Controller) writeFile(ctx context.Context, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create the page fault file: %v", err)
+	}
+	defer file.Close()
+
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], c.opts.Seed)
+	copy(key[8:], "stress-go pagefault")
+	data := rand.NewChaCha8(key)
+	buffer := make([]byte, writeChunk)
+	for written := int64(0); written < c.opts.Size; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := min(int64(len(buffer)), c.opts.Size-written)
+		data.Read(buffer[:n])
+		if _, err := file.Write(buffer[:n]); err != nil {
+			return fmt.Errorf("failed to write the page fault file: %v", err)
+		}
+		written += n
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync the page fault file: %v", err)
+	}
+	return nil
+}
+
+// touch reads one byte of a random page of mapping at a time until ctx is done,
+// pacing itself to its share of the target rate.
+func (c *Controller) touch(ctx context.Context, mapping []byte, worker int) {
+	pageSize := int64(os.Getpagesize())
+	pages := int64(len(mapping)) / pageSize
+	rng := rand.New(rand.NewPCG(c.opts.Seed, uint64(worker)))
+	var sum byte
+	next := time.Now()
+	for ctx.Err() == nil {
+		if c.paused.Load() || worker >= c.activeWorkers() {
+			time.Sleep(timing.IdleInterval)
+			next = time.Now()
+			continue
+		}
+		if rate := c.targetRate(); rate > 0 {
+			next = next.Add(time.Duration(float64(time.Second) * float64(c.workers) / rate))
+			if wait := time.Until(next); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+		sum += mapping[rng.Int64N(pages)*pageSize]
+		c.touches.Add(1)
+	}
+	sink.Add(uint64(sum))
+}
+
+// sink keeps the bytes read from the mapping alive so that the reads are not optimized away.
+var sink atomic.Uint64
+
+// targetRate returns the current fault rate target, or 0 for as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return c.opts.Rate * math.Float64frombits(c.scale.Load())
+}
+
+// activeWorkers returns how many workers touch pages. With a target rate every
+// worker runs at its share of it; without one the scale sets the worker count.
+func (c *Controller) activeWorkers() int {
+	scale := math.Float64frombits(c.scale.Load())
+	if c.opts.Rate > 0 {
+		if scale > 0 {
+			return c.workers
+		}
+		return 0
+	}
+	return min(int(math.Ceil(float64(c.workers)*scale)), c.workers)
+}
+
+// SetScale は Options で指定した目標ページフォールト数に掛ける係数を変更します (1.0 で指定どおり)。
+// 目標を指定していない場合は、ページに触れるワーカーの数に掛けます。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまでページに触れるのを止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了してファイルを削除するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, c.err
+}
+
+// workDir returns the temporary directory, or "" before run creates it.
+func (c *Controller) workDir() string {
+	if dir := c.dir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:   c.targetRate(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Paused:   c.paused.Load(),
+		Dir:      c.workDir(),
+	}
+}
@@ -0,0 +1,15 @@
+package pagefault
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "pagefault",
+		Name:        "mmap",
+		Usage:       "--pagefault <size>",
+		Description: "Random reads of a memory-mapped file larger than memory, served by major page faults",
+		Available:   Supported,
+		Requirement: "a Unix-like OS",
+	})
+}
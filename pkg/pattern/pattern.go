@@ -0,0 +1,86 @@
+// Package pattern は時間とともに負荷レベルを変える負荷パターンを提供します。
+package pattern
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pattern は負荷レベルの段階 (ステップ) の並びです。各ステップのレベルを Hold の間維持し、
+// 最後のステップの後は最後のレベルを維持します。
+type Pattern struct {
+	// Levels は各ステップの負荷レベルです。設定された目標値に対する倍率 (1.0 で設定どおり) です。
+	Levels []float64
+	// Hold は1つのステップを維持する時間です。
+	Hold time.Duration
+}
+
+// Parse は "steps:levels=20,40,60,80;hold=2m" 形式の負荷パターンを解析します。
+// levels は設定された目標値に対するパーセンテージです。
+//
+// 引数:
+//
+//	spec - 負荷パターンの指定
+func Parse(spec string) (*Pattern, error) {
+	kind, params, _ := strings.Cut(spec, ":")
+	if kind != "steps" {
+		return nil, fmt.Errorf("unknown pattern %q (expected steps:levels=...;hold=...)", kind)
+	}
+
+	p := &Pattern{}
+	for _, param := range strings.Split(params, ";") {
+		if param = strings.TrimSpace(param); param == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pattern parameter %q (expected key=value)", param)
+		}
+		switch key {
+		case "levels":
+			for _, field := range strings.Split(value, ",") {
+				percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(field), "%"), 64)
+				if err != nil || percent < 0 || percent > 100 {
+					return nil, fmt.Errorf("invalid pattern level %q (expected 0-100)", field)
+				}
+				p.Levels = append(p.Levels, percent/100)
+			}
+		case "hold":
+			hold, err := time.ParseDuration(value)
+			if err != nil || hold <= 0 {
+				return nil, fmt.Errorf("invalid pattern hold time %q", value)
+			}
+			p.Hold = hold
+		default:
+			return nil, fmt.Errorf("unknown pattern parameter %q", key)
+		}
+	}
+	if len(p.Levels) == 0 {
+		return nil, fmt.Errorf("pattern levels are required (e.g., levels=20,40,60,80)")
+	}
+	if p.Hold == 0 {
+		return nil, fmt.Errorf("pattern hold time is required (e.g., hold=2m)")
+	}
+	return p, nil
+}
+
+// Step は経過時間 elapsed におけるステップの番号 (0始まり) を返します。
+func (p *Pattern) Step(elapsed time.Duration) int {
+	return min(int(elapsed/p.Hold), len(p.Levels)-1)
+}
+
+// Duration はすべてのステップを一巡する時間です。
+func (p *Pattern) Duration() time.Duration {
+	return p.Hold * time.Duration(len(p.Levels))
+}
+
+// String はパターンを "steps 20%,40%,60%,80% every 2m0s" の形式で返します。
+func (p *Pattern) String() string {
+	levels := make([]string, len(p.Levels))
+	for i, level := range p.Levels {
+		levels[i] = strconv.FormatFloat(level*100, 'f', -1, 64) + "%"
+	}
+	return fmt.Sprintf("steps %s every %v", strings.Join(levels, ","), p.Hold)
+}
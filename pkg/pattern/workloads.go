@@ -0,0 +1,14 @@
+package pattern
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Patterns,
+		Domain:      "cpu, memory",
+		Name:        "steps",
+		Usage:       "--pattern 'steps:levels=20,40,60,80;hold=2m'",
+		Description: "Step the CPU and memory load through levels, holding each for a fixed time",
+		Available:   true,
+	})
+}
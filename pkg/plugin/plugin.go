@@ -0,0 +1,258 @@
+// Package plugin は外部コマンドとして実装された負荷生成モジュール (プラグイン) を
+// Stressor として実行します。
+//
+// プラグインは標準入出力で JSON Lines (1行に1つの JSON オブジェクト) をやり取りします。
+// stress-go は負荷の開始時に {"type":"start"} を、停止時に {"type":"stop"} を標準入力に
+// 書き込み、標準入力を閉じます。プラグインは停止の指示から StopGrace 以内に終了する必要が
+// あり、超過すると強制終了されます。プラグインは標準出力に次のメッセージを書き込めます。
+//
+//	{"type":"log","text":"..."}                                   進行状況のメッセージ
+//	{"type":"sample","unit":"ops","target":100,"achieved":95}     目標値と実測値
+//	{"type":"latency","operation":"write","seconds":0.002}        1回の操作の所要時間
+//	{"type":"issue","text":"..."}                                 負荷が妨げられた理由
+//	{"type":"error","text":"..."}                                 致命的なエラー
+//
+// 標準エラー出力の各行は進行状況のメッセージとして扱われます。
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// StopGrace はプラグインに停止を指示してから強制終了するまでの猶予時間です。
+const StopGrace = 10 * time.Second
+
+// Options はプラグインの設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用するプラグインの名前です。
+	Name string
+	// Command は実行するコマンドと引数です。
+	Command []string
+	// Seed は環境変数 STRESS_GO_SEED としてプラグインに渡す乱数のシードです。0 の場合は渡しません。
+	Seed uint64
+	// Recorder はプラグインが報告したメッセージと指標の記録先です。
+	Recorder *metrics.Recorder
+}
+
+// ParseSpec は "名前=コマンド 引数..." 形式の指定を Options に変換します。
+// 引数は空白で区切られます。
+//
+// 引数:
+//
+//	spec - プラグインの指定 (例: "gpu=/opt/exerciser --level 3")
+func ParseSpec(spec string) (Options, error) {
+	name, command, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return Options{}, fmt.Errorf("invalid plugin %q: expected name=command", spec)
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return Options{}, fmt.Errorf("invalid plugin %q: missing command", spec)
+	}
+	return Options{Name: name, Command: fields}, nil
+}
+
+// event is one line written by the plugin to its standard output.
+type event struct {
+	Type      string  `json:"type"`
+	Text      string  `json:"text"`
+	Unit      string  `json:"unit"`
+	Target    float64 `json:"target"`
+	Achieved  float64 `json:"achieved"`
+	Operation string  `json:"operation"`
+	Seconds   float64 `json:"seconds"`
+}
+
+// command is one line written to the plugin's standard input.
+type command struct {
+	Type string `json:"type"`
+}
+
+// Stressor はプラグインのプロセスを実行する stressor.Stressor です。New で作成します。
+type Stressor struct {
+	opts Options
+
+	mu      sync.Mutex
+	stats   stressor.Stats
+	process *os.Process // set while the plugin runs
+}
+
+// New は opts に従ってプラグインを実行する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - プラグインの設定
+func New(opts Options) *Stressor {
+	return &Stressor{opts: opts}
+}
+
+// Name はプラグインの名前を返します。
+func (s *Stressor) Name() string {
+	return s.opts.Name
+}
+
+// Init はプラグインのコマンドが実行可能であることを確認します。
+func (s *Stressor) Init() error {
+	if s.opts.Name == "" || len(s.opts.Command) == 0 {
+		return fmt.Errorf("plugin name and command must be specified")
+	}
+	if _, err := exec.LookPath(s.opts.Command[0]); err != nil {
+		return fmt.Errorf("plugin command not found: %v", err)
+	}
+	return nil
+}
+
+// Run はプラグインを起動し、ctx が終了したら停止を指示して終了を待ちます。
+func (s *Stressor) Run(ctx context.Context) error {
+	recorder := s.opts.Recorder
+	cmd := exec.Command(s.opts.Command[0], s.opts.Command[1:]...)
+	cmd.Env = append(os.Environ(), "STRESS_GO_PLUGIN_NAME="+s.opts.Name)
+	if s.opts.Seed != 0 {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("STRESS_GO_SEED=%d", s.opts.Seed))
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin: %v", err)
+	}
+	s.setProcess(cmd.Process)
+	recorder.Logf(s.opts.Name, "Started plugin (pid %d)", cmd.Process.Pid)
+
+	encoder := json.NewEncoder(stdin)
+	encoder.Encode(command{Type: "start"})
+
+	// Ask the plugin to stop when the run ends, and kill it if it does not
+	exited := make(chan struct{})
+	var killed bool
+	var stopper sync.WaitGroup
+	stopper.Add(1)
+	go func() {
+		defer stopper.Done()
+		select {
+		case <-exited:
+			return
+		case <-ctx.Done():
+		}
+		encoder.Encode(command{Type: "stop"})
+		stdin.Close()
+		select {
+		case <-exited:
+		case <-time.After(StopGrace):
+			killed = true
+			cmd.Process.Kill()
+		}
+	}()
+
+	var logs sync.WaitGroup
+	logs.Add(1)
+	go func() {
+		defer logs.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			recorder.Logf(s.opts.Name, "%s", scanner.Text())
+		}
+	}()
+
+	fatal := s.readEvents(stdout)
+	logs.Wait()
+	waitErr := cmd.Wait()
+	s.setProcess(nil)
+	close(exited)
+	stopper.Wait()
+
+	switch {
+	case fatal != nil:
+		return fatal
+	case killed:
+		return fmt.Errorf("plugin did not stop within %v and was killed", StopGrace)
+	case waitErr != nil && ctx.Err() == nil:
+		return fmt.Errorf("plugin exited: %v", waitErr)
+	}
+	recorder.Logf(s.opts.Name, "Plugin finished")
+	return nil
+}
+
+// readEvents processes the plugin's output until it closes its standard output
+// and returns the fatal error it reported, if any.
+func (s *Stressor) readEvents(r io.Reader) error {
+	recorder := s.opts.Recorder
+	var fatal error
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal(line, &e); err != nil {
+			recorder.Logf(s.opts.Name, "Ignoring malformed plugin output: %s", line)
+			continue
+		}
+		switch e.Type {
+		case "log":
+			recorder.Logf(s.opts.Name, "%s", e.Text)
+		case "sample":
+			recorder.Record(s.opts.Name, e.Unit, e.Target, e.Achieved)
+			s.mu.Lock()
+			s.stats = stressor.Stats{Unit: e.Unit, Target: e.Target, Achieved: e.Achieved}
+			s.mu.Unlock()
+		case "latency":
+			recorder.ObserveLatency(s.opts.Name, e.Operation, time.Duration(e.Seconds*float64(time.Second)))
+		case "issue":
+			recorder.Flag(s.opts.Name, e.Text)
+		case "error":
+			fatal = errors.New(e.Text)
+		default:
+			recorder.Logf(s.opts.Name, "Ignoring unknown plugin message type %q", e.Type)
+		}
+	}
+	return fatal
+}
+
+// Stats はプラグインが最後に報告した目標値と実測値を返します。
+func (s *Stressor) Stats() stressor.Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Cleanup はプラグインのプロセスが残っている場合に強制終了します。
+func (s *Stressor) Cleanup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.process != nil {
+		return s.process.Kill()
+	}
+	return nil
+}
+
+// setProcess records the running plugin process for Cleanup.
+func (s *Stressor) setProcess(p *os.Process) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.process = p
+}
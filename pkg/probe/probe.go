@@ -0,0 +1,230 @@
+// Package probe は負荷と並行して動かし、他のプロセスから見た遅延を計測するカナリアプローブを提供します。
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Name はプローブのレイテンシを記録するときの負荷生成モジュール名です。
+const Name = "Probe"
+
+// プローブの種類です。
+const (
+	// Wakeup は短いスリープから起床するまでの遅延 (要求した時間を超えた分) を計測します。
+	Wakeup = "wakeup"
+	// Alloc は 4KiB のメモリを確保して書き込むまでの時間を計測します。
+	Alloc = "alloc"
+	// Pread は一時ファイルの任意の位置から 4KiB を読み取る時間を計測します。
+	Pread = "pread"
+	// RTT はループバックの TCP 接続で1バイトを往復させる時間を計測します。
+	RTT = "rtt"
+)
+
+// Kinds はすべてのプローブの種類です。
+var Kinds = []string{Wakeup, Alloc, Pread, RTT}
+
+// Valid は kind がプローブの種類として有効かどうかを返します。
+func Valid(kind string) bool {
+	return slices.Contains(Kinds, kind)
+}
+
+const (
+	// wakeupSleep is short enough that any extra delay is the scheduler's.
+	wakeupSleep = 100 * time.Microsecond
+	// probeSize is the size of the allocations and reads.
+	probeSize = 4096
+	// preadFileSize is the size of the file the pread probe reads from.
+	preadFileSize = 1024 * 1024
+)
+
+// sink keeps the allocations of the alloc probe from being optimized away.
+var sink []byte
+
+// Options はプローブの設定です。
+type Options struct {
+	// Interval は各プローブを実行する間隔です。
+	Interval time.Duration
+	// Kinds は実行するプローブの種類です。空の場合はすべて実行します。
+	Kinds []string
+	// Seed は pread プローブのファイルの内容と読み取る位置を決める乱数のシードです。
+	// 0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// Recorder はレイテンシとメッセージの記録先です。
+	Recorder *metrics.Recorder
+}
+
+// op is a single probe operation, which returns the latency it observed.
+type op func() (time.Duration, error)
+
+// Run は ctx が終了するまで各プローブを Interval ごとに実行し、レイテンシを Recorder に記録します。
+// 準備できないプローブや実行中に失敗したプローブは、メッセージを記録して停止します。
+//
+// 引数:
+//
+//	ctx  - プローブの制御に使用するコンテキスト
+//	opts - プローブの設定
+func Run(ctx context.Context, opts Options) {
+	kinds := opts.Kinds
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+
+	var wg sync.WaitGroup
+	for _, kind := range kinds {
+		probe, cleanup, err := prepare(kind, opts.Seed)
+		if err != nil {
+			opts.Recorder.Logf(Name, "Cannot start the %s probe: %v", kind, err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cleanup()
+			run(ctx, kind, probe, opts)
+		}()
+	}
+	wg.Wait()
+}
+
+// run repeats probe every interval until ctx is done or the probe fails.
+func run(ctx context.Context, kind string, probe op, opts Options) {
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d, err := probe()
+			if err != nil {
+				if ctx.Err() == nil {
+					opts.Recorder.Logf(Name, "The %s probe stopped: %v", kind, err)
+				}
+				return
+			}
+			opts.Recorder.ObserveLatency(Name, kind, d)
+		}
+	}
+}
+
+// prepare sets up the probe of kind and returns it with a function that
+// releases what it set up. seed drives the random choices of the probe.
+func prepare(kind string, seed uint64) (op, func(), error) {
+	switch kind {
+	case Wakeup:
+		return wakeup, func() {}, nil
+	case Alloc:
+		return alloc, func() {}, nil
+	case Pread:
+		return preparePread(seed)
+	case RTT:
+		return prepareRTT()
+	default:
+		return nil, nil, fmt.Errorf("unknown probe %q", kind)
+	}
+}
+
+// wakeup measures how late a short sleep wakes up.
+func wakeup() (time.Duration, error) {
+	start := time.Now()
+	time.Sleep(wakeupSleep)
+	return max(time.Since(start)-wakeupSleep, 0), nil
+}
+
+// alloc measures allocating and touching a small buffer.
+func alloc() (time.Duration, error) {
+	start := time.Now()
+	buf := make([]byte, probeSize)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	sink = buf
+	return time.Since(start), nil
+}
+
+// preparePread writes the file the pread probe reads from, with the contents and
+// the read offsets generated from seed.
+func preparePread(seed uint64) (op, func(), error) {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	copy(key[8:], "stress-go pread")
+	src := rand.NewChaCha8(key)
+	rng := rand.New(src)
+
+	f, err := os.CreateTemp("", "stress-go-probe-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create probe file: %v", err)
+	}
+	cleanup := func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if _, err := io.CopyN(f, src, preadFileSize); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write probe file: %v", err)
+	}
+
+	buf := make([]byte, probeSize)
+	probe := func() (time.Duration, error) {
+		offset := int64(rng.IntN(preadFileSize/probeSize)) * probeSize
+		start := time.Now()
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	}
+	return probe, cleanup, nil
+}
+
+// prepareRTT connects to an echo server on the loopback interface.
+func prepareRTT() (op, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen on loopback: %v", err)
+	}
+	go func() {
+		server, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer server.Close()
+		io.Copy(server, server)
+	}()
+	client, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		listener.Close()
+		return nil, nil, fmt.Errorf("failed to connect on loopback: %v", err)
+	}
+	cleanup := func() {
+		client.Close()
+		listener.Close()
+	}
+
+	buf := []byte{0}
+	probe := func() (time.Duration, error) {
+		start := time.Now()
+		if _, err := client.Write(buf); err != nil {
+			return 0, err
+		}
+		if _, err := io.ReadFull(client, buf); err != nil {
+			return 0, err
+		}
+		return time.Since(start), nil
+	}
+	return probe, cleanup, nil
+}
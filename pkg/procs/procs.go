@@ -0,0 +1,357 @@
+// Package procs は短命な子プロセスの生成と回収を繰り返すことで、スケジューラー・
+// PID テーブル・プロセスアカウンティングに負荷をかけます。子プロセスを一定時間
+// 待機させることで、同時に存在するプロセスの数を保つこともできます。
+package procs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// sampleInterval is how often the achieved rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// retryInterval is how long the spawner waits before spawning again after an error.
+const retryInterval = 100 * time.Millisecond
+
+// killDelay is how long a child may take to exit after its stdin is closed at
+// the end of the load before it is killed.
+const killDelay = time.Second
+
+// maxLag is how far the spawner may fall behind its schedule, for example
+// while all the slots are taken, before it gives up catching up.
+const maxLag = time.Second
+
+// defaultMax is the number of children alive at once unless Options.Max is set.
+const defaultMax = 64
+
+// childEnv marks a process started by Start without Options.Command as a child,
+// holding how long it lives.
+const childEnv = "STRESS_GO_PROCS_CHILD"
+
+// Options はプロセス負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Procs" を使用します。
+	Name string
+	// Max は同時に存在する子プロセスの最大数です。0 の場合は 64 です。
+	Max int
+	// Rate は1秒あたりに生成する子プロセスの数です。0 の場合は制限せずに可能な限り生成します。
+	Rate float64
+	// Lifetime は子プロセスが終了するまでの時間です。0 の場合は起動してすぐに終了します。
+	Lifetime time.Duration
+	// Command は子プロセスとして実行するコマンドと引数です。空の場合は実行中のプログラム自身を
+	// 子プロセスとして起動します。この場合、プログラムは main の最初で RunChild を呼び出す必要があります。
+	Command []string
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if o.Max < 0 || o.Rate < 0 || o.Lifetime < 0 {
+		return fmt.Errorf("procs count, rate and lifetime must not be negative")
+	}
+	return nil
+}
+
+// Result はプロセス負荷の実行結果です。
+type Result struct {
+	// Spawned は生成した子プロセスの数です。
+	Spawned int64
+	// Failed は生成に失敗した回数です。
+	Failed int64
+	// Peak は同時に存在した子プロセスの最大数です。
+	Peak int64
+}
+
+// Stats は実行中のプロセス負荷の状態です。
+type Stats struct {
+	// Target は現在の目標値 (1秒あたりの生成数) です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定での実測値です。
+	Achieved float64
+	// Running は現在存在する子プロセスの数です。
+	Running int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// Controller は実行中のプロセス負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+	// lastError is the last error logged, so that the spawner retrying against
+	// the same failure does not repeat it every retryInterval
+	mu        sync.Mutex
+	lastError string
+
+	spawned  atomic.Int64
+	failed   atomic.Int64
+	running  atomic.Int64
+	peak     atomic.Int64
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	achieved atomic.Uint64 // math.Float64bits of the last measured rate
+}
+
+// RunChild は Options.Command を指定せずに Start が起動した子プロセスとして実行されている場合に、
+// Options.Lifetime だけ待機してプロセスを終了します。子プロセスでない場合は何もせずに戻ります。
+// 待機中に親プロセスが標準入力を閉じた場合は、すぐに終了します。
+func RunChild() {
+	value, ok := os.LookupEnv(childEnv)
+	if !ok {
+		return
+	}
+	if lifetime, _ := time.ParseDuration(value); lifetime > 0 {
+		go func() {
+			// The parent closes stdin when the load ends, and so does its death
+			io.Copy(io.Discard, os.Stdin)
+			os.Exit(0)
+		}()
+		time.Sleep(lifetime)
+	}
+	os.Exit(0)
+}
+
+// Start は opts に従ってプロセス負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続き、終了時に子プロセスをすべて終了させて回収します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Procs"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	if opts.Max == 0 {
+		opts.Max = defaultMax
+	}
+	if len(opts.Command) == 0 {
+		self, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the executable for the children: %v", err)
+		}
+		opts.Command = []string{self}
+	}
+
+	c := &Controller{opts: opts, done: make(chan struct{})}
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c, nil
+}
+
+// run starts the spawner and records the achieved rate until ctx is done.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("Procs", "Load generation completed")
+	if c.opts.Lifetime > 0 {
+		recorder.Logf("Procs", "Spawning up to %d child processes that live for %v", c.opts.Max, c.opts.Lifetime)
+	} else {
+		recorder.Logf("Procs", "Spawning up to %d short-lived child processes", c.opts.Max)
+	}
+
+	spawner := make(chan struct{})
+	go func() {
+		defer close(spawner)
+		c.spawn(ctx)
+	}()
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	last, lastTime := c.spawned.Load(), time.Now()
+	var counted int64
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			spawned := c.spawned.Load()
+			rate := float64(spawned-last) / now.Sub(lastTime).Seconds()
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.Record("Procs", metrics.UnitProcsPerSecond, c.targetRate(), rate)
+			last, lastTime = spawned, now
+			counted = c.count(counted)
+		}
+	}
+	<-spawner
+	c.count(counted)
+
+	c.result = Result{Spawned: c.spawned.Load(), Failed: c.failed.Load(), Peak: c.peak.Load()}
+	recorder.Logf("Procs", "Spawned %d processes, at most %d at once, with %d failures",
+		c.result.Spawned, c.result.Peak, c.result.Failed)
+}
+
+// count adds the processes spawned since the last call to the counts of the
+// recorder, so that they stay current for the metrics endpoint while the load
+// runs, and returns the new total.
+func (c *Controller) count(counted int64) int64 {
+	spawned := c.spawned.Load()
+	c.opts.Recorder.AddCount("Procs", "spawned", spawned-counted)
+	return spawned
+}
+
+// spawn starts children at the target rate while fewer than Options.Max are
+// alive, until ctx is done, and then waits for every child to be reaped.
+func (c *Controller) spawn(ctx context.Context) {
+	var children sync.WaitGroup
+	defer children.Wait()
+	slots := make(chan struct{}, c.opts.Max)
+	next := time.Now()
+	for ctx.Err() == nil {
+		if !c.pace(ctx, &next) {
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		cmd, err := c.start(ctx)
+		if err != nil {
+			<-slots
+			c.fail(ctx, err)
+			continue
+		}
+		c.spawned.Add(1)
+		running := c.running.Add(1)
+		for peak := c.peak.Load(); running > peak; peak = c.peak.Load() {
+			if c.peak.CompareAndSwap(peak, running) {
+				break
+			}
+		}
+		children.Add(1)
+		go func() {
+			defer children.Done()
+			// A child killed at the end of the load is not a failure
+			cmd.Wait()
+			c.running.Add(-1)
+			<-slots
+		}()
+	}
+}
+
+// start starts one child. When ctx is done its stdin is closed, which ends a
+// child running RunChild, and it is killed if it is still running killDelay later.
+func (c *Controller) start(ctx context.Context) (*exec.Cmd, error) {
+	cmd := exec.CommandContext(ctx, c.opts.Command[0], c.opts.Command[1:]...)
+	cmd.Env = append(os.Environ(), childEnv+"="+c.opts.Lifetime.String())
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Cancel = stdin.Close
+	cmd.WaitDelay = killDelay
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+// fail counts an error and logs it unless it repeats the last one, then waits
+// retryInterval before the spawner tries again. Errors caused by the end of
+// the load are ignored.
+func (c *Controller) fail(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	c.failed.Add(1)
+	c.opts.Recorder.AddCount("Procs", "errors", 1)
+	message := err.Error()
+	c.mu.Lock()
+	repeated := message == c.lastError
+	c.lastError = message
+	c.mu.Unlock()
+	if !repeated {
+		if errors.Is(err, syscall.EAGAIN) {
+			c.opts.Recorder.Logf("Procs", "Process limit reached at %d children: %v", c.running.Load(), err)
+		} else {
+			c.opts.Recorder.Logf("Procs", "Spawn error: %v", err)
+		}
+	}
+	timing.Sleep(ctx, retryInterval)
+}
+
+// pace waits until the next child is due at the target rate, and reports false
+// when the spawner should check ctx and its pause state again instead of spawning.
+func (c *Controller) pace(ctx context.Context, next *time.Time) bool {
+	if c.paused.Load() || c.scale.Load() == 0 {
+		timing.Sleep(ctx, timing.IdleInterval)
+		*next = time.Now()
+		return false
+	}
+	rate := c.targetRate()
+	if rate <= 0 {
+		return true
+	}
+	*next = next.Add(time.Duration(float64(time.Second) / rate))
+	if lag := time.Since(*next); lag > maxLag {
+		*next = time.Now()
+	}
+	return timing.Sleep(ctx, time.Until(*next))
+}
+
+// targetRate returns the current target in spawns per second, or 0 for as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return c.opts.Rate * math.Float64frombits(c.scale.Load())
+}
+
+// SetScale は Options で指定した生成の速さに掛ける係数を変更します (1.0 で指定どおり)。
+// 速さを指定していない場合は、0 で停止し、それ以外では可能な限り生成します。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで子プロセスの生成を止めます。存在する子プロセスはそのままにします。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了して子プロセスをすべて回収するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, nil
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:   c.targetRate(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Running:  c.running.Load(),
+		Paused:   c.paused.Load(),
+	}
+}
@@ -0,0 +1,22 @@
+package procs
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "procs",
+		Name:        "spawn",
+		Usage:       "--procs N",
+		Description: "Spawning and reaping short-lived child processes to load the scheduler and PID table",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "procs",
+		Name:        "sleepers",
+		Usage:       "--procs N --procs-lifetime 10s",
+		Description: "Keeping up to N sleeping child processes alive to fill the process table",
+		Available:   true,
+	})
+}
@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Point は記録開始からの経過時間におけるホストのリソース使用状況です。
+type Point struct {
+	Offset time.Duration `json:"offset"`
+	// CPU is the system-wide CPU utilization in the range 0.0-1.0.
+	CPU float64 `json:"cpu"`
+	// Memory and Disk are the bytes in use on the host and the recorded filesystem.
+	Memory int64 `json:"memory"`
+	Disk   int64 `json:"disk"`
+}
+
+// Profile は実ホストで記録したリソース使用状況の時系列です。
+type Profile struct {
+	Host     string        `json:"host"`
+	Recorded time.Time     `json:"recorded"`
+	Interval time.Duration `json:"interval"`
+	DiskPath string        `json:"diskPath"`
+	Points   []Point       `json:"points"`
+}
+
+// Record は ctx が終了するまで interval ごとにホストのCPU・メモリ・ディスク使用状況を記録します。
+// diskPath はディスク使用量を記録するファイルシステム上のパスです。
+func Record(ctx context.Context, interval time.Duration, diskPath string) (*Profile, error) {
+	host, _ := os.Hostname()
+	p := &Profile{
+		Host:     host,
+		Recorded: time.Now(),
+		Interval: interval,
+		DiskPath: diskPath,
+	}
+
+	prevCPU, err := sysinfo.ReadCPUTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return p, nil
+		case <-ticker.C:
+			curCPU, err := sysinfo.ReadCPUTimes()
+			if err != nil {
+				return p, err
+			}
+			snapshot, err := sysinfo.Read()
+			if err != nil {
+				return p, err
+			}
+			disk, err := sysinfo.ReadDiskSpace(diskPath)
+			if err != nil {
+				return p, err
+			}
+
+			p.Points = append(p.Points, Point{
+				Offset: time.Since(p.Recorded),
+				CPU:    sysinfo.Utilization(prevCPU, curCPU),
+				Memory: snapshot.MemoryTotal - snapshot.MemoryAvailable,
+				Disk:   disk.Used(),
+			})
+			prevCPU = curCPU
+		}
+	}
+}
+
+// Load はプロファイルファイルを読み込みます。
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %v", err)
+	}
+
+	var p Profile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %v", path, err)
+	}
+	if len(p.Points) == 0 {
+		return nil, fmt.Errorf("profile %s contains no samples", path)
+	}
+	return &p, nil
+}
+
+// Save はプロファイルを JSON ファイルとして書き出します。
+func (p *Profile) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode profile: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	return nil
+}
+
+// Duration returns the time span covered by the profile.
+func (p *Profile) Duration() time.Duration {
+	if len(p.Points) == 0 {
+		return 0
+	}
+	return p.Points[len(p.Points)-1].Offset
+}
+
+// At は経過時間 offset における使用状況を前後の記録点から線形補間して返します。
+func (p *Profile) At(offset time.Duration) Point {
+	points := p.Points
+	if offset <= points[0].Offset {
+		return points[0]
+	}
+	for i := 1; i < len(points); i++ {
+		if offset > points[i].Offset {
+			continue
+		}
+		prev, next := points[i-1], points[i]
+		ratio := float64(offset-prev.Offset) / float64(next.Offset-prev.Offset)
+		return Point{
+			Offset: offset,
+			CPU:    prev.CPU + (next.CPU-prev.CPU)*ratio,
+			Memory: prev.Memory + int64(float64(next.Memory-prev.Memory)*ratio),
+			Disk:   prev.Disk + int64(float64(next.Disk-prev.Disk)*ratio),
+		}
+	}
+	return points[len(points)-1]
+}
+
+// Baseline は記録中のメモリ・ディスク使用量の最小値を返します。
+// 再生時はこの値を超えた分を負荷として再現します。
+func (p *Profile) Baseline() (memory, disk int64) {
+	memory, disk = p.Points[0].Memory, p.Points[0].Disk
+	for _, point := range p.Points[1:] {
+		memory = min(memory, point.Memory)
+		disk = min(disk, point.Disk)
+	}
+	return memory, disk
+}
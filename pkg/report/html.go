@@ -0,0 +1,323 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Chart dimensions in SVG user units.
+const (
+	chartWidth  = 720
+	chartHeight = 240
+	chartMargin = 50
+)
+
+// Series colors, assigned in order.
+var palette = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+// RunInfo は HTML レポートに記載する実行条件です。
+type RunInfo struct {
+	Start    time.Time
+	End      time.Time
+	Timeout  time.Duration
+	Settings []string
+}
+
+// series is a single line in a line chart.
+type series struct {
+	name   string
+	points [][2]float64 // (seconds since start, value)
+}
+
+// chart is a rendered chart section of the report.
+type chart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// WriteHTML は収集した時系列データから外部リソースに依存しない HTML レポートを書き出します。
+func WriteHTML(path string, info RunInfo, recorder *metrics.Recorder) error {
+	data := struct {
+		Info       RunInfo
+		Elapsed    time.Duration
+		Deviations []metrics.Deviation
+		Workers    []metrics.WorkerUsage
+		Phases     []metrics.Phase
+		Load       []chart
+		System     []chart
+		Latency    []chart
+		Format     func(string, float64) string
+		Percent    func(float64) float64
+	}{
+		Info:       info,
+		Elapsed:    info.End.Sub(info.Start).Truncate(time.Second),
+		Deviations: recorder.Deviations(),
+		Workers:    recorder.WorkerUsages(),
+		Phases:     recorder.Phases(),
+		Load:       loadCharts(info.Start, recorder.Samples()),
+		System:     systemCharts(info.Start, recorder.Values()),
+		Latency:    latencyCharts(recorder.Latencies()),
+		Format:     metrics.FormatValue,
+		Percent:    func(ratio float64) float64 { return ratio * 100 },
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report file: %v", err)
+	}
+	defer file.Close()
+
+	if err := reportTemplate.Execute(file, data); err != nil {
+		return fmt.Errorf("failed to render report: %v", err)
+	}
+	return file.Close()
+}
+
+// loadCharts builds one target-vs-achieved chart per stressor.
+func loadCharts(start time.Time, samples []metrics.Sample) []chart {
+	targets := make(map[string]*series)
+	achieved := make(map[string]*series)
+	units := make(map[string]string)
+	var names []string
+	for _, s := range samples {
+		if _, ok := targets[s.Stressor]; !ok {
+			targets[s.Stressor] = &series{name: "target"}
+			achieved[s.Stressor] = &series{name: "achieved"}
+			units[s.Stressor] = s.Unit
+			names = append(names, s.Stressor)
+		}
+		t := s.Time.Sub(start).Seconds()
+		targets[s.Stressor].points = append(targets[s.Stressor].points, [2]float64{t, s.Target})
+		achieved[s.Stressor].points = append(achieved[s.Stressor].points, [2]float64{t, s.Achieved})
+	}
+	sort.Strings(names)
+
+	var charts []chart
+	for _, name := range names {
+		charts = append(charts, chart{
+			Title: name + " load over time",
+			SVG:   lineChart(units[name], []*series{targets[name], achieved[name]}),
+		})
+	}
+	return charts
+}
+
+// systemCharts builds one chart per system metric series.
+func systemCharts(start time.Time, values []metrics.Value) []chart {
+	bySeries := make(map[string]*series)
+	units := make(map[string]string)
+	var names []string
+	for _, v := range values {
+		s, ok := bySeries[v.Series]
+		if !ok {
+			s = &series{name: v.Series}
+			bySeries[v.Series] = s
+			units[v.Series] = v.Unit
+			names = append(names, v.Series)
+		}
+		s.points = append(s.points, [2]float64{v.Time.Sub(start).Seconds(), v.Value})
+	}
+	sort.Strings(names)
+
+	var charts []chart
+	for _, name := range names {
+		charts = append(charts, chart{
+			Title: name,
+			SVG:   lineChart(units[name], []*series{bySeries[name]}),
+		})
+	}
+	return charts
+}
+
+// latencyCharts builds one histogram per measured operation.
+func latencyCharts(latencies map[string]metrics.Histogram) []chart {
+	var names []string
+	for name := range latencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var charts []chart
+	for _, name := range names {
+		h := latencies[name]
+		charts = append(charts, chart{
+			Title: fmt.Sprintf("%s latency (n=%d, mean %v, min %v, max %v)", name, h.Count, h.Mean(), h.Min, h.Max),
+			SVG:   histogramChart(h),
+		})
+	}
+	return charts
+}
+
+// lineChart renders the given series as an SVG line chart sharing one y axis.
+func lineChart(unit string, lines []*series) template.HTML {
+	var maxX, maxY float64
+	for _, s := range lines {
+		for _, p := range s.points {
+			maxX = max(maxX, p[0])
+			maxY = max(maxY, p[1])
+		}
+	}
+	if maxX == 0 {
+		maxX = 1
+	}
+	if maxY == 0 {
+		maxY = 1
+	}
+	maxY *= 1.1
+
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+
+	var b strings.Builder
+	writeAxes(&b, metrics.FormatValue(unit, maxY), fmt.Sprintf("%.0fs", maxX))
+	for i, s := range lines {
+		color := palette[i%len(palette)]
+		var points []string
+		for _, p := range s.points {
+			x := chartMargin + p[0]/maxX*plotWidth
+			y := chartMargin + plotHeight - p[1]/maxY*plotHeight
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="%s"/>`, color, strings.Join(points, " "))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s">%s</text>`,
+			chartWidth-chartMargin-120, chartMargin+16*(i+1), color, html.EscapeString(s.name))
+	}
+	return svg(b.String())
+}
+
+// histogramChart renders the non-empty range of a latency histogram as an SVG bar chart.
+func histogramChart(h metrics.Histogram) template.HTML {
+	first, last := -1, -1
+	var maxCount int64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		maxCount = max(maxCount, c)
+	}
+	if first < 0 {
+		return svg("")
+	}
+
+	plotWidth := float64(chartWidth - 2*chartMargin)
+	plotHeight := float64(chartHeight - 2*chartMargin)
+	barWidth := plotWidth / float64(last-first+1)
+
+	var b strings.Builder
+	writeAxes(&b, fmt.Sprintf("%d", maxCount), "")
+	for i := first; i <= last; i++ {
+		x := chartMargin + float64(i-first)*barWidth
+		height := float64(h.Counts[i]) / float64(maxCount) * plotHeight
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+			x+1, chartMargin+plotHeight-height, barWidth-2, height, palette[0])
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" font-size="10" text-anchor="middle">&le;%v</text>`,
+			x+barWidth/2, chartHeight-chartMargin+14, metrics.BucketUpperBound(i))
+	}
+	return svg(b.String())
+}
+
+// writeAxes draws the plot frame with the y-axis maximum and x-axis end labels.
+func writeAxes(b *strings.Builder, yLabel, xLabel string) {
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`,
+		chartMargin, chartMargin, chartMargin, chartHeight-chartMargin)
+	fmt.Fprintf(b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#333"/>`,
+		chartMargin, chartHeight-chartMargin, chartWidth-chartMargin, chartHeight-chartMargin)
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="11">%s</text>`, 4, chartMargin-6, html.EscapeString(yLabel))
+	fmt.Fprintf(b, `<text x="%d" y="%d" font-size="11" text-anchor="end">%s</text>`,
+		chartWidth-chartMargin, chartHeight-chartMargin+28, html.EscapeString(xLabel))
+}
+
+// svg wraps chart elements in an svg element. All dynamic text is escaped by the callers.
+func svg(body string) template.HTML {
+	return template.HTML(fmt.Sprintf(
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">%s</svg>`,
+		chartWidth, chartHeight, body))
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>stress-go report {{.Info.Start.Format "2006-01-02 15:04:05"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.degraded { color: #d62728; font-weight: bold; }
+.ok { color: #2ca02c; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>stress-go report</h1>
+<table>
+<tr><th>Start</th><td>{{.Info.Start.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>End</th><td>{{.Info.End.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Duration</th><td>{{.Elapsed}} (requested {{.Info.Timeout}})</td></tr>
+{{range .Info.Settings}}<tr><th>Setting</th><td>{{.}}</td></tr>
+{{end}}</table>
+
+<h2>Target vs achieved</h2>
+{{if .Deviations}}<table>
+<tr><th>Stressor</th><th>Status</th><th>Target</th><th>Achieved (mean)</th><th>Min</th><th>Max</th><th>Mean deviation</th><th>Issues</th></tr>
+{{range .Deviations}}<tr>
+<td>{{.Stressor}}</td>
+<td>{{if .Degraded}}<span class="degraded">DEGRADED</span>{{else}}<span class="ok">OK</span>{{end}}</td>
+<td>{{call $.Format .Unit .MeanTarget}}</td>
+<td>{{call $.Format .Unit .MeanAchieved}}</td>
+<td>{{call $.Format .Unit .MinAchieved}}</td>
+<td>{{call $.Format .Unit .MaxAchieved}}</td>
+<td>{{printf "%+.1f%%" .MeanDeviation}}</td>
+<td>{{range .Issues}}{{.}}<br>{{end}}</td>
+</tr>
+{{end}}</table>{{else}}<p>No samples recorded.</p>{{end}}
+
+{{if .Workers}}<h2>Per-worker CPU time</h2>
+<table>
+<tr><th>Stressor</th><th>Worker</th><th>Status</th><th>Target</th><th>Achieved (mean)</th><th>Min</th></tr>
+{{range .Workers}}<tr>
+<td>{{.Stressor}}</td>
+<td>{{.Worker}}</td>
+<td>{{if .Lagging}}<span class="degraded">LAGGING</span>{{else}}<span class="ok">OK</span>{{end}}</td>
+<td>{{call $.Format "%" (call $.Percent .MeanTarget)}}</td>
+<td>{{call $.Format "%" (call $.Percent .MeanAchieved)}}</td>
+<td>{{call $.Format "%" (call $.Percent .MinAchieved)}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+
+{{if .Phases}}<h2>Load steps</h2>
+<table>
+<tr><th>Step</th><th>Start</th><th>End</th></tr>
+{{range .Phases}}<tr>
+<td>{{.Name}}</td>
+<td>{{.Start.Format "15:04:05.000"}}</td>
+<td>{{if not .End.IsZero}}{{.End.Format "15:04:05.000"}}{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}
+{{if .Load}}<h2>Load over time</h2>
+{{range .Load}}<h3>{{.Title}}</h3>
+{{.SVG}}
+{{end}}{{end}}
+{{if .Latency}}<h2>Latency histograms</h2>
+{{range .Latency}}<h3>{{.Title}}</h3>
+{{.SVG}}
+{{end}}{{end}}
+{{if .System}}<h2>System metrics</h2>
+{{range .System}}<h3>{{.Title}}</h3>
+{{.SVG}}
+{{end}}{{end}}
+</body>
+</html>
+`))
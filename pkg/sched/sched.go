@@ -0,0 +1,377 @@
+// Package sched は多数のゴルーチンの組がチャネルでメッセージを受け渡すピンポンと、高分解能タイマーの
+// 発火を繰り返すことで、Go ランタイムのスケジューラーとタイマー、OS のスレッドの切り替えに負荷をかけます。
+// CPU の演算とは異なり、ゴルーチンの待機と再開、スレッドの休止と起床の経路を測定します。
+package sched
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// sampleInterval is how often the achieved rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// maxLag is how far a pair may fall behind its schedule, for example while the
+// scheduler is saturated, before it gives up catching up.
+const maxLag = time.Second
+
+// defaultTimerInterval is how often each timer fires unless Options.TimerInterval is set.
+const defaultTimerInterval = time.Millisecond
+
+// maxGoroutines is the most goroutines a load may start. Each takes at least
+// 2 KB of stack, 2 GB in all.
+const maxGoroutines = 1 << 20
+
+// Options はスケジューラー負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Scheduler" を使用します。
+	Name string
+	// Pairs はバッファのないチャネルでメッセージを受け渡すゴルーチンの組の数です。
+	Pairs int
+	// Rate はすべての組の合計で1秒あたりに受け渡すメッセージの数です。0 の場合は制限せずに可能な限り受け渡します。
+	Rate float64
+	// Timers はタイマーを繰り返し発火させるゴルーチンの数です。
+	Timers int
+	// TimerInterval は各タイマーの発火間隔です。0 の場合は 1ms です。
+	TimerInterval time.Duration
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if o.Pairs < 0 || o.Rate < 0 || o.Timers < 0 || o.TimerInterval < 0 {
+		return fmt.Errorf("pairs, rate, timers and timer interval must not be negative")
+	}
+	if o.Pairs == 0 && o.Timers == 0 {
+		return fmt.Errorf("no goroutine pairs or timers to run")
+	}
+	if goroutines := 2*o.Pairs + o.Timers; goroutines > maxGoroutines {
+		return fmt.Errorf("%d goroutines exceed the maximum of %d", goroutines, maxGoroutines)
+	}
+	return nil
+}
+
+// Result はスケジューラー負荷の実行結果です。
+type Result struct {
+	// Messages は受け渡したメッセージの数です。
+	Messages int64
+	// Fires はタイマーが発火した回数です。
+	Fires int64
+	// Lateness はタイマーが予定の時刻より遅れて発火した時間の平均です。
+	Lateness time.Duration
+	// ContextSwitches はプロセス全体で OS が行ったコンテキストスイッチの回数です。数えられない場合は -1 です。
+	ContextSwitches int64
+}
+
+// Stats は実行中のスケジューラー負荷の状態です。
+type Stats struct {
+	// Target は現在の目標値 (1秒あたりのメッセージとタイマーの発火の数) です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定での実測値です。
+	Achieved float64
+	// ContextSwitches は直近の測定での1秒あたりの OS のコンテキストスイッチの数です。
+	ContextSwitches float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// counter is a count updated by a single goroutine, padded to a cache line of
+// its own so that thousands of goroutines do not contend on shared lines.
+type counter struct {
+	atomic.Int64
+	_ [56]byte
+}
+
+// sum returns the total of counters.
+func sum(counters []counter) int64 {
+	var total int64
+	for i := range counters {
+		total += counters[i].Load()
+	}
+	return total
+}
+
+// Controller は実行中のスケジューラー負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+
+	messages []counter     // per pair
+	fires    []counter     // per timer
+	lateness []counter     // per timer, in nanoseconds
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	achieved atomic.Uint64 // math.Float64bits of the last measured rate
+	switches atomic.Uint64 // math.Float64bits of the last measured context switch rate
+}
+
+// Start は opts に従ってスケジューラー負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続きます。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Scheduler"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	opts.TimerInterval = cmp.Or(opts.TimerInterval, defaultTimerInterval)
+
+	c := &Controller{
+		opts:     opts,
+		done:     make(chan struct{}),
+		messages: make([]counter, opts.Pairs),
+		fires:    make([]counter, opts.Timers),
+		lateness: make([]counter, opts.Timers),
+	}
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c, nil
+}
+
+// run starts the pairs and the timers and records the achieved rates until ctx is done.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("Scheduler", "Load generation completed")
+	switch {
+	case c.opts.Pairs > 0 && c.opts.Timers > 0:
+		recorder.Logf("Scheduler", "Ping-ponging messages between %d goroutine pairs and firing %d timers every %v",
+			c.opts.Pairs, c.opts.Timers, c.opts.TimerInterval)
+	case c.opts.Pairs > 0:
+		recorder.Logf("Scheduler", "Ping-ponging messages between %d goroutine pairs", c.opts.Pairs)
+	default:
+		recorder.Logf("Scheduler", "Firing %d timers every %v", c.opts.Timers, c.opts.TimerInterval)
+	}
+	if c.opts.Pairs > 0 && c.opts.Rate > 0 {
+		recorder.Logf("Scheduler", "Target rate: %.0f messages/s", c.opts.Rate)
+	}
+
+	var workers sync.WaitGroup
+	for i := range c.opts.Pairs {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			c.pingPong(ctx, &c.messages[i])
+		}()
+	}
+	for i := range c.opts.Timers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			// Spread the first fires over one interval so that the timers do
+			// not all expire together
+			if timing.Sleep(ctx, c.opts.TimerInterval*time.Duration(i)/time.Duration(c.opts.Timers)) {
+				c.fire(ctx, &c.fires[i], &c.lateness[i])
+			}
+		}()
+	}
+
+	name := cmp.Or(c.opts.Name, "Scheduler")
+	firstSwitches, switchesErr := sysinfo.ContextSwitches()
+	if switchesErr != nil {
+		recorder.Logf("Scheduler", "Context switches are not counted: %v", switchesErr)
+	}
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	lastWakeups, lastSwitches, lastTime := int64(0), firstSwitches, time.Now()
+	var counted counts
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			elapsed := now.Sub(lastTime).Seconds()
+			wakeups := sum(c.messages) + sum(c.fires)
+			rate := float64(wakeups-lastWakeups) / elapsed
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.Record("Scheduler", metrics.UnitWakeupsPerSecond, c.targetRate(), rate)
+			if switchesErr == nil {
+				if switches, err := sysinfo.ContextSwitches(); err == nil {
+					switchRate := float64(switches-lastSwitches) / elapsed
+					c.switches.Store(math.Float64bits(switchRate))
+					recorder.RecordValue(name+" context switches", metrics.UnitSwitchesPerSecond, switchRate)
+					lastSwitches = switches
+				}
+			}
+			lastWakeups, lastTime = wakeups, now
+			counted = c.count(counted)
+		}
+	}
+	workers.Wait()
+	c.count(counted)
+
+	c.result = Result{Messages: sum(c.messages), Fires: sum(c.fires), ContextSwitches: -1}
+	if c.result.Fires > 0 {
+		c.result.Lateness = time.Duration(sum(c.lateness) / c.result.Fires)
+	}
+	if switchesErr == nil {
+		if switches, err := sysinfo.ContextSwitches(); err == nil {
+			c.result.ContextSwitches = switches - firstSwitches
+		}
+	}
+	if c.opts.Pairs > 0 {
+		recorder.Logf("Scheduler", "Passed %d messages between %d goroutine pairs", c.result.Messages, c.opts.Pairs)
+	}
+	if c.opts.Timers > 0 {
+		recorder.Logf("Scheduler", "Fired %d timers, %v late on average", c.result.Fires, c.result.Lateness)
+	}
+	if c.result.ContextSwitches >= 0 {
+		recorder.Logf("Scheduler", "The process made %d context switches", c.result.ContextSwitches)
+	}
+}
+
+// counts are the totals already added to the counts of the recorder.
+type counts struct {
+	messages, fires int64
+}
+
+// count adds the messages and fires since the last call to the counts of the
+// recorder, so that they stay current for the metrics endpoint while the load
+// runs, and returns the new totals.
+func (c *Controller) count(counted counts) counts {
+	now := counts{messages: sum(c.messages), fires: sum(c.fires)}
+	c.opts.Recorder.AddCount("Scheduler", "messages", now.messages-counted.messages)
+	c.opts.Recorder.AddCount("Scheduler", "timer_fires", now.fires-counted.fires)
+	return now
+}
+
+// pingPong passes a message to a partner goroutine and waits for it to come
+// back, at the pair's share of the target rate, until ctx is done. Each pass
+// over the unbuffered channels parks one goroutine and wakes the other.
+func (c *Controller) pingPong(ctx context.Context, messages *counter) {
+	ping, pong := make(chan struct{}), make(chan struct{})
+	go func() {
+		for range ping {
+			pong <- struct{}{}
+		}
+	}()
+	// Closing ping ends the partner, which only waits on ping between passes
+	defer close(ping)
+	next := time.Now()
+	for ctx.Err() == nil {
+		if !c.pace(ctx, &next) {
+			continue
+		}
+		ping <- struct{}{}
+		<-pong
+		messages.Add(2)
+	}
+}
+
+// pace waits until the pair's next round trip is due at the target rate, and
+// reports false when the pair should check ctx and its pause state again instead.
+func (c *Controller) pace(ctx context.Context, next *time.Time) bool {
+	if c.paused.Load() || c.scale.Load() == 0 {
+		timing.Sleep(ctx, timing.IdleInterval)
+		*next = time.Now()
+		return false
+	}
+	if c.opts.Rate <= 0 {
+		return true
+	}
+	// A round trip is two messages, and each pair takes its share of the rate
+	rate := c.opts.Rate * math.Float64frombits(c.scale.Load()) / 2 / float64(c.opts.Pairs)
+	*next = next.Add(time.Duration(float64(time.Second) / rate))
+	if lag := time.Since(*next); lag > maxLag {
+		*next = time.Now()
+	}
+	return timing.Sleep(ctx, time.Until(*next))
+}
+
+// fire re-arms a timer every interval, divided by the scale, until ctx is
+// done, adding up how late it fires. The timer keeps to its schedule rather
+// than counting each interval from the last late fire, unless it falls more
+// than maxLag behind.
+func (c *Controller) fire(ctx context.Context, fires, lateness *counter) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	due := time.Now()
+	for ctx.Err() == nil {
+		scale := math.Float64frombits(c.scale.Load())
+		if c.paused.Load() || scale == 0 {
+			timing.Sleep(ctx, timing.IdleInterval)
+			due = time.Now()
+			continue
+		}
+		due = due.Add(time.Duration(float64(c.opts.TimerInterval) / scale))
+		if lag := time.Since(due); lag > maxLag {
+			due = time.Now()
+		}
+		timer.Reset(time.Until(due))
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			// Measured on waking up, so that the time the goroutine waited to
+			// be scheduled counts as well as the timer's own delay
+			fires.Add(1)
+			lateness.Add(int64(max(time.Since(due), 0)))
+		}
+	}
+}
+
+// targetRate returns the current target in messages and timer fires per
+// second, or 0 when the pairs pass messages as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() || (c.opts.Pairs > 0 && c.opts.Rate <= 0) {
+		return 0
+	}
+	fires := float64(c.opts.Timers) * float64(time.Second) / float64(c.opts.TimerInterval)
+	return (c.opts.Rate + fires) * math.Float64frombits(c.scale.Load())
+}
+
+// SetScale は Options で指定したメッセージの受け渡しの速さとタイマーの発火の頻度に掛ける係数を
+// 変更します (1.0 で指定どおり)。0 で停止します。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまでメッセージの受け渡しとタイマーの発火を止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了してすべてのゴルーチンが終わるまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, nil
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:          c.targetRate(),
+		Achieved:        math.Float64frombits(c.achieved.Load()),
+		ContextSwitches: math.Float64frombits(c.switches.Load()),
+		Paused:          c.paused.Load(),
+	}
+}
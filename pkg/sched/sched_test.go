@@ -0,0 +1,46 @@
+package sched
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		opts Options
+		ok   bool
+	}{
+		{Options{Pairs: 100}, true},
+		{Options{Timers: 100, TimerInterval: time.Millisecond}, true},
+		{Options{Pairs: 10, Rate: 1000, Timers: 10}, true},
+		{Options{}, false},
+		{Options{Pairs: -1}, false},
+		{Options{Timers: 10, TimerInterval: -time.Millisecond}, false},
+		{Options{Pairs: maxGoroutines/2 + 1}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v.Validate() = %v, want ok=%v", tt.opts, err, tt.ok)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	c, err := Start(context.Background(), Options{Pairs: 4, Timers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	c.Stop()
+	result, err := c.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Messages == 0 || result.Messages%2 != 0 {
+		t.Errorf("Messages = %d, want a positive even count", result.Messages)
+	}
+	if result.Fires == 0 {
+		t.Errorf("Fires = 0, want timers to have fired")
+	}
+}
@@ -0,0 +1,22 @@
+package sched
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "sched",
+		Name:        "switch",
+		Usage:       "--switch N",
+		Description: "Ping-ponging messages between N goroutine pairs over unbuffered channels to load the scheduler and thread wakeups",
+		Available:   true,
+	})
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "sched",
+		Name:        "timers",
+		Usage:       "--timers N --timer-interval 1ms",
+		Description: "Firing N high-resolution timers over and over to load the runtime timers and the kernel's timer wakeups",
+		Available:   true,
+	})
+}
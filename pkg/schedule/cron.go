@@ -0,0 +1,190 @@
+// Package schedule は cron 形式の時刻指定を解析し、次に一致する時刻を求めます。
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchLimit bounds how far ahead Next looks for a matching minute, so that
+// an expression that never matches (e.g., February 30) does not loop forever.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// field is the range and the names accepted in one field of an expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names of the values from min, if any
+}
+
+var (
+	minuteField  = field{name: "minute", min: 0, max: 59}
+	hourField    = field{name: "hour", min: 0, max: 23}
+	dayField     = field{name: "day of month", min: 1, max: 31}
+	monthField   = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// Cron は cron 形式の時刻指定 (分 時 日 月 曜日) です。Parse で作成します。
+type Cron struct {
+	expr    string
+	minute  uint64 // bit n is set if minute n matches
+	hour    uint64
+	day     uint64
+	month   uint64
+	weekday uint64
+	// anyDay and anyWeekday are set for a field starting with "*" (e.g., "*" or
+	// "*/2"), which cron does not combine with the other day field: otherwise a
+	// restricted day of month OR day of week matches.
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Parse は "0 2 * * 1-5" のような5つのフィールド (分 時 日 月 曜日) の時刻指定を解析します。
+// 各フィールドには *、値、範囲 (1-5)、間隔 (*/15、0-30/10) とそれらのカンマ区切りのリストを指定できます。
+// 月と曜日には名前 (jan、mon など) も使用できます。曜日の 0 と 7 はどちらも日曜日です。
+//
+// 引数:
+//
+//	expr - 時刻指定
+func Parse(expr string) (*Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day month weekday)", expr)
+	}
+	c := &Cron{expr: strings.Join(fields, " ")}
+	targets := []*uint64{&c.minute, &c.hour, &c.day, &c.month, &c.weekday}
+	for i, f := range []field{minuteField, hourField, dayField, monthField, weekdayField} {
+		set, err := f.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+		}
+		*targets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if c.weekday&(1<<7) != 0 {
+		c.weekday = c.weekday&^(1<<7) | 1
+	}
+	c.anyDay = strings.HasPrefix(fields[2], "*")
+	c.anyWeekday = strings.HasPrefix(fields[4], "*")
+	return c, nil
+}
+
+// parse returns the set of values that a field of an expression matches.
+func (f field) parse(s string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		spec, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepText)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		switch {
+		case spec == "*":
+		case strings.Contains(spec, "-"):
+			lowText, highText, _ := strings.Cut(spec, "-")
+			var err error
+			if low, err = f.value(lowText); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(highText); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, spec)
+			}
+		default:
+			value, err := f.value(spec)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			// "5/10" means from 5 to the end in steps of 10
+			if !hasStep {
+				high = value
+			}
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses one value of the field, given as a number or a name.
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q (%d-%d)", f.name, s, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Matches は t の分が時刻指定に一致するかどうかを返します。
+//
+// 引数:
+//
+//	t - 判定する時刻
+func (c *Cron) Matches(t time.Time) bool {
+	return c.minute&(1<<t.Minute()) != 0 && c.hour&(1<<t.Hour()) != 0 &&
+		c.month&(1<<int(t.Month())) != 0 && c.dayMatches(t)
+}
+
+// Next は t より後で時刻指定に一致する最初の時刻 (分の始まり) を返します。
+// 5年以内に一致する時刻がない場合はゼロ値を返します。
+//
+// 引数:
+//
+//	t - 基準の時刻
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(searchLimit); next.Before(limit); {
+		switch {
+		case c.month&(1<<int(next.Month())) == 0:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case c.hour&(1<<next.Hour()) == 0:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case c.minute&(1<<next.Minute()) == 0:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day fields.
+func (c *Cron) dayMatches(t time.Time) bool {
+	day := c.day&(1<<t.Day()) != 0
+	weekday := c.weekday&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekday
+	case c.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// String は正規化した時刻指定 ("0 2 * * 1-5" など) を返します。
+func (c *Cron) String() string {
+	return c.expr
+}
@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr string
+		ok   bool
+	}{
+		{"0 2 * * 1-5", true},
+		{"*/15 * * * *", true},
+		{"0-30/10 8-18 1,15 jan-jun mon", true},
+		{"0 0 * * 7", true},
+		{"0 0 * *", false},
+		{"0 0 * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"* * * * 8", false},
+		{"*/0 * * * *", false},
+		{"30-10 * * * *", false},
+		{"* * * foo *", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if (err == nil) != tt.ok {
+				t.Errorf("Parse(%q) = %v, want ok=%v", tt.expr, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Thursday, 2026-01-01 10:30
+	from := time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2026, 1, 1, 10, 40, 0, 0, time.UTC)},
+		{"0 9 * * mon", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// A restricted day of month or day of week matches either
+		{"0 0 10 * mon", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		// A stepped "*" is unrestricted, so the day of week alone decides
+		{"0 0 */2 * mon", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * */3", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := c.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", from, got, tt.want)
+			}
+		})
+	}
+}
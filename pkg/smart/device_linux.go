@@ -0,0 +1,46 @@
+package smart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// DeviceOf は path のファイルシステムがあるディスクのデバイスのパス ("/dev/sda" など) を返します。
+// パーティションや、物理デバイスが1つだけの device-mapper (LVM など) はそのディスクを返します。
+//
+// 引数:
+//
+//	path - ファイルシステム上のパス
+func DeviceOf(path string) (string, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return "", err
+	}
+	dev := uint64(stat.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	sysPath, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", major, minor))
+	if err != nil {
+		return "", fmt.Errorf("%s is not on a block device", path)
+	}
+
+	// Follow device-mapper devices down to the single disk they are built on
+	for {
+		slaves, _ := os.ReadDir(filepath.Join(sysPath, "slaves"))
+		if len(slaves) != 1 {
+			if len(slaves) > 1 {
+				return "", fmt.Errorf("%s is on %s, which spans %d devices", path, filepath.Base(sysPath), len(slaves))
+			}
+			break
+		}
+		if sysPath, err = filepath.EvalSymlinks(filepath.Join(sysPath, "slaves", slaves[0].Name())); err != nil {
+			return "", err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(sysPath, "partition")); err == nil {
+		sysPath = filepath.Dir(sysPath)
+	}
+	return "/dev/" + filepath.Base(sysPath), nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package smart
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// DeviceOf は path のファイルシステムがあるディスクのデバイスのパスを返します。
+// このプラットフォームでは常にエラーを返すため、デバイスを明示的に指定する必要があります。
+//
+// 引数:
+//
+//	path - ファイルシステム上のパス
+func DeviceOf(path string) (string, error) {
+	return "", fmt.Errorf("finding the device of %s is not supported on %s", path, runtime.GOOS)
+}
@@ -0,0 +1,157 @@
+// Package smart はストレージデバイスの SMART の健康状態を smartctl (smartmontools) で読み取ります。
+// ストレージ負荷の実行中に健康状態の悪化を検出するために使用します。
+package smart
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ATA attribute IDs of the counters that grow as a disk wears out.
+const (
+	attributeReallocated   = 5
+	attributePending       = 197
+	attributeUncorrectable = 198
+)
+
+// Health は SMART で読み取ったデバイスの健康状態です。デバイスが報告しない項目は 0 です。
+type Health struct {
+	// Device はデバイスのパス ("/dev/sda" など) です。
+	Device string
+	// Model はデバイスのモデル名です。
+	Model string
+	// Passed は SMART の総合評価が合格かどうかです。
+	Passed bool
+	// Temperature は現在の温度 (°C) です。
+	Temperature float64
+	// ReallocatedSectors は代替処理済みのセクタ数 (ATA 属性 5) です。
+	ReallocatedSectors int64
+	// PendingSectors は代替処理を待っているセクタ数 (ATA 属性 197) です。
+	PendingSectors int64
+	// UncorrectableSectors は訂正できないセクタ数 (ATA 属性 198) です。
+	UncorrectableSectors int64
+	// MediaErrors は訂正できなかったデータの完全性エラーの数 (NVMe) です。
+	MediaErrors int64
+	// CriticalWarning は NVMe のクリティカルワーニングのビットです。0 以外は警告があります。
+	CriticalWarning int64
+}
+
+// Errors は ATA の不良セクタ数と NVMe のメディアエラー数の合計を返します。
+func (h Health) Errors() int64 {
+	return h.ReallocatedSectors + h.PendingSectors + h.UncorrectableSectors + h.MediaErrors
+}
+
+// Degradation は before から h までの健康状態の悪化を説明する文字列を返します。悪化していなければ空です。
+// 総合評価の不合格、不良セクタやメディアエラーの増加、クリティカルワーニングを悪化とみなします。
+//
+// 引数:
+//
+//	before - 比較の基準とする以前の健康状態
+func (h Health) Degradation(before Health) []string {
+	var reasons []string
+	if before.Passed && !h.Passed {
+		reasons = append(reasons, "overall health assessment failed")
+	}
+	counters := []struct {
+		name          string
+		before, after int64
+	}{
+		{"reallocated sectors", before.ReallocatedSectors, h.ReallocatedSectors},
+		{"pending sectors", before.PendingSectors, h.PendingSectors},
+		{"uncorrectable sectors", before.UncorrectableSectors, h.UncorrectableSectors},
+		{"media errors", before.MediaErrors, h.MediaErrors},
+	}
+	for _, c := range counters {
+		if c.after > c.before {
+			reasons = append(reasons, fmt.Sprintf("%s %d -> %d", c.name, c.before, c.after))
+		}
+	}
+	if before.CriticalWarning == 0 && h.CriticalWarning != 0 {
+		reasons = append(reasons, fmt.Sprintf("critical warning 0x%02x", h.CriticalWarning))
+	}
+	return reasons
+}
+
+// report is the part of the output of "smartctl --json" that Read uses.
+type report struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	ModelName   string `json:"model_name"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	ATAAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value int64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeLog struct {
+		CriticalWarning int64 `json:"critical_warning"`
+		MediaErrors     int64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// Read は smartctl を実行して device の健康状態を読み取ります。通常は root 権限が必要です。
+//
+// 引数:
+//
+//	ctx    - smartctl の実行の制御に使用するコンテキスト
+//	device - デバイスのパス ("/dev/sda"、"/dev/nvme0n1" など)
+func Read(ctx context.Context, device string) (Health, error) {
+	output, err := exec.CommandContext(ctx, "smartctl", "--json", "-i", "-H", "-A", device).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		if errors.Is(err, exec.ErrNotFound) {
+			return Health{}, fmt.Errorf("smartctl not found; install smartmontools")
+		}
+		return Health{}, fmt.Errorf("failed to run smartctl: %v", err)
+	}
+
+	var r report
+	if err := json.Unmarshal(output, &r); err != nil {
+		return Health{}, fmt.Errorf("failed to parse the smartctl output for %s: %v", device, err)
+	}
+	// Bits 0 and 1 of the exit status mean that the device could not be read;
+	// the other bits report the health of a device that was read
+	if r.Smartctl.ExitStatus&0x3 != 0 || r.SmartStatus == nil {
+		var messages []string
+		for _, m := range r.Smartctl.Messages {
+			messages = append(messages, m.String)
+		}
+		return Health{}, fmt.Errorf("smartctl cannot read %s: %s", device, strings.Join(messages, "; "))
+	}
+
+	h := Health{
+		Device:          device,
+		Model:           r.ModelName,
+		Passed:          r.SmartStatus.Passed,
+		Temperature:     r.Temperature.Current,
+		MediaErrors:     r.NVMeLog.MediaErrors,
+		CriticalWarning: r.NVMeLog.CriticalWarning,
+	}
+	for _, a := range r.ATAAttributes.Table {
+		switch a.ID {
+		case attributeReallocated:
+			h.ReallocatedSectors = a.Raw.Value
+		case attributePending:
+			h.PendingSectors = a.Raw.Value
+		case attributeUncorrectable:
+			h.UncorrectableSectors = a.Raw.Value
+		}
+	}
+	return h, nil
+}
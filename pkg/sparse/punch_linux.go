@@ -0,0 +1,29 @@
+package sparse
+
+import (
+	"os"
+	"syscall"
+)
+
+// Supported はこのプラットフォームでスパースファイルに穴を開けられるかどうかです。
+const Supported = true
+
+// Modes of fallocate(2): deallocate the range and keep the file size.
+const (
+	fallocKeepSize  = 0x01
+	fallocPunchHole = 0x02
+)
+
+// punchHole deallocates length bytes of file at offset; they then read as zeros.
+func punchHole(file *os.File, offset, length int64) error {
+	return syscall.Fallocate(int(file.Fd()), fallocPunchHole|fallocKeepSize, offset, length)
+}
+
+// allocatedBytes returns the disk space that file occupies, or 0 if unknown.
+func allocatedBytes(file *os.File) int64 {
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(int(file.Fd()), &stat); err != nil {
+		return 0
+	}
+	return stat.Blocks * 512
+}
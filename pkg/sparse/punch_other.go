@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sparse
+
+import (
+	"fmt"
+	"os"
+)
+
+// Supported はこのプラットフォームでスパースファイルに穴を開けられるかどうかです。
+const Supported = false
+
+func punchHole(file *os.File, offset, length int64) error {
+	return fmt.Errorf("punching holes is not supported on this platform")
+}
+
+func allocatedBytes(file *os.File) int64 {
+	return 0
+}
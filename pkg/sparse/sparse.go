@@ -0,0 +1,378 @@
+// Package sparse はスパースファイルに穴を開けては埋め直し続けることで、ファイルシステムのエクステント管理に負荷をかけます。
+// 仮想マシンのディスクイメージやデータベースのファイルのように、領域の解放 (FALLOC_FL_PUNCH_HOLE) と
+// 再確保が繰り返され、断片化したエクステントを持つファイルでの動作を試験します。
+package sparse
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+	"github.com/utkamioka/stress-go/pkg/tempdirs"
+)
+
+// sampleInterval is how often the achieved operation rate is recorded.
+const sampleInterval = 2 * time.Second
+
+// extentUnit is the alignment and the smallest size of a punched or filled
+// range: a file system block on most file systems, so that holes free blocks.
+const extentUnit = 4096
+
+// maxExtentUnits is the largest punched or filled range, in extentUnits.
+const maxExtentUnits = 256
+
+// syncEvery is the number of operations after which a worker syncs its file,
+// so that the extent changes reach the file system's metadata on disk.
+const syncEvery = 64
+
+// defaultFiles is the number of sparse files, each with its own worker, unless Options.Files is set.
+const defaultFiles = 4
+
+// Options はスパースファイルの負荷の設定です。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Sparse" を使用します。
+	Name string
+	// Size はスパースファイルの見かけのサイズの合計（バイト）です。ディスクを占有するのはこのうち書き込んだ範囲だけで、
+	// 穴を開ける操作と埋める操作が同じ割合のため、およそ半分になります。
+	Size int64
+	// Files はスパースファイルの数です。ファイルごとに1つのワーカーが操作します。0 の場合は 4 です。
+	Files int
+	// Dir はファイルを作成するディレクトリです。空の場合はOSの一時ディレクトリを使用します。
+	Dir string
+	// Rate は目標の操作数 (穴を開ける・埋める操作の合計、1秒あたり) です。0 の場合は制限せずに可能な限り操作します。
+	Rate float64
+	// Seed は操作する範囲と書き込む内容を決める乱数のシードです。0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// Budget は実行全体の負荷生成モジュールで共有するディスク容量の上限です (nil可)。
+	// ファイルを作成する前に見かけのサイズの合計を予約し、上限に収まらない場合はファイルを小さくします。
+	Budget *budget.Budget
+	// Recorder は目標値と実測値、穴の内容の検証結果を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Validate はオプションの値が有効かどうかを検証します。
+func (o Options) Validate() error {
+	if !Supported {
+		return fmt.Errorf("punching holes in files is not supported on this platform")
+	}
+	if o.Size <= 0 {
+		return fmt.Errorf("sparse file size must be positive")
+	}
+	if o.Files < 0 || o.Rate < 0 {
+		return fmt.Errorf("sparse files and rate must not be negative")
+	}
+	if o.Size/int64(cmp.Or(o.Files, defaultFiles)) < maxExtentUnits*extentUnit {
+		return fmt.Errorf("sparse files must be at least %d KB each", maxExtentUnits*extentUnit/1024)
+	}
+	return nil
+}
+
+// Result はスパースファイルの負荷の実行結果です。
+type Result struct {
+	// Punches は穴を開けた回数です。
+	Punches int64
+	// Fills は穴を埋めた (データを書き込んだ) 回数です。
+	Fills int64
+	// AllocatedBytes は終了時にファイルが実際に占有していたディスク容量（バイト）です。
+	AllocatedBytes int64
+}
+
+// Stats は実行中のスパースファイルの負荷の状態です。
+type Stats struct {
+	// Target は現在の目標操作数（1秒あたり）です。制限しない場合は 0 です。
+	Target float64
+	// Achieved は直近の測定での操作数（1秒あたり）です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// Dir はファイルを書き込む一時ディレクトリです。作成前は空です。
+	Dir string
+}
+
+// Controller は実行中のスパースファイルの負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result
+	err    error
+
+	punches  atomic.Int64
+	fills    atomic.Int64
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	dir      atomic.Pointer[string] // the temporary directory once run creates it
+	achieved atomic.Uint64          // math.Float64bits of the last measured operation rate
+}
+
+// Start は opts に従ってスパースファイルの負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続き、終了時にファイルを削除します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Sparse"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+	if opts.Files == 0 {
+		opts.Files = defaultFiles
+	}
+
+	c := &Controller{opts: opts, done: make(chan struct{})}
+	c.scale.Store(math.Float64bits(1))
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+	return c, nil
+}
+
+// run creates the sparse files and punches and fills them until ctx is done.
+func (c *Controller) run(ctx context.Context) {
+	defer close(c.done)
+	recorder := c.opts.Recorder
+	defer recorder.Logf("Sparse", "Load generation completed")
+
+	dir, err := os.MkdirTemp(c.opts.Dir, "stress-go-sparse-")
+	if err != nil {
+		c.err = fmt.Errorf("failed to create temporary directory: %v", err)
+		return
+	}
+	c.dir.Store(&dir)
+	tempdirs.Track(dir)
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			recorder.Logf("Sparse", "Failed to remove %s: %v", dir, err)
+		}
+	}()
+	if space, err := sysinfo.ReadDiskSpace(dir); err == nil && space.Available < c.opts.Size {
+		c.err = fmt.Errorf("insufficient disk space for %d MiB of sparse files: %d MiB free in %s",
+			c.opts.Size/(1024*1024), space.Available/(1024*1024), dir)
+		return
+	}
+
+	// The files may fill up to their apparent size, which counts against the disk
+	// space shared with the other stressors
+	size := c.opts.Budget.Reserve(c.opts.Size)
+	defer c.opts.Budget.Release(size)
+	if size < c.opts.Size {
+		limit := c.opts.Budget.Limit()
+		recorder.Flag("Sparse", i18n.Sprintf("files capped by hard disk limit (%d MiB)", limit/(1024*1024)))
+		if size/int64(c.opts.Files) < maxExtentUnits*extentUnit {
+			c.err = fmt.Errorf("no room for the sparse files within the hard disk limit of %d MiB", limit/(1024*1024))
+			return
+		}
+		recorder.Logf("Sparse", "Sparse files capped from %d MiB to %d MiB by the disk limit", c.opts.Size/(1024*1024), size/(1024*1024))
+	}
+	fileSize := size / int64(c.opts.Files)
+	fileSize -= fileSize % extentUnit
+	files := make([]*os.File, c.opts.Files)
+	for i := range files {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("sparse-%d.dat", i)))
+		if err == nil {
+			err = file.Truncate(fileSize)
+			files[i] = file
+		}
+		if err != nil {
+			c.err = fmt.Errorf("failed to create a sparse file: %v", err)
+			closeFiles(files)
+			return
+		}
+	}
+	defer closeFiles(files)
+	// Fail early on file systems such as tmpfs before 3.5 or FAT that cannot punch holes
+	if err := punchHole(files[0], 0, extentUnit); err != nil {
+		c.err = fmt.Errorf("cannot punch holes in files in %s: %v", dir, err)
+		return
+	}
+
+	if c.opts.Rate > 0 {
+		recorder.Logf("Sparse", "Punching and filling holes in %d sparse files of %d MiB in %s at %.0f operations/s",
+			len(files), fileSize/(1024*1024), dir, c.opts.Rate)
+	} else {
+		recorder.Logf("Sparse", "Punching and filling holes in %d sparse files of %d MiB in %s",
+			len(files), fileSize/(1024*1024), dir)
+	}
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.churn(ctx, file, fileSize, i)
+		}()
+	}
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	lastOps, lastTime := int64(0), time.Now()
+	for done := false; !done; {
+		select {
+		case <-ctx.Done():
+			done = true
+		case now := <-ticker.C:
+			ops := c.punches.Load() + c.fills.Load()
+			rate := float64(ops-lastOps) / now.Sub(lastTime).Seconds()
+			c.achieved.Store(math.Float64bits(rate))
+			recorder.Record("Sparse", metrics.UnitOpsPerSecond, c.targetRate(), rate)
+			lastOps, lastTime = ops, now
+		}
+	}
+	wg.Wait()
+
+	c.result.Punches = c.punches.Load()
+	c.result.Fills = c.fills.Load()
+	for _, file := range files {
+		c.result.AllocatedBytes += allocatedBytes(file)
+	}
+	recorder.AddCount("Sparse", "punches", c.result.Punches)
+	recorder.AddCount("Sparse", "fills", c.result.Fills)
+	recorder.Logf("Sparse", "Punched %d holes and filled %d; %d MiB of %d MiB allocated at the end",
+		c.result.Punches, c.result.Fills, c.result.AllocatedBytes/(1024*1024), fileSize*int64(len(files))/(1024*1024))
+}
+
+// closeFiles closes the files opened so far.
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		if file != nil {
+			file.Close()
+		}
+	}
+}
+
+// churn punches holes in and fills random ranges of file until ctx is done,
+// pacing itself to its share of the target rate. After each punch it reads the
+// start of the hole back, which the file system must return as zeros.
+func (c *Controller) churn(ctx context.Context, file *os.File, size int64, worker int) {
+	recorder := c.opts.Recorder
+	rng := rand.New(rand.NewPCG(c.opts.Seed, uint64(worker)))
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], c.opts.Seed)
+	binary.LittleEndian.PutUint64(key[8:], uint64(worker))
+	data := rand.NewChaCha8(key)
+	buffer := make([]byte, maxExtentUnits*extentUnit)
+	zeros := make([]byte, extentUnit)
+	units := size / extentUnit
+
+	next := time.Now()
+	for ops := 1; ctx.Err() == nil; ops++ {
+		if c.paused.Load() || c.scale.Load() == 0 {
+			time.Sleep(timing.IdleInterval)
+			next = time.Now()
+			continue
+		}
+		if rate := c.targetRate(); rate > 0 {
+			next = next.Add(time.Duration(float64(time.Second) * float64(c.opts.Files) / rate))
+			if wait := time.Until(next); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		length := (1 + rng.Int64N(maxExtentUnits)) * extentUnit
+		offset := rng.Int64N(units-length/extentUnit+1) * extentUnit
+		if rng.IntN(2) == 0 {
+			if err := punchHole(file, offset, length); err != nil {
+				recorder.Logf("Sparse", "Punch error: %v", err)
+				recorder.AddCount("Sparse", "errors", 1)
+				continue
+			}
+			c.punches.Add(1)
+			if _, err := file.ReadAt(buffer[:extentUnit], offset); err != nil {
+				recorder.Logf("Sparse", "Read error: %v", err)
+				recorder.AddCount("Sparse", "errors", 1)
+			} else if !bytes.Equal(buffer[:extentUnit], zeros) {
+				recorder.RecordVerification("Sparse", 1, fmt.Sprintf("%s: hole punched at offset %d reads back data", file.Name(), offset))
+			} else {
+				recorder.RecordVerification("Sparse", 1)
+			}
+		} else {
+			data.Read(buffer[:length])
+			if _, err := file.WriteAt(buffer[:length], offset); err != nil {
+				recorder.Logf("Sparse", "Write error: %v", err)
+				recorder.AddCount("Sparse", "errors", 1)
+				continue
+			}
+			c.fills.Add(1)
+			recorder.AddCount("Sparse", "bytes_written", length)
+		}
+		if ops%syncEvery == 0 {
+			if err := file.Sync(); err != nil {
+				recorder.Logf("Sparse", "Sync error: %v", err)
+				recorder.AddCount("Sparse", "errors", 1)
+			}
+		}
+	}
+}
+
+// targetRate returns the current operation rate target, or 0 for as fast as possible.
+func (c *Controller) targetRate() float64 {
+	if c.paused.Load() {
+		return 0
+	}
+	return c.opts.Rate * math.Float64frombits(c.scale.Load())
+}
+
+// SetScale は Options で指定した目標操作数に掛ける係数を変更します (1.0 で指定どおり)。
+// 目標を指定していない場合は、0 で停止し、それ以外では可能な限り操作します。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+}
+
+// Pause は Resume が呼ばれるまで操作を止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了してファイルを削除するまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	<-c.done
+	return c.result, c.err
+}
+
+// workDir returns the temporary directory, or "" before run creates it.
+func (c *Controller) workDir() string {
+	if dir := c.dir.Load(); dir != nil {
+		return *dir
+	}
+	return ""
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target:   c.targetRate(),
+		Achieved: math.Float64frombits(c.achieved.Load()),
+		Paused:   c.paused.Load(),
+		Dir:      c.workDir(),
+	}
+}
@@ -0,0 +1,15 @@
+package sparse
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "sparse",
+		Name:        "punch",
+		Usage:       "--sparse <size>",
+		Description: "Punching holes in sparse files with fallocate and filling them again, churning the file system's extents",
+		Available:   Supported,
+		Requirement: "Linux",
+	})
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Sizes used by the storage benchmark.
+const (
+	benchBlockSize   = 1024 * 1024
+	benchIOSize      = 4096
+	benchMaxFileSize = 2 * 1024 * 1024 * 1024
+)
+
+// BenchResult は Benchmark の結果です。
+type BenchResult struct {
+	// WriteBytesPerSecond は 1MiB 単位の順次書き込みの速度（バイト/秒）です。最後の fsync までを含みます。
+	WriteBytesPerSecond float64
+	// IOPS は 4KiB のランダム書き込みを1回ずつ fsync した場合の1秒あたりの操作数です（キュー深度 1）。
+	IOPS float64
+}
+
+// Benchmark は dir に一時ファイルを作成し、順次書き込みの速度とランダム書き込みの IOPS をそれぞれ d の間計測します。
+// ファイルは空きディスク容量の 10% (最大 2GiB) を上限に、上限に達すると先頭から上書きします。
+// 作成した一時ファイルは終了時に削除されます。
+//
+// 引数:
+//
+//	ctx      - 計測を中断するためのコンテキスト
+//	dir      - 一時ディレクトリを作成するディレクトリ。空の場合は DefaultDir
+//	d        - それぞれの計測時間
+//	recorder - 一時ディレクトリの選択などを記録する Recorder（nil可）
+func Benchmark(ctx context.Context, dir string, d time.Duration, recorder *metrics.Recorder) (BenchResult, error) {
+	tempDir, cleanup, err := createTempDir(dir, recorder)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	defer cleanup()
+
+	fileSize, err := calculatePercentageSize(nil, tempDir, 10, 0)
+	if err != nil {
+		return BenchResult{}, err
+	}
+	fileSize = max(min(fileSize, benchMaxFileSize)/benchBlockSize, 1) * benchBlockSize
+
+	file, err := os.Create(filepath.Join(tempDir, "bench.dat"))
+	if err != nil {
+		return BenchResult{}, err
+	}
+	defer file.Close()
+
+	var result BenchResult
+	buffer := make([]byte, benchBlockSize)
+	if _, err := rand.Read(buffer); err != nil {
+		return result, err
+	}
+
+	// Sequential writes, wrapping around at the file size
+	writeCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	var written, offset int64
+	start := time.Now()
+	for writeCtx.Err() == nil {
+		if _, err := file.WriteAt(buffer, offset); err != nil {
+			return result, err
+		}
+		written += benchBlockSize
+		offset += benchBlockSize
+		if offset >= fileSize {
+			offset = 0
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return result, err
+	}
+	result.WriteBytesPerSecond = float64(written) / time.Since(start).Seconds()
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	// Random synchronous writes within the part of the file that was written
+	blocks := min(written, fileSize) / benchIOSize
+	iopsCtx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	var ops int64
+	random := make([]byte, 8)
+	start = time.Now()
+	for iopsCtx.Err() == nil {
+		if _, err := rand.Read(random); err != nil {
+			return result, err
+		}
+		at := int64(binary.LittleEndian.Uint64(random)%uint64(blocks)) * benchIOSize
+		if _, err := file.WriteAt(buffer[:benchIOSize], at); err != nil {
+			return result, err
+		}
+		if err := file.Sync(); err != nil {
+			return result, err
+		}
+		ops++
+	}
+	result.IOPS = float64(ops) / time.Since(start).Seconds()
+	return result, ctx.Err()
+}
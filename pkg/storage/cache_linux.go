@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// dropCachesPath is the kernel interface that frees the clean page cache.
+const dropCachesPath = "/proc/sys/vm/drop_caches"
+
+// DropPageCache はダーティページをディスクに書き出した後、ページキャッシュを破棄します。
+// 読み込みの計測がキャッシュではなくデバイスの性能を反映するようにするために使用します。
+// /proc/sys/vm/drop_caches への書き込み権限 (通常は root) が必要です。
+func DropPageCache() error {
+	// Dirty pages cannot be dropped, so write them out first
+	syscall.Sync()
+	if err := os.WriteFile(dropCachesPath, []byte("1\n"), 0); err != nil {
+		if errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EROFS) {
+			return fmt.Errorf("dropping the page cache needs root privileges and a writable %s", dropCachesPath)
+		}
+		return fmt.Errorf("failed to drop the page cache: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package storage
+
+import "fmt"
+
+// DropPageCache は Linux 以外では未対応のため、常にエラーを返します。
+func DropPageCache() error {
+	return fmt.Errorf("dropping the page cache is only supported on Linux")
+}
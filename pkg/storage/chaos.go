@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// corruptRequest asks the run loop to corrupt a block of one of the stress files.
+type corruptRequest struct {
+	rng   *rand.Rand
+	reply chan corruptResult
+}
+
+// corruptResult describes the corrupted block, or why none was corrupted.
+type corruptResult struct {
+	detail string
+	err    error
+}
+
+// CorruptBlock は障害注入のために、rng で選んだストレス用ファイルの1ブロックを破損させ、その位置を返します。
+// 破損は次にそのファイルを読み込んだときの検証で検出し、修復します。Verify が有効な場合にのみ使用できます。
+func (c *Controller) CorruptBlock(rng *rand.Rand) (string, error) {
+	if !c.opts.Verify {
+		return "", fmt.Errorf("corrupted blocks can only be detected with verification enabled")
+	}
+	req := corruptRequest{rng: rng, reply: make(chan corruptResult, 1)}
+	select {
+	case c.corrupts <- req:
+	case <-c.stopped:
+		return "", fmt.Errorf("storage load is not running")
+	}
+	result := <-req.reply
+	return result.detail, result.err
+}
+
+// corruptBlock overwrites part of the payload of a random whole block of f with
+// random bytes, records it in in so that it is repaired rather than reported,
+// and returns the index of the block. The I/O unit of ioSize bytes holding the
+// block is locked meanwhile, so that I/O workers see it either intact or
+// recorded.
+func corruptBlock(f stressFile, rng *rand.Rand, ioSize int, in *integrity) (uint64, error) {
+	file, err := os.OpenFile(f.path, os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	blocks := info.Size() / blockSize
+	if blocks == 0 {
+		return 0, fmt.Errorf("%s holds no whole block", f.path)
+	}
+	index := uint64(rng.Int64N(blocks))
+	garbage := make([]byte, 16)
+	for i := range garbage {
+		garbage[i] = byte(rng.UintN(256))
+	}
+	unit := in.unit(f, int64(index)*blockSize/int64(ioSize))
+	unit.Lock()
+	defer unit.Unlock()
+	// Past the header, so that the checksum is what catches it
+	if _, err := file.WriteAt(garbage, int64(index)*blockSize+24); err != nil {
+		return index, err
+	}
+	in.inject(f, index)
+	return index, nil
+}
+
+// recoverCorruption rewrites the injected corrupt block at index of f with valid
+// data of the write numbered sequence and records the recovery.
+func recoverCorruption(recorder *metrics.Recorder, data io.Reader, f stressFile, index, sequence uint64) {
+	recorder.Logf("Storage", "Detected the injected corruption of %s block %d", f.path, index)
+	file, err := os.OpenFile(f.path, os.O_WRONLY, 0)
+	if err == nil {
+		block := make([]byte, blockSize)
+		offset := int64(index) * blockSize
+		if err = fillData(block, data, f, offset, sequence, true); err == nil {
+			_, err = file.WriteAt(block, offset)
+		}
+		file.Close()
+	}
+	if err != nil {
+		recorder.Logf("Storage", "Cannot repair %s block %d: %v", f.path, index, err)
+		return
+	}
+	recorder.RecordRecovery("Storage", "corrupt")
+}
@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/supervise"
+)
+
+// Controller は実行中のストレージ負荷を操作します。Start が返します。
+type Controller struct {
+	opts   Options
+	dir    string
+	quota  *quota
+	group  *supervise.Group
+	cancel context.CancelFunc
+	err    error
+	// scan is the result of the final integrity scan, set by the run loop
+	scan *ScanResult
+	// iops and throughput are the averages of the I/O workers, set by the run loop
+	iops, throughput float64
+
+	// mu guards the stress files shared with the I/O workers and their totals
+	mu    sync.Mutex
+	files []stressFile
+	io    ioCounters
+
+	override atomic.Int64  // target set by SetTarget, or -1
+	scale    atomic.Uint64 // math.Float64bits of the factor set by SetScale
+	paused   atomic.Bool
+	target   atomic.Int64
+	used     atomic.Int64
+	changed  chan struct{}
+	// percent is what Options.Percent resolved to at the latest sample of the monitor
+	percent percentTarget
+	// data generates the written data; only the run loop uses it
+	data *rand.ChaCha8
+	// integrity tracks the content of the stress files in verify mode
+	integrity *integrity
+	// corrupts carries CorruptBlock requests to the run loop; stopped is closed when it ends.
+	corrupts chan corruptRequest
+	stopped  chan struct{}
+}
+
+// Stats は実行中のストレージ負荷の状態です。
+type Stats struct {
+	// Target は現在の目標ディスク使用量（バイト）です。
+	Target int64
+	// Used はストレス用ファイルが現在占有しているディスク容量（バイト）です。
+	Used int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// Dir はストレス用ファイルを書き込む一時ディレクトリです。
+	Dir string
+}
+
+// Start は opts に従ってストレージ負荷をバックグラウンドで開始し、操作用の Controller を返します。
+// 負荷は ctx が終了するか Stop が呼ばれるまで続き、終了時に一時ファイルを削除します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - 負荷の設定
+func Start(ctx context.Context, opts Options) (*Controller, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// A named job is recorded under its own name instead of "Storage"
+	if opts.Name != "" {
+		opts.Recorder = opts.Recorder.WithName(opts.Name)
+	}
+
+	dir, cleanup, err := createTempDir(opts.Dir, opts.Recorder)
+	if err != nil {
+		return nil, err
+	}
+	netfs := NetworkFilesystem(dir)
+	if netfs != "" {
+		opts.NetworkMix = true
+	}
+
+	c := &Controller{
+		opts:      opts,
+		dir:       dir,
+		quota:     &quota{limit: opts.MaxBytes, shared: opts.Budget},
+		data:      newDataSource(opts.Seed),
+		integrity: newIntegrity(),
+		changed:   make(chan struct{}, 1),
+		corrupts:  make(chan corruptRequest),
+		stopped:   make(chan struct{}),
+	}
+	c.override.Store(-1)
+	c.scale.Store(math.Float64bits(1))
+
+	// Fail early when the initial target cannot be determined
+	if opts.Percent > 0 {
+		c.percent.resolve(opts.Monitor, c.parent(), opts.Percent, 0)
+	}
+	if _, err := c.targetSize(); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	switch {
+	case opts.Percent > 0:
+		opts.Recorder.Logf("Storage", "Starting dynamic load generation with %.1f%% of free disk space", opts.Percent)
+	case opts.Target != nil:
+		opts.Recorder.Logf("Storage", "Starting variable load generation")
+	default:
+		opts.Recorder.Logf("Storage", "Starting load generation with %d MiB", opts.Size/(1024*1024))
+	}
+	if opts.Verify {
+		opts.Recorder.Logf("Storage", "Writing checksummed blocks and verifying them on every read")
+	}
+	if netfs != "" {
+		opts.Recorder.Logf("Storage", "%s is on %s: adding stat, lock and small synchronous write operations", dir, netfs)
+		if opts.Percent > 0 {
+			// The server may share its free space with other exports or report it lazily
+			opts.Recorder.Logf("Storage", "Warning: free space reported by %s may be shared or stale, so the percentage target may be inaccurate", netfs)
+			opts.Recorder.Flag("Storage", i18n.Sprintf("percentage of free space on %s", netfs))
+		}
+	} else if opts.NetworkMix {
+		opts.Recorder.Logf("Storage", "Adding stat, lock and small synchronous write operations")
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.group, ctx = supervise.WithContext(ctx)
+	if opts.Percent > 0 {
+		c.follow(ctx, opts.Monitor)
+	}
+	c.group.Go(func() {
+		defer close(c.stopped)
+		defer c.quota.close()
+		defer cleanup()
+		c.err = c.run(ctx)
+		opts.Recorder.Logf("Storage", "Storage load generation completed")
+	})
+	return c, nil
+}
+
+// SetTarget は目標ディスク使用量（バイト）を変更します。
+// 以降は Options で指定したサイズ・パーセンテージ・関数の代わりにこの値を使用します。
+func (c *Controller) SetTarget(bytes int64) {
+	c.override.Store(max(bytes, 0))
+	c.notify()
+}
+
+// SetScale は Options で指定した目標ディスク使用量に掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で目標を指定している場合は影響しません。
+func (c *Controller) SetScale(factor float64) {
+	c.scale.Store(math.Float64bits(max(factor, 0)))
+	c.notify()
+}
+
+// Pause はストレス用ファイルをすべて削除し、Resume が呼ばれるまで読み書きを止めます。
+func (c *Controller) Pause() {
+	c.paused.Store(true)
+	c.notify()
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *Controller) Resume() {
+	c.paused.Store(false)
+	c.notify()
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *Controller) Stop() {
+	c.cancel()
+}
+
+// Wait は負荷が終了し一時ファイルが削除されるまで待ち、結果を返します。
+func (c *Controller) Wait() (Result, error) {
+	c.group.Wait()
+	return Result{PeakBytes: c.quota.peak.Load(), Scan: c.scan, IOPS: c.iops, BytesPerSecond: c.throughput}, c.err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *Controller) Stats() Stats {
+	return Stats{
+		Target: c.target.Load(),
+		Used:   c.used.Load(),
+		Paused: c.paused.Load(),
+		Dir:    c.dir,
+	}
+}
+
+// percentTarget holds the disk usage a percentage of free disk space resolved
+// to, or the error resolving it.
+type percentTarget struct {
+	mu   sync.Mutex
+	size int64
+	err  error
+}
+
+// resolve resolves percent against the free space m reports for the filesystem holding dir.
+func (p *percentTarget) resolve(m *monitor.Monitor, dir string, percent float64, used int64) {
+	size, err := calculatePercentageSize(m, dir, percent, used)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size, p.err = size, err
+}
+
+func (p *percentTarget) get() (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size, p.err
+}
+
+// parent returns the directory the temporary directory is in, whose free space
+// Options.Percent is resolved against. It is on the same filesystem and, unlike
+// the temporary directory, may be watched by the monitor for other loads too.
+func (c *Controller) parent() string {
+	return filepath.Dir(c.dir)
+}
+
+// follow resolves Options.Percent again on every sample of m, sampling with a
+// monitor of its own when m is nil, and brings the stress files in line with it.
+func (c *Controller) follow(ctx context.Context, m *monitor.Monitor) {
+	if m == nil {
+		m = monitor.New(monitor.DefaultInterval)
+		c.group.Go(func() { m.Run(ctx) })
+	}
+	parent := c.parent()
+	unwatch := m.Watch(parent)
+	samples := m.Subscribe(ctx)
+	c.group.Go(func() {
+		defer unwatch()
+		for range samples {
+			c.percent.resolve(m, parent, c.opts.Percent, c.used.Load())
+			c.notify()
+		}
+	})
+}
+
+// ioSize returns the number of bytes read or written by one system call.
+func (c *Controller) ioSize() int {
+	if c.opts.BlockSize > 0 {
+		return c.opts.BlockSize
+	}
+	return defaultIOSize
+}
+
+// notify wakes the adjustment loop so that a change takes effect immediately.
+func (c *Controller) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// targetSize returns the number of bytes the stress files should currently hold.
+func (c *Controller) targetSize() (int64, error) {
+	if c.paused.Load() {
+		return 0, nil
+	}
+	if override := c.override.Load(); override >= 0 {
+		return override, nil
+	}
+	var size int64
+	var err error
+	switch {
+	case c.opts.Percent > 0:
+		size, err = c.percent.get()
+	case c.opts.Target != nil:
+		size = max(c.opts.Target(), 0)
+	default:
+		size = c.opts.Size
+	}
+	if err != nil {
+		return 0, err
+	}
+	return int64(float64(size) * math.Float64frombits(c.scale.Load())), nil
+}
@@ -0,0 +1,6 @@
+//go:build !linux && !darwin && !netbsd && !openbsd
+
+package storage
+
+// dsyncFlag is 0 where the syscall package has no O_DSYNC.
+const dsyncFlag = 0
@@ -0,0 +1,8 @@
+//go:build linux || darwin || netbsd || openbsd
+
+package storage
+
+import "syscall"
+
+// dsyncFlag is the O_DSYNC flag of open(2).
+const dsyncFlag = syscall.O_DSYNC
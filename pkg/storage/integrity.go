@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"io"
+	"sync"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// unitLocks is the number of locks that keep the I/O workers from reading or
+// writing the same I/O unit at once in verify mode; units share them by hash.
+const unitLocks = 256
+
+// integrity is what verify mode knows about the stress files beyond what their
+// blocks say: the write that last rewrote each block, the blocks corrupted on
+// purpose by CorruptBlock, and the bad blocks already reported.
+type integrity struct {
+	mu       sync.Mutex
+	sequence uint64
+	// rewritten holds the sequence number of every block of a file written
+	// again since the file was created; other blocks hold write 0
+	rewritten map[string]map[uint64]uint64
+	injected  map[string]map[uint64]bool
+	reported  map[string]map[uint64]bool
+	units     [unitLocks]sync.Mutex
+}
+
+func newIntegrity() *integrity {
+	return &integrity{
+		rewritten: make(map[string]map[uint64]uint64),
+		injected:  make(map[string]map[uint64]bool),
+		reported:  make(map[string]map[uint64]bool),
+	}
+}
+
+// unit returns the lock of the I/O unit numbered unit in f.
+func (in *integrity) unit(f stressFile, unit int64) *sync.Mutex {
+	return &in.units[(uint64(f.id)*31+uint64(unit))%unitLocks]
+}
+
+// nextSequence returns the number of a new write.
+func (in *integrity) nextSequence() uint64 {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.sequence++
+	return in.sequence
+}
+
+// rewrite records that count blocks of f from first were written by the write
+// numbered sequence, which also overwrote any injected corruption there.
+func (in *integrity) rewrite(f stressFile, first, count, sequence uint64) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	blocks := in.rewritten[f.path]
+	if blocks == nil {
+		blocks = make(map[uint64]uint64)
+		in.rewritten[f.path] = blocks
+	}
+	for index := first; index < first+count; index++ {
+		blocks[index] = sequence
+		delete(in.injected[f.path], index)
+	}
+}
+
+// expected returns a function that gives the sequence number of the write that
+// a block of f should hold.
+func (in *integrity) expected(f stressFile) func(index uint64) uint64 {
+	return func(index uint64) uint64 {
+		in.mu.Lock()
+		defer in.mu.Unlock()
+		return in.rewritten[f.path][index]
+	}
+}
+
+// inject records that the block at index of f was corrupted on purpose.
+func (in *integrity) inject(f stressFile, index uint64) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.injected[f.path] == nil {
+		in.injected[f.path] = make(map[uint64]bool)
+	}
+	in.injected[f.path][index] = true
+}
+
+// detected reports whether the bad block at index of f was corrupted on
+// purpose, and forgets it.
+func (in *integrity) detected(f stressFile, index uint64) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if !in.injected[f.path][index] {
+		return false
+	}
+	delete(in.injected[f.path], index)
+	return true
+}
+
+// report reports whether the bad block at index of f is found for the first
+// time, so that a block that stays bad is counted once however often it is read.
+func (in *integrity) report(f stressFile, index uint64) bool {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.reported[f.path][index] {
+		return false
+	}
+	if in.reported[f.path] == nil {
+		in.reported[f.path] = make(map[uint64]bool)
+	}
+	in.reported[f.path][index] = true
+	return true
+}
+
+// forget drops what is known about f when it is deleted.
+func (in *integrity) forget(f stressFile) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	delete(in.rewritten, f.path)
+	delete(in.injected, f.path)
+	delete(in.reported, f.path)
+}
+
+// triage repairs the blocks of bad that were corrupted on purpose, with data
+// from data, and returns the details of the others and of those among them
+// found for the first time, which are counted as corrupt blocks.
+func (c *Controller) triage(f stressFile, bad []badBlock, data io.Reader) (all, fresh []string) {
+	recorder := c.opts.Recorder
+	for _, b := range bad {
+		if c.integrity.detected(f, b.index) {
+			recoverCorruption(recorder, data, f, b.index, c.integrity.expected(f)(b.index))
+			continue
+		}
+		all = append(all, b.detail)
+		if c.integrity.report(f, b.index) {
+			fresh = append(fresh, b.detail)
+			recorder.AddCount("Storage", "corrupt_blocks", 1)
+		}
+	}
+	return all, fresh
+}
+
+// logBadBlocks logs the bad blocks of f found for the first time by a read.
+func logBadBlocks(recorder *metrics.Recorder, f stressFile, fresh []string) {
+	if len(fresh) > 0 {
+		recorder.Logf("Storage", "Verification error: %s", fresh[0])
+	}
+	if len(fresh) > 1 {
+		recorder.Logf("Storage", "%d more bad blocks in %s", len(fresh)-1, f.path)
+	}
+}
@@ -0,0 +1,279 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/internal/timing"
+)
+
+// IOMode はストレス用ファイルを書き終えた後に続ける読み書きの方式です。
+// 空の場合は一定間隔でファイルを1つずつ読み直して追記します。
+type IOMode string
+
+const (
+	// IOSeqWrite はファイルを先頭から順に上書きし続けます。
+	IOSeqWrite IOMode = "seqwrite"
+	// IOSeqRead はファイルを先頭から順に読み続けます。
+	IOSeqRead IOMode = "seqread"
+	// IORandRead はファイルのランダムな位置を pread で読み続けます。
+	IORandRead IOMode = "randread"
+	// IORandWrite はファイルのランダムな位置を pwrite で上書きし続けます。
+	IORandWrite IOMode = "randwrite"
+	// IOMixed はファイルのランダムな位置の読み込みと上書きを半分ずつ行います。
+	IOMixed IOMode = "mixed"
+)
+
+// IOModes はすべての読み書きの方式です。
+var IOModes = []IOMode{IOSeqWrite, IOSeqRead, IORandRead, IORandWrite, IOMixed}
+
+// ioRound is how long a worker keeps one stress file open before it picks the
+// next one, so that files deleted when the target shrinks are let go promptly.
+const ioRound = time.Second
+
+// ioRetryInterval is how long a worker waits after an I/O error or while there
+// are no stress files to work on.
+const ioRetryInterval = 100 * time.Millisecond
+
+// Validate は読み書きの方式が有効かどうかを検証します。空の場合は一定間隔の読み込みと追記とみなします。
+func (m IOMode) Validate() error {
+	if m != "" && !slices.Contains(IOModes, m) {
+		return fmt.Errorf("unknown I/O mode %q (seqwrite, seqread, randread, randwrite or mixed)", m)
+	}
+	return nil
+}
+
+// sequential reports whether the mode walks through the files from the start.
+func (m IOMode) sequential() bool {
+	return m == IOSeqWrite || m == IOSeqRead
+}
+
+// write reports whether the next operation of the mode writes, drawing from rng
+// for the mixed mode.
+func (m IOMode) write(rng *rand.Rand) bool {
+	switch m {
+	case IOSeqWrite, IORandWrite:
+		return true
+	case IOMixed:
+		return rng.IntN(2) == 0
+	default:
+		return false
+	}
+}
+
+// ioCounters are the totals of the I/O workers, read by the run loop for the
+// achieved IOPS and throughput.
+type ioCounters struct {
+	ops     int64
+	read    int64
+	written int64
+}
+
+// publish makes the stress files that have been written completely available
+// to the I/O workers.
+func (c *Controller) publish(files []stressFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = slices.Clone(files)
+}
+
+// activeFiles returns the stress files the I/O workers may read and write.
+func (c *Controller) activeFiles() []stressFile {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.files
+}
+
+// ioTotals returns the totals of the I/O workers so far.
+func (c *Controller) ioTotals() ioCounters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.io
+}
+
+// startIO starts the I/O workers of Options.IOMode and returns a function that
+// waits for them to stop after ctx is done.
+func (c *Controller) startIO(ctx context.Context) func() {
+	if c.opts.IOMode == "" {
+		return func() {}
+	}
+	depth := cmp.Or(c.opts.IODepth, 1)
+	c.opts.Recorder.Logf("Storage", "Running %s I/O of %d bytes on %d workers", c.opts.IOMode, c.ioSize(), depth)
+	if c.opts.FsyncEvery > 0 {
+		c.opts.Recorder.Logf("Storage", "Calling fsync after every %d writes", c.opts.FsyncEvery)
+	}
+	var wg sync.WaitGroup
+	for i := range depth {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.ioWorker(ctx, i)
+		}()
+	}
+	return wg.Wait
+}
+
+// ioWorker keeps reading or writing the stress files in the I/O mode until ctx
+// is done. Sequential workers start at different files and carry on where they
+// left off in a file; random workers pick a random file every round.
+func (c *Controller) ioWorker(ctx context.Context, id int) {
+	seed := c.opts.Seed
+	if seed != 0 {
+		seed += uint64(id) + 1
+	}
+	data := newDataSource(seed)
+	rng := rand.New(data)
+	buffer := make([]byte, c.ioSize())
+
+	var current stressFile
+	var offset int64
+	next := id
+	for ctx.Err() == nil {
+		files := c.activeFiles()
+		if len(files) == 0 {
+			timing.Sleep(ctx, ioRetryInterval)
+			continue
+		}
+		switch {
+		case !c.opts.IOMode.sequential():
+			current = files[rng.IntN(len(files))]
+		case offset == 0 || !slices.Contains(files, current):
+			current, offset = files[next%len(files)], 0
+			next++
+		}
+		var err error
+		offset, err = c.ioRoundOn(ctx, current, offset, buffer, data, rng)
+		if err != nil && ctx.Err() == nil {
+			c.opts.Recorder.Logf("Storage", "I/O error: %v", err)
+			flagWriteError(c.opts.Recorder, c.quota, err)
+			offset = 0
+			timing.Sleep(ctx, ioRetryInterval)
+		}
+	}
+}
+
+// ioRoundOn reads or writes f for up to ioRound. Sequential modes start at
+// offset and return where the next round continues, or 0 at the end of the file.
+func (c *Controller) ioRoundOn(ctx context.Context, f stressFile, offset int64, buffer []byte, data *rand.ChaCha8, rng *rand.Rand) (int64, error) {
+	recorder, mode := c.opts.Recorder, c.opts.IOMode
+	file, err := os.OpenFile(f.path, os.O_RDWR|c.opts.Sync.openFlag(), 0)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := int64(len(buffer))
+	blocks := info.Size() / size
+	if blocks == 0 {
+		return 0, nil
+	}
+
+	var writes int
+	end := time.Now().Add(ioRound)
+	for ctx.Err() == nil && time.Now().Before(end) {
+		at := offset
+		if !mode.sequential() {
+			at = rng.Int64N(blocks) * size
+		}
+		write := mode.write(rng)
+		n, err := c.ioOperation(file, f, buffer, at, write, data)
+		c.countIO(write, n)
+		if err != nil {
+			return 0, err
+		}
+
+		if write {
+			writes++
+			if c.opts.FsyncEvery > 0 && writes%c.opts.FsyncEvery == 0 {
+				if err := timeOperation(recorder, "fsync", file.Sync); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if mode.sequential() {
+			offset += size
+			if offset+size > info.Size() {
+				return 0, nil
+			}
+		}
+	}
+	return offset, nil
+}
+
+// ioOperation writes buffer, filled from data, to f at offset, or reads it from
+// there, and returns the number of bytes moved. In verify mode the I/O unit is
+// locked meanwhile, writes are numbered and recorded, and the blocks read are
+// checked.
+func (c *Controller) ioOperation(file *os.File, f stressFile, buffer []byte, offset int64, write bool, data *rand.ChaCha8) (int, error) {
+	recorder, verify := c.opts.Recorder, c.opts.Verify
+	var sequence uint64
+	if verify {
+		unit := c.integrity.unit(f, offset/int64(len(buffer)))
+		unit.Lock()
+		defer unit.Unlock()
+		if write {
+			sequence = c.integrity.nextSequence()
+		}
+	}
+	if write {
+		if err := fillData(buffer, data, f, offset, sequence, verify); err != nil {
+			return 0, err
+		}
+	}
+
+	operation := "read"
+	start := time.Now()
+	var n int
+	var err error
+	if write {
+		operation = "write"
+		n, err = file.WriteAt(buffer, offset)
+	} else {
+		n, err = file.ReadAt(buffer, offset)
+	}
+	recorder.ObserveLatency("Storage", operation, time.Since(start))
+	if !verify {
+		return n, err
+	}
+
+	first, blocks := uint64(offset/blockSize), uint64(len(buffer)/blockSize)
+	if write {
+		if err != nil {
+			// Part of the unit may hold the new data and part the old
+			sequence = unknownSequence
+		}
+		c.integrity.rewrite(f, first, blocks, sequence)
+		return n, err
+	}
+	if err == nil {
+		bad := checkBlocks(buffer[:n], f, first, c.integrity.expected(f))
+		_, fresh := c.triage(f, bad, data)
+		recorder.RecordVerification("Storage", int64(blocks), fresh...)
+		logBadBlocks(recorder, f, fresh)
+	}
+	return n, err
+}
+
+// countIO adds one operation that moved n bytes to the totals.
+func (c *Controller) countIO(write bool, n int) {
+	name := "bytes_read"
+	c.mu.Lock()
+	c.io.ops++
+	if write {
+		name = "bytes_written"
+		c.io.written += int64(n)
+	} else {
+		c.io.read += int64(n)
+	}
+	c.mu.Unlock()
+	c.opts.Recorder.AddCount("Storage", name, int64(n))
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// runIO runs a small storage load in mode until its I/O workers have done at
+// least ops operations, and returns the controller after it has stopped.
+func runIO(t *testing.T, opts Options, ops int64) *Controller {
+	t.Helper()
+	opts.Size = 1024 * 1024
+	opts.Files = 2
+	opts.Dir = t.TempDir()
+	opts.Seed = 1
+	c, err := Start(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for c.ioTotals().ops < ops && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Stop()
+	if _, err := c.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.ioTotals().ops; got < ops {
+		t.Fatalf("%s: %d operations in 10s, want at least %d", opts.IOMode, got, ops)
+	}
+	return c
+}
+
+func TestIOModes(t *testing.T) {
+	tests := []struct {
+		mode          IOMode
+		reads, writes bool
+	}{
+		{IOSeqWrite, false, true},
+		{IOSeqRead, true, false},
+		{IORandRead, true, false},
+		{IORandWrite, false, true},
+		{IOMixed, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			c := runIO(t, Options{IOMode: tt.mode, IODepth: 2, BlockSize: 4096}, 64)
+			totals := c.ioTotals()
+			if (totals.read > 0) != tt.reads || (totals.written > 0) != tt.writes {
+				t.Errorf("read %d and wrote %d bytes, want reads=%v writes=%v", totals.read, totals.written, tt.reads, tt.writes)
+			}
+			// Every operation moves one whole block of the 512 KiB files
+			if got, want := totals.read+totals.written, totals.ops*4096; got != want {
+				t.Errorf("moved %d bytes in %d operations, want %d", got, totals.ops, want)
+			}
+		})
+	}
+}
+
+func TestIOModeFsyncEvery(t *testing.T) {
+	recorder := metrics.NewRecorder()
+	c := runIO(t, Options{IOMode: IORandWrite, FsyncEvery: 4, BlockSize: 4096, Recorder: recorder}, 64)
+	writes := c.ioTotals().written / 4096
+	fsyncs := recorder.Latencies()["Storage fsync"].Count
+	if fsyncs == 0 || fsyncs > writes/4 {
+		t.Errorf("%d fsync calls after %d writes, want 1 to %d", fsyncs, writes, writes/4)
+	}
+	if got := recorder.Counts()["Storage"]["bytes_written"]; got < c.ioTotals().written {
+		t.Errorf("bytes_written = %d, want at least the %d bytes of the I/O workers", got, c.ioTotals().written)
+	}
+}
+
+func TestIOModeValidate(t *testing.T) {
+	for _, mode := range append([]IOMode{""}, IOModes...) {
+		if err := mode.Validate(); err != nil {
+			t.Errorf("IOMode(%q).Validate() = %v, want nil", mode, err)
+		}
+	}
+	if err := IOMode("append").Validate(); err == nil {
+		t.Errorf(`IOMode("append").Validate() = nil, want an error`)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"syscall"
+)
+
+// errLockUnsupported is returned by lockRange where byte-range locks are not available.
+var errLockUnsupported = errors.New("byte-range locks are not supported")
+
+// lockRange takes and releases an exclusive POSIX lock on length bytes of file
+// from offset, which on NFS goes to the lock manager and on SMB to the server.
+func lockRange(file *os.File, offset, length int64) error {
+	lock := syscall.Flock_t{Type: syscall.F_WRLCK, Whence: io.SeekStart, Start: offset, Len: length}
+	if err := syscall.FcntlFlock(file.Fd(), syscall.F_SETLKW, &lock); err != nil {
+		return err
+	}
+	lock.Type = syscall.F_UNLCK
+	return syscall.FcntlFlock(file.Fd(), syscall.F_SETLK, &lock)
+}
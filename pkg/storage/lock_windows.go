@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+// errLockUnsupported is returned by lockRange where byte-range locks are not available.
+var errLockUnsupported = errors.New("byte-range locks are not supported")
+
+// lockRange is not implemented on Windows, where the syscall package lacks LockFileEx.
+func lockRange(file *os.File, offset, length int64) error {
+	return errLockUnsupported
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Sizes and counts of the operations that the network mix adds on every tick.
+const (
+	statRounds      = 16         // times every stress file is stat'ed, and the directory listed
+	lockRounds      = 8          // lock and unlock round trips
+	smallSyncWrites = 8          // small writes, each followed by an fsync
+	smallWriteSize  = 4 * 1024   // size of one small write
+	smallFileSize   = 256 * 1024 // the small writes cycle through a file of this size
+)
+
+// networkFilesystems names the filesystems served over the network or by a
+// userspace daemon, for which the network mix is added.
+var networkFilesystems = map[string]string{
+	"nfs":  "NFS",
+	"cifs": "SMB",
+	"smb2": "SMB",
+	"ceph": "CephFS",
+	"fuse": "FUSE",
+}
+
+// NetworkFilesystem は dir がネットワークファイルシステム (NFS、SMB、CephFS) または FUSE 上にある場合にその名前を返します。
+// ローカルのファイルシステムの場合や判定できない場合は空文字列を返します。
+//
+// 引数:
+//
+//	dir - 調べるディレクトリ
+func NetworkFilesystem(dir string) string {
+	fs, err := sysinfo.FilesystemType(dir)
+	if err != nil {
+		return ""
+	}
+	return networkFilesystems[fs]
+}
+
+// networkMix runs the operations that dominate the load on a network
+// filesystem but are cheap on a local disk: attribute lookups, which are
+// server round trips once the attribute cache expires, byte-range locks, which
+// go to the lock manager, and small synchronous writes, each of which waits for
+// the server to commit it.
+type networkMix struct {
+	recorder *metrics.Recorder
+	quota    *quota
+	data     io.Reader
+	small    *os.File
+	size     int64 // bytes of the small file written so far
+	offset   int64 // where the next small write goes
+	buffer   []byte
+}
+
+// newNetworkMix creates the file for the small synchronous writes in dir.
+func newNetworkMix(dir string, recorder *metrics.Recorder, q *quota, data io.Reader) (*networkMix, error) {
+	small, err := os.OpenFile(filepath.Join(dir, "small-sync.dat"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the file for small writes: %v", err)
+	}
+	return &networkMix{recorder: recorder, quota: q, data: data, small: small, buffer: make([]byte, smallWriteSize)}, nil
+}
+
+// run performs one round of the mix against the stress files in dir.
+func (m *networkMix) run(dir string, files []stressFile) {
+	failed := 0
+	for range statRounds {
+		if timeOperation(m.recorder, "stat", func() error {
+			_, err := os.ReadDir(dir)
+			return err
+		}) != nil {
+			failed++
+		}
+		for _, f := range files {
+			if timeOperation(m.recorder, "stat", func() error {
+				_, err := os.Stat(f.path)
+				return err
+			}) != nil {
+				failed++
+			}
+		}
+	}
+	m.recorder.AddCount("Storage", "stat_ops", int64(statRounds*(len(files)+1)))
+
+	for range lockRounds {
+		if err := timeOperation(m.recorder, "lock", func() error { return lockRange(m.small, 0, smallWriteSize) }); err != nil {
+			if err == errLockUnsupported {
+				break
+			}
+			failed++
+		}
+		m.recorder.AddCount("Storage", "lock_ops", 1)
+	}
+
+	for range smallSyncWrites {
+		if m.offset >= m.size {
+			// The small file grows to its full size once and is then rewritten
+			if granted := m.quota.reserve(smallWriteSize); granted < smallWriteSize {
+				m.quota.release(granted)
+				flagWriteError(m.recorder, m.quota, errDiskLimit)
+				break
+			}
+			m.size += smallWriteSize
+		}
+		m.data.Read(m.buffer)
+		if timeOperation(m.recorder, "small sync write", func() error {
+			if _, err := m.small.WriteAt(m.buffer, m.offset); err != nil {
+				return err
+			}
+			return m.small.Sync()
+		}) != nil {
+			failed++
+		}
+		m.recorder.AddCount("Storage", "small_sync_writes", 1)
+		m.offset = (m.offset + smallWriteSize) % smallFileSize
+	}
+
+	if failed > 0 {
+		m.recorder.Logf("Storage", "%d metadata, lock or small write operations failed", failed)
+		m.recorder.AddCount("Storage", "errors", int64(failed))
+	}
+}
+
+// close closes the small file; it is removed with the temporary directory.
+func (m *networkMix) close() {
+	m.small.Close()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+)
+
+// errDiskLimit is returned when a write would exceed Options.MaxBytes or the
+// shared Options.Budget.
+var errDiskLimit = errors.New("hard disk usage limit reached")
+
+// quota tracks the bytes held in the stress files of one stressor against its
+// optional hard cap and the budget shared by all stressors of the run.
+type quota struct {
+	limit  int64          // 0 means unlimited
+	shared *budget.Budget // nil means unlimited
+	usage  atomic.Int64
+	peak   atomic.Int64
+	// capped is the limit that last cut a reservation short, for the flag
+	capped atomic.Int64
+}
+
+// reserve claims up to n bytes of the remaining budget and returns the granted amount.
+func (q *quota) reserve(n int64) int64 {
+	var granted int64
+	for {
+		used := q.usage.Load()
+		granted = n
+		if q.limit > 0 {
+			granted = min(n, max(q.limit-used, 0))
+		}
+		if q.usage.CompareAndSwap(used, used+granted) {
+			break
+		}
+	}
+	if granted < n {
+		q.capped.Store(q.limit)
+	}
+	if shared := q.shared.Reserve(granted); shared < granted {
+		q.usage.Add(shared - granted)
+		q.capped.Store(q.shared.Limit())
+		granted = shared
+	}
+	q.updatePeak(q.usage.Load())
+	return granted
+}
+
+// updatePeak raises the recorded peak usage to at least used.
+func (q *quota) updatePeak(used int64) {
+	for {
+		peak := q.peak.Load()
+		if used <= peak || q.peak.CompareAndSwap(peak, used) {
+			return
+		}
+	}
+}
+
+// release returns bytes to the budget after stress data has been deleted or not written.
+func (q *quota) release(n int64) {
+	q.usage.Add(-n)
+	q.shared.Release(n)
+}
+
+// close returns all bytes still held to the shared budget once the stress
+// files have been removed.
+func (q *quota) close() {
+	q.shared.Release(q.usage.Swap(0))
+}
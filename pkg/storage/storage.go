@@ -1,308 +1,662 @@
 package storage
 
 import (
+	"cmp"
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"syscall"
 	"time"
+
+	"github.com/utkamioka/stress-go/pkg/budget"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+	"github.com/utkamioka/stress-go/pkg/tempdirs"
 )
 
-// GenerateLoad は指定されたストレージサイズで負荷を生成します。
+// adjustInterval is how often the stress files are brought in line with the target
+// and read from and appended to.
+const adjustInterval = 2 * time.Second
+
+// maxFileSize is the largest stress file. Data is written and deleted in files of at
+// most this size so that lowering the target frees space promptly.
+const maxFileSize = 64 * 1024 * 1024
+
+// defaultIOSize is the number of bytes read or written by one system call when
+// Options.BlockSize is 0.
+const defaultIOSize = 64 * 1024
+
+// appendSize is the amount of data appended to a stress file on every tick.
+const appendSize = 256 * 1024
+
+// maxScanReport is the number of bad blocks the final integrity scan logs one by one.
+const maxScanReport = 20
+
+// Options はストレージ負荷の設定です。Size・Percent・Target のいずれか1つを指定します。
+type Options struct {
+	// Name は進行状況の表示や指標の記録に使用する名前です。空の場合は "Storage" を使用します。
+	// 同じ種類の負荷を複数のジョブとして実行する場合に、ジョブを区別するために指定します。
+	Name string
+	// Size は書き込むデータサイズ（バイト）です。
+	Size int64
+	// Percent は空きディスク容量に対するパーセンテージです。空き容量の変化に合わせて書き込み量を調整します。
+	Percent float64
+	// Monitor は Percent の書き込み量の計算に使用する空きディスク容量の測定値の提供元です (nil可)。
+	// 測定のたびに書き込み量を計算し直します。nil の場合は負荷が自分で測定します。
+	Monitor *monitor.Monitor
+	// Target は目標ディスク使用量（バイト）を返す関数です。調整のたびに呼び出されます。
+	Target func() int64
+	// Dir は一時ディレクトリを作成するディレクトリです。空の場合はOSの一時ディレクトリを使用します。
+	// ただしコンテナ内で一時ディレクトリが overlayfs 上にある場合は、マウントされたボリュームを使用します。
+	Dir string
+	// Files は目標サイズを分けて書き込むストレス用ファイルの数です。目標が増えた場合は同じ大きさの
+	// ファイルを追加するため、目標の変化に応じて数は前後します。0 の場合は 64MB ごとのファイルに分けます。
+	Files int
+	// BlockSize は1回の読み書きのシステムコールで扱うバイト数です。0 の場合は 64KB です。
+	// Verify の場合は検証用のブロック (4KB) の倍数である必要があります。
+	BlockSize int
+	// IOMode はファイルを書き終えた後に続ける読み書きの方式です。空の場合は一定間隔でファイルを
+	// 1つずつ読み直して追記します。指定した場合は IODepth 個のワーカーが BlockSize ごとの読み書きを
+	// 止まらずに続け、IOPS とスループットを記録します。Verify の場合は読み込んだブロックを検証し、
+	// 上書きしたブロックには書き込みの通し番号を記録して、古いデータが残っていないかも検証します。
+	IOMode IOMode
+	// IODepth は IOMode の読み書きを並行して行うワーカーの数 (キューの深さ) です。0 の場合は 1 です。
+	IODepth int
+	// FsyncEvery は IOMode の各ワーカーが何回の書き込みごとに fsync するかです。0 の場合は fsync しません。
+	FsyncEvery int
+	// MaxBytes はストレス用ファイルが占有するディスク容量の上限（バイト）です。目標サイズにかかわらず、
+	// この上限を超えて書き込むことはありません。0 の場合は制限しません。
+	MaxBytes int64
+	// Budget は実行全体の負荷生成モジュールで共有するディスク容量の上限です (nil可)。
+	// 書き込む前に予約し、ファイルを削除したときに返却するため、MaxBytes と同じく超えることはありません。
+	Budget *budget.Budget
+	// Verify はデータをチェックサム・ファイル番号・位置・書き込みの通し番号付きのブロックとして書き込み、
+	// 読み込みのたびに検証します。終了時には一時ファイルを削除する前に、すべてのファイルを読み直して検証します。
+	// 結果は Recorder に記録し、不正なブロックはブロックごとに一度だけ数えます (corrupt_blocks)。
+	Verify bool
+	// DropCaches は最初の書き込みが終わった後、読み込みを始める前にページキャッシュを破棄します (DropPageCache を参照)。
+	// 破棄できない場合はログに記録し、そのまま負荷を続けます。
+	DropCaches bool
+	// Sync は書き込んだデータをディスクに永続化する方法です。空の場合は SyncFsync です。
+	Sync SyncMode
+	// NetworkMix は大きなファイルの読み書きに加えて、ネットワークファイルシステムで負荷の中心となる操作
+	// (属性の取得とディレクトリの一覧、バイト範囲ロック、fsync 付きの小さな書き込み) を行います。
+	// 一時ディレクトリが NFS・SMB・FUSE などの上にある場合は (NetworkFilesystem を参照)、指定しなくても行います。
+	NetworkMix bool
+	// Seed は書き込むデータを生成する乱数のシードです。同じシードでは同じデータを書き込みます。
+	// 0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// Recorder は目標値と実測値を記録する Recorder です（nil可）。
+	Recorder *metrics.Recorder
+}
+
+// Result はストレージ負荷の実行結果です。
+type Result struct {
+	// PeakBytes は実行中にストレス用ファイルが占有したディスク容量の最大値（バイト）です。
+	PeakBytes int64
+	// Scan は Verify の場合に終了時に行ったすべてのファイルの検証の結果です。検証しなかった場合は nil です。
+	Scan *ScanResult
+	// IOPS と BytesPerSecond は IOMode の読み書きの実行中の平均です。IOMode が空の場合は 0 です。
+	IOPS           float64
+	BytesPerSecond float64
+}
+
+// ScanResult は終了時のすべてのストレス用ファイルの検証の結果です。
+type ScanResult struct {
+	// Files は検証したファイルの数です。
+	Files int
+	// Blocks は検証したブロックの数です。
+	Blocks int64
+	// BadBlocks は不一致だったブロックごとの内容 (ファイル、ブロック番号、オフセット、問題) です。
+	BadBlocks []string
+}
+
+// Passed は不一致のブロックがなかったかどうかを返します。
+func (s ScanResult) Passed() bool {
+	return len(s.BadBlocks) == 0
+}
+
+// GenerateLoad は opts に従ってストレージ負荷を生成し、ctx が終了するまで読み書きを続けます。
+// 作成した一時ファイルは終了時に削除されます。
 //
 // 引数:
 //
 //	ctx  - 負荷生成の制御に使用するコンテキスト
-//	size - 書き込むデータサイズ（バイト）。負の値の場合は空きディスク容量のパーセンテージとして解釈
-func GenerateLoad(ctx context.Context, size int64) {
-	// Create temporary directory
-	tempDir, err := os.MkdirTemp("", "stress-tool-storage-*")
+//	opts - 負荷の設定
+func GenerateLoad(ctx context.Context, opts Options) (Result, error) {
+	c, err := Start(ctx, opts)
 	if err != nil {
-		fmt.Printf("[Storage] Error: Failed to create temporary directory: %v\n", err)
-		return
-	}
-	defer func() {
-		os.RemoveAll(tempDir)
-		fmt.Printf("[Storage] Cleaned up temporary files\n")
-	}()
-
-	fmt.Printf("[Storage] Temporary directory: %s\n", tempDir)
-
-	if size < 0 {
-		// Percentage specification - use dynamic adjustment
-		percent := float64(-size)
-		fmt.Printf("[Storage] Starting dynamic load generation with %.1f%% of free disk space\n", percent)
-		if err := performDynamicStorageOperations(ctx, tempDir, percent); err != nil {
-			fmt.Printf("[Storage] Error: %v\n", err)
+		return Result{}, err
+	}
+	return c.Wait()
+}
+
+// Validate は目標サイズの指定方法 (Size・Percent・Target) がちょうど1つであることなど、オプションの値を検証します。
+func (opts Options) Validate() error {
+	specified := 0
+	for _, set := range []bool{opts.Size > 0, opts.Percent > 0, opts.Target != nil} {
+		if set {
+			specified++
 		}
-	} else {
-		// Absolute value specification - use static allocation
-		fmt.Printf("[Storage] Starting load generation with %d MB\n", size/(1024*1024))
-		if err := performStorageOperations(ctx, tempDir, size); err != nil {
-			fmt.Printf("[Storage] Error: %v\n", err)
+	}
+	if specified != 1 {
+		return fmt.Errorf("exactly one of size, percentage or target must be specified")
+	}
+	if opts.MaxBytes < 0 {
+		return fmt.Errorf("invalid disk limit: %d", opts.MaxBytes)
+	}
+	if opts.Files < 0 {
+		return fmt.Errorf("invalid number of files: %d", opts.Files)
+	}
+	if err := opts.IOMode.Validate(); err != nil {
+		return err
+	}
+	if opts.IODepth < 0 || opts.FsyncEvery < 0 {
+		return fmt.Errorf("invalid I/O depth or fsync interval: %d, %d", opts.IODepth, opts.FsyncEvery)
+	}
+	if opts.BlockSize < 0 || (opts.Verify && opts.BlockSize%blockSize != 0) {
+		return fmt.Errorf("block size must be a multiple of %d bytes to verify blocks: %d", blockSize, opts.BlockSize)
+	}
+	return opts.Sync.Validate()
+}
+
+// createTempDir creates the working directory for stress files under parent
+// (chosen by DefaultDir when empty) and returns a function that removes it.
+func createTempDir(parent string, recorder *metrics.Recorder) (string, func(), error) {
+	if parent == "" {
+		parent = DefaultDir(recorder)
+	}
+	tempDir, err := os.MkdirTemp(parent, "stress-tool-storage-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	tempdirs.Track(tempDir)
+	recorder.Logf("Storage", "Temporary directory: %s", tempDir)
+
+	cleanup := func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			recorder.Logf("Storage", "Failed to remove %s: %v", tempDir, err)
+			return
 		}
+		recorder.Logf("Storage", "Cleaned up temporary files")
 	}
+	return tempDir, cleanup, nil
+}
 
-	fmt.Printf("[Storage] Storage load generation completed\n")
+// DefaultDir は Options.Dir が空の場合に一時ディレクトリを作成するディレクトリを返します。
+// 通常はOSの一時ディレクトリですが、コンテナ内でそれがコンテナの書き込み層 (overlayfs) 上に
+// ある場合は、書き込み可能なマウント済みボリュームを返します。overlayfs への書き込みは
+// ディスクではなくストレージドライバーの性能を測ることになり、ノードのイメージ領域も消費するためです。
+//
+// 引数:
+//
+//	recorder - 選択の理由を記録する Recorder（nil可）
+func DefaultDir(recorder *metrics.Recorder) string {
+	tempDir := os.TempDir()
+	if !sysinfo.InContainer() {
+		return tempDir
+	}
+	if fs, err := sysinfo.FilesystemType(tempDir); err != nil || (fs != "overlay" && fs != "aufs") {
+		return tempDir
+	}
+	for _, dir := range append([]string{"/var/tmp"}, sysinfo.VolumeMounts()...) {
+		if fs, err := sysinfo.FilesystemType(dir); err != nil || fs == "overlay" || fs == "aufs" || fs == "tmpfs" {
+			continue
+		}
+		probe, err := os.MkdirTemp(dir, ".stress-go-probe-*")
+		if err != nil {
+			continue
+		}
+		os.Remove(probe)
+		recorder.Logf("Storage", "Container detected: %s is on overlayfs, using the volume at %s", tempDir, dir)
+		return dir
+	}
+	recorder.Logf("Storage", "Container detected: %s is on overlayfs and no writable volume was found; set TMPDIR to a mounted volume to test a real disk", tempDir)
+	recorder.Flag("Storage", "files written to the container's overlayfs layer")
+	return tempDir
 }
 
-// performStorageOperations はストレージの読み書き操作を実行します。
-func performStorageOperations(ctx context.Context, tempDir string, totalSize int64) error {
-	const chunkSize = 1024 * 1024 // 1MB chunks
-	const numFiles = 10           // 複数ファイルに分散
+// run keeps the total size of the stress files in line with the controller's target
+// until ctx is done, writing or deleting files on every tick and whenever the target is
+// changed, and reads and appends to the files to keep I/O going.
+func (c *Controller) run(ctx context.Context) error {
+	recorder, q := c.opts.Recorder, c.quota
+	var files []stressFile
+	var totalWritten int64
+	fileCounter := 0
+	operationCount := 0
+
+	var mix *networkMix
+	if c.opts.NetworkMix {
+		var err error
+		if mix, err = newNetworkMix(c.dir, recorder, q, c.data); err != nil {
+			return err
+		}
+		defer mix.close()
+	}
+
+	adjust := func(initial bool) error {
+		targetSize, err := c.targetSize()
+		if err != nil {
+			recorder.Logf("Storage", "Error recalculating size: %v", err)
+			recorder.Flag("Storage", err.Error())
+			return nil
+		}
+		c.target.Store(targetSize)
+
+		if targetSize > totalWritten {
+			fileSize := int64(maxFileSize)
+			if c.opts.Files > 0 {
+				fileSize = max((targetSize+int64(c.opts.Files)-1)/int64(c.opts.Files), 1)
+			}
+			// Need to write more data
+			var additionalSize int64
+			for totalWritten < targetSize {
+				f := stressFile{
+					path: filepath.Join(c.dir, fmt.Sprintf("stress-file-%d.dat", fileCounter)),
+					id:   uint32(fileCounter),
+				}
+				fileCounter++
+				written, err := writeFile(q, c.data, f, min(targetSize-totalWritten, fileSize), c.ioSize(), c.opts.Verify, c.opts.Sync)
+				if written > 0 {
+					// Keep partial files so the achieved size stays accurate
+					files = append(files, f)
+					totalWritten += written
+					additionalSize += written
+					recorder.AddCount("Storage", "bytes_written", written)
+				} else {
+					os.Remove(f.path)
+				}
+				if errors.Is(err, errDiskLimit) {
+					// Nothing more may be written; wait for the target to shrink
+					flagWriteError(recorder, q, err)
+					break
+				}
+				if err != nil {
+					flagWriteError(recorder, q, err)
+					if initial {
+						c.used.Store(totalWritten)
+						recorder.Record("Storage", metrics.UnitBytes, float64(targetSize), float64(totalWritten))
+						return fmt.Errorf("file write error: %v", err)
+					}
+					recorder.Logf("Storage", "Error writing additional file: %v", err)
+					break
+				}
+			}
+			if additionalSize > 0 {
+				recorder.Logf("Storage", "Increased disk usage by %d MiB (total: %d MiB)",
+					additionalSize/(1024*1024), totalWritten/(1024*1024))
+			}
+		} else if targetSize < totalWritten && len(files) > 0 {
+			// Need to delete some files
+			excessSize := totalWritten - targetSize
+			deletedSize := int64(0)
+
+			// Delete files from the end without dropping below the target
+			for i := len(files) - 1; i >= 0; i-- {
+				info, err := os.Stat(files[i].path)
+				if err != nil {
+					break
+				}
+				fileSize := info.Size()
+				if deletedSize+fileSize > excessSize {
+					break
+				}
+				if err := os.Remove(files[i].path); err != nil {
+					break
+				}
+				q.release(fileSize)
+				deletedSize += fileSize
+				totalWritten -= fileSize
+				c.integrity.forget(files[i])
+				files = files[:i]
+			}
 
-	fileSize := totalSize / numFiles
-	if fileSize < chunkSize {
-		fileSize = chunkSize
+			if deletedSize > 0 {
+				recorder.Logf("Storage", "Decreased disk usage by %d MiB (total: %d MiB)",
+					deletedSize/(1024*1024), totalWritten/(1024*1024))
+			}
+		}
+		c.used.Store(totalWritten)
+		c.publish(files)
+		return nil
 	}
 
-	// 複数ファイルを作成して書き込み
-	filePaths := make([]string, numFiles)
-	for i := 0; i < numFiles; i++ {
-		filePaths[i] = filepath.Join(tempDir, fmt.Sprintf("stress-file-%d.dat", i))
+	// Checks every block of f and records the result, returning all bad blocks
+	// and those found for the first time. Blocks corrupted on purpose by
+	// CorruptBlock are repaired instead of reported.
+	verify := func(f stressFile) (int64, []string, []string, error) {
+		checked, bad, err := verifyFile(f, c.ioSize(), c.integrity.expected(f))
+		errs, fresh := c.triage(f, bad, c.data)
+		recorder.RecordVerification("Storage", checked, fresh...)
+		return checked, errs, fresh, err
 	}
 
-	// 書き込みフェーズ
-	fmt.Printf("[Storage] Writing data to %d files...\n", numFiles)
-	for i, filePath := range filePaths {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
+	// Re-reads every stress file before they are deleted, so that data which went
+	// bad after its last read during the run is caught too
+	scan := func() {
+		recorder.Logf("Storage", "Final integrity scan of %d files...", len(files))
+		result := &ScanResult{Files: len(files)}
+		for _, f := range files {
+			checked, errs, _, err := verify(f)
+			result.Blocks += checked
+			result.BadBlocks = append(result.BadBlocks, errs...)
+			if err != nil {
+				recorder.Logf("Storage", "Read error: %v", err)
+				recorder.AddCount("Storage", "errors", 1)
+				result.BadBlocks = append(result.BadBlocks, i18n.Sprintf("%s: read error after block %d: %v", f.path, checked, err))
+				recorder.RecordVerification("Storage", 0, result.BadBlocks[len(result.BadBlocks)-1])
+			}
 		}
+		recorder.AddCount("Storage", "scan_blocks", result.Blocks)
+		recorder.AddCount("Storage", "scan_bad_blocks", int64(len(result.BadBlocks)))
+		c.scan = result
 
-		if err := writeFile(filePath, fileSize); err != nil {
-			return fmt.Errorf("file write error: %v", err)
+		if result.Passed() {
+			recorder.Logf("Storage", "Final integrity scan passed: %d blocks in %d files", result.Blocks, result.Files)
+			return
+		}
+		recorder.Logf("Storage", "Final integrity scan FAILED: %d bad of %d blocks in %d files",
+			len(result.BadBlocks), result.Blocks, result.Files)
+		for i, detail := range result.BadBlocks {
+			if i == maxScanReport {
+				recorder.Logf("Storage", "... and %d more bad blocks", len(result.BadBlocks)-i)
+				break
+			}
+			recorder.Logf("Storage", "Bad block: %s", detail)
 		}
-		fmt.Printf("[Storage] File write %d/%d completed\n", i+1, numFiles)
+		recorder.Flag("Storage", "final integrity scan found bad blocks")
 	}
 
-	// Continuous read/write operations
-	fmt.Printf("[Storage] Starting continuous read/write operations\n")
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+	// Reads and appends to one file per tick, cycling through the files
+	performIO := func() {
+		if len(files) == 0 {
+			return
+		}
+		f := files[operationCount%len(files)]
 
-	operationCount := 0
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case <-ticker.C:
-			// ランダムにファイルを選択して読み書き
-			fileIndex := operationCount % numFiles
-			filePath := filePaths[fileIndex]
-
-			// Read operation
-			if err := readFile(filePath); err != nil {
-				fmt.Printf("[Storage] Read error: %v\n", err)
+		// Read operation, checking every block in verify mode
+		read := func() error {
+			n, err := readFile(f.path, c.ioSize())
+			recorder.AddCount("Storage", "bytes_read", n)
+			return err
+		}
+		if c.opts.Verify {
+			read = func() error {
+				blocks, _, fresh, err := verify(f)
+				recorder.AddCount("Storage", "bytes_read", blocks*blockSize)
+				logBadBlocks(recorder, f, fresh)
+				return err
 			}
+		}
+		if err := timeOperation(recorder, "read", read); err != nil {
+			recorder.Logf("Storage", "Read error: %v", err)
+			recorder.AddCount("Storage", "errors", 1)
+		}
 
-			// Update partial data (append write)
-			if err := appendToFile(filePath, chunkSize/4); err != nil {
-				fmt.Printf("[Storage] Append error: %v\n", err)
+		// Update partial data (append write)
+		if err := timeOperation(recorder, "append", func() error { return appendToFile(q, c.data, f, appendSize, c.opts.Verify, c.opts.Sync) }); err != nil {
+			if !errors.Is(err, errDiskLimit) {
+				recorder.Logf("Storage", "Append error: %v", err)
 			}
+			flagWriteError(recorder, q, err)
+		} else {
+			totalWritten += appendSize
+			c.used.Store(totalWritten)
+			recorder.AddCount("Storage", "bytes_written", appendSize)
+		}
 
-			operationCount++
-			fmt.Printf("[Storage] I/O operation %d completed\n", operationCount)
+		if mix != nil {
+			mix.run(c.dir, files)
 		}
-	}
-}
 
-// performDynamicStorageOperations executes storage operations with dynamic size adjustment
-func performDynamicStorageOperations(ctx context.Context, tempDir string, percent float64) error {
-	var currentFiles []string
-	var totalWritten int64
-	fileCounter := 0
-	
-	// Check and adjust every 3 seconds
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
+		operationCount++
+		recorder.Logf("Storage", "I/O operation %d completed (%d files active)", operationCount, len(files))
+	}
 
-	// Initial calculation and file creation
-	targetSize, err := calculatePercentageSize(percent)
-	if err != nil {
+	if err := adjust(true); err != nil {
 		return err
 	}
-
-	if targetSize > 0 {
-		filePath := filepath.Join(tempDir, fmt.Sprintf("dynamic-stress-file-%d.dat", fileCounter))
-		if err := writeFile(filePath, targetSize); err != nil {
-			return fmt.Errorf("initial file write error: %v", err)
+	// Reads from here on should hit the device, not the pages just written
+	if c.opts.DropCaches && totalWritten > 0 {
+		if err := DropPageCache(); err != nil {
+			recorder.Logf("Storage", "Cannot drop the page cache after writing, reads may be served from it: %v", err)
+		} else {
+			recorder.Logf("Storage", "Dropped the page cache after writing %d MiB", totalWritten/(1024*1024))
 		}
-		currentFiles = append(currentFiles, filePath)
-		totalWritten = targetSize
-		fileCounter++
-		fmt.Printf("[Storage] Initial allocation: %d MB\n", targetSize/(1024*1024))
 	}
 
+	last, lastTime := c.ioTotals(), time.Now()
+	if c.opts.IOMode != "" {
+		started := lastTime
+		defer func() {
+			total, elapsed := c.ioTotals(), time.Since(started).Seconds()
+			c.iops = float64(total.ops) / elapsed
+			c.throughput = float64(total.read+total.written) / elapsed
+			recorder.Logf("Storage", "Achieved %.0f IOPS and %.1f MiB/s on average (%d MiB read, %d MiB written)",
+				c.iops, c.throughput/(1024*1024), total.read/(1024*1024), total.written/(1024*1024))
+		}()
+	}
+	// The workers stop before the averages are taken, the files are scanned and
+	// they are deleted
+	waitIO := c.startIO(ctx)
+	defer waitIO()
+	// Reports the IOPS and throughput of the I/O workers since the last tick
+	reportIO := func(now time.Time) {
+		total, elapsed := c.ioTotals(), now.Sub(lastTime).Seconds()
+		iops := float64(total.ops-last.ops) / elapsed
+		read := float64(total.read-last.read) / elapsed
+		written := float64(total.written-last.written) / elapsed
+		last, lastTime = total, now
+		recorder.RecordValue(cmp.Or(c.opts.Name, "Storage")+" throughput", metrics.UnitBytesPerSecond, read+written)
+		recorder.Logf("Storage", "%.0f IOPS, %.1f MiB/s read, %.1f MiB/s written (%d files active)",
+			iops, read/(1024*1024), written/(1024*1024), len(files))
+	}
+
+	ticker := time.NewTicker(adjustInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
+			if c.opts.Verify && len(files) > 0 {
+				waitIO()
+				scan()
+			}
 			return nil
-			
-		case <-ticker.C:
-			// Recalculate target size based on current free space
-			newTargetSize, err := calculatePercentageSize(percent)
-			if err != nil {
-				fmt.Printf("[Storage] Error recalculating size: %v\n", err)
+		case <-c.changed:
+			adjust(false)
+		case req := <-c.corrupts:
+			if len(files) == 0 {
+				req.reply <- corruptResult{err: fmt.Errorf("no stress files written")}
 				continue
 			}
-			
-			// Adjust disk usage if needed
-			if newTargetSize > totalWritten {
-				// Need to write more data
-				additionalSize := newTargetSize - totalWritten
-				if additionalSize > 0 {
-					filePath := filepath.Join(tempDir, fmt.Sprintf("dynamic-stress-file-%d.dat", fileCounter))
-					if err := writeFile(filePath, additionalSize); err != nil {
-						fmt.Printf("[Storage] Error writing additional file: %v\n", err)
-						continue
-					}
-					currentFiles = append(currentFiles, filePath)
-					totalWritten += additionalSize
-					fileCounter++
-					fmt.Printf("[Storage] Increased disk usage by %d MB (total: %d MB)\n", 
-						additionalSize/(1024*1024), totalWritten/(1024*1024))
-				}
-			} else if newTargetSize < totalWritten && len(currentFiles) > 1 {
-				// Need to delete some files
-				excessSize := totalWritten - newTargetSize
-				deletedSize := int64(0)
-				
-				// Delete files from the end
-				for i := len(currentFiles) - 1; i > 0 && deletedSize < excessSize; i-- {
-					filePath := currentFiles[i]
-					if info, err := os.Stat(filePath); err == nil {
-						fileSize := info.Size()
-						if err := os.Remove(filePath); err == nil {
-							deletedSize += fileSize
-							totalWritten -= fileSize
-							currentFiles = currentFiles[:i]
-						}
-					}
-				}
-				
-				if deletedSize > 0 {
-					fmt.Printf("[Storage] Decreased disk usage by %d MB (total: %d MB)\n", 
-						deletedSize/(1024*1024), totalWritten/(1024*1024))
-				}
-			}
-			
-			// Perform I/O operations on remaining files
-			if len(currentFiles) > 0 {
-				fileIndex := int(time.Now().Unix()) % len(currentFiles)
-				filePath := currentFiles[fileIndex]
-				
-				// Read operation
-				if err := readFile(filePath); err != nil {
-					fmt.Printf("[Storage] Read error: %v\n", err)
-				}
-				
-				// Light append operation to maintain activity
-				if err := appendToFile(filePath, 1024); err != nil {
-					fmt.Printf("[Storage] Append error: %v\n", err)
-				}
-				
-				fmt.Printf("[Storage] Dynamic I/O operation completed (%d files active)\n", len(currentFiles))
+			f := files[req.rng.IntN(len(files))]
+			index, err := corruptBlock(f, req.rng, c.ioSize(), c.integrity)
+			req.reply <- corruptResult{detail: i18n.Sprintf("%s block %d", f.path, index), err: err}
+		case now := <-ticker.C:
+			adjust(false)
+			if c.opts.IOMode != "" {
+				reportIO(now)
+			} else {
+				performIO()
 			}
+			recorder.Record("Storage", metrics.UnitBytes, float64(c.target.Load()), float64(totalWritten))
 		}
 	}
 }
 
-// writeFile は指定されたサイズのランダムデータを書き込みます。
-func writeFile(filePath string, size int64) error {
-	file, err := os.Create(filePath)
+// writeFile は指定されたサイズの data からのランダムデータを ioSize バイトずつ書き込み、実際に書き込んだバイト数を返します。
+// verify の場合は検証用のブロック単位で書き込みます。sync に従って書き込みを永続化します。
+func writeFile(q *quota, data io.Reader, f stressFile, size int64, ioSize int, verify bool, sync SyncMode) (int64, error) {
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC|sync.openFlag(), 0666)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer file.Close()
 
-	const bufferSize = 64 * 1024 // 64KB buffer
-	buffer := make([]byte, bufferSize)
+	buffer := make([]byte, ioSize)
+	if verify {
+		size = (size + blockSize - 1) / blockSize * blockSize
+	}
 
 	written := int64(0)
 	for written < size {
-		// ランダムデータを生成
-		if _, err := rand.Read(buffer); err != nil {
-			return err
+		writeSize := ioSize
+		if written+int64(ioSize) > size {
+			writeSize = int(size - written)
 		}
 
-		writeSize := bufferSize
-		if written+int64(bufferSize) > size {
-			writeSize = int(size - written)
+		granted := int(q.reserve(int64(writeSize)))
+		if verify {
+			// Only whole blocks can be checked
+			q.release(int64(granted % blockSize))
+			granted -= granted % blockSize
+		}
+		if granted == 0 {
+			return written, errDiskLimit
 		}
 
-		n, err := file.Write(buffer[:writeSize])
-		if err != nil {
-			return err
+		// ランダムデータを生成
+		if err := fillData(buffer[:granted], data, f, written, 0, verify); err != nil {
+			q.release(int64(granted))
+			return written, err
 		}
 
+		n, err := file.Write(buffer[:granted])
 		written += int64(n)
+		q.release(int64(granted - n))
+		if err != nil {
+			if partial := written % blockSize; verify && partial != 0 && file.Truncate(written-partial) == nil {
+				// Drop the incomplete block so that it is not reported as corrupt
+				written -= partial
+				q.release(partial)
+			}
+			return written, err
+		}
 	}
 
-	return file.Sync() // ディスクに強制書き込み
+	if sync.openFlag() != 0 {
+		// Every write has already waited for the disk
+		return written, nil
+	}
+	return written, file.Sync() // ディスクに強制書き込み
 }
 
-// readFile はファイルを読み取ります。
-func readFile(filePath string) error {
+// readFile はファイルを ioSize バイトずつ読み取り、読み取ったバイト数を返します。
+func readFile(filePath string, ioSize int) (int64, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	defer file.Close()
 
-	buffer := make([]byte, 64*1024)
-	
+	buffer := make([]byte, ioSize)
+
 	// ファイル全体を読み取り
+	var total int64
 	for {
 		n, err := file.Read(buffer)
+		total += int64(n)
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return err
+			return total, err
 		}
 		if n == 0 {
 			break
 		}
 	}
 
-	return nil
+	return total, nil
 }
 
-// appendToFile はファイルにデータを追記します。
-func appendToFile(filePath string, size int) error {
-	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644)
+// appendToFile はファイルにデータを追記します。verify の場合は検証用のブロックを追記します。
+// sync が O_DSYNC・O_SYNC の方式の場合は、追記が永続化されるまで待ちます。
+func appendToFile(q *quota, data io.Reader, f stressFile, size int, verify bool, sync SyncMode) error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|sync.openFlag(), 0644)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if granted := q.reserve(int64(size)); granted < int64(size) {
+		q.release(granted)
+		return errDiskLimit
+	}
+
 	buffer := make([]byte, size)
-	if _, err := rand.Read(buffer); err != nil {
+	if err := fillData(buffer, data, f, info.Size(), 0, verify); err != nil {
+		q.release(int64(size))
 		return err
 	}
 
-	_, err = file.Write(buffer)
+	n, err := file.Write(buffer)
+	q.release(int64(size - n))
 	return err
 }
 
-// calculatePercentageSize は空きディスク容量のパーセンテージから実際のサイズを計算します。
-func calculatePercentageSize(percent float64) (int64, error) {
-	// Get free space of current working directory
-	wd, err := os.Getwd()
-	if err != nil {
-		return 0, fmt.Errorf("failed to get working directory: %v", err)
+// timeOperation runs op and records its latency under the given operation name.
+func timeOperation(recorder *metrics.Recorder, operation string, op func() error) error {
+	start := time.Now()
+	err := op()
+	recorder.ObserveLatency("Storage", operation, time.Since(start))
+	return err
+}
+
+// flagWriteError counts a write failure and records it when the environment rather
+// than the tool caused it.
+func flagWriteError(recorder *metrics.Recorder, q *quota, err error) {
+	if !errors.Is(err, errDiskLimit) {
+		recorder.AddCount("Storage", "errors", 1)
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		recorder.Flag("Storage", "no space left on device (ENOSPC)")
 	}
+	if errors.Is(err, errDiskLimit) {
+		recorder.Flag("Storage", i18n.Sprintf("writes capped by hard disk limit (%d MiB)", q.capped.Load()/(1024*1024)))
+	}
+}
 
-	freeSpace, err := getDiskFreeSpace(wd)
+// ResolvePercent は dir を含むファイルシステムの空きディスク容量に対するパーセンテージ指定が、
+// 現時点で何バイトの負荷になるかを返します。
+//
+// 引数:
+//
+//	dir     - ストレージ負荷で使用するディレクトリ
+//	percent - 空きディスク容量に対するパーセンテージ
+func ResolvePercent(dir string, percent float64) (int64, error) {
+	return calculatePercentageSize(nil, dir, percent, 0)
+}
+
+// calculatePercentageSize は dir を含むファイルシステムの空きディスク容量のパーセンテージから実際のサイズを計算します。
+// 空きディスク容量は m が測定した空き容量に、ストレス用ファイルが書き込み済みの容量を加えたものです。
+//
+// 引数:
+//
+//	m       - 空きディスク容量の測定値の提供元 (nil の場合はシステムから直接読み取る)
+//	dir     - ストレージ負荷で使用するディレクトリ
+//	percent - 空きディスク容量に対するパーセンテージ
+//	used    - ストレス用ファイルが書き込み済みの容量（バイト）
+func calculatePercentageSize(m *monitor.Monitor, dir string, percent float64, used int64) (int64, error) {
+	disk, err := m.DiskSpace(dir)
 	if err != nil {
 		return 0, err
 	}
 
-	targetSize := int64(float64(freeSpace) * percent / 100.0)
+	// Space the stress files already take would be free without them
+	targetSize := int64(float64(disk.Available+used) * percent / 100.0)
 
 	// Use 90% of calculated size for safety
 	targetSize = int64(float64(targetSize) * 0.90)
@@ -312,4 +666,4 @@ func calculatePercentageSize(percent float64) (int64, error) {
 	}
 
 	return targetSize, nil
-}
\ No newline at end of file
+}
@@ -1,35 +0,0 @@
-package storage
-
-import (
-	"fmt"
-	"syscall"
-	"unsafe"
-)
-
-var (
-	kernel32         = syscall.NewLazyDLL("kernel32.dll")
-	getDiskFreeSpaceEx = kernel32.NewProc("GetDiskFreeSpaceExW")
-)
-
-// getDiskFreeSpace gets available disk space on Windows environment.
-func getDiskFreeSpace(path string) (int64, error) {
-	pathPtr, err := syscall.UTF16PtrFromString(path)
-	if err != nil {
-		return 0, fmt.Errorf("path conversion error: %v", err)
-	}
-
-	var freeBytesAvailable, totalNumberOfBytes, totalNumberOfFreeBytes uint64
-
-	ret, _, errno := getDiskFreeSpaceEx.Call(
-		uintptr(unsafe.Pointer(pathPtr)),
-		uintptr(unsafe.Pointer(&freeBytesAvailable)),
-		uintptr(unsafe.Pointer(&totalNumberOfBytes)),
-		uintptr(unsafe.Pointer(&totalNumberOfFreeBytes)),
-	)
-
-	if ret == 0 {
-		return 0, fmt.Errorf("failed to get disk space: %v", errno)
-	}
-
-	return int64(freeBytesAvailable), nil
-}
\ No newline at end of file
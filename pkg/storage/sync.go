@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// SyncMode はストレス用ファイルへの書き込みをディスクに永続化する方法です。
+// データベースの多くは O_DSYNC で書き込むため、バッファ付きの書き込みと fsync とは負荷時のレイテンシの傾向が異なります。
+type SyncMode string
+
+const (
+	// SyncFsync はページキャッシュにバッファして書き込み、ファイルを書き終えるたびに fsync します。
+	SyncFsync SyncMode = "fsync"
+	// SyncDsync はファイルを O_DSYNC で開き、書き込みのたびにデータ (と読み出しに必要なメタデータ) が永続化されるまで待ちます。
+	SyncDsync SyncMode = "dsync"
+	// SyncFull はファイルを O_SYNC で開き、書き込みのたびにデータとすべてのメタデータが永続化されるまで待ちます。
+	SyncFull SyncMode = "sync"
+)
+
+// SyncModes はすべての永続化の方法です。
+var SyncModes = []SyncMode{SyncFsync, SyncDsync, SyncFull}
+
+// Validate は永続化の方法が有効で、このプラットフォームで使用できるかどうかを検証します。空の場合は SyncFsync とみなします。
+func (m SyncMode) Validate() error {
+	if m != "" && !slices.Contains(SyncModes, m) {
+		return fmt.Errorf("unknown sync mode %q (fsync, dsync or sync)", m)
+	}
+	if m == SyncDsync && dsyncFlag == 0 {
+		return fmt.Errorf("O_DSYNC is not supported on this platform; use sync instead")
+	}
+	return nil
+}
+
+// openFlag returns the flag with which the stress files are opened for writing.
+func (m SyncMode) openFlag() int {
+	switch m {
+	case SyncDsync:
+		return dsyncFlag
+	case SyncFull:
+		return os.O_SYNC
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// blockSize is the unit in which data is written and checked in verify mode.
+const blockSize = 4096
+
+// VerifyBlockSize は Verify の場合にデータを書き込み検証するブロックの大きさ (バイト) です。
+// Options.BlockSize はこの倍数である必要があります。
+const VerifyBlockSize = blockSize
+
+// blockMagic marks the start of every block written in verify mode.
+const blockMagic = 0x31564753 // "SGV1"
+
+// A verified block holds, in little-endian order:
+//
+//	0-3     blockMagic
+//	4-7     number of the stress file
+//	8-15    index of the block in the file
+//	16-23   sequence number of the write, 0 for the first one
+//	24-4091 random payload
+//	4092-   CRC-32 of the preceding bytes
+//
+// The header catches blocks that were written to or read from the wrong place,
+// the sequence number blocks that still hold the data of an earlier write (a
+// lost write), and the checksum catches corrupted data.
+const checksumOffset = blockSize - 4
+
+// stressFile is a stress file and the number that identifies its blocks in verify mode.
+type stressFile struct {
+	path string
+	id   uint32
+}
+
+// unknownSequence stands for the sequence number of blocks whose content is
+// unknown after a failed write; only their checksum and header are checked.
+const unknownSequence = math.MaxUint64
+
+// badBlock is a block that failed verification.
+type badBlock struct {
+	index  uint64
+	detail string
+}
+
+// newDataSource returns the generator of the written data for seed, or for a
+// random seed when it is 0. The key also holds the package name so that other
+// users of the same seed get different streams.
+func newDataSource(seed uint64) *rand.ChaCha8 {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], seed)
+	copy(key[8:], "stress-go storage")
+	return rand.NewChaCha8(key)
+}
+
+// fillData fills buffer, which is written at offset in f, with random data from
+// data or, in verify mode, with whole blocks of the write numbered sequence. The
+// offset and the buffer length must then be multiples of blockSize.
+func fillData(buffer []byte, data io.Reader, f stressFile, offset int64, sequence uint64, verify bool) error {
+	if _, err := io.ReadFull(data, buffer); err != nil || !verify {
+		return err
+	}
+	for i := 0; i+blockSize <= len(buffer); i += blockSize {
+		block := buffer[i : i+blockSize]
+		binary.LittleEndian.PutUint32(block[0:], blockMagic)
+		binary.LittleEndian.PutUint32(block[4:], f.id)
+		binary.LittleEndian.PutUint64(block[8:], uint64(offset+int64(i))/blockSize)
+		binary.LittleEndian.PutUint64(block[16:], sequence)
+		binary.LittleEndian.PutUint32(block[checksumOffset:], crc32.ChecksumIEEE(block[:checksumOffset]))
+	}
+	return nil
+}
+
+// checkBlock returns what is wrong with the block at index in f, which the write
+// numbered sequence wrote last, or "" if it is intact.
+func checkBlock(block []byte, f stressFile, index, sequence uint64) string {
+	stored := binary.LittleEndian.Uint32(block[checksumOffset:])
+	if computed := crc32.ChecksumIEEE(block[:checksumOffset]); stored != computed {
+		return i18n.Sprintf("checksum mismatch (stored %08x, computed %08x)", stored, computed)
+	}
+	if magic := binary.LittleEndian.Uint32(block[0:]); magic != blockMagic {
+		return i18n.Sprintf("not a verification block (magic %08x)", magic)
+	}
+	id, at := binary.LittleEndian.Uint32(block[4:]), binary.LittleEndian.Uint64(block[8:])
+	if id != f.id || at != index {
+		return i18n.Sprintf("holds block %d of file %d", at, id)
+	}
+	if stored := binary.LittleEndian.Uint64(block[16:]); sequence != unknownSequence && stored != sequence {
+		return i18n.Sprintf("holds write %d instead of write %d (lost write)", stored, sequence)
+	}
+	return ""
+}
+
+// checkBlocks checks the whole blocks of buffer, read from f starting at the
+// block numbered first, against the write sequence expected returns for each.
+// A trailing partial block is reported as truncated.
+func checkBlocks(buffer []byte, f stressFile, first uint64, expected func(index uint64) uint64) []badBlock {
+	var bad []badBlock
+	for i := 0; i < len(buffer); i += blockSize {
+		index := first + uint64(i/blockSize)
+		problem := i18n.T("truncated block")
+		if i+blockSize <= len(buffer) {
+			problem = checkBlock(buffer[i:i+blockSize], f, index, expected(index))
+		}
+		if problem != "" {
+			bad = append(bad, badBlock{index, i18n.Sprintf("%s block %d (offset %d): %s", f.path, index, index*blockSize, problem)})
+		}
+	}
+	return bad
+}
+
+// verifyFile reads f ioSize bytes at a time and checks every block against the
+// write sequence expected returns for it. It returns the number of blocks checked
+// and the bad ones.
+func verifyFile(f stressFile, ioSize int, expected func(index uint64) uint64) (int64, []badBlock, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	buffer := make([]byte, ioSize)
+	var checked int64
+	var bad []badBlock
+	for {
+		n, err := io.ReadFull(file, buffer)
+		bad = append(bad, checkBlocks(buffer[:n], f, uint64(checked), expected)...)
+		checked += int64((n + blockSize - 1) / blockSize)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return checked, bad, nil
+		}
+		if err != nil {
+			return checked, bad, err
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// testFile is the stress file the blocks of the tests belong to.
+var testFile = stressFile{path: "f", id: 3}
+
+// testBlock returns block index of testFile as the write numbered sequence left it.
+func testBlock(t *testing.T, index, sequence uint64) []byte {
+	t.Helper()
+	block := make([]byte, blockSize)
+	if err := fillData(block, newDataSource(1), testFile, int64(index)*blockSize, sequence, true); err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+// seal recomputes the checksum of block after a change to its header or payload.
+func seal(block []byte) {
+	binary.LittleEndian.PutUint32(block[checksumOffset:], crc32.ChecksumIEEE(block[:checksumOffset]))
+}
+
+func TestCheckBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		corrupt  func(block []byte)
+		sequence uint64
+		want     string
+	}{
+		{"intact", func([]byte) {}, 5, ""},
+		{"unknown sequence", func([]byte) {}, unknownSequence, ""},
+		{"payload", func(b []byte) { b[100] ^= 1 }, 5, "checksum mismatch"},
+		{"checksum", func(b []byte) { b[checksumOffset] ^= 1 }, 5, "checksum mismatch"},
+		{"magic", func(b []byte) { b[0] ^= 1; seal(b) }, 5, "not a verification block (magic 31564752)"},
+		{"file id", func(b []byte) { binary.LittleEndian.PutUint32(b[4:], 4); seal(b) }, 5, "holds block 7 of file 4"},
+		{"index", func(b []byte) { binary.LittleEndian.PutUint64(b[8:], 8); seal(b) }, 5, "holds block 8 of file 3"},
+		{"lost write", func(b []byte) { binary.LittleEndian.PutUint64(b[16:], 4); seal(b) }, 5, "holds write 4 instead of write 5 (lost write)"},
+		{"stale write of unknown block", func(b []byte) { binary.LittleEndian.PutUint64(b[16:], 4); seal(b) }, unknownSequence, ""},
+		{"header after checksum", func(b []byte) { b[0] ^= 1 }, 5, "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block := testBlock(t, 7, 5)
+			tt.corrupt(block)
+			got := checkBlock(block, testFile, 7, tt.sequence)
+			if (got == "") != (tt.want == "") || !strings.Contains(got, tt.want) {
+				t.Errorf("checkBlock() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBlocks(t *testing.T) {
+	var buffer []byte
+	for index := uint64(4); index < 8; index++ {
+		buffer = append(buffer, testBlock(t, index, 0)...)
+	}
+	buffer[blockSize+10] ^= 1                                 // block 5: payload
+	binary.LittleEndian.PutUint64(buffer[3*blockSize+16:], 1) // block 7: write 1 of block 7 lost
+	seal(buffer[3*blockSize : 4*blockSize])
+	buffer = append(buffer, make([]byte, 100)...) // block 8: truncated
+
+	bad := checkBlocks(buffer, testFile, 4, func(index uint64) uint64 {
+		if index == 7 {
+			return 2
+		}
+		return 0
+	})
+	want := []struct {
+		index  uint64
+		detail string
+	}{
+		{5, "f block 5 (offset 20480): checksum mismatch"},
+		{7, "f block 7 (offset 28672): holds write 1 instead of write 2 (lost write)"},
+		{8, "f block 8 (offset 32768): truncated block"},
+	}
+	if len(bad) != len(want) {
+		t.Fatalf("checkBlocks() = %v, want %d bad blocks", bad, len(want))
+	}
+	for i, w := range want {
+		if bad[i].index != w.index || !strings.HasPrefix(bad[i].detail, w.detail) {
+			t.Errorf("bad block %d = %+v, want %d %q", i, bad[i], w.index, w.detail)
+		}
+	}
+}
+
+func TestTriage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	f := stressFile{path: path, id: 3}
+	content := make([]byte, 4*blockSize)
+	if err := fillData(content, newDataSource(1), f, 0, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	// Block 1 was corrupted on purpose, block 2 went bad by itself
+	content[blockSize+10] ^= 1
+	content[2*blockSize+10] ^= 1
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	recorder := metrics.NewRecorder()
+	c := &Controller{opts: Options{Recorder: recorder}, integrity: newIntegrity()}
+	c.integrity.inject(f, 1)
+
+	_, bad, err := verifyFile(f, 2*blockSize, c.integrity.expected(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, fresh := c.triage(f, bad, newDataSource(2))
+	if len(all) != 1 || len(fresh) != 1 || !strings.Contains(all[0], "block 2 ") {
+		t.Fatalf("first triage = %q, %q, want block 2 in both", all, fresh)
+	}
+
+	// The injected block is repaired; the other one stays bad but is counted once
+	_, bad, err = verifyFile(f, 2*blockSize, c.integrity.expected(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, fresh = c.triage(f, bad, newDataSource(2))
+	if len(all) != 1 || len(fresh) != 0 {
+		t.Errorf("second triage = %q, %q, want block 2 only in all", all, fresh)
+	}
+	if got := recorder.Counts()["Storage"]["corrupt_blocks"]; got != 1 {
+		t.Errorf("corrupt_blocks = %d, want 1", got)
+	}
+	faults := recorder.Faults()
+	if len(faults) != 1 || faults[0].Recovered != 1 {
+		t.Errorf("faults = %+v, want one recovered corruption", faults)
+	}
+}
+
+func TestVerifyFailure(t *testing.T) {
+	recorder := metrics.NewRecorder()
+	c, err := Start(context.Background(), Options{
+		Size: 1024 * 1024, Files: 1, Dir: t.TempDir(), Seed: 1, Verify: true, Recorder: recorder,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for c.Stats().Used < 1024*1024 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.mu.Lock()
+	files := append([]stressFile(nil), c.files...)
+	c.mu.Unlock()
+	if len(files) == 0 {
+		c.Stop()
+		c.Wait()
+		t.Fatal("no stress file written in 10s")
+	}
+
+	// Flip a payload bit of the first block behind the back of the load
+	file, err := os.OpenFile(files[0].path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 1)
+	if _, err := file.ReadAt(b, 100); err != nil {
+		t.Fatal(err)
+	}
+	b[0] ^= 1
+	if _, err := file.WriteAt(b, 100); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	c.Stop()
+	result, err := c.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Scan == nil || result.Scan.Passed() {
+		t.Fatalf("Scan = %+v, want a failed scan", result.Scan)
+	}
+	if !strings.Contains(result.Scan.BadBlocks[0], "block 0 (offset 0): checksum mismatch") {
+		t.Errorf("BadBlocks = %q, want block 0", result.Scan.BadBlocks)
+	}
+	// The failures are what makes the run exit with a verification failure
+	verifications := recorder.Verifications()
+	if len(verifications) != 1 || verifications[0].Failures != 1 {
+		t.Errorf("verifications = %+v, want one failure", verifications)
+	}
+}
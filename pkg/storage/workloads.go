@@ -0,0 +1,33 @@
+package storage
+
+import "github.com/utkamioka/stress-go/pkg/workload"
+
+func init() {
+	workload.Register(workload.Workload{
+		Kind:        workload.Engines,
+		Domain:      "storage",
+		Name:        "sync",
+		Usage:       "--storage <size>",
+		Description: "Synchronous writes, appends and reads of temporary files through the page cache, with fsync after each file",
+		Available:   true,
+	})
+	for _, w := range []struct {
+		mode        IOMode
+		description string
+	}{
+		{IOSeqWrite, "Sequential overwrites of the stress files on --io-depth workers, reporting IOPS and throughput"},
+		{IOSeqRead, "Sequential reads of the stress files on --io-depth workers, reporting IOPS and throughput"},
+		{IORandRead, "Reads at random offsets of the stress files with pread, reporting IOPS and throughput"},
+		{IORandWrite, "Overwrites at random offsets of the stress files with pwrite, reporting IOPS and throughput"},
+		{IOMixed, "Random reads and overwrites in equal parts, reporting IOPS and throughput"},
+	} {
+		workload.Register(workload.Workload{
+			Kind:        workload.Engines,
+			Domain:      "storage",
+			Name:        string(w.mode),
+			Usage:       "--storage <size> --io-mode " + string(w.mode),
+			Description: w.description,
+			Available:   true,
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package stress
+
+import (
+	"context"
+
+	"github.com/utkamioka/stress-go/pkg/cpu"
+)
+
+// CPUOptions はCPU負荷の設定です。
+type CPUOptions struct {
+	// Name は指標の記録に使用する名前です。空の場合は "CPU" を使用します。
+	Name string
+	// Cores は使用するCPUコア数です。0 の場合は全CPUコアを使用します。
+	Cores int
+	// Target は各コアの目標使用率（0.0〜1.0）を返す関数です。周期ごとに呼び出されます。
+	// nil の場合は常に100%の負荷をかけます。
+	Target func() float64
+	// MaxPercent は使用可能なCPU時間に対する使用率の上限（%）です。0 の場合は制限しません。
+	MaxPercent float64
+	// Method は演算方式 ("integer" または "bignum") です。空の場合は "integer" です。
+	Method string
+	// Verify は各コアで定期的に浮動小数点演算の結果を検証します。結果は OnMessage に通知します。
+	Verify bool
+	// OnSample は目標値と実測値が記録されるたびに呼び出されます（nil可）。
+	OnSample func(Sample)
+	// OnMessage は負荷生成モジュールがメッセージを出力するたびに呼び出されます（nil可）。
+	OnMessage func(Message)
+}
+
+// internal converts the options to those of the internal package.
+func (o CPUOptions) internal() cpu.Options {
+	return cpu.Options{
+		Name:       o.Name,
+		Cores:      o.Cores,
+		Target:     o.Target,
+		MaxPercent: o.MaxPercent,
+		Method:     cpu.Method(o.Method),
+		Verify:     o.Verify,
+		Recorder:   newRecorder(o.OnSample, o.OnMessage),
+	}
+}
+
+// CPUResult はCPU負荷の結果です。
+type CPUResult struct {
+	// Cores は負荷をかけたコア数です。
+	Cores int
+	// MeanCores は実行中にプロセスが消費したCPU時間から求めた平均使用コア数です。
+	MeanCores float64
+}
+
+// CPUStats は実行中のCPU負荷の状態です。
+type CPUStats struct {
+	// Cores は負荷をかけているコア数です。
+	Cores int
+	// Target は現在の目標使用コア数です。
+	Target float64
+	// Achieved は直近の測定で実際に使用されていたコア数です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// CPUController は実行中のCPU負荷を操作するハンドルです。
+type CPUController struct {
+	c *cpu.Controller
+}
+
+// SetTarget は各コアの目標使用率（0.0〜1.0）を変更します。
+// 以降は CPUOptions.Target の代わりにこの値を使用します。
+func (c *CPUController) SetTarget(ratio float64) {
+	c.c.SetTarget(ratio)
+}
+
+// SetScale は CPUOptions で指定した目標使用率に掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で使用率を指定している場合は影響しません。
+func (c *CPUController) SetScale(factor float64) {
+	c.c.SetScale(factor)
+}
+
+// Pause は Resume が呼ばれるまで負荷を止めます。
+func (c *CPUController) Pause() {
+	c.c.Pause()
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *CPUController) Resume() {
+	c.c.Resume()
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *CPUController) Stop() {
+	c.c.Stop()
+}
+
+// Wait は負荷が終了するまで待ち、結果を返します。
+func (c *CPUController) Wait() (CPUResult, error) {
+	r, err := c.c.Wait()
+	return CPUResult{Cores: r.Cores, MeanCores: r.MeanCores}, err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *CPUController) Stats() CPUStats {
+	s := c.c.Stats()
+	return CPUStats{Cores: s.Cores, Target: s.Target, Achieved: s.Achieved, Paused: s.Paused}
+}
+
+// GenerateCPULoad は ctx が終了するまでCPU負荷を生成し、結果を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - CPU負荷の設定
+func GenerateCPULoad(ctx context.Context, opts CPUOptions) (CPUResult, error) {
+	r, err := cpu.GenerateLoad(ctx, opts.internal())
+	return CPUResult{Cores: r.Cores, MeanCores: r.MeanCores}, err
+}
+
+// StartCPULoad はCPU負荷の生成を開始し、実行中に操作するための Controller を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - CPU負荷の設定
+func StartCPULoad(ctx context.Context, opts CPUOptions) (*CPUController, error) {
+	c, err := cpu.Start(ctx, opts.internal())
+	if err != nil {
+		return nil, err
+	}
+	return &CPUController{c: c}, nil
+}
@@ -0,0 +1,147 @@
+package stress
+
+import (
+	"context"
+
+	"github.com/utkamioka/stress-go/pkg/memory"
+)
+
+// MemoryOptions はメモリ負荷の設定です。Size・Percent・Target のいずれかで確保するサイズを指定します。
+type MemoryOptions struct {
+	// Name は指標の記録に使用する名前です。空の場合は "Memory" を使用します。
+	Name string
+	// Size は確保するメモリサイズ（バイト）です。
+	Size int64
+	// Percent は空きメモリに対するパーセンテージです。空きメモリの変化に合わせて確保量を調整します。
+	Percent float64
+	// Target は目標メモリサイズ（バイト）を返す関数です。調整のたびに呼び出されます。
+	Target func() int64
+	// MaxBytes は確保するメモリの上限（バイト）です。0 の場合は制限しません。
+	MaxBytes int64
+	// Verify は確保したメモリに検証用のパターンを書き込み、定期的に読み戻して検証します。
+	Verify bool
+	// Content は確保したメモリに書き込むデータの種類 ("compressible"、"incompressible"、"mixed") です。
+	// 空の場合は各ページの1バイトだけを書き込みます。Verify とは同時に指定できません。
+	Content string
+	// Mode は確保したメモリに対して続ける操作 ("churn"、"bandwidth"、"lock"、"swap") です。
+	// 空の場合は確保したメモリを保持するだけです。"lock" 以外は Verify とは同時に指定できません。
+	Mode string
+	// Rate は Mode が "churn"・"swap" の場合に1秒あたりに書き換えるバイト数です。0 の場合は制限しません。
+	Rate int64
+	// Workers は Mode が "churn"・"bandwidth"・"swap" の場合の読み書きを行うワーカーの数です。
+	// 0 の場合は "bandwidth" では論理 CPU の数、それ以外では 1 です。
+	Workers int
+	// Seed は書き込むデータを生成する乱数のシードです。0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// OnSample は目標値と実測値が記録されるたびに呼び出されます（nil可）。
+	OnSample func(Sample)
+	// OnMessage は負荷生成モジュールがメッセージを出力するたびに呼び出されます（nil可）。
+	OnMessage func(Message)
+}
+
+// internal converts the options to those of the internal package.
+func (o MemoryOptions) internal() memory.Options {
+	return memory.Options{
+		Name:     o.Name,
+		Size:     o.Size,
+		Percent:  o.Percent,
+		Target:   o.Target,
+		MaxBytes: o.MaxBytes,
+		Verify:   o.Verify,
+		Content:  memory.Content(o.Content),
+		Mode:     memory.Mode(o.Mode),
+		Rate:     o.Rate,
+		Workers:  o.Workers,
+		Seed:     o.Seed,
+		Recorder: newRecorder(o.OnSample, o.OnMessage),
+	}
+}
+
+// MemoryResult はメモリ負荷の結果です。
+type MemoryResult struct {
+	// PeakBytes は実行中に確保したメモリの最大量（バイト）です。
+	PeakBytes int64
+	// BytesPerSecond は Mode のワーカーが1秒あたりに読み書きした平均のバイト数です。ワーカーを使わない場合は 0 です。
+	BytesPerSecond float64
+}
+
+// MemoryStats は実行中のメモリ負荷の状態です。
+type MemoryStats struct {
+	// Target は現在の目標メモリサイズ（バイト）です。
+	Target int64
+	// Allocated は現在確保しているメモリの量（バイト）です。
+	Allocated int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// BytesPerSecond は Mode のワーカーが直近に読み書きした1秒あたりのバイト数です。
+	BytesPerSecond float64
+}
+
+// MemoryController は実行中のメモリ負荷を操作するハンドルです。
+type MemoryController struct {
+	c *memory.Controller
+}
+
+// SetTarget は目標メモリサイズ（バイト）を変更します。
+// 以降は MemoryOptions の Size・Percent・Target の代わりにこの値を使用します。
+func (c *MemoryController) SetTarget(bytes int64) {
+	c.c.SetTarget(bytes)
+}
+
+// SetScale は MemoryOptions で指定した目標メモリサイズに掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で目標を指定している場合は影響しません。
+func (c *MemoryController) SetScale(factor float64) {
+	c.c.SetScale(factor)
+}
+
+// Pause は確保しているメモリをすべて解放し、Resume が呼ばれるまで負荷を止めます。
+func (c *MemoryController) Pause() {
+	c.c.Pause()
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *MemoryController) Resume() {
+	c.c.Resume()
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *MemoryController) Stop() {
+	c.c.Stop()
+}
+
+// Wait は負荷が終了するまで待ち、結果を返します。
+func (c *MemoryController) Wait() (MemoryResult, error) {
+	r, err := c.c.Wait()
+	return MemoryResult{PeakBytes: r.PeakBytes, BytesPerSecond: r.BytesPerSecond}, err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *MemoryController) Stats() MemoryStats {
+	s := c.c.Stats()
+	return MemoryStats{Target: s.Target, Allocated: s.Allocated, Paused: s.Paused, BytesPerSecond: s.BytesPerSecond}
+}
+
+// GenerateMemoryLoad は ctx が終了するまでメモリ負荷を生成し、結果を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - メモリ負荷の設定
+func GenerateMemoryLoad(ctx context.Context, opts MemoryOptions) (MemoryResult, error) {
+	r, err := memory.GenerateLoad(ctx, opts.internal())
+	return MemoryResult{PeakBytes: r.PeakBytes, BytesPerSecond: r.BytesPerSecond}, err
+}
+
+// StartMemoryLoad はメモリ負荷の生成を開始し、実行中に操作するための Controller を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - メモリ負荷の設定
+func StartMemoryLoad(ctx context.Context, opts MemoryOptions) (*MemoryController, error) {
+	c, err := memory.Start(ctx, opts.internal())
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryController{c: c}, nil
+}
@@ -0,0 +1,174 @@
+package stress
+
+import (
+	"context"
+
+	"github.com/utkamioka/stress-go/pkg/storage"
+)
+
+// StorageOptions はストレージ負荷の設定です。Size・Percent・Target のいずれかで書き込むサイズを指定します。
+type StorageOptions struct {
+	// Name は指標の記録に使用する名前です。空の場合は "Storage" を使用します。
+	Name string
+	// Size は書き込むデータサイズ（バイト）です。
+	Size int64
+	// Percent は空きディスク容量に対するパーセンテージです。空き容量の変化に合わせて書き込み量を調整します。
+	Percent float64
+	// Target は目標ディスク使用量（バイト）を返す関数です。調整のたびに呼び出されます。
+	Target func() int64
+	// Dir は一時ディレクトリを作成するディレクトリです。空の場合はOSの一時ディレクトリを使用します。
+	Dir string
+	// Files は目標サイズを分けて書き込むファイルの数です。0 の場合は 64MB ごとのファイルに分けます。
+	Files int
+	// BlockSize は1回の読み書きのバイト数です。0 の場合は 64KB です。Verify の場合は 4096 の倍数にします。
+	BlockSize int
+	// IOMode はファイルを書き終えた後に続ける読み書きの方式 ("seqwrite"、"seqread"、"randread"、"randwrite"、"mixed") です。
+	// 空の場合は一定間隔でファイルを1つずつ読み直して追記します。Verify とは併用できません。
+	IOMode string
+	// IODepth は IOMode の読み書きを並行して行うワーカーの数です。0 の場合は 1 です。
+	IODepth int
+	// FsyncEvery は IOMode の各ワーカーが何回の書き込みごとに fsync するかです。0 の場合は fsync しません。
+	FsyncEvery int
+	// MaxBytes はストレス用ファイルが占有するディスク容量の上限（バイト）です。0 の場合は制限しません。
+	MaxBytes int64
+	// Verify はデータをチェックサム付きのブロックとして書き込み、読み込みのたびと終了時に検証します。
+	Verify bool
+	// Sync は書き込んだデータを永続化する方法 ("fsync"、"dsync"、"sync") です。空の場合は "fsync" です。
+	Sync string
+	// Seed は書き込むデータを生成する乱数のシードです。0 の場合は実行のたびに異なるシードを使用します。
+	Seed uint64
+	// OnSample は目標値と実測値が記録されるたびに呼び出されます（nil可）。
+	OnSample func(Sample)
+	// OnMessage は負荷生成モジュールがメッセージを出力するたびに呼び出されます（nil可）。
+	OnMessage func(Message)
+}
+
+// internal converts the options to those of the internal package.
+func (o StorageOptions) internal() storage.Options {
+	return storage.Options{
+		Name:       o.Name,
+		Size:       o.Size,
+		Percent:    o.Percent,
+		Target:     o.Target,
+		Dir:        o.Dir,
+		Files:      o.Files,
+		BlockSize:  o.BlockSize,
+		IOMode:     storage.IOMode(o.IOMode),
+		IODepth:    o.IODepth,
+		FsyncEvery: o.FsyncEvery,
+		MaxBytes:   o.MaxBytes,
+		Verify:     o.Verify,
+		Sync:       storage.SyncMode(o.Sync),
+		Seed:       o.Seed,
+		Recorder:   newRecorder(o.OnSample, o.OnMessage),
+	}
+}
+
+// StorageResult はストレージ負荷の結果です。
+type StorageResult struct {
+	// PeakBytes は実行中にストレス用ファイルが占有したディスク容量の最大値（バイト）です。
+	PeakBytes int64
+	// Scan は Verify の場合に終了時に行ったすべてのファイルの検証の結果です。検証しなかった場合は nil です。
+	Scan *StorageScan
+	// IOPS と BytesPerSecond は IOMode の読み書きの平均です。IOMode が空の場合は 0 です。
+	IOPS           float64
+	BytesPerSecond float64
+}
+
+// StorageScan は終了時に行ったすべてのファイルの検証の結果です。
+type StorageScan struct {
+	// Files は検証したファイルの数です。
+	Files int
+	// Blocks は検証したブロックの数です。
+	Blocks int64
+	// BadBlocks は不一致だったブロックごとの内容 (ファイル、ブロック番号、オフセット、問題) です。
+	BadBlocks []string
+}
+
+// storageResult converts a result of the internal package.
+func storageResult(r storage.Result) StorageResult {
+	result := StorageResult{PeakBytes: r.PeakBytes, IOPS: r.IOPS, BytesPerSecond: r.BytesPerSecond}
+	if r.Scan != nil {
+		result.Scan = &StorageScan{Files: r.Scan.Files, Blocks: r.Scan.Blocks, BadBlocks: r.Scan.BadBlocks}
+	}
+	return result
+}
+
+// StorageStats は実行中のストレージ負荷の状態です。
+type StorageStats struct {
+	// Target は現在の目標ディスク使用量（バイト）です。
+	Target int64
+	// Used はストレス用ファイルが現在占有しているディスク容量（バイト）です。
+	Used int64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// StorageController は実行中のストレージ負荷を操作するハンドルです。
+type StorageController struct {
+	c *storage.Controller
+}
+
+// SetTarget は目標ディスク使用量（バイト）を変更します。
+// 以降は StorageOptions の Size・Percent・Target の代わりにこの値を使用します。
+func (c *StorageController) SetTarget(bytes int64) {
+	c.c.SetTarget(bytes)
+}
+
+// SetScale は StorageOptions で指定した目標ディスク使用量に掛ける係数を変更します (1.0 で指定どおり)。
+// SetTarget で目標を指定している場合は影響しません。
+func (c *StorageController) SetScale(factor float64) {
+	c.c.SetScale(factor)
+}
+
+// Pause はストレス用ファイルをすべて削除し、Resume が呼ばれるまで読み書きを止めます。
+func (c *StorageController) Pause() {
+	c.c.Pause()
+}
+
+// Resume は Pause で止めた負荷を再開します。
+func (c *StorageController) Resume() {
+	c.c.Resume()
+}
+
+// Stop は負荷を停止します。終了を待つには Wait を呼び出します。
+func (c *StorageController) Stop() {
+	c.c.Stop()
+}
+
+// Wait は負荷が終了し、一時ファイルを削除するまで待ち、結果を返します。
+func (c *StorageController) Wait() (StorageResult, error) {
+	r, err := c.c.Wait()
+	return storageResult(r), err
+}
+
+// Stats は現在の負荷の状態を返します。
+func (c *StorageController) Stats() StorageStats {
+	s := c.c.Stats()
+	return StorageStats{Target: s.Target, Used: s.Used, Paused: s.Paused}
+}
+
+// GenerateStorageLoad は ctx が終了するまでストレージ負荷を生成し、結果を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - ストレージ負荷の設定
+func GenerateStorageLoad(ctx context.Context, opts StorageOptions) (StorageResult, error) {
+	r, err := storage.GenerateLoad(ctx, opts.internal())
+	return storageResult(r), err
+}
+
+// StartStorageLoad はストレージ負荷の生成を開始し、実行中に操作するための Controller を返します。
+//
+// 引数:
+//
+//	ctx  - 負荷生成の制御に使用するコンテキスト
+//	opts - ストレージ負荷の設定
+func StartStorageLoad(ctx context.Context, opts StorageOptions) (*StorageController, error) {
+	c, err := storage.Start(ctx, opts.internal())
+	if err != nil {
+		return nil, err
+	}
+	return &StorageController{c: c}, nil
+}
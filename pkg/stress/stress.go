@@ -0,0 +1,63 @@
+// Package stress は stress-go の安定した公開 API です。
+//
+// 他のプロジェクトから負荷生成を利用する場合はこのパッケージをインポートしてください。
+// ここで公開する型と関数はセマンティックバージョニングに従い、メジャーバージョンが
+// 変わらない限り互換性を保ちます。型はすべてこのパッケージが定義しており、内部の
+// パッケージの変更の影響を受けません。pkg 以下のその他のパッケージは内部実装であり、
+// マイナーバージョンでも変更される可能性があります。
+package stress
+
+import (
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// Version は stress-go のバージョンです。リリースタグ (v0.1.0 など) と一致します。
+const Version = "0.1.0"
+
+// Sample は負荷生成モジュールが定期的に記録する目標値と実測値です。
+type Sample struct {
+	// Time は記録した時刻です。
+	Time time.Time
+	// Stressor は負荷生成モジュール (またはジョブ) の名前です。
+	Stressor string
+	// Unit は Target と Achieved の単位 ("cores"、"bytes" など) です。
+	Unit string
+	// Target は目標値です。
+	Target float64
+	// Achieved は実測値です。
+	Achieved float64
+	// OpsPerSecond は前回の記録からの操作数/秒です。個別の操作を持たない負荷では 0 です。
+	OpsPerSecond float64
+}
+
+// Message は負荷生成モジュールが出力するメッセージです。
+type Message struct {
+	// Time は出力した時刻です。
+	Time time.Time
+	// Stressor は負荷生成モジュール (またはジョブ) の名前です。
+	Stressor string
+	// Text はメッセージの本文です。
+	Text string
+}
+
+// newRecorder returns a recorder that forwards the samples and messages to
+// the callbacks, or nil if there are none.
+func newRecorder(onSample func(Sample), onMessage func(Message)) *metrics.Recorder {
+	if onSample == nil && onMessage == nil {
+		return nil
+	}
+	recorder := metrics.NewRecorder()
+	if onSample != nil {
+		recorder.OnSample(func(s metrics.Sample) {
+			onSample(Sample{Time: s.Time, Stressor: s.Stressor, Unit: s.Unit, Target: s.Target, Achieved: s.Achieved, OpsPerSecond: s.OpsPerSecond})
+		})
+	}
+	if onMessage != nil {
+		recorder.OnMessage(func(m metrics.Message) {
+			onMessage(Message{Time: m.Time, Stressor: m.Stressor, Text: m.Text})
+		})
+	}
+	return recorder
+}
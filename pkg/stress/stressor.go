@@ -0,0 +1,157 @@
+package stress
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Stressor は CPU・メモリ・ストレージの負荷に共通の操作です。種類を問わずに複数の負荷を
+// まとめて開始・監視・停止する場合に使用します。NewCPU・NewMemory・NewStorage が返します。
+type Stressor interface {
+	// Name は負荷の名前 (Options の Name、空の場合は "CPU"・"Memory"・"Storage") を返します。
+	Name() string
+	// Start は負荷の生成をバックグラウンドで開始します。負荷は ctx が終了するか Stop が呼ばれるまで続きます。
+	// オプションが無効な場合や、すでに開始している場合はエラーを返します。
+	Start(ctx context.Context) error
+	// Stats は現在の負荷の状態を返します。Start の前は名前と単位のみを返します。
+	Stats() Stats
+	// Stop は負荷を停止します。Start の前に呼び出した場合は何もしません。
+	Stop()
+	// Wait は負荷が終了し後始末が済むまで待ち、負荷の生成で発生したエラーを返します。
+	Wait() error
+}
+
+// Stats は Stressor の現在の状態です。
+type Stats struct {
+	// Name は負荷の名前です。
+	Name string
+	// Unit は Target と Achieved の単位 ("cores"、"bytes") です。
+	Unit string
+	// Target は現在の目標値です。
+	Target float64
+	// Achieved は直近の実測値です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+}
+
+// NewCPU は opts に従ってCPU負荷を生成する Stressor を返します。
+func NewCPU(opts CPUOptions) Stressor {
+	return &stressor{name: cmp.Or(opts.Name, "CPU"), unit: "cores", start: func(ctx context.Context) (running, error) {
+		c, err := StartCPULoad(ctx, opts)
+		if err != nil {
+			return running{}, err
+		}
+		return running{
+			stop: c.Stop,
+			wait: func() error { _, err := c.Wait(); return err },
+			stats: func(s *Stats) {
+				stats := c.Stats()
+				s.Target, s.Achieved, s.Paused = stats.Target, stats.Achieved, stats.Paused
+			},
+		}, nil
+	}}
+}
+
+// NewMemory は opts に従ってメモリ負荷を生成する Stressor を返します。
+func NewMemory(opts MemoryOptions) Stressor {
+	return &stressor{name: cmp.Or(opts.Name, "Memory"), unit: "bytes", start: func(ctx context.Context) (running, error) {
+		c, err := StartMemoryLoad(ctx, opts)
+		if err != nil {
+			return running{}, err
+		}
+		return running{
+			stop: c.Stop,
+			wait: func() error { _, err := c.Wait(); return err },
+			stats: func(s *Stats) {
+				stats := c.Stats()
+				s.Target, s.Achieved, s.Paused = float64(stats.Target), float64(stats.Allocated), stats.Paused
+			},
+		}, nil
+	}}
+}
+
+// NewStorage は opts に従ってストレージ負荷を生成する Stressor を返します。
+func NewStorage(opts StorageOptions) Stressor {
+	return &stressor{name: cmp.Or(opts.Name, "Storage"), unit: "bytes", start: func(ctx context.Context) (running, error) {
+		c, err := StartStorageLoad(ctx, opts)
+		if err != nil {
+			return running{}, err
+		}
+		return running{
+			stop: c.Stop,
+			wait: func() error { _, err := c.Wait(); return err },
+			stats: func(s *Stats) {
+				stats := c.Stats()
+				s.Target, s.Achieved, s.Paused = float64(stats.Target), float64(stats.Used), stats.Paused
+			},
+		}, nil
+	}}
+}
+
+// running holds the operations of a started load.
+type running struct {
+	stop  func()
+	wait  func() error
+	stats func(*Stats)
+}
+
+// stressor adapts the controller of one kind of load to Stressor.
+type stressor struct {
+	name  string
+	unit  string
+	start func(context.Context) (running, error)
+
+	mu      sync.Mutex
+	run     running
+	started bool
+}
+
+func (s *stressor) Name() string {
+	return s.name
+}
+
+func (s *stressor) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("%s load already started", s.name)
+	}
+	r, err := s.start(ctx)
+	if err != nil {
+		return err
+	}
+	s.run, s.started = r, true
+	return nil
+}
+
+func (s *stressor) Stats() Stats {
+	stats := Stats{Name: s.name, Unit: s.unit}
+	if r, ok := s.current(); ok {
+		r.stats(&stats)
+	}
+	return stats
+}
+
+func (s *stressor) Stop() {
+	if r, ok := s.current(); ok {
+		r.stop()
+	}
+}
+
+func (s *stressor) Wait() error {
+	r, ok := s.current()
+	if !ok {
+		return fmt.Errorf("%s load not started", s.name)
+	}
+	return r.wait()
+}
+
+// current returns the operations of the load once it has started.
+func (s *stressor) current() (running, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.run, s.started
+}
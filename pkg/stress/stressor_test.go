@@ -0,0 +1,84 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// newStorage returns a small storage Stressor writing under t.TempDir().
+func newStorage(t *testing.T) Stressor {
+	return NewStorage(StorageOptions{Size: 1024 * 1024, Files: 1, Dir: t.TempDir(), Seed: 1})
+}
+
+func TestStressorStartTwice(t *testing.T) {
+	s := newStorage(t)
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		s.Stop()
+		s.Wait()
+	}()
+	if err := s.Start(context.Background()); err == nil {
+		t.Error("second Start() = nil, want an error")
+	}
+}
+
+func TestStressorBeforeStart(t *testing.T) {
+	s := newStorage(t)
+	if got, want := s.Stats(), (Stats{Name: "Storage", Unit: "bytes"}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	s.Stop()
+	if err := s.Wait(); err == nil {
+		t.Error("Wait() = nil, want an error")
+	}
+}
+
+func TestStressorStopAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newStorage(t)
+	if err := s.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := s.Wait(); err != nil {
+		t.Fatalf("Wait() = %v, want nil", err)
+	}
+	s.Stop()
+	if err := s.Wait(); err != nil {
+		t.Errorf("Wait() after Stop = %v, want nil", err)
+	}
+	if stats := s.Stats(); stats.Name != "Storage" || stats.Unit != "bytes" {
+		t.Errorf("Stats() = %+v, want the storage name and unit", stats)
+	}
+}
+
+func TestStressorErrors(t *testing.T) {
+	// An invalid option or directory fails Start and leaves the load unstarted
+	invalid := NewStorage(StorageOptions{Dir: t.TempDir()})
+	if err := invalid.Start(context.Background()); err == nil {
+		t.Error("Start() without a size = nil, want an error")
+	}
+	missing := NewStorage(StorageOptions{Size: 1024 * 1024, Dir: filepath.Join(t.TempDir(), "missing")})
+	if err := missing.Start(context.Background()); err == nil {
+		t.Error("Start() in a missing directory = nil, want an error")
+	}
+	if err := missing.Wait(); err == nil {
+		t.Error("Wait() after a failed Start = nil, want an error")
+	}
+
+	// The error that ends the run of the load is returned by Wait
+	failed := errors.New("disk full")
+	s := &stressor{name: "Storage", unit: "bytes", start: func(context.Context) (running, error) {
+		return running{stop: func() {}, wait: func() error { return failed }, stats: func(*Stats) {}}, nil
+	}}
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Wait(); !errors.Is(err, failed) {
+		t.Errorf("Wait() = %v, want %v", err, failed)
+	}
+}
@@ -0,0 +1,489 @@
+package stressor
+
+import (
+	"cmp"
+	"context"
+	"sync/atomic"
+
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/fsmeta"
+	"github.com/utkamioka/stress-go/pkg/gpu"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/network"
+	"github.com/utkamioka/stress-go/pkg/pagefault"
+	"github.com/utkamioka/stress-go/pkg/procs"
+	"github.com/utkamioka/stress-go/pkg/sched"
+	"github.com/utkamioka/stress-go/pkg/sparse"
+	"github.com/utkamioka/stress-go/pkg/storage"
+)
+
+// cpuStressor adapts the CPU controller to the Stressor interface.
+type cpuStressor struct {
+	controls
+	opts       cpu.Options
+	controller atomic.Pointer[cpu.Controller]
+}
+
+// NewCPU は opts に従ってCPU負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - CPU負荷の設定
+func NewCPU(opts cpu.Options) Stressor {
+	return &cpuStressor{opts: opts}
+}
+
+func (s *cpuStressor) Name() string { return cmp.Or(s.opts.Name, "CPU") }
+
+func (s *cpuStressor) Init() error { return s.opts.Validate() }
+
+func (s *cpuStressor) Run(ctx context.Context) error {
+	c, err := cpu.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *cpuStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitCores}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitCores, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused}
+}
+
+func (s *cpuStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// memoryStressor adapts the memory controller to the Stressor interface.
+type memoryStressor struct {
+	controls
+	opts       memory.Options
+	controller atomic.Pointer[memory.Controller]
+}
+
+// NewMemory は opts に従ってメモリ負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - メモリ負荷の設定
+func NewMemory(opts memory.Options) Stressor {
+	return &memoryStressor{opts: opts}
+}
+
+func (s *memoryStressor) Name() string { return cmp.Or(s.opts.Name, "Memory") }
+
+func (s *memoryStressor) Init() error { return s.opts.Validate() }
+
+func (s *memoryStressor) Run(ctx context.Context) error {
+	c, err := memory.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *memoryStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitBytes}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitBytes, Target: float64(stats.Target), Achieved: float64(stats.Allocated), Paused: stats.Paused}
+}
+
+func (s *memoryStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// storageStressor adapts the storage controller to the Stressor interface.
+type storageStressor struct {
+	controls
+	opts       storage.Options
+	controller atomic.Pointer[storage.Controller]
+}
+
+// NewStorage は opts に従ってストレージ負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - ストレージ負荷の設定
+func NewStorage(opts storage.Options) Stressor {
+	return &storageStressor{opts: opts}
+}
+
+func (s *storageStressor) Name() string { return cmp.Or(s.opts.Name, "Storage") }
+
+func (s *storageStressor) Init() error { return s.opts.Validate() }
+
+func (s *storageStressor) Run(ctx context.Context) error {
+	c, err := storage.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *storageStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitBytes}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitBytes, Target: float64(stats.Target), Achieved: float64(stats.Used), Paused: stats.Paused, Dir: stats.Dir}
+}
+
+func (s *storageStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// gpuStressor adapts the GPU controller to the Stressor interface.
+type gpuStressor struct {
+	controls
+	opts       gpu.Options
+	controller atomic.Pointer[gpu.Controller]
+}
+
+// NewGPU は opts に従ってGPU負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - GPU負荷の設定
+func NewGPU(opts gpu.Options) Stressor {
+	return &gpuStressor{opts: opts}
+}
+
+func (s *gpuStressor) Name() string { return cmp.Or(s.opts.Name, "GPU") }
+
+func (s *gpuStressor) Init() error { return s.opts.Validate() }
+
+func (s *gpuStressor) Run(ctx context.Context) error {
+	c, err := gpu.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *gpuStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitPercent}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitPercent, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused}
+}
+
+func (s *gpuStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// pageFaultStressor adapts the page fault controller to the Stressor interface.
+type pageFaultStressor struct {
+	controls
+	opts       pagefault.Options
+	controller atomic.Pointer[pagefault.Controller]
+}
+
+// NewPageFault は opts に従ってページフォールト負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - ページフォールト負荷の設定
+func NewPageFault(opts pagefault.Options) Stressor {
+	return &pageFaultStressor{opts: opts}
+}
+
+func (s *pageFaultStressor) Name() string { return cmp.Or(s.opts.Name, "PageFault") }
+
+func (s *pageFaultStressor) Init() error { return s.opts.Validate() }
+
+func (s *pageFaultStressor) Run(ctx context.Context) error {
+	c, err := pagefault.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *pageFaultStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitFaultsPerSecond}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitFaultsPerSecond, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused, Dir: stats.Dir}
+}
+
+func (s *pageFaultStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// sparseStressor adapts the sparse file controller to the Stressor interface.
+type sparseStressor struct {
+	controls
+	opts       sparse.Options
+	controller atomic.Pointer[sparse.Controller]
+}
+
+// NewSparse は opts に従ってスパースファイルに穴を開けては埋め直す負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - スパースファイルの負荷の設定
+func NewSparse(opts sparse.Options) Stressor {
+	return &sparseStressor{opts: opts}
+}
+
+func (s *sparseStressor) Name() string { return cmp.Or(s.opts.Name, "Sparse") }
+
+func (s *sparseStressor) Init() error { return s.opts.Validate() }
+
+func (s *sparseStressor) Run(ctx context.Context) error {
+	c, err := sparse.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *sparseStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitOpsPerSecond}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitOpsPerSecond, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused, Dir: stats.Dir}
+}
+
+func (s *sparseStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// networkStressor adapts the network controller to the Stressor interface.
+type networkStressor struct {
+	controls
+	opts       network.Options
+	controller atomic.Pointer[network.Controller]
+}
+
+// NewNetwork は opts に従って TCP・UDP の送信やコネクションの生成と切断を繰り返す負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - ネットワーク負荷の設定
+func NewNetwork(opts network.Options) Stressor {
+	return &networkStressor{opts: opts}
+}
+
+func (s *networkStressor) Name() string { return cmp.Or(s.opts.Name, "Network") }
+
+func (s *networkStressor) Init() error { return s.opts.Validate() }
+
+func (s *networkStressor) Run(ctx context.Context) error {
+	c, err := network.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *networkStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: s.opts.Unit()}
+	}
+	stats := c.Stats()
+	return Stats{Unit: s.opts.Unit(), Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused}
+}
+
+func (s *networkStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// procsStressor adapts the process controller to the Stressor interface.
+type procsStressor struct {
+	controls
+	opts       procs.Options
+	controller atomic.Pointer[procs.Controller]
+}
+
+// NewProcs は opts に従って子プロセスの生成と回収を繰り返す負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - プロセス負荷の設定
+func NewProcs(opts procs.Options) Stressor {
+	return &procsStressor{opts: opts}
+}
+
+func (s *procsStressor) Name() string { return cmp.Or(s.opts.Name, "Procs") }
+
+func (s *procsStressor) Init() error { return s.opts.Validate() }
+
+func (s *procsStressor) Run(ctx context.Context) error {
+	c, err := procs.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *procsStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitProcsPerSecond}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitProcsPerSecond, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused}
+}
+
+func (s *procsStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// filesStressor adapts the file descriptor and metadata controller to the Stressor interface.
+type filesStressor struct {
+	controls
+	opts       fsmeta.Options
+	controller atomic.Pointer[fsmeta.Controller]
+}
+
+// NewFiles は opts に従ってファイルディスクリプタを保持し、小さなファイルの作成と削除を繰り返す負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - ファイルディスクリプタとメタデータの負荷の設定
+func NewFiles(opts fsmeta.Options) Stressor {
+	return &filesStressor{opts: opts}
+}
+
+func (s *filesStressor) Name() string { return cmp.Or(s.opts.Name, "Files") }
+
+func (s *filesStressor) Init() error { return s.opts.Validate() }
+
+func (s *filesStressor) Run(ctx context.Context) error {
+	c, err := fsmeta.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *filesStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitOpsPerSecond}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitOpsPerSecond, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused, Dir: stats.Dir}
+}
+
+func (s *filesStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
+
+// schedulerStressor adapts the scheduler controller to the Stressor interface.
+type schedulerStressor struct {
+	controls
+	opts       sched.Options
+	controller atomic.Pointer[sched.Controller]
+}
+
+// NewScheduler は opts に従ってゴルーチンのピンポンとタイマーの発火を繰り返す負荷を生成する Stressor を作成します。
+//
+// 引数:
+//
+//	opts - スケジューラー負荷の設定
+func NewScheduler(opts sched.Options) Stressor {
+	return &schedulerStressor{opts: opts}
+}
+
+func (s *schedulerStressor) Name() string { return cmp.Or(s.opts.Name, "Scheduler") }
+
+func (s *schedulerStressor) Init() error { return s.opts.Validate() }
+
+func (s *schedulerStressor) Run(ctx context.Context) error {
+	c, err := sched.Start(ctx, s.opts)
+	if err != nil {
+		return err
+	}
+	s.controller.Store(c)
+	s.attach(c)
+	_, err = c.Wait()
+	return err
+}
+
+func (s *schedulerStressor) Stats() Stats {
+	c := s.controller.Load()
+	if c == nil {
+		return Stats{Unit: metrics.UnitWakeupsPerSecond}
+	}
+	stats := c.Stats()
+	return Stats{Unit: metrics.UnitWakeupsPerSecond, Target: stats.Target, Achieved: stats.Achieved, Paused: stats.Paused}
+}
+
+func (s *schedulerStressor) Cleanup() error {
+	if c := s.controller.Load(); c != nil {
+		c.Stop()
+	}
+	return nil
+}
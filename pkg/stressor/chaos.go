@@ -0,0 +1,89 @@
+package stressor
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+)
+
+// Fault は障害注入で負荷生成モジュールに与える障害の種類です。
+type Fault string
+
+const (
+	// FaultKill は CPU のワーカー1つを停止します。ワーカーは少し後に再起動します。
+	FaultKill Fault = "kill"
+	// FaultDrop は確保したメモリのチャンク1つを解放します。次の調整で確保し直します。
+	FaultDrop Fault = "drop"
+	// FaultCorrupt はストレス用ファイルの1ブロックを破損させます。検証で検出して修復します。
+	FaultCorrupt Fault = "corrupt"
+)
+
+// Faults はすべての障害の種類です。
+var Faults = []Fault{FaultKill, FaultDrop, FaultCorrupt}
+
+// errNotRunning is returned when a fault is injected before the stressor has started.
+var errNotRunning = errors.New("not running")
+
+// unsupported returns the error for a fault the stressor cannot take.
+func unsupported(f Fault) error {
+	return fmt.Errorf("%s faults are not supported", f)
+}
+
+// Injectable は障害注入 (--chaos) に対応する Stressor です。
+// 組み込みの CPU・Memory・Storage が実装しています。
+type Injectable interface {
+	Stressor
+	// Faults は注入できる障害の種類を返します。
+	Faults() []Fault
+	// Inject は障害 f を1つ注入し、その内容を返します。対象は rng で選びます。
+	Inject(f Fault, rng *rand.Rand) (string, error)
+}
+
+func (s *cpuStressor) Faults() []Fault { return []Fault{FaultKill} }
+
+func (s *cpuStressor) Inject(f Fault, rng *rand.Rand) (string, error) {
+	if f != FaultKill {
+		return "", unsupported(f)
+	}
+	c := s.controller.Load()
+	if c == nil {
+		return "", errNotRunning
+	}
+	id, err := c.KillWorker(rng)
+	return i18n.Sprintf("worker %d", id), err
+}
+
+func (s *memoryStressor) Faults() []Fault { return []Fault{FaultDrop} }
+
+func (s *memoryStressor) Inject(f Fault, rng *rand.Rand) (string, error) {
+	if f != FaultDrop {
+		return "", unsupported(f)
+	}
+	c := s.controller.Load()
+	if c == nil {
+		return "", errNotRunning
+	}
+	return c.DropChunk(rng)
+}
+
+// Faults only offers corruption when the storage stressor verifies its data, as
+// it could not be detected otherwise.
+func (s *storageStressor) Faults() []Fault {
+	if !s.opts.Verify {
+		return nil
+	}
+	return []Fault{FaultCorrupt}
+}
+
+func (s *storageStressor) Inject(f Fault, rng *rand.Rand) (string, error) {
+	if f != FaultCorrupt {
+		return "", unsupported(f)
+	}
+	c := s.controller.Load()
+	if c == nil {
+		return "", errNotRunning
+	}
+	return c.CorruptBlock(rng)
+}
@@ -0,0 +1,167 @@
+package stressor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Controllable は実行中に一時停止・再開・負荷レベルの変更ができる Stressor です。
+// 組み込みの CPU・Memory・Storage・GPU が実装しています。
+type Controllable interface {
+	Stressor
+	// Pause は Resume が呼ばれるまで負荷を止めます。
+	Pause()
+	// Resume は Pause で止めた負荷を再開します。
+	Resume()
+	// SetLevel は設定された目標値に掛ける負荷レベルを変更します (1.0 で設定どおり、0.5 で半分)。
+	SetLevel(level float64)
+	// SetRamp は負荷レベルにさらに掛ける、ランプアップ・ランプダウンの係数 (0.0〜1.0) を変更します。
+	// 負荷パターンや制御 API が変更する負荷レベルとは独立しています。
+	SetRamp(factor float64)
+	// SetGuard は負荷レベルにさらに掛ける、安全制限 (--max-mem・--max-disk-fill) による抑制の係数
+	// (0.0〜1.0) を変更します。負荷レベルやランプの係数とは独立しています。
+	SetGuard(factor float64)
+}
+
+// Retargetable は実行中に目標値そのものを変更できる Controllable です。
+// 組み込みの CPU・Memory・Storage が実装しています。
+type Retargetable interface {
+	Controllable
+	// SetTarget は目標値を Stats の Unit の値 (CPU はコア数、Memory と Storage はバイト数) に変更します。
+	// 以降は設定された目標値の代わりにこの値を使用し、負荷レベルとランプの係数は掛かりません。
+	// 一時停止は引き続き有効です。負荷の開始前はエラーを返します。
+	SetTarget(target float64) error
+}
+
+// controller is the part of the built-in controllers used for run-time control.
+type controller interface {
+	Pause()
+	Resume()
+	SetScale(factor float64)
+}
+
+// controls implements Controllable's control methods for the built-in stressors.
+// Requests made before the controller has started are applied when it attaches.
+// The controller's scale is the level times the ramp and guard factors.
+type controls struct {
+	mu       sync.Mutex
+	target   controller
+	paused   bool
+	level    float64
+	levelSet bool
+	ramp     float64
+	rampSet  bool
+	guard    float64
+	guardSet bool
+}
+
+// attach applies the requests made so far to c and forwards later ones.
+func (c *controls) attach(target controller) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.target = target
+	if c.paused {
+		target.Pause()
+	}
+	if c.levelSet || c.rampSet || c.guardSet {
+		target.SetScale(c.scale())
+	}
+}
+
+// scale returns the factor the controller applies to its configured target.
+func (c *controls) scale() float64 {
+	scale := 1.0
+	if c.levelSet {
+		scale = c.level
+	}
+	if c.rampSet {
+		scale *= c.ramp
+	}
+	if c.guardSet {
+		scale *= c.guard
+	}
+	return scale
+}
+
+func (c *controls) Pause() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = true
+	if c.target != nil {
+		c.target.Pause()
+	}
+}
+
+func (c *controls) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paused = false
+	if c.target != nil {
+		c.target.Resume()
+	}
+}
+
+func (c *controls) SetLevel(level float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.level, c.levelSet = max(level, 0), true
+	if c.target != nil {
+		c.target.SetScale(c.scale())
+	}
+}
+
+func (c *controls) SetRamp(factor float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ramp, c.rampSet = min(max(factor, 0), 1), true
+	if c.target != nil {
+		c.target.SetScale(c.scale())
+	}
+}
+
+func (c *controls) SetGuard(factor float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guard, c.guardSet = min(max(factor, 0), 1), true
+	if c.target != nil {
+		c.target.SetScale(c.scale())
+	}
+}
+
+// SetTarget spreads the cores over the controller's workers as a busy ratio of each.
+func (s *cpuStressor) SetTarget(target float64) error {
+	c := s.controller.Load()
+	if c == nil {
+		return errNotRunning
+	}
+	cores := c.Stats().Cores
+	if target < 0 || target > float64(cores) {
+		return fmt.Errorf("target must be between 0 and %d cores", cores)
+	}
+	c.SetTarget(target / float64(cores))
+	return nil
+}
+
+func (s *memoryStressor) SetTarget(target float64) error {
+	c := s.controller.Load()
+	if c == nil {
+		return errNotRunning
+	}
+	if target < 0 {
+		return fmt.Errorf("target must not be negative")
+	}
+	c.SetTarget(int64(target))
+	return nil
+}
+
+func (s *storageStressor) SetTarget(target float64) error {
+	c := s.controller.Load()
+	if c == nil {
+		return errNotRunning
+	}
+	if target < 0 {
+		return fmt.Errorf("target must not be negative")
+	}
+	c.SetTarget(int64(target))
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package stressor は負荷生成モジュールの共通インターフェースと、その登録先を提供します。
+package stressor
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Stressor はすべての負荷生成モジュールが実装するインターフェースです。
+// 呼び出し側は Init、Run、Cleanup の順に呼び出します (Run を使用すると簡単です)。
+type Stressor interface {
+	// Name は進行状況の表示や指標の記録に使用する名前 ("CPU" など) を返します。
+	Name() string
+	// Init は負荷をかける前にオプションを検証します。
+	Init() error
+	// Run は ctx が終了するまで負荷を生成します。
+	Run(ctx context.Context) error
+	// Stats は現在の負荷の状態を返します。Run の開始前は目標値・実測値ともに 0 を返します。
+	Stats() Stats
+	// Cleanup は負荷の生成で確保した資源を解放します。
+	Cleanup() error
+}
+
+// Stats は Stressor の現在の状態です。
+type Stats struct {
+	// Unit は Target と Achieved の単位 (metrics.UnitCores や metrics.UnitBytes) です。
+	Unit string
+	// Target は現在の目標値です。
+	Target float64
+	// Achieved は直近の実測値です。
+	Achieved float64
+	// Paused は負荷が一時停止中かどうかです。
+	Paused bool
+	// Dir は負荷の生成でファイルを書き込むディレクトリです。ファイルを書き込まない Stressor では空です。
+	Dir string
+}
+
+// Run は s を初期化して ctx が終了するまで負荷を生成し、最後に後始末を行います。
+// Run がパニックした場合も Cleanup は呼び出されます。
+//
+// 引数:
+//
+//	ctx - 負荷生成の制御に使用するコンテキスト
+//	s   - 実行する Stressor
+func Run(ctx context.Context, s Stressor) (err error) {
+	if err := s.Init(); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, s.Cleanup())
+	}()
+	return s.Run(ctx)
+}
+
+// Registry は実行する Stressor を登録順に保持します。
+// ゼロ値は空の Registry として使用できます。
+type Registry struct {
+	mu        sync.Mutex
+	stressors []Stressor
+}
+
+// Register は s を登録します。
+func (r *Registry) Register(s Stressor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stressors = append(r.stressors, s)
+}
+
+// Unregister は登録された s を取り除きます。登録されていない場合は何もしません。
+func (r *Registry) Unregister(s Stressor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stressors = slices.DeleteFunc(r.stressors, func(t Stressor) bool { return t == s })
+}
+
+// Stressors は登録された Stressor を登録順に返します。
+func (r *Registry) Stressors() []Stressor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Stressor(nil), r.stressors...)
+}
+
+// Len は登録された Stressor の数を返します。
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.stressors)
+}
+
+// timeoutStressor stops the wrapped stressor after its own timeout.
+type timeoutStressor struct {
+	Stressor
+	timeout time.Duration
+}
+
+// WithTimeout は s の負荷を、全体の実行時間とは別に最大 timeout の間だけ生成する Stressor を返します。
+//
+// 引数:
+//
+//	s       - 対象の Stressor
+//	timeout - s が負荷を生成する最大時間
+func WithTimeout(s Stressor, timeout time.Duration) Stressor {
+	return &timeoutStressor{Stressor: s, timeout: timeout}
+}
+
+func (s *timeoutStressor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Stressor.Run(ctx)
+}
+
+// delayedStressor starts the wrapped stressor some time into the run.
+type delayedStressor struct {
+	Stressor
+	delay time.Duration
+}
+
+// WithDelay は s の負荷の生成を、全体の開始から delay だけ遅れて開始する Stressor を返します。
+// delay が経過する前に全体が終了した場合、s は負荷を生成しません。
+//
+// 引数:
+//
+//	s     - 対象の Stressor
+//	delay - 負荷の生成を開始するまでの時間
+func WithDelay(s Stressor, delay time.Duration) Stressor {
+	return &delayedStressor{Stressor: s, delay: delay}
+}
+
+func (s *delayedStressor) Run(ctx context.Context) error {
+	timer := time.NewTimer(s.delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-timer.C:
+	}
+	return s.Stressor.Run(ctx)
+}
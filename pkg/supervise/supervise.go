@@ -0,0 +1,79 @@
+package supervise
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicError はゴルーチン内で発生したパニックの値とスタックトレースです。
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Run は fn を実行し、fn がパニックした場合は回復して *PanicError を返します。
+// fn 内の defer はパニック時にも実行されるため、後始末はそのまま行われます。
+func Run(fn func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	fn()
+	return nil
+}
+
+// Group は負荷生成モジュール内部のゴルーチンをまとめて管理します。
+// いずれかのゴルーチンがパニックすると他のゴルーチンのコンテキストをキャンセルし、
+// Wait の呼び出し元で同じパニックを再発生させます。
+type Group struct {
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	err    *PanicError
+}
+
+// WithContext は新しい Group と、パニック時にキャンセルされるコンテキストを返します。
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go は fn を新しいゴルーチンで実行します。
+func (g *Group) Go(fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := Run(fn); err != nil {
+			g.mu.Lock()
+			if g.err == nil {
+				g.err = err.(*PanicError)
+			}
+			g.mu.Unlock()
+			g.cancel()
+		}
+	}()
+}
+
+// Wait はすべてのゴルーチンの終了を待ちます。
+// いずれかがパニックしていた場合は、そのパニックを呼び出し元で再発生させます。
+func (g *Group) Wait() {
+	if err := g.Join(); err != nil {
+		panic(err)
+	}
+}
+
+// Join はすべてのゴルーチンの終了を待ち、いずれかがパニックしていた場合はその内容を返します。
+func (g *Group) Join() *PanicError {
+	g.wg.Wait()
+	g.cancel()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
@@ -0,0 +1,102 @@
+package sysinfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCacheLineSize はキャッシュラインのサイズを検出できない場合に使用するサイズ (バイト) です。
+// x86 と多くの ARM コアのキャッシュラインは 64 バイトです (Apple Silicon は 128 バイト)。
+const DefaultCacheLineSize = 64
+
+// Cache は CPU キャッシュ1つの構成です。
+type Cache struct {
+	// Level はキャッシュのレベル (1、2、3 など) です。
+	Level int
+	// Type はキャッシュの種類 ("Data"、"Instruction"、"Unified") です。
+	Type string
+	// Size はキャッシュのサイズ (バイト) です。
+	Size int64
+	// LineSize はキャッシュラインのサイズ (バイト) です。不明な場合は 0 です。
+	LineSize int
+	// SharedCPUs はキャッシュを共有する論理 CPU の数です。不明な場合は 0 です。
+	SharedCPUs int
+}
+
+// holdsData reports whether the cache holds data, not only instructions.
+func (c Cache) holdsData() bool {
+	return c.Type != "Instruction"
+}
+
+// name returns the usual short name of the cache, such as "L1d" or "L3".
+func (c Cache) name() string {
+	name := fmt.Sprintf("L%d", c.Level)
+	switch c.Type {
+	case "Data":
+		name += "d"
+	case "Instruction":
+		name += "i"
+	}
+	return name
+}
+
+// Caches は1つの CPU コアから見たキャッシュの構成です。レベルの低い順に並びます。
+type Caches []Cache
+
+// Data は level のデータキャッシュ (または統合キャッシュ) を返します。ない場合は false を返します。
+//
+// 引数:
+//
+//	level - キャッシュのレベル
+func (c Caches) Data(level int) (Cache, bool) {
+	for _, cache := range c {
+		if cache.Level == level && cache.holdsData() {
+			return cache, true
+		}
+	}
+	return Cache{}, false
+}
+
+// LastLevel は最もレベルの高いデータキャッシュ (通常は L3) を返します。キャッシュが不明な場合は false を返します。
+// これより十分大きい作業領域へのアクセスはメモリに届きます。
+func (c Caches) LastLevel() (Cache, bool) {
+	var last Cache
+	for _, cache := range c {
+		if cache.holdsData() && cache.Level > last.Level {
+			last = cache
+		}
+	}
+	return last, last.Level > 0
+}
+
+// LineSize は L1 データキャッシュのキャッシュラインのサイズ (バイト) を返します。
+// 不明な場合は DefaultCacheLineSize を返します。
+func (c Caches) LineSize() int {
+	if l1, ok := c.Data(1); ok && l1.LineSize > 0 {
+		return l1.LineSize
+	}
+	return DefaultCacheLineSize
+}
+
+// String は構成を "L1d 48 KiB, L1i 32 KiB, L2 2 MiB, L3 300 MiB (shared by 16 CPUs), 64-byte lines" の形式で返します。
+func (c Caches) String() string {
+	parts := make([]string, 0, len(c)+1)
+	for _, cache := range c {
+		part := cache.name() + " " + formatCacheSize(cache.Size)
+		if cache.SharedCPUs > 1 {
+			part += fmt.Sprintf(" (shared by %d CPUs)", cache.SharedCPUs)
+		}
+		parts = append(parts, part)
+	}
+	parts = append(parts, fmt.Sprintf("%d-byte lines", c.LineSize()))
+	return strings.Join(parts, ", ")
+}
+
+// formatCacheSize formats a cache size in KiB or MiB, the binary units the
+// vendors mean when they state it in KB or MB.
+func formatCacheSize(size int64) string {
+	if size >= 1024*1024 && size%(1024*1024) == 0 {
+		return fmt.Sprintf("%d MiB", size/(1024*1024))
+	}
+	return fmt.Sprintf("%d KiB", size/1024)
+}
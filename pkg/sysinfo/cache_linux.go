@@ -0,0 +1,63 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ReadCaches は /sys/devices/system/cpu/cpu0/cache から CPU 0 のキャッシュの構成を取得します。
+// 性能の異なるコアが混在する場合も、CPU 0 の構成を返します。
+func ReadCaches() (Caches, error) {
+	dirs, err := filepath.Glob("/sys/devices/system/cpu/cpu0/cache/index[0-9]*")
+	if err != nil || len(dirs) == 0 {
+		return nil, fmt.Errorf("CPU cache information is not available in sysfs")
+	}
+	var caches Caches
+	for _, dir := range dirs {
+		size, err := parseCacheSize(readTextFile(filepath.Join(dir, "size")))
+		if err != nil {
+			continue
+		}
+		caches = append(caches, Cache{
+			Level:      readIntFile(filepath.Join(dir, "level")),
+			Type:       readTextFile(filepath.Join(dir, "type")),
+			Size:       size,
+			LineSize:   readIntFile(filepath.Join(dir, "coherency_line_size")),
+			SharedCPUs: countCPUList(readTextFile(filepath.Join(dir, "shared_cpu_list"))),
+		})
+	}
+	if len(caches) == 0 {
+		return nil, fmt.Errorf("CPU cache information is not available in sysfs")
+	}
+	slices.SortStableFunc(caches, func(a, b Cache) int { return a.Level - b.Level })
+	return caches, nil
+}
+
+// readTextFile returns the trimmed contents of a sysfs file, or "" if it cannot be read.
+func readTextFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseCacheSize parses a sysfs cache size such as "48K" or "32M".
+func parseCacheSize(s string) (int64, error) {
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		unit, s = 1024, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		unit, s = 1024*1024, strings.TrimSuffix(s, "M")
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid cache size %q", s)
+	}
+	return n * unit, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ReadCaches は CPU のキャッシュの構成を返します。このプラットフォームではキャッシュの構成を取得できないため、
+// 常にエラーを返します。
+func ReadCaches() (Caches, error) {
+	return nil, fmt.Errorf("CPU cache detection is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,77 @@
+package sysinfo
+
+import (
+	"math"
+	"runtime"
+)
+
+// Cgroup はプロセスが属する cgroup のリソース制限です。
+// 制限のない項目は 0 (I/O は空) です。
+type Cgroup struct {
+	// Version は検出した cgroup のバージョン (1 または 2) です。
+	Version int
+	// Path はプロセスが属する cgroup のパスです。
+	Path string
+	// CPUQuota は CPU 時間の上限 (コア数換算) です。
+	CPUQuota float64
+	// CPUSet は cpuset で使用が許可された CPU の数です。
+	CPUSet int
+	// MemoryLimit はメモリ使用量の上限 (バイト) です。
+	MemoryLimit int64
+	// MemoryUsage は cgroup 全体の現在のメモリ使用量 (バイト) です。
+	MemoryUsage int64
+	// IO はデバイスごとの I/O 帯域・IOPS の上限です。
+	IO []IOLimit
+}
+
+// IOLimit は1つのブロックデバイスに対する I/O の上限です。制限のない項目は 0 です。
+type IOLimit struct {
+	// Device は "8:0" 形式のデバイス番号です。
+	Device    string
+	ReadBPS   int64
+	WriteBPS  int64
+	ReadIOPS  int64
+	WriteIOPS int64
+}
+
+// MemoryHeadroom は cgroup のメモリ上限までの残り (バイト) を返します。
+// 上限がない場合は -1 を返します。
+func (c Cgroup) MemoryHeadroom() int64 {
+	if c.MemoryLimit == 0 {
+		return -1
+	}
+	return max(c.MemoryLimit-c.MemoryUsage, 0)
+}
+
+// EffectiveCPUs はプロセスが実際に使用できる CPU の量 (コア数換算) を返します。
+// 論理 CPU 数を cgroup の CPU クォータと cpuset で制限した値です。
+func EffectiveCPUs() float64 {
+	cpus := float64(runtime.NumCPU())
+	cg, err := ReadCgroup()
+	if err != nil {
+		return cpus
+	}
+	if cg.CPUSet > 0 {
+		cpus = math.Min(cpus, float64(cg.CPUSet))
+	}
+	if cg.CPUQuota > 0 {
+		cpus = math.Min(cpus, cg.CPUQuota)
+	}
+	return cpus
+}
+
+// MemoryAvailable はプロセスが新たに確保できるメモリの量 (バイト) を返します。
+// システムの利用可能メモリを cgroup のメモリ上限までの残りで制限した値です。
+func MemoryAvailable() (int64, error) {
+	snapshot, err := Read()
+	if err != nil {
+		return 0, err
+	}
+	available := snapshot.MemoryAvailable
+	if cg, err := ReadCgroup(); err == nil {
+		if headroom := cg.MemoryHeadroom(); headroom >= 0 {
+			available = min(available, headroom)
+		}
+	}
+	return available, nil
+}
@@ -0,0 +1,321 @@
+package sysinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroupV1Unlimited is the smallest value treated as "no limit" in cgroup v1 files,
+// which report an unlimited memory limit as a page-aligned value near MaxInt64.
+const cgroupV1Unlimited = 1 << 62
+
+// cgroupMount is a mounted cgroup hierarchy from /proc/self/mountinfo.
+type cgroupMount struct {
+	root        string // the cgroup path mounted at point
+	point       string
+	controllers []string // v1 controllers, empty for the unified hierarchy
+}
+
+// ReadCgroup はプロセスが属する cgroup (v1 または v2) のリソース制限を取得します。
+// 上位の cgroup の制限も考慮した実効値を返します。
+func ReadCgroup() (Cgroup, error) {
+	paths, err := readCgroupPaths()
+	if err != nil {
+		return Cgroup{}, err
+	}
+	mounts, err := readCgroupMounts()
+	if err != nil {
+		return Cgroup{}, err
+	}
+
+	// On hybrid hosts the v1 hierarchies own the controllers, so they take precedence
+	v1 := make(map[string]string)
+	for _, m := range mounts {
+		for _, controller := range m.controllers {
+			if path, ok := paths[controller]; ok {
+				v1[controller] = cgroupDir(m, path)
+			}
+		}
+	}
+	if v1["memory"] != "" || v1["cpu"] != "" {
+		return readCgroupV1(v1, paths), nil
+	}
+
+	for _, m := range mounts {
+		if m.controllers == nil {
+			if path, ok := paths[""]; ok {
+				return readCgroupV2(cgroupDir(m, path), m.point, path), nil
+			}
+		}
+	}
+	return Cgroup{}, errors.New("no cgroup hierarchy mounted")
+}
+
+// CgroupV2Root は cgroup v2 (unified hierarchy) のマウントポイントを返します。
+// cgroup v1 のコントローラーがマウントされているハイブリッド構成や、cgroup v2 がない場合はエラーを返します。
+func CgroupV2Root() (string, error) {
+	mounts, err := readCgroupMounts()
+	if err != nil {
+		return "", err
+	}
+	root := ""
+	for _, m := range mounts {
+		if m.controllers != nil {
+			return "", fmt.Errorf("cgroup v1 controllers are mounted (%s at %s)", strings.Join(m.controllers, ","), m.point)
+		}
+		if root == "" {
+			root = m.point
+		}
+	}
+	if root == "" {
+		return "", errors.New("no cgroup v2 hierarchy mounted")
+	}
+	return root, nil
+}
+
+// readCgroupPaths parses /proc/self/cgroup into the cgroup path of each controller.
+// The unified hierarchy is stored under the empty controller name.
+func readCgroupPaths() (map[string]string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		// Each line is "<hierarchy-id>:<controllers>:<path>"
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+	return paths, nil
+}
+
+// readCgroupMounts lists the cgroup hierarchies mounted in this mount namespace.
+func readCgroupMounts() ([]cgroupMount, error) {
+	entries, err := readMountinfo()
+	if err != nil {
+		return nil, err
+	}
+	var mounts []cgroupMount
+	for _, e := range entries {
+		m := cgroupMount{root: e.root, point: e.point}
+		switch e.fstype {
+		case "cgroup2":
+		case "cgroup":
+			for _, option := range strings.Split(e.superOptions, ",") {
+				if option != "rw" && option != "ro" && !strings.Contains(option, "=") {
+					m.controllers = append(m.controllers, option)
+				}
+			}
+			if m.controllers == nil {
+				continue
+			}
+		default:
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+// cgroupDir maps a cgroup path to its directory under the mount m.
+func cgroupDir(m cgroupMount, path string) string {
+	if m.root != "/" {
+		path = strings.TrimPrefix(path, m.root)
+	}
+	return filepath.Join(m.point, path)
+}
+
+// readCgroupV2 reads the limits of the unified hierarchy directory dir, whose mount point is top.
+func readCgroupV2(dir, top, path string) Cgroup {
+	c := Cgroup{Version: 2, Path: path}
+	walkCgroup(dir, top, func(d string) {
+		// cpu.max holds "<quota> <period>" with quota "max" when unlimited
+		if fields := strings.Fields(readCgroupValue(d, "cpu.max")); len(fields) == 2 {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				c.CPUQuota = minLimit(c.CPUQuota, quota/period)
+			}
+		}
+		if limit, err := strconv.ParseInt(readCgroupValue(d, "memory.max"), 10, 64); err == nil {
+			c.MemoryLimit = minLimit(c.MemoryLimit, limit)
+		}
+		for _, line := range strings.Split(readCgroupValue(d, "io.max"), "\n") {
+			// Each line is "<major:minor> rbps=<n> wbps=<n> riops=<n> wiops=<n>"
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			var limit IOLimit
+			for _, field := range fields[1:] {
+				key, value, _ := strings.Cut(field, "=")
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				switch key {
+				case "rbps":
+					limit.ReadBPS = n
+				case "wbps":
+					limit.WriteBPS = n
+				case "riops":
+					limit.ReadIOPS = n
+				case "wiops":
+					limit.WriteIOPS = n
+				}
+			}
+			c.IO = mergeIOLimit(c.IO, fields[0], limit)
+		}
+	})
+	c.CPUSet = countCPUList(readCgroupValue(dir, "cpuset.cpus.effective"))
+	c.MemoryUsage, _ = strconv.ParseInt(readCgroupValue(dir, "memory.current"), 10, 64)
+	return c
+}
+
+// readCgroupV1 reads the limits of the v1 hierarchies, given the directory of each controller.
+func readCgroupV1(dirs, paths map[string]string) Cgroup {
+	c := Cgroup{Version: 1, Path: paths["memory"]}
+	if c.Path == "" {
+		c.Path = paths["cpu"]
+	}
+	if dir := dirs["cpu"]; dir != "" {
+		walkCgroup(dir, "", func(d string) {
+			// A quota of -1 means unlimited
+			quota, err1 := strconv.ParseFloat(readCgroupValue(d, "cpu.cfs_quota_us"), 64)
+			period, err2 := strconv.ParseFloat(readCgroupValue(d, "cpu.cfs_period_us"), 64)
+			if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+				c.CPUQuota = minLimit(c.CPUQuota, quota/period)
+			}
+		})
+	}
+	if dir := dirs["cpuset"]; dir != "" {
+		c.CPUSet = countCPUList(readCgroupValue(dir, "cpuset.effective_cpus"))
+		if c.CPUSet == 0 {
+			c.CPUSet = countCPUList(readCgroupValue(dir, "cpuset.cpus"))
+		}
+	}
+	if dir := dirs["memory"]; dir != "" {
+		walkCgroup(dir, "", func(d string) {
+			limit, err := strconv.ParseInt(readCgroupValue(d, "memory.limit_in_bytes"), 10, 64)
+			if err == nil && limit < cgroupV1Unlimited {
+				c.MemoryLimit = minLimit(c.MemoryLimit, limit)
+			}
+		})
+		c.MemoryUsage, _ = strconv.ParseInt(readCgroupValue(dir, "memory.usage_in_bytes"), 10, 64)
+	}
+	if dir := dirs["blkio"]; dir != "" {
+		throttles := []struct {
+			file  string
+			field func(*IOLimit) *int64
+		}{
+			{"blkio.throttle.read_bps_device", func(l *IOLimit) *int64 { return &l.ReadBPS }},
+			{"blkio.throttle.write_bps_device", func(l *IOLimit) *int64 { return &l.WriteBPS }},
+			{"blkio.throttle.read_iops_device", func(l *IOLimit) *int64 { return &l.ReadIOPS }},
+			{"blkio.throttle.write_iops_device", func(l *IOLimit) *int64 { return &l.WriteIOPS }},
+		}
+		walkCgroup(dir, "", func(d string) {
+			for _, throttle := range throttles {
+				// Each line is "<major:minor> <value>"
+				for _, line := range strings.Split(readCgroupValue(d, throttle.file), "\n") {
+					fields := strings.Fields(line)
+					if len(fields) != 2 {
+						continue
+					}
+					if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+						var limit IOLimit
+						*throttle.field(&limit) = n
+						c.IO = mergeIOLimit(c.IO, fields[0], limit)
+					}
+				}
+			}
+		})
+	}
+	return c
+}
+
+// walkCgroup calls fn for dir and each of its ancestors up to the mount point top,
+// since a limit on any ancestor also applies to the group. An empty top walks up to
+// the directory that no longer holds cgroup control files.
+func walkCgroup(dir, top string, fn func(dir string)) {
+	for {
+		if top == "" {
+			if _, err := os.Stat(filepath.Join(dir, "cgroup.procs")); err != nil {
+				return
+			}
+		}
+		fn(dir)
+		if dir == top || dir == "/" {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// readCgroupValue returns the trimmed content of a cgroup control file, or "" if unreadable.
+func readCgroupValue(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// minLimit returns the tighter of two limits, where 0 means unlimited.
+func minLimit[T int64 | float64](current, limit T) T {
+	if limit <= 0 {
+		return current
+	}
+	if current == 0 || limit < current {
+		return limit
+	}
+	return current
+}
+
+// mergeIOLimit tightens the limits recorded for device with limit.
+func mergeIOLimit(limits []IOLimit, device string, limit IOLimit) []IOLimit {
+	for i := range limits {
+		if limits[i].Device == device {
+			l := &limits[i]
+			l.ReadBPS = minLimit(l.ReadBPS, limit.ReadBPS)
+			l.WriteBPS = minLimit(l.WriteBPS, limit.WriteBPS)
+			l.ReadIOPS = minLimit(l.ReadIOPS, limit.ReadIOPS)
+			l.WriteIOPS = minLimit(l.WriteIOPS, limit.WriteIOPS)
+			return limits
+		}
+	}
+	limit.Device = device
+	return append(limits, limit)
+}
+
+// countCPUList counts the CPUs in a cpuset list such as "0-3,8,10-11".
+func countCPUList(list string) int {
+	count := 0
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				continue
+			}
+		}
+		count += last - first + 1
+	}
+	return count
+}
@@ -0,0 +1,24 @@
+package sysinfo
+
+import "testing"
+
+func TestCountCPUList(t *testing.T) {
+	tests := []struct {
+		list string
+		want int
+	}{
+		{"0", 1},
+		{"0-3", 4},
+		{"0-3,8,10-11", 7},
+		{" 0-1, 4 \n", 3},
+		{"", 0},
+		{"x,2", 1},
+		{"0-x", 0},
+		{"3-1", 0},
+	}
+	for _, tt := range tests {
+		if got := countCPUList(tt.list); got != tt.want {
+			t.Errorf("countCPUList(%q) = %d, want %d", tt.list, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ReadCgroup はプロセスが属する cgroup のリソース制限を取得します。
+// cgroup は Linux 固有のため、このプラットフォームでは常にエラーを返します。
+func ReadCgroup() (Cgroup, error) {
+	return Cgroup{}, fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
+
+// CgroupV2Root は cgroup v2 のマウントポイントを返します。このプラットフォームでは常にエラーを返します。
+func CgroupV2Root() (string, error) {
+	return "", fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
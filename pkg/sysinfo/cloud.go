@@ -0,0 +1,177 @@
+package sysinfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// metadataAddr is the link-local address of the instance metadata service,
+// which AWS, GCP and Azure all serve on.
+const metadataAddr = "http://169.254.169.254"
+
+// metadataClient never goes through a proxy: the metadata service is only
+// reachable from the instance itself.
+var metadataClient = &http.Client{Transport: &http.Transport{Proxy: nil}}
+
+// CloudInstance はクラウドのインスタンスメタデータサービスから取得したインスタンスの情報です。
+type CloudInstance struct {
+	// Provider は "aws"・"gcp"・"azure" のいずれかです。
+	Provider string
+	// InstanceType はインスタンスタイプ (m5.large、e2-standard-4、Standard_D4s_v5 など) です。
+	InstanceType string
+	// Zone はアベイラビリティゾーン (Azure でゾーンのない場合はリージョン) です。
+	Zone string
+	// Lifecycle は "spot" または "on-demand" です。
+	Lifecycle string
+	// InstanceID はインスタンスの ID です。
+	InstanceID string
+}
+
+// ReadCloudInstance は AWS・GCP・Azure のインスタンスメタデータサービスに並行して問い合わせ、
+// 最初に応答したものからインスタンスの情報を返します。クラウド外ではタイムアウトまでにエラーを返します。
+//
+// 引数:
+//
+//	timeout - 問い合わせ全体の待ち時間
+func ReadCloudInstance(timeout time.Duration) (CloudInstance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		instance CloudInstance
+		err      error
+	}
+	readers := []func(context.Context) (CloudInstance, error){readAWS, readGCP, readAzure}
+	results := make(chan result, len(readers))
+	for _, read := range readers {
+		go func() {
+			instance, err := read(ctx)
+			results <- result{instance, err}
+		}()
+	}
+	for range readers {
+		if r := <-results; r.err == nil {
+			return r.instance, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return CloudInstance{}, fmt.Errorf("no instance metadata service responded within %v", timeout)
+	}
+	return CloudInstance{}, fmt.Errorf("no instance metadata service found")
+}
+
+// readAWS reads the EC2 instance metadata, using an IMDSv2 session token.
+func readAWS(ctx context.Context) (CloudInstance, error) {
+	token, err := metadataRequest(ctx, http.MethodPut, "/latest/api/token", map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return CloudInstance{}, fmt.Errorf("aws: %v", err)
+	}
+	header := map[string]string{"X-aws-ec2-metadata-token": token}
+	get := func(key string) (string, error) {
+		return metadataRequest(ctx, http.MethodGet, "/latest/meta-data/"+key, header)
+	}
+
+	instance := CloudInstance{Provider: "aws", Lifecycle: "on-demand"}
+	for key, field := range map[string]*string{
+		"instance-type":               &instance.InstanceType,
+		"placement/availability-zone": &instance.Zone,
+		"instance-id":                 &instance.InstanceID,
+	} {
+		if *field, err = get(key); err != nil {
+			return CloudInstance{}, fmt.Errorf("aws: %v", err)
+		}
+	}
+	if lifecycle, err := get("instance-life-cycle"); err == nil && lifecycle == "spot" {
+		instance.Lifecycle = "spot"
+	}
+	return instance, nil
+}
+
+// readGCP reads the Compute Engine instance metadata.
+func readGCP(ctx context.Context) (CloudInstance, error) {
+	header := map[string]string{"Metadata-Flavor": "Google"}
+	get := func(key string) (string, error) {
+		return metadataRequest(ctx, http.MethodGet, "/computeMetadata/v1/instance/"+key, header)
+	}
+
+	instance := CloudInstance{Provider: "gcp", Lifecycle: "on-demand"}
+	machineType, err := get("machine-type")
+	if err != nil {
+		return CloudInstance{}, fmt.Errorf("gcp: %v", err)
+	}
+	// The type and zone are resource paths such as projects/123/zones/us-central1-a
+	instance.InstanceType = path.Base(machineType)
+	if zone, err := get("zone"); err == nil {
+		instance.Zone = path.Base(zone)
+	}
+	instance.InstanceID, _ = get("id")
+	if model, err := get("scheduling/provisioning-model"); err == nil && model == "SPOT" {
+		instance.Lifecycle = "spot"
+	} else if preemptible, err := get("scheduling/preemptible"); err == nil && preemptible == "TRUE" {
+		instance.Lifecycle = "spot"
+	}
+	return instance, nil
+}
+
+// readAzure reads the Azure Instance Metadata Service.
+func readAzure(ctx context.Context) (CloudInstance, error) {
+	body, err := metadataRequest(ctx, http.MethodGet, "/metadata/instance/compute?api-version=2021-02-01", map[string]string{"Metadata": "true"})
+	if err != nil {
+		return CloudInstance{}, fmt.Errorf("azure: %v", err)
+	}
+	var compute struct {
+		VMSize   string `json:"vmSize"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		Priority string `json:"priority"`
+		VMID     string `json:"vmId"`
+	}
+	if err := json.Unmarshal([]byte(body), &compute); err != nil {
+		return CloudInstance{}, fmt.Errorf("azure: invalid metadata: %v", err)
+	}
+	instance := CloudInstance{
+		Provider:     "azure",
+		InstanceType: compute.VMSize,
+		Zone:         compute.Location,
+		Lifecycle:    "on-demand",
+		InstanceID:   compute.VMID,
+	}
+	if compute.Zone != "" {
+		instance.Zone = compute.Location + "-" + compute.Zone
+	}
+	if strings.EqualFold(compute.Priority, "Spot") || strings.EqualFold(compute.Priority, "Low") {
+		instance.Lifecycle = "spot"
+	}
+	return instance, nil
+}
+
+// metadataRequest sends a request to the metadata service and returns the body
+// of a successful response.
+func metadataRequest(ctx context.Context, method, path string, header map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, metadataAddr+path, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,13 @@
+package sysinfo
+
+import "os"
+
+// InContainer はコンテナ内で実行しているかどうかを返します。
+// コンテナランタイムの痕跡 (/.dockerenv, cgroup のパスなど)、Kubernetes の環境変数、
+// および cgroup 名前空間から判定します。
+func InContainer() bool {
+	if ReadEnvironment().ContainerRuntime != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	return inCgroupNamespace()
+}
@@ -0,0 +1,120 @@
+package sysinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// initialCgroupNamespace is the fixed inode of the host's initial cgroup namespace.
+const initialCgroupNamespace = "cgroup:[4026531835]"
+
+// filesystemTypes maps the statfs magic numbers of common filesystems to their names.
+var filesystemTypes = map[uint32]string{
+	0x794c7630: "overlay",
+	0x61756673: "aufs",
+	0x01021994: "tmpfs",
+	0xef53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683e: "btrfs",
+	0x2fc12fc1: "zfs",
+	0x6969:     "nfs",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x00c36400: "ceph",
+	0x65735546: "fuse",
+}
+
+// volumeFilesystems are the filesystem types of mounts backed by a real disk or a network share.
+var volumeFilesystems = map[string]bool{
+	"ext2": true, "ext3": true, "ext4": true, "xfs": true, "btrfs": true, "zfs": true,
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "ceph": true,
+}
+
+// mountEntry is one line of /proc/self/mountinfo.
+type mountEntry struct {
+	root         string
+	point        string
+	options      string // per-mount options such as "rw,relatime"
+	fstype       string
+	superOptions string
+}
+
+// readMountinfo parses the mounts of this process's mount namespace.
+func readMountinfo() ([]mountEntry, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []mountEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// The optional fields end with "-", followed by the filesystem type, source and super options
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 6 || sep+3 >= len(fields) {
+			continue
+		}
+		entries = append(entries, mountEntry{
+			root:         fields[3],
+			point:        fields[4],
+			options:      fields[5],
+			fstype:       fields[sep+1],
+			superOptions: fields[sep+3],
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// inCgroupNamespace reports whether the process runs in a cgroup namespace other than the host's.
+func inCgroupNamespace() bool {
+	ns, err := os.Readlink("/proc/self/ns/cgroup")
+	return err == nil && ns != initialCgroupNamespace
+}
+
+// FilesystemType は path を含むファイルシステムの種類 ("overlay", "ext4", "tmpfs" など) を返します。
+//
+// 引数:
+//
+//	path - 調べるファイルまたはディレクトリのパス
+func FilesystemType(path string) (string, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return "", err
+	}
+	if name, ok := filesystemTypes[uint32(stat.Type)]; ok {
+		return name, nil
+	}
+	return fmt.Sprintf("0x%x", uint32(stat.Type)), nil
+}
+
+// VolumeMounts はディスクやネットワーク共有を背後に持つ、書き込み可能なマウントポイントを返します。
+// コンテナでは overlayfs の書き込み層ではなく、マウントされたボリュームを探すのに使用します。
+func VolumeMounts() []string {
+	entries, err := readMountinfo()
+	if err != nil {
+		return nil
+	}
+	var points []string
+	for _, e := range entries {
+		if !volumeFilesystems[e.fstype] || !strings.HasPrefix(e.options, "rw") {
+			continue
+		}
+		// Files such as /etc/hosts are bind-mounted into containers from the host's disk
+		if info, err := os.Stat(e.point); err != nil || !info.IsDir() {
+			continue
+		}
+		points = append(points, e.point)
+	}
+	return points
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// inCgroupNamespace reports whether the process runs in a cgroup namespace other than the host's.
+func inCgroupNamespace() bool {
+	return false
+}
+
+// FilesystemType は path を含むファイルシステムの種類 ("overlay", "ext4", "tmpfs" など) を返します。
+// このプラットフォームでは常にエラーを返します。
+//
+// 引数:
+//
+//	path - 調べるファイルまたはディレクトリのパス
+func FilesystemType(path string) (string, error) {
+	return "", fmt.Errorf("filesystem type detection is not supported on %s", runtime.GOOS)
+}
+
+// VolumeMounts はディスクやネットワーク共有を背後に持つ、書き込み可能なマウントポイントを返します。
+// このプラットフォームでは常に nil を返します。
+func VolumeMounts() []string {
+	return nil
+}
@@ -0,0 +1,140 @@
+package sysinfo
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// containerIDPattern matches the 64-hex-digit container ID in cgroup paths.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// Environment は実行環境 (ホスト、Kubernetes のノード・Pod、コンテナランタイム) の情報です。
+// 複数の Pod やホストの結果を区別するため、メトリクス・ログ・レポートに付与します。
+type Environment struct {
+	Hostname string
+	// Node・Pod・Namespace は Kubernetes の Downward API で環境変数
+	// NODE_NAME・POD_NAME・POD_NAMESPACE に設定された値です。
+	Node      string
+	Pod       string
+	Namespace string
+	// ContainerRuntime は検出したコンテナランタイム (docker, containerd, cri-o, podman など) です。
+	// コンテナ外で実行している場合は空です。
+	ContainerRuntime string
+	// ContainerID は cgroup から取得したコンテナ ID (先頭12文字) です。
+	ContainerID string
+	// Cloud は ReadCloudInstance で取得したクラウドのインスタンスの情報です。
+	// ReadEnvironment は設定しません。
+	Cloud CloudInstance
+}
+
+// ReadEnvironment は環境変数と /proc, /.dockerenv などから実行環境の情報を取得します。
+// 取得できない項目は空のままにします。
+func ReadEnvironment() Environment {
+	env := Environment{
+		Node:      os.Getenv("NODE_NAME"),
+		Pod:       os.Getenv("POD_NAME"),
+		Namespace: os.Getenv("POD_NAMESPACE"),
+	}
+	env.Hostname, _ = os.Hostname()
+
+	cgroup, _ := os.ReadFile("/proc/self/cgroup")
+	env.ContainerRuntime = detectRuntime(string(cgroup))
+	if id := containerIDPattern.FindString(string(cgroup)); id != "" {
+		env.ContainerID = id[:12]
+	}
+
+	// Without the Downward API, a pod's hostname is its name
+	if env.Pod == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		env.Pod = env.Hostname
+	}
+	return env
+}
+
+// detectRuntime identifies the container runtime from marker files, the
+// container environment variable and the process's cgroup paths.
+func detectRuntime(cgroup string) string {
+	if runtime := os.Getenv("container"); runtime != "" {
+		return runtime
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return "podman"
+	}
+	for _, marker := range []struct{ pattern, runtime string }{
+		{"crio-", "cri-o"},
+		{"cri-containerd", "containerd"},
+		{"containerd", "containerd"},
+		{"libpod", "podman"},
+		{"docker", "docker"},
+		{"kubepods", "kubernetes"},
+	} {
+		if strings.Contains(cgroup, marker.pattern) {
+			return marker.runtime
+		}
+	}
+	return ""
+}
+
+// Labels は空でない項目をメトリクスのラベル名をキーとする map で返します。
+func (e Environment) Labels() map[string]string {
+	labels := make(map[string]string)
+	for key, value := range map[string]string{
+		"host":              e.Hostname,
+		"node":              e.Node,
+		"pod":               e.Pod,
+		"namespace":         e.Namespace,
+		"container_runtime": e.ContainerRuntime,
+		"container_id":      e.ContainerID,
+		"cloud_provider":    e.Cloud.Provider,
+		"instance_type":     e.Cloud.InstanceType,
+		"zone":              e.Cloud.Zone,
+		"lifecycle":         e.Cloud.Lifecycle,
+		"instance_id":       e.Cloud.InstanceID,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// Describe は実行環境を1行の説明にします (例: "node n1, pod default/stress-abc, containerd")。
+// ホスト名のみの場合は空文字列を返します。
+func (e Environment) Describe() string {
+	var parts []string
+	if e.Node != "" {
+		parts = append(parts, "node "+e.Node)
+	}
+	if e.Pod != "" {
+		pod := e.Pod
+		if e.Namespace != "" {
+			pod = e.Namespace + "/" + pod
+		}
+		parts = append(parts, "pod "+pod)
+	}
+	if e.ContainerRuntime != "" {
+		runtime := e.ContainerRuntime
+		if e.ContainerID != "" {
+			runtime += " " + e.ContainerID
+		}
+		parts = append(parts, "container "+runtime)
+	}
+	if e.Cloud.Provider != "" {
+		parts = append(parts, strings.Join(nonEmpty(e.Cloud.Provider, e.Cloud.InstanceType, e.Cloud.Zone, e.Cloud.Lifecycle), " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// nonEmpty returns the values that are not empty.
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package sysinfo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoPowerDomains は RAPL のエネルギーカウンタを持つ電力ドメインがないことを示すエラーです。
+var ErrNoPowerDomains = errors.New("no RAPL energy counters found")
+
+// PowerDomain は RAPL (Running Average Power Limit) のエネルギーカウンタを持つ電力ドメインです。
+type PowerDomain struct {
+	// Name は "package-0"、"dram-0" のようなドメイン名です。末尾の数字は CPU パッケージの番号です。
+	Name string
+	// DRAM は DRAM のドメインかどうかです。false の場合は CPU パッケージ全体のドメインです。
+	DRAM bool
+
+	// path is the counter file, in microjoules.
+	path string
+	// wrap is the value at which the counter wraps around to zero.
+	wrap uint64
+}
+
+// Energy は1つの電力ドメインで計測したエネルギーです。
+type Energy struct {
+	Domain PowerDomain
+	// Joules は消費したエネルギー (J) です。
+	Joules float64
+	// Duration は計測した時間です。
+	Duration time.Duration
+}
+
+// Watts は計測した時間の平均電力 (W) を返します。
+func (e Energy) Watts() float64 {
+	if e.Duration <= 0 {
+		return 0
+	}
+	return e.Joules / e.Duration.Seconds()
+}
+
+// EnergyMeter は RAPL のエネルギーカウンタを積算します。カウンタは数分から数十分で一周するため、
+// それより短い間隔で Sample を呼び出す必要があります。
+type EnergyMeter struct {
+	mu      sync.Mutex
+	domains []PowerDomain
+	start   time.Time
+	last    time.Time
+	// counters are the last readings in microjoules, totals the energy since start.
+	counters []uint64
+	totals   []uint64
+}
+
+// NewEnergyMeter はこのホストの RAPL の電力ドメインを検出して計測を開始します。
+// RAPL に対応していない、またはカウンタを読めない (通常は root 権限が必要) 場合はエラーを返します。
+func NewEnergyMeter() (*EnergyMeter, error) {
+	domains, err := PowerDomains()
+	if err != nil {
+		return nil, err
+	}
+	m := &EnergyMeter{
+		domains:  domains,
+		counters: make([]uint64, len(domains)),
+		totals:   make([]uint64, len(domains)),
+	}
+	for i, d := range domains {
+		if m.counters[i], err = readEnergy(d); err != nil {
+			return nil, err
+		}
+	}
+	m.start = time.Now()
+	m.last = m.start
+	return m, nil
+}
+
+// Sample はカウンタを読み取って積算し、前回の Sample (初回は計測開始) からのドメインごとのエネルギーを返します。
+// 読み取れなかったドメインは結果に含めません。
+func (m *EnergyMeter) Sample() []Energy {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	interval := now.Sub(m.last)
+	m.last = now
+	energy := make([]Energy, 0, len(m.domains))
+	for i, d := range m.domains {
+		counter, err := readEnergy(d)
+		if err != nil {
+			continue
+		}
+		delta := counter - m.counters[i]
+		if counter < m.counters[i] {
+			delta = d.wrap - m.counters[i] + counter
+		}
+		m.counters[i] = counter
+		m.totals[i] += delta
+		energy = append(energy, Energy{Domain: d, Joules: float64(delta) / 1e6, Duration: interval})
+	}
+	return energy
+}
+
+// Total は計測開始から最後の Sample までのドメインごとのエネルギーを返します。
+func (m *EnergyMeter) Total() []Energy {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	energy := make([]Energy, len(m.domains))
+	for i, d := range m.domains {
+		energy[i] = Energy{Domain: d, Joules: float64(m.totals[i]) / 1e6, Duration: m.last.Sub(m.start)}
+	}
+	return energy
+}
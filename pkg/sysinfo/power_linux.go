@@ -0,0 +1,68 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// powercapDir holds the RAPL zones registered by the intel_rapl driver, which
+// also serves AMD processors.
+const powercapDir = "/sys/class/powercap"
+
+// PowerDomains は RAPL の CPU パッケージと DRAM の電力ドメインを返します。
+// パッケージに含まれるコア・アンコアのドメインと、プラットフォーム全体 (psys) のドメインは返しません。
+func PowerDomains() ([]PowerDomain, error) {
+	zones, _ := filepath.Glob(filepath.Join(powercapDir, "intel-rapl:*"))
+	var domains []PowerDomain
+	for _, zone := range zones {
+		// intel-rapl:<package> or intel-rapl:<package>:<subzone>
+		index := strings.Split(strings.TrimPrefix(filepath.Base(zone), "intel-rapl:"), ":")
+		data, err := os.ReadFile(filepath.Join(zone, "name"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(data))
+
+		var d PowerDomain
+		switch {
+		case len(index) == 1 && strings.HasPrefix(name, "package"):
+			d.Name = name
+		case len(index) == 2 && name == "dram":
+			d.Name, d.DRAM = "dram-"+index[0], true
+		default:
+			continue
+		}
+		d.path = filepath.Join(zone, "energy_uj")
+		if d.wrap, err = readUint(filepath.Join(zone, "max_energy_range_uj")); err != nil {
+			continue
+		}
+		domains = append(domains, d)
+	}
+	if len(domains) == 0 {
+		return nil, ErrNoPowerDomains
+	}
+	if _, err := readEnergy(domains[0]); err != nil {
+		if os.IsPermission(err) {
+			return nil, fmt.Errorf("RAPL energy counters are readable only by root")
+		}
+		return nil, fmt.Errorf("failed to read RAPL energy counter: %v", err)
+	}
+	return domains, nil
+}
+
+// readEnergy reads the counter of d in microjoules.
+func readEnergy(d PowerDomain) (uint64, error) {
+	return readUint(d.path)
+}
+
+// readUint reads a sysfs file holding a single unsigned integer.
+func readUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
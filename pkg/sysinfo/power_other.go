@@ -0,0 +1,15 @@
+//go:build !linux
+
+package sysinfo
+
+import "fmt"
+
+// PowerDomains は Linux 以外では未対応のため、常に ErrNoPowerDomains を返します。
+func PowerDomains() ([]PowerDomain, error) {
+	return nil, ErrNoPowerDomains
+}
+
+// readEnergy is never called, as there are no power domains.
+func readEnergy(d PowerDomain) (uint64, error) {
+	return 0, fmt.Errorf("RAPL energy counters are only supported on Linux")
+}
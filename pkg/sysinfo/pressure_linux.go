@@ -0,0 +1,35 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadPressure は resource ("cpu"、"memory"、"io") の PSI (Pressure Stall Information) から、
+// 直近10秒間に資源を待って停止したタスクがあった時間の割合 (some avg10、%) を返します。
+//
+// 引数:
+//
+//	resource - 対象の資源 ("cpu"、"memory"、"io")
+func ReadPressure(resource string) (float64, error) {
+	data, err := os.ReadFile("/proc/pressure/" + resource)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s pressure (needs Linux 4.20 or later with PSI enabled): %v", resource, err)
+	}
+
+	// Lines look like "some avg10=1.34 avg60=12.14 avg300=7.16 total=222713421"
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		value, ok := strings.CutPrefix(fields[1], "avg10=")
+		if !ok {
+			break
+		}
+		return strconv.ParseFloat(value, 64)
+	}
+	return 0, fmt.Errorf("unexpected /proc/pressure/%s format", resource)
+}
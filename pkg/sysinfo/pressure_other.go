@@ -0,0 +1,10 @@
+//go:build !linux
+
+package sysinfo
+
+import "fmt"
+
+// ReadPressure は Linux 以外では未対応のため、常にエラーを返します。
+func ReadPressure(resource string) (float64, error) {
+	return 0, fmt.Errorf("pressure stall information is only available on Linux")
+}
@@ -0,0 +1,65 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is USER_HZ, the unit of the CPU times in /proc, which Linux fixes
+// at 100 on every architecture it exposes to user space.
+const clockTicks = 100
+
+// ReadProcessCPUTime は pid のプロセスが使用した累積CPU時間 (ユーザー + システム) を /proc から取得します。
+//
+// 引数:
+//
+//	pid - 対象のプロセス ID
+func ReadProcessCPUTime(pid int) (time.Duration, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read process %d: %v", pid, err)
+	}
+
+	// The command name in parentheses may hold spaces, so the fields are counted
+	// from the closing parenthesis: state is the first, utime the 12th, stime the 13th
+	i := strings.LastIndex(string(data), ")")
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected /proc/%d/stat format", pid)
+	}
+	var ticks uint64
+	for _, field := range fields[11:13] {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid CPU time of process %d: %v", pid, err)
+		}
+		ticks += value
+	}
+	return time.Duration(ticks) * time.Second / clockTicks, nil
+}
+
+// ResidentMemory は現在のプロセスが物理メモリ上に保持しているメモリ量 (VmRSS、バイト) を /proc/self/status から取得します。
+func ResidentMemory() (int64, error) {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/self/status: %v", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		value, ok := strings.CutPrefix(line, "VmRSS:")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "kB")), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid VmRSS %q: %v", strings.TrimSpace(value), err)
+		}
+		return kb * 1024, nil
+	}
+	return 0, fmt.Errorf("VmRSS not found in /proc/self/status")
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !windows
+
+package sysinfo
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReadProcessCPUTime は Linux と Windows 以外では未対応のため、常にエラーを返します。
+func ReadProcessCPUTime(pid int) (time.Duration, error) {
+	return 0, fmt.Errorf("reading the CPU time of another process is only supported on Linux and Windows")
+}
+
+// ResidentMemory は Linux 以外では未対応のため、常にエラーを返します。
+func ResidentMemory() (int64, error) {
+	return 0, fmt.Errorf("reading the resident memory is only supported on Linux")
+}
@@ -0,0 +1,36 @@
+package sysinfo
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// ReadProcessCPUTime は pid のプロセスが使用した累積CPU時間 (ユーザー + カーネル) を GetProcessTimes から取得します。
+//
+// 引数:
+//
+//	pid - 対象のプロセス ID
+func ReadProcessCPUTime(pid int) (time.Duration, error) {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open process %d: %v", pid, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return 0, fmt.Errorf("failed to get times of process %d: %v", pid, err)
+	}
+
+	// Filetime values are expressed in 100-nanosecond intervals
+	ticks := func(ft syscall.Filetime) int64 {
+		return int64(ft.HighDateTime)<<32 | int64(ft.LowDateTime)
+	}
+	return time.Duration((ticks(kernel) + ticks(user)) * 100), nil
+}
+
+// ResidentMemory は Windows では未対応のため、常にエラーを返します。
+func ResidentMemory() (int64, error) {
+	return 0, fmt.Errorf("reading the resident memory is only supported on Linux")
+}
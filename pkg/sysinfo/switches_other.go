@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package sysinfo
+
+import "fmt"
+
+// ContextSwitches はこのプラットフォームでは未対応のため、常にエラーを返します。
+func ContextSwitches() (int64, error) {
+	return 0, fmt.Errorf("counting context switches is not supported on this platform")
+}
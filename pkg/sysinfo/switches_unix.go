@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package sysinfo
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// ContextSwitches は現在のプロセスのすべてのスレッドがこれまでに行ったコンテキストスイッチの回数
+// (自発的なものと非自発的なものの合計) を返します。
+func ContextSwitches() (int64, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, fmt.Errorf("failed to read the resource usage: %v", err)
+	}
+	return int64(usage.Nvcsw) + int64(usage.Nivcsw), nil
+}
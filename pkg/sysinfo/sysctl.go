@@ -0,0 +1,54 @@
+//go:build darwin || freebsd || openbsd
+
+package sysinfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"syscall"
+)
+
+// longSize is the size of a C long, the element type of kern.cp_time and of
+// the scale of vm.loadavg.
+const longSize = strconv.IntSize / 8
+
+// sysctlBytes returns the raw value of a sysctl. syscall.Sysctl drops a trailing
+// NUL byte, which truncates binary values, so the value is padded back to a
+// multiple of align bytes.
+func sysctlBytes(name string, align int) ([]byte, error) {
+	value, err := syscall.Sysctl(name)
+	if err != nil {
+		return nil, err
+	}
+	data := []byte(value)
+	for len(data)%align != 0 {
+		data = append(data, 0)
+	}
+	return data, nil
+}
+
+// sysctlUint64 returns an unsigned integer sysctl such as hw.physmem, which is
+// 32 bits wide on 32-bit FreeBSD.
+func sysctlUint64(name string) (uint64, error) {
+	data, err := sysctlBytes(name, 4)
+	if err != nil {
+		return 0, err
+	}
+	switch len(data) {
+	case 4:
+		return uint64(binary.NativeEndian.Uint32(data)), nil
+	case 8:
+		return binary.NativeEndian.Uint64(data), nil
+	default:
+		return 0, fmt.Errorf("unexpected size of %s: %d bytes", name, len(data))
+	}
+}
+
+// readLong decodes a C long from the start of data.
+func readLong(data []byte) uint64 {
+	if longSize == 4 {
+		return uint64(binary.NativeEndian.Uint32(data))
+	}
+	return binary.NativeEndian.Uint64(data)
+}
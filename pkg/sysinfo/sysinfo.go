@@ -0,0 +1,45 @@
+package sysinfo
+
+// Snapshot は取得時点のシステムリソース状況です。
+type Snapshot struct {
+	// LoadAverage is the 1-minute load average, or 0 where the OS has no such concept.
+	LoadAverage     float64
+	MemoryTotal     int64
+	MemoryAvailable int64
+	// SwapTotal and SwapFree are 0 where the OS does not report swap.
+	SwapTotal int64
+	SwapFree  int64
+}
+
+// CPUTimes はシステム全体の累積CPU時間です。単位はOS依存のため差分の比率のみ意味を持ちます。
+type CPUTimes struct {
+	Busy  uint64
+	Total uint64
+}
+
+// Utilization は2つの CPUTimes の間のCPU使用率（0.0〜1.0）を返します。
+func Utilization(prev, cur CPUTimes) float64 {
+	total := cur.Total - prev.Total
+	if cur.Total <= prev.Total || total == 0 {
+		return 0
+	}
+	busy := float64(cur.Busy-prev.Busy) / float64(total)
+	if busy < 0 {
+		return 0
+	}
+	if busy > 1 {
+		return 1
+	}
+	return busy
+}
+
+// DiskSpace はファイルシステムの容量です（バイト）。
+type DiskSpace struct {
+	Total     int64
+	Available int64
+}
+
+// Used returns the number of bytes in use on the filesystem.
+func (d DiskSpace) Used() int64 {
+	return d.Total - d.Available
+}
@@ -0,0 +1,23 @@
+//go:build freebsd || openbsd
+
+package sysinfo
+
+import "fmt"
+
+// ReadCPUTimes はシステム全体の累積CPU時間を sysctl の kern.cp_time から取得します。
+func ReadCPUTimes() (CPUTimes, error) {
+	data, err := sysctlBytes("kern.cp_time", longSize)
+	if err != nil {
+		return CPUTimes{}, fmt.Errorf("failed to read kern.cp_time: %v", err)
+	}
+	// The states end with idle on both FreeBSD (user, nice, sys, intr, idle) and
+	// OpenBSD (user, nice, sys, spin, intr, idle)
+	var times CPUTimes
+	var idle uint64
+	for i := 0; i+longSize <= len(data); i += longSize {
+		idle = readLong(data[i:])
+		times.Total += idle
+	}
+	times.Busy = times.Total - idle
+	return times, nil
+}
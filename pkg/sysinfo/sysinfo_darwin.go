@@ -0,0 +1,82 @@
+package sysinfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+)
+
+// Read は現在のシステムリソース状況を sysctl から取得します。
+// 利用可能なメモリは空きページ、パージ可能なページとファイルキャッシュのページの合計です。
+func Read() (Snapshot, error) {
+	var snapshot Snapshot
+
+	// struct loadavg { fixpt_t ldavg[3]; long fscale; }
+	loadavg, err := sysctlBytes("vm.loadavg", longSize)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read load average: %v", err)
+	}
+	if len(loadavg) < 12+longSize {
+		return snapshot, fmt.Errorf("unexpected vm.loadavg size: %d bytes", len(loadavg))
+	}
+	if scale := readLong(loadavg[len(loadavg)-longSize:]); scale > 0 {
+		snapshot.LoadAverage = float64(binary.NativeEndian.Uint32(loadavg)) / float64(scale)
+	}
+
+	total, err := sysctlUint64("hw.memsize")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.memsize: %v", err)
+	}
+	pageSize, err := sysctlUint64("hw.pagesize")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.pagesize: %v", err)
+	}
+	free, err := syscall.SysctlUint32("vm.page_free_count")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read vm.page_free_count: %v", err)
+	}
+	pages := uint64(free)
+	// Purgeable and file-backed pages are reclaimed on demand; older releases
+	// lack the counter of the file-backed ones
+	for _, name := range []string{"vm.page_purgeable_count", "vm.page_pageable_external_count"} {
+		if count, err := syscall.SysctlUint32(name); err == nil {
+			pages += uint64(count)
+		}
+	}
+	snapshot.MemoryTotal = int64(total)
+	snapshot.MemoryAvailable = int64(min(pages*pageSize, total))
+	return snapshot, nil
+}
+
+// ReadCPUTimes は macOS では未対応です。CPU の累積時間は Mach の host_statistics でしか取得できません。
+func ReadCPUTimes() (CPUTimes, error) {
+	return CPUTimes{}, fmt.Errorf("system CPU times are not supported on macOS")
+}
+
+// ReadDiskSpace は指定されたパスを含むファイルシステムの容量を取得します。
+func ReadDiskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, fmt.Errorf("failed to get disk space: %v", err)
+	}
+
+	return DiskSpace{
+		Total:     int64(stat.Blocks) * int64(stat.Bsize),
+		Available: int64(stat.Bavail) * int64(stat.Bsize),
+	}, nil
+}
+
+// ReadTemperature は macOS では未対応です。温度センサーは SMC を通じてしか読み取れません。
+func ReadTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on macOS")
+}
+
+// ReadCPUTemperature は macOS では未対応です。
+func ReadCPUTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on macOS")
+}
+
+// ReadThermalHeadroom は macOS では未対応です。
+func ReadThermalHeadroom() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on macOS")
+}
@@ -0,0 +1,158 @@
+package sysinfo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+)
+
+// deciKelvinZero is 0 °C in the tenths of a kelvin used by FreeBSD temperature sysctls.
+const deciKelvinZero = 2731
+
+// Read は現在のシステムリソース状況を sysctl から取得します。
+// 利用可能なメモリは空きページと非アクティブページの合計です。
+func Read() (Snapshot, error) {
+	var snapshot Snapshot
+
+	// struct loadavg { fixpt_t ldavg[3]; long fscale; }
+	loadavg, err := sysctlBytes("vm.loadavg", longSize)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read load average: %v", err)
+	}
+	if len(loadavg) < 12+longSize {
+		return snapshot, fmt.Errorf("unexpected vm.loadavg size: %d bytes", len(loadavg))
+	}
+	if scale := readLong(loadavg[len(loadavg)-longSize:]); scale > 0 {
+		snapshot.LoadAverage = float64(binary.NativeEndian.Uint32(loadavg)) / float64(scale)
+	}
+
+	total, err := sysctlUint64("hw.physmem")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.physmem: %v", err)
+	}
+	pageSize, err := syscall.SysctlUint32("hw.pagesize")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.pagesize: %v", err)
+	}
+	var pages uint64
+	for _, name := range []string{"vm.stats.vm.v_free_count", "vm.stats.vm.v_inactive_count"} {
+		count, err := syscall.SysctlUint32(name)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		pages += uint64(count)
+	}
+	snapshot.MemoryTotal = int64(total)
+	snapshot.MemoryAvailable = int64(pages * uint64(pageSize))
+	return snapshot, nil
+}
+
+// ReadDiskSpace は指定されたパスを含むファイルシステムの容量を取得します。
+func ReadDiskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, fmt.Errorf("failed to get disk space: %v", err)
+	}
+
+	return DiskSpace{
+		Total:     int64(stat.Blocks) * int64(stat.Bsize),
+		Available: stat.Bavail * int64(stat.Bsize),
+	}, nil
+}
+
+// ReadTemperature は coretemp/amdtemp の CPU センサーと ACPI サーマルゾーンの最高温度（℃）を返します。
+func ReadTemperature() (float64, error) {
+	found := false
+	var highest float64
+	for _, sensor := range temperatureSensors() {
+		if !found || sensor.temp > highest {
+			highest = sensor.temp
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no temperature sensors found (load coretemp or amdtemp)")
+	}
+	return highest, nil
+}
+
+// ReadCPUTemperature は coretemp/amdtemp の CPU センサーの最高温度（℃）を返します。
+func ReadCPUTemperature() (float64, error) {
+	found := false
+	var highest float64
+	for i := 0; ; i++ {
+		temp, err := readDeciKelvin(fmt.Sprintf("dev.cpu.%d.temperature", i))
+		if err != nil {
+			break
+		}
+		if !found || temp > highest {
+			highest = temp
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no CPU temperature sensor found (load coretemp or amdtemp)")
+	}
+	return highest, nil
+}
+
+// ReadThermalHeadroom は各センサーの臨界温度 (CPU の TjMax、ACPI サーマルゾーンの _CRT) までの
+// 余裕（℃）のうち最小のものを返します。
+func ReadThermalHeadroom() (float64, error) {
+	found := false
+	var headroom float64
+	for _, sensor := range temperatureSensors() {
+		if sensor.critical <= 0 {
+			continue
+		}
+		if !found || sensor.critical-sensor.temp < headroom {
+			headroom = sensor.critical - sensor.temp
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no temperature sensors with critical trip points found")
+	}
+	return headroom, nil
+}
+
+// sensor is one temperature reading and its critical temperature, or 0 if unknown.
+type sensor struct {
+	temp, critical float64
+}
+
+// temperatureSensors reads the per-CPU sensors of the coretemp and amdtemp drivers
+// and the ACPI thermal zones.
+func temperatureSensors() []sensor {
+	var sensors []sensor
+	for i := 0; ; i++ {
+		temp, err := readDeciKelvin(fmt.Sprintf("dev.cpu.%d.temperature", i))
+		if err != nil {
+			break
+		}
+		critical, _ := readDeciKelvin(fmt.Sprintf("dev.cpu.%d.coretemp.tjmax", i))
+		sensors = append(sensors, sensor{temp: temp, critical: critical})
+	}
+	for i := 0; ; i++ {
+		temp, err := readDeciKelvin(fmt.Sprintf("hw.acpi.thermal.tz%d.temperature", i))
+		if err != nil {
+			break
+		}
+		critical, _ := readDeciKelvin(fmt.Sprintf("hw.acpi.thermal.tz%d._CRT", i))
+		sensors = append(sensors, sensor{temp: temp, critical: critical})
+	}
+	return sensors
+}
+
+// readDeciKelvin reads a temperature sysctl expressed in tenths of a kelvin.
+func readDeciKelvin(name string) (float64, error) {
+	value, err := syscall.SysctlUint32(name)
+	if err != nil {
+		return 0, err
+	}
+	// Unavailable readings, such as _CRT of a zone without one, are -1
+	if int32(value) <= 0 {
+		return 0, fmt.Errorf("%s is not available", name)
+	}
+	return float64(int32(value)-deciKelvinZero) / 10, nil
+}
@@ -0,0 +1,257 @@
+package sysinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Read は現在のシステムリソース状況を /proc から取得します。
+func Read() (Snapshot, error) {
+	var snapshot Snapshot
+
+	loadavg, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read load average: %v", err)
+	}
+	fields := strings.Fields(string(loadavg))
+	if len(fields) == 0 {
+		return snapshot, fmt.Errorf("unexpected /proc/loadavg format")
+	}
+	if snapshot.LoadAverage, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return snapshot, fmt.Errorf("invalid load average: %v", err)
+	}
+
+	meminfo, err := readMeminfo()
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.MemoryTotal = meminfo["MemTotal"]
+	snapshot.MemoryAvailable = meminfo["MemAvailable"]
+	snapshot.SwapTotal = meminfo["SwapTotal"]
+	snapshot.SwapFree = meminfo["SwapFree"]
+
+	return snapshot, nil
+}
+
+// readMeminfo parses /proc/meminfo into a map of byte values keyed by field name.
+func readMeminfo() (map[string]int64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory information: %v", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]int64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Lines look like "MemAvailable:   12345678 kB"
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && fields[1] == "kB" {
+			value *= 1024
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
+
+// ReadCPUTimes はシステム全体の累積CPU時間を /proc/stat から取得します。
+func ReadCPUTimes() (CPUTimes, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return CPUTimes{}, fmt.Errorf("failed to read CPU statistics: %v", err)
+	}
+
+	// The first line aggregates all CPUs: "cpu user nice system idle iowait irq softirq steal ..."
+	line, _, _ := strings.Cut(string(data), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return CPUTimes{}, fmt.Errorf("unexpected /proc/stat format")
+	}
+
+	var times CPUTimes
+	for i, field := range fields[1:] {
+		// guest and guest_nice are already included in user and nice
+		if i >= 8 {
+			break
+		}
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return CPUTimes{}, fmt.Errorf("invalid CPU statistics: %v", err)
+		}
+		times.Total += value
+		// idle and iowait are not busy time
+		if i != 3 && i != 4 {
+			times.Busy += value
+		}
+	}
+	return times, nil
+}
+
+// ReadDiskSpace は指定されたパスを含むファイルシステムの容量を取得します。
+func ReadDiskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, fmt.Errorf("failed to get disk space: %v", err)
+	}
+
+	return DiskSpace{
+		Total:     int64(stat.Blocks) * int64(stat.Bsize),
+		Available: int64(stat.Bavail) * int64(stat.Bsize),
+	}, nil
+}
+
+// ReadTemperature はサーマルゾーンおよび hwmon センサーの最高温度（℃）を返します。
+func ReadTemperature() (float64, error) {
+	var paths []string
+	for _, pattern := range []string{
+		"/sys/class/thermal/thermal_zone*/temp",
+		"/sys/class/hwmon/hwmon*/temp*_input",
+	} {
+		matches, _ := filepath.Glob(pattern)
+		paths = append(paths, matches...)
+	}
+
+	found := false
+	var highest float64
+	for _, path := range paths {
+		celsius, err := readMilliCelsius(path)
+		if err != nil {
+			continue
+		}
+		if !found || celsius > highest {
+			highest = celsius
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no temperature sensors found")
+	}
+	return highest, nil
+}
+
+// cpuSensorDrivers are the hwmon drivers that report the temperature of the CPU die.
+var cpuSensorDrivers = []string{"coretemp", "k10temp", "zenpower", "cpu_thermal", "soc_thermal"}
+
+// cpuThermalZones are the types of the thermal zones that cover the CPU, for hosts
+// without a CPU hwmon driver (mostly ARM boards).
+var cpuThermalZones = []string{"x86_pkg_temp", "cpu-thermal", "cpu_thermal", "soc-thermal", "soc_thermal"}
+
+// ReadCPUTemperature は CPU ダイの温度センサー (coretemp・k10temp などの hwmon、または CPU のサーマルゾーン) の
+// 最高温度（℃）を返します。ディスクや無線 LAN など CPU 以外のセンサーは含みません。
+func ReadCPUTemperature() (float64, error) {
+	var paths []string
+	hwmons, _ := filepath.Glob("/sys/class/hwmon/hwmon*")
+	for _, hwmon := range hwmons {
+		name, err := os.ReadFile(filepath.Join(hwmon, "name"))
+		if err != nil || !slices.Contains(cpuSensorDrivers, strings.TrimSpace(string(name))) {
+			continue
+		}
+		inputs, _ := filepath.Glob(filepath.Join(hwmon, "temp*_input"))
+		paths = append(paths, inputs...)
+	}
+	if len(paths) == 0 {
+		zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*")
+		for _, zone := range zones {
+			zoneType, err := os.ReadFile(filepath.Join(zone, "type"))
+			if err == nil && slices.Contains(cpuThermalZones, strings.TrimSpace(string(zoneType))) {
+				paths = append(paths, filepath.Join(zone, "temp"))
+			}
+		}
+	}
+
+	found := false
+	var highest float64
+	for _, path := range paths {
+		celsius, err := readMilliCelsius(path)
+		if err != nil {
+			continue
+		}
+		if !found || celsius > highest {
+			highest = celsius
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no CPU temperature sensor found (coretemp, k10temp or a CPU thermal zone)")
+	}
+	return highest, nil
+}
+
+// ReadThermalHeadroom は各センサーの臨界温度（critical トリップポイント）までの
+// 余裕（℃）のうち最小のものを返します。
+func ReadThermalHeadroom() (float64, error) {
+	found := false
+	var headroom float64
+	update := func(temp, critical float64) {
+		if !found || critical-temp < headroom {
+			headroom = critical - temp
+			found = true
+		}
+	}
+
+	zones, _ := filepath.Glob("/sys/class/thermal/thermal_zone*")
+	for _, zone := range zones {
+		temp, err := readMilliCelsius(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		types, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+		for _, typePath := range types {
+			tripType, err := os.ReadFile(typePath)
+			if err != nil || strings.TrimSpace(string(tripType)) != "critical" {
+				continue
+			}
+			critical, err := readMilliCelsius(strings.TrimSuffix(typePath, "_type") + "_temp")
+			if err == nil && critical > 0 {
+				update(temp, critical)
+			}
+		}
+	}
+
+	inputs, _ := filepath.Glob("/sys/class/hwmon/hwmon*/temp*_input")
+	for _, input := range inputs {
+		temp, err := readMilliCelsius(input)
+		if err != nil {
+			continue
+		}
+		critical, err := readMilliCelsius(strings.TrimSuffix(input, "_input") + "_crit")
+		if err == nil && critical > 0 {
+			update(temp, critical)
+		}
+	}
+
+	if !found {
+		return 0, fmt.Errorf("no temperature sensors with critical trip points found")
+	}
+	return headroom, nil
+}
+
+// readMilliCelsius reads a sysfs temperature file expressed in millidegrees Celsius.
+func readMilliCelsius(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	milli, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(milli) / 1000, nil
+}
@@ -0,0 +1,102 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Read は現在のシステムリソース状況を sysctl と vmstat から取得します。
+// 利用可能なメモリは空きページと非アクティブページの合計です。
+func Read() (Snapshot, error) {
+	var snapshot Snapshot
+
+	// vm.loadavg and vm.uvmexp are not reachable by name through the syscall
+	// package on OpenBSD, so they are read with the base system's tools
+	out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read load average: %v", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return snapshot, fmt.Errorf("unexpected vm.loadavg format")
+	}
+	if snapshot.LoadAverage, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return snapshot, fmt.Errorf("invalid load average: %v", err)
+	}
+
+	total, err := sysctlUint64("hw.physmem")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.physmem: %v", err)
+	}
+	pageSize, err := syscall.SysctlUint32("hw.pagesize")
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read hw.pagesize: %v", err)
+	}
+	pages, err := readFreePages()
+	if err != nil {
+		return snapshot, err
+	}
+	snapshot.MemoryTotal = int64(total)
+	snapshot.MemoryAvailable = pages * int64(pageSize)
+	return snapshot, nil
+}
+
+// readFreePages returns the number of free and inactive pages from "vmstat -s".
+func readFreePages() (int64, error) {
+	out, err := exec.Command("vmstat", "-s").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run vmstat: %v", err)
+	}
+	var pages int64
+	found := 0
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		count, label, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if !ok || (label != "pages free" && label != "pages inactive") {
+			continue
+		}
+		n, err := strconv.ParseInt(count, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid vmstat output %q: %v", scanner.Text(), err)
+		}
+		pages += n
+		found++
+	}
+	if found == 0 {
+		return 0, fmt.Errorf("free pages not found in vmstat output")
+	}
+	return pages, nil
+}
+
+// ReadDiskSpace は指定されたパスを含むファイルシステムの容量を取得します。
+func ReadDiskSpace(path string) (DiskSpace, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskSpace{}, fmt.Errorf("failed to get disk space: %v", err)
+	}
+
+	return DiskSpace{
+		Total:     int64(stat.F_blocks) * int64(stat.F_bsize),
+		Available: stat.F_bavail * int64(stat.F_bsize),
+	}, nil
+}
+
+// ReadTemperature は OpenBSD では未対応です。
+func ReadTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on OpenBSD")
+}
+
+// ReadCPUTemperature は OpenBSD では未対応です。
+func ReadCPUTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on OpenBSD")
+}
+
+// ReadThermalHeadroom は OpenBSD では未対応です。
+func ReadThermalHeadroom() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on OpenBSD")
+}
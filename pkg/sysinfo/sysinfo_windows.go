@@ -0,0 +1,100 @@
+package sysinfo
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32             = syscall.NewLazyDLL("kernel32.dll")
+	globalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	getSystemTimes       = kernel32.NewProc("GetSystemTimes")
+	getDiskFreeSpaceEx   = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// memoryStatusEx mirrors the Win32 MEMORYSTATUSEX structure.
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// Read は現在のシステムリソース状況を Windows API から取得します。
+// Windows にはロードアベレージがないため LoadAverage は常に 0 です。
+func Read() (Snapshot, error) {
+	var snapshot Snapshot
+
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	ret, _, errno := globalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return snapshot, fmt.Errorf("failed to get memory status: %v", errno)
+	}
+
+	snapshot.MemoryTotal = int64(status.TotalPhys)
+	snapshot.MemoryAvailable = int64(status.AvailPhys)
+	return snapshot, nil
+}
+
+// ReadCPUTimes はシステム全体の累積CPU時間を GetSystemTimes から取得します。
+func ReadCPUTimes() (CPUTimes, error) {
+	var idle, kernel, user syscall.Filetime
+	ret, _, errno := getSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+	if ret == 0 {
+		return CPUTimes{}, fmt.Errorf("failed to get system times: %v", errno)
+	}
+
+	ticks := func(ft syscall.Filetime) uint64 {
+		return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+	}
+	// Kernel time includes idle time
+	total := ticks(kernel) + ticks(user)
+	return CPUTimes{Busy: total - ticks(idle), Total: total}, nil
+}
+
+// ReadDiskSpace は指定されたパスを含むボリュームの容量を取得します。
+func ReadDiskSpace(path string) (DiskSpace, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return DiskSpace{}, fmt.Errorf("path conversion error: %v", err)
+	}
+
+	var freeBytesAvailable, totalNumberOfBytes, totalNumberOfFreeBytes uint64
+	ret, _, errno := getDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalNumberOfBytes)),
+		uintptr(unsafe.Pointer(&totalNumberOfFreeBytes)),
+	)
+	if ret == 0 {
+		return DiskSpace{}, fmt.Errorf("failed to get disk space: %v", errno)
+	}
+
+	return DiskSpace{Total: int64(totalNumberOfBytes), Available: int64(freeBytesAvailable)}, nil
+}
+
+// ReadTemperature は Windows では未対応です。
+func ReadTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on Windows")
+}
+
+// ReadCPUTemperature は Windows では未対応です。
+func ReadCPUTemperature() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on Windows")
+}
+
+// ReadThermalHeadroom は Windows では未対応です。
+func ReadThermalHeadroom() (float64, error) {
+	return 0, fmt.Errorf("temperature sensors are not supported on Windows")
+}
@@ -0,0 +1,132 @@
+package sysinfo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ThreadAccounting は ThreadCPUTime でスレッドごとの CPU 時間を計測できるかどうかです。
+const ThreadAccounting = true
+
+// cpuMask is a CPU affinity mask for up to 1024 CPUs, as used by glibc.
+type cpuMask [16]uint64
+
+func (m *cpuMask) has(cpu int) bool {
+	return cpu >= 0 && cpu < len(m)*64 && m[cpu/64]&(1<<(cpu%64)) != 0
+}
+
+// getAffinity returns the affinity mask of the calling thread.
+func getAffinity() (cpuMask, error) {
+	var mask cpuMask
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_GETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return mask, errno
+	}
+	return mask, nil
+}
+
+// setAffinity restricts thread tid, or the calling thread if tid is 0, to mask.
+func setAffinity(tid int, mask *cpuMask) error {
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid), unsafe.Sizeof(*mask), uintptr(unsafe.Pointer(mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// CurrentThread は呼び出し元の OS スレッドの ID を返します。
+func CurrentThread() int {
+	return syscall.Gettid()
+}
+
+// ThreadCPUTime はこのプロセスのスレッド tid が使用した CPU 時間を返します。
+// /proc/stat のクロックティックではなく、スケジューラーの統計 (schedstat) からナノ秒単位で取得します。
+//
+// 引数:
+//
+//	tid - 対象のスレッド ID (CurrentThread の値)
+func ThreadCPUTime(tid int) (time.Duration, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/self/task/%d/schedstat", tid))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty schedstat for thread %d", tid)
+	}
+	ns, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schedstat for thread %d: %v", tid, err)
+	}
+	return time.Duration(ns), nil
+}
+
+// AllowedCPUs はプロセスが実行できる CPU (cpuset とアフィニティ) の番号を返します。
+func AllowedCPUs() ([]int, error) {
+	mask, err := getAffinity()
+	if err != nil {
+		return nil, err
+	}
+	var cpus []int
+	for i := range len(mask) * 64 {
+		if mask.has(i) {
+			cpus = append(cpus, i)
+		}
+	}
+	return cpus, nil
+}
+
+// PinThread は呼び出し元の OS スレッドを cpu でのみ実行するように制限します。
+// 呼び出し元は runtime.LockOSThread でゴルーチンをスレッドに固定しておく必要があります。
+//
+// 引数:
+//
+//	cpu - スレッドを実行する CPU の番号
+func PinThread(cpu int) error {
+	var mask cpuMask
+	mask[cpu/64] |= 1 << (cpu % 64)
+	return setAffinity(0, &mask)
+}
+
+// ExcludeCPU はプロセスのすべてのスレッドのアフィニティから cpu を除きます。
+// 以降に作成されるスレッドは作成元のスレッドのアフィニティを引き継ぎます。
+//
+// 引数:
+//
+//	cpu - 除外する CPU の番号
+func ExcludeCPU(cpu int) error {
+	mask, err := getAffinity()
+	if err != nil {
+		return fmt.Errorf("failed to read the CPU affinity: %v", err)
+	}
+	if !mask.has(cpu) {
+		return fmt.Errorf("CPU %d is not available to the process", cpu)
+	}
+	mask[cpu/64] &^= 1 << (cpu % 64)
+	if mask == (cpuMask{}) {
+		return fmt.Errorf("CPU %d is the only CPU available to the process", cpu)
+	}
+
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return fmt.Errorf("failed to list the threads: %v", err)
+	}
+	tids := []int{}
+	for _, e := range entries {
+		if tid, err := strconv.Atoi(e.Name()); err == nil {
+			tids = append(tids, tid)
+		}
+	}
+	for _, tid := range tids {
+		// A thread may exit while the list is walked
+		if err := setAffinity(tid, &mask); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("failed to set the CPU affinity of thread %d: %v", tid, err)
+		}
+	}
+	return nil
+}
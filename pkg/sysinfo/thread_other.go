@@ -0,0 +1,51 @@
+//go:build !linux
+
+package sysinfo
+
+import (
+	"errors"
+	"time"
+)
+
+// ThreadAccounting は ThreadCPUTime でスレッドごとの CPU 時間を計測できるかどうかです。
+const ThreadAccounting = false
+
+// errThreadUnsupported is returned where threads cannot be measured or confined.
+var errThreadUnsupported = errors.New("per-thread CPU accounting and affinity are only supported on Linux")
+
+// CurrentThread は呼び出し元の OS スレッドの ID を返します。Linux 以外では常に 0 です。
+func CurrentThread() int {
+	return 0
+}
+
+// ThreadCPUTime はスレッドの CPU 時間を返します。Linux 以外では常にエラーを返します。
+//
+// 引数:
+//
+//	tid - 対象のスレッド ID (CurrentThread の値)
+func ThreadCPUTime(tid int) (time.Duration, error) {
+	return 0, errThreadUnsupported
+}
+
+// AllowedCPUs はプロセスが実行できる CPU の番号を返します。Linux 以外では常にエラーを返します。
+func AllowedCPUs() ([]int, error) {
+	return nil, errThreadUnsupported
+}
+
+// PinThread は呼び出し元の OS スレッドを cpu に制限します。Linux 以外では常にエラーを返します。
+//
+// 引数:
+//
+//	cpu - スレッドを実行する CPU の番号
+func PinThread(cpu int) error {
+	return errThreadUnsupported
+}
+
+// ExcludeCPU はプロセスのスレッドのアフィニティから cpu を除きます。Linux 以外では常にエラーを返します。
+//
+// 引数:
+//
+//	cpu - 除外する CPU の番号
+func ExcludeCPU(cpu int) error {
+	return errThreadUnsupported
+}
@@ -0,0 +1,116 @@
+package sysinfo
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// CoreCluster は種類と性能が同じ CPU コアのまとまりです
+// (big.LITTLE の big コア群、ハイブリッド構成の高性能コア群など)。
+type CoreCluster struct {
+	// Name はコアの名前です (例: "Neoverse-N1", "Cortex-A55")。不明な場合は空です。
+	Name string
+	// CPUs はクラスタに属する論理 CPU の番号です。
+	CPUs []int
+	// Capacity はカーネルが示す相対性能 (最も速いコアが 1024) です。不明な場合は 0 です。
+	Capacity int
+	// MaxFreqMHz は最大クロック周波数 (MHz) です。不明な場合は 0 です。
+	MaxFreqMHz int
+}
+
+// Topology は CPU の構成です。
+type Topology struct {
+	// Arch は CPU アーキテクチャ (runtime.GOARCH) です。
+	Arch string
+	// Features は CPU が対応する拡張命令の名前 (例: "asimd", "sve", "avx2") です。
+	Features []string
+	// Clusters はコアのクラスタです。性能の高い順に並びます。
+	Clusters []CoreCluster
+}
+
+// Heterogeneous は性能の異なるコアが混在する (big.LITTLE などの) 構成かどうかを返します。
+func (t Topology) Heterogeneous() bool {
+	return len(t.Clusters) > 1
+}
+
+// HasFeature は CPU が拡張命令 name に対応しているかどうかを返します。
+//
+// 引数:
+//
+//	name - /proc/cpuinfo の表記による拡張命令の名前 (例: "sve")
+func (t Topology) HasFeature(name string) bool {
+	return slices.Contains(t.Features, name)
+}
+
+// CPUs は論理 CPU の番号を性能の高いクラスタから順に返します。
+func (t Topology) CPUs() []int {
+	var cpus []int
+	for _, c := range t.Clusters {
+		cpus = append(cpus, c.CPUs...)
+	}
+	return cpus
+}
+
+// String は構成を "4x Cortex-X1 (2.80 GHz) + 4x Cortex-A55 (1.80 GHz)" の形式で返します。
+func (t Topology) String() string {
+	parts := make([]string, 0, len(t.Clusters))
+	for _, c := range t.Clusters {
+		part := fmt.Sprintf("%dx", len(c.CPUs))
+		if c.Name != "" {
+			part += " " + c.Name
+		}
+		var details []string
+		if c.MaxFreqMHz > 0 {
+			details = append(details, fmt.Sprintf("%.2f GHz", float64(c.MaxFreqMHz)/1000))
+		}
+		if c.Capacity > 0 && t.Heterogeneous() {
+			details = append(details, fmt.Sprintf("capacity %d", c.Capacity))
+		}
+		if len(details) > 0 {
+			part += " (" + strings.Join(details, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// cpuDesc is what is known about one logical CPU while the topology is being read.
+type cpuDesc struct {
+	id       int
+	name     string
+	capacity int
+	maxFreq  int
+}
+
+// groupClusters groups CPUs of the same kind, fastest cluster first.
+func groupClusters(cpus []cpuDesc) []CoreCluster {
+	var clusters []CoreCluster
+	for _, cpu := range cpus {
+		i := slices.IndexFunc(clusters, func(c CoreCluster) bool {
+			return c.Name == cpu.name && c.Capacity == cpu.capacity && c.MaxFreqMHz == cpu.maxFreq
+		})
+		if i < 0 {
+			clusters = append(clusters, CoreCluster{Name: cpu.name, Capacity: cpu.capacity, MaxFreqMHz: cpu.maxFreq})
+			i = len(clusters) - 1
+		}
+		clusters[i].CPUs = append(clusters[i].CPUs, cpu.id)
+	}
+	slices.SortStableFunc(clusters, func(a, b CoreCluster) int {
+		if a.Capacity != b.Capacity {
+			return b.Capacity - a.Capacity
+		}
+		return b.MaxFreqMHz - a.MaxFreqMHz
+	})
+	return clusters
+}
+
+// uniformTopology describes NumCPU identical CPUs, for systems that expose no details.
+func uniformTopology() Topology {
+	cpus := make([]cpuDesc, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i].id = i
+	}
+	return Topology{Arch: runtime.GOARCH, Clusters: groupClusters(cpus)}
+}
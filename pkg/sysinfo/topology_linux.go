@@ -0,0 +1,109 @@
+package sysinfo
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// armCores names the cores of common ARM servers and SoCs by implementer and part
+// number, as shown by "CPU implementer" and "CPU part" in /proc/cpuinfo.
+var armCores = map[string]string{
+	"0x41/0xd03": "Cortex-A53", "0x41/0xd04": "Cortex-A35", "0x41/0xd05": "Cortex-A55",
+	"0x41/0xd07": "Cortex-A57", "0x41/0xd08": "Cortex-A72", "0x41/0xd09": "Cortex-A73",
+	"0x41/0xd0a": "Cortex-A75", "0x41/0xd0b": "Cortex-A76", "0x41/0xd0c": "Neoverse-N1",
+	"0x41/0xd0d": "Cortex-A77", "0x41/0xd40": "Neoverse-V1", "0x41/0xd41": "Cortex-A78",
+	"0x41/0xd44": "Cortex-X1", "0x41/0xd46": "Cortex-A510", "0x41/0xd47": "Cortex-A710",
+	"0x41/0xd48": "Cortex-X2", "0x41/0xd49": "Neoverse-N2", "0x41/0xd4b": "Cortex-A78C",
+	"0x41/0xd4d": "Cortex-A715", "0x41/0xd4e": "Cortex-X3", "0x41/0xd4f": "Neoverse-V2",
+	"0x41/0xd80": "Cortex-A520", "0x41/0xd81": "Cortex-A720", "0x41/0xd82": "Cortex-X4",
+	"0x41/0xd84": "Neoverse-V3", "0x41/0xd8e": "Neoverse-N3",
+	"0x46/0x001": "A64FX",
+	"0x48/0xd01": "TaiShan-V110",
+	"0x61/0x022": "Icestorm", "0x61/0x023": "Firestorm", "0x61/0x024": "Icestorm",
+	"0x61/0x025": "Firestorm", "0x61/0x028": "Icestorm", "0x61/0x029": "Firestorm",
+	"0x61/0x032": "Blizzard", "0x61/0x033": "Avalanche",
+	"0xc0/0xac3": "Ampere-1", "0xc0/0xac4": "Ampere-1A",
+}
+
+// ReadTopology は /proc/cpuinfo と /sys/devices/system/cpu から CPU の構成を取得します。
+// ARM の big.LITTLE や x86 のハイブリッド構成では、コアの種類・相対性能・最大周波数ごとに
+// クラスタを分けます。
+func ReadTopology() (Topology, error) {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return uniformTopology(), fmt.Errorf("cannot read CPU information: %v", err)
+	}
+	defer f.Close()
+
+	t := Topology{Arch: runtime.GOARCH}
+	var cpus []cpuDesc
+	var current *cpuDesc
+	var implementer string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				continue
+			}
+			cpus = append(cpus, cpuDesc{id: id})
+			current = &cpus[len(cpus)-1]
+		case "model name":
+			if current != nil {
+				current.name = value
+			}
+		case "CPU implementer":
+			implementer = value
+		case "CPU part":
+			if current != nil {
+				part := implementer + "/" + value
+				if name, ok := armCores[part]; ok {
+					current.name = name
+				} else {
+					current.name = part
+				}
+			}
+		case "Features", "flags":
+			if t.Features == nil {
+				t.Features = strings.Fields(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return uniformTopology(), fmt.Errorf("cannot read CPU information: %v", err)
+	}
+	if len(cpus) == 0 {
+		return uniformTopology(), nil
+	}
+
+	for i := range cpus {
+		dir := fmt.Sprintf("/sys/devices/system/cpu/cpu%d", cpus[i].id)
+		cpus[i].capacity = readIntFile(dir + "/cpu_capacity")
+		cpus[i].maxFreq = readIntFile(dir+"/cpufreq/cpuinfo_max_freq") / 1000
+	}
+	t.Clusters = groupClusters(cpus)
+	return t, nil
+}
+
+// readIntFile returns the integer in a sysfs file, or 0 if it cannot be read.
+func readIntFile(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return value
+}
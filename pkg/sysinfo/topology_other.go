@@ -0,0 +1,9 @@
+//go:build !linux
+
+package sysinfo
+
+// ReadTopology は CPU の構成を返します。Linux 以外ではコアの詳細を取得できないため、
+// 論理 CPU 数分の同じコアからなる構成を返します。
+func ReadTopology() (Topology, error) {
+	return uniformTopology(), nil
+}
@@ -0,0 +1,90 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Notify は systemd に状態を通知します（sd_notify 相当）。
+// systemd 配下で実行されていない（NOTIFY_SOCKET が未設定の）場合は何もせず false を返します。
+func Notify(state string) (bool, error) {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return false, nil
+	}
+
+	// A leading '@' denotes a socket in the abstract namespace
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to send notification: %v", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval は systemd が要求するウォッチドッグの間隔を返します。
+// ウォッチドッグが無効、または別プロセス宛ての場合は 0 を返します。
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Watchdog は負荷や後始末の進行が報告された場合にだけ systemd のウォッチドッグに通知します。
+// 進行が止まった (負荷生成モジュールやメインループが応答しない) 場合は通知をやめ、systemd に再起動させます。
+type Watchdog struct {
+	progressed atomic.Bool
+}
+
+// Progress は前回の通知以降に進行があったことを報告します。
+func (w *Watchdog) Progress() {
+	w.progressed.Store(true)
+}
+
+// Run は ctx が終了するまで、要求間隔の半分ごとに、その間に Progress で進行が報告されていれば WATCHDOG=1 を送信します。
+//
+// 引数:
+//
+//	ctx  - 通知を終了するためのコンテキスト
+//	logf - 通知に失敗した場合の警告の出力先
+func (w *Watchdog) Run(ctx context.Context, logf func(format string, args ...any)) {
+	interval := WatchdogInterval()
+	if interval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !w.progressed.Swap(false) {
+				continue
+			}
+			if _, err := Notify("WATCHDOG=1"); err != nil {
+				logf("Warning: %v\n", err)
+			}
+		}
+	}
+}
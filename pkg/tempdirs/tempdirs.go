@@ -0,0 +1,42 @@
+// Package tempdirs は負荷生成モジュールが作成した一時ディレクトリを記録し、終了時に削除されたことを確認します。
+//
+// 負荷生成モジュールは一時ディレクトリを作成したら Track で記録し、停止するときに削除します。
+// 実行の終了時に Remaining を呼び出すと、削除されずに残っているディレクトリがわかります。
+package tempdirs
+
+import (
+	"os"
+	"slices"
+	"sync"
+)
+
+var (
+	mu      sync.Mutex
+	tracked []string
+)
+
+// Track は負荷生成モジュールが作成した一時ディレクトリ dir を記録します。
+//
+// 引数:
+//
+//	dir - 作成した一時ディレクトリ
+func Track(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(tracked, dir) {
+		tracked = append(tracked, dir)
+	}
+}
+
+// Remaining は Track で記録したディレクトリのうち、まだ存在するものを返します。
+func Remaining() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var remaining []string
+	for _, dir := range tracked {
+		if _, err := os.Lstat(dir); err == nil {
+			remaining = append(remaining, dir)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,24 @@
+package tempdirs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRemaining(t *testing.T) {
+	kept, removed := t.TempDir(), filepath.Join(t.TempDir(), "removed")
+	if err := os.Mkdir(removed, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	Track(kept)
+	Track(removed)
+	Track(kept)
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	if got := Remaining(); !slices.Equal(got, []string{kept}) {
+		t.Errorf("Remaining() = %v, want [%s]", got, kept)
+	}
+}
@@ -0,0 +1,138 @@
+// Package tenant は負荷を cgroup v2 の子 cgroup (テナント) に分けて実行し、
+// 各テナントの制限 (CPU・メモリ・I/O) が実際に守られているかを計測します。
+package tenant
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+)
+
+// cpuPeriod is the cpu.max period the CPU limits are written with, the
+// kernel's default of 100ms.
+const cpuPeriod = 100 * time.Millisecond
+
+// Limits は1つのテナントの cgroup に設定する制限です。0 の項目は設定しません (親の設定を引き継ぎます)。
+type Limits struct {
+	// CPUMax は使用できる CPU の上限 (コア数、cpu.max) です。
+	CPUMax float64
+	// CPUWeight は CPU を取り合う場合の重み (1〜10000、cpu.weight) です。
+	CPUWeight int
+	// MemoryMax はメモリの上限 (バイト、memory.max) です。超えると OOM killer が動作します。
+	MemoryMax int64
+	// MemoryHigh はメモリの抑制の閾値 (バイト、memory.high) です。超えると回収が強制され、処理が遅くなります。
+	MemoryHigh int64
+	// IOWeight は I/O を取り合う場合の重み (1〜10000、io.weight) です。
+	IOWeight int
+	// PidsMax はプロセス・スレッド数の上限 (pids.max) です。
+	PidsMax int
+}
+
+// ParseLimits は "cpu-max=1.5,memory-max=2GB" の形式の制限を解析します。空の場合は制限を設定しません。
+//
+// 引数:
+//
+//	spec - カンマ区切りの key=value (cpu-max, cpu-weight, memory-max, memory-high, io-weight, pids-max)
+func ParseLimits(spec string) (Limits, error) {
+	var l Limits
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Limits{}, fmt.Errorf("invalid limit %q (expected key=value)", field)
+		}
+		var err error
+		switch key {
+		case "cpu-max":
+			l.CPUMax, err = strconv.ParseFloat(value, 64)
+			if err == nil && l.CPUMax <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "cpu-weight":
+			l.CPUWeight, err = parseWeight(value)
+		case "memory-max":
+			l.MemoryMax, err = bytesize.ParseAbsolute(value)
+		case "memory-high":
+			l.MemoryHigh, err = bytesize.ParseAbsolute(value)
+		case "io-weight":
+			l.IOWeight, err = parseWeight(value)
+		case "pids-max":
+			l.PidsMax, err = strconv.Atoi(value)
+			if err == nil && l.PidsMax <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		default:
+			return Limits{}, fmt.Errorf("unknown limit %q (cpu-max, cpu-weight, memory-max, memory-high, io-weight or pids-max)", key)
+		}
+		if err != nil {
+			return Limits{}, fmt.Errorf("invalid %s %q: %v", key, value, err)
+		}
+	}
+	return l, nil
+}
+
+// parseWeight parses a cpu.weight or io.weight value.
+func parseWeight(value string) (int, error) {
+	weight, err := strconv.Atoi(value)
+	if err == nil && (weight < 1 || weight > 10000) {
+		err = fmt.Errorf("must be in range 1-10000")
+	}
+	return weight, err
+}
+
+// String は制限を cgroup のファイル名で表します。制限がない場合は "none" です。
+func (l Limits) String() string {
+	var parts []string
+	if l.CPUMax > 0 {
+		parts = append(parts, fmt.Sprintf("cpu.max %.2f cores", l.CPUMax))
+	}
+	if l.CPUWeight > 0 {
+		parts = append(parts, fmt.Sprintf("cpu.weight %d", l.CPUWeight))
+	}
+	if l.MemoryMax > 0 {
+		parts = append(parts, fmt.Sprintf("memory.max %d MiB", l.MemoryMax/(1024*1024)))
+	}
+	if l.MemoryHigh > 0 {
+		parts = append(parts, fmt.Sprintf("memory.high %d MiB", l.MemoryHigh/(1024*1024)))
+	}
+	if l.IOWeight > 0 {
+		parts = append(parts, fmt.Sprintf("io.weight %d", l.IOWeight))
+	}
+	if l.PidsMax > 0 {
+		parts = append(parts, fmt.Sprintf("pids.max %d", l.PidsMax))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Stats は1つのテナントの cgroup の累積の統計です。
+type Stats struct {
+	// CPUUsage は cgroup 内のプロセスが使用した CPU 時間です (cpu.stat の usage_usec)。
+	CPUUsage time.Duration
+	// Periods と ThrottledPeriods は cpu.max の制御周期の数と、そのうち上限に達して停止させられた周期の数です。
+	Periods, ThrottledPeriods int64
+	// Throttled は上限に達して停止させられていた時間の合計です (throttled_usec)。
+	Throttled time.Duration
+	// MemoryCurrent と MemoryPeak は現在と最大のメモリ使用量 (バイト) です。MemoryPeak はカーネルが memory.peak を提供しない場合 0 です。
+	MemoryCurrent, MemoryPeak int64
+	// MemoryHighEvents と MemoryMaxEvents は memory.high を超えて回収を強制された回数と、memory.max に達した回数です。
+	MemoryHighEvents, MemoryMaxEvents int64
+	// OOMKills は OOM killer が cgroup 内のプロセスを停止した回数です。
+	OOMKills int64
+}
+
+// ThrottledShare は制御周期のうち上限に達して停止させられた周期の割合 (0〜1) です。
+func (s Stats) ThrottledShare() float64 {
+	if s.Periods == 0 {
+		return 0
+	}
+	return float64(s.ThrottledPeriods) / float64(s.Periods)
+}
@@ -0,0 +1,222 @@
+package tenant
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// controllers are the cgroup v2 controllers that the limits are written to.
+var controllers = []string{"cpu", "memory", "io", "pids"}
+
+// Hierarchy はテナントの cgroup をまとめる親 cgroup です。NewHierarchy で作成し、Remove で削除します。
+type Hierarchy struct {
+	dir    string
+	groups []*Group
+}
+
+// Group は1つのテナントの cgroup です。Hierarchy.Add で作成します。
+type Group struct {
+	// Name はテナントの名前で、cgroup のディレクトリ名です。
+	Name string
+	// Limits は cgroup に設定した制限です。
+	Limits Limits
+	dir    string
+}
+
+// NewHierarchy は cgroup v2 の最上位に name の親 cgroup を作成し、テナントの cgroup で CPU・メモリ・I/O・プロセス数を制限できるようにします。
+// cgroup を作成する権限 (通常は root) が必要です。
+//
+// 引数:
+//
+//	name - 親 cgroup のディレクトリ名
+func NewHierarchy(name string) (*Hierarchy, error) {
+	root, err := sysinfo.CgroupV2Root()
+	if err != nil {
+		return nil, fmt.Errorf("cgroup v2 is required: %v", err)
+	}
+	if err := enableControllers(root); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(root, name)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %v", dir, err)
+	}
+	h := &Hierarchy{dir: dir}
+	if err := enableControllers(dir); err != nil {
+		h.Remove()
+		return nil, err
+	}
+	return h, nil
+}
+
+// enableControllers makes the controllers that dir offers available to its
+// children. A controller the kernel does not offer is left out; the limits
+// that need it fail when they are written.
+func enableControllers(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("failed to read the controllers of %s: %v", dir, err)
+	}
+	available := strings.Fields(string(data))
+	for _, c := range controllers {
+		if !slices.Contains(available, c) {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte("+"+c), 0); err != nil {
+			if errors.Is(err, syscall.EBUSY) {
+				// A cgroup other than the root cannot both hold processes and delegate controllers
+				return fmt.Errorf("failed to enable the %s controller in %s: the cgroup holds processes of its own", c, dir)
+			}
+			return fmt.Errorf("failed to enable the %s controller in %s: %v", c, dir, err)
+		}
+	}
+	return nil
+}
+
+// Add はテナント name の cgroup を作成し、limits を設定します。
+//
+// 引数:
+//
+//	name   - テナントの名前 (cgroup のディレクトリ名)
+//	limits - 設定する制限
+func (h *Hierarchy) Add(name string, limits Limits) (*Group, error) {
+	g := &Group{Name: name, Limits: limits, dir: filepath.Join(h.dir, name)}
+	if err := os.Mkdir(g.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %v", g.dir, err)
+	}
+	h.groups = append(h.groups, g)
+
+	type setting struct{ file, value string }
+	var settings []setting
+	if limits.CPUMax > 0 {
+		period := cpuPeriod.Microseconds()
+		settings = append(settings, setting{"cpu.max", fmt.Sprintf("%d %d", int64(limits.CPUMax*float64(period)), period)})
+	}
+	if limits.CPUWeight > 0 {
+		settings = append(settings, setting{"cpu.weight", strconv.Itoa(limits.CPUWeight)})
+	}
+	if limits.MemoryMax > 0 {
+		settings = append(settings, setting{"memory.max", strconv.FormatInt(limits.MemoryMax, 10)})
+	}
+	if limits.MemoryHigh > 0 {
+		settings = append(settings, setting{"memory.high", strconv.FormatInt(limits.MemoryHigh, 10)})
+	}
+	if limits.IOWeight > 0 {
+		settings = append(settings, setting{"io.weight", fmt.Sprintf("default %d", limits.IOWeight)})
+	}
+	if limits.PidsMax > 0 {
+		settings = append(settings, setting{"pids.max", strconv.Itoa(limits.PidsMax)})
+	}
+	for _, s := range settings {
+		if err := os.WriteFile(filepath.Join(g.dir, s.file), []byte(s.value), 0); err != nil {
+			return nil, fmt.Errorf("failed to set %s of tenant %s: %v", s.file, name, err)
+		}
+	}
+	return g, nil
+}
+
+// Remove はテナントの cgroup と親 cgroup を削除します。cgroup に残っているプロセスは停止します。
+func (h *Hierarchy) Remove() error {
+	var errs []error
+	for _, g := range h.groups {
+		if err := removeCgroup(g.dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := removeCgroup(h.dir); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// removeCgroup kills the processes left in dir and removes it once they are
+// gone, which takes a moment after the kill.
+func removeCgroup(dir string) error {
+	os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+	var err error
+	for range 50 {
+		if err = syscall.Rmdir(dir); err == nil || errors.Is(err, syscall.ENOENT) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("failed to remove cgroup %s: %v", dir, err)
+}
+
+// Start は cmd を開始し、そのプロセスをテナントの cgroup に移動します。
+//
+// 引数:
+//
+//	cmd - 開始するコマンド
+func (g *Group) Start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// The child spends its first moments parsing its options, so that moving it
+	// after the start does not let any load escape the limits
+	if err := os.WriteFile(filepath.Join(g.dir, "cgroup.procs"), []byte(strconv.Itoa(cmd.Process.Pid)), 0); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("failed to move process %d into tenant %s: %v", cmd.Process.Pid, g.Name, err)
+	}
+	return nil
+}
+
+// Stats はテナントの cgroup の現在までの統計を返します。
+func (g *Group) Stats() (Stats, error) {
+	var s Stats
+	cpuStat, err := readKeyed(filepath.Join(g.dir, "cpu.stat"))
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to read the CPU statistics of tenant %s: %v", g.Name, err)
+	}
+	s.CPUUsage = time.Duration(cpuStat["usage_usec"]) * time.Microsecond
+	s.Periods = cpuStat["nr_periods"]
+	s.ThrottledPeriods = cpuStat["nr_throttled"]
+	s.Throttled = time.Duration(cpuStat["throttled_usec"]) * time.Microsecond
+
+	s.MemoryCurrent = readInt(filepath.Join(g.dir, "memory.current"))
+	s.MemoryPeak = readInt(filepath.Join(g.dir, "memory.peak"))
+	if events, err := readKeyed(filepath.Join(g.dir, "memory.events")); err == nil {
+		s.MemoryHighEvents = events["high"]
+		s.MemoryMaxEvents = events["max"]
+		s.OOMKills = events["oom_kill"]
+	}
+	return s, nil
+}
+
+// readKeyed reads a flat keyed cgroup file of "<key> <value>" lines.
+func readKeyed(path string) (map[string]int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]int64)
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			if n, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				values[fields[0]] = n
+			}
+		}
+	}
+	return values, nil
+}
+
+// readInt reads a cgroup file holding a single number, or returns 0.
+func readInt(path string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return n
+}
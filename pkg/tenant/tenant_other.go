@@ -0,0 +1,45 @@
+//go:build !linux
+
+package tenant
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Hierarchy はテナントの cgroup をまとめる親 cgroup です。cgroup は Linux 固有のため、このプラットフォームでは作成できません。
+type Hierarchy struct{}
+
+// Group は1つのテナントの cgroup です。
+type Group struct {
+	// Name はテナントの名前です。
+	Name string
+	// Limits は cgroup に設定した制限です。
+	Limits Limits
+}
+
+// NewHierarchy は cgroup が Linux 固有のため、常にエラーを返します。
+func NewHierarchy(name string) (*Hierarchy, error) {
+	return nil, fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
+
+// Add は NewHierarchy が常に失敗するため呼び出されません。
+func (h *Hierarchy) Add(name string, limits Limits) (*Group, error) {
+	return nil, fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
+
+// Remove は何もしません。
+func (h *Hierarchy) Remove() error {
+	return nil
+}
+
+// Start は NewHierarchy が常に失敗するため呼び出されません。
+func (g *Group) Start(cmd *exec.Cmd) error {
+	return fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
+
+// Stats は NewHierarchy が常に失敗するため呼び出されません。
+func (g *Group) Stats() (Stats, error) {
+	return Stats{}, fmt.Errorf("cgroups are not supported on %s", runtime.GOOS)
+}
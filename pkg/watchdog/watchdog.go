@@ -0,0 +1,121 @@
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// Metrics that can be watched.
+const (
+	MetricLoadAverage     = "loadavg"
+	MetricMemoryAvailable = "mem-available"
+	MetricDiskFree        = "disk-free"
+	MetricTemperature     = "temp"
+	// The pressure stall metrics are the share of time some task waited for the
+	// resource over the last 10 seconds, in percent.
+	MetricPSICPU    = "psi-cpu"
+	MetricPSIMemory = "psi-memory"
+	MetricPSIIO     = "psi-io"
+)
+
+// checkInterval is how often the conditions are evaluated.
+const checkInterval = 1 * time.Second
+
+// Condition は負荷テストを中止する条件です。
+type Condition struct {
+	// Expr is the condition as written by the user, e.g. "loadavg>64".
+	Expr   string
+	Metric string
+	// Path is the filesystem path for MetricDiskFree.
+	Path string
+	// Above trips the condition when the value exceeds Threshold; otherwise when it falls below.
+	Above     bool
+	Threshold float64
+}
+
+// Trip は成立した中止条件と、そのときの観測値です。
+type Trip struct {
+	Condition Condition
+	Value     float64
+}
+
+// String describes the trip for log output.
+func (t *Trip) String() string {
+	return fmt.Sprintf("%s (observed %s)", t.Condition.Expr, formatValue(t.Condition.Metric, t.Value))
+}
+
+// Watch は ctx が終了するまで条件を継続的に評価し、最初に成立した条件を返します。
+// 条件が成立しないまま ctx が終了した場合は nil を返します。
+//
+// 引数:
+//
+//	ctx        - 監視の制御に使用するコンテキスト
+//	conditions - 監視する条件
+//	recorder   - 評価できない条件を記録する Recorder（nil可）
+func Watch(ctx context.Context, conditions []Condition, recorder *metrics.Recorder) *Trip {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	warned := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, c := range conditions {
+				value, err := measure(c)
+				if err != nil {
+					// Report unreadable metrics once instead of every second
+					if !warned[c.Expr] {
+						recorder.Logf("Watchdog", "Warning: cannot evaluate %s: %v", c.Expr, err)
+						warned[c.Expr] = true
+					}
+					continue
+				}
+				if (c.Above && value > c.Threshold) || (!c.Above && value < c.Threshold) {
+					return &Trip{Condition: c, Value: value}
+				}
+			}
+		}
+	}
+}
+
+// measure reads the current value of the condition's metric.
+func measure(c Condition) (float64, error) {
+	switch c.Metric {
+	case MetricLoadAverage:
+		snapshot, err := sysinfo.Read()
+		return snapshot.LoadAverage, err
+	case MetricMemoryAvailable:
+		snapshot, err := sysinfo.Read()
+		return float64(snapshot.MemoryAvailable), err
+	case MetricDiskFree:
+		disk, err := sysinfo.ReadDiskSpace(c.Path)
+		return float64(disk.Available), err
+	case MetricTemperature:
+		return sysinfo.ReadTemperature()
+	case MetricPSICPU, MetricPSIMemory, MetricPSIIO:
+		return sysinfo.ReadPressure(strings.TrimPrefix(c.Metric, "psi-"))
+	default:
+		return 0, fmt.Errorf("unknown metric %q", c.Metric)
+	}
+}
+
+// formatValue formats an observed value in the metric's natural unit.
+func formatValue(metric string, value float64) string {
+	switch metric {
+	case MetricMemoryAvailable, MetricDiskFree:
+		return fmt.Sprintf("%d MiB", int64(value)/(1024*1024))
+	case MetricTemperature:
+		return fmt.Sprintf("%.1f°C", value)
+	case MetricPSICPU, MetricPSIMemory, MetricPSIIO:
+		return fmt.Sprintf("%.1f%%", value)
+	default:
+		return fmt.Sprintf("%.2f", value)
+	}
+}
@@ -0,0 +1,4 @@
+// Package winsvc は stress-go を Windows サービスとして登録・実行するための
+// サービスコントロールマネージャー (SCM) とイベントログの API を提供します。
+// Windows 以外では何も提供しません。
+package winsvc
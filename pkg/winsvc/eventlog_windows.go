@@ -0,0 +1,120 @@
+package winsvc
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Event types for ReportEventW.
+const (
+	eventlogErrorType       = 0x1
+	eventlogWarningType     = 0x2
+	eventlogInformationType = 0x4
+)
+
+// eventID is the ID of every event; EventCreate.exe renders IDs 1-1000 as the message text.
+const eventID = 1
+
+// Registry access used to register the event source.
+const (
+	keyAllAccess    = 0xF003F
+	regOptionNone   = 0
+	regExpandSz     = 2
+	regDword        = 4
+	eventSourceRoot = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`
+)
+
+var (
+	registerEventSource   = advapi32.NewProc("RegisterEventSourceW")
+	deregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	reportEvent           = advapi32.NewProc("ReportEventW")
+	regCreateKeyEx        = advapi32.NewProc("RegCreateKeyExW")
+	regSetValueEx         = advapi32.NewProc("RegSetValueExW")
+	regDeleteKey          = advapi32.NewProc("RegDeleteKeyW")
+)
+
+// EventLog は Windows のアプリケーションイベントログへの書き込み先です。
+type EventLog struct {
+	handle uintptr
+}
+
+// OpenEventLog はイベントログのソース source に書き込む EventLog を開きます。
+func OpenEventLog(source string) (*EventLog, error) {
+	handle, _, errno := registerEventSource.Call(0, uintptr(unsafe.Pointer(utf16Ptr(source))))
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to open event log %s: %v", source, errno)
+	}
+	return &EventLog{handle: handle}, nil
+}
+
+// Info は情報イベントを書き込みます。
+func (l *EventLog) Info(message string) error {
+	return l.report(eventlogInformationType, message)
+}
+
+// Warning は警告イベントを書き込みます。
+func (l *EventLog) Warning(message string) error {
+	return l.report(eventlogWarningType, message)
+}
+
+// Error はエラーイベントを書き込みます。
+func (l *EventLog) Error(message string) error {
+	return l.report(eventlogErrorType, message)
+}
+
+// Close はイベントログを閉じます。
+func (l *EventLog) Close() error {
+	if ret, _, errno := deregisterEventSource.Call(l.handle); ret == 0 {
+		return fmt.Errorf("failed to close event log: %v", errno)
+	}
+	return nil
+}
+
+func (l *EventLog) report(eventType uint16, message string) error {
+	messages := []*uint16{utf16Ptr(message)}
+	ret, _, errno := reportEvent.Call(l.handle, uintptr(eventType), 0, eventID, 0, 1, 0, uintptr(unsafe.Pointer(&messages[0])), 0)
+	if ret == 0 {
+		return fmt.Errorf("failed to write event log: %v", errno)
+	}
+	return nil
+}
+
+// installEventSource registers name as an event source whose messages are shown
+// as written, using the generic message file of EventCreate.exe.
+func installEventSource(name string) error {
+	var key syscall.Handle
+	ret, _, _ := regCreateKeyEx.Call(
+		uintptr(syscall.HKEY_LOCAL_MACHINE),
+		uintptr(unsafe.Pointer(utf16Ptr(eventSourceRoot+name))),
+		0, 0, regOptionNone, keyAllAccess, 0,
+		uintptr(unsafe.Pointer(&key)), 0,
+	)
+	if ret != 0 {
+		return fmt.Errorf("failed to register event source %s: %v", name, syscall.Errno(ret))
+	}
+	defer syscall.RegCloseKey(key)
+
+	messageFile, _ := syscall.UTF16FromString(`%SystemRoot%\System32\EventCreate.exe`)
+	ret, _, _ = regSetValueEx.Call(uintptr(key), uintptr(unsafe.Pointer(utf16Ptr("EventMessageFile"))), 0, regExpandSz,
+		uintptr(unsafe.Pointer(&messageFile[0])), uintptr(len(messageFile)*2))
+	if ret != 0 {
+		return fmt.Errorf("failed to register event source %s: %v", name, syscall.Errno(ret))
+	}
+	types := uint32(eventlogErrorType | eventlogWarningType | eventlogInformationType)
+	ret, _, _ = regSetValueEx.Call(uintptr(key), uintptr(unsafe.Pointer(utf16Ptr("TypesSupported"))), 0, regDword,
+		uintptr(unsafe.Pointer(&types)), unsafe.Sizeof(types))
+	if ret != 0 {
+		return fmt.Errorf("failed to register event source %s: %v", name, syscall.Errno(ret))
+	}
+	return nil
+}
+
+// removeEventSource removes the registration made by installEventSource.
+func removeEventSource(name string) error {
+	ret, _, _ := regDeleteKey.Call(uintptr(syscall.HKEY_LOCAL_MACHINE), uintptr(unsafe.Pointer(utf16Ptr(eventSourceRoot+name))))
+	if ret != 0 && syscall.Errno(ret) != syscall.ERROR_FILE_NOT_FOUND {
+		return fmt.Errorf("failed to remove event source %s: %v", name, syscall.Errno(ret))
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package winsvc
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32    = syscall.NewLazyDLL("kernel32.dll")
+	createEvent = kernel32.NewProc("CreateEventW")
+	setEvent    = kernel32.NewProc("SetEvent")
+)
+
+// StopEvent は名前付きイベントによる停止の要求です。Windows には SIGTERM がないため、
+// サービスが子プロセスとして実行する負荷テストに停止を要求するために使用します。
+type StopEvent struct {
+	handle syscall.Handle
+}
+
+// OpenStopEvent は名前付きイベント name を開きます。存在しない場合は作成します。
+func OpenStopEvent(name string) (*StopEvent, error) {
+	// A manual-reset event stays signaled, so a request is never lost
+	handle, _, errno := createEvent.Call(0, 1, 0, uintptr(unsafe.Pointer(utf16Ptr(name))))
+	if handle == 0 {
+		return nil, fmt.Errorf("failed to open stop event %s: %v", name, errno)
+	}
+	return &StopEvent{handle: syscall.Handle(handle)}, nil
+}
+
+// Signal は停止を要求します。
+func (e *StopEvent) Signal() error {
+	if ret, _, errno := setEvent.Call(uintptr(e.handle)); ret == 0 {
+		return fmt.Errorf("failed to signal stop event: %v", errno)
+	}
+	return nil
+}
+
+// Wait は停止が要求されるまで待ちます。
+func (e *StopEvent) Wait() error {
+	if _, err := syscall.WaitForSingleObject(e.handle, syscall.INFINITE); err != nil {
+		return fmt.Errorf("failed to wait for stop event: %v", err)
+	}
+	return nil
+}
+
+// Close はイベントを閉じます。
+func (e *StopEvent) Close() error {
+	return syscall.CloseHandle(e.handle)
+}
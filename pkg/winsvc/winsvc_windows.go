@@ -0,0 +1,274 @@
+package winsvc
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Access rights, service types and states used with the service control manager.
+const (
+	scManagerAllAccess = 0xF003F
+	serviceAllAccess   = 0xF01FF
+
+	serviceWin32OwnProcess = 0x10
+	serviceAutoStart       = 2
+	serviceDemandStart     = 3
+	serviceErrorNormal     = 1
+	serviceConfigDesc      = 1
+
+	serviceStopped     = 1
+	serviceStopPending = 3
+	serviceRunning     = 4
+
+	serviceAcceptStop     = 0x1
+	serviceAcceptShutdown = 0x4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented    = 120
+	errorServiceSpecificError  = 1066
+	errorServiceDoesNotExist   = 1060
+	errorFailedServiceConnect  = 1063
+	errorServiceMarkedToDelete = 1072
+)
+
+// stopWaitHint is how long the service control manager is told to wait between
+// progress reports while the service stops.
+const stopWaitHint = 30 * time.Second
+
+var (
+	advapi32                      = syscall.NewLazyDLL("advapi32.dll")
+	openSCManager                 = advapi32.NewProc("OpenSCManagerW")
+	createService                 = advapi32.NewProc("CreateServiceW")
+	openService                   = advapi32.NewProc("OpenServiceW")
+	deleteService                 = advapi32.NewProc("DeleteService")
+	closeServiceHandle            = advapi32.NewProc("CloseServiceHandle")
+	changeServiceConfig2          = advapi32.NewProc("ChangeServiceConfig2W")
+	startServiceCtrlDispatcher    = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	registerServiceCtrlHandlerEx  = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	setServiceStatus              = advapi32.NewProc("SetServiceStatus")
+	serviceMainCallback           = syscall.NewCallback(serviceMain)
+	serviceControlHandlerCallback = syscall.NewCallback(controlHandler)
+)
+
+// serviceStatus mirrors the Win32 SERVICE_STATUS structure.
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// serviceTableEntry mirrors the Win32 SERVICE_TABLE_ENTRYW structure.
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+// Config はサービスの登録内容です。
+type Config struct {
+	// Name はサービス名です。イベントログのソース名にも使用します。
+	Name string
+	// DisplayName と Description はサービス一覧に表示する名前と説明です。
+	DisplayName string
+	Description string
+	// CommandLine はサービスとして実行するコマンドライン (実行ファイルのパスを含む) です。
+	CommandLine string
+	// AutoStart は OS の起動時にサービスを開始するかどうかです。false の場合は手動開始です。
+	AutoStart bool
+}
+
+// Install はサービスを登録し、同じ名前のイベントログのソースを登録します。管理者権限が必要です。
+func Install(config Config) error {
+	scm, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeServiceHandle.Call(scm)
+
+	startType := serviceDemandStart
+	if config.AutoStart {
+		startType = serviceAutoStart
+	}
+	service, _, errno := createService.Call(
+		scm,
+		uintptr(unsafe.Pointer(utf16Ptr(config.Name))),
+		uintptr(unsafe.Pointer(utf16Ptr(config.DisplayName))),
+		serviceAllAccess,
+		serviceWin32OwnProcess,
+		uintptr(startType),
+		serviceErrorNormal,
+		uintptr(unsafe.Pointer(utf16Ptr(config.CommandLine))),
+		0, 0, 0, 0, 0,
+	)
+	if service == 0 {
+		return fmt.Errorf("failed to create service %s: %v", config.Name, errno)
+	}
+	defer closeServiceHandle.Call(service)
+
+	if config.Description != "" {
+		description := struct{ text *uint16 }{utf16Ptr(config.Description)}
+		changeServiceConfig2.Call(service, serviceConfigDesc, uintptr(unsafe.Pointer(&description)))
+	}
+	if err := installEventSource(config.Name); err != nil {
+		return fmt.Errorf("service %s created, but %v", config.Name, err)
+	}
+	return nil
+}
+
+// Uninstall はサービスとイベントログのソースの登録を削除します。実行中のサービスは停止後に削除されます。
+func Uninstall(name string) error {
+	scm, err := connect()
+	if err != nil {
+		return err
+	}
+	defer closeServiceHandle.Call(scm)
+
+	service, _, errno := openService.Call(scm, uintptr(unsafe.Pointer(utf16Ptr(name))), serviceAllAccess)
+	if service == 0 {
+		if errno == syscall.Errno(errorServiceDoesNotExist) {
+			return fmt.Errorf("service %s is not installed", name)
+		}
+		return fmt.Errorf("failed to open service %s: %v", name, errno)
+	}
+	defer closeServiceHandle.Call(service)
+
+	if ret, _, errno := deleteService.Call(service); ret == 0 && errno != syscall.Errno(errorServiceMarkedToDelete) {
+		return fmt.Errorf("failed to delete service %s: %v", name, errno)
+	}
+	return removeEventSource(name)
+}
+
+// connect opens the local service control manager.
+func connect() (uintptr, error) {
+	scm, _, errno := openSCManager.Call(0, 0, scManagerAllAccess)
+	if scm == 0 {
+		return 0, fmt.Errorf("failed to connect to the service control manager (run as administrator): %v", errno)
+	}
+	return scm, nil
+}
+
+// The running service. The service control manager calls back into serviceMain
+// and controlHandler, which cannot carry state of their own.
+var current struct {
+	name    string
+	execute func(stop <-chan struct{}) uint32
+
+	mu         sync.Mutex
+	handle     uintptr
+	status     serviceStatus
+	stop       chan struct{}
+	stopOnce   sync.Once
+	checkPoint uint32
+}
+
+// Run は SCM から起動されたプロセスをサービス name として実行します。execute が返るまで
+// サービスを実行中として報告し、execute の戻り値をサービスの終了コードとします (0 は正常終了)。
+// サービスの停止またはシャットダウンが要求されると stop が閉じられます。
+// SCM から起動されていない場合はエラーを返します。
+//
+// 引数:
+//
+//	name    - サービス名
+//	execute - サービスの処理
+func Run(name string, execute func(stop <-chan struct{}) uint32) error {
+	current.name = name
+	current.execute = execute
+	current.stop = make(chan struct{})
+
+	table := []serviceTableEntry{{name: utf16Ptr(name), proc: serviceMainCallback}, {}}
+	// The dispatcher returns once the service has stopped
+	ret, _, errno := startServiceCtrlDispatcher.Call(uintptr(unsafe.Pointer(&table[0])))
+	if ret == 0 {
+		if errno == syscall.Errno(errorFailedServiceConnect) {
+			return fmt.Errorf("not started by the service control manager (use sc.exe start %s)", name)
+		}
+		return fmt.Errorf("failed to start the service dispatcher: %v", errno)
+	}
+	return nil
+}
+
+// serviceMain is the ServiceMain of the service, run on a thread of the dispatcher.
+func serviceMain(argc uint32, argv **uint16) uintptr {
+	handle, _, _ := registerServiceCtrlHandlerEx.Call(uintptr(unsafe.Pointer(utf16Ptr(current.name))), serviceControlHandlerCallback, 0)
+	if handle == 0 {
+		return 0
+	}
+	current.mu.Lock()
+	current.handle = handle
+	current.mu.Unlock()
+	report(serviceRunning, 0)
+
+	code := current.execute(current.stop)
+	report(serviceStopped, code)
+	return 0
+}
+
+// controlHandler is the HandlerEx of the service. It must return promptly, so a
+// stop request only closes the stop channel and reports progress until execute returns.
+func controlHandler(control, eventType uint32, eventData, context uintptr) uintptr {
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		current.stopOnce.Do(func() {
+			report(serviceStopPending, 0)
+			close(current.stop)
+			go reportStopProgress()
+		})
+		return 0
+	case serviceControlInterrogate:
+		return 0
+	}
+	return errorCallNotImplemented
+}
+
+// reportStopProgress keeps the service control manager waiting while the load
+// test cleans up.
+func reportStopProgress() {
+	ticker := time.NewTicker(stopWaitHint / 3)
+	defer ticker.Stop()
+	for range ticker.C {
+		current.mu.Lock()
+		stopping := current.status.CurrentState == serviceStopPending
+		current.mu.Unlock()
+		if !stopping {
+			return
+		}
+		report(serviceStopPending, 0)
+	}
+}
+
+// report sends the service state to the service control manager.
+func report(state uint32, exitCode uint32) {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	status := serviceStatus{ServiceType: serviceWin32OwnProcess, CurrentState: state}
+	switch state {
+	case serviceRunning:
+		status.ControlsAccepted = serviceAcceptStop | serviceAcceptShutdown
+	case serviceStopPending:
+		current.checkPoint++
+		status.CheckPoint = current.checkPoint
+		status.WaitHint = uint32(stopWaitHint / time.Millisecond)
+	case serviceStopped:
+		if exitCode != 0 {
+			status.Win32ExitCode = errorServiceSpecificError
+			status.ServiceSpecificExitCode = exitCode
+		}
+	}
+	current.status = status
+	setServiceStatus.Call(current.handle, uintptr(unsafe.Pointer(&status)))
+}
+
+// utf16Ptr converts s for a Win32 call; s never contains NUL here.
+func utf16Ptr(s string) *uint16 {
+	p, _ := syscall.UTF16PtrFromString(s)
+	return p
+}
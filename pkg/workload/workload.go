@@ -0,0 +1,117 @@
+// Package workload は選択できる負荷の方式 (CPU の演算方式、負荷パターン、エンジン) の一覧を保持します。
+//
+// 各パッケージが init で自身の方式を登録し、このビルドと OS で使用できるかどうかも登録します。
+// stress-go list はこの一覧を表示し、オプションの検証にも使用します。
+package workload
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Kind は方式の種類です。
+type Kind string
+
+const (
+	// Methods は CPU 負荷の演算方式です。
+	Methods Kind = "methods"
+	// Patterns は --pattern の負荷パターンです。
+	Patterns Kind = "patterns"
+	// Engines はストレージの I/O や GPU など、負荷を生成するエンジンです。
+	Engines Kind = "engines"
+)
+
+// Kinds はすべての種類です。
+var Kinds = []Kind{Methods, Patterns, Engines}
+
+// Workload は選択できる負荷の方式1つです。
+type Workload struct {
+	Kind Kind
+	// Name は方式の名前です。オプションでの指定に使用します。
+	Name string
+	// Domain は方式が属する負荷 ("cpu"、"storage"、"gpu" など) です。
+	Domain string
+	// Usage は方式を選択するオプションの例です。
+	Usage string
+	// Description は方式の説明です。
+	Description string
+	// Available はこのビルドと OS で方式を使用できるかどうかです。
+	Available bool
+	// Requirement は方式を使用するために必要な条件です。Available が false の場合に表示します。
+	Requirement string
+}
+
+var (
+	mu        sync.Mutex
+	workloads []Workload
+)
+
+// Register は方式を一覧に登録します。同じ種類・負荷・名前の方式は置き換えます。
+//
+// 引数:
+//
+//	w - 登録する方式
+func Register(w Workload) {
+	mu.Lock()
+	defer mu.Unlock()
+	workloads = slices.DeleteFunc(workloads, func(r Workload) bool {
+		return r.Kind == w.Kind && r.Domain == w.Domain && r.Name == w.Name
+	})
+	workloads = append(workloads, w)
+}
+
+// List は kind の方式を負荷・名前の順に返します。
+//
+// 引数:
+//
+//	kind - 方式の種類
+func List(kind Kind) []Workload {
+	mu.Lock()
+	defer mu.Unlock()
+	var result []Workload
+	for _, w := range workloads {
+		if w.Kind == kind {
+			result = append(result, w)
+		}
+	}
+	slices.SortFunc(result, func(a, b Workload) int {
+		if c := strings.Compare(a.Domain, b.Domain); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+// Lookup は kind と domain の方式を name で探します。
+//
+// 引数:
+//
+//	kind   - 方式の種類
+//	domain - 方式が属する負荷
+//	name   - 方式の名前
+func Lookup(kind Kind, domain, name string) (Workload, bool) {
+	for _, w := range List(kind) {
+		if w.Domain == domain && w.Name == name {
+			return w, true
+		}
+	}
+	return Workload{}, false
+}
+
+// Names は kind と domain の方式の名前を返します。エラーメッセージで選択肢を示すために使用します。
+//
+// 引数:
+//
+//	kind   - 方式の種類
+//	domain - 方式が属する負荷
+func Names(kind Kind, domain string) []string {
+	var names []string
+	for _, w := range List(kind) {
+		if w.Domain == domain {
+			names = append(names, w.Name)
+		}
+	}
+	return names
+}
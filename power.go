@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// powerInterval is how often the RAPL energy counters are read: often enough for
+// the power chart, and well within the minutes after which the counters wrap
+// under full load.
+const powerInterval = 5 * time.Second
+
+// startPowerMeter starts measuring the energy used by the CPU packages and DRAM
+// when the run loads the CPU or memory. It returns nil when the host has no
+// readable RAPL counters, saying why unless the counters are simply absent.
+func startPowerMeter(config Config) *sysinfo.EnergyMeter {
+	if config.CPU < 0 && config.Memory == "" && config.Profile == "" {
+		return nil
+	}
+	meter, err := sysinfo.NewEnergyMeter()
+	if err != nil {
+		if !errors.Is(err, sysinfo.ErrNoPowerDomains) {
+			term.Printf("Power: not measured (%v)\n", err)
+		}
+		return nil
+	}
+	return meter
+}
+
+// measurePower records the average power of each domain over every interval
+// until ctx is done.
+func measurePower(ctx context.Context, meter *sysinfo.EnergyMeter, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(powerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range meter.Sample() {
+				recorder.RecordValue("Power "+e.Domain.Name, "W", e.Watts())
+			}
+		}
+	}
+}
+
+// printPowerReport prints the average power and the energy used by each domain,
+// and by the CPU packages and DRAM together.
+func printPowerReport(energy []sysinfo.Energy) {
+	if len(energy) == 0 {
+		return
+	}
+
+	term.Printf("Power (RAPL):\n")
+	var packageWatts, dramWatts, joules float64
+	for _, e := range energy {
+		term.Printf("  %s: %.1f W average, %.0f J\n", e.Domain.Name, e.Watts(), e.Joules)
+		if e.Domain.DRAM {
+			dramWatts += e.Watts()
+		} else {
+			packageWatts += e.Watts()
+		}
+		joules += e.Joules
+	}
+	term.Printf("  Total: package %.1f W, DRAM %.1f W average, %.0f J (%.3f kWh)\n",
+		packageWatts, dramWatts, joules, joules/3.6e6)
+	term.Println()
+}
+
+// powerSummaries converts the measured energy for the run summary.
+func powerSummaries(energy []sysinfo.Energy) []cluster.PowerSummary {
+	var summaries []cluster.PowerSummary
+	for _, e := range energy {
+		summaries = append(summaries, cluster.PowerSummary{
+			Domain: e.Domain.Name,
+			DRAM:   e.Domain.DRAM,
+			Watts:  e.Watts(),
+			Joules: e.Joules,
+		})
+	}
+	return summaries
+}
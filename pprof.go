@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	rpprof "runtime/pprof"
+	"time"
+)
+
+// registerPprof serves the runtime profiles of stress-go itself under /debug/pprof/,
+// for diagnosing the tool on hardware where it misbehaves.
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+// dumpProfiles writes a CPU profile covering each interval, and a heap profile
+// at the end of it, into dir until ctx is done. The returned channel is closed
+// once the last profiles are written.
+func dumpProfiles(ctx context.Context, dir string, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	if dir == "" {
+		close(done)
+		return done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			stop, err := startCPUProfile(filepath.Join(dir, profileName("cpu")))
+			if err != nil {
+				term.Eprintf("Warning: Cannot write CPU profile: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+			stop()
+			if err := writeHeapProfile(filepath.Join(dir, profileName("heap"))); err != nil {
+				term.Eprintf("Warning: Cannot write heap profile: %v\n", err)
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// profileName returns the file name of a profile of kind taken now.
+func profileName(kind string) string {
+	return fmt.Sprintf("%s-%s.pprof", kind, time.Now().Format("20060102-150405"))
+}
+
+// startCPUProfile starts profiling the CPU into path and returns the function
+// that stops it. The function does nothing when profiling could not start.
+func startCPUProfile(path string) (func(), error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return func() {}, err
+	}
+	if err := rpprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return func() {}, err
+	}
+	return func() {
+		rpprof.StopCPUProfile()
+		f.Close()
+	}, nil
+}
+
+// writeHeapProfile writes a heap profile into path, as of the last garbage collection.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	runtime.GC()
+	return rpprof.WriteHeapProfile(f)
+}
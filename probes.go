@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/probe"
+)
+
+// defaultProbeInterval is the probe interval when only --probes is given.
+const defaultProbeInterval = 100 * time.Millisecond
+
+// parseProbes returns the probes listed in spec, or every probe when spec is empty.
+func parseProbes(spec string) ([]string, error) {
+	var kinds []string
+	for _, name := range splitList(spec) {
+		kind := strings.ToLower(name)
+		if !probe.Valid(kind) {
+			return nil, fmt.Errorf("unknown probe %q (%s)", name, strings.Join(probe.Kinds, ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// probeOptions returns the probe settings of the run, with a zero interval when
+// the probes are off.
+func probeOptions(config Config, recorder *metrics.Recorder) probe.Options {
+	return probe.Options{Interval: config.ProbeInterval, Kinds: config.Probes, Seed: config.Seed, Recorder: recorder}
+}
+
+// startProbes runs the probes until ctx is done and returns a channel that is
+// closed once they have stopped.
+func startProbes(ctx context.Context, opts probe.Options) <-chan struct{} {
+	done := make(chan struct{})
+	if opts.Interval <= 0 {
+		close(done)
+		return done
+	}
+	go func() {
+		defer close(done)
+		probe.Run(ctx, opts)
+	}()
+	return done
+}
+
+// probeLatencies returns the histograms of the probes in latencies, keyed by probe.
+func probeLatencies(latencies map[string]metrics.Histogram) map[string]metrics.Histogram {
+	probes := make(map[string]metrics.Histogram)
+	for key, h := range latencies {
+		if kind, ok := strings.CutPrefix(key, probe.Name+" "); ok {
+			probes[kind] = h
+		}
+	}
+	return probes
+}
+
+// printProbeReport prints the latency distribution of each probe, with the idle
+// 99th percentile when the baseline measured it.
+func printProbeReport(latencies map[string]metrics.Histogram, base *baseline) {
+	probes := probeLatencies(latencies)
+	if len(probes) == 0 {
+		return
+	}
+
+	term.Printf("Probe latency:\n")
+	for _, kind := range probe.Kinds {
+		h, ok := probes[kind]
+		if !ok {
+			continue
+		}
+		line := i18n.Sprintf("  %s: p50 %v, p99 %v, max %v (%d samples)", kind,
+			roundLatency(h.Quantile(0.5)), roundLatency(h.Quantile(0.99)), roundLatency(h.Max), h.Count)
+		if idle, ok := base.probe(kind); ok {
+			line += i18n.Sprintf(", idle p99 %v", roundLatency(idle.Quantile(0.99)))
+		}
+		term.Printf("%s\n", line)
+	}
+	term.Println()
+}
+
+// roundLatency rounds a latency to three significant digits for display.
+func roundLatency(d time.Duration) time.Duration {
+	for unit := time.Nanosecond; unit < time.Hour; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit)
+		}
+	}
+	return d
+}
+
+// probeSummaries converts the probe latencies for the run summary.
+func probeSummaries(latencies map[string]metrics.Histogram, base *baseline) []cluster.ProbeSummary {
+	probes := probeLatencies(latencies)
+	var summaries []cluster.ProbeSummary
+	for _, kind := range probe.Kinds {
+		h, ok := probes[kind]
+		if !ok {
+			continue
+		}
+		s := cluster.ProbeSummary{
+			Probe: kind,
+			Count: h.Count,
+			P50:   h.Quantile(0.5).Seconds(),
+			P99:   h.Quantile(0.99).Seconds(),
+			Max:   h.Max.Seconds(),
+		}
+		if idle, ok := base.probe(kind); ok {
+			s.IdleP99 = idle.Quantile(0.99).Seconds()
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/monitor"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// progressPhase follows the phase the run is in, such as a step of --pattern,
+// from the phase events, for the progress line.
+type progressPhase struct {
+	mu    sync.Mutex
+	name  string
+	start time.Time
+	// end is when the phase is planned to end, or zero when it lasts until the
+	// end of the run.
+	end time.Time
+}
+
+// observe records the phase each PhaseStarted event enters.
+func (p *progressPhase) observe(e events.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e.Type {
+	case events.PhaseStarted:
+		p.name, p.start = e.Message, e.Time
+		p.end, _ = e.Fields["end"].(time.Time)
+	case events.PhaseFinished:
+		p.name = ""
+	}
+}
+
+// describe returns the current phase with its progress and the time left in
+// it, or "" outside any phase. A phase without an end of its own ends with the run.
+func (p *progressPhase) describe(runEnd time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.name == "" {
+		return ""
+	}
+	end := runEnd
+	if !p.end.IsZero() && p.end.Before(runEnd) {
+		end = p.end
+	}
+	length, left := end.Sub(p.start), time.Until(end)
+	if length <= 0 || left <= 0 {
+		return i18n.Sprintf(" | %s", p.name)
+	}
+	return i18n.Sprintf(" | %s (%.0f%% done, %v left)", p.name, float64(length-left)/float64(length)*100, left.Truncate(time.Second))
+}
+
+// showProgress prints the share of the run completed so far and the time it
+// ends at. The total follows changes made to the deadline while the run is in
+// progress. The current phase shows its own progress and how long it has left,
+// and stressors with their own timeout show how long they have left. With a
+// dashboard, the lines of the dashboard follow the progress line.
+func showProgress(ctx context.Context, dl *deadline.Deadline, phase *progressPhase, ends []stressorEnd, dash *dashboard) {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	defer term.ClearStatus()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			remaining := dl.Remaining()
+			if remaining <= 0 {
+				return
+			}
+
+			total := dl.Total()
+			end := time.Now().Add(remaining)
+			progress := float64(total-remaining) / float64(total) * 100
+			line := i18n.T(progressPrefix) + i18n.Sprintf(" %.1f%% (Remaining: %v, ends at %s)",
+				progress, remaining.Truncate(time.Second), end.Format(time.TimeOnly))
+			line += phase.describe(end)
+			for _, e := range ends {
+				if wait := time.Until(e.start); wait > 0 {
+					line += i18n.Sprintf(" [%s: starts in %v]", e.name, wait.Truncate(time.Second))
+				} else if left := time.Until(e.end); left > 0 {
+					line += i18n.Sprintf(" [%s: %v left]", e.name, left.Truncate(time.Second))
+				} else {
+					line += i18n.Sprintf(" [%s: done]", e.name)
+				}
+			}
+			if dash != nil {
+				line += "\n" + strings.Join(dash.lines(), "\n")
+			}
+			term.SetStatus(line)
+		}
+	}
+}
+
+// dashboardBar is the width of the usage bars of the dashboard.
+const dashboardBar = 10
+
+// dashboard renders the state of the system the monitor samples, and the
+// target and achieved load of every stressor, below the progress line.
+type dashboard struct {
+	monitor  *monitor.Monitor
+	registry *stressor.Registry
+	guard    *safetyGuard
+}
+
+// lines returns the lines of the dashboard: the CPU, memory and swap in use,
+// the fill of every filesystem the stressors write to, and a line per stressor.
+func (d *dashboard) lines() []string {
+	snapshot := d.monitor.Latest()
+	var lines []string
+	if !snapshot.Time.IsZero() {
+		var system []string
+		if snapshot.CPU >= 0 {
+			system = append(system, i18n.Sprintf("CPU %s", usageBar(snapshot.CPU)))
+		}
+		if used, ok := snapshot.MemoryUsed(); ok {
+			system = append(system, i18n.Sprintf("Memory %s %s available", usageBar(used), bytesize.Format(snapshot.MemoryAvailable)))
+		}
+		if used, ok := snapshot.SwapUsed(); ok {
+			system = append(system, i18n.Sprintf("Swap %s", usageBar(used)))
+		}
+		if snapshot.MemoryPressure >= 0 {
+			system = append(system, i18n.Sprintf("Memory pressure %.1f%%", snapshot.MemoryPressure))
+		}
+		lines = append(lines, strings.Join(system, "  "))
+		for _, path := range slices.Sorted(maps.Keys(snapshot.Disks)) {
+			disk := snapshot.Disks[path]
+			if used, ok := snapshot.DiskUsed(path); ok {
+				lines = append(lines, i18n.Sprintf("Disk %s %s available in %s", usageBar(used), bytesize.Format(disk.Available), path))
+			}
+		}
+	}
+	for _, s := range d.registry.Stressors() {
+		stats := s.Stats()
+		line := i18n.Sprintf("  %s: %s of %s", s.Name(),
+			metrics.FormatValue(stats.Unit, stats.Achieved), metrics.FormatValue(stats.Unit, stats.Target))
+		if stats.Paused {
+			line += i18n.T(" (paused)")
+		}
+		if factor := d.guard.factor(s.Name()); factor < 1 {
+			line += i18n.Sprintf(" (backed off to %.0f%% by the safety guard)", factor*100)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// usageBar draws share, from 0 to 1, as a bar followed by its percentage.
+func usageBar(share float64) string {
+	filled := min(max(int(share*dashboardBar+0.5), 0), dashboardBar)
+	return fmt.Sprintf("[%s%s] %3.0f%%", strings.Repeat("#", filled), strings.Repeat(".", dashboardBar-filled), share*100)
+}
+
+// stressorEnd is when a stressor with its own timeout stops, and when it
+// starts if that is later than the run.
+type stressorEnd struct {
+	name  string
+	start time.Time
+	end   time.Time
+}
+
+// stressorEnds lists the registered stressors that have their own timeout.
+func stressorEnds(registry *stressor.Registry, timeouts, starts map[string]time.Duration, start time.Time) []stressorEnd {
+	var ends []stressorEnd
+	for _, s := range registry.Stressors() {
+		if timeout, ok := timeouts[strings.ToLower(s.Name())]; ok {
+			begin := start.Add(starts[strings.ToLower(s.Name())])
+			ends = append(ends, stressorEnd{name: s.Name(), start: begin, end: begin.Add(timeout)})
+		}
+	}
+	return ends
+}
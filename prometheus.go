@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// registerMetrics serves the metrics of the run under /metrics in Prometheus
+// text format: the target and achieved load and the running totals that every
+// stressor records, the system metrics and the remaining duration, so that a
+// long run can be scraped and set beside the metrics of the machine.
+func registerMetrics(ctx context.Context, mux *http.ServeMux, dl *deadline.Deadline, recorder *metrics.Recorder) {
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if err := recorder.WritePrometheus(&buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeRunState(&buf, dl, recorder, ctx.Err() == nil)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
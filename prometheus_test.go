@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+func TestRegisterMetrics(t *testing.T) {
+	ctx, dl, cancel := deadline.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	recorder := metrics.NewRecorder()
+	recorder.Record("CPU", metrics.UnitCores, 2, 1.5)
+	recorder.AddCount("Storage", "bytes_written", 4096)
+	recorder.AddCount("Storage", "bytes_read", 1024)
+	mux := http.NewServeMux()
+	registerMetrics(ctx, mux, dl, recorder)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "target", want: `stress_go_target{stressor="CPU",unit="cores"} 2`},
+		{name: "achieved", want: `stress_go_achieved{stressor="CPU",unit="cores"} 1.5`},
+		{name: "bytes written", want: `stress_go_count_total{stressor="Storage",name="bytes_written"} 4096`},
+		{name: "bytes read", want: `stress_go_count_total{stressor="Storage",name="bytes_read"} 1024`},
+		{name: "running", want: "stress_go_running 1"},
+		{name: "remaining", want: "stress_go_remaining_seconds 3"},
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	body := rec.Body.String()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("metrics do not contain %q:\n%s", tt.want, body)
+			}
+		})
+	}
+}
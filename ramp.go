@@ -0,0 +1,73 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// rampSteps is how many steps a ramp takes at most; the load changes at most
+// every rampMinStep so that short ramps do not log a change of the memory or
+// disk footprint many times a second.
+const (
+	rampSteps   = 100
+	rampMinStep = time.Second
+)
+
+// rampFactor returns the factor of the configured load at elapsed into a run
+// with remaining left: it grows from 0 to 1 over up from the start, and falls
+// back to 0 over down before the end. Either ramp is skipped when 0.
+func rampFactor(elapsed, remaining, up, down time.Duration) float64 {
+	factor := 1.0
+	if up > 0 {
+		factor = min(factor, float64(elapsed)/float64(up))
+	}
+	if down > 0 {
+		factor = min(factor, float64(remaining)/float64(down))
+	}
+	return min(max(factor, 0), 1)
+}
+
+// startRamp grows the load of every controllable stressor from nothing to the
+// configured load over up, and takes it back to nothing over down before the
+// end of the run, so that CPU duty cycles, memory and disk footprints change
+// gradually, as real traffic does, rather than in one step. The ramp down
+// follows the deadline as --extend-by or the control API move it. It applies
+// the starting factor before the stressors start.
+func startRamp(ctx context.Context, dl *deadline.Deadline, up, down time.Duration, registry *stressor.Registry) {
+	var targets []stressor.Controllable
+	for _, s := range registry.Stressors() {
+		if c, ok := s.(stressor.Controllable); ok {
+			targets = append(targets, c)
+		}
+	}
+	start := time.Now()
+	apply := func(factor float64) {
+		for _, t := range targets {
+			t.SetRamp(factor)
+		}
+	}
+	last := rampFactor(0, dl.Remaining(), up, down)
+	apply(last)
+
+	// The shorter of the ramps sets the pace
+	step := max(min(cmp.Or(up, down), cmp.Or(down, up))/rampSteps, rampMinStep)
+	go func() {
+		ticker := time.NewTicker(step)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if factor := rampFactor(time.Since(start), dl.Remaining(), up, down); factor != last {
+				apply(factor)
+				last = factor
+			}
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRampFactor(t *testing.T) {
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		remaining time.Duration
+		up, down  time.Duration
+		want      float64
+	}{
+		{name: "start of ramp up", elapsed: 0, remaining: time.Hour, up: time.Minute, want: 0},
+		{name: "middle of ramp up", elapsed: 15 * time.Second, remaining: time.Hour, up: time.Minute, want: 0.25},
+		{name: "after ramp up", elapsed: 2 * time.Minute, remaining: time.Hour, up: time.Minute, down: time.Minute, want: 1},
+		{name: "middle of ramp down", elapsed: time.Hour, remaining: 30 * time.Second, up: time.Minute, down: time.Minute, want: 0.5},
+		{name: "end of run", elapsed: time.Hour, remaining: 0, down: time.Minute, want: 0},
+		{name: "overlapping ramps", elapsed: 30 * time.Second, remaining: 15 * time.Second, up: time.Minute, down: time.Minute, want: 0.25},
+		{name: "no ramps", elapsed: 0, remaining: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rampFactor(tt.elapsed, tt.remaining, tt.up, tt.down); got != tt.want {
+				t.Errorf("rampFactor(%v, %v, %v, %v) = %v, want %v", tt.elapsed, tt.remaining, tt.up, tt.down, got, tt.want)
+			}
+		})
+	}
+}
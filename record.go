@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/profile"
+)
+
+// runRecord implements the record subcommand, which samples the host's
+// CPU/memory/disk utilization into a profile file for later replay.
+func runRecord(args []string) {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	output := flags.String("output", "", "Profile file to write [required]")
+	interval := flags.Duration("interval", 5*time.Second, "Sampling interval")
+	timeout := flags.Duration("timeout", 0, "Recording duration (0 = until interrupted)")
+	path := flags.String("path", ".", "Path whose filesystem usage is recorded")
+	flags.Parse(args)
+
+	if *output == "" {
+		term.Eprintf("Error: --output option is required\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+	if *interval <= 0 {
+		term.Eprintf("Error: --interval must be positive\n")
+		os.Exit(exitConfigError)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Saving profile...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	term.Printf("Recording load profile every %v to %s (Ctrl+C to stop)...\n", *interval, *output)
+	p, err := profile.Record(ctx, *interval, *path)
+	if err != nil {
+		term.Eprintf("Error: Recording stopped: %v\n", err)
+		if p == nil {
+			os.Exit(exitFailure)
+		}
+	}
+	if len(p.Points) == 0 {
+		term.Eprintf("Error: No samples were recorded\n")
+		os.Exit(exitFailure)
+	}
+
+	if err := p.Save(*output); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Recorded %d samples (%v) to %s\n", len(p.Points), p.Duration().Truncate(time.Second), *output)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/profile"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// registerReplay registers variable-load stressors that follow the recorded profile.
+// CPU utilization is reproduced as the same share of all cores, while memory and
+// disk usage are reproduced as the amount above the lowest value seen while recording.
+func registerReplay(registry *stressor.Registry, p *profile.Profile, offset time.Duration, opts stressorOptions) {
+	start := time.Now().Add(-offset)
+	at := func() profile.Point { return p.At(time.Since(start)) }
+	memoryBaseline, diskBaseline := p.Baseline()
+
+	var maxCPU float64
+	var maxMemory, maxDisk int64
+	for _, point := range p.Points {
+		maxCPU = max(maxCPU, point.CPU)
+		maxMemory = max(maxMemory, point.Memory-memoryBaseline)
+		maxDisk = max(maxDisk, point.Disk-diskBaseline)
+	}
+
+	if maxCPU > 0 {
+		cpuOpts := opts.cpu
+		cpuOpts.Cores = 0
+		cpuOpts.Target = func() float64 { return at().CPU }
+		registry.Register(stressor.NewCPU(cpuOpts))
+	}
+
+	if maxMemory > 0 {
+		memoryOpts := opts.memory
+		memoryOpts.Size, memoryOpts.Percent = 0, 0
+		memoryOpts.Target = func() int64 { return at().Memory - memoryBaseline }
+		registry.Register(stressor.NewMemory(memoryOpts))
+	}
+
+	if maxDisk > 0 {
+		storageOpts := opts.storage
+		storageOpts.Size, storageOpts.Percent = 0, 0
+		storageOpts.Target = func() int64 { return at().Disk - diskBaseline }
+		registry.Register(stressor.NewStorage(storageOpts))
+	}
+
+	term.Printf("Replaying profile: peak CPU %.0f%%, peak memory +%d MiB, peak disk +%d MiB\n",
+		maxCPU*100, maxMemory/(1024*1024), maxDisk/(1024*1024))
+}
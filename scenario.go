@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// scenario is a --scenario file: stages of built-in loads, each starting at its
+// own time into the run and stopping after its own duration, such as
+//
+//	{"stages": [
+//	  {"name": "warmup", "load": "cpu:cores=0", "duration": "2m"},
+//	  {"name": "hold", "load": "memory:size=4GB", "duration": "5m"},
+//	  {"name": "disk", "load": "storage:size=80%", "start": "6m", "duration": "1m"}
+//	]}
+type scenario struct {
+	Stages []scenarioStage `json:"stages"`
+}
+
+// scenarioStage is one stage as written in the file.
+type scenarioStage struct {
+	// Name addresses the stage in the progress, reports, --stressor-timeout and
+	// the control API (default: stage1, stage2, ...).
+	Name string `json:"name"`
+	// Load is the kind and options of the stage as in --job, e.g. "memory:size=4GB".
+	Load string `json:"load"`
+	// Start is when the stage starts into the run; without it the stage starts
+	// when the previous stage ends, so that stages run one after the other.
+	Start    string `json:"start"`
+	Duration string `json:"duration"`
+}
+
+// stage is a parsed scenario stage.
+type stage struct {
+	name     string
+	load     string
+	start    time.Duration
+	duration time.Duration
+}
+
+// jobSpec returns the stage as a --job value.
+func (s stage) jobSpec() string {
+	return s.name + "=" + s.load
+}
+
+// loadScenario reads and parses a --scenario file.
+func loadScenario(path string) ([]stage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stages, err := parseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scenario %s: %v", path, err)
+	}
+	return stages, nil
+}
+
+// parseScenario parses the stages of a scenario and works out when each starts.
+func parseScenario(data []byte) ([]stage, error) {
+	var sc scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, err
+	}
+	if len(sc.Stages) == 0 {
+		return nil, fmt.Errorf("no stages")
+	}
+
+	var stages []stage
+	var previousEnd time.Duration
+	for i, s := range sc.Stages {
+		st := stage{name: strings.TrimSpace(s.Name), load: strings.TrimSpace(s.Load), start: previousEnd}
+		if st.name == "" {
+			st.name = fmt.Sprintf("stage%d", i+1)
+		}
+		if st.load == "" {
+			return nil, fmt.Errorf("stage %s has no load", st.name)
+		}
+		var err error
+		if s.Start != "" {
+			st.start, err = time.ParseDuration(s.Start)
+			if err != nil || st.start < 0 {
+				return nil, fmt.Errorf("stage %s: invalid start %q", st.name, s.Start)
+			}
+		}
+		st.duration, err = time.ParseDuration(s.Duration)
+		if err != nil || st.duration <= 0 {
+			return nil, fmt.Errorf("stage %s: invalid duration %q", st.name, s.Duration)
+		}
+		previousEnd = st.start + st.duration
+		stages = append(stages, st)
+	}
+	return stages, nil
+}
+
+// scenarioLength returns when the last stage of a scenario ends.
+func scenarioLength(stages []stage) time.Duration {
+	var length time.Duration
+	for _, s := range stages {
+		length = max(length, s.start+s.duration)
+	}
+	return length
+}
+
+// stageStressor creates the stressor of a stage added by a reload, whose name
+// must differ from the built-in and plugin stressors and the registered ones.
+func stageStressor(st stage, config Config, opts stressorOptions, registry *stressor.Registry) (stressor.Stressor, error) {
+	jobs, err := parseJobs([]string{st.jobSpec()}, config.Plugins)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range registry.Stressors() {
+		if strings.EqualFold(s.Name(), st.name) {
+			return nil, fmt.Errorf("the name %s is already used", st.name)
+		}
+	}
+	return jobs[0].stressor(config, opts), nil
+}
+
+// scenarioRun runs the stages of a --scenario file, each under its own context,
+// so that reloading the file on SIGHUP can call off and reschedule the stages
+// that have not started yet. Stages that have started are left as they are.
+type scenarioRun struct {
+	path       string
+	start      time.Time
+	ctx        context.Context
+	wg         *sync.WaitGroup
+	supervisor *stressorSupervisor
+	registry   *stressor.Registry
+	health     *runHealth
+	bus        *events.Bus
+	// build creates the stressor of a stage added by a reload.
+	build func(stage) (stressor.Stressor, error)
+
+	mu     sync.Mutex
+	closed bool
+	stages map[string]*scheduledStage // by lower-case name
+}
+
+// scheduledStage is a stage as read from the file and the stressor running it.
+type scheduledStage struct {
+	stage
+	stressor stressor.Stressor
+	cancel   context.CancelFunc
+}
+
+// launch starts the stages of the file given at startup, whose stressors are
+// already registered, with their timeouts after --stressor-timeout.
+func (r *scenarioRun) launch(stages []stage, timeouts map[string]time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stages = make(map[string]*scheduledStage)
+	for _, s := range r.registry.Stressors() {
+		for _, st := range stages {
+			if strings.EqualFold(s.Name(), st.name) {
+				r.schedule(st, s, timeouts[strings.ToLower(st.name)])
+			}
+		}
+	}
+}
+
+// owns reports whether name is a stage the scenario starts itself.
+func (r *scenarioRun) owns(name string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.stages[strings.ToLower(name)]
+	return ok
+}
+
+// schedule runs s for duration from the start of st into the run.
+func (r *scenarioRun) schedule(st stage, s stressor.Stressor, duration time.Duration) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	r.stages[strings.ToLower(st.name)] = &scheduledStage{stage: st, stressor: s, cancel: cancel}
+	run := stressor.WithTimeout(s, duration)
+	if delay := st.start - time.Since(r.start); delay > 0 {
+		run = stressor.WithDelay(run, delay)
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		r.supervisor.run(s.Name(), func() error { return stressor.Run(ctx, run) })
+	}()
+}
+
+// reload reads the file again and applies the changes to the stages that have
+// not started yet: removed or changed ones are called off, and new or changed
+// ones are scheduled at their start. It reports problems on the bus and keeps
+// the current stages when the file is invalid.
+func (r *scenarioRun) reload() {
+	stages, err := loadScenario(r.path)
+	if err != nil {
+		r.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Scenario not reloaded: %v", err)})
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	elapsed := time.Since(r.start)
+	next := make(map[string]stage)
+	for _, st := range stages {
+		next[strings.ToLower(st.name)] = st
+	}
+
+	var cancelled, scheduled int
+	for name, current := range r.stages {
+		if st, ok := next[name]; ok && st == current.stage {
+			delete(next, name)
+			continue
+		}
+		if current.start <= elapsed {
+			r.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Stage %s has already started and is left as it is", current.name)})
+			delete(next, name)
+			continue
+		}
+		current.cancel()
+		r.registry.Unregister(current.stressor)
+		delete(r.stages, name)
+		cancelled++
+	}
+	for _, st := range stages {
+		if _, ok := next[strings.ToLower(st.name)]; !ok {
+			continue
+		}
+		if st.start <= elapsed {
+			r.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Stage %s would have started already and is skipped", st.name)})
+			continue
+		}
+		s, err := r.build(st)
+		if err != nil {
+			r.bus.Publish(events.Event{Type: events.RunControlled, Message: i18n.Sprintf("Stage %s skipped: %v", st.name, err)})
+			continue
+		}
+		r.registry.Register(s)
+		r.health.expect([]string{s.Name()})
+		r.schedule(st, s, st.duration)
+		scheduled++
+	}
+	r.bus.Publish(events.Event{Type: events.RunControlled,
+		Message: i18n.Sprintf("Scenario reloaded from %s: %d stage(s) scheduled, %d called off", r.path, scheduled, cancelled)})
+}
+
+// close stops further reloads, so that no stage is added while the run is
+// cleaning up.
+func (r *scenarioRun) close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+}
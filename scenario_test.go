@@ -0,0 +1,53 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []stage
+		length  time.Duration
+		ok      bool
+	}{
+		{
+			name: "sequential and overlapping",
+			content: `{"stages": [
+				{"name": "warmup", "load": "cpu:cores=0", "duration": "2m"},
+				{"load": "memory:size=4GB", "duration": "5m"},
+				{"name": "disk", "load": "storage:size=80%", "start": "3m", "duration": "1m"}
+			]}`,
+			want: []stage{
+				{name: "warmup", load: "cpu:cores=0", start: 0, duration: 2 * time.Minute},
+				{name: "stage2", load: "memory:size=4GB", start: 2 * time.Minute, duration: 5 * time.Minute},
+				{name: "disk", load: "storage:size=80%", start: 3 * time.Minute, duration: time.Minute},
+			},
+			length: 7 * time.Minute,
+			ok:     true,
+		},
+		{name: "no stages", content: `{"stages": []}`},
+		{name: "no load", content: `{"stages": [{"duration": "1m"}]}`},
+		{name: "no duration", content: `{"stages": [{"load": "cpu"}]}`},
+		{name: "negative start", content: `{"stages": [{"load": "cpu", "start": "-1m", "duration": "1m"}]}`},
+		{name: "not json", content: "stages:\n  - load: cpu\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScenario([]byte(tt.content))
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseScenario() error = %v, want ok %v", err, tt.ok)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseScenario() = %+v, want %+v", got, tt.want)
+			}
+			if length := scenarioLength(got); length != tt.length {
+				t.Errorf("scenarioLength() = %v, want %v", length, tt.length)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/schedule"
+)
+
+// scheduledLoad is one line of the daemon's --schedule file: when to start a
+// load test, how long it runs, and its options.
+type scheduledLoad struct {
+	line     int
+	cron     *schedule.Cron
+	duration time.Duration
+	args     []string
+}
+
+// loadSchedule reads the --schedule file. Each non-empty line holds the five
+// fields of a cron expression in local time, the duration of the load test and
+// its options, e.g. "0 2 * * 1-5 1h --cpu 0 --memory 60%"; # starts a comment.
+func loadSchedule(path string) ([]scheduledLoad, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schedule: %v", err)
+	}
+
+	var loads []scheduledLoad
+	for n, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("%s:%d: expected a cron expression, a duration and the load test options", path, n+1)
+		}
+		cron, err := schedule.Parse(strings.Join(fields[:5], " "))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n+1, err)
+		}
+		if cron.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("%s:%d: %q never matches", path, n+1, cron)
+		}
+		duration, err := time.ParseDuration(fields[5])
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%s:%d: invalid duration %q", path, n+1, fields[5])
+		}
+		args := fields[6:]
+		if slices.ContainsFunc(args, func(arg string) bool {
+			return arg == "--timeout" || arg == "-timeout" || strings.HasPrefix(arg, "--timeout=") || strings.HasPrefix(arg, "-timeout=")
+		}) {
+			return nil, fmt.Errorf("%s:%d: the duration sets the timeout; remove --timeout from the options", path, n+1)
+		}
+		if !strings.HasPrefix(args[0], "-") {
+			return nil, fmt.Errorf("%s:%d: unsupported load test options %q", path, n+1, strings.Join(args, " "))
+		}
+		loads = append(loads, scheduledLoad{
+			line:     n + 1,
+			cron:     cron,
+			duration: duration,
+			args:     append([]string{"--timeout", duration.String()}, args...),
+		})
+	}
+	if len(loads) == 0 {
+		return nil, fmt.Errorf("no schedules in %s", path)
+	}
+	return loads, nil
+}
+
+// runSchedule starts each scheduled load test through the agent when its cron
+// expression matches, until ctx is done. A load test that is due while another
+// one is still running is skipped rather than queued, so that runs never overlap.
+func runSchedule(ctx context.Context, agent *cluster.Agent, loads []scheduledLoad, logf func(format string, args ...any)) {
+	now := time.Now()
+	for _, l := range loads {
+		logf("Scheduled %q for %v (line %d, next at %s): %s",
+			l.cron, l.duration, l.line, l.cron.Next(now).Format(time.DateTime), strings.Join(l.args[2:], " "))
+	}
+
+	for {
+		var next time.Time
+		for _, l := range loads {
+			if t := l.cron.Next(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+		if next.IsZero() {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		for _, l := range loads {
+			if !l.cron.Matches(next) {
+				continue
+			}
+			if _, err := agent.Start(l.args); err != nil {
+				logf("Skipped the load test scheduled by line %d: %v", l.line, err)
+			}
+		}
+		now = next
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLoadSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		duration time.Duration
+		args     []string
+		ok       bool
+	}{
+		{
+			name:     "load test",
+			content:  "# nightly\n0 2 * * 1-5 1h --cpu 0 --memory 60%\n",
+			duration: time.Hour,
+			args:     []string{"--timeout", "1h0m0s", "--cpu", "0", "--memory", "60%"},
+			ok:       true,
+		},
+		{
+			name:     "trailing comment",
+			content:  "*/30 * * * * 5m --storage 1GB # short\n",
+			duration: 5 * time.Minute,
+			args:     []string{"--timeout", "5m0s", "--storage", "1GB"},
+			ok:       true,
+		},
+		{name: "no schedules", content: "\n# none\n", ok: false},
+		{name: "no options", content: "0 2 * * * 1h\n", ok: false},
+		{name: "bad cron", content: "0 25 * * * 1h --cpu 0\n", ok: false},
+		{name: "never matches", content: "0 0 30 2 * 1h --cpu 0\n", ok: false},
+		{name: "bad duration", content: "0 2 * * * soon --cpu 0\n", ok: false},
+		{name: "zero duration", content: "0 2 * * * 0s --cpu 0\n", ok: false},
+		{name: "timeout", content: "0 2 * * * 1h --cpu 0 --timeout=2h\n", ok: false},
+		{name: "subcommand", content: "0 2 * * * 1h bench --cpu 0\n", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schedule")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			loads, err := loadSchedule(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("loadSchedule = %v, want ok=%v", err, tt.ok)
+			}
+			if err != nil {
+				return
+			}
+			if len(loads) != 1 {
+				t.Fatalf("loadSchedule = %d loads, want 1", len(loads))
+			}
+			if loads[0].duration != tt.duration || !slices.Equal(loads[0].args, tt.args) {
+				t.Errorf("loadSchedule = %v %q, want %v %q", loads[0].duration, loads[0].args, tt.duration, tt.args)
+			}
+		})
+	}
+}
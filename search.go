@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+	"github.com/utkamioka/stress-go/pkg/watchdog"
+)
+
+// Search methods.
+const (
+	searchBisect = "bisect"
+	searchStep   = "step"
+)
+
+// searchTrial is the outcome of holding one load level.
+type searchTrial struct {
+	Level    float64 `json:"level"`
+	Target   float64 `json:"target"`
+	Achieved float64 `json:"achieved"`
+	Passed   bool    `json:"passed"`
+	// Failure is the criterion that failed, with the observed value.
+	Failure string `json:"failure,omitempty"`
+}
+
+// searchReport is the result of the search subcommand as written by --json.
+type searchReport struct {
+	Version  string        `json:"version"`
+	Time     time.Time     `json:"time"`
+	Stressor string        `json:"stressor"`
+	Unit     string        `json:"unit"`
+	Method   string        `json:"method"`
+	Criteria []string      `json:"criteria"`
+	Trials   []searchTrial `json:"trials"`
+	// Sustainable is the highest level that passed, or nil when none did.
+	Sustainable *searchTrial `json:"sustainable"`
+	// BreakingPoint is the lowest level that failed, or nil when full load passed.
+	BreakingPoint *searchTrial `json:"breaking_point"`
+}
+
+// runSearch implements the search subcommand, which raises the level of one
+// stressor until a criterion fails and reports the highest level that held.
+func runSearch(args []string) {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	cores := flags.Int("cpu", -1, "Search the CPU load on this many cores (0 = use all cores)")
+	memorySpec := flags.String("memory", "", "Search the memory load up to this size (e.g., 8GB, 90%)")
+	var until stringList
+	flags.Var(&until, "until", "Criterion that ends the search when it holds, as for --abort-if (e.g., psi-cpu>20%); repeatable")
+	method := flags.String("method", searchBisect, "How to choose the levels: bisect or step")
+	stepSpec := flags.String("step", "10%", "Level increment for --method step")
+	precisionSpec := flags.String("precision", "5%", "Resolution at which --method bisect stops")
+	settle := flags.Duration("settle", 10*time.Second, "Time to let each level settle before the criteria are checked")
+	hold := flags.Duration("hold", 30*time.Second, "Time each level must meet the criteria for")
+	jsonPath := flags.String("json", "", "Write the trials and the result to this file as JSON")
+	flags.Parse(args)
+
+	if (*cores >= 0) == (*memorySpec != "") {
+		term.Eprintf("Error: Specify either --cpu or --memory to search\n")
+		os.Exit(exitConfigError)
+	}
+	criteria, err := parseAbortConditions(until)
+	if err == nil && len(criteria) == 0 {
+		err = fmt.Errorf("at least one --until criterion is required")
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if *method != searchBisect && *method != searchStep {
+		term.Eprintf("Error: Unknown search method %q (bisect or step)\n", *method)
+		os.Exit(exitConfigError)
+	}
+	step, err := parseLevel(*stepSpec)
+	var precision float64
+	if err == nil {
+		precision, err = parseLevel(*precisionSpec)
+	}
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	if *settle < 0 || *hold <= 0 {
+		term.Eprintf("Error: --settle must not be negative and --hold must be positive\n")
+		os.Exit(exitConfigError)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping search...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	recorder := metrics.NewRecorder()
+	recorder.OnMessage(printMessage)
+	var s stressor.Controllable
+	if *cores >= 0 {
+		opts := cpu.Options{Cores: *cores, Recorder: recorder}
+		if opts.Calibration, err = loadCalibration(""); err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		s = stressor.NewCPU(opts).(stressor.Controllable)
+	} else {
+		spec, err := bytesize.Parse(*memorySpec)
+		if err != nil {
+			term.Eprintf("Error: Invalid --memory: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		s = stressor.NewMemory(memory.Options{Size: spec.Bytes, Percent: spec.Percent, Recorder: recorder}).(stressor.Controllable)
+	}
+
+	report := searchReport{
+		Version:  stress.Version,
+		Time:     time.Now(),
+		Stressor: s.Name(),
+		Unit:     s.Stats().Unit,
+		Method:   *method,
+	}
+	for _, c := range criteria {
+		report.Criteria = append(report.Criteria, c.Expr)
+	}
+
+	// Start below the first level so that the stressor does not open at full load
+	s.SetLevel(0)
+	runCtx, stopRun := context.WithCancel(ctx)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- stressor.Run(runCtx, s)
+	}()
+
+	term.Printf("Searching the maximum sustainable %s load until %s (%s, %v per level)...\n",
+		s.Name(), strings.Join(report.Criteria, ", "), *method, *settle+*hold)
+	trial := func(level float64) (searchTrial, bool) {
+		t, ok := holdLevel(ctx, s, level, *settle, *hold, criteria, recorder)
+		if !ok {
+			return t, false
+		}
+		report.Trials = append(report.Trials, t)
+		target := metrics.FormatValue(report.Unit, t.Target)
+		if t.Passed {
+			term.Printf("  Level %.0f%% (%s): passed, achieved %s\n", t.Level*100, target, metrics.FormatValue(report.Unit, t.Achieved))
+		} else {
+			term.Printf("  Level %.0f%% (%s): failed on %s\n", t.Level*100, target, t.Failure)
+		}
+		return t, true
+	}
+	pass := func(t searchTrial) {
+		if report.Sustainable == nil || t.Level > report.Sustainable.Level {
+			report.Sustainable = &t
+		}
+	}
+	fail := func(t searchTrial) {
+		if report.BreakingPoint == nil || t.Level < report.BreakingPoint.Level {
+			report.BreakingPoint = &t
+		}
+	}
+
+	completed := true
+	switch *method {
+	case searchStep:
+		for i := 1; ; i++ {
+			// The last step is full load even when the increment does not divide it
+			level := min(float64(i)*step, 1)
+			var t searchTrial
+			if t, completed = trial(level); !completed {
+				break
+			}
+			if !t.Passed {
+				fail(t)
+				break
+			}
+			pass(t)
+			if level >= 1-1e-9 {
+				break
+			}
+		}
+	case searchBisect:
+		// Full load is tried last, as it is the level most likely to break the host
+		low, high := 0.0, 1.0
+		for high-low > precision {
+			var t searchTrial
+			if t, completed = trial((low + high) / 2); !completed {
+				break
+			}
+			if t.Passed {
+				pass(t)
+				low = t.Level
+			} else {
+				fail(t)
+				high = t.Level
+			}
+		}
+		if completed && report.BreakingPoint == nil {
+			var t searchTrial
+			if t, completed = trial(1); completed {
+				if t.Passed {
+					pass(t)
+				} else {
+					fail(t)
+				}
+			}
+		}
+	}
+
+	stopRun()
+	if err := <-runErr; err != nil {
+		term.Eprintf("Error: %s load failed: %v\n", s.Name(), err)
+		os.Exit(exitStartupFailure)
+	}
+
+	term.Println()
+	if !completed {
+		term.Printf("Search interrupted.\n")
+	}
+	if t := report.Sustainable; t != nil {
+		term.Printf("Maximum sustainable level: %.0f%% (%s)\n", t.Level*100, metrics.FormatValue(report.Unit, t.Target))
+	} else if completed {
+		term.Printf("Maximum sustainable level: none, the criteria failed at every level tried\n")
+	}
+	if t := report.BreakingPoint; t != nil {
+		term.Printf("Breaking point: %.0f%% (%s), failed on %s\n", t.Level*100, metrics.FormatValue(report.Unit, t.Target), t.Failure)
+	} else if completed {
+		term.Printf("Breaking point: none, the criteria held at full load\n")
+	}
+
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*jsonPath, append(data, '\n'), 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write search results: %v\n", err)
+			os.Exit(exitFailure)
+		}
+		term.Printf("Search results written to %s\n", *jsonPath)
+	}
+	if !completed {
+		os.Exit(exitFailure)
+	}
+}
+
+// holdLevel runs s at level, lets it settle and then checks the criteria for
+// hold. It returns false when ctx is done before the trial completes.
+func holdLevel(ctx context.Context, s stressor.Controllable, level float64, settle, hold time.Duration, criteria []watchdog.Condition, recorder *metrics.Recorder) (searchTrial, bool) {
+	s.SetLevel(level)
+	timer := time.NewTimer(settle)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return searchTrial{}, false
+	case <-timer.C:
+	}
+
+	watchCtx, cancel := context.WithTimeout(ctx, hold)
+	trip := watchdog.Watch(watchCtx, criteria, recorder)
+	cancel()
+	if trip == nil && ctx.Err() != nil {
+		return searchTrial{}, false
+	}
+
+	stats := s.Stats()
+	t := searchTrial{Level: level, Target: stats.Target, Achieved: stats.Achieved, Passed: trip == nil}
+	if trip != nil {
+		t.Failure = trip.String()
+	}
+	return t, true
+}
+
+// parseLevel parses a load level given as a percentage ("10%") or a fraction
+// ("0.1"), which must be above 0 and at most 1.
+func parseLevel(value string) (float64, error) {
+	trimmed, percent := strings.CutSuffix(strings.TrimSpace(value), "%")
+	level, err := strconv.ParseFloat(trimmed, 64)
+	if err == nil && percent {
+		level /= 100
+	}
+	if err != nil || level <= 0 || level > 1 {
+		return 0, fmt.Errorf("invalid level %q: expected a percentage above 0%% and at most 100%%", value)
+	}
+	return level, nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cpu"
+	"github.com/utkamioka/stress-go/pkg/fsmeta"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/network"
+	"github.com/utkamioka/stress-go/pkg/pagefault"
+	"github.com/utkamioka/stress-go/pkg/procs"
+	"github.com/utkamioka/stress-go/pkg/sched"
+	"github.com/utkamioka/stress-go/pkg/sparse"
+	"github.com/utkamioka/stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// selftestDuration is how long each stressor runs; it covers at least one
+// CPU usage sample.
+const selftestDuration = 6 * time.Second
+
+// Sizes used by the self-test; small enough to run on any host.
+const (
+	selftestMemory    = 16 * 1024 * 1024
+	selftestStorage   = 8 * 1024 * 1024
+	selftestPageFault = 8 * 1024 * 1024
+	selftestSparse    = 8 * 1024 * 1024
+	// selftestNetworkRate is bytes per second over loopback
+	selftestNetworkRate = 8 * 1024 * 1024
+	// selftestProcsRate is child processes spawned per second
+	selftestProcsRate = 20
+	// selftestFDs and selftestInodes are the descriptors held and the small
+	// files churned, at selftestFilesRate creations and removals per second
+	selftestFDs       = 64
+	selftestInodes    = 1000
+	selftestFilesRate = 200
+	// selftestPairs and selftestTimers are the goroutine pairs passing
+	// selftestSwitchRate messages per second and the timers firing every millisecond
+	selftestPairs      = 8
+	selftestSwitchRate = 10000
+	selftestTimers     = 8
+)
+
+// selftestRSSInterval is how often the memory case samples the resident memory.
+const selftestRSSInterval = 200 * time.Millisecond
+
+// selftestCase runs one stressor at tiny scale and verifies its effect. The
+// optional watch runs alongside the stressor, e.g. to sample the process.
+type selftestCase struct {
+	name        string
+	unsupported bool
+	create      func(recorder *metrics.Recorder) stressor.Stressor
+	watch       func(ctx context.Context)
+	verify      func(recorder *metrics.Recorder) error
+}
+
+// runSelftest implements the selftest subcommand, which checks that every
+// stressor produces a measurable load and cleans up after itself. The GPU
+// stressor is left out, as it needs a gpu build and a device.
+func runSelftest(args []string) {
+	flag.NewFlagSet("selftest", flag.ExitOnError).Parse(args)
+
+	// The memory case checks the memory the process actually holds, not what the
+	// stressor reports, from the peak resident memory over its baseline
+	var baselineRSS, peakRSS int64
+	var rssErr error
+
+	cases := []selftestCase{
+		{
+			name: "CPU",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewCPU(cpu.Options{Cores: 1, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				achieved, ok := lastAchieved(recorder, "CPU")
+				if !ok {
+					return fmt.Errorf("no CPU usage was measured")
+				}
+				if achieved < 0.25 {
+					return fmt.Errorf("measured only %.2f cores of 1", achieved)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Memory",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				baselineRSS, rssErr = sysinfo.ResidentMemory()
+				peakRSS = baselineRSS
+				return stressor.NewMemory(memory.Options{Size: selftestMemory, Recorder: recorder})
+			},
+			watch: func(ctx context.Context) {
+				ticker := time.NewTicker(selftestRSSInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if rss, err := sysinfo.ResidentMemory(); err == nil {
+							peakRSS = max(peakRSS, rss)
+						}
+					}
+				}
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				achieved, ok := lastAchieved(recorder, "Memory")
+				if !ok {
+					return fmt.Errorf("no memory allocation was recorded")
+				}
+				if rssErr == nil {
+					// Resident memory the stressor added, where the platform reports it
+					achieved = float64(peakRSS - baselineRSS)
+				}
+				if achieved < selftestMemory {
+					return fmt.Errorf("allocated %s of %s", metrics.FormatValue(metrics.UnitBytes, achieved),
+						metrics.FormatValue(metrics.UnitBytes, selftestMemory))
+				}
+				return nil
+			},
+		},
+		{
+			name: "Storage",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewStorage(storage.Options{Size: selftestStorage, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				achieved, ok := lastAchieved(recorder, "Storage")
+				if !ok {
+					return fmt.Errorf("no data was written")
+				}
+				if achieved < selftestStorage {
+					return fmt.Errorf("wrote %s of %s", metrics.FormatValue(metrics.UnitBytes, achieved),
+						metrics.FormatValue(metrics.UnitBytes, selftestStorage))
+				}
+				return nil
+			},
+		},
+		{
+			name:        "PageFault",
+			unsupported: !pagefault.Supported,
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewPageFault(pagefault.Options{Size: selftestPageFault, Workers: 1, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				if _, ok := lastAchieved(recorder, "PageFault"); !ok {
+					return fmt.Errorf("no page fault rate was measured")
+				}
+				if recorder.Counts()["PageFault"]["touches"] == 0 {
+					return fmt.Errorf("no page of the mapped file was touched")
+				}
+				return nil
+			},
+		},
+		{
+			name:        "Sparse",
+			unsupported: !sparse.Supported,
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewSparse(sparse.Options{Size: selftestSparse, Files: 1, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				counts := recorder.Counts()["Sparse"]
+				if counts["punches"]+counts["fills"] == 0 {
+					return fmt.Errorf("no hole was punched or filled")
+				}
+				if errs := verificationErrors(recorder.Verifications()); errs > 0 {
+					return fmt.Errorf("%d punched hole(s) read back data", errs)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Network",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewNetwork(network.Options{Mode: network.ModeTCP, Rate: selftestNetworkRate, Connections: 1, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				counts := recorder.Counts()["Network"]
+				if counts["bytes_received"] == 0 {
+					return fmt.Errorf("no data was echoed back (sent %d bytes)", counts["bytes_sent"])
+				}
+				if counts["errors"] > 0 {
+					return fmt.Errorf("%d network error(s)", counts["errors"])
+				}
+				return nil
+			},
+		},
+		{
+			name: "Files",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewFiles(fsmeta.Options{FDs: selftestFDs, Inodes: selftestInodes, Rate: selftestFilesRate, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				counts := recorder.Counts()["Files"]
+				if counts["created"] < selftestInodes || counts["removed"] == 0 {
+					return fmt.Errorf("created %d and removed %d small files", counts["created"], counts["removed"])
+				}
+				if counts["errors"] > 0 {
+					return fmt.Errorf("%d file error(s)", counts["errors"])
+				}
+				return nil
+			},
+		},
+		{
+			name: "Procs",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewProcs(procs.Options{Max: 4, Rate: selftestProcsRate, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				counts := recorder.Counts()["Procs"]
+				if counts["spawned"] == 0 {
+					return fmt.Errorf("no child process was spawned")
+				}
+				if counts["errors"] > 0 {
+					return fmt.Errorf("%d spawn error(s)", counts["errors"])
+				}
+				return nil
+			},
+		},
+		{
+			name: "Scheduler",
+			create: func(recorder *metrics.Recorder) stressor.Stressor {
+				return stressor.NewScheduler(sched.Options{Pairs: selftestPairs, Rate: selftestSwitchRate, Timers: selftestTimers, Recorder: recorder})
+			},
+			verify: func(recorder *metrics.Recorder) error {
+				counts := recorder.Counts()["Scheduler"]
+				if counts["messages"] == 0 || counts["timer_fires"] == 0 {
+					return fmt.Errorf("passed %d messages and fired %d timers", counts["messages"], counts["timer_fires"])
+				}
+				return nil
+			},
+		},
+	}
+
+	var failed []string
+	for _, c := range cases {
+		term.Printf("=== %s ===\n", c.name)
+		if c.unsupported {
+			term.Printf("--- SKIP: %s: not supported on this platform\n", c.name)
+			continue
+		}
+		if err := runSelftestCase(c); err != nil {
+			term.Printf("--- FAIL: %s: %v\n", c.name, err)
+			failed = append(failed, c.name)
+		} else {
+			term.Printf("--- PASS: %s\n", c.name)
+		}
+	}
+
+	term.Println()
+	if len(failed) > 0 {
+		term.Printf("Self-test failed: %v\n", failed)
+		os.Exit(exitVerificationFailure)
+	}
+	term.Println("Self-test passed.")
+}
+
+// runSelftestCase runs a single case and checks the process state it leaves behind.
+func runSelftestCase(c selftestCase) error {
+	maxProcs := runtime.GOMAXPROCS(0)
+	gcPercent := currentGCPercent()
+	tempDirs := storageTempDirs()
+
+	recorder := metrics.NewRecorder()
+	recorder.OnMessage(printMessage)
+	ctx, cancel := context.WithTimeout(context.Background(), selftestDuration)
+	s := c.create(recorder)
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		if c.watch != nil {
+			c.watch(ctx)
+		}
+	}()
+	err := stressor.Run(ctx, s)
+	cancel()
+	<-watched
+
+	if err != nil {
+		return err
+	}
+	if err := c.verify(recorder); err != nil {
+		return err
+	}
+	for _, d := range recorder.Deviations() {
+		if len(d.Issues) > 0 {
+			return fmt.Errorf("load was impeded: %v", d.Issues)
+		}
+	}
+
+	// Cleanup checks
+	if got := runtime.GOMAXPROCS(0); got != maxProcs {
+		return fmt.Errorf("GOMAXPROCS left at %d (was %d)", got, maxProcs)
+	}
+	if got := currentGCPercent(); got != gcPercent {
+		return fmt.Errorf("GC percent left at %d (was %d)", got, gcPercent)
+	}
+	for _, dir := range storageTempDirs() {
+		if !slices.Contains(tempDirs, dir) {
+			return fmt.Errorf("temporary directory %s was not removed", dir)
+		}
+	}
+	return nil
+}
+
+// lastAchieved returns the most recent achieved value recorded for a stressor.
+func lastAchieved(recorder *metrics.Recorder, stressor string) (float64, bool) {
+	samples := recorder.Samples()
+	for i := len(samples) - 1; i >= 0; i-- {
+		if samples[i].Stressor == stressor {
+			return samples[i].Achieved, true
+		}
+	}
+	return 0, false
+}
+
+// currentGCPercent reads the GC percent without changing it.
+func currentGCPercent() int {
+	percent := debug.SetGCPercent(100)
+	debug.SetGCPercent(percent)
+	return percent
+}
+
+// storageTempDirs lists the working directories created by the storage, page
+// fault, sparse and file stressors.
+func storageTempDirs() []string {
+	var dirs []string
+	for _, pattern := range []string{"stress-tool-storage-*", "stress-go-pagefault-*", "stress-go-sparse-*", "stress-go-files-*"} {
+		matches, _ := filepath.Glob(filepath.Join(os.TempDir(), pattern))
+		dirs = append(dirs, matches...)
+	}
+	return dirs
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// runService reports that services are Windows only; use systemd elsewhere.
+func runService(args []string) {
+	term.Eprintf("Error: the service command is only available on Windows (use a systemd unit on Linux)\n")
+	os.Exit(exitConfigError)
+}
+
+// notifyServiceStop does nothing; only Windows services stop the load test by event.
+func notifyServiceStop(c chan<- os.Signal) {}
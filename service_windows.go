@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/utkamioka/stress-go/pkg/winsvc"
+)
+
+// stopEventVariable names the environment variable through which the service
+// passes the load test the name of the event that asks it to stop.
+const stopEventVariable = "STRESS_GO_STOP_EVENT"
+
+// runService implements the service subcommand, which installs, removes and runs
+// stress-go as a Windows service.
+func runService(args []string) {
+	if len(args) == 0 {
+		term.Eprintf("Error: unknown service command (expected install, uninstall or run)\n")
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+	switch args[0] {
+	case "install":
+		runServiceInstall(args[1:])
+	case "uninstall":
+		runServiceUninstall(args[1:])
+	case "run":
+		runServiceRun(args[1:])
+	default:
+		term.Eprintf("Error: unknown service command %q (expected install, uninstall or run)\n", args[0])
+		printUsage()
+		os.Exit(exitConfigError)
+	}
+}
+
+// runServiceInstall registers a service that runs the load test given after --.
+func runServiceInstall(args []string) {
+	flags := flag.NewFlagSet("service install", flag.ExitOnError)
+	name := flags.String("name", "stress-go", "Name of the service")
+	displayName := flags.String("display-name", "stress-go load test", "Name shown in the list of services")
+	auto := flags.Bool("auto", false, "Start the service when Windows starts (default: manual start)")
+	flags.Parse(args)
+
+	jobArgs := flags.Args()
+	if err := validateJobArgs(jobArgs); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		term.Eprintf("Error: Cannot locate the stress-go executable: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	commandLine := []string{syscall.EscapeArg(executable), "service", "run", "--name", syscall.EscapeArg(*name), "--"}
+	for _, arg := range jobArgs {
+		commandLine = append(commandLine, syscall.EscapeArg(arg))
+	}
+	err = winsvc.Install(winsvc.Config{
+		Name:        *name,
+		DisplayName: *displayName,
+		Description: "Applies system load with stress-go: " + strings.Join(jobArgs, " "),
+		CommandLine: strings.Join(commandLine, " "),
+		AutoStart:   *auto,
+	})
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Installed service %s: %s\n", *name, strings.Join(jobArgs, " "))
+	term.Printf("Start it with: sc.exe start %s\n", *name)
+}
+
+// runServiceUninstall removes the service and its event source.
+func runServiceUninstall(args []string) {
+	flags := flag.NewFlagSet("service uninstall", flag.ExitOnError)
+	name := flags.String("name", "stress-go", "Name of the service")
+	flags.Parse(args)
+
+	if err := winsvc.Uninstall(*name); err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitFailure)
+	}
+	term.Printf("Removed service %s\n", *name)
+}
+
+// runServiceRun is started by the service control manager. It runs the load test
+// as a child process, logs its output to the event log and stops it gracefully
+// when the service is stopped.
+func runServiceRun(args []string) {
+	flags := flag.NewFlagSet("service run", flag.ExitOnError)
+	name := flags.String("name", "stress-go", "Name of the service")
+	flags.Parse(args)
+
+	log, err := winsvc.OpenEventLog(*name)
+	if err != nil {
+		term.Eprintf("Warning: %v\n", err)
+	}
+	err = winsvc.Run(*name, func(stop <-chan struct{}) uint32 {
+		return runServiceJob(flags.Args(), stop, log)
+	})
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+}
+
+// runServiceJob runs stress-go with args until it exits or stop is closed, and
+// returns its exit status as the service's exit code.
+func runServiceJob(args []string, stop <-chan struct{}, log *winsvc.EventLog) uint32 {
+	logLine := func(line string) {
+		if log == nil {
+			return
+		}
+		switch {
+		case hasLabel(line, "Error:"):
+			log.Error(line)
+		case hasLabel(line, "Warning:"):
+			log.Warning(line)
+		default:
+			log.Info(line)
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		logLine(fmt.Sprintf("Error: Cannot locate the stress-go executable: %v", err))
+		return exitFailure
+	}
+
+	// The load test stops as if interrupted once the service signals this event
+	eventName := fmt.Sprintf("stress-go-stop-%d", os.Getpid())
+	event, err := winsvc.OpenStopEvent(eventName)
+	if err != nil {
+		logLine(fmt.Sprintf("Error: %v", err))
+		return exitFailure
+	}
+	defer event.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Env = append(os.Environ(), stopEventVariable+"="+eventName)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		logLine(fmt.Sprintf("Error: Failed to start the load test: %v", err))
+		return exitFailure
+	}
+	logLine("Started load test: " + strings.Join(args, " "))
+
+	output := make(chan struct{})
+	go func() {
+		defer close(output)
+		scanner := bufio.NewScanner(reader)
+		scanner.Split(scanTerminalLines)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !isProgressLine(line) {
+				logLine(line)
+			}
+		}
+		io.Copy(io.Discard, reader)
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+			logLine("Service stop requested, stopping the load test")
+			event.Signal()
+		case <-exited:
+		}
+	}()
+
+	cmd.Wait()
+	close(exited)
+	writer.Close()
+	<-output
+
+	code := cmd.ProcessState.ExitCode()
+	logLine(fmt.Sprintf("Load test finished with exit status %d", code))
+	return uint32(max(code, 0))
+}
+
+// notifyServiceStop relays the stop request of a service started by runServiceJob
+// to c as SIGTERM.
+func notifyServiceStop(c chan<- os.Signal) {
+	name := os.Getenv(stopEventVariable)
+	if name == "" {
+		return
+	}
+	event, err := winsvc.OpenStopEvent(name)
+	if err != nil {
+		term.Eprintf("Warning: %v\n", err)
+		return
+	}
+	go func() {
+		if event.Wait() == nil {
+			c <- syscall.SIGTERM
+		}
+	}()
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyLevel relays SIGUSR1, which raises the load level, to up and SIGUSR2,
+// which lowers it, to down.
+func notifyLevel(up, down chan<- os.Signal) bool {
+	signal.Notify(up, syscall.SIGUSR1)
+	signal.Notify(down, syscall.SIGUSR2)
+	return true
+}
+
+// notifyReload relays SIGHUP, which reloads the --scenario file, to c.
+func notifyReload(c chan<- os.Signal) bool {
+	signal.Notify(c, syscall.SIGHUP)
+	return true
+}
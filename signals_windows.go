@@ -0,0 +1,13 @@
+package main
+
+import "os"
+
+// notifyLevel reports that load level changes by signal are unavailable; Windows has no SIGUSR1 and SIGUSR2.
+func notifyLevel(up, down chan<- os.Signal) bool {
+	return false
+}
+
+// notifyReload reports that scenario reloads by signal are unavailable; Windows has no SIGHUP.
+func notifyReload(c chan<- os.Signal) bool {
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/memory"
+	"github.com/utkamioka/stress-go/pkg/profile"
+	"github.com/utkamioka/stress-go/pkg/storage"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// maxStorageBlockSize is the largest --storage-bs, the size of a whole stress file.
+const maxStorageBlockSize = 64 * 1024 * 1024
+
+// describeSize shows how a --memory or --storage value was understood; base names
+// what a percentage is taken of.
+func describeSize(spec bytesize.Spec, base string) string {
+	if spec.IsPercent() {
+		return i18n.Sprintf("%s of %s", spec, base)
+	}
+	return spec.String()
+}
+
+// checkCapacity rejects absolute memory and storage sizes that exceed what this
+// host could ever provide, which are almost always typos (e.g. 64TB for 64GB).
+func checkCapacity(config Config) error {
+	// --memory-mode swap exceeds physical memory on purpose
+	if size := config.MemorySpec.Bytes; size > 0 && memory.Mode(config.MemoryMode) != memory.ModeSwap {
+		if snapshot, err := sysinfo.Read(); err == nil && snapshot.MemoryTotal > 0 && size > snapshot.MemoryTotal {
+			return fmt.Errorf("--memory %s exceeds the %s of memory on this host",
+				config.MemorySpec, bytesize.Format(snapshot.MemoryTotal))
+		}
+	}
+	if size := config.StorageSpec.Bytes; size > 0 {
+		dir := storageDir(config)
+		if space, err := sysinfo.ReadDiskSpace(dir); err == nil && space.Total > 0 && size > space.Total {
+			return fmt.Errorf("--storage %s exceeds the %s filesystem holding %s",
+				config.StorageSpec, bytesize.Format(space.Total), dir)
+		}
+	}
+	return nil
+}
+
+// storageDir returns the directory the storage load writes to: --storage-path,
+// or the directory chosen by the storage package.
+func storageDir(config Config) string {
+	if config.StoragePath != "" {
+		return config.StoragePath
+	}
+	return storage.DefaultDir(nil)
+}
+
+// checkStoragePath checks at startup that --storage-path is a writable
+// directory, so that a typo fails the run before any load starts.
+func checkStoragePath(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	probe, err := os.MkdirTemp(dir, ".stress-go-probe-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	return os.Remove(probe)
+}
+
+// printDryRun shows the settings of the run and what the memory and storage
+// targets resolve to on this host right now, without applying any load.
+func printDryRun(config Config, replayProfile *profile.Profile) {
+	term.Println("Dry run: the options are valid, no load will be applied.")
+	term.Printf("Duration: %v\n", config.Timeout)
+	for _, line := range describeLoad(config, replayProfile) {
+		term.Println(line)
+	}
+
+	term.Println()
+	term.Println("Resolved on this host:")
+	if config.Memory != "" {
+		available, err := sysinfo.MemoryAvailable()
+		switch {
+		case err != nil:
+			term.Printf("  Memory: cannot read available memory: %v\n", err)
+		case config.MemorySpec.IsPercent():
+			// The target follows free memory during the run
+			if target, err := memory.ResolvePercent(config.MemorySpec.Percent); err != nil {
+				term.Printf("  Memory: %v\n", err)
+			} else {
+				term.Printf("  Memory: %d bytes (%s) now, %s of %s free with a safety margin\n",
+					target, bytesize.Format(target), config.MemorySpec, bytesize.Format(available))
+			}
+		default:
+			term.Printf("  Memory: %d bytes (%s), %s free\n",
+				config.MemorySpec.Bytes, bytesize.Format(config.MemorySpec.Bytes), bytesize.Format(available))
+		}
+	}
+	if config.Storage != "" {
+		dir := storageDir(config)
+		space, err := sysinfo.ReadDiskSpace(dir)
+		switch {
+		case err != nil:
+			term.Printf("  Storage: cannot read free space of %s: %v\n", dir, err)
+		case config.StorageSpec.IsPercent():
+			if target, err := storage.ResolvePercent(dir, config.StorageSpec.Percent); err != nil {
+				term.Printf("  Storage: %v\n", err)
+			} else {
+				term.Printf("  Storage: %d bytes (%s) in %s, %s of %s free with a safety margin\n",
+					target, bytesize.Format(target), dir, config.StorageSpec, bytesize.Format(space.Available))
+			}
+		default:
+			term.Printf("  Storage: %d bytes (%s) in %s, %s free\n",
+				config.StorageSpec.Bytes, bytesize.Format(config.StorageSpec.Bytes), dir, bytesize.Format(space.Available))
+		}
+	}
+	if config.CPU >= 0 {
+		term.Printf("  CPU: %.2f usable cores\n", sysinfo.EffectiveCPUs())
+	}
+}
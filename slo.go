@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/probe"
+)
+
+// defaultSLOWindow is the window over which each SLO is evaluated.
+const defaultSLOWindow = 10 * time.Second
+
+// sloStressors maps the stressor names of achieved-load SLOs to the stressors.
+var sloStressors = map[string]string{"cpu": "CPU", "memory": "Memory", "storage": "Storage", "gpu": "GPU"}
+
+// slo is a service level objective that must hold in every window, such as
+// "wakeup-p99<5ms" or "cpu-achieved>90%".
+type slo struct {
+	// Expr is the objective as written by the user.
+	Expr string
+	// Probe is the probe whose latency is checked, or "" for an achieved-load SLO.
+	Probe string
+	// Quantile is the latency quantile checked; 1 checks the maximum.
+	Quantile float64
+	// Stressor is the stressor whose achieved load is checked.
+	Stressor string
+	// Above makes the objective hold while the value exceeds Threshold; otherwise
+	// while it is below. Latencies are in seconds, achieved loads a share of the target.
+	Above     bool
+	Threshold float64
+}
+
+// sloBudget is the number of violated windows each SLO may have, either as a
+// count or as a share of its windows.
+type sloBudget struct {
+	// limit is the number of windows, or the share of the windows when percent is set.
+	limit   float64
+	percent bool
+	set     bool
+}
+
+// parseSLOs parses --slo expressions.
+func parseSLOs(exprs []string) ([]slo, error) {
+	var slos []slo
+	for _, expr := range exprs {
+		for _, part := range splitList(expr) {
+			s, err := parseSLO(part)
+			if err != nil {
+				return nil, err
+			}
+			slos = append(slos, s)
+		}
+	}
+	return slos, nil
+}
+
+// parseSLO parses a single "<probe>-<stat><op><latency>" or
+// "<stressor>-achieved<op><percent>" expression.
+func parseSLO(expr string) (slo, error) {
+	index := strings.IndexAny(expr, "<>")
+	dash := strings.LastIndex(expr[:max(index, 0)], "-")
+	if index <= 0 || dash <= 0 {
+		return slo{}, fmt.Errorf("invalid SLO %q: expected <probe>-<stat><op><latency> or <stressor>-achieved<op><percent>", expr)
+	}
+	s := slo{Expr: expr, Above: expr[index] == '>'}
+	name, stat := strings.ToLower(expr[:dash]), strings.ToLower(expr[dash+1:index])
+	value := strings.TrimSpace(expr[index+1:])
+
+	if stressorName, ok := sloStressors[name]; ok {
+		if stat != "achieved" {
+			return s, fmt.Errorf("invalid SLO %q: stressors support only %s-achieved", expr, name)
+		}
+		level, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || !strings.HasSuffix(value, "%") {
+			return s, fmt.Errorf("invalid achieved load in %q: expected a percentage of the target", expr)
+		}
+		s.Stressor, s.Threshold = stressorName, level/100
+		return s, nil
+	}
+
+	if !probe.Valid(name) {
+		return s, fmt.Errorf("unknown probe or stressor %q in SLO %q (probes: %s; stressors: cpu, memory, storage, gpu)",
+			name, expr, strings.Join(probe.Kinds, ", "))
+	}
+	s.Probe = name
+	switch {
+	case stat == "max":
+		s.Quantile = 1
+	case strings.HasPrefix(stat, "p"):
+		percentile, err := strconv.ParseFloat(stat[1:], 64)
+		if err != nil || percentile <= 0 || percentile > 100 {
+			return s, fmt.Errorf("invalid percentile %q in %q", stat, expr)
+		}
+		s.Quantile = percentile / 100
+	default:
+		return s, fmt.Errorf("invalid statistic %q in %q: expected p50, p99, p99.9 or max", stat, expr)
+	}
+	latency, err := time.ParseDuration(value)
+	if err != nil {
+		return s, fmt.Errorf("invalid latency in %q: %v", expr, err)
+	}
+	s.Threshold = latency.Seconds()
+	return s, nil
+}
+
+// parseSLOBudget parses --slo-budget, a number of windows ("3") or a share of
+// the windows ("1%").
+func parseSLOBudget(value string) (sloBudget, error) {
+	if value == "" {
+		return sloBudget{}, nil
+	}
+	if trimmed, ok := strings.CutSuffix(value, "%"); ok {
+		share, err := strconv.ParseFloat(trimmed, 64)
+		if err != nil || share < 0 || share > 100 {
+			return sloBudget{}, fmt.Errorf("invalid --slo-budget %q: expected a number of windows or a percentage", value)
+		}
+		return sloBudget{limit: share / 100, percent: true, set: true}, nil
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return sloBudget{}, fmt.Errorf("invalid --slo-budget %q: expected a number of windows or a percentage", value)
+	}
+	return sloBudget{limit: float64(count), set: true}, nil
+}
+
+// exceeded reports whether violations out of windows exceed the budget.
+func (b sloBudget) exceeded(violations, windows int) bool {
+	if !b.set {
+		return false
+	}
+	allowed := b.limit
+	if b.percent {
+		allowed *= float64(windows)
+	}
+	return float64(violations) > allowed
+}
+
+// String describes the budget for the final message.
+func (b sloBudget) String() string {
+	if b.percent {
+		return fmt.Sprintf("%g%%", b.limit*100)
+	}
+	return fmt.Sprintf("%g", b.limit)
+}
+
+// sloViolation is a window in which an SLO did not hold.
+type sloViolation struct {
+	Time  time.Time
+	Value float64
+}
+
+// sloResult is the outcome of one SLO over the run.
+type sloResult struct {
+	slo
+	Windows    int
+	Violations []sloViolation
+}
+
+// sloMonitor evaluates the SLOs once every window while the stressors run.
+type sloMonitor struct {
+	mu      sync.Mutex
+	results []sloResult
+}
+
+// newSLOMonitor returns a monitor of slos that has not evaluated any window yet.
+func newSLOMonitor(slos []slo) *sloMonitor {
+	m := &sloMonitor{}
+	for _, s := range slos {
+		m.results = append(m.results, sloResult{slo: s})
+	}
+	return m
+}
+
+// start evaluates the SLOs every window until ctx is done. Windows in which the
+// probe or the stressor recorded nothing are not counted.
+func (m *sloMonitor) start(ctx context.Context, window time.Duration, recorder *metrics.Recorder) {
+	if len(m.results) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		previous, since := recorder.Latencies(), time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				w := sloWindow{since: since, latencies: recorder.Latencies(), previous: previous, samples: recorder.Samples()}
+				m.evaluate(now, w, recorder)
+				previous, since = w.latencies, now
+			}
+		}
+	}()
+}
+
+// sloWindow is what was recorded up to the end of a window.
+type sloWindow struct {
+	since time.Time
+	// latencies and previous are the latencies at the end and at the start of the window.
+	latencies, previous map[string]metrics.Histogram
+	samples             []metrics.Sample
+}
+
+// evaluate checks every SLO over the window ending at now.
+func (m *sloMonitor) evaluate(now time.Time, w sloWindow, recorder *metrics.Recorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.results {
+		r := &m.results[i]
+		value, ok := r.measure(w)
+		if !ok {
+			continue
+		}
+		r.Windows++
+		if (r.Above && value > r.Threshold) || (!r.Above && value < r.Threshold) {
+			continue
+		}
+		r.Violations = append(r.Violations, sloViolation{Time: now, Value: value})
+		recorder.Logf("SLO", "Violated %s (observed %s)", r.Expr, r.format(value))
+	}
+}
+
+// measure returns the value of the SLO over the window, and false when the
+// window has nothing to measure.
+func (s slo) measure(w sloWindow) (float64, bool) {
+	if s.Probe != "" {
+		key := probe.Name + " " + s.Probe
+		h := w.latencies[key].Since(w.previous[key])
+		if h.Count == 0 {
+			return 0, false
+		}
+		if s.Quantile >= 1 {
+			return h.Max.Seconds(), true
+		}
+		return h.Quantile(s.Quantile).Seconds(), true
+	}
+	var sum float64
+	var n int
+	for _, sample := range w.samples {
+		if sample.Stressor == s.Stressor && sample.Time.After(w.since) && sample.Target > 0 {
+			sum += sample.Achieved / sample.Target
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// format formats a value of the SLO for display.
+func (s slo) format(value float64) string {
+	if s.Probe != "" {
+		return roundLatency(time.Duration(value * float64(time.Second))).String()
+	}
+	return fmt.Sprintf("%.1f%%", value*100)
+}
+
+// Results returns the outcome of each SLO so far.
+func (m *sloMonitor) Results() []sloResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := make([]sloResult, len(m.results))
+	for i, r := range m.results {
+		r.Violations = slices.Clone(r.Violations)
+		results[i] = r
+	}
+	return results
+}
+
+// stressorConfigured reports whether the run loads the stressor of an achieved-load SLO.
+func stressorConfigured(config Config, name string) bool {
+	switch name {
+	case "CPU":
+		return config.CPU >= 0 || config.Profile != ""
+	case "Memory":
+		return config.Memory != "" || config.Profile != ""
+	case "Storage":
+		return config.Storage != ""
+	}
+	return false
+}
+
+// exceededSLOs returns the SLOs whose violations exceed budget.
+func exceededSLOs(results []sloResult, budget sloBudget) []string {
+	var exprs []string
+	for _, r := range results {
+		if budget.exceeded(len(r.Violations), r.Windows) {
+			exprs = append(exprs, r.Expr)
+		}
+	}
+	return exprs
+}
+
+// printSLOReport prints how many windows violated each SLO, and when.
+func printSLOReport(results []sloResult, window time.Duration) {
+	if len(results) == 0 {
+		return
+	}
+
+	term.Printf("SLOs (%v windows):\n", window)
+	for _, r := range results {
+		line := i18n.Sprintf("  %s: %d of %d windows violated", r.Expr, len(r.Violations), r.Windows)
+		if n := len(r.Violations); n > 0 {
+			worst := r.Violations[0].Value
+			for _, v := range r.Violations {
+				if r.Above {
+					worst = math.Min(worst, v.Value)
+				} else {
+					worst = math.Max(worst, v.Value)
+				}
+			}
+			line += i18n.Sprintf(" (first at %s, last at %s, worst %s)",
+				r.Violations[0].Time.Format("15:04:05"), r.Violations[n-1].Time.Format("15:04:05"), r.format(worst))
+		}
+		term.Printf("%s\n", line)
+	}
+	term.Println()
+}
+
+// sloSummaries converts the SLO results for the run summary.
+func sloSummaries(results []sloResult, budget sloBudget) []cluster.SLOSummary {
+	var summaries []cluster.SLOSummary
+	for _, r := range results {
+		s := cluster.SLOSummary{
+			SLO:      r.Expr,
+			Windows:  r.Windows,
+			Exceeded: budget.exceeded(len(r.Violations), r.Windows),
+		}
+		for _, v := range r.Violations {
+			s.Violations = append(s.Violations, cluster.SLOViolation{Time: v.Time, Value: v.Value})
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/smart"
+)
+
+// defaultSmartInterval is how often --smart reads the device unless
+// --smart-interval is given. Drives update most attributes only every minute or so.
+const defaultSmartInterval = time.Minute
+
+// smartReadTimeout bounds one smartctl run, so that a hung device does not stall the monitor.
+const smartReadTimeout = 30 * time.Second
+
+// smartMonitor follows the SMART health of the device under test through the run.
+type smartMonitor struct {
+	device   string
+	interval time.Duration
+	maxTemp  float64
+
+	mu      sync.Mutex
+	first   smart.Health
+	last    smart.Health
+	hottest float64
+}
+
+// startSmart reads the SMART health of the device under --smart-device, or of
+// the disk that the storage load writes to, as the baseline of the run. It
+// returns nil, saying why, when the health cannot be read.
+func startSmart(config Config) *smartMonitor {
+	if !config.Smart {
+		return nil
+	}
+	device := config.SmartDevice
+	if device == "" {
+		var err error
+		if device, err = smart.DeviceOf(storageDir(config)); err != nil {
+			term.Printf("SMART: not monitored (%v; set --smart-device)\n", err)
+			return nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), smartReadTimeout)
+	defer cancel()
+	health, err := smart.Read(ctx, device)
+	if err != nil {
+		term.Printf("SMART: not monitored (%v)\n", err)
+		return nil
+	}
+	if !health.Passed {
+		term.Printf("SMART: Warning: %s already fails its overall health assessment\n", device)
+	}
+	term.Printf("SMART: monitoring %s (%s) every %v: %s\n", device, health.Model, config.SmartInterval, describeSmart(health))
+	return &smartMonitor{
+		device:   device,
+		interval: config.SmartInterval,
+		maxTemp:  config.SmartMaxTemp,
+		first:    health,
+		last:     health,
+		hottest:  health.Temperature,
+	}
+}
+
+// watch reads the health every interval until ctx is done, logging what changed
+// and recording the temperature. It returns why the health degraded since the
+// start of the run, or an empty string if it did not.
+func (m *smartMonitor) watch(ctx context.Context, recorder *metrics.Recorder) string {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	recorder.RecordValue("Disk temperature", "°C", m.first.Temperature)
+	warned := false
+	for {
+		select {
+		case <-ctx.Done():
+			return ""
+		case <-ticker.C:
+		}
+		readCtx, cancel := context.WithTimeout(ctx, smartReadTimeout)
+		health, err := smart.Read(readCtx, m.device)
+		cancel()
+		if err != nil {
+			// Report an unreadable device once instead of every interval
+			if ctx.Err() == nil && !warned {
+				recorder.Logf("SMART", "Warning: %v", err)
+				warned = true
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		previous := m.last
+		m.last = health
+		m.hottest = max(m.hottest, health.Temperature)
+		m.mu.Unlock()
+		recorder.RecordValue("Disk temperature", "°C", health.Temperature)
+		if health.Errors() != previous.Errors() || health.Passed != previous.Passed || health.CriticalWarning != previous.CriticalWarning {
+			recorder.Logf("SMART", "%s: %s", m.device, describeSmart(health))
+		}
+
+		reasons := health.Degradation(m.first)
+		if m.maxTemp > 0 && health.Temperature > m.maxTemp {
+			reasons = append(reasons, i18n.Sprintf("temperature %.0f°C above %.0f°C", health.Temperature, m.maxTemp))
+		}
+		if len(reasons) > 0 {
+			return i18n.Sprintf("SMART health of %s degraded: %s", m.device, strings.Join(reasons, ", "))
+		}
+	}
+}
+
+// describeSmart summarizes the health attributes that the monitor compares.
+func describeSmart(h smart.Health) string {
+	status := i18n.T("passed")
+	if !h.Passed {
+		status = i18n.T("FAILED")
+	}
+	return i18n.Sprintf("health %s, temperature %.0f°C, reallocated %d, pending %d, uncorrectable %d, media errors %d",
+		status, h.Temperature, h.ReallocatedSectors, h.PendingSectors, h.UncorrectableSectors, h.MediaErrors)
+}
+
+// printReport prints the health at the start and the end of the run and the
+// highest temperature seen in between.
+func (m *smartMonitor) printReport() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	term.Printf("SMART (%s):\n", m.device)
+	term.Printf("  Start: %s\n", describeSmart(m.first))
+	term.Printf("  End:   %s\n", describeSmart(m.last))
+	term.Printf("  Highest temperature: %.0f°C\n", m.hottest)
+	term.Println()
+}
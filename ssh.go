@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+)
+
+// interruptByte is the character a remote terminal turns into SIGINT.
+const interruptByte = "\x03"
+
+// summaryMarker prefixes the line on which the remote command prints the run summary.
+const summaryMarker = "STRESS-GO-SUMMARY "
+
+// sshHost is one line of the --ssh-hosts file: a host for ssh and the
+// variables substituted into the load test options for that host.
+type sshHost struct {
+	target string
+	vars   map[string]string
+}
+
+// sshOptions configures how the coordinator reaches the hosts over ssh.
+type sshOptions struct {
+	copy bool // copy this executable to the hosts before running
+	// remotePath is the path of stress-go on the remote hosts. With copy and an
+	// empty remotePath, the executable goes to a new private directory instead.
+	remotePath string
+	extra      []string // additional ssh/scp options
+}
+
+// sshRun tracks the load test running on one host over ssh.
+type sshRun struct {
+	host sshHost
+	args []string
+	// remotePath is the path of stress-go on the host, and tempDir the private
+	// directory it was copied to, removed after the run, if any
+	remotePath string
+	tempDir    string
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	output     []string
+	summary    *cluster.Summary
+	err        error
+	code       int
+}
+
+// loadSSHHosts reads the --ssh-hosts file. Each non-empty line holds an ssh
+// destination followed by optional key=value variables; # starts a comment.
+func loadSSHHosts(path string) ([]sshHost, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh hosts: %v", err)
+	}
+
+	var hosts []sshHost
+	for n, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		// ssh and scp would take a destination starting with "-" as an option
+		if strings.HasPrefix(fields[0], "-") {
+			return nil, fmt.Errorf("%s:%d: invalid host %q", path, n+1, fields[0])
+		}
+		host := sshHost{target: fields[0], vars: make(map[string]string)}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("%s:%d: invalid variable %q: expected key=value", path, n+1, field)
+			}
+			host.vars[key] = value
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in %s", path)
+	}
+	return hosts, nil
+}
+
+// expandArgs substitutes the host's variables into the load test options.
+// Templates use Go template syntax; {{.Host}} and {{.Index}} are always defined.
+func expandArgs(args []string, host sshHost, index int) ([]string, error) {
+	data := map[string]string{"Host": host.target, "Index": fmt.Sprint(index)}
+	for key, value := range host.vars {
+		data[key] = value
+	}
+
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		tmpl, err := template.New("arg").Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %q: %v", arg, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("host %s: %v", host.target, err)
+		}
+		expanded[i] = buf.String()
+	}
+	return expanded, nil
+}
+
+// shellQuote quotes s for a POSIX shell on the remote host.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runSSHFanout runs the load test on every host over ssh, streams the output
+// prefixed with the host, and returns the result on each host.
+func runSSHFanout(hosts []sshHost, jobArgs []string, opts sshOptions, startDelay time.Duration, sigChan <-chan os.Signal) []hostResult {
+	runs := make([]*sshRun, len(hosts))
+	for i, host := range hosts {
+		args, err := expandArgs(jobArgs, host, i)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			os.Exit(exitConfigError)
+		}
+		runs[i] = &sshRun{host: host, args: args, remotePath: opts.remotePath}
+	}
+
+	if opts.copy {
+		err := copyExecutable(runs, opts)
+		defer removeCopies(runs, opts)
+		if err != nil {
+			removeCopies(runs, opts)
+			os.Exit(exitStartupFailure)
+		}
+	}
+
+	// Without an agent to measure clocks, the start barrier relies on NTP
+	if startDelay > 0 {
+		startAt := time.Now().Add(startDelay)
+		term.Printf("Start barrier: %s (host clocks must be synchronized)\n", startAt.Format("15:04:05.000"))
+		for _, r := range runs {
+			r.args = append(r.args, "--start-at", startAt.UTC().Format(time.RFC3339Nano))
+		}
+	}
+
+	term.Printf("Starting on %d hosts over ssh\n", len(runs))
+	var printMu sync.Mutex
+	var wg sync.WaitGroup
+	for _, r := range runs {
+		if err := r.start(opts, &printMu, &wg); err != nil {
+			r.err = err
+			term.Eprintf("[%s] Error: %v\n", r.host.target, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-sigChan:
+		term.Println("\nInterrupt signal received. Stopping all hosts...")
+		for _, r := range runs {
+			if r.stdin != nil {
+				io.WriteString(r.stdin, interruptByte)
+			}
+		}
+		select {
+		case <-done:
+		case <-sigChan:
+			// A second interrupt drops the connections; the remote runs get SIGHUP
+			term.Println("\nInterrupt signal received again. Closing ssh connections...")
+			for _, r := range runs {
+				if r.cmd != nil && r.cmd.Process != nil {
+					r.cmd.Process.Kill()
+				}
+			}
+			<-done
+		}
+	}
+
+	var results []hostResult
+	for _, r := range runs {
+		results = append(results, hostResult{
+			host:   r.host.target,
+			status: cluster.JobStatus{State: cluster.StateFinished, ExitCode: r.code, Output: r.output, Summary: r.summary},
+			err:    r.err,
+		})
+	}
+	return results
+}
+
+// start launches the load test on the host. A terminal is allocated so that an
+// interrupt can be forwarded and the remote stress-go still cleans up. The remote
+// command prints the run summary after stress-go exits and keeps its exit status.
+func (r *sshRun) start(opts sshOptions, printMu *sync.Mutex, wg *sync.WaitGroup) error {
+	command := shellQuote(r.remotePath)
+	for _, arg := range r.args {
+		command += " " + shellQuote(arg)
+	}
+	remote := fmt.Sprintf(`f=$(mktemp) || exit 1; %s --summary-json "$f"; rc=$?; printf '%s%%s\n' "$(cat "$f")"; rm -f "$f"; exit $rc`,
+		command, summaryMarker)
+	sshArgs := append([]string{"-tt", "-o", "BatchMode=yes"}, opts.extra...)
+	sshArgs = append(sshArgs, "--", r.host.target, remote)
+
+	r.cmd = exec.Command("ssh", sshArgs...)
+	stdin, err := r.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := r.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	r.cmd.Stderr = r.cmd.Stdout
+	if err := r.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run ssh: %v", err)
+	}
+	r.stdin = stdin
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stdout)
+		scanner.Split(scanTerminalLines)
+		for scanner.Scan() {
+			// Progress lines overwrite each other on a terminal and would interleave here
+			line := scanner.Text()
+			if line == "" || isProgressLine(line) {
+				continue
+			}
+			if data, ok := strings.CutPrefix(line, summaryMarker); ok {
+				var summary cluster.Summary
+				if json.Unmarshal([]byte(data), &summary) == nil {
+					r.summary = &summary
+				}
+				continue
+			}
+			r.output = append(r.output, line)
+			printMu.Lock()
+			term.Printf("[%s] %s\n", r.host.target, line)
+			printMu.Unlock()
+		}
+		r.cmd.Wait()
+		r.code = r.cmd.ProcessState.ExitCode()
+		// ssh reports its own failures (connection, authentication) as 255
+		if r.code == 255 {
+			r.err = fmt.Errorf("ssh failed%s", lastOutput(cluster.JobStatus{Output: r.output}))
+		}
+	}()
+	return nil
+}
+
+// scanTerminalLines is a bufio.SplitFunc that splits terminal output into lines
+// at both \n and \r, so that redrawn progress lines become separate tokens.
+func scanTerminalLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// copyExecutable copies this executable to every host and makes it executable.
+// Unless --ssh-path is given, each host gets it in a new directory made by
+// mktemp -d, which other users can neither predict nor write to.
+func copyExecutable(runs []*sshRun, opts sshOptions) error {
+	executable, err := os.Executable()
+	if err != nil {
+		term.Eprintf("Error: Cannot locate the stress-go executable: %v\n", err)
+		return err
+	}
+
+	if opts.remotePath != "" {
+		term.Printf("Copying %s to %s on %d hosts\n", filepath.Base(executable), opts.remotePath, len(runs))
+	} else {
+		term.Printf("Copying %s to a temporary directory on %d hosts\n", filepath.Base(executable), len(runs))
+	}
+	var mu sync.Mutex
+	var failed error
+	var wg sync.WaitGroup
+	for _, r := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			output, err := r.copyExecutable(executable, opts)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				term.Eprintf("[%s] Error: Copy failed: %v %s\n", r.host.target, err, strings.TrimSpace(string(output)))
+				failed = err
+			}
+		}()
+	}
+	wg.Wait()
+	return failed
+}
+
+// copyExecutable copies executable to the host and returns the output of the
+// command that failed, if any.
+func (r *sshRun) copyExecutable(executable string, opts sshOptions) ([]byte, error) {
+	if r.remotePath == "" {
+		output, err := r.ssh(opts, "mktemp -d")
+		if err != nil {
+			return output, err
+		}
+		r.tempDir = strings.TrimSpace(string(output))
+		if !strings.HasPrefix(r.tempDir, "/") {
+			return output, fmt.Errorf("unexpected mktemp output")
+		}
+		r.remotePath = r.tempDir + "/stress-go"
+	}
+	scpArgs := append([]string{"-q", "-o", "BatchMode=yes"}, opts.extra...)
+	scpArgs = append(scpArgs, "--", executable, r.host.target+":"+r.remotePath)
+	if output, err := exec.Command("scp", scpArgs...).CombinedOutput(); err != nil {
+		return output, err
+	}
+	return r.ssh(opts, "chmod 700 "+shellQuote(r.remotePath))
+}
+
+// removeCopies removes the temporary directories the executable was copied to.
+func removeCopies(runs []*sshRun, opts sshOptions) {
+	var wg sync.WaitGroup
+	for _, r := range runs {
+		if r.tempDir == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if output, err := r.ssh(opts, "rm -rf "+shellQuote(r.tempDir)); err != nil {
+				term.Eprintf("[%s] Warning: Failed to remove %s: %v %s\n", r.host.target, r.tempDir, err, strings.TrimSpace(string(output)))
+			}
+			r.tempDir = ""
+		}()
+	}
+	wg.Wait()
+}
+
+// ssh runs command on the host without a terminal and returns its output.
+func (r *sshRun) ssh(opts sshOptions, command string) ([]byte, error) {
+	sshArgs := append([]string{"-o", "BatchMode=yes"}, opts.extra...)
+	sshArgs = append(sshArgs, "--", r.host.target, command)
+	output, err := exec.Command("ssh", sshArgs...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		output = append(output, exitErr.Stderr...)
+	}
+	return output, err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSSHHosts(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []sshHost
+		ok      bool
+	}{
+		{
+			name:    "hosts and variables",
+			content: "# load generators\nweb1 dir=/data\n\nops@web2:2222 dir=/mnt size=10GB # the big one\n",
+			want: []sshHost{
+				{target: "web1", vars: map[string]string{"dir": "/data"}},
+				{target: "ops@web2:2222", vars: map[string]string{"dir": "/mnt", "size": "10GB"}},
+			},
+			ok: true,
+		},
+		{name: "empty value", content: "web1 tag=\n", want: []sshHost{{target: "web1", vars: map[string]string{"tag": ""}}}, ok: true},
+		{name: "no hosts", content: "# nothing\n\n", ok: false},
+		{name: "option as host", content: "-oProxyCommand=id\n", ok: false},
+		{name: "bare variable", content: "web1 dir\n", ok: false},
+		{name: "no key", content: "web1 =x\n", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hosts")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadSSHHosts(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("loadSSHHosts = %v, want ok=%v", err, tt.ok)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadSSHHosts = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadSSHHosts(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loadSSHHosts of a missing file succeeded")
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stressor"
+)
+
+// stateVersion is the format of the --state file; resume refuses other versions.
+const stateVersion = 1
+
+// stateDirPrefixes are the names of the temporary directories the stressors
+// write to. resume removes only leftover directories matching one of them, so
+// an edited state file cannot make it delete anything else.
+var stateDirPrefixes = []string{"stress-tool-storage-", "stress-go-pagefault-", "stress-go-sparse-", "stress-go-files-"}
+
+// runState is the checkpoint written to --state while a run goes on, from which
+// "stress-go resume" continues it after a crash or a deliberate restart.
+type runState struct {
+	Version int `json:"version"`
+	// Args are the options of the run as given, including the replay or burnin keyword.
+	Args []string `json:"args"`
+	// Started is when the first segment of the run started.
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+	// ElapsedSeconds is the time the run has been going over all its segments,
+	// and RemainingSeconds the time left of it.
+	ElapsedSeconds   float64 `json:"elapsed_seconds"`
+	RemainingSeconds float64 `json:"remaining_seconds"`
+	// Counts are the cumulative counters of each stressor over all the segments.
+	Counts map[string]map[string]int64 `json:"counts,omitempty"`
+	// Dirs are the directories the stressors were writing their files to.
+	Dirs []string `json:"dirs,omitempty"`
+	// Finished is set once the run has ended on its own; only an interrupted
+	// or crashed run can be resumed.
+	Finished bool `json:"finished"`
+}
+
+// elapsed returns the time the run has been going over all its segments.
+func (s *runState) elapsed() time.Duration {
+	return time.Duration(s.ElapsedSeconds * float64(time.Second))
+}
+
+// remaining returns the time left of the run.
+func (s *runState) remaining() time.Duration {
+	return time.Duration(s.RemainingSeconds * float64(time.Second))
+}
+
+// loadState reads a --state file and checks that the run can be resumed from it.
+func loadState(path string) (*runState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s runState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %v", path, err)
+	}
+	switch {
+	case s.Version != stateVersion:
+		return nil, fmt.Errorf("unsupported state file version %d in %s", s.Version, path)
+	case len(s.Args) == 0:
+		return nil, fmt.Errorf("state file %s has no options", path)
+	case s.Finished:
+		return nil, fmt.Errorf("the run in %s has already finished", path)
+	case s.RemainingSeconds <= 0:
+		return nil, fmt.Errorf("the run in %s has no time left", path)
+	}
+	return &s, nil
+}
+
+// withOption returns args with every occurrence of the option name (as -name,
+// --name, with a separate value or with =) replaced by one at the end set to value.
+func withOption(args []string, name, value string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		option, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || option != name {
+			result = append(result, args[i])
+			continue
+		}
+		if !hasValue {
+			i++
+		}
+	}
+	return append(result, "--"+name, value)
+}
+
+// runResume handles "stress-go resume": it loads the state of an interrupted
+// run, removes the files it left behind and returns the state together with the
+// options that continue the run for the time left, with checkpoints to the same file.
+func runResume(args []string) (*runState, []string) {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	var path string
+	fs.StringVar(&path, "state", "", "State file written by the interrupted run's --state")
+	fs.Parse(args)
+	if path == "" {
+		term.Eprintf("Error: --state option is required\n")
+		os.Exit(exitConfigError)
+	}
+
+	state, err := loadState(path)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	for _, dir := range state.Dirs {
+		if !slices.ContainsFunc(stateDirPrefixes, func(prefix string) bool { return strings.HasPrefix(filepath.Base(dir), prefix) }) {
+			term.Eprintf("Warning: Not removing %s, which is not a stress-go directory\n", dir)
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			term.Eprintf("Warning: Failed to remove %s: %v\n", dir, err)
+		} else {
+			term.Printf("Removed the files left in %s\n", dir)
+		}
+	}
+	term.Printf("Resuming the run started at %s: %v elapsed, %v remaining\n\n",
+		state.Started.Local().Format("2006-01-02 15:04:05"), state.elapsed().Truncate(time.Second), state.remaining().Truncate(time.Second))
+
+	resumed := withOption(state.Args, "timeout", state.remaining().String())
+	return state, withOption(resumed, "state", path)
+}
+
+// checkpoint periodically writes the state of the run to a --state file.
+type checkpoint struct {
+	path    string
+	args    []string
+	started time.Time
+	// offset is the time the run had been going before this segment started at start.
+	offset   time.Duration
+	start    time.Time
+	dl       *deadline.Deadline
+	recorder *metrics.Recorder
+	registry *stressor.Registry
+
+	mu sync.Mutex
+	// dirs are the directories seen so far, kept until they have been removed
+	dirs []string
+}
+
+// run saves the state every interval until ctx is done. The final state is
+// saved by the caller once the stressors have stopped.
+func (c *checkpoint) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.save(time.Now(), false); err != nil {
+				term.Eprintf("Warning: Failed to write the run state: %v\n", err)
+			}
+		}
+	}
+}
+
+// save atomically replaces the state file with the state of the run as of at.
+func (c *checkpoint) save(at time.Time, finished bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.registry.Stressors() {
+		if dir := s.Stats().Dir; dir != "" && !slices.Contains(c.dirs, dir) {
+			c.dirs = append(c.dirs, dir)
+		}
+	}
+	// Directories the stressors have cleaned up no longer need removing
+	c.dirs = slices.DeleteFunc(c.dirs, func(dir string) bool {
+		_, err := os.Stat(dir)
+		return os.IsNotExist(err)
+	})
+
+	state := runState{
+		Version:          stateVersion,
+		Args:             c.args,
+		Started:          c.started,
+		Updated:          time.Now(),
+		ElapsedSeconds:   (c.offset + at.Sub(c.start)).Seconds(),
+		RemainingSeconds: max(c.dl.End().Sub(at), 0).Seconds(),
+		Counts:           c.recorder.Counts(),
+		Dirs:             c.dirs,
+		Finished:         finished,
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), "."+filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	// The state must survive the crash it is written for
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// seedCounts adds the counters of the earlier segments of a resumed run to recorder.
+func seedCounts(recorder *metrics.Recorder, counts map[string]map[string]int64) {
+	for stressor, c := range counts {
+		for name, value := range c {
+			recorder.AddCount(stressor, name, value)
+		}
+	}
+}
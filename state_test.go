@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWithOption(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		value string
+		want  []string
+	}{
+		{
+			name:  "separate value",
+			args:  []string{"--timeout", "72h", "--cpu", "0"},
+			value: "10h0m0s",
+			want:  []string{"--cpu", "0", "--timeout", "10h0m0s"},
+		},
+		{
+			name:  "equals and single dash",
+			args:  []string{"burnin", "-timeout=72h", "--memory", "50%"},
+			value: "1h0m0s",
+			want:  []string{"burnin", "--memory", "50%", "--timeout", "1h0m0s"},
+		},
+		{
+			name:  "repeated",
+			args:  []string{"--timeout", "1h", "--timeout=2h", "--cpu", "1"},
+			value: "30m0s",
+			want:  []string{"--cpu", "1", "--timeout", "30m0s"},
+		},
+		{
+			name:  "absent",
+			args:  []string{"burnin"},
+			value: "5h0m0s",
+			want:  []string{"burnin", "--timeout", "5h0m0s"},
+		},
+		{
+			name:  "similar name",
+			args:  []string{"--timeout-x", "1", "--stop-timeout", "1m"},
+			value: "1s",
+			want:  []string{"--timeout-x", "1", "--stop-timeout", "1m", "--timeout", "1s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withOption(tt.args, "timeout", tt.value); !slices.Equal(got, tt.want) {
+				t.Errorf("withOption(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadState(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		elapsed   time.Duration
+		remaining time.Duration
+		ok        bool
+	}{
+		{
+			name:      "interrupted",
+			content:   `{"version":1,"args":["--timeout","72h","--cpu","0"],"elapsed_seconds":3600,"remaining_seconds":255600}`,
+			elapsed:   time.Hour,
+			remaining: 71 * time.Hour,
+			ok:        true,
+		},
+		{name: "finished", content: `{"version":1,"args":["--cpu","0"],"remaining_seconds":60,"finished":true}`, ok: false},
+		{name: "no time left", content: `{"version":1,"args":["--cpu","0"],"elapsed_seconds":60}`, ok: false},
+		{name: "no options", content: `{"version":1,"remaining_seconds":60}`, ok: false},
+		{name: "other version", content: `{"version":2,"args":["--cpu","0"],"remaining_seconds":60}`, ok: false},
+		{name: "not json", content: "elapsed=1h\n", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			state, err := loadState(path)
+			if (err == nil) != tt.ok {
+				t.Fatalf("loadState() error = %v, want ok %v", err, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			if state.elapsed() != tt.elapsed || state.remaining() != tt.remaining {
+				t.Errorf("loadState() = %v elapsed, %v remaining, want %v, %v", state.elapsed(), state.remaining(), tt.elapsed, tt.remaining)
+			}
+		})
+	}
+}
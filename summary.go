@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/events"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/sysinfo"
+)
+
+// writeSummary returns a subscriber that writes the machine-readable summary of
+// the run to path when the run finishes. Agents and the coordinator collect these
+// summaries to build the cluster report.
+func writeSummary(path string, summarize func(events.Event) cluster.Summary) func(events.Event) {
+	return func(e events.Event) {
+		if e.Type != events.RunFinished {
+			return
+		}
+		data, err := json.Marshal(summarize(e))
+		if err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write summary: %v\n", err)
+		}
+	}
+}
+
+// summarizer returns a function that builds the summary of the run from what has
+// been recorded so far and the RunFinished event.
+func summarizer(start time.Time, seed uint64, recorder *metrics.Recorder, supervisor *stressorSupervisor, meter *sysinfo.EnergyMeter, self *overheadMeter, base *baseline, slos *sloMonitor, budget sloBudget, thresholds []threshold) func(events.Event) cluster.Summary {
+	return func(e events.Event) cluster.Summary {
+		code, _ := e.Fields["exit_code"].(int)
+		summary := cluster.Summary{
+			Start:       start,
+			End:         e.Time,
+			ExitCode:    code,
+			Seed:        seed,
+			Message:     e.Message,
+			Environment: recorder.Labels(),
+			Stressors:   []cluster.StressorSummary{},
+			Power:       powerSummaries(meter.Total()),
+			Baseline:    base.deltas(),
+			Probes:      probeSummaries(recorder.Latencies(), base),
+			SLOs:        sloSummaries(slos.Results(), budget),
+			Thresholds:  thresholdSummaries(evaluateThresholds(thresholds, recorder.Deviations(), recorder.Values())),
+		}
+		usage, _ := self.total()
+		summary.Overhead = &usage
+		checks := make(map[string]metrics.Verification)
+		for _, v := range recorder.Verifications() {
+			checks[v.Stressor] = v
+		}
+		workers := make(map[string][]cluster.WorkerSummary)
+		for _, w := range recorder.WorkerUsages() {
+			workers[w.Stressor] = append(workers[w.Stressor], cluster.WorkerSummary{
+				Worker:      w.Worker,
+				Target:      w.MeanTarget,
+				Achieved:    w.MeanAchieved,
+				MinAchieved: w.MinAchieved,
+			})
+		}
+		for _, d := range recorder.Deviations() {
+			summary.Stressors = append(summary.Stressors, cluster.StressorSummary{
+				Name:          d.Stressor,
+				Unit:          d.Unit,
+				Target:        d.MeanTarget,
+				Achieved:      d.MeanAchieved,
+				MinAchieved:   d.MinAchieved,
+				Deviation:     d.MeanDeviation,
+				Degraded:      d.Degraded,
+				Issues:        d.Issues,
+				Checks:        checks[d.Stressor].Checks,
+				CheckFailures: checks[d.Stressor].Failures,
+				Workers:       workers[d.Stressor],
+			})
+		}
+		for _, p := range recorder.Phases() {
+			summary.Phases = append(summary.Phases, cluster.Phase{Name: p.Name, Start: p.Start, End: p.End})
+		}
+		for _, f := range supervisor.Failures() {
+			summary.Failures = append(summary.Failures, fmt.Sprintf("%s: %v", f.name, f.err))
+		}
+		return summary
+	}
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+	"github.com/utkamioka/stress-go/pkg/stress"
+	"github.com/utkamioka/stress-go/pkg/tenant"
+)
+
+// cpuContainmentTolerance is how far above its cpu.max a tenant may run in one
+// sample interval before it counts as not contained, for the sampling jitter.
+const cpuContainmentTolerance = 0.05
+
+// tenantName is the pattern of a tenant name, which names its cgroup directory.
+var tenantName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tenantSpec is one --tenant of the tenants subcommand.
+type tenantSpec struct {
+	name   string
+	limits tenant.Limits
+	args   []string
+}
+
+// tenantReport is the result of one tenant as written by --json.
+type tenantReport struct {
+	Name   string `json:"name"`
+	Limits string `json:"limits"`
+	// MeanCores and MaxCores are the CPU the tenant's cgroup used on average and
+	// in the busiest sample interval.
+	MeanCores        float64 `json:"mean_cores"`
+	MaxCores         float64 `json:"max_cores"`
+	ThrottledShare   float64 `json:"throttled_share"`
+	ThrottledSeconds float64 `json:"throttled_seconds"`
+	PeakMemoryBytes  int64   `json:"peak_memory_bytes"`
+	MemoryHighEvents int64   `json:"memory_high_events"`
+	MemoryMaxEvents  int64   `json:"memory_max_events"`
+	OOMKills         int64   `json:"oom_kills"`
+	// Contained is false when the tenant used more than its limits allow, as
+	// described by Escapes.
+	Contained bool     `json:"contained"`
+	Escapes   []string `json:"escapes,omitempty"`
+	// ExitCode and Summary are the outcome of the tenant's stress-go run.
+	ExitCode int              `json:"exit_code"`
+	Summary  *cluster.Summary `json:"summary,omitempty"`
+}
+
+// tenantsReport is the result of the tenants subcommand as written by --json.
+type tenantsReport struct {
+	Version string         `json:"version"`
+	Time    time.Time      `json:"time"`
+	Timeout string         `json:"timeout"`
+	Tenants []tenantReport `json:"tenants"`
+}
+
+// tenantRun follows the stress-go process of one tenant.
+type tenantRun struct {
+	spec        tenantSpec
+	group       *tenant.Group
+	cmd         *exec.Cmd
+	summaryPath string
+	done        chan struct{}
+
+	last     tenant.Stats
+	lastTime time.Time
+	maxCores float64
+	maxMem   int64
+}
+
+// runTenants implements the tenants subcommand, which runs a load in each of
+// several cgroups with their own limits and reports what each achieved and how
+// often it was throttled, to test whether the limits contain a noisy tenant.
+func runTenants(args []string) {
+	flags := flag.NewFlagSet("tenants", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 0, "Duration to apply the load of every tenant [required]")
+	var specs stringList
+	flags.Var(&specs, "tenant", "Tenant given as name:limits:options, e.g. noisy:cpu-max=1,memory-max=1GB:--cpu 0 --memory 2GB (repeatable)")
+	interval := flags.Duration("interval", 5*time.Second, "Interval between readings of the cgroup statistics")
+	jsonPath := flags.String("json", "", "Write the result of every tenant to this file as JSON")
+	flags.Parse(args)
+
+	if *timeout <= 0 {
+		term.Eprintf("Error: --timeout option is required\n")
+		os.Exit(exitConfigError)
+	}
+	if *interval <= 0 {
+		term.Eprintf("Error: --interval must be positive\n")
+		os.Exit(exitConfigError)
+	}
+	tenants, err := parseTenants(specs)
+	if err != nil {
+		term.Eprintf("Error: %v\n", err)
+		os.Exit(exitConfigError)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		term.Eprintf("Error: Cannot locate the stress-go executable: %v\n", err)
+		os.Exit(exitFailure)
+	}
+
+	hierarchy, err := tenant.NewHierarchy(fmt.Sprintf("stress-go-tenants-%d", os.Getpid()))
+	if err != nil {
+		term.Eprintf("Error: Cannot create the tenant cgroups: %v\n", err)
+		os.Exit(exitStartupFailure)
+	}
+
+	term.Printf("Running %d tenants for %v in their own cgroups...\n", len(tenants), *timeout)
+	var runs []*tenantRun
+	var printMu sync.Mutex
+	for _, spec := range tenants {
+		run, err := startTenant(executable, spec, *timeout, hierarchy, &printMu)
+		if err != nil {
+			term.Eprintf("Error: %v\n", err)
+			stopTenants(runs)
+			hierarchy.Remove()
+			os.Exit(exitStartupFailure)
+		}
+		term.Printf("  %s: limits %s, options %s\n", spec.name, spec.limits, strings.Join(spec.args, " "))
+		runs = append(runs, run)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	allDone := make(chan struct{})
+	go func() {
+		for _, r := range runs {
+			<-r.done
+		}
+		close(allDone)
+	}()
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	start := time.Now()
+wait:
+	for {
+		select {
+		case <-sigChan:
+			term.Println("\nInterrupt signal received. Stopping tenants...")
+			stopTenants(runs)
+		case now := <-ticker.C:
+			for _, r := range runs {
+				r.sample(now)
+			}
+		case <-allDone:
+			break wait
+		}
+	}
+	elapsed := time.Since(start)
+
+	report := tenantsReport{Version: stress.Version, Time: start, Timeout: timeout.String()}
+	for _, r := range runs {
+		report.Tenants = append(report.Tenants, r.report(elapsed))
+	}
+	printTenantReport(report.Tenants)
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*jsonPath, data, 0644)
+		}
+		if err != nil {
+			term.Eprintf("Error: Failed to write tenant results: %v\n", err)
+		} else {
+			term.Printf("Tenant results written to %s\n", *jsonPath)
+		}
+	}
+
+	var escaped []string
+	for _, t := range report.Tenants {
+		if !t.Contained {
+			escaped = append(escaped, t.Name)
+		}
+	}
+	if err := hierarchy.Remove(); err != nil {
+		term.Eprintf("Warning: %v\n", err)
+	}
+	if len(escaped) > 0 {
+		term.Printf("Isolation failed: %s used more than their limits allow.\n", strings.Join(escaped, ", "))
+		os.Exit(exitFailure)
+	}
+	term.Println("All tenants stayed within their limits.")
+}
+
+// parseTenants parses the --tenant options.
+func parseTenants(specs []string) ([]tenantSpec, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("at least one --tenant is required")
+	}
+	var tenants []tenantSpec
+	seen := make(map[string]bool)
+	for _, s := range specs {
+		parts := strings.SplitN(s, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid --tenant %q (expected name:limits:options)", s)
+		}
+		name := parts[0]
+		if !tenantName.MatchString(name) {
+			return nil, fmt.Errorf("invalid tenant name %q (letters, digits, - and _ only)", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate tenant name %q", name)
+		}
+		seen[name] = true
+		limits, err := tenant.ParseLimits(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %v", name, err)
+		}
+		args := strings.Fields(parts[2])
+		if len(args) == 0 {
+			return nil, fmt.Errorf("tenant %s: no load options", name)
+		}
+		for _, arg := range args {
+			// The run time and the summary are set for every tenant alike
+			option, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if strings.HasPrefix(arg, "-") && (option == "timeout" || option == "summary-json" || option == "lang") {
+				return nil, fmt.Errorf("tenant %s: --%s is set by the tenants command", name, option)
+			}
+		}
+		tenants = append(tenants, tenantSpec{name: name, limits: limits, args: args})
+	}
+	return tenants, nil
+}
+
+// startTenant creates the cgroup of spec and starts its stress-go run inside it.
+// The output of the run is printed with the tenant's name in front.
+func startTenant(executable string, spec tenantSpec, timeout time.Duration, hierarchy *tenant.Hierarchy, printMu *sync.Mutex) (*tenantRun, error) {
+	group, err := hierarchy.Add(spec.name, spec.limits)
+	if err != nil {
+		return nil, err
+	}
+	summaryFile, err := os.CreateTemp("", "stress-go-tenant-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create summary file: %v", err)
+	}
+	summaryFile.Close()
+
+	args := append([]string{"--lang", string(i18n.Current())}, spec.args...)
+	args = append(args, "--timeout", timeout.String(), "--summary-json", summaryFile.Name())
+	cmd := exec.Command(executable, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.Remove(summaryFile.Name())
+		return nil, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := group.Start(cmd); err != nil {
+		os.Remove(summaryFile.Name())
+		return nil, fmt.Errorf("failed to start tenant %s: %v", spec.name, err)
+	}
+
+	r := &tenantRun{spec: spec, group: group, cmd: cmd, summaryPath: summaryFile.Name(), done: make(chan struct{}), lastTime: time.Now()}
+	go func() {
+		defer close(r.done)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" || isProgressLine(line) {
+				continue
+			}
+			printMu.Lock()
+			term.Printf("[%s] %s\n", spec.name, line)
+			printMu.Unlock()
+		}
+		cmd.Wait()
+	}()
+	return r, nil
+}
+
+// stopTenants asks the stress-go run of every tenant to stop and clean up.
+func stopTenants(runs []*tenantRun) {
+	for _, r := range runs {
+		r.cmd.Process.Signal(syscall.SIGTERM)
+	}
+}
+
+// sample reads the cgroup statistics and records the CPU used since the last
+// sample, and the memory, for the maximums of the report.
+func (r *tenantRun) sample(now time.Time) {
+	stats, err := r.group.Stats()
+	if err != nil {
+		return
+	}
+	if wall := now.Sub(r.lastTime); wall > 0 {
+		r.maxCores = max(r.maxCores, float64(stats.CPUUsage-r.last.CPUUsage)/float64(wall))
+	}
+	r.maxMem = max(r.maxMem, stats.MemoryCurrent)
+	r.last, r.lastTime = stats, now
+}
+
+// report returns the result of the tenant once its run has finished.
+func (r *tenantRun) report(elapsed time.Duration) tenantReport {
+	stats, err := r.group.Stats()
+	if err != nil {
+		stats = r.last
+	}
+	t := tenantReport{
+		Name:             r.spec.name,
+		Limits:           r.spec.limits.String(),
+		MeanCores:        float64(stats.CPUUsage) / float64(elapsed),
+		MaxCores:         r.maxCores,
+		ThrottledShare:   stats.ThrottledShare(),
+		ThrottledSeconds: stats.Throttled.Seconds(),
+		PeakMemoryBytes:  max(stats.MemoryPeak, r.maxMem),
+		MemoryHighEvents: stats.MemoryHighEvents,
+		MemoryMaxEvents:  stats.MemoryMaxEvents,
+		OOMKills:         stats.OOMKills,
+		Contained:        true,
+		ExitCode:         r.cmd.ProcessState.ExitCode(),
+	}
+	t.Summary, _ = cluster.ReadSummary(r.summaryPath)
+	os.Remove(r.summaryPath)
+
+	limits := r.spec.limits
+	if limits.CPUMax > 0 && t.MaxCores > limits.CPUMax*(1+cpuContainmentTolerance) {
+		t.Escapes = append(t.Escapes, i18n.Sprintf("CPU %.2f cores above cpu.max of %.2f cores", t.MaxCores, limits.CPUMax))
+	}
+	if limits.MemoryMax > 0 && t.PeakMemoryBytes > limits.MemoryMax {
+		t.Escapes = append(t.Escapes, i18n.Sprintf("memory %s above memory.max of %s",
+			metrics.FormatValue(metrics.UnitBytes, float64(t.PeakMemoryBytes)), metrics.FormatValue(metrics.UnitBytes, float64(limits.MemoryMax))))
+	}
+	t.Contained = len(t.Escapes) == 0
+	return t
+}
+
+// printTenantReport prints what each tenant used and achieved.
+func printTenantReport(tenants []tenantReport) {
+	term.Println()
+	term.Printf("Tenants:\n")
+	for _, t := range tenants {
+		term.Printf("  %s (limits %s, exit code %d):\n", t.Name, t.Limits, t.ExitCode)
+		term.Printf("    CPU: %.2f cores mean, %.2f cores max; throttled in %.1f%% of periods (%.1fs)\n",
+			t.MeanCores, t.MaxCores, t.ThrottledShare*100, t.ThrottledSeconds)
+		term.Printf("    Memory: %s peak; memory.high %d times, memory.max %d times, %d OOM kills\n",
+			metrics.FormatValue(metrics.UnitBytes, float64(t.PeakMemoryBytes)), t.MemoryHighEvents, t.MemoryMaxEvents, t.OOMKills)
+		if t.Summary != nil {
+			for _, s := range t.Summary.Stressors {
+				term.Printf("    [%s] target %s, achieved %s\n", s.Name,
+					metrics.FormatValue(s.Unit, s.Target), metrics.FormatValue(s.Unit, s.Achieved))
+			}
+		}
+		for _, e := range t.Escapes {
+			term.Printf("    ! Not contained: %s\n", e)
+		}
+	}
+	term.Println()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/utkamioka/stress-go/pkg/deadline"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// textfileName is the file picked up by node_exporter's textfile collector.
+const textfileName = "stress_go.prom"
+
+// exportTextfile periodically writes stressor metrics in Prometheus textfile format
+// to dir until ctx is done, then writes a final snapshot marking the run as finished.
+func exportTextfile(ctx context.Context, dir string, interval time.Duration, dl *deadline.Deadline, recorder *metrics.Recorder) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := writeTextfile(dir, dl, recorder, ctx.Err() == nil); err != nil {
+			term.Eprintf("Warning: Failed to write textfile metrics: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if err := writeTextfile(dir, dl, recorder, false); err != nil {
+				term.Eprintf("Warning: Failed to write textfile metrics: %v\n", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkTextfileDir checks at startup that dir is a directory the metrics file
+// can be written to, so that a typo fails the run instead of warning every
+// interval while the load runs.
+func checkTextfileDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	probe, err := os.CreateTemp(dir, "."+textfileName+".*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// writeTextfile atomically replaces the metrics file so the collector never reads a partial file.
+func writeTextfile(dir string, dl *deadline.Deadline, recorder *metrics.Recorder, running bool) error {
+	var buf bytes.Buffer
+	if err := recorder.WritePrometheus(&buf); err != nil {
+		return err
+	}
+
+	writeRunState(&buf, dl, recorder, running)
+
+	tmp, err := os.CreateTemp(dir, "."+textfileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// node_exporter requires the file to be world-readable
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, textfileName))
+}
+
+// writeRunState writes whether the run is in progress and how long it has left.
+func writeRunState(w io.Writer, dl *deadline.Deadline, recorder *metrics.Recorder, running bool) {
+	remaining := dl.Remaining()
+	if !running {
+		remaining = 0
+	}
+	runningValue := 0
+	if running {
+		runningValue = 1
+	}
+	fmt.Fprintf(w, "# HELP stress_go_running Whether a stress test is in progress.\n")
+	fmt.Fprintf(w, "# TYPE stress_go_running gauge\n")
+	labels := metrics.PrometheusLabels(recorder.Labels())
+	fmt.Fprintf(w, "stress_go_running%s %d\n", labels, runningValue)
+	fmt.Fprintf(w, "# HELP stress_go_remaining_seconds Remaining duration of the stress test.\n")
+	fmt.Fprintf(w, "# TYPE stress_go_remaining_seconds gauge\n")
+	fmt.Fprintf(w, "stress_go_remaining_seconds%s %g\n", labels, remaining.Seconds())
+}
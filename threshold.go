@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/utkamioka/stress-go/pkg/bytesize"
+	"github.com/utkamioka/stress-go/pkg/cluster"
+	"github.com/utkamioka/stress-go/pkg/i18n"
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+// threshold is a --fail-under bound that the result of a stressor must reach,
+// such as "cpu=90%" or "storage=200MB/s".
+type threshold struct {
+	// Expr is the threshold and Bound its bound as written by the user.
+	Expr, Bound string
+	// Name is the stressor or job whose result is checked, matched without
+	// regard to case.
+	Name string
+	// Share is the least mean achieved load as a share of the mean target; 0
+	// when Rate is set instead.
+	Share float64
+	// Rate is the least mean throughput in bytes per second, checked against the
+	// "<name> throughput" or "<name> bandwidth" series.
+	Rate float64
+}
+
+// thresholdResult is how a stressor fared against a threshold.
+type thresholdResult struct {
+	threshold
+	// Stressor is the name the result was recorded under, or "" when nothing was
+	// recorded for Name, which fails the threshold.
+	Stressor string
+	// Value is the achieved share of the target or the mean throughput.
+	Value  float64
+	Failed bool
+}
+
+// parseThresholds parses the comma-separated --fail-under bounds.
+func parseThresholds(value string) ([]threshold, error) {
+	var thresholds []threshold
+	for _, expr := range splitList(value) {
+		name, bound, ok := strings.Cut(expr, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid threshold %q: expected <stressor>=<percent>%% or <stressor>=<rate>/s", expr)
+		}
+		bound = strings.TrimSpace(bound)
+		t := threshold{Expr: expr, Name: name, Bound: bound}
+		if percent, ok := strings.CutSuffix(bound, "%"); ok {
+			share, err := strconv.ParseFloat(percent, 64)
+			if err != nil || share <= 0 || share > 100 {
+				return nil, fmt.Errorf("invalid share of the target in %q: expected a percentage of 0-100", expr)
+			}
+			t.Share = share / 100
+		} else if size, ok := strings.CutSuffix(bound, "/s"); ok {
+			rate, err := bytesize.ParseAbsolute(size)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("invalid throughput in %q: expected a size per second (e.g., 200MB/s)", expr)
+			}
+			t.Rate = float64(rate)
+		} else {
+			return nil, fmt.Errorf("invalid threshold %q: expected <stressor>=<percent>%% or <stressor>=<rate>/s", expr)
+		}
+		thresholds = append(thresholds, t)
+	}
+	return thresholds, nil
+}
+
+// evaluateThresholds checks every threshold against the results of the run.
+func evaluateThresholds(thresholds []threshold, deviations []metrics.Deviation, values []metrics.Value) []thresholdResult {
+	results := make([]thresholdResult, 0, len(thresholds))
+	for _, t := range thresholds {
+		r := thresholdResult{threshold: t}
+		if t.Rate > 0 {
+			var sum float64
+			var n int
+			for _, v := range values {
+				if v.Unit == metrics.UnitBytesPerSecond && (strings.EqualFold(v.Series, t.Name+" throughput") || strings.EqualFold(v.Series, t.Name+" bandwidth")) {
+					r.Stressor = v.Series
+					sum += v.Value
+					n++
+				}
+			}
+			if n > 0 {
+				r.Value = sum / float64(n)
+			}
+			r.Failed = n == 0 || r.Value < t.Rate
+		} else {
+			for _, d := range deviations {
+				if strings.EqualFold(d.Stressor, t.Name) && d.Samples > 0 && d.MeanTarget > 0 {
+					r.Stressor, r.Value = d.Stressor, d.MeanAchieved/d.MeanTarget
+				}
+			}
+			r.Failed = r.Stressor == "" || r.Value < t.Share
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// failedThresholds returns the expressions of the thresholds that were not met.
+func failedThresholds(results []thresholdResult) []string {
+	var failed []string
+	for _, r := range results {
+		if r.Failed {
+			failed = append(failed, r.Expr)
+		}
+	}
+	return failed
+}
+
+// printThresholdReport prints how each stressor fared against --fail-under.
+func printThresholdReport(results []thresholdResult) {
+	if len(results) == 0 {
+		return
+	}
+	term.Printf("Thresholds (--fail-under):\n")
+	for _, r := range results {
+		status := "PASS"
+		if r.Failed {
+			status = "FAIL"
+		}
+		switch {
+		case r.Stressor == "":
+			term.Printf("  [%s] %s: no result recorded\n", r.Name, status)
+		case r.Rate > 0:
+			term.Printf("  [%s] %s: %s on average, at least %s required\n", r.Stressor, status,
+				metrics.FormatValue(metrics.UnitBytesPerSecond, r.Value), r.Bound)
+		default:
+			term.Printf("  [%s] %s: achieved %.1f%% of the target, at least %s required\n", r.Stressor, status, r.Value*100, r.Bound)
+		}
+	}
+	term.Println()
+}
+
+// thresholdSummaries converts the threshold results for the run summary.
+func thresholdSummaries(results []thresholdResult) []cluster.ThresholdSummary {
+	var summaries []cluster.ThresholdSummary
+	for _, r := range results {
+		summaries = append(summaries, cluster.ThresholdSummary{Threshold: r.Expr, Value: r.Value, Failed: r.Failed})
+	}
+	return summaries
+}
+
+// describeThresholds describes the --fail-under bounds for the settings.
+func describeThresholds(thresholds []threshold) string {
+	exprs := make([]string, len(thresholds))
+	for i, t := range thresholds {
+		exprs[i] = t.Expr
+	}
+	return i18n.Sprintf("Fail under: %s", strings.Join(exprs, ", "))
+}
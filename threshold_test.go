@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/utkamioka/stress-go/pkg/metrics"
+)
+
+func TestParseThresholds(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []threshold
+		ok    bool
+	}{
+		{value: "", ok: true},
+		{value: "cpu=90%", want: []threshold{{Expr: "cpu=90%", Bound: "90%", Name: "cpu", Share: 0.9}}, ok: true},
+		{value: "cpu=90%, storage=2MiB/s", want: []threshold{
+			{Expr: "cpu=90%", Bound: "90%", Name: "cpu", Share: 0.9},
+			{Expr: "storage=2MiB/s", Bound: "2MiB/s", Name: "storage", Rate: 2 * 1024 * 1024},
+		}, ok: true},
+		{value: "cpu", ok: false},
+		{value: "=90%", ok: false},
+		{value: "cpu=0%", ok: false},
+		{value: "cpu=150%", ok: false},
+		{value: "cpu=90", ok: false},
+		{value: "storage=fast/s", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseThresholds(tt.value)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseThresholds(%q) error = %v, want ok %v", tt.value, err, tt.ok)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseThresholds(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseThresholds(%q)[%d] = %+v, want %+v", tt.value, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateThresholds(t *testing.T) {
+	deviations := []metrics.Deviation{
+		{Stressor: "CPU", Samples: 10, MeanTarget: 4, MeanAchieved: 3.8},
+		{Stressor: "db", Samples: 10, MeanTarget: 100, MeanAchieved: 50},
+	}
+	values := []metrics.Value{
+		{Series: "Storage throughput", Unit: metrics.UnitBytesPerSecond, Value: 100},
+		{Series: "Storage throughput", Unit: metrics.UnitBytesPerSecond, Value: 300},
+		{Series: "Memory bandwidth", Unit: metrics.UnitBytesPerSecond, Value: 1000},
+	}
+	thresholds, err := parseThresholds("cpu=90%,DB=60%,storage=150B/s,memory=2KB/s,gpu=50%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		stressor string
+		value    float64
+		failed   bool
+	}{
+		{"CPU", 0.95, false},
+		{"db", 0.5, true},
+		{"Storage throughput", 200, false},
+		{"Memory bandwidth", 1000, true},
+		{"", 0, true},
+	}
+
+	results := evaluateThresholds(thresholds, deviations, values)
+	for i, r := range results {
+		if r.Stressor != want[i].stressor || r.Value != want[i].value || r.Failed != want[i].failed {
+			t.Errorf("%s: got %q %g failed %v, want %q %g failed %v",
+				r.Expr, r.Stressor, r.Value, r.Failed, want[i].stressor, want[i].value, want[i].failed)
+		}
+	}
+	if got := failedThresholds(results); len(got) != 3 {
+		t.Errorf("failedThresholds = %v, want 3 failures", got)
+	}
+}